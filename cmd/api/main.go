@@ -7,8 +7,15 @@ import (
 	"comment-review-platform/internal/services"
 	"comment-review-platform/pkg/database"
 	redispkg "comment-review-platform/pkg/redis"
+	"comment-review-platform/pkg/tracing"
+	"context"
 	"database/sql"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -19,6 +26,28 @@ func main() {
 	cfg := config.LoadConfig()
 	log.Println("✅ Configuration loaded")
 
+	// Load JWT signing keys (refuses to boot with the placeholder secret)
+	if _, err := services.InitTokenKeys(cfg); err != nil {
+		log.Fatalf("❌ Invalid JWT configuration: %v", err)
+	}
+
+	// Initialize tracing; spans are exported to an OTLP collector and/or a
+	// local file, and without either trace IDs are still propagated
+	if err := tracing.Init(tracing.Config{
+		ServiceName:  cfg.TracingServiceName,
+		OTLPEndpoint: cfg.TracingOTLPEndpoint,
+		OTLPHeaders:  tracing.ParseHeaders(cfg.TracingOTLPHeaders),
+		FilePath:     cfg.TracingFilePath,
+		SampleRatio:  cfg.TracingSampleRatio,
+	}); err != nil {
+		log.Fatalf("❌ Failed to initialize tracing: %v", err)
+	}
+	if tracing.Enabled() {
+		log.Printf("✅ Tracing enabled (sample ratio %.2f)", cfg.TracingSampleRatio)
+	} else {
+		log.Println("⚠️ OTEL_EXPORTER_OTLP_ENDPOINT is not set; traces are not exported")
+	}
+
 	// Initialize PostgreSQL
 	db, err := database.InitPostgres(cfg.DatabaseURL)
 	if err != nil {
@@ -33,14 +62,32 @@ func main() {
 	}
 	defer redispkg.Close()
 
-	// Initialize audit logger (requires DB connection)
-	middleware.InitAuditLogger(db)
+	// Failed requests are grouped into error issues as their audit entries
+	// are stored
+	middleware.InitErrorIssueService(services.NewErrorIssueService())
+
+	// Initialize audit logger (requires DB connection); entries are written
+	// in batches and spilled to disk while Postgres is unavailable
+	middleware.InitAuditLogger(db, middleware.AuditWriterConfig{
+		QueueSize:     cfg.AuditQueueSize,
+		BatchSize:     cfg.AuditBatchSize,
+		FlushInterval: time.Duration(cfg.AuditFlushIntervalMs) * time.Millisecond,
+		SpillDir:      cfg.AuditSpillDir,
+		SpillMaxBytes: int64(cfg.AuditSpillMaxMB) << 20,
+	})
 
 	// Initialize alerting and metrics services
 	alertService := services.NewAlertService(redispkg.Client)
 	middleware.InitAlertService(alertService)
 	metricsService := services.NewMetricsService(redispkg.Client, cfg.MetricsWindowMinutes)
+	metricsService.RegisterDBMetrics(db)
 	middleware.InitMetricsService(metricsService)
+	if cfg.MetricsToken == "" {
+		log.Println("⚠️ METRICS_TOKEN is not set; /metrics is served without authentication")
+	}
+
+	// Initialize permission cache (in-process + Redis, invalidated via pub/sub)
+	permissionCache := services.InitPermissionCache(redispkg.Client, time.Duration(cfg.PermissionCacheTTLSeconds)*time.Second)
 
 	// Create database tables if they don't exist
 	if err := createTables(db); err != nil {
@@ -64,20 +111,65 @@ func main() {
 	// Start AI review scheduler
 	go startAIReviewScheduler()
 
+	// Start video import job recovery (resumes jobs interrupted by a restart)
+	go startVideoImportWorker()
+
+	// Start video duplicate inheritance (applies decided originals to pending re-uploads)
+	go startVideoDuplicateInheritor()
+
+	// Start video review reconciliation (repairs orphaned first/second review rows)
+	go startVideoReviewReconciler()
+
+	// Start near-duplicate comment clustering (groups spam waves for bulk decisions)
+	go startCommentClusterer()
+
+	// Start permission grant expiry sweeper
+	go services.NewPermissionService().StartGrantExpirySweeper(time.Minute)
+
 	// Setup Gin router
-	router := setupRouter(db, metricsService)
+	router := setupRouter(db, metricsService, permissionCache)
+
+	// Start audit log chain checkpointer (signs chain heads for verification)
+	go startAuditCheckpointer()
 
-	// Start audit log cleanup (retention: 90 days, runs daily)
+	// Start audit log archival to R2 (hot retention: 90 days, runs daily)
 	go middleware.StartAuditLogCleanup(90, 24*time.Hour)
 
 	// Start server
-	log.Printf("🚀 Server starting on port %s", cfg.Port)
-	if err := router.Run(":" + cfg.Port); err != nil {
-		log.Fatalf("❌ Failed to start server: %v", err)
+	server := &http.Server{Addr: ":" + cfg.Port, Handler: router}
+	go func() {
+		log.Printf("🚀 Server starting on port %s", cfg.Port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("❌ Failed to start server: %v", err)
+		}
+	}()
+
+	// Stop on SIGINT/SIGTERM: finish in-flight requests, then drain the
+	// audit log queue so no entry is lost, then flush the queued spans
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	log.Println("🛑 Shutting down server...")
+
+	// Long-lived notification streams can hold the server past its timeout,
+	// so the audit drain gets its own
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️ Error shutting down server: %v", err)
 	}
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancelDrain()
+	if err := middleware.ShutdownAuditLogger(drainCtx); err != nil {
+		log.Printf("⚠️ Error draining audit logs: %v", err)
+	}
+	if err := tracing.Shutdown(drainCtx); err != nil {
+		log.Printf("⚠️ Error flushing traces: %v", err)
+	}
+	log.Println("✅ Server stopped")
 }
 
-func setupRouter(db interface{}, metricsService *services.MetricsService) *gin.Engine {
+func setupRouter(db interface{}, metricsService *services.MetricsService, permissionCache *services.PermissionCache) *gin.Engine {
 	router := gin.New()
 
 	// Global middleware (executed in order)
@@ -96,7 +188,7 @@ func setupRouter(db interface{}, metricsService *services.MetricsService) *gin.E
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Trace-Id, X-Page-Url")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Trace-Id, X-Page-Url, traceparent, tracestate")
 		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Trace-Id, X-Request-Id")
 
 		// SSE specific headers
@@ -120,12 +212,15 @@ func setupRouter(db interface{}, metricsService *services.MetricsService) *gin.E
 	}
 
 	// Health check
-	monitoringHandler := handlers.NewMonitoringHandler(sqlDB, redispkg.Client, metricsService)
+	monitoringHandler := handlers.NewMonitoringHandler(sqlDB, redispkg.Client, metricsService, permissionCache)
 	router.GET("/health", monitoringHandler.Health)
+	router.GET("/metrics", monitoringHandler.Prometheus)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler()
 	taskHandler := handlers.NewTaskHandler()
+	commentClusterHandler := handlers.NewCommentClusterHandler()
+	moderationStatusHandler := handlers.NewModerationStatusHandler()
 	secondReviewHandler := handlers.NewSecondReviewHandler()
 	qualityCheckHandler := handlers.NewQualityCheckHandler()
 	aiHumanDiffHandler := handlers.NewAIHumanDiffHandler()
@@ -133,7 +228,6 @@ func setupRouter(db interface{}, metricsService *services.MetricsService) *gin.E
 	aiReviewHandler := handlers.NewAIReviewHandler()
 	auditLogHandler := handlers.NewAuditLogHandler()
 	documentHandler := handlers.NewDocumentHandler()
-	bugReportHandler := handlers.NewBugReportHandler()
 
 	// Initialize video handler
 	videoHandler, err := handlers.NewVideoHandler()
@@ -150,8 +244,14 @@ func setupRouter(db interface{}, metricsService *services.MetricsService) *gin.E
 
 	// Initialize SSE manager and notification service
 	sseManager := services.NewSSEManager()
+	metricsService.RegisterSSEMetrics(sseManager)
 	notificationService := services.NewNotificationService(sqlDB, sseManager)
 	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	bugReportHandler := handlers.NewBugReportHandler(notificationService)
+	errorIssueHandler := handlers.NewErrorIssueHandler()
+
+	// Public verification keys for platform access tokens
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
 
 	// API routes
 	api := router.Group("/api")
@@ -171,6 +271,16 @@ func setupRouter(db interface{}, metricsService *services.MetricsService) *gin.E
 			auth.POST("/register-with-code", middleware.EndpointRateLimiterV2(3, 5*time.Minute+30*time.Second), authHandler.RegisterWithCode)
 			// Rate limit: 10 per minute for email checking
 			auth.GET("/check-email", middleware.EndpointRateLimiterV2(10, 1*time.Minute), authHandler.CheckEmail)
+			// OIDC single sign-on (authorization code + PKCE)
+			auth.GET("/oidc/config", authHandler.OIDCConfig)
+			auth.GET("/oidc/login", middleware.EndpointRateLimiterV2(10, 1*time.Minute), authHandler.OIDCLogin)
+			auth.POST("/oidc/callback", middleware.EndpointRateLimiterV2(10, 1*time.Minute), authHandler.OIDCCallback)
+			// Rate limit: 30 per 5 minutes for token refresh
+			auth.POST("/refresh", middleware.EndpointRateLimiterV2(30, 5*time.Minute), authHandler.RefreshToken)
+			// Session management: logout, list devices, per-device logout
+			auth.POST("/logout", middleware.AuthMiddleware(), authHandler.Logout)
+			auth.GET("/sessions", middleware.AuthMiddleware(), authHandler.ListSessions)
+			auth.DELETE("/sessions/:id", middleware.AuthMiddleware(), authHandler.RevokeSession)
 			auth.GET("/profile", middleware.AuthMiddleware(), authHandler.GetProfile)
 			auth.PUT("/profile", middleware.AuthMiddleware(), authHandler.UpdateProfile)
 			auth.PUT("/profile/system", middleware.AuthMiddleware(), middleware.RequirePermission("users:profile:update"), authHandler.UpdateSystemProfile)
@@ -193,8 +303,12 @@ func setupRouter(db interface{}, metricsService *services.MetricsService) *gin.E
 		// System documents (requires authentication)
 		api.GET("/docs", middleware.AuthMiddleware(), documentHandler.ListDocuments)
 
-		// Bug report submission (requires authentication)
+		// Bug report submission and follow-up (requires authentication; reporters
+		// see their own reports, admins see all of them)
 		api.POST("/bug-reports", middleware.AuthMiddleware(), bugReportHandler.Create)
+		api.GET("/bug-reports/mine", middleware.AuthMiddleware(), bugReportHandler.ListMine)
+		api.GET("/bug-reports/:id", middleware.AuthMiddleware(), bugReportHandler.Get)
+		api.POST("/bug-reports/:id/comments", middleware.AuthMiddleware(), bugReportHandler.AddComment)
 
 		// Task routes (requires specific queue permissions)
 		tasks := api.Group("/tasks")
@@ -207,6 +321,11 @@ func setupRouter(db interface{}, metricsService *services.MetricsService) *gin.E
 			tasks.POST("/submit-batch", middleware.RequirePermission("tasks:first-review:submit"), taskHandler.SubmitBatchReviews)
 			tasks.POST("/return", middleware.RequirePermission("tasks:first-review:return"), taskHandler.ReturnTasks)
 
+			// Near-duplicate comment clusters (first review)
+			tasks.GET("/comment-clusters", middleware.RequirePermission("tasks:comment-clusters:view"), commentClusterHandler.ListClusters)
+			tasks.GET("/comment-clusters/:id", middleware.RequirePermission("tasks:comment-clusters:view"), commentClusterHandler.GetCluster)
+			tasks.POST("/comment-clusters/:id/decide", middleware.RequirePermission("tasks:comment-clusters:decide"), commentClusterHandler.DecideCluster)
+
 			// Second review routes
 			tasks.POST("/second-review/claim", middleware.UserRateLimiterV2(10, time.Minute), middleware.RequirePermission("tasks:second-review:claim"), secondReviewHandler.ClaimSecondReviewTasks)
 			tasks.GET("/second-review/my", middleware.RequirePermission("tasks:second-review:claim"), secondReviewHandler.GetMySecondReviewTasks)
@@ -251,51 +370,16 @@ func setupRouter(db interface{}, metricsService *services.MetricsService) *gin.E
 		video := api.Group("/video")
 		video.Use(middleware.AuthMiddleware())
 		{
-			// Routes for each pool: 100k, 1m, 10m
-			video.POST("/:pool/tasks/claim", middleware.UserRateLimiterV2(10, time.Minute), func(c *gin.Context) {
-				pool := c.Param("pool")
-				middleware.RequirePermission("queue.video." + pool + ".claim")(c)
-				if c.IsAborted() {
-					return
-				}
-				videoQueueHandler.ClaimTasks(c)
-			})
-
-			video.GET("/:pool/tasks/my", func(c *gin.Context) {
-				pool := c.Param("pool")
-				middleware.RequirePermission("queue.video." + pool + ".my")(c)
-				if c.IsAborted() {
-					return
-				}
-				videoQueueHandler.GetMyTasks(c)
-			})
-
-			video.POST("/:pool/tasks/submit", func(c *gin.Context) {
-				pool := c.Param("pool")
-				middleware.RequirePermission("queue.video." + pool + ".submit")(c)
-				if c.IsAborted() {
-					return
-				}
-				videoQueueHandler.SubmitReview(c)
-			})
-
-			video.POST("/:pool/tasks/submit-batch", func(c *gin.Context) {
-				pool := c.Param("pool")
-				middleware.RequirePermission("queue.video." + pool + ".submit")(c)
-				if c.IsAborted() {
-					return
-				}
-				videoQueueHandler.SubmitBatchReviews(c)
-			})
-
-			video.POST("/:pool/tasks/return", func(c *gin.Context) {
-				pool := c.Param("pool")
-				middleware.RequirePermission("queue.video." + pool + ".return")(c)
-				if c.IsAborted() {
-					return
-				}
-				videoQueueHandler.ReturnTasks(c)
-			})
+			// Pools configured in video_pools (admin: /admin/video-pools)
+			video.GET("/pools", videoQueueHandler.ListPools)
+
+			// Routes for each configured pool
+			// Access: queue.video.<pool>.<action>, or queue.video.<action> granted globally or scoped to pool:<pool>
+			video.POST("/:pool/tasks/claim", middleware.UserRateLimiterV2(10, time.Minute), middleware.RequireVideoPoolPermission("claim"), videoQueueHandler.ClaimTasks)
+			video.GET("/:pool/tasks/my", middleware.RequireVideoPoolPermission("my"), videoQueueHandler.GetMyTasks)
+			video.POST("/:pool/tasks/submit", middleware.RequireVideoPoolPermission("submit"), videoQueueHandler.SubmitReview)
+			video.POST("/:pool/tasks/submit-batch", middleware.RequireVideoPoolPermission("submit"), videoQueueHandler.SubmitBatchReviews)
+			video.POST("/:pool/tasks/return", middleware.RequireVideoPoolPermission("return"), videoQueueHandler.ReturnTasks)
 
 			// Get tags for a specific pool
 			video.GET("/:pool/tags", videoQueueHandler.GetTags)
@@ -345,6 +429,7 @@ func setupRouter(db interface{}, metricsService *services.MetricsService) *gin.E
 			admin.GET("/permissions", middleware.RequirePermission("permissions:read"), adminHandler.ListPermissions)
 			admin.POST("/permissions/grant", middleware.RequirePermission("permissions:grant"), adminHandler.GrantPermissions)
 			admin.POST("/permissions/revoke", middleware.RequirePermission("permissions:revoke"), adminHandler.RevokePermissions)
+			admin.GET("/permissions/expirations", middleware.RequirePermission("permissions:read"), adminHandler.ListGrantExpirations)
 
 			// User management
 			admin.GET("/users", middleware.RequirePermission("users:list"), adminHandler.GetPendingUsers)
@@ -352,6 +437,7 @@ func setupRouter(db interface{}, metricsService *services.MetricsService) *gin.E
 			admin.PUT("/users/:id/approve", middleware.RequirePermission("users:approve"), adminHandler.ApproveUser)
 			admin.POST("/users", middleware.RequirePermission("users:approve"), adminHandler.CreateUser)
 			admin.DELETE("/users/:id", middleware.RequirePermission("users:approve"), adminHandler.DeleteUser)
+			admin.POST("/users/:id/force-logout", middleware.RequirePermission("users:sessions:revoke"), adminHandler.ForceLogoutUser)
 
 			// Statistics
 			admin.GET("/stats/overview", middleware.RequirePermission("stats:overview"), adminHandler.GetOverviewStats)
@@ -362,6 +448,8 @@ func setupRouter(db interface{}, metricsService *services.MetricsService) *gin.E
 			admin.GET("/monitoring/metrics", middleware.RequirePermission("monitoring.read"), monitoringHandler.Metrics)
 			admin.GET("/monitoring/summary", middleware.RequirePermission("monitoring.read"), monitoringHandler.DailySummary)
 			admin.GET("/monitoring/endpoints", middleware.RequirePermission("monitoring.read"), monitoringHandler.DailyEndpointHealth)
+			admin.GET("/monitoring/permission-cache", middleware.RequirePermission("monitoring.read"), monitoringHandler.PermissionCacheStats)
+			admin.GET("/monitoring/audit-writer", middleware.RequirePermission("monitoring.read"), monitoringHandler.AuditWriterStats)
 
 			// Tag management (comment tags)
 			admin.GET("/tags", middleware.RequirePermission("tags:list"), adminHandler.GetAllTags)
@@ -377,6 +465,9 @@ func setupRouter(db interface{}, metricsService *services.MetricsService) *gin.E
 			admin.DELETE("/video-tags/:id", middleware.RequirePermission("tags:delete"), videoTagHandler.DeleteVideoTag)
 			admin.PATCH("/video-tags/:id/toggle", middleware.RequirePermission("tags:update"), videoTagHandler.ToggleVideoTagActive)
 
+			// Allowed comment and video status transitions
+			admin.GET("/moderation/status-graph", middleware.RequirePermission("moderation:status-graph:read"), moderationStatusHandler.GetStatusGraph)
+
 			// Moderation Rules management
 			admin.POST("/moderation-rules", middleware.RequirePermission("moderation-rules:create"), moderationRulesHandler.CreateRule)
 			admin.PUT("/moderation-rules/:id", middleware.RequirePermission("moderation-rules:update"), moderationRulesHandler.UpdateRule)
@@ -396,13 +487,29 @@ func setupRouter(db interface{}, metricsService *services.MetricsService) *gin.E
 			// Video management (if video handler is available)
 			if videoHandler != nil {
 				admin.POST("/videos/import", middleware.RequirePermission("videos:import"), videoHandler.ImportVideos)
+				admin.GET("/videos/import-jobs", middleware.RequirePermission("videos:import"), videoHandler.ListImportJobs)
+				admin.GET("/videos/import-jobs/:id", middleware.RequirePermission("videos:import"), videoHandler.GetImportJob)
+				admin.GET("/videos/import-jobs/:id/errors", middleware.RequirePermission("videos:import"), videoHandler.ListImportJobErrors)
+				admin.POST("/videos/import-jobs/:id/cancel", middleware.RequirePermission("videos:import"), videoHandler.CancelImportJob)
+				admin.POST("/videos/import-jobs/:id/resume", middleware.RequirePermission("videos:import"), videoHandler.ResumeImportJob)
+				admin.POST("/videos/probe", middleware.RequirePermission("videos:import"), videoHandler.ProbeVideos)
+				admin.POST("/videos/thumbnails", middleware.RequirePermission("videos:import"), videoHandler.GenerateThumbnails)
+				admin.POST("/videos/fingerprints", middleware.RequirePermission("videos:import"), videoHandler.FingerprintVideos)
+				admin.POST("/videos/review-reconciliation", middleware.RequirePermission("videos:import"), videoHandler.ReconcileReviews)
 				admin.GET("/videos", middleware.RequirePermission("videos:list"), videoHandler.ListVideos)
 				admin.GET("/videos/:id", middleware.RequirePermission("videos:read"), videoHandler.GetVideo)
+				admin.GET("/videos/:id/timeline", middleware.RequirePermission("videos:read"), videoHandler.GetVideoTimeline)
 			}
 
 			// Video Queue Pool statistics (admin only)
+			admin.GET("/video-queue/stats", middleware.RequirePermission("stats:overview"), videoQueueHandler.GetAllPoolStats)
 			admin.GET("/video-queue/:pool/stats", middleware.RequirePermission("stats:overview"), videoQueueHandler.GetPoolStats)
 
+			// Video traffic-pool ladder
+			admin.GET("/video-pools", middleware.RequirePermission("video-pools:manage"), videoQueueHandler.ListAllPools)
+			admin.POST("/video-pools", middleware.RequirePermission("video-pools:manage"), videoQueueHandler.CreatePool)
+			admin.PUT("/video-pools/:pool", middleware.RequirePermission("video-pools:manage"), videoQueueHandler.UpdatePool)
+
 			// AI review management
 			admin.POST("/ai-review/jobs", middleware.RequirePermission("ai-review:jobs:create"), aiReviewHandler.CreateJob)
 			admin.POST("/ai-review/jobs/:id/start", middleware.RequirePermission("ai-review:jobs:start"), aiReviewHandler.StartJob)
@@ -417,15 +524,26 @@ func setupRouter(db interface{}, metricsService *services.MetricsService) *gin.E
 			// System documents (edit)
 			admin.PUT("/docs/:key", middleware.RequirePermission("docs:edit"), documentHandler.UpdateDocument)
 
-			// Audit log management
-			admin.GET("/audit-logs", middleware.RequirePermission("audit.logs.read"), auditLogHandler.ListLogs)
-			admin.GET("/audit-logs/:id", middleware.RequirePermission("audit.logs.read"), auditLogHandler.GetLog)
-			admin.POST("/audit-logs/export", middleware.RequirePermission("audit.logs.export"), auditLogHandler.ExportLogs)
-			admin.GET("/audit-logs/exports", middleware.RequirePermission("audit.logs.read"), auditLogHandler.ListExports)
+			// Audit log management (grants may be scoped to module:<action_category>)
+			admin.GET("/audit-logs", middleware.RequirePermissionInAnyScope("audit.logs.read"), auditLogHandler.ListLogs)
+			admin.GET("/audit-logs/archives", middleware.RequirePermission("audit.logs.read"), auditLogHandler.ListArchives)
+			admin.GET("/audit-logs/verify", middleware.RequirePermission("audit.logs.verify"), auditLogHandler.VerifyChain)
+			admin.GET("/audit-logs/:id", middleware.RequirePermissionInAnyScope("audit.logs.read"), auditLogHandler.GetLog)
+			admin.POST("/audit-logs/export", middleware.RequirePermissionInAnyScope("audit.logs.export"), auditLogHandler.ExportLogs)
+			admin.GET("/audit-logs/exports", middleware.RequirePermissionInAnyScope("audit.logs.read"), auditLogHandler.ListExports)
 
 			// Bug reports (admin only)
 			admin.GET("/bug-reports", bugReportHandler.List)
 			admin.POST("/bug-reports/export", bugReportHandler.Export)
+			admin.GET("/bug-reports/error-groups", bugReportHandler.ListErrorGroups)
+			admin.PUT("/bug-reports/:id/status", bugReportHandler.UpdateStatus)
+			admin.PUT("/bug-reports/:id/assignee", bugReportHandler.Assign)
+			admin.PUT("/bug-reports/:id/duplicate", bugReportHandler.MarkDuplicate)
+
+			// Error issues (failed requests grouped by fingerprint)
+			admin.GET("/error-issues", errorIssueHandler.List)
+			admin.GET("/error-issues/:id", errorIssueHandler.Get)
+			admin.PUT("/error-issues/:id/status", errorIssueHandler.UpdateStatus)
 		}
 	}
 
@@ -444,6 +562,23 @@ func initializeDefaultData() error {
 	return nil
 }
 
+func startAuditCheckpointer() {
+	auditChainService := services.NewAuditChainService(database.DB, config.AppConfig.AuditChainSigningKey)
+	if config.AppConfig.AuditChainSigningKey == "" {
+		log.Println("⚠️ AUDIT_CHAIN_SIGNING_KEY is not set; audit log checkpoints and tombstones will be unsigned")
+	}
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	log.Println("✅ Audit log checkpointer started (runs every 10 minutes)")
+
+	for range ticker.C {
+		if _, err := auditChainService.Checkpoint(); err != nil {
+			log.Printf("⚠️ Error checkpointing audit log chains: %v", err)
+		}
+	}
+}
+
 func startSamplingScheduler() {
 	samplingService := services.NewSamplingService()
 	log.Println("✅ Daily sampling scheduler started")
@@ -511,6 +646,52 @@ func startDailyStatsAggregator() {
 	}
 }
 
+func startVideoImportWorker() {
+	videoImportService, err := services.NewVideoImportService()
+	if err != nil {
+		log.Printf("⚠️ Video import worker disabled: %v", err)
+		return
+	}
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	log.Println("✅ Video import worker started (runs every minute)")
+
+	for range ticker.C {
+		if err := videoImportService.ResumeStaleJobs(); err != nil {
+			log.Printf("⚠️ Error resuming video import jobs: %v", err)
+		}
+	}
+}
+
+func startVideoDuplicateInheritor() {
+	fingerprintService := services.NewVideoFingerprintService(nil)
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	log.Println("✅ Video duplicate inheritor started (runs every minute)")
+
+	for range ticker.C {
+		if _, err := fingerprintService.InheritDecisions(); err != nil {
+			log.Printf("⚠️ Error inheriting video duplicate decisions: %v", err)
+		}
+	}
+}
+
+func startVideoReviewReconciler() {
+	reconcileService := services.NewVideoReviewReconciliationService()
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	log.Println("✅ Video review reconciler started (runs every hour)")
+
+	for range ticker.C {
+		if _, err := reconcileService.Reconcile(false); err != nil {
+			log.Printf("⚠️ Error reconciling video reviews: %v", err)
+		}
+	}
+}
+
 func startAIReviewScheduler() {
 	aiReviewService := services.NewAIReviewService()
 	ticker := time.NewTicker(1 * time.Minute)
@@ -524,3 +705,17 @@ func startAIReviewScheduler() {
 		}
 	}
 }
+
+func startCommentClusterer() {
+	clusterService := services.NewCommentClusterService()
+	ticker := time.NewTicker(2 * time.Minute)
+	defer ticker.Stop()
+
+	log.Println("✅ Comment clusterer started (runs every 2 minutes)")
+
+	for range ticker.C {
+		if err := clusterService.ClusterPending(); err != nil {
+			log.Printf("⚠️ Error clustering pending comments: %v", err)
+		}
+	}
+}
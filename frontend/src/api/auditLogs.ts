@@ -1,5 +1,7 @@
 import request from './request'
 import type {
+  AuditChainVerifyResponse,
+  AuditLogArchive,
   AuditLogEntry,
   AuditLogExportListResponse,
   AuditLogExportRequest,
@@ -12,8 +14,11 @@ export function getAuditLogs(params: AuditLogQueryParams) {
   return request.get<any, AuditLogQueryResponse>('/admin/audit-logs', { params })
 }
 
-export function getAuditLog(id: string) {
-  return request.get<any, AuditLogEntry>(`/admin/audit-logs/${id}`)
+// createdAt lets the server find entries that were already archived
+export function getAuditLog(id: string, createdAt?: string) {
+  return request.get<any, AuditLogEntry>(`/admin/audit-logs/${id}`, {
+    params: createdAt ? { created_at: createdAt } : undefined,
+  })
 }
 
 export function exportAuditLogs(payload: AuditLogExportRequest) {
@@ -23,3 +28,11 @@ export function exportAuditLogs(payload: AuditLogExportRequest) {
 export function listAuditLogExports(params?: { page?: number; page_size?: number }) {
   return request.get<any, AuditLogExportListResponse>('/admin/audit-logs/exports', { params })
 }
+
+export function verifyAuditLogs(params: { start_time: string; end_time: string }) {
+  return request.get<any, AuditChainVerifyResponse>('/admin/audit-logs/verify', { params })
+}
+
+export function listAuditLogArchives(params: { start_time: string; end_time: string }) {
+  return request.get<any, { data: AuditLogArchive[] }>('/admin/audit-logs/archives', { params })
+}
@@ -11,6 +11,13 @@ export function login(username: string, password: string) {
   })
 }
 
+/**
+ * Logout (revokes the current session on the server)
+ */
+export function logout() {
+  return request.post<any, { message: string }>('/auth/logout')
+}
+
 /**
  * Register
  */
@@ -48,6 +55,30 @@ export function loginWithCode(email: string, code: string) {
   })
 }
 
+/**
+ * 单点登录配置
+ */
+export function getOIDCConfig() {
+  return request.get<any, { enabled: boolean; provider_name: string }>('/auth/oidc/config')
+}
+
+/**
+ * 发起单点登录，返回身份提供方授权地址
+ */
+export function startOIDCLogin() {
+  return request.get<any, { authorization_url: string }>('/auth/oidc/login')
+}
+
+/**
+ * 单点登录回调：用授权码换取登录凭证
+ */
+export function completeOIDCLogin(code: string, state: string) {
+  return request.post<any, LoginResponse>('/auth/oidc/callback', {
+    code,
+    state,
+  })
+}
+
 /**
  * 验证码注册
  */
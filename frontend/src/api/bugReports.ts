@@ -1,5 +1,12 @@
 import request from './request'
-import type { BugReportListResponse } from '../types'
+import type {
+  BugReport,
+  BugReportComment,
+  BugReportDetail,
+  BugReportErrorGroupResponse,
+  BugReportListResponse,
+  BugReportStatus,
+} from '../types'
 
 export function submitBugReport(formData: FormData) {
   return request.post<any, { message: string }>(
@@ -21,6 +28,9 @@ export function listBugReports(params: {
   user_id?: number
   username?: string
   keyword?: string
+  status?: BugReportStatus
+  assignee_id?: number
+  error_signature?: string
 }) {
   return request.get<any, BugReportListResponse>('/admin/bug-reports', {
     params,
@@ -33,9 +43,62 @@ export function exportBugReports(payload: {
   user_id?: number
   username?: string
   keyword?: string
+  status?: BugReportStatus
+  assignee_id?: number
+  error_signature?: string
   format: 'csv' | 'json'
 }) {
   return request.post<any, Blob>('/admin/bug-reports/export', payload, {
     responseType: 'blob',
   })
 }
+
+export function listBugReportErrorGroups(params: {
+  open_only?: boolean
+  page?: number
+  page_size?: number
+}) {
+  return request.get<any, BugReportErrorGroupResponse>('/admin/bug-reports/error-groups', {
+    params,
+  })
+}
+
+export function listMyBugReports(params: {
+  page?: number
+  page_size?: number
+  status?: BugReportStatus
+}) {
+  return request.get<any, BugReportListResponse>('/bug-reports/mine', {
+    params,
+  })
+}
+
+export function getBugReport(id: number) {
+  return request.get<any, BugReportDetail>(`/bug-reports/${id}`)
+}
+
+export function addBugReportComment(
+  id: number,
+  payload: { body: string; parent_id?: number; internal?: boolean }
+) {
+  return request.post<any, BugReportComment>(`/bug-reports/${id}/comments`, payload)
+}
+
+export function updateBugReportStatus(
+  id: number,
+  payload: { status: BugReportStatus; resolution?: string }
+) {
+  return request.put<any, BugReport>(`/admin/bug-reports/${id}/status`, payload)
+}
+
+export function assignBugReport(id: number, assigneeId: number | null) {
+  return request.put<any, BugReport>(`/admin/bug-reports/${id}/assignee`, {
+    assignee_id: assigneeId,
+  })
+}
+
+export function markBugReportDuplicate(id: number, duplicateOf: number | null) {
+  return request.put<any, BugReport>(`/admin/bug-reports/${id}/duplicate`, {
+    duplicate_of: duplicateOf,
+  })
+}
@@ -0,0 +1,23 @@
+import request from './request'
+import type { ErrorIssue, ErrorIssueDetail, ErrorIssueListResponse, ErrorIssueStatus } from '../types'
+
+export function listErrorIssues(params: {
+  page?: number
+  page_size?: number
+  status?: ErrorIssueStatus
+  error_code?: string
+  keyword?: string
+  sort?: 'last_seen' | 'first_seen' | 'occurrences' | 'users'
+}) {
+  return request.get<any, ErrorIssueListResponse>('/admin/error-issues', {
+    params,
+  })
+}
+
+export function getErrorIssue(id: number) {
+  return request.get<any, ErrorIssueDetail>(`/admin/error-issues/${id}`)
+}
+
+export function updateErrorIssueStatus(id: number, status: ErrorIssueStatus) {
+  return request.put<any, ErrorIssue>(`/admin/error-issues/${id}/status`, { status })
+}
@@ -1,5 +1,5 @@
 import request from './request'
-import type { ModerationRule, ListModerationRulesResponse } from '../types'
+import type { ModerationRule, ListModerationRulesResponse, ModerationStatusGraph } from '../types'
 
 /**
  * List all moderation rules with filtering and pagination
@@ -57,3 +57,10 @@ export function updateRule(id: number, rule: ModerationRule) {
 export function deleteRule(id: number) {
   return request.delete<any, { message: string }>(`/admin/moderation-rules/${id}`)
 }
+
+/**
+ * Get the allowed status transitions of comments and videos
+ */
+export function getStatusGraph() {
+  return request.get<any, { graphs: ModerationStatusGraph[] }>('/admin/moderation/status-graph')
+}
@@ -1,6 +1,6 @@
 import axios, { type AxiosResponse } from 'axios'
 import { ElMessage } from 'element-plus'
-import { getToken, removeToken } from '../utils/auth'
+import { getToken, getRefreshToken, setToken, setRefreshToken, removeToken } from '../utils/auth'
 import { createTraceId } from '../utils/trace'
 import { buildTraceMessage } from '../utils/traceNotice'
 
@@ -31,16 +31,52 @@ request.interceptors.request.use(
   }
 )
 
+// Refresh the short-lived access token once, sharing the in-flight request
+let refreshPromise: Promise<string> | null = null
+
+function refreshAccessToken(): Promise<string> {
+  if (!refreshPromise) {
+    const refreshToken = getRefreshToken()
+    if (!refreshToken) {
+      return Promise.reject(new Error('no refresh token'))
+    }
+    refreshPromise = axios
+      .post('/api/auth/refresh', { refresh_token: refreshToken })
+      .then(({ data }) => {
+        setToken(data.token)
+        setRefreshToken(data.refresh_token)
+        return data.token as string
+      })
+      .finally(() => {
+        refreshPromise = null
+      })
+  }
+  return refreshPromise
+}
+
 // Response interceptor
 request.interceptors.response.use(
   (response: AxiosResponse) => {
     return response.data
   },
-  (error) => {
+  async (error) => {
     console.error('Response error:', error)
 
     if (error.response) {
       const { status, data } = error.response
+      const original = error.config as any
+
+      // Expired access token: try one refresh, then replay the request
+      if (status === 401 && original && !original._retried && getRefreshToken()) {
+        original._retried = true
+        try {
+          const token = await refreshAccessToken()
+          original.headers.Authorization = `Bearer ${token}`
+          return request(original)
+        } catch {
+          // fall through to the normal 401 handling below
+        }
+      }
 
       // Handle 429 Too Many Requests - 限流保护
       if (status === 429) {
@@ -1,5 +1,15 @@
 import request from './request'
-import type { TasksResponse, TagsResponse, ReviewResult, SearchTasksRequest, SearchTasksResponse } from '../types'
+import type {
+  TasksResponse,
+  TagsResponse,
+  ReviewResult,
+  SearchTasksRequest,
+  SearchTasksResponse,
+  CommentCluster,
+  CommentClustersResponse,
+  DecideCommentClusterRequest,
+  DecideCommentClusterResponse,
+} from '../types'
 
 /**
  * Claim tasks with custom count (1-50)
@@ -54,3 +64,24 @@ export function searchTasks(params: SearchTasksRequest) {
   return request.get<any, SearchTasksResponse>('/tasks/search', { params })
 }
 
+
+/**
+ * List open clusters of near-identical pending comments, largest first
+ */
+export function listCommentClusters(params: { min_size?: number; page?: number; page_size?: number } = {}) {
+  return request.get<any, CommentClustersResponse>('/tasks/comment-clusters', { params })
+}
+
+/**
+ * Get a comment cluster with its open members
+ */
+export function getCommentCluster(id: number) {
+  return request.get<any, CommentCluster>(`/tasks/comment-clusters/${id}`)
+}
+
+/**
+ * Decide every member of a cluster except the excluded tasks
+ */
+export function decideCommentCluster(id: number, data: DecideCommentClusterRequest) {
+  return request.post<any, DecideCommentClusterResponse>(`/tasks/comment-clusters/${id}/decide`, data)
+}
@@ -1,9 +1,40 @@
 import request from './request'
-import type { VideoQueueTag } from '../types'
+import type { VideoAnnotation, VideoAnnotationInput, VideoDuplicate, VideoQueueTag } from '../types'
 
 // Types for Video Queue Pool System
 
-export type Pool = '100k' | '1m' | '10m'
+// Pool name as configured in the traffic-pool ladder, e.g. '100k'
+export type Pool = string
+
+export interface VideoPool {
+  name: Pool
+  display_name: string
+  description: string
+  sort_order: number
+  next_pool: Pool | null
+  terminal_status: string | null
+  claim_max_count: number
+  max_tags: number
+  tag_set: Pool | null
+  is_active: boolean
+  created_at: string
+  updated_at: string
+}
+
+export interface CreateVideoPoolRequest {
+  name: string
+  display_name: string
+  description?: string
+  sort_order?: number
+  next_pool?: Pool | null
+  terminal_status?: string | null
+  claim_max_count?: number
+  max_tags?: number
+  tag_set?: Pool | null
+  is_active?: boolean
+}
+
+export type UpdateVideoPoolRequest = Partial<Omit<CreateVideoPoolRequest, 'name'>>
 
 export type ReviewDecision = 'push_next_pool' | 'natural_pool' | 'remove_violation'
 
@@ -28,7 +59,9 @@ export interface VideoQueueTask {
     status: string
     created_at: string
     updated_at: string
+    duplicate?: VideoDuplicate
   }
+  annotations?: VideoAnnotation[]
 }
 
 // Re-export VideoQueueTag from types
@@ -48,6 +81,7 @@ export interface SubmitVideoQueueReviewRequest {
   review_decision: ReviewDecision
   reason: string
   tags: string[]
+  annotations?: VideoAnnotationInput[]
 }
 
 export interface BatchSubmitVideoQueueReviewRequest {
@@ -75,7 +109,7 @@ export interface VideoQueuePoolStats {
 
 /**
  * 领取视频队列任务
- * @param pool 队列类型 ('100k' | '1m' | '10m')
+ * @param pool 流量池名称
  * @param data 领取请求
  */
 export const claimVideoQueueTasks = (
@@ -87,7 +121,7 @@ export const claimVideoQueueTasks = (
 
 /**
  * 获取我的视频队列任务
- * @param pool 队列类型 ('100k' | '1m' | '10m')
+ * @param pool 流量池名称
  */
 export const getMyVideoQueueTasks = (pool: Pool): Promise<{ tasks: VideoQueueTask[], count: number }> => {
   return request.get(`/video/${pool}/tasks/my`)
@@ -95,7 +129,7 @@ export const getMyVideoQueueTasks = (pool: Pool): Promise<{ tasks: VideoQueueTas
 
 /**
  * 提交单个视频队列审核
- * @param pool 队列类型 ('100k' | '1m' | '10m')
+ * @param pool 流量池名称
  * @param data 审核结果
  */
 export const submitVideoQueueReview = (pool: Pool, data: SubmitVideoQueueReviewRequest): Promise<{ message: string }> => {
@@ -104,7 +138,7 @@ export const submitVideoQueueReview = (pool: Pool, data: SubmitVideoQueueReviewR
 
 /**
  * 批量提交视频队列审核
- * @param pool 队列类型 ('100k' | '1m' | '10m')
+ * @param pool 流量池名称
  * @param data 批量审核结果
  */
 export const submitBatchVideoQueueReviews = (
@@ -116,7 +150,7 @@ export const submitBatchVideoQueueReviews = (
 
 /**
  * 归还视频队列任务
- * @param pool 队列类型 ('100k' | '1m' | '10m')
+ * @param pool 流量池名称
  * @param data 归还请求
  */
 export const returnVideoQueueTasks = (
@@ -128,7 +162,7 @@ export const returnVideoQueueTasks = (
 
 /**
  * 获取视频队列标签
- * @param pool 队列类型 ('100k' | '1m' | '10m')
+ * @param pool 流量池名称
  */
 export const getVideoQueueTags = (pool: Pool): Promise<GetVideoQueueTagsResponse> => {
   return request.get(`/video/${pool}/tags`)
@@ -136,12 +170,47 @@ export const getVideoQueueTags = (pool: Pool): Promise<GetVideoQueueTagsResponse
 
 /**
  * 获取视频队列统计 (管理员)
- * @param pool 队列类型 ('100k' | '1m' | '10m')
+ * @param pool 流量池名称
  */
 export const getVideoQueuePoolStats = (pool: Pool): Promise<VideoQueuePoolStats> => {
   return request.get(`/admin/video-queue/${pool}/stats`)
 }
 
+/**
+ * 获取所有流量池统计 (管理员)，按流量池阶梯顺序
+ */
+export const getAllVideoQueuePoolStats = (): Promise<{ pools: VideoQueuePoolStats[] }> => {
+  return request.get('/admin/video-queue/stats')
+}
+
+/**
+ * 获取启用中的流量池阶梯
+ */
+export const getVideoPools = (): Promise<{ pools: VideoPool[] }> => {
+  return request.get('/video/pools')
+}
+
+/**
+ * 获取全部流量池配置，包括已停用的 (管理员)
+ */
+export const getAllVideoPools = (): Promise<{ pools: VideoPool[] }> => {
+  return request.get('/admin/video-pools')
+}
+
+/**
+ * 新增流量池 (管理员)
+ */
+export const createVideoPool = (data: CreateVideoPoolRequest): Promise<VideoPool> => {
+  return request.post('/admin/video-pools', data)
+}
+
+/**
+ * 更新流量池配置 (管理员)
+ */
+export const updateVideoPool = (name: Pool, data: UpdateVideoPoolRequest): Promise<VideoPool> => {
+  return request.put(`/admin/video-pools/${name}`, data)
+}
+
 /**
  * 生成视频 URL (复用原有 API)
  */
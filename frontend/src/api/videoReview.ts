@@ -1,20 +1,41 @@
 import request from './request'
 import type {
   ImportVideosRequest,
-  ImportVideosResponse,
+  VideoImportJob,
+  ListVideoImportJobErrorsResponse,
   ListVideosRequest,
   ListVideosResponse,
   GenerateVideoURLRequest,
   GenerateVideoURLResponse,
-  TikTokVideo
+  TikTokVideo,
+  VideoTimeline
 } from '@/types'
 
 // Admin video management APIs
 
-export const importVideos = (data: ImportVideosRequest): Promise<ImportVideosResponse> => {
+export const importVideos = (data: ImportVideosRequest): Promise<VideoImportJob> => {
   return request.post('/admin/videos/import', data)
 }
 
+export const getImportJob = (id: number): Promise<VideoImportJob> => {
+  return request.get(`/admin/videos/import-jobs/${id}`)
+}
+
+export const listImportJobErrors = (
+  id: number,
+  params?: { page?: number; page_size?: number }
+): Promise<ListVideoImportJobErrorsResponse> => {
+  return request.get(`/admin/videos/import-jobs/${id}/errors`, { params })
+}
+
+export const cancelImportJob = (id: number): Promise<VideoImportJob> => {
+  return request.post(`/admin/videos/import-jobs/${id}/cancel`)
+}
+
+export const resumeImportJob = (id: number): Promise<VideoImportJob> => {
+  return request.post(`/admin/videos/import-jobs/${id}/resume`)
+}
+
 export const listVideos = (params?: ListVideosRequest): Promise<ListVideosResponse> => {
   return request.get('/admin/videos', { params })
 }
@@ -23,6 +44,10 @@ export const getVideo = (id: number): Promise<TikTokVideo> => {
   return request.get(`/admin/videos/${id}`)
 }
 
+export const getVideoTimeline = (id: number): Promise<VideoTimeline> => {
+  return request.get(`/admin/videos/${id}/timeline`)
+}
+
 export const generateVideoURL = (data: GenerateVideoURLRequest): Promise<GenerateVideoURLResponse> => {
   return request.post('/admin/videos/generate-url', data)
 }
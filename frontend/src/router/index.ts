@@ -21,6 +21,12 @@ const router = createRouter({
       component: () => import('../views/Login.vue'),
       meta: { requiresAuth: false },
     },
+    {
+      path: '/auth/oidc/callback',
+      name: 'OIDCCallback',
+      component: () => import('../views/OIDCCallback.vue'),
+      meta: { requiresAuth: false },
+    },
     {
       path: '/register',
       name: 'Register',
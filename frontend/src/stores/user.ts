@@ -1,8 +1,8 @@
 import { defineStore } from 'pinia'
 import { ref } from 'vue'
 import type { User } from '../types'
-import { setToken, setUser, getUser, removeToken } from '../utils/auth'
-import { login as loginApi, getProfile, loginWithCode as loginWithCodeApi } from '../api/auth'
+import { setToken, setRefreshToken, setUser, getUser, removeToken } from '../utils/auth'
+import { login as loginApi, getProfile, loginWithCode as loginWithCodeApi, completeOIDCLogin } from '../api/auth'
 
 export const useUserStore = defineStore('user', () => {
   const user = ref<User | null>(getUser())
@@ -15,6 +15,7 @@ export const useUserStore = defineStore('user', () => {
     user.value = res.user
     permissions.value = []
     setToken(res.token)
+    setRefreshToken(res.refresh_token)
     setUser(res.user)
     return res
   }
@@ -39,6 +40,18 @@ export const useUserStore = defineStore('user', () => {
     user.value = res.user
     permissions.value = []
     setToken(res.token)
+    setRefreshToken(res.refresh_token)
+    setUser(res.user)
+    return res
+  }
+
+  async function loginWithOIDC(code: string, state: string) {
+    const res = await completeOIDCLogin(code, state)
+    token.value = res.token
+    user.value = res.user
+    permissions.value = []
+    setToken(res.token)
+    setRefreshToken(res.refresh_token)
     setUser(res.user)
     return res
   }
@@ -64,6 +77,7 @@ export const useUserStore = defineStore('user', () => {
     permissions,
     login,
     loginWithCode,
+    loginWithOIDC,
     logout,
     loadProfile,
     isAdmin,
@@ -79,14 +79,76 @@ export interface BugReport {
   page_url?: string
   user_agent?: string
   screenshots: BugReportScreenshot[]
+  trace_ids: string[]
+  status: BugReportStatus
+  assignee_id?: number | null
+  duplicate_of?: number | null
+  resolution?: string
+  resolved_at?: string | null
   created_at: string
+  updated_at: string
 }
 
+export type BugReportStatus = 'new' | 'triaged' | 'in_progress' | 'resolved' | 'wontfix'
+
 export interface BugReportAdminItem extends BugReport {
   username: string
+  assignee_username?: string
+  comment_count: number
+  error_signature?: string
+  linked_errors?: BugReportErrorLink[]
   screenshots: BugReportScreenshotView[]
 }
 
+export interface BugReportErrorLink {
+  trace_id: string
+  audit_log_id: string
+  occurred_at: string
+  endpoint?: string
+  http_method?: string
+  status_code: number
+  error_code?: string
+  error_type?: string
+  error_message?: string
+  error_stack?: string
+}
+
+export interface BugReportErrorGroup {
+  error_signature: string
+  error_codes: string[]
+  report_count: number
+  open_count: number
+  reporter_count: number
+  first_reported_at: string
+  last_reported_at: string
+  latest_report_id: number
+}
+
+export interface BugReportErrorGroupResponse {
+  data: BugReportErrorGroup[]
+  total: number
+  page: number
+  page_size: number
+  total_pages: number
+}
+
+export interface BugReportComment {
+  id: number
+  report_id: number
+  parent_id?: number | null
+  author_id: number
+  author_username: string
+  author_role: string
+  body: string
+  internal: boolean
+  created_at: string
+  replies: BugReportComment[]
+}
+
+export interface BugReportDetail extends BugReportAdminItem {
+  comments: BugReportComment[]
+}
+
 export interface BugReportListResponse {
   data: BugReportAdminItem[]
   total: number
@@ -95,6 +157,56 @@ export interface BugReportListResponse {
   total_pages: number
 }
 
+// Error issue types
+export type ErrorIssueStatus = 'open' | 'resolved' | 'ignored'
+
+export interface ErrorIssue {
+  id: number
+  fingerprint: string
+  http_method: string
+  endpoint: string
+  error_code: string
+  error_type?: string
+  stack_frames: string[]
+  status_code: number
+  title?: string
+  last_trace_id?: string
+  status: ErrorIssueStatus
+  occurrence_count: number
+  affected_users: number
+  first_seen_at: string
+  last_seen_at: string
+  resolved_at?: string | null
+  resolved_by?: number | null
+  resolved_by_username?: string
+  regressed_at?: string | null
+  regression_count: number
+  updated_at: string
+}
+
+export interface ErrorIssueOccurrence {
+  audit_log_id: string
+  trace_id?: string
+  user_id?: number | null
+  username?: string
+  status_code: number
+  error_message?: string
+  occurred_at: string
+}
+
+export interface ErrorIssueDetail extends ErrorIssue {
+  sample_stack?: string
+  recent_occurrences: ErrorIssueOccurrence[]
+}
+
+export interface ErrorIssueListResponse {
+  data: ErrorIssue[]
+  total: number
+  page: number
+  page_size: number
+  total_pages: number
+}
+
 // Audit log types
 export interface AuditLogEntry {
   id: string
@@ -169,12 +281,29 @@ export interface AuditLogQueryResponse {
   page: number
   page_size: number
   total_pages: number
+  archived_days?: number
+}
+
+export interface AuditLogArchive {
+  id: number
+  archive_day: string
+  part: number
+  object_key: string
+  entry_count: number
+  first_seq?: number
+  last_seq?: number
+  oldest_at: string
+  newest_at: string
+  size_bytes: number
+  sha256: string
+  created_at: string
 }
 
 export interface AuditLogExportRequest {
   start_time: string
   end_time: string
   format: 'csv' | 'json' | 'xlsx'
+  compression?: 'none' | 'gzip'
   fields?: string[]
   user_id?: number
   username?: string
@@ -195,11 +324,13 @@ export interface AuditLogExportRequest {
   device_type?: string
 }
 
+export type AuditLogExportStatus = 'queued' | 'processing' | 'completed' | 'failed'
+
 export interface AuditLogExportResponse {
   export_id: string
-  download_url: string
-  expires_at: string
-  row_count: number
+  status: AuditLogExportStatus
+  format: string
+  compression: string
 }
 
 export interface AuditLogExportRecord {
@@ -207,14 +338,21 @@ export interface AuditLogExportRecord {
   user_id: number
   username: string
   export_format: string
+  compression: string
   filters?: any
   fields?: string[]
-  status: string
+  status: AuditLogExportStatus
   row_count?: number
+  total_rows?: number
+  processed_rows: number
+  size_bytes: number
   file_key?: string
   download_url?: string
   expires_at?: string
   error_message?: string
+  started_at?: string
+  completed_at?: string
+  updated_at: string
   created_at: string
 }
 
@@ -226,6 +364,42 @@ export interface AuditLogExportListResponse {
   total_pages: number
 }
 
+export type AuditChainBreakKind =
+  | 'content_mismatch'
+  | 'broken_link'
+  | 'missing_entries'
+  | 'checkpoint_mismatch'
+  | 'bad_signature'
+  | 'truncated'
+
+export interface AuditChainBreak {
+  day: string
+  seq: number
+  entry_id?: string
+  kind: AuditChainBreakKind
+  detail: string
+}
+
+export interface AuditChainDayResult {
+  day: string
+  entries: number
+  last_seq: number
+  head_seq: number
+  checkpoints: number
+  tombstoned: number
+  valid: boolean
+}
+
+export interface AuditChainVerifyResponse {
+  valid: boolean
+  signed: boolean
+  entries: number
+  unchained: number
+  days: AuditChainDayResult[]
+  breaks: AuditChainBreak[]
+  checked_at: string
+}
+
 // Monitoring types
 export interface MonitoringSummary {
   date: string
@@ -260,6 +434,53 @@ export interface ReviewResult {
   reason: string
 }
 
+// Near-duplicate comment clusters
+export interface CommentClusterMember {
+  task_id: number
+  comment_id: number
+  text: string
+  similarity: number
+  status: 'pending' | 'in_progress'
+  reviewer_id: number | null
+  created_at: string
+}
+
+export interface CommentCluster {
+  id: number
+  representative_task_id: number
+  representative_text: string
+  status: 'open' | 'decided' | 'closed'
+  pending_count: number
+  decided_by?: number
+  decided_at?: string
+  created_at: string
+  updated_at: string
+  members?: CommentClusterMember[]
+}
+
+export interface CommentClustersResponse {
+  data: CommentCluster[]
+  total: number
+  page: number
+  page_size: number
+  total_pages: number
+}
+
+export interface DecideCommentClusterRequest {
+  is_approved: boolean
+  tags: string[]
+  reason: string
+  exclude_task_ids: number[]
+}
+
+export interface DecideCommentClusterResponse {
+  cluster_id: number
+  cluster_status: 'open' | 'decided' | 'closed'
+  decisions: { task_id: number; comment_id: number; review_result_id: number }[]
+  excluded_count: number
+  skipped_count: number
+}
+
 // AI Review types
 export interface AIReviewJob {
   id: number
@@ -364,6 +585,9 @@ export interface AIReviewComparisonResponse {
 // API Response types
 export interface LoginResponse {
   token: string
+  refresh_token: string
+  expires_at: string
+  session_id: string
   user: User
 }
 
@@ -575,6 +799,7 @@ export interface TaskSearchResult {
   pool?: string | null
   overall_score?: number | null
   traffic_pool_result?: string | null
+  annotations?: VideoAnnotation[]
 }
 
 export interface SearchTasksResponse {
@@ -815,6 +1040,67 @@ export interface TikTokVideo {
   status: 'pending' | 'first_review_completed' | 'second_review_completed'
   created_at: string
   updated_at: string
+  width?: number | null
+  height?: number | null
+  video_codec?: string | null
+  frame_rate?: number | null
+  bitrate?: number | null
+  has_audio?: boolean | null
+  audio_codec?: string | null
+  probed_at?: string
+  thumbnail_url?: string
+  keyframe_strip_url?: string
+  duplicate?: VideoDuplicate
+}
+
+// Link from a re-uploaded video to the earliest import of the same clip
+export interface VideoDuplicate {
+  video_id: number
+  original_video_id: number
+  method: 'frames' | 'container'
+  similarity: number
+  action: 'flagged' | 'inherited'
+  inherited_status: string | null
+  created_at: string
+  resolved_at: string | null
+  original_filename: string
+  original_status: string
+  original_is_approved: boolean | null
+  original_reason: string | null
+}
+
+export interface VideoTimelineEvent {
+  occurred_at: string
+  type: 'imported' | 'task_created' | 'task_claimed' | 'review_submitted' | 'status_changed' | 'duplicate_linked'
+  stage?: 'first_review' | 'second_review' | 'queue'
+  pool?: string
+  task_id?: number
+  reviewer_id?: number
+  reviewer_name?: string
+  is_approved?: boolean
+  decision?: 'push_next_pool' | 'natural_pool' | 'remove_violation'
+  overall_score?: number
+  reason?: string
+  tags?: string[]
+  from_status?: string
+  to_status?: string
+  source?: string
+  related_video_id?: number
+}
+
+export interface VideoTimeline {
+  video: TikTokVideo
+  events: VideoTimelineEvent[]
+}
+
+// Allowed status transitions of one content type (comment, video)
+export interface ModerationStatusGraph {
+  name: string
+  initial: string
+  states: string[]
+  final: string[]
+  transitions: { from: string; to: string }[]
+  aliases?: Record<string, string[]> // placeholder state -> concrete statuses
 }
 
 // Video Queue Tag for video queue pool system (with scope and queue_id)
@@ -829,16 +1115,64 @@ export interface VideoQueueTag {
   created_at: string
 }
 
+// Time-ranged note a reviewer attaches to a video review
+export interface VideoAnnotationInput {
+  start_seconds: number
+  end_seconds: number
+  tag: string
+  note?: string
+}
+
+export interface VideoAnnotation extends VideoAnnotationInput {
+  id: number
+  video_id: number
+  review_stage: 'first_review' | 'second_review' | 'queue'
+  task_id: number
+  reviewer_id: number | null
+  note: string
+  created_at: string
+}
+
 // Video Review Request/Response Types
 
 export interface ImportVideosRequest {
   r2_path_prefix: string
 }
 
-export interface ImportVideosResponse {
+export type VideoImportJobStatus = 'pending' | 'running' | 'completed' | 'failed' | 'canceled'
+
+export interface VideoImportJob {
+  id: number
+  r2_path_prefix: string
+  status: VideoImportJobStatus
+  cursor?: string
+  listed_count: number
   imported_count: number
   skipped_count: number
-  errors: string[]
+  failed_count: number
+  last_error?: string
+  created_by?: number
+  created_at: string
+  updated_at: string
+  started_at?: string
+  heartbeat_at?: string
+  completed_at?: string
+}
+
+export interface VideoImportJobError {
+  id: number
+  job_id: number
+  video_key: string
+  error_message: string
+  created_at: string
+}
+
+export interface ListVideoImportJobErrorsResponse {
+  data: VideoImportJobError[]
+  total: number
+  page: number
+  page_size: number
+  total_pages: number
 }
 
 export interface ListVideosRequest {
@@ -1,7 +1,16 @@
+// Trace IDs are W3C/OpenTelemetry trace IDs (32 lowercase hex digits), so the
+// server continues the trace under the same ID
 export function createTraceId(): string {
-  if (typeof crypto !== 'undefined' && 'randomUUID' in crypto) {
-    return crypto.randomUUID()
+  const bytes = new Uint8Array(16)
+  if (typeof crypto !== 'undefined' && 'getRandomValues' in crypto) {
+    crypto.getRandomValues(bytes)
+  } else {
+    for (let i = 0; i < bytes.length; i++) {
+      bytes[i] = Math.floor(Math.random() * 256)
+    }
   }
-  const rand = Math.random().toString(36).slice(2, 10)
-  return `${Date.now().toString(36)}-${rand}`
+  if (bytes.every((b) => b === 0)) {
+    bytes[15] = 1
+  }
+  return Array.from(bytes, (b) => b.toString(16).padStart(2, '0')).join('')
 }
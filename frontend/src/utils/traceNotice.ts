@@ -4,6 +4,10 @@ export const TRACE_COPY_HOTKEY = 'Ctrl+Shift+C'
 
 let lastTraceId = ''
 let lastTraceTime = ''
+// Trace IDs of the most recent failed requests, newest first, sent along
+// with bug reports so the server errors can be linked
+const MAX_RECENT_TRACE_IDS = 10
+const recentTraceIds: string[] = []
 let hotkeyInitialized = false
 
 const formatTime = (value: Date) => value.toLocaleString('zh-CN')
@@ -43,8 +47,16 @@ export const recordTraceInfo = (traceId: string, at: Date) => {
   if (!traceId) return
   lastTraceId = traceId
   lastTraceTime = formatTime(at)
+  const existing = recentTraceIds.indexOf(traceId)
+  if (existing !== -1) {
+    recentTraceIds.splice(existing, 1)
+  }
+  recentTraceIds.unshift(traceId)
+  recentTraceIds.length = Math.min(recentTraceIds.length, MAX_RECENT_TRACE_IDS)
 }
 
+export const getRecentTraceIds = () => [...recentTraceIds]
+
 export const buildTraceMessage = (message: string, error?: any) => {
   const now = new Date()
   const traceId = resolveTraceIdFromError(error)
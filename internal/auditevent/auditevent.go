@@ -0,0 +1,224 @@
+// Package auditevent lets services record what they changed as typed audit
+// events, instead of the audit middleware guessing it from the request path.
+//
+// Services call Record with the entity before and after the change. Inside an
+// HTTP request the event is collected for the audit middleware, which writes
+// it with the request's trace ID; elsewhere it goes to the sink installed by
+// the audit logger.
+package auditevent
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+
+	"comment-review-platform/internal/observability"
+)
+
+// Action identifies a domain change; it is stored as the audit log's action_type
+type Action string
+
+const (
+	ReviewSubmit     Action = "review.submit"
+	UserStatusChange Action = "user.status_change"
+	PermissionGrant  Action = "permission.grant"
+	PermissionRevoke Action = "permission.revoke"
+
+	BugReportStatusChange Action = "bug_report.status_change"
+	BugReportAssign       Action = "bug_report.assign"
+	BugReportDuplicate    Action = "bug_report.duplicate"
+
+	ErrorIssueStatusChange Action = "error_issue.status_change"
+	ErrorIssueRegression   Action = "error_issue.regression"
+)
+
+type actionInfo struct {
+	category    string
+	description string
+}
+
+var actions = map[Action]actionInfo{
+	ReviewSubmit:     {"content_moderation", "提交评论审核结果"},
+	UserStatusChange: {"user_management", "变更用户状态"},
+	PermissionGrant:  {"authorization", "授予权限"},
+	PermissionRevoke: {"authorization", "撤销权限"},
+
+	BugReportStatusChange: {"system_operation", "变更错误反馈状态"},
+	BugReportAssign:       {"system_operation", "分配错误反馈"},
+	BugReportDuplicate:    {"system_operation", "标记重复错误反馈"},
+
+	ErrorIssueStatusChange: {"system_operation", "变更错误问题状态"},
+	ErrorIssueRegression:   {"system_operation", "错误问题回归并自动重新打开"},
+}
+
+// Category returns the audit category of an action
+func (a Action) Category() string {
+	if info, ok := actions[a]; ok {
+		return info.category
+	}
+	return "system_operation"
+}
+
+// Description returns the human-readable description of an action
+func (a Action) Description() string {
+	if info, ok := actions[a]; ok {
+		return info.description
+	}
+	return string(a)
+}
+
+// Event describes one change made by a service. Before is nil for entities
+// that were created and After is nil for entities that were removed.
+type Event struct {
+	Action       Action
+	ResourceType string
+	ResourceID   string
+	ActorID      *int
+	Before       interface{}
+	After        interface{}
+}
+
+// Recorded is an event as handed to the audit log
+type Recorded struct {
+	Action       Action
+	ResourceType string
+	ResourceID   string
+	ActorID      *int
+	Changes      json.RawMessage
+	TraceID      string
+	OccurredAt   time.Time
+}
+
+// Collector gathers the events recorded while one request is handled
+type Collector struct {
+	mu     sync.Mutex
+	events []Recorded
+}
+
+// Events returns the events recorded so far, in order
+func (c *Collector) Events() []Recorded {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Recorded(nil), c.events...)
+}
+
+func (c *Collector) add(event Recorded) {
+	c.mu.Lock()
+	c.events = append(c.events, event)
+	c.mu.Unlock()
+}
+
+type collectorContextKey struct{}
+
+// WithCollector returns a context whose recorded events are gathered by the
+// returned collector
+func WithCollector(ctx context.Context) (context.Context, *Collector) {
+	collector := &Collector{}
+	return context.WithValue(ctx, collectorContextKey{}, collector), collector
+}
+
+var (
+	sinkMu sync.RWMutex
+	sink   func(Recorded)
+)
+
+// SetSink installs the handler for events recorded outside a collector, such
+// as by background jobs
+func SetSink(fn func(Recorded)) {
+	sinkMu.Lock()
+	sink = fn
+	sinkMu.Unlock()
+}
+
+// Record computes the event's diff and hands it to the request's collector,
+// or to the sink if ctx carries none. Events without a handler are dropped.
+func Record(ctx context.Context, event Event) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	recorded := Recorded{
+		Action:       event.Action,
+		ResourceType: event.ResourceType,
+		ResourceID:   event.ResourceID,
+		ActorID:      event.ActorID,
+		Changes:      Diff(event.Before, event.After),
+		TraceID:      observability.TraceID(ctx),
+		OccurredAt:   time.Now(),
+	}
+
+	if collector, ok := ctx.Value(collectorContextKey{}).(*Collector); ok {
+		collector.add(recorded)
+		return
+	}
+	sinkMu.RLock()
+	fn := sink
+	sinkMu.RUnlock()
+	if fn != nil {
+		fn(recorded)
+	}
+}
+
+// Diff returns {"before": {...}, "after": {...}} holding only the top-level
+// fields that differ between the JSON forms of before and after. A nil side
+// is written as null, so creations and removals keep the whole entity. It
+// returns nil if nothing changed.
+func Diff(before, after interface{}) json.RawMessage {
+	beforeFields, beforeOK := fields(before)
+	afterFields, afterOK := fields(after)
+
+	var payload map[string]interface{}
+	switch {
+	case beforeOK && afterOK:
+		changedBefore := map[string]interface{}{}
+		changedAfter := map[string]interface{}{}
+		for key, value := range beforeFields {
+			if other, ok := afterFields[key]; !ok || !reflect.DeepEqual(value, other) {
+				changedBefore[key] = value
+			}
+		}
+		for key, value := range afterFields {
+			if other, ok := beforeFields[key]; !ok || !reflect.DeepEqual(value, other) {
+				changedAfter[key] = value
+			}
+		}
+		if len(changedBefore) == 0 && len(changedAfter) == 0 {
+			return nil
+		}
+		payload = map[string]interface{}{"before": changedBefore, "after": changedAfter}
+	default:
+		beforeValue, afterValue := jsonValue(before), jsonValue(after)
+		if reflect.DeepEqual(beforeValue, afterValue) {
+			return nil
+		}
+		payload = map[string]interface{}{"before": beforeValue, "after": afterValue}
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+// fields returns the JSON object form of v, if it has one
+func fields(v interface{}) (map[string]interface{}, bool) {
+	object, ok := jsonValue(v).(map[string]interface{})
+	return object, ok
+}
+
+func jsonValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,114 @@
+package auditevent
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"comment-review-platform/internal/observability"
+)
+
+type user struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+	Role   string `json:"role"`
+}
+
+func decodeDiff(t *testing.T, raw json.RawMessage) map[string]interface{} {
+	t.Helper()
+	var diff map[string]interface{}
+	if err := json.Unmarshal(raw, &diff); err != nil {
+		t.Fatalf("invalid diff %s: %v", raw, err)
+	}
+	return diff
+}
+
+func TestDiffKeepsOnlyChangedFields(t *testing.T) {
+	raw := Diff(user{ID: 1, Status: "pending", Role: "reviewer"}, user{ID: 1, Status: "approved", Role: "reviewer"})
+	diff := decodeDiff(t, raw)
+
+	before := diff["before"].(map[string]interface{})
+	after := diff["after"].(map[string]interface{})
+	if len(before) != 1 || before["status"] != "pending" {
+		t.Fatalf("before = %v, want only status=pending", before)
+	}
+	if len(after) != 1 || after["status"] != "approved" {
+		t.Fatalf("after = %v, want only status=approved", after)
+	}
+}
+
+func TestDiffAddedAndRemovedKeys(t *testing.T) {
+	before := map[string]interface{}{"a": 1}
+	after := map[string]interface{}{"b": 2}
+	diff := decodeDiff(t, Diff(before, after))
+
+	if _, ok := diff["before"].(map[string]interface{})["a"]; !ok {
+		t.Fatalf("removed key missing from before: %v", diff)
+	}
+	if _, ok := diff["after"].(map[string]interface{})["b"]; !ok {
+		t.Fatalf("added key missing from after: %v", diff)
+	}
+}
+
+func TestDiffCreationKeepsWholeEntity(t *testing.T) {
+	var missing *user
+	diff := decodeDiff(t, Diff(missing, &user{ID: 2, Status: "approved"}))
+
+	if diff["before"] != nil {
+		t.Fatalf("before = %v, want null", diff["before"])
+	}
+	after := diff["after"].(map[string]interface{})
+	if after["status"] != "approved" || after["id"] != float64(2) {
+		t.Fatalf("after = %v, want the whole entity", after)
+	}
+}
+
+func TestDiffUnchangedIsNil(t *testing.T) {
+	if raw := Diff(user{ID: 1, Status: "approved"}, user{ID: 1, Status: "approved"}); raw != nil {
+		t.Fatalf("Diff = %s, want nil", raw)
+	}
+	if raw := Diff(nil, nil); raw != nil {
+		t.Fatalf("Diff(nil, nil) = %s, want nil", raw)
+	}
+}
+
+func TestRecordUsesCollectorAndTraceID(t *testing.T) {
+	var sunk []Recorded
+	SetSink(func(r Recorded) { sunk = append(sunk, r) })
+	defer SetSink(nil)
+
+	ctx := observability.WithTraceID(context.Background(), "trace-1")
+	ctx, collector := WithCollector(ctx)
+	Record(ctx, Event{Action: UserStatusChange, ResourceType: "user", ResourceID: "5", Before: user{Status: "pending"}, After: user{Status: "approved"}})
+
+	events := collector.Events()
+	if len(events) != 1 {
+		t.Fatalf("collected %d events, want 1", len(events))
+	}
+	if events[0].TraceID != "trace-1" || events[0].Action != UserStatusChange || events[0].Changes == nil {
+		t.Fatalf("unexpected event %+v", events[0])
+	}
+	if len(sunk) != 0 {
+		t.Fatalf("sink received %d events, want 0", len(sunk))
+	}
+}
+
+func TestRecordFallsBackToSink(t *testing.T) {
+	var sunk []Recorded
+	SetSink(func(r Recorded) { sunk = append(sunk, r) })
+	defer SetSink(nil)
+
+	Record(context.Background(), Event{Action: PermissionRevoke, ResourceType: "user", ResourceID: "9"})
+	if len(sunk) != 1 || sunk[0].TraceID != "" {
+		t.Fatalf("sink received %+v, want one event without a trace ID", sunk)
+	}
+}
+
+func TestActionMetadata(t *testing.T) {
+	if PermissionGrant.Category() != "authorization" {
+		t.Fatalf("category = %q", PermissionGrant.Category())
+	}
+	if Action("custom.thing").Description() != "custom.thing" {
+		t.Fatalf("unknown actions should describe themselves")
+	}
+}
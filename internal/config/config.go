@@ -13,6 +13,24 @@ type Config struct {
 	Port      string
 	JWTSecret string
 
+	// JWT Signing Keys (RS256/EdDSA); JWTSecret is only needed for HS256
+	JWTKeysDir     string
+	JWTActiveKeyID string
+
+	// Session Configuration
+	AccessTokenTTLMinutes int
+	RefreshTokenTTLHours  int
+
+	// OIDC Single Sign-On Configuration
+	OIDCIssuerURL        string
+	OIDCClientID         string
+	OIDCClientSecret     string
+	OIDCRedirectURL      string
+	OIDCScopes           string
+	OIDCProviderName     string
+	OIDCGroupsClaim      string
+	OIDCGroupPermissions string
+
 	// Redis Configuration
 	RedisAddr          string
 	RedisPassword      string
@@ -37,6 +55,17 @@ type Config struct {
 	R2BugReportPathPrefix string
 	R2AvatarPathPrefix    string
 
+	// Video Thumbnail Configuration
+	R2ThumbnailPathPrefix string
+	ThumbnailExtractor    string // "auto", "ffmpeg" or "embedded"
+	FFmpegPath            string
+	KeyframeStripFrames   int
+
+	// Video Duplicate Detection Configuration
+	VideoDuplicatePolicy            string  // "flag", "inherit" or "off"
+	VideoDuplicateMinSimilarity     float64 // flag duplicates at or above this frame similarity
+	VideoDuplicateInheritSimilarity float64 // inherit the earlier decision at or above this similarity
+
 	// Resend (Email) Configuration
 	ResendAPIKey    string
 	ResendFromEmail string
@@ -58,6 +87,27 @@ type Config struct {
 
 	// Metrics Configuration
 	MetricsWindowMinutes int
+	MetricsToken         string // bearer token required on /metrics; empty leaves it open
+
+	// Tracing Configuration
+	TracingServiceName  string
+	TracingOTLPEndpoint string  // OTLP/HTTP collector base URL; empty disables export
+	TracingOTLPHeaders  string  // comma-separated key=value pairs sent with every export
+	TracingFilePath     string  // also append spans to this file as OTLP JSON lines, for tests and local runs
+	TracingSampleRatio  float64 // share of new traces recorded
+
+	// Permission Cache Configuration
+	PermissionCacheTTLSeconds int
+
+	// Audit Log Writer Configuration
+	AuditQueueSize       int    // entries buffered in memory before spilling to disk
+	AuditBatchSize       int    // rows per insert
+	AuditFlushIntervalMs int    // flush a partial batch after this long
+	AuditSpillDir        string // where entries go while Postgres is unavailable; empty disables spilling
+	AuditSpillMaxMB      int
+
+	// Audit Log Hash Chain Configuration
+	AuditChainSigningKey string // HMAC key for chain checkpoints and tombstones; keep it outside the database
 }
 
 var AppConfig *Config
@@ -86,6 +136,17 @@ func LoadConfig() *Config {
 	alertThresholdWindowSeconds, _ := strconv.Atoi(getEnv("ALERT_THRESHOLD_WINDOW_SECONDS", "60"))
 	alertSilenceSeconds, _ := strconv.Atoi(getEnv("ALERT_SILENCE_SECONDS", "300"))
 	metricsWindowMinutes, _ := strconv.Atoi(getEnv("METRICS_WINDOW_MINUTES", "5"))
+	tracingSampleRatio, _ := strconv.ParseFloat(getEnv("TRACING_SAMPLE_RATIO", "1"), 64)
+	permissionCacheTTLSeconds, _ := strconv.Atoi(getEnv("PERMISSION_CACHE_TTL_SECONDS", "300"))
+	auditQueueSize, _ := strconv.Atoi(getEnv("AUDIT_QUEUE_SIZE", "10000"))
+	auditBatchSize, _ := strconv.Atoi(getEnv("AUDIT_BATCH_SIZE", "200"))
+	auditFlushIntervalMs, _ := strconv.Atoi(getEnv("AUDIT_FLUSH_INTERVAL_MS", "1000"))
+	auditSpillMaxMB, _ := strconv.Atoi(getEnv("AUDIT_SPILL_MAX_MB", "512"))
+	accessTokenTTLMinutes, _ := strconv.Atoi(getEnv("ACCESS_TOKEN_TTL_MINUTES", "15"))
+	refreshTokenTTLHours, _ := strconv.Atoi(getEnv("REFRESH_TOKEN_TTL_HOURS", "720"))
+	keyframeStripFrames, _ := strconv.Atoi(getEnv("KEYFRAME_STRIP_FRAMES", "8"))
+	videoDuplicateMinSimilarity, _ := strconv.ParseFloat(getEnv("VIDEO_DUPLICATE_MIN_SIMILARITY", "0.8"), 64)
+	videoDuplicateInheritSimilarity, _ := strconv.ParseFloat(getEnv("VIDEO_DUPLICATE_INHERIT_SIMILARITY", "0.95"), 64)
 
 	databaseURL := getEnv("DATABASE_URL", "")
 	if databaseURL == "" {
@@ -101,7 +162,7 @@ func LoadConfig() *Config {
 
 	AppConfig = &Config{
 		Port:               getEnv("PORT", "8080"),
-		JWTSecret:          getEnv("JWT_SECRET", "your-secret-key-change-this"),
+		JWTSecret:          getEnv("JWT_SECRET", ""),
 		RedisAddr:          getEnv("REDIS_ADDR", "localhost:6379"),
 		RedisPassword:      getEnv("REDIS_PASSWORD", ""),
 		RedisDB:            redisDB,
@@ -130,6 +191,45 @@ func LoadConfig() *Config {
 
 		// Metrics Configuration
 		MetricsWindowMinutes: metricsWindowMinutes,
+		MetricsToken:         getEnv("METRICS_TOKEN", ""),
+
+		// Tracing Configuration
+		TracingServiceName:  getEnv("OTEL_SERVICE_NAME", "comment-review-platform"),
+		TracingOTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TracingOTLPHeaders:  getEnv("OTEL_EXPORTER_OTLP_HEADERS", ""),
+		TracingFilePath:     getEnv("TRACING_FILE_PATH", ""),
+		TracingSampleRatio:  tracingSampleRatio,
+
+		// Permission Cache Configuration
+		PermissionCacheTTLSeconds: permissionCacheTTLSeconds,
+
+		// Audit Log Writer Configuration
+		AuditQueueSize:       auditQueueSize,
+		AuditBatchSize:       auditBatchSize,
+		AuditFlushIntervalMs: auditFlushIntervalMs,
+		AuditSpillDir:        getEnv("AUDIT_SPILL_DIR", "data/audit-spill"),
+		AuditSpillMaxMB:      auditSpillMaxMB,
+
+		// Audit Log Hash Chain Configuration
+		AuditChainSigningKey: getEnv("AUDIT_CHAIN_SIGNING_KEY", ""),
+
+		// JWT Signing Keys
+		JWTKeysDir:     getEnv("JWT_KEYS_DIR", ""),
+		JWTActiveKeyID: getEnv("JWT_ACTIVE_KEY_ID", ""),
+
+		// Session Configuration
+		AccessTokenTTLMinutes: accessTokenTTLMinutes,
+		RefreshTokenTTLHours:  refreshTokenTTLHours,
+
+		// OIDC Single Sign-On Configuration
+		OIDCIssuerURL:        getEnv("OIDC_ISSUER_URL", ""),
+		OIDCClientID:         getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:     getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:      getEnv("OIDC_REDIRECT_URL", ""),
+		OIDCScopes:           getEnv("OIDC_SCOPES", "openid email profile"),
+		OIDCProviderName:     getEnv("OIDC_PROVIDER_NAME", "企业 SSO"),
+		OIDCGroupsClaim:      getEnv("OIDC_GROUPS_CLAIM", "groups"),
+		OIDCGroupPermissions: getEnv("OIDC_GROUP_PERMISSIONS", ""),
 
 		// Cloudflare R2 Configuration
 		CloudflareAccountID:   getEnv("CLOUDFLARE_ACCOUNT_ID", ""),
@@ -140,6 +240,17 @@ func LoadConfig() *Config {
 		R2VideoPathPrefix:     getEnv("R2_VIDEO_PATH_PREFIX", "gregorwang/douyin/Postman Agent/"),
 		R2BugReportPathPrefix: getEnv("R2_BUG_REPORT_PATH_PREFIX", "bug-reports/screenshots/"),
 		R2AvatarPathPrefix:    getEnv("R2_AVATAR_PATH_PREFIX", "user-avatars/"),
+
+		// Video Thumbnail Configuration
+		R2ThumbnailPathPrefix: getEnv("R2_THUMBNAIL_PATH_PREFIX", "video-thumbnails/"),
+		ThumbnailExtractor:    getEnv("THUMBNAIL_EXTRACTOR", "auto"),
+		FFmpegPath:            getEnv("FFMPEG_PATH", "ffmpeg"),
+		KeyframeStripFrames:   keyframeStripFrames,
+
+		// Video Duplicate Detection Configuration
+		VideoDuplicatePolicy:            getEnv("VIDEO_DUPLICATE_POLICY", "flag"),
+		VideoDuplicateMinSimilarity:     videoDuplicateMinSimilarity,
+		VideoDuplicateInheritSimilarity: videoDuplicateInheritSimilarity,
 	}
 
 	return AppConfig
@@ -60,7 +60,7 @@ func (h *AdminHandler) ApproveUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.adminService.ApproveUser(userID, req.Status); err != nil {
+	if err := h.adminService.ApproveUser(c.Request.Context(), middleware.GetUserID(c), userID, req.Status); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -105,6 +105,23 @@ func (h *AdminHandler) DeleteUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
 
+// ForceLogoutUser revokes every active session of a user
+func (h *AdminHandler) ForceLogoutUser(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	revoked, err := h.adminService.ForceLogout(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User sessions revoked", "revoked": revoked})
+}
+
 // GetOverviewStats retrieves overall statistics with optional cache refresh
 func (h *AdminHandler) GetOverviewStats(c *gin.Context) {
 	// Check if force refresh is requested
@@ -517,15 +534,21 @@ func (h *AdminHandler) GetUserPermissions(c *gin.Context) {
 		return
 	}
 
-	permissions, err := h.permissionService.GetUserPermissions(userID)
+	grants, err := h.permissionService.GetUserPermissionGrants(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	permissions := make([]string, 0, len(grants))
+	for _, grant := range grants {
+		permissions = append(permissions, grant.PermissionKey)
+	}
+
 	c.JSON(http.StatusOK, models.UserPermissionsResponse{
 		UserID:      userID,
 		Permissions: permissions,
+		Grants:      grants,
 	})
 }
 
@@ -541,7 +564,7 @@ func (h *AdminHandler) GrantPermissions(c *gin.Context) {
 	}
 
 	// Grant permissions
-	err := h.permissionService.GrantPermissions(req.UserID, req.PermissionKeys, adminUserID)
+	err := h.permissionService.GrantPermissions(c.Request.Context(), req.UserID, req.PermissionKeys, adminUserID, req.ExpiresAt, req.Scopes)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -551,6 +574,8 @@ func (h *AdminHandler) GrantPermissions(c *gin.Context) {
 		"message":     "Permissions granted successfully",
 		"user_id":     req.UserID,
 		"permissions": req.PermissionKeys,
+		"expires_at":  req.ExpiresAt,
+		"scopes":      req.Scopes,
 	})
 }
 
@@ -563,7 +588,7 @@ func (h *AdminHandler) RevokePermissions(c *gin.Context) {
 	}
 
 	// Revoke permissions
-	err := h.permissionService.RevokePermissions(req.UserID, req.PermissionKeys)
+	err := h.permissionService.RevokePermissions(c.Request.Context(), req.UserID, req.PermissionKeys, middleware.GetUserID(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -575,3 +600,25 @@ func (h *AdminHandler) RevokePermissions(c *gin.Context) {
 		"permissions": req.PermissionKeys,
 	})
 }
+
+// ListGrantExpirations lists time-bounded grants removed by the expiry sweeper
+func (h *AdminHandler) ListGrantExpirations(c *gin.Context) {
+	var userID *int
+	if raw := c.Query("user_id"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id parameter"})
+			return
+		}
+		userID = &parsed
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	expirations, err := h.permissionService.ListGrantExpirations(userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"expirations": expirations, "count": len(expirations)})
+}
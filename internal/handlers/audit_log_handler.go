@@ -1,22 +1,29 @@
 package handlers
 
 import (
+	"comment-review-platform/internal/config"
 	"comment-review-platform/internal/handlers/base"
 	"comment-review-platform/internal/middleware"
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/services"
+	"comment-review-platform/pkg/database"
 	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AuditLogHandler struct {
 	service *services.AuditLogService
+	chain   *services.AuditChainService
 }
 
 func NewAuditLogHandler() *AuditLogHandler {
 	return &AuditLogHandler{
 		service: services.NewAuditLogService(),
+		chain:   services.NewAuditChainService(database.DB, config.AppConfig.AuditChainSigningKey),
 	}
 }
 
@@ -27,6 +34,15 @@ func (h *AuditLogHandler) ListLogs(c *gin.Context) {
 		return
 	}
 
+	if modules, restricted := middleware.GetPermissionScopes(c, "audit.logs.read", services.PermissionScopeModule); restricted {
+		allowed := restrictToScopes(splitCSV(req.ActionCategories), modules)
+		if len(allowed) == 0 {
+			respondScopeDenied(c)
+			return
+		}
+		req.ActionCategories = strings.Join(allowed, ",")
+	}
+
 	response, err := h.service.QueryLogs(req)
 	if err != nil {
 		base.RespondInternalError(c, base.ErrCodeFetchFailed, err.Error())
@@ -38,7 +54,8 @@ func (h *AuditLogHandler) ListLogs(c *gin.Context) {
 
 func (h *AuditLogHandler) GetLog(c *gin.Context) {
 	id := c.Param("id")
-	entry, err := h.service.GetLogByID(id)
+	// created_at locates entries that retention already moved to the archive
+	entry, err := h.service.GetLogByID(id, c.Query("created_at"))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			base.RespondNotFound(c, "Audit log not found")
@@ -48,6 +65,13 @@ func (h *AuditLogHandler) GetLog(c *gin.Context) {
 		return
 	}
 
+	if modules, restricted := middleware.GetPermissionScopes(c, "audit.logs.read", services.PermissionScopeModule); restricted {
+		if len(restrictToScopes([]string{entry.ActionCategory}, modules)) == 0 {
+			base.RespondNotFound(c, "Audit log not found")
+			return
+		}
+	}
+
 	base.RespondSuccess(c, entry)
 }
 
@@ -64,6 +88,15 @@ func (h *AuditLogHandler) ExportLogs(c *gin.Context) {
 		return
 	}
 
+	if modules, restricted := middleware.GetPermissionScopes(c, "audit.logs.export", services.PermissionScopeModule); restricted {
+		allowed := restrictToScopes(req.ActionCategories, modules)
+		if len(allowed) == 0 {
+			respondScopeDenied(c)
+			return
+		}
+		req.ActionCategories = allowed
+	}
+
 	username := middleware.GetUsername(c)
 	role := middleware.GetRole(c)
 	response, err := h.service.ExportLogs(userID, username, role, req)
@@ -100,3 +133,78 @@ func (h *AuditLogHandler) ListExports(c *gin.Context) {
 
 	base.RespondSuccess(c, response)
 }
+
+// ListArchives lists the R2 archive objects holding audit logs of a range
+func (h *AuditLogHandler) ListArchives(c *gin.Context) {
+	var req struct {
+		StartTime string `form:"start_time" binding:"required"`
+		EndTime   string `form:"end_time" binding:"required"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	archives, err := h.service.ListArchives(req.StartTime, req.EndTime)
+	if err != nil {
+		base.RespondBadRequest(c, base.ErrCodeFetchFailed, err.Error())
+		return
+	}
+
+	base.RespondSuccess(c, gin.H{"data": archives})
+}
+
+// VerifyChain recomputes the audit log hash chains of the requested days and
+// reports every entry that was edited, deleted or reordered
+func (h *AuditLogHandler) VerifyChain(c *gin.Context) {
+	var req models.AuditChainVerifyRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	response, err := h.chain.Verify(req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAuditVerifyRange) {
+			base.RespondBadRequest(c, base.ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		base.RespondInternalError(c, base.ErrCodeFetchFailed, err.Error())
+		return
+	}
+
+	base.RespondSuccess(c, response)
+}
+
+// restrictToScopes narrows the requested values to the granted scope values.
+// An empty request means "everything the grant allows".
+func restrictToScopes(requested, granted []string) []string {
+	if len(requested) == 0 {
+		return granted
+	}
+	allowed := make(map[string]struct{}, len(granted))
+	for _, value := range granted {
+		allowed[value] = struct{}{}
+	}
+	result := make([]string, 0, len(requested))
+	for _, value := range requested {
+		if _, ok := allowed[value]; ok {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func splitCSV(value string) []string {
+	var result []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+func respondScopeDenied(c *gin.Context) {
+	base.RespondError(c, http.StatusForbidden, base.ErrCodePermissionDenied, "Requested modules are outside the scope of your permission grant")
+}
@@ -1,11 +1,10 @@
 package handlers
 
 import (
-	"comment-review-platform/internal/config"
 	"comment-review-platform/internal/middleware"
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/services"
-	jwtpkg "comment-review-platform/pkg/jwt"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -14,12 +13,16 @@ import (
 type AuthHandler struct {
 	authService *services.AuthService
 	profileService *services.ProfileService
+	sessionService *services.SessionService
+	oidcService    *services.OIDCService
 }
 
 func NewAuthHandler() *AuthHandler {
 	return &AuthHandler{
 		authService: services.NewAuthService(),
 		profileService: services.NewProfileService(),
+		sessionService: services.NewSessionService(),
+		oidcService:    services.NewOIDCService(),
 	}
 }
 
@@ -76,12 +79,12 @@ func (h *AuthHandler) LoginWithCode(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "账号未审批"})
 		return
 	}
-    token, err := jwtpkg.GenerateToken(user.ID, user.Username, user.Role, config.AppConfig.JWTSecret)
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
-        return
-    }
-    c.JSON(http.StatusOK, models.LoginResponse{Token: token, User: *user})
+	response, err := h.sessionService.CreateSession(user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // RegisterWithCode 验证码注册
@@ -157,19 +160,142 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Generate JWT token
-	token, err := jwtpkg.GenerateToken(user.ID, user.Username, user.Role, config.AppConfig.JWTSecret)
+	// Start a session: short-lived access token plus rotating refresh token
+	response, err := h.sessionService.CreateSession(user, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.LoginResponse{
-		Token: token,
-		User:  *user,
+	c.JSON(http.StatusOK, response)
+}
+
+// OIDCConfig tells the login page whether single sign-on is available
+func (h *AuthHandler) OIDCConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":       h.oidcService.Enabled(),
+		"provider_name": h.oidcService.ProviderName(),
 	})
 }
 
+// OIDCLogin starts an authorization-code + PKCE login and returns the provider URL
+func (h *AuthHandler) OIDCLogin(c *gin.Context) {
+	authorizationURL, err := h.oidcService.BeginLogin(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, services.ErrOIDCDisabled) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to start single sign-on: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authorizationURL})
+}
+
+// OIDCCallback completes single sign-on with the code and state returned by the provider
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	var req struct {
+		Code  string `json:"code" binding:"required"`
+		State string `json:"state" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.oidcService.CompleteLogin(c.Request.Context(), req.Code, req.State)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrOIDCDisabled):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrOIDCInvalidState):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "单点登录失败: " + err.Error()})
+		}
+		return
+	}
+	if user.Status != "approved" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "账号未审批，请等待管理员审批", "status": user.Status})
+		return
+	}
+
+	response, err := h.sessionService.CreateSession(user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// JWKS publishes the public keys that verify platform access tokens
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, services.TokenKeys().JWKS())
+}
+
+// RefreshToken exchanges a refresh token for a new access/refresh token pair
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.sessionService.Refresh(req.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout revokes the session of the current access token
+func (h *AuthHandler) Logout(c *gin.Context) {
+	sessionID := middleware.GetSessionID(c)
+	if sessionID != "" {
+		if err := h.sessionService.RevokeSession(middleware.GetUserID(c), sessionID, services.SessionRevokeLogout); err != nil && !errors.Is(err, services.ErrSessionNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// ListSessions lists the current user's active sessions (devices)
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.sessionService.ListSessions(middleware.GetUserID(c), middleware.GetSessionID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession logs out one of the current user's devices
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	err := h.sessionService.RevokeSession(middleware.GetUserID(c), c.Param("id"), services.SessionRevokeUser)
+	if err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
 // GetProfile retrieves current user profile
 func (h *AuthHandler) GetProfile(c *gin.Context) {
 	userID := middleware.GetUserID(c)
@@ -231,7 +357,7 @@ func (h *AuthHandler) UploadAvatar(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少头像文件"})
 		return
 	}
-	if err := h.profileService.UpdateAvatar(userID, file); err != nil {
+	if err := h.profileService.UpdateAvatar(c.Request.Context(), userID, file); err != nil {
 		switch err {
 		case services.ErrAvatarTooLarge:
 			c.JSON(http.StatusBadRequest, gin.H{"error": "头像文件过大，请小于1MB"})
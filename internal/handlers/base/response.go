@@ -29,17 +29,18 @@ type SuccessResponse struct {
 
 // 预定义错误码
 const (
-	ErrCodeInvalidRequest    = "INVALID_REQUEST"
-	ErrCodeUnauthorized      = "UNAUTHORIZED"
-	ErrCodePermissionDenied  = "PERMISSION_DENIED"
-	ErrCodeNotFound          = "NOT_FOUND"
-	ErrCodeClaimFailed       = "CLAIM_FAILED"
-	ErrCodeSubmitFailed      = "SUBMIT_FAILED"
-	ErrCodeReturnFailed      = "RETURN_FAILED"
-	ErrCodeFetchFailed       = "FETCH_FAILED"
-	ErrCodeInternalError     = "INTERNAL_ERROR"
-	ErrCodeSQLTimeout        = "SQL_TIMEOUT"
-	ErrCodeRateLimitExceeded = "RATE_LIMIT_EXCEEDED"
+	ErrCodeInvalidRequest          = "INVALID_REQUEST"
+	ErrCodeUnauthorized            = "UNAUTHORIZED"
+	ErrCodePermissionDenied        = "PERMISSION_DENIED"
+	ErrCodeNotFound                = "NOT_FOUND"
+	ErrCodeClaimFailed             = "CLAIM_FAILED"
+	ErrCodeSubmitFailed            = "SUBMIT_FAILED"
+	ErrCodeReturnFailed            = "RETURN_FAILED"
+	ErrCodeFetchFailed             = "FETCH_FAILED"
+	ErrCodeInternalError           = "INTERNAL_ERROR"
+	ErrCodeSQLTimeout              = "SQL_TIMEOUT"
+	ErrCodeRateLimitExceeded       = "RATE_LIMIT_EXCEEDED"
+	ErrCodeInvalidStatusTransition = "INVALID_STATUS_TRANSITION"
 )
 
 // RespondError 返回错误响应
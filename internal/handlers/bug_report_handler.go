@@ -5,10 +5,12 @@ import (
 	"comment-review-platform/internal/middleware"
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/services"
+	"comment-review-platform/pkg/statemachine"
 	"errors"
 	"fmt"
 	"mime/multipart"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,9 +19,9 @@ type BugReportHandler struct {
 	bugReportService *services.BugReportService
 }
 
-func NewBugReportHandler() *BugReportHandler {
+func NewBugReportHandler(notificationService *services.NotificationService) *BugReportHandler {
 	return &BugReportHandler{
-		bugReportService: services.NewBugReportService(),
+		bugReportService: services.NewBugReportService(notificationService),
 	}
 }
 
@@ -47,12 +49,13 @@ func (h *BugReportHandler) Create(c *gin.Context) {
 		ErrorDetails: c.PostForm("error_details"),
 		PageURL:      c.PostForm("page_url"),
 		UserAgent:    c.GetHeader("User-Agent"),
+		TraceIDs:     c.PostFormArray("trace_ids"),
 	}
 	if input.PageURL == "" {
 		input.PageURL = c.GetHeader("X-Page-Url")
 	}
 
-	report, err := h.bugReportService.CreateBugReport(userID, input, files)
+	report, err := h.bugReportService.CreateBugReport(c.Request.Context(), userID, input, files)
 	if err != nil {
 		switch {
 		case errors.Is(err, services.ErrBugReportLimitReached):
@@ -111,3 +114,183 @@ func (h *BugReportHandler) Export(c *gin.Context) {
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
 	c.Data(http.StatusOK, contentType, data)
 }
+
+// ListErrorGroups lists the groups of bug reports sharing server error codes (admin).
+func (h *BugReportHandler) ListErrorGroups(c *gin.Context) {
+	var req models.BugReportErrorGroupRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	response, err := h.bugReportService.ListErrorGroups(req)
+	if err != nil {
+		base.RespondInternalError(c, base.ErrCodeFetchFailed, err.Error())
+		return
+	}
+
+	base.RespondSuccess(c, response)
+}
+
+// ListMine lists the current user's own bug reports with their progress.
+func (h *BugReportHandler) ListMine(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		base.RespondUnauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req models.MyBugReportsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	response, err := h.bugReportService.ListMyReports(userID, req)
+	if err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, err.Error())
+		return
+	}
+
+	base.RespondSuccess(c, response)
+}
+
+// Get returns a bug report with its comments; reporters can only read their own.
+func (h *BugReportHandler) Get(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		base.RespondUnauthorized(c, "User not authenticated")
+		return
+	}
+	id, ok := bugReportID(c)
+	if !ok {
+		return
+	}
+
+	detail, err := h.bugReportService.GetReport(userID, middleware.GetRole(c) == "admin", id)
+	if err != nil {
+		respondBugReportError(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, detail)
+}
+
+// AddComment posts a comment or reply on a bug report.
+func (h *BugReportHandler) AddComment(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		base.RespondUnauthorized(c, "User not authenticated")
+		return
+	}
+	id, ok := bugReportID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateBugReportCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	comment, err := h.bugReportService.AddComment(userID, middleware.GetRole(c) == "admin", id, req)
+	if err != nil {
+		respondBugReportError(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, comment)
+}
+
+// UpdateStatus moves a bug report to a new triage status (admin).
+func (h *BugReportHandler) UpdateStatus(c *gin.Context) {
+	id, ok := bugReportID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateBugReportStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	report, err := h.bugReportService.UpdateStatus(c.Request.Context(), middleware.GetUserID(c), id, req)
+	if err != nil {
+		respondBugReportError(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, report)
+}
+
+// Assign sets or clears the admin handling a bug report (admin).
+func (h *BugReportHandler) Assign(c *gin.Context) {
+	id, ok := bugReportID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AssignBugReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	report, err := h.bugReportService.Assign(c.Request.Context(), middleware.GetUserID(c), id, req.AssigneeID)
+	if err != nil {
+		respondBugReportError(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, report)
+}
+
+// MarkDuplicate links a bug report to the report it duplicates (admin).
+func (h *BugReportHandler) MarkDuplicate(c *gin.Context) {
+	id, ok := bugReportID(c)
+	if !ok {
+		return
+	}
+
+	var req models.MarkBugReportDuplicateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	report, err := h.bugReportService.MarkDuplicate(c.Request.Context(), middleware.GetUserID(c), id, req.DuplicateOf)
+	if err != nil {
+		respondBugReportError(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, report)
+}
+
+func bugReportID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid bug report ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func respondBugReportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrBugReportNotFound):
+		base.RespondNotFound(c, "错误反馈不存在")
+	case errors.Is(err, services.ErrBugReportForbidden):
+		base.RespondError(c, http.StatusForbidden, base.ErrCodePermissionDenied, "无权访问该错误反馈")
+	case errors.Is(err, statemachine.ErrInvalidTransition):
+		base.RespondError(c, http.StatusConflict, base.ErrCodeInvalidStatusTransition, err.Error())
+	case errors.Is(err, services.ErrBugReportAssigneeNotAdmin),
+		errors.Is(err, services.ErrBugReportInvalidDuplicate),
+		errors.Is(err, services.ErrBugReportInvalidParent):
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, err.Error())
+	default:
+		base.RespondInternalError(c, base.ErrCodeInternalError, err.Error())
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"comment-review-platform/internal/handlers/base"
+	"comment-review-platform/internal/middleware"
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommentClusterHandler struct {
+	clusterService *services.CommentClusterService
+}
+
+func NewCommentClusterHandler() *CommentClusterHandler {
+	return &CommentClusterHandler{
+		clusterService: services.NewCommentClusterService(),
+	}
+}
+
+// ListClusters lists open clusters of near-identical pending comments, largest first
+func (h *CommentClusterHandler) ListClusters(c *gin.Context) {
+	minSize, _ := strconv.Atoi(c.DefaultQuery("min_size", "2"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, err := h.clusterService.ListClusters(minSize, page, pageSize)
+	if err != nil {
+		base.RespondInternalError(c, base.ErrCodeFetchFailed, err.Error())
+		return
+	}
+
+	base.RespondSuccess(c, response)
+}
+
+// GetCluster returns a cluster with the members still open for review
+func (h *CommentClusterHandler) GetCluster(c *gin.Context) {
+	clusterID, err := getIntParam(c, "id")
+	if err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid cluster ID")
+		return
+	}
+
+	cluster, err := h.clusterService.GetCluster(clusterID)
+	if err != nil {
+		respondCommentClusterError(c, err, base.ErrCodeFetchFailed)
+		return
+	}
+
+	base.RespondSuccess(c, cluster)
+}
+
+// DecideCluster submits one decision for every member of a cluster except the
+// ones the reviewer deselected
+func (h *CommentClusterHandler) DecideCluster(c *gin.Context) {
+	reviewerID := middleware.GetUserID(c)
+	if reviewerID == 0 {
+		base.RespondUnauthorized(c, "User not authenticated")
+		return
+	}
+
+	clusterID, err := getIntParam(c, "id")
+	if err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid cluster ID")
+		return
+	}
+
+	var req models.DecideCommentClusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	response, err := h.clusterService.DecideCluster(reviewerID, clusterID, req)
+	if err != nil {
+		respondCommentClusterError(c, err, base.ErrCodeSubmitFailed)
+		return
+	}
+
+	// One audit entry per decided comment, as if each had been submitted alone
+	items := make([]middleware.AuditContext, 0, len(response.Decisions))
+	for _, d := range response.Decisions {
+		changes, _ := json.Marshal(gin.H{
+			"cluster_id":       clusterID,
+			"comment_id":       d.CommentID,
+			"review_result_id": d.ReviewResultID,
+			"is_approved":      req.IsApproved,
+			"tags":             req.Tags,
+			"reason":           req.Reason,
+		})
+		items = append(items, middleware.AuditContext{
+			ActionType:        "review.submit",
+			ActionCategory:    "content_moderation",
+			ActionDescription: "整簇审核：提交审核结果",
+			ResourceType:      "review_task",
+			ResourceID:        strconv.Itoa(d.TaskID),
+			Changes:           changes,
+		})
+	}
+	middleware.AddAuditItems(c, items...)
+
+	base.RespondSuccess(c, response)
+}
+
+func respondCommentClusterError(c *gin.Context, err error, code string) {
+	switch {
+	case errors.Is(err, services.ErrCommentClusterNotFound):
+		base.RespondNotFound(c, err.Error())
+	case errors.Is(err, services.ErrCommentClusterNotOpen), errors.Is(err, services.ErrCommentClusterEmpty):
+		base.RespondError(c, http.StatusConflict, code, err.Error())
+	case code == base.ErrCodeFetchFailed:
+		base.RespondInternalError(c, code, err.Error())
+	default:
+		respondSubmitError(c, err)
+	}
+}
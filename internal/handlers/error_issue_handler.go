@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"comment-review-platform/internal/handlers/base"
+	"comment-review-platform/internal/middleware"
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/services"
+	"comment-review-platform/pkg/statemachine"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ErrorIssueHandler struct {
+	errorIssueService *services.ErrorIssueService
+}
+
+func NewErrorIssueHandler() *ErrorIssueHandler {
+	return &ErrorIssueHandler{
+		errorIssueService: services.NewErrorIssueService(),
+	}
+}
+
+// List pages through error issues (admin).
+func (h *ErrorIssueHandler) List(c *gin.Context) {
+	var req models.ErrorIssueListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	response, err := h.errorIssueService.List(req)
+	if err != nil {
+		base.RespondInternalError(c, base.ErrCodeFetchFailed, err.Error())
+		return
+	}
+
+	base.RespondSuccess(c, response)
+}
+
+// Get returns an error issue with its recent occurrences (admin).
+func (h *ErrorIssueHandler) Get(c *gin.Context) {
+	id, ok := errorIssueID(c)
+	if !ok {
+		return
+	}
+
+	detail, err := h.errorIssueService.Get(id)
+	if err != nil {
+		respondErrorIssueError(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, detail)
+}
+
+// UpdateStatus resolves, ignores or reopens an error issue (admin).
+func (h *ErrorIssueHandler) UpdateStatus(c *gin.Context) {
+	id, ok := errorIssueID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateErrorIssueStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	issue, err := h.errorIssueService.UpdateStatus(c.Request.Context(), middleware.GetUserID(c), id, req)
+	if err != nil {
+		respondErrorIssueError(c, err)
+		return
+	}
+
+	base.RespondSuccess(c, issue)
+}
+
+func errorIssueID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid error issue ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func respondErrorIssueError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrErrorIssueNotFound):
+		base.RespondNotFound(c, "错误问题不存在")
+	case errors.Is(err, statemachine.ErrInvalidTransition):
+		base.RespondError(c, http.StatusConflict, base.ErrCodeInvalidStatusTransition, err.Error())
+	default:
+		base.RespondInternalError(c, base.ErrCodeInternalError, err.Error())
+	}
+}
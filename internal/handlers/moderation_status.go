@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"comment-review-platform/internal/handlers/base"
+	"comment-review-platform/internal/repository"
+	"comment-review-platform/internal/services"
+	"comment-review-platform/pkg/statemachine"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ModerationStatusHandler struct {
+	statusService *services.ModerationStatusService
+}
+
+func NewModerationStatusHandler() *ModerationStatusHandler {
+	return &ModerationStatusHandler{
+		statusService: services.NewModerationStatusService(),
+	}
+}
+
+// GetStatusGraph lists the allowed status transitions of comments and videos
+func (h *ModerationStatusHandler) GetStatusGraph(c *gin.Context) {
+	graphs, err := h.statusService.StatusGraphs()
+	if err != nil {
+		base.RespondInternalError(c, base.ErrCodeFetchFailed, err.Error())
+		return
+	}
+
+	base.RespondSuccess(c, gin.H{"graphs": graphs})
+}
+
+// respondSubmitError reports a failed review submit, as a conflict when the
+// content's status does not allow the decision
+func respondSubmitError(c *gin.Context, err error) {
+	if errors.Is(err, statemachine.ErrInvalidTransition) || errors.Is(err, repository.ErrStatusChanged) {
+		base.RespondError(c, http.StatusConflict, base.ErrCodeInvalidStatusTransition, err.Error())
+		return
+	}
+	base.RespondBadRequest(c, base.ErrCodeSubmitFailed, err.Error())
+}
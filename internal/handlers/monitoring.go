@@ -1,31 +1,38 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
 	"database/sql"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"comment-review-platform/internal/config"
+	"comment-review-platform/internal/middleware"
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/services"
+	"comment-review-platform/pkg/prom"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
 
 type MonitoringHandler struct {
-	db      *sql.DB
-	redis   *redis.Client
-	metrics *services.MetricsService
+	db              *sql.DB
+	redis           *redis.Client
+	metrics         *services.MetricsService
+	permissionCache *services.PermissionCache
 }
 
-func NewMonitoringHandler(db *sql.DB, redisClient *redis.Client, metrics *services.MetricsService) *MonitoringHandler {
+func NewMonitoringHandler(db *sql.DB, redisClient *redis.Client, metrics *services.MetricsService, permissionCache *services.PermissionCache) *MonitoringHandler {
 	return &MonitoringHandler{
-		db:      db,
-		redis:   redisClient,
-		metrics: metrics,
+		db:              db,
+		redis:           redisClient,
+		metrics:         metrics,
+		permissionCache: permissionCache,
 	}
 }
 
@@ -105,6 +112,38 @@ func (h *MonitoringHandler) Metrics(c *gin.Context) {
 	})
 }
 
+// Prometheus serves HTTP, runtime, pool and business metrics in the
+// Prometheus text format. When METRICS_TOKEN is set, scrapers must send it
+// as a bearer token.
+func (h *MonitoringHandler) Prometheus(c *gin.Context) {
+	if token := config.AppConfig.MetricsToken; token != "" {
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var body bytes.Buffer
+	if err := h.metrics.WritePrometheus(&body); err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Data(http.StatusOK, prom.ContentType, body.Bytes())
+}
+
+func (h *MonitoringHandler) PermissionCacheStats(c *gin.Context) {
+	if h.permissionCache == nil {
+		c.JSON(http.StatusOK, models.PermissionCacheStats{})
+		return
+	}
+	c.JSON(http.StatusOK, h.permissionCache.Stats())
+}
+
+func (h *MonitoringHandler) AuditWriterStats(c *gin.Context) {
+	c.JSON(http.StatusOK, middleware.AuditWriterStats())
+}
+
 func (h *MonitoringHandler) DailySummary(c *gin.Context) {
 	if h.db == nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "database not initialized"})
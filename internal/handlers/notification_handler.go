@@ -1,10 +1,8 @@
 package handlers
 
 import (
-	"comment-review-platform/internal/config"
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/services"
-	"comment-review-platform/pkg/jwt"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -17,11 +15,13 @@ import (
 
 type NotificationHandler struct {
 	notificationService *services.NotificationService
+	sessionService      *services.SessionService
 }
 
 func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
 	return &NotificationHandler{
 		notificationService: notificationService,
+		sessionService:      services.NewSessionService(),
 	}
 }
 
@@ -35,7 +35,7 @@ func (h *NotificationHandler) StreamSSE(c *gin.Context) {
 	}
 
 	// Validate JWT token
-	claims, err := jwt.ValidateToken(token, config.AppConfig.JWTSecret)
+	claims, err := h.sessionService.ValidateAccessToken(c.Request.Context(), token)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 		return
@@ -229,7 +229,7 @@ func (h *NotificationHandler) GetRecent(c *gin.Context) {
 		return
 	}
 
-	totalCount, err := h.notificationService.GetTotalNotificationCount()
+	totalCount, err := h.notificationService.GetTotalNotificationCount(userID.(int))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -80,7 +80,7 @@ func (h *SecondReviewHandler) SubmitSecondReview(c *gin.Context) {
 	}
 
 	if err := h.secondReviewService.SubmitSecondReview(reviewerID, req); err != nil {
-		base.RespondBadRequest(c, base.ErrCodeSubmitFailed, err.Error())
+		respondSubmitError(c, err)
 		return
 	}
 
@@ -33,7 +33,7 @@ func (h *TaskHandler) ClaimTasks(c *gin.Context) {
 		return
 	}
 
-	tasks, err := h.taskService.ClaimTasks(reviewerID, req.Count)
+	tasks, err := h.taskService.ClaimTasks(c.Request.Context(), reviewerID, req.Count)
 	if err != nil {
 		base.RespondBadRequest(c, base.ErrCodeClaimFailed, err.Error())
 		return
@@ -53,7 +53,7 @@ func (h *TaskHandler) GetMyTasks(c *gin.Context) {
 		return
 	}
 
-	tasks, err := h.taskService.GetMyTasks(reviewerID)
+	tasks, err := h.taskService.GetMyTasks(c.Request.Context(), reviewerID)
 	if err != nil {
 		base.RespondInternalError(c, base.ErrCodeFetchFailed, err.Error())
 		return
@@ -79,8 +79,8 @@ func (h *TaskHandler) SubmitReview(c *gin.Context) {
 		return
 	}
 
-	if err := h.taskService.SubmitReview(reviewerID, req); err != nil {
-		base.RespondBadRequest(c, base.ErrCodeSubmitFailed, err.Error())
+	if err := h.taskService.SubmitReview(c.Request.Context(), reviewerID, req); err != nil {
+		respondSubmitError(c, err)
 		return
 	}
 
@@ -101,7 +101,7 @@ func (h *TaskHandler) SubmitBatchReviews(c *gin.Context) {
 		return
 	}
 
-	if err := h.taskService.SubmitBatchReviews(reviewerID, req.Reviews); err != nil {
+	if err := h.taskService.SubmitBatchReviews(c.Request.Context(), reviewerID, req.Reviews); err != nil {
 		base.RespondBadRequest(c, base.ErrCodeSubmitFailed, err.Error())
 		return
 	}
@@ -126,7 +126,7 @@ func (h *TaskHandler) ReturnTasks(c *gin.Context) {
 		return
 	}
 
-	returnedCount, err := h.taskService.ReturnTasks(reviewerID, req.TaskIDs)
+	returnedCount, err := h.taskService.ReturnTasks(c.Request.Context(), reviewerID, req.TaskIDs)
 	if err != nil {
 		base.RespondBadRequest(c, base.ErrCodeReturnFailed, err.Error())
 		return
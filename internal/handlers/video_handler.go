@@ -5,6 +5,8 @@ import (
 	"comment-review-platform/internal/middleware"
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/services"
+	"errors"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -12,6 +14,8 @@ import (
 
 type VideoHandler struct {
 	videoService        *services.VideoService
+	importService       *services.VideoImportService
+	reconcileService    *services.VideoReviewReconciliationService
 	firstReviewService  *services.VideoFirstReviewService
 	secondReviewService *services.VideoSecondReviewService
 }
@@ -21,9 +25,15 @@ func NewVideoHandler() (*VideoHandler, error) {
 	if err != nil {
 		return nil, err
 	}
+	importService, err := services.NewVideoImportService()
+	if err != nil {
+		return nil, err
+	}
 
 	return &VideoHandler{
 		videoService:        videoService,
+		importService:       importService,
+		reconcileService:    services.NewVideoReviewReconciliationService(),
 		firstReviewService:  services.NewVideoFirstReviewService(),
 		secondReviewService: services.NewVideoSecondReviewService(),
 	}, nil
@@ -31,7 +41,7 @@ func NewVideoHandler() (*VideoHandler, error) {
 
 // Admin endpoints
 
-// ImportVideos imports videos from R2 bucket path
+// ImportVideos starts a background job importing videos from an R2 bucket path
 func (h *VideoHandler) ImportVideos(c *gin.Context) {
 	var req models.ImportVideosRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -39,7 +49,153 @@ func (h *VideoHandler) ImportVideos(c *gin.Context) {
 		return
 	}
 
-	response, err := h.videoService.ImportVideosFromR2(req.R2PathPrefix)
+	job, err := h.importService.CreateJob(req.R2PathPrefix, c.GetInt("user_id"))
+	if err != nil {
+		base.RespondInternalError(c, base.ErrCodeInternalError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ListImportJobs lists video import jobs, newest first
+func (h *VideoHandler) ListImportJobs(c *gin.Context) {
+	var req models.ListVideoImportJobsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	response, err := h.importService.ListJobs(req)
+	if err != nil {
+		base.RespondInternalError(c, base.ErrCodeFetchFailed, err.Error())
+		return
+	}
+
+	base.RespondSuccess(c, response)
+}
+
+// GetImportJob returns the status and progress of a video import job
+func (h *VideoHandler) GetImportJob(c *gin.Context) {
+	jobID, err := getIntParam(c, "id")
+	if err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.importService.GetJob(jobID)
+	if err != nil {
+		respondImportJobError(c, err, base.ErrCodeFetchFailed)
+		return
+	}
+
+	base.RespondSuccess(c, job)
+}
+
+// ListImportJobErrors lists the items a video import job failed to import
+func (h *VideoHandler) ListImportJobErrors(c *gin.Context) {
+	jobID, err := getIntParam(c, "id")
+	if err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid job ID")
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, err := h.importService.ListJobErrors(jobID, page, pageSize)
+	if err != nil {
+		respondImportJobError(c, err, base.ErrCodeFetchFailed)
+		return
+	}
+
+	base.RespondSuccess(c, response)
+}
+
+// CancelImportJob stops a pending or running video import job
+func (h *VideoHandler) CancelImportJob(c *gin.Context) {
+	jobID, err := getIntParam(c, "id")
+	if err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.importService.CancelJob(jobID)
+	if err != nil {
+		respondImportJobError(c, err, base.ErrCodeInvalidRequest)
+		return
+	}
+
+	base.RespondSuccess(c, job)
+}
+
+// ResumeImportJob continues a canceled or failed video import job from its cursor
+func (h *VideoHandler) ResumeImportJob(c *gin.Context) {
+	jobID, err := getIntParam(c, "id")
+	if err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.importService.ResumeJob(jobID)
+	if err != nil {
+		respondImportJobError(c, err, base.ErrCodeInvalidRequest)
+		return
+	}
+
+	base.RespondSuccess(c, job)
+}
+
+func respondImportJobError(c *gin.Context, err error, code string) {
+	if errors.Is(err, services.ErrVideoImportJobNotFound) {
+		base.RespondNotFound(c, err.Error())
+		return
+	}
+	base.RespondBadRequest(c, code, err.Error())
+}
+
+// ProbeVideos probes container metadata for videos that have none yet
+func (h *VideoHandler) ProbeVideos(c *gin.Context) {
+	var req models.ProbeVideosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	response, err := h.videoService.ProbeMissingMetadata(req.AfterID, req.Limit)
+	if err != nil {
+		base.RespondInternalError(c, base.ErrCodeInternalError, err.Error())
+		return
+	}
+
+	base.RespondSuccess(c, response)
+}
+
+// GenerateThumbnails backfills poster frames and keyframe strips for videos that have none
+func (h *VideoHandler) GenerateThumbnails(c *gin.Context) {
+	var req models.GenerateThumbnailsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	response, err := h.videoService.GenerateMissingThumbnails(req.AfterID, req.Limit)
+	if err != nil {
+		base.RespondInternalError(c, base.ErrCodeInternalError, err.Error())
+		return
+	}
+
+	base.RespondSuccess(c, response)
+}
+
+// FingerprintVideos backfills fingerprints and duplicate links for videos that have none
+func (h *VideoHandler) FingerprintVideos(c *gin.Context) {
+	var req models.FingerprintVideosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	response, err := h.videoService.FingerprintMissingVideos(req.AfterID, req.Limit)
 	if err != nil {
 		base.RespondInternalError(c, base.ErrCodeInternalError, err.Error())
 		return
@@ -48,6 +204,24 @@ func (h *VideoHandler) ImportVideos(c *gin.Context) {
 	base.RespondSuccess(c, response)
 }
 
+// ReconcileReviews repairs video review tasks left without a result, second
+// review task or status update. Pass dry_run=true to only report them.
+func (h *VideoHandler) ReconcileReviews(c *gin.Context) {
+	var req models.ReconcileVideoReviewsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	report, err := h.reconcileService.Reconcile(req.DryRun)
+	if err != nil {
+		base.RespondInternalError(c, base.ErrCodeInternalError, err.Error())
+		return
+	}
+
+	base.RespondSuccess(c, report)
+}
+
 // ListVideos lists all videos with pagination
 func (h *VideoHandler) ListVideos(c *gin.Context) {
 	var req models.ListVideosRequest
@@ -105,6 +279,29 @@ func (h *VideoHandler) GetVideo(c *gin.Context) {
 	base.RespondSuccess(c, video)
 }
 
+// GetVideoTimeline returns a video's review history across all stages
+func (h *VideoHandler) GetVideoTimeline(c *gin.Context) {
+	videoID, err := getIntParam(c, "id")
+	if err != nil {
+		base.RespondBadRequest(c, base.ErrCodeInvalidRequest, "Invalid video ID")
+		return
+	}
+
+	video, err := h.videoService.GetVideoByID(videoID)
+	if err != nil {
+		base.RespondNotFound(c, "Video not found")
+		return
+	}
+
+	timeline, err := h.videoService.GetVideoTimeline(video)
+	if err != nil {
+		base.RespondInternalError(c, base.ErrCodeFetchFailed, err.Error())
+		return
+	}
+
+	base.RespondSuccess(c, timeline)
+}
+
 // GenerateVideoURL generates a pre-signed URL for video access
 func (h *VideoHandler) GenerateVideoURL(c *gin.Context) {
 	var req models.GenerateVideoURLRequest
@@ -184,8 +381,8 @@ func (h *VideoHandler) SubmitVideoFirstReview(c *gin.Context) {
 		return
 	}
 
-	if err := h.firstReviewService.SubmitFirstReview(reviewerID, req); err != nil {
-		base.RespondBadRequest(c, base.ErrCodeSubmitFailed, err.Error())
+	if err := h.firstReviewService.SubmitFirstReview(c.Request.Context(), reviewerID, req); err != nil {
+		respondSubmitError(c, err)
 		return
 	}
 
@@ -206,7 +403,7 @@ func (h *VideoHandler) SubmitBatchVideoFirstReviews(c *gin.Context) {
 		return
 	}
 
-	if err := h.firstReviewService.SubmitBatchFirstReviews(reviewerID, req.Reviews); err != nil {
+	if err := h.firstReviewService.SubmitBatchFirstReviews(c.Request.Context(), reviewerID, req.Reviews); err != nil {
 		base.RespondBadRequest(c, base.ErrCodeSubmitFailed, err.Error())
 		return
 	}
@@ -305,8 +502,8 @@ func (h *VideoHandler) SubmitVideoSecondReview(c *gin.Context) {
 		return
 	}
 
-	if err := h.secondReviewService.SubmitSecondReview(reviewerID, req); err != nil {
-		base.RespondBadRequest(c, base.ErrCodeSubmitFailed, err.Error())
+	if err := h.secondReviewService.SubmitSecondReview(c.Request.Context(), reviewerID, req); err != nil {
+		respondSubmitError(c, err)
 		return
 	}
 
@@ -327,7 +524,7 @@ func (h *VideoHandler) SubmitBatchVideoSecondReviews(c *gin.Context) {
 		return
 	}
 
-	if err := h.secondReviewService.SubmitBatchSecondReviews(reviewerID, req.Reviews); err != nil {
+	if err := h.secondReviewService.SubmitBatchSecondReviews(c.Request.Context(), reviewerID, req.Reviews); err != nil {
 		base.RespondBadRequest(c, base.ErrCodeSubmitFailed, err.Error())
 		return
 	}
@@ -4,6 +4,7 @@ import (
 	"comment-review-platform/internal/middleware"
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/services"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -11,11 +12,13 @@ import (
 
 type VideoQueueHandler struct {
 	videoQueueService *services.VideoQueueService
+	videoPoolService  *services.VideoPoolService
 }
 
 func NewVideoQueueHandler() *VideoQueueHandler {
 	return &VideoQueueHandler{
 		videoQueueService: services.NewVideoQueueService(),
+		videoPoolService:  services.NewVideoPoolService(),
 	}
 }
 
@@ -31,7 +34,7 @@ func (h *VideoQueueHandler) ClaimTasks(c *gin.Context) {
 		return
 	}
 
-	tasks, err := h.videoQueueService.ClaimTasks(pool, reviewerID, req.Count)
+	tasks, err := h.videoQueueService.ClaimTasks(c.Request.Context(), pool, reviewerID, req.Count)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -49,7 +52,7 @@ func (h *VideoQueueHandler) GetMyTasks(c *gin.Context) {
 	reviewerID := middleware.GetUserID(c)
 	pool := c.Param("pool")
 
-	tasks, err := h.videoQueueService.GetMyTasks(pool, reviewerID)
+	tasks, err := h.videoQueueService.GetMyTasks(c.Request.Context(), pool, reviewerID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -73,7 +76,7 @@ func (h *VideoQueueHandler) SubmitReview(c *gin.Context) {
 		return
 	}
 
-	if err := h.videoQueueService.SubmitReview(pool, reviewerID, req); err != nil {
+	if err := h.videoQueueService.SubmitReview(c.Request.Context(), pool, reviewerID, req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -93,7 +96,7 @@ func (h *VideoQueueHandler) SubmitBatchReviews(c *gin.Context) {
 		return
 	}
 
-	if err := h.videoQueueService.SubmitBatchReviews(pool, reviewerID, req.Reviews); err != nil {
+	if err := h.videoQueueService.SubmitBatchReviews(c.Request.Context(), pool, reviewerID, req.Reviews); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -116,7 +119,7 @@ func (h *VideoQueueHandler) ReturnTasks(c *gin.Context) {
 		return
 	}
 
-	returnedCount, err := h.videoQueueService.ReturnTasks(pool, reviewerID, req.TaskIDs)
+	returnedCount, err := h.videoQueueService.ReturnTasks(c.Request.Context(), pool, reviewerID, req.TaskIDs)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -135,7 +138,7 @@ func (h *VideoQueueHandler) GetTags(c *gin.Context) {
 
 	tags, err := h.videoQueueService.GetTags(pool)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(videoPoolErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -149,9 +152,86 @@ func (h *VideoQueueHandler) GetPoolStats(c *gin.Context) {
 
 	stats, err := h.videoQueueService.GetPoolStats(pool)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(videoPoolErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, stats)
 }
+
+// GetAllPoolStats retrieves statistics for every configured pool (admin only)
+// GET /api/admin/video-queue/stats
+func (h *VideoQueueHandler) GetAllPoolStats(c *gin.Context) {
+	stats, err := h.videoQueueService.GetAllPoolStats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ListVideoQueuePoolStatsResponse{Pools: stats})
+}
+
+// ListPools lists the active pools of the ladder for reviewers
+// GET /api/video/pools
+func (h *VideoQueueHandler) ListPools(c *gin.Context) {
+	h.listPools(c, false)
+}
+
+// ListAllPools lists every pool, including inactive ones (admin only)
+// GET /api/admin/video-pools
+func (h *VideoQueueHandler) ListAllPools(c *gin.Context) {
+	h.listPools(c, true)
+}
+
+func (h *VideoQueueHandler) listPools(c *gin.Context, includeInactive bool) {
+	pools, err := h.videoPoolService.ListPools(includeInactive)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ListVideoPoolsResponse{Pools: pools})
+}
+
+// CreatePool adds a pool to the ladder (admin only)
+// POST /api/admin/video-pools
+func (h *VideoQueueHandler) CreatePool(c *gin.Context) {
+	var req models.CreateVideoPoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pool, err := h.videoPoolService.CreatePool(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, pool)
+}
+
+// UpdatePool changes a pool's place in the ladder or its limits (admin only)
+// PUT /api/admin/video-pools/{pool}
+func (h *VideoQueueHandler) UpdatePool(c *gin.Context) {
+	var req models.UpdateVideoPoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pool, err := h.videoPoolService.UpdatePool(c.Param("pool"), req)
+	if err != nil {
+		c.JSON(videoPoolErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pool)
+}
+
+func videoPoolErrorStatus(err error, fallback int) int {
+	if errors.Is(err, services.ErrVideoPoolNotFound) {
+		return http.StatusNotFound
+	}
+	return fallback
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -12,9 +13,13 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
+	"comment-review-platform/internal/auditevent"
 	"comment-review-platform/internal/config"
+	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/observability"
+	"comment-review-platform/internal/repository"
 	"comment-review-platform/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -24,6 +29,7 @@ const (
 	auditRequestBodyKey    = "audit_request_body"
 	auditRequestBodyRawKey = "audit_request_body_raw"
 	auditContextKey        = "audit_context"
+	auditItemsKey          = "audit_items"
 	maxAuditPayloadBytes   = 32 * 1024
 	maxAuditTextFieldBytes = 64 * 1024
 )
@@ -83,19 +89,41 @@ type AuditContext struct {
 
 // AuditLogger handles audit log operations
 type AuditLogger struct {
-	db *sql.DB
+	db     *sql.DB
+	writer *AuditWriter
 }
 
 var auditLogger *AuditLogger
 
 // NewAuditLogger creates a new audit logger instance
-func NewAuditLogger(db *sql.DB) *AuditLogger {
-	return &AuditLogger{db: db}
+func NewAuditLogger(db *sql.DB, cfg AuditWriterConfig) *AuditLogger {
+	return &AuditLogger{db: db, writer: NewAuditWriter(db, cfg)}
 }
 
-// InitAuditLogger initializes the global audit logger
-func InitAuditLogger(db *sql.DB) {
-	auditLogger = NewAuditLogger(db)
+// InitAuditLogger initializes the global audit logger and starts its writer
+func InitAuditLogger(db *sql.DB, cfg AuditWriterConfig) {
+	auditLogger = NewAuditLogger(db, cfg)
+	auditLogger.writer.Start()
+	auditevent.SetSink(func(event auditevent.Recorded) {
+		auditLogger.writer.Enqueue(buildAuditEventEntry(event))
+	})
+}
+
+// ShutdownAuditLogger drains queued audit entries; call it after the HTTP
+// server has stopped accepting requests
+func ShutdownAuditLogger(ctx context.Context) error {
+	if auditLogger == nil {
+		return nil
+	}
+	return auditLogger.writer.Shutdown(ctx)
+}
+
+// AuditWriterStats reports the audit writer's queue and throughput counters
+func AuditWriterStats() models.AuditWriterStats {
+	if auditLogger == nil {
+		return models.AuditWriterStats{}
+	}
+	return auditLogger.writer.Stats()
 }
 
 // SetAuditContext allows handlers to set explicit audit metadata
@@ -103,6 +131,19 @@ func SetAuditContext(c *gin.Context, ctx AuditContext) {
 	c.Set(auditContextKey, ctx)
 }
 
+// AddAuditItems records one extra audit entry per item alongside the request's
+// own entry, for requests that act on many resources at once. Item entries
+// share the request's ID, user and outcome but carry their own action,
+// resource and changes.
+func AddAuditItems(c *gin.Context, items ...AuditContext) {
+	if existing, ok := c.Get(auditItemsKey); ok {
+		if list, okCast := existing.([]AuditContext); okCast {
+			items = append(list, items...)
+		}
+	}
+	c.Set(auditItemsKey, items)
+}
+
 // AuditLogMiddleware returns a middleware that logs audit information
 func AuditLogMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -116,6 +157,10 @@ func AuditLogMiddleware() gin.HandlerFunc {
 
 		captureRequestBody(c)
 
+		// Services record what they changed on the request's context
+		ctx, events := auditevent.WithCollector(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+
 		bodyWriter := &bodyLogWriter{
 			ResponseWriter: c.Writer,
 			buffer:         newLimitedBuffer(maxAuditPayloadBytes),
@@ -132,46 +177,48 @@ func AuditLogMiddleware() gin.HandlerFunc {
 
 		// Build audit log entry
 		auditEntry := buildAuditLogEntry(c, startTime, requestID, bodyWriter)
+		eventEntries := applyAuditEvents(&auditEntry, events.Events())
+		itemEntries := buildAuditItemEntries(c, auditEntry)
 
-		// Async write to avoid blocking response
-		go func(logEntry AuditLog) {
-			if err := auditLogger.Save(logEntry); err != nil {
-				observability.Infof(logEntry.RequestID, "Failed to save audit log: %v", err)
-			}
-			if logEntry.StatusCode >= 400 {
-				if logEntry.StatusCode == http.StatusForbidden {
-					return
-				}
-				if svc := getAlertService(); svc != nil {
-					alertEvent := services.AlertEvent{
-						TraceID:          logEntry.RequestID,
-						HTTPMethod:       logEntry.HTTPMethod,
-						Endpoint:         logEntry.Endpoint,
-						StatusCode:       logEntry.StatusCode,
-						OccurredAt:       logEntry.CreatedAt,
-						ErrorCode:        logEntry.ErrorCode,
-						ErrorType:        logEntry.ErrorType,
-						ErrorDescription: logEntry.ErrorDescription,
-						ErrorMessage:     logEntry.ErrorMessage,
-						ErrorStack:       logEntry.ErrorStack,
-						ModuleName:       logEntry.ModuleName,
-						MethodName:       logEntry.MethodName,
-						ServerIP:         logEntry.ServerIP,
-						ServerPort:       logEntry.ServerPort,
-						PageURL:          logEntry.PageURL,
-						UserID:           logEntry.UserID,
-						Username:         logEntry.Username,
-						UserAgent:        logEntry.UserAgent,
-						RequestBody:      logEntry.RequestBody,
-						RequestParams:    logEntry.RequestParams,
-						ResponseBody:     logEntry.ResponseBody,
-					}
-					if err := svc.NotifyFromAuditLog(alertEvent); err != nil {
-						observability.Infof(logEntry.RequestID, "Failed to send alert: %v", err)
-					}
-				}
+		// Queue for the batched writer; the response is never blocked
+		entries := append([]AuditLog{auditEntry}, eventEntries...)
+		auditLogger.writer.Enqueue(append(entries, itemEntries...)...)
+
+		if auditEntry.StatusCode >= 400 && auditEntry.StatusCode != http.StatusForbidden {
+			if svc := getAlertService(); svc != nil {
+				go notifyAuditAlert(svc, auditEntry)
 			}
-		}(auditEntry)
+		}
+	}
+}
+
+// notifyAuditAlert reports a failed request to the alert service
+func notifyAuditAlert(svc *services.AlertService, logEntry AuditLog) {
+	alertEvent := services.AlertEvent{
+		TraceID:          logEntry.RequestID,
+		HTTPMethod:       logEntry.HTTPMethod,
+		Endpoint:         logEntry.Endpoint,
+		StatusCode:       logEntry.StatusCode,
+		OccurredAt:       logEntry.CreatedAt,
+		ErrorCode:        logEntry.ErrorCode,
+		ErrorType:        logEntry.ErrorType,
+		ErrorDescription: logEntry.ErrorDescription,
+		ErrorMessage:     logEntry.ErrorMessage,
+		ErrorStack:       logEntry.ErrorStack,
+		ModuleName:       logEntry.ModuleName,
+		MethodName:       logEntry.MethodName,
+		ServerIP:         logEntry.ServerIP,
+		ServerPort:       logEntry.ServerPort,
+		PageURL:          logEntry.PageURL,
+		UserID:           logEntry.UserID,
+		Username:         logEntry.Username,
+		UserAgent:        logEntry.UserAgent,
+		RequestBody:      logEntry.RequestBody,
+		RequestParams:    logEntry.RequestParams,
+		ResponseBody:     logEntry.ResponseBody,
+	}
+	if err := svc.NotifyFromAuditLog(alertEvent); err != nil {
+		observability.Infof(logEntry.RequestID, "Failed to send alert: %v", err)
 	}
 }
 
@@ -283,76 +330,120 @@ func buildAuditLogEntry(c *gin.Context, startTime time.Time, requestID string, b
 	}
 }
 
-// Save stores an audit log entry to the database
+// buildAuditItemEntries derives the per-item entries added with AddAuditItems
+// from the request's entry. Payloads stay on the request's entry only.
+func buildAuditItemEntries(c *gin.Context, requestEntry AuditLog) []AuditLog {
+	value, ok := c.Get(auditItemsKey)
+	if !ok {
+		return nil
+	}
+	items, ok := value.([]AuditContext)
+	if !ok || len(items) == 0 {
+		return nil
+	}
+
+	entries := make([]AuditLog, 0, len(items))
+	for _, item := range items {
+		entry := requestEntry
+		entry.ActionType = fallbackString(item.ActionType, requestEntry.ActionType)
+		entry.ActionCategory = fallbackString(item.ActionCategory, requestEntry.ActionCategory)
+		entry.ActionDescription = fallbackString(item.ActionDescription, requestEntry.ActionDescription)
+		entry.ResourceType = item.ResourceType
+		entry.ResourceID = item.ResourceID
+		entry.ResourceIDs = item.ResourceIDs
+		entry.Changes = item.Changes
+		entry.RequestBody = nil
+		entry.RequestParams = nil
+		entry.ResponseBody = nil
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// applyAuditEvents merges the domain events services recorded during the
+// request into its audit entries. The first event describes a successful
+// request's own entry; every other event gets an entry of its own sharing the
+// request's ID. Events are only recorded for committed changes, so their
+// entries succeed even when the request as a whole failed.
+func applyAuditEvents(requestEntry *AuditLog, events []auditevent.Recorded) []AuditLog {
+	if len(events) == 0 {
+		return nil
+	}
+	if requestEntry.Result == "success" {
+		setAuditEvent(requestEntry, events[0])
+		events = events[1:]
+	}
+
+	entries := make([]AuditLog, 0, len(events))
+	for _, event := range events {
+		entry := *requestEntry
+		setAuditEvent(&entry, event)
+		entry.CreatedAt = event.OccurredAt
+		entry.Result = "success"
+		entry.ErrorCode = ""
+		entry.ErrorType = ""
+		entry.ErrorDescription = ""
+		entry.ErrorMessage = ""
+		entry.ErrorStack = ""
+		entry.RequestBody = nil
+		entry.RequestParams = nil
+		entry.ResponseBody = nil
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func setAuditEvent(entry *AuditLog, event auditevent.Recorded) {
+	entry.ActionType = string(event.Action)
+	entry.ActionCategory = event.Action.Category()
+	entry.ActionDescription = event.Action.Description()
+	entry.ResourceType = event.ResourceType
+	entry.ResourceID = event.ResourceID
+	entry.ResourceIDs = nil
+	entry.Changes = event.Changes
+}
+
+// buildAuditEventEntry builds the entry of a domain event recorded outside an
+// HTTP request
+func buildAuditEventEntry(event auditevent.Recorded) AuditLog {
+	entry := AuditLog{
+		CreatedAt: event.OccurredAt,
+		UserID:    event.ActorID,
+		RequestID: fallbackString(event.TraceID, newTraceID()),
+		Result:    "success",
+		ServerIP:  resolveLocalIP(),
+	}
+	setAuditEvent(&entry, event)
+	return entry
+}
+
+// Save stores an audit log entry to the database synchronously, bypassing
+// the batched writer
 func (a *AuditLogger) Save(entry AuditLog) error {
 	if a.db == nil {
 		return nil // Database not initialized, skip logging
 	}
+	return insertAuditLogs(a.db, []AuditLog{entry})
+}
 
-	query := `
-		INSERT INTO audit_logs (
-			created_at, user_id, username, user_role,
-			action_type, action_category, action_description, result,
-			endpoint, http_method, status_code, request_id, session_id,
-			request_body, request_params, response_body, ip_address, user_agent,
-			geo_location, device_type, browser, os,
-			resource_type, resource_id, resource_ids, changes,
-			error_code, error_type, error_description, error_message, error_stack, duration_ms,
-			module_name, method_name, server_ip, server_port, page_url
-		) VALUES (
-			$1, $2, $3, $4,
-			$5, $6, $7, $8,
-			$9, $10, $11, $12, $13,
-			$14, $15, $16, $17, $18,
-			$19, $20, $21, $22,
-			$23, $24, $25, $26,
-			$27, $28, $29, $30, $31, $32,
-			$33, $34, $35, $36, $37
-		)
-	`
-
-	_, err := a.db.Exec(
-		query,
-		entry.CreatedAt,
-		entry.UserID,
-		nullableString(entry.Username),
-		nullableString(entry.UserRole),
-		nullableString(entry.ActionType),
-		nullableString(entry.ActionCategory),
-		nullableString(entry.ActionDescription),
-		nullableString(entry.Result),
-		nullableString(entry.Endpoint),
-		nullableString(entry.HTTPMethod),
-		entry.StatusCode,
-		nullableString(entry.RequestID),
-		nullableString(entry.SessionID),
-		nullableJSON(entry.RequestBody),
-		nullableJSON(entry.RequestParams),
-		nullableJSON(entry.ResponseBody),
-		nullableString(entry.IPAddress),
-		nullableString(entry.UserAgent),
-		nullableString(entry.GeoLocation),
-		nullableString(entry.DeviceType),
-		nullableString(entry.Browser),
-		nullableString(entry.OS),
-		nullableString(entry.ResourceType),
-		nullableString(entry.ResourceID),
-		nullableJSON(entry.ResourceIDs),
-		nullableJSON(entry.Changes),
-		nullableString(entry.ErrorCode),
-		nullableString(entry.ErrorType),
-		nullableString(entry.ErrorDescription),
-		nullableString(entry.ErrorMessage),
-		nullableString(entry.ErrorStack),
-		nullableInt(entry.DurationMs),
-		nullableString(entry.ModuleName),
-		nullableString(entry.MethodName),
-		nullableString(entry.ServerIP),
-		nullableString(entry.ServerPort),
-		nullableString(entry.PageURL),
-	)
-
-	return err
+// insertAuditLogs appends entries to the audit log's hash chains with one
+// multi-row insert
+func insertAuditLogs(db *sql.DB, entries []AuditLog) error {
+	if db == nil || len(entries) == 0 {
+		return nil
+	}
+	rows := make([]models.AuditLogEntry, len(entries))
+	for i, entry := range entries {
+		rows[i] = models.AuditLogEntry(entry)
+	}
+	if err := repository.NewAuditChainRepository(db).InsertEntries(rows); err != nil {
+		return err
+	}
+	// Error issue occurrences reference the stored rows by ID
+	for i := range entries {
+		entries[i].ID = rows[i].ID
+	}
+	return nil
 }
 
 // SetCheckedPermission marks that a permission check was performed
@@ -381,24 +472,20 @@ func StartAuditLogCleanup(retentionDays int, interval time.Duration) {
 	log.Printf("Audit log cleanup started: retention=%d days, interval=%v", retentionDays, interval)
 }
 
-// CleanupOldLogs deletes audit logs older than specified retention period
+// CleanupOldLogs moves audit logs older than the retention period to
+// compressed archives in R2, where ListLogs can still reach them. Rows stay in
+// the database while R2 is not configured.
 func (a *AuditLogger) CleanupOldLogs(retentionDays int) error {
 	if a.db == nil {
 		return nil
 	}
 
-	query := `
-		DELETE FROM audit_logs
-		WHERE created_at < NOW() - INTERVAL '1 day' * $1
-	`
-
-	result, err := a.db.Exec(query, retentionDays)
+	rowsAffected, err := services.NewAuditArchiveService(a.db, config.AppConfig.AuditChainSigningKey).ArchiveExpired(retentionDays)
 	if err != nil {
 		return err
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	log.Printf("Cleaned up %d audit logs older than %d days", rowsAffected, retentionDays)
+	log.Printf("Archived %d audit logs older than %d days", rowsAffected, retentionDays)
 	return nil
 }
 
@@ -412,6 +499,8 @@ type auditMetadata struct {
 	Changes           json.RawMessage
 }
 
+// resolveAuditMetadata describes requests whose handlers record no domain
+// event: explicit AuditContext first, then a coarse guess from the route
 func resolveAuditMetadata(c *gin.Context, requestBody interface{}) auditMetadata {
 	if ctxValue, ok := c.Get(auditContextKey); ok {
 		if ctx, okCast := ctxValue.(AuditContext); okCast {
@@ -434,7 +523,6 @@ func resolveAuditMetadata(c *gin.Context, requestBody interface{}) auditMetadata
 
 	resourceType, resourceID := resolveResource(path, c)
 	resourceIDs := extractResourceIDs(requestBody)
-	changes := buildChanges(c.Request.Method, requestBody)
 
 	actionType, category, description := resolveAction(path, c.Request.Method, requestBody)
 
@@ -445,7 +533,6 @@ func resolveAuditMetadata(c *gin.Context, requestBody interface{}) auditMetadata
 		ResourceType:      resourceType,
 		ResourceID:        resourceID,
 		ResourceIDs:       resourceIDs,
-		Changes:           changes,
 	}
 }
 
@@ -557,6 +644,9 @@ func resolveAction(path, method string, requestBody interface{}) (string, string
 		if method == "POST" && strings.HasSuffix(path, "/return") {
 			return "review.return", "content_moderation", "退回任务"
 		}
+		if method == "POST" && strings.HasPrefix(path, "/api/tasks/comment-clusters/") && strings.HasSuffix(path, "/decide") {
+			return "review.cluster_decide", "content_moderation", "整簇审核相似评论"
+		}
 		if strings.Contains(path, "/quality-check") {
 			return "review.quality_check", "content_moderation", "质检操作"
 		}
@@ -599,6 +689,9 @@ func resolveAction(path, method string, requestBody interface{}) (string, string
 	if strings.HasPrefix(path, "/api/admin/audit-logs/export") {
 		return "data.export", "data_operation", "导出审计日志"
 	}
+	if strings.HasPrefix(path, "/api/admin/audit-logs/verify") {
+		return "audit.logs.verify", "system_operation", "校验审计日志哈希链"
+	}
 	if strings.HasPrefix(path, "/api/admin/audit-logs") {
 		return "audit.logs.read", "system_operation", "查询审计日志"
 	}
@@ -665,24 +758,6 @@ func extractResourceIDs(body interface{}) json.RawMessage {
 	return nil
 }
 
-func buildChanges(method string, body interface{}) json.RawMessage {
-	if body == nil {
-		return nil
-	}
-	if method != "PUT" && method != "PATCH" && method != "DELETE" {
-		return nil
-	}
-
-	payload := map[string]interface{}{
-		"after": body,
-	}
-	raw, err := json.Marshal(payload)
-	if err != nil {
-		return nil
-	}
-	return raw
-}
-
 func deriveResult(statusCode int) string {
 	if statusCode == 206 || statusCode == 207 {
 		return "partial"
@@ -1082,11 +1157,19 @@ func maskID(value string) string {
 	return value[:6] + "****" + value[len(value)-4:]
 }
 
+// truncateString cuts value to at most max bytes without splitting a
+// character. Invalid UTF-8, e.g. from a raw header, is replaced first as
+// Postgres refuses to store it.
 func truncateString(value string, max int) string {
+	value = strings.ToValidUTF8(value, "\uFFFD")
 	if len(value) <= max {
 		return value
 	}
-	return value[:max]
+	cut := max
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return value[:cut]
 }
 
 func fallbackString(value, fallback string) string {
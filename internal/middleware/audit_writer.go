@@ -0,0 +1,544 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	maxAuditBatchSize = repository.MaxAuditInsertBatch
+
+	// maxAuditSpillLineBytes bounds one spilled entry; payloads and text
+	// fields are already truncated, so real entries stay far below it
+	maxAuditSpillLineBytes = 16 << 20
+
+	auditSpillPendingFile = "pending.ndjson"
+	auditSpillReplayGlob  = "replay-*.ndjson"
+
+	// Rows Postgres rejects on their own are kept here for inspection rather
+	// than retried forever; the directory is not replayed or size-capped
+	auditDeadLetterDir  = "dead-letter"
+	auditDeadLetterFile = "rejected.ndjson"
+)
+
+// AuditWriterConfig tunes the buffered audit log writer
+type AuditWriterConfig struct {
+	QueueSize     int           // entries held in memory before spilling to disk
+	BatchSize     int           // rows per insert
+	FlushInterval time.Duration // flush a partial batch after this long
+	SpillDir      string        // empty disables spilling; overflow is dropped instead
+	SpillMaxBytes int64
+}
+
+// AuditWriter persists audit entries in batches from a single goroutine.
+// Requests only enqueue; when the queue is full or Postgres fails, entries are
+// appended to NDJSON files in SpillDir and replayed once inserts succeed again.
+// A batch Postgres rejects for its data is split until the offending rows are
+// isolated, and those rows go to a dead-letter file so they cannot block the
+// rest.
+type AuditWriter struct {
+	cfg    AuditWriterConfig
+	insert func([]AuditLog) error
+
+	queue    chan AuditLog
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	closed   atomic.Bool
+
+	spillMu    sync.Mutex
+	spillBytes atomic.Int64
+
+	enqueued      atomic.Int64
+	written       atomic.Int64
+	spilled       atomic.Int64
+	replayed      atomic.Int64
+	dropped       atomic.Int64
+	deadLettered  atomic.Int64
+	failedBatches atomic.Int64
+	lastFlushMs   atomic.Int64
+	lastFlushAt   atomic.Int64
+	lastError     atomic.Value // string
+}
+
+// NewAuditWriter creates a writer that inserts into db; call Start to run it
+func NewAuditWriter(db *sql.DB, cfg AuditWriterConfig) *AuditWriter {
+	return newAuditWriter(cfg, func(entries []AuditLog) error {
+		if err := insertAuditLogs(db, entries); err != nil {
+			return err
+		}
+		trackErrorIssues(entries)
+		return nil
+	})
+}
+
+func newAuditWriter(cfg AuditWriterConfig, insert func([]AuditLog) error) *AuditWriter {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 10000
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 200
+	}
+	if cfg.BatchSize > maxAuditBatchSize {
+		cfg.BatchSize = maxAuditBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	return &AuditWriter{
+		cfg:    cfg,
+		insert: insert,
+		queue:  make(chan AuditLog, cfg.QueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start prepares the spill directory and starts the flush loop
+func (w *AuditWriter) Start() {
+	if w.cfg.SpillDir != "" {
+		if err := os.MkdirAll(w.cfg.SpillDir, 0o755); err != nil {
+			log.Printf("⚠️ Audit spill disabled, cannot create %s: %v", w.cfg.SpillDir, err)
+			w.cfg.SpillDir = ""
+		} else {
+			w.spillBytes.Store(w.spillDirSize())
+		}
+	}
+	go w.run()
+	log.Printf("✅ Audit log writer started (queue=%d, batch=%d, flush every %v)", w.cfg.QueueSize, w.cfg.BatchSize, w.cfg.FlushInterval)
+}
+
+// Enqueue hands entries to the writer without blocking. Entries that do not
+// fit in the queue are spilled to disk, or dropped when spilling is disabled
+// or full. Each entry gets its ID here, so a spilled entry keeps it when it is
+// replayed and a replay of a stored entry is rejected rather than duplicated.
+func (w *AuditWriter) Enqueue(entries ...AuditLog) {
+	var overflow []AuditLog
+	for _, entry := range entries {
+		if entry.ID == "" {
+			entry.ID = uuid.NewString()
+		}
+		if w.closed.Load() {
+			overflow = append(overflow, entry)
+			continue
+		}
+		select {
+		case w.queue <- entry:
+			w.enqueued.Add(1)
+		default:
+			overflow = append(overflow, entry)
+		}
+	}
+	if len(overflow) > 0 {
+		w.spill(overflow)
+	}
+}
+
+// Shutdown stops accepting entries into the queue and waits until everything
+// queued has been inserted or spilled
+func (w *AuditWriter) Shutdown(ctx context.Context) error {
+	w.stopOnce.Do(func() {
+		w.closed.Store(true)
+		close(w.stop)
+	})
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("audit writer drain: %w", ctx.Err())
+	}
+}
+
+// Stats reports queue depth and throughput counters
+func (w *AuditWriter) Stats() models.AuditWriterStats {
+	stats := models.AuditWriterStats{
+		QueueDepth:    len(w.queue),
+		QueueCapacity: cap(w.queue),
+		BatchSize:     w.cfg.BatchSize,
+		Enqueued:      w.enqueued.Load(),
+		Written:       w.written.Load(),
+		Spilled:       w.spilled.Load(),
+		Replayed:      w.replayed.Load(),
+		Dropped:       w.dropped.Load(),
+		DeadLettered:  w.deadLettered.Load(),
+		FailedBatches: w.failedBatches.Load(),
+		SpillEnabled:  w.cfg.SpillDir != "",
+		SpillBytes:    w.spillBytes.Load(),
+		LastFlushMs:   w.lastFlushMs.Load(),
+	}
+	if cap(w.queue) > 0 {
+		stats.QueueUtilization = float64(stats.QueueDepth) / float64(stats.QueueCapacity)
+	}
+	if at := w.lastFlushAt.Load(); at > 0 {
+		t := time.Unix(0, at)
+		stats.LastFlushAt = &t
+	}
+	if msg, ok := w.lastError.Load().(string); ok {
+		stats.LastError = msg
+	}
+	return stats
+}
+
+func (w *AuditWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]AuditLog, 0, w.cfg.BatchSize)
+	flush := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		ok := w.flush(batch)
+		batch = make([]AuditLog, 0, w.cfg.BatchSize)
+		return ok
+	}
+
+	for {
+		select {
+		case entry := <-w.queue:
+			batch = append(batch, entry)
+			if len(batch) >= w.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			if flush() {
+				w.replaySpill()
+			}
+		case <-w.stop:
+			// Drain whatever requests managed to queue before shutdown
+			for {
+				select {
+				case entry := <-w.queue:
+					batch = append(batch, entry)
+					if len(batch) >= w.cfg.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush inserts a batch, spilling it to disk when the insert fails
+func (w *AuditWriter) flush(batch []AuditLog) bool {
+	start := time.Now()
+	rejected, processed, err := w.insertSplitting(batch)
+	w.lastFlushMs.Store(time.Since(start).Milliseconds())
+	w.lastFlushAt.Store(time.Now().UnixNano())
+	w.deadLetter(rejected)
+	if len(rejected) > 0 || err != nil {
+		w.failedBatches.Add(1)
+	}
+	if err != nil {
+		w.lastError.Store(err.Error())
+		log.Printf("⚠️ Error writing %d audit logs, spilling to disk: %v", len(batch)-processed, err)
+		w.spill(batch[processed:])
+		return false
+	}
+	return true
+}
+
+// insertSplitting inserts entries in order. A batch Postgres rejects for its
+// data, e.g. a value it cannot store, is split in halves until the rejected
+// rows are isolated; those are returned instead of being inserted. Any other
+// error, such as a lost connection, stops the insert and is returned with the
+// number of entries processed before it, so entries[processed:] are the ones
+// not yet handled.
+func (w *AuditWriter) insertSplitting(entries []AuditLog) (rejected []AuditLog, processed int, err error) {
+	if len(entries) == 0 {
+		return nil, 0, nil
+	}
+	err = w.insert(entries)
+	if err == nil {
+		w.written.Add(int64(len(entries)))
+		return nil, len(entries), nil
+	}
+	if !isAuditRowError(err) {
+		return nil, 0, err
+	}
+	if len(entries) == 1 {
+		w.lastError.Store(err.Error())
+		log.Printf("⚠️ Audit log %s rejected by Postgres, moving it to the dead-letter file: %v", entries[0].RequestID, err)
+		return entries, 1, nil
+	}
+
+	mid := len(entries) / 2
+	rejected, processed, err = w.insertSplitting(entries[:mid])
+	if err != nil {
+		return rejected, processed, err
+	}
+	rest, restProcessed, err := w.insertSplitting(entries[mid:])
+	return append(rejected, rest...), mid + restProcessed, err
+}
+
+// isAuditRowError reports whether Postgres rejected an insert because of the
+// data in it (data exception or integrity constraint violation), which
+// retrying the same rows cannot fix, rather than failing to run it at all
+func isAuditRowError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code.Class() {
+	case "22", "23":
+		return true
+	}
+	return false
+}
+
+// deadLetter appends rows Postgres rejected to the dead-letter file, outside
+// the replayed spill files
+func (w *AuditWriter) deadLetter(entries []AuditLog) {
+	if len(entries) == 0 {
+		return
+	}
+	if w.cfg.SpillDir == "" {
+		w.dropped.Add(int64(len(entries)))
+		return
+	}
+
+	dir := filepath.Join(w.cfg.SpillDir, auditDeadLetterDir)
+	err := os.MkdirAll(dir, 0o755)
+	if err == nil {
+		var f *os.File
+		f, err = os.OpenFile(filepath.Join(dir, auditDeadLetterFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err == nil {
+			encoder := json.NewEncoder(f)
+			for _, entry := range entries {
+				if err = encoder.Encode(entry); err != nil {
+					break
+				}
+			}
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	}
+	if err != nil {
+		log.Printf("⚠️ Error writing %d rejected audit logs to the dead-letter file: %v", len(entries), err)
+		w.dropped.Add(int64(len(entries)))
+		return
+	}
+	w.deadLettered.Add(int64(len(entries)))
+}
+
+// spill appends entries to the pending spill file
+func (w *AuditWriter) spill(entries []AuditLog) {
+	if w.cfg.SpillDir == "" {
+		w.dropped.Add(int64(len(entries)))
+		return
+	}
+
+	w.spillMu.Lock()
+	defer w.spillMu.Unlock()
+
+	var buf strings.Builder
+	kept := 0
+	for i, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			w.dropped.Add(1)
+			continue
+		}
+		if w.cfg.SpillMaxBytes > 0 && w.spillBytes.Load()+int64(buf.Len()+len(line)+1) > w.cfg.SpillMaxBytes {
+			w.dropped.Add(int64(len(entries) - i))
+			break
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+		kept++
+	}
+	if kept == 0 {
+		return
+	}
+
+	f, err := os.OpenFile(filepath.Join(w.cfg.SpillDir, auditSpillPendingFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err == nil {
+		_, err = f.WriteString(buf.String())
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		log.Printf("⚠️ Error spilling %d audit logs: %v", kept, err)
+		w.dropped.Add(int64(kept))
+		return
+	}
+	w.spillBytes.Add(int64(buf.Len()))
+	w.spilled.Add(int64(kept))
+}
+
+// replaySpill inserts spilled entries back in batches. The pending file is
+// rotated first so new spills never race with the replay; a file that fails
+// midway is rewritten with only the entries not yet inserted. Replay stops at
+// the first file that fails to insert, as Postgres is then unavailable.
+func (w *AuditWriter) replaySpill() {
+	if w.cfg.SpillDir == "" || w.spillBytes.Load() == 0 {
+		return
+	}
+
+	w.spillMu.Lock()
+	pending := filepath.Join(w.cfg.SpillDir, auditSpillPendingFile)
+	if _, err := os.Stat(pending); err == nil {
+		rotated := filepath.Join(w.cfg.SpillDir, fmt.Sprintf("replay-%d.ndjson", time.Now().UnixNano()))
+		if err := os.Rename(pending, rotated); err != nil {
+			log.Printf("⚠️ Error rotating audit spill file: %v", err)
+		}
+	}
+	w.spillMu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(w.cfg.SpillDir, auditSpillReplayGlob))
+	if err != nil {
+		return
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		if err := w.replayFile(file); err != nil {
+			log.Printf("⚠️ Error replaying audit spill %s: %v", filepath.Base(file), err)
+			return
+		}
+	}
+}
+
+func (w *AuditWriter) replayFile(path string) error {
+	entries, size, err := readAuditSpill(path)
+	if err != nil {
+		// A file that cannot be read would fail every replay; set it aside
+		// with the dead letters and go on with the next one
+		log.Printf("⚠️ Moving unreadable audit spill %s to the dead-letter directory: %v", filepath.Base(path), err)
+		return w.deadLetterFile(path)
+	}
+
+	for start := 0; start < len(entries); start += w.cfg.BatchSize {
+		end := start + w.cfg.BatchSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+		rejected, processed, err := w.insertSplitting(entries[start:end])
+		w.deadLetter(rejected)
+		w.replayed.Add(int64(processed - len(rejected)))
+		if len(rejected) > 0 || err != nil {
+			w.failedBatches.Add(1)
+		}
+		if err != nil {
+			w.lastError.Store(err.Error())
+			if rewriteErr := rewriteAuditSpill(path, entries[start+processed:]); rewriteErr != nil {
+				log.Printf("⚠️ Error rewriting audit spill %s: %v", filepath.Base(path), rewriteErr)
+			}
+			w.spillBytes.Store(w.spillDirSize())
+			return err
+		}
+	}
+
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	w.spillBytes.Add(-size)
+	return nil
+}
+
+// deadLetterFile moves a whole spill file into the dead-letter directory
+func (w *AuditWriter) deadLetterFile(path string) error {
+	dir := filepath.Join(w.cfg.SpillDir, auditDeadLetterDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(path, filepath.Join(dir, filepath.Base(path))); err != nil {
+		return err
+	}
+	w.spillBytes.Store(w.spillDirSize())
+	return nil
+}
+
+func (w *AuditWriter) spillDirSize() int64 {
+	var total int64
+	entries, err := os.ReadDir(w.cfg.SpillDir)
+	if err != nil {
+		return 0
+	}
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && !e.IsDir() {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// readAuditSpill reads an NDJSON spill file, skipping lines that no longer
+// decode (e.g. a line cut short by a crash)
+func readAuditSpill(path string) ([]AuditLog, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var entries []AuditLog
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxAuditSpillLineBytes)
+	for scanner.Scan() {
+		var entry AuditLog
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Printf("⚠️ Skipping unreadable audit spill line in %s: %v", filepath.Base(path), err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+	return entries, info.Size(), nil
+}
+
+// rewriteAuditSpill atomically replaces a spill file with the given entries
+func rewriteAuditSpill(path string, entries []AuditLog) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(f)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
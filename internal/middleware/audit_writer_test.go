@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+)
+
+type fakeAuditStore struct {
+	mu      sync.Mutex
+	fail    bool
+	reject  string // request ID of a row Postgres refuses
+	batches [][]AuditLog
+}
+
+func (s *fakeAuditStore) insert(entries []AuditLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("connection refused")
+	}
+	for _, entry := range entries {
+		if s.reject != "" && entry.RequestID == s.reject {
+			return &pq.Error{Code: "22021", Message: "invalid byte sequence for encoding \"UTF8\""}
+		}
+	}
+	s.batches = append(s.batches, append([]AuditLog(nil), entries...))
+	return nil
+}
+
+func (s *fakeAuditStore) setFail(fail bool) {
+	s.mu.Lock()
+	s.fail = fail
+	s.mu.Unlock()
+}
+
+func (s *fakeAuditStore) written() []AuditLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var all []AuditLog
+	for _, b := range s.batches {
+		all = append(all, b...)
+	}
+	return all
+}
+
+func auditEntries(n int) []AuditLog {
+	entries := make([]AuditLog, n)
+	for i := range entries {
+		entries[i] = AuditLog{RequestID: string(rune('a' + i%26)), StatusCode: 200, CreatedAt: time.Unix(int64(i), 0)}
+	}
+	return entries
+}
+
+func TestAuditWriterBatchesAndDrainsOnShutdown(t *testing.T) {
+	store := &fakeAuditStore{}
+	w := newAuditWriter(AuditWriterConfig{QueueSize: 100, BatchSize: 10, FlushInterval: time.Hour}, store.insert)
+	w.Start()
+
+	w.Enqueue(auditEntries(25)...)
+	if err := w.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	if got := len(store.written()); got != 25 {
+		t.Fatalf("wrote %d entries, want 25", got)
+	}
+	for _, b := range store.batches {
+		if len(b) > 10 {
+			t.Errorf("batch of %d exceeds batch size 10", len(b))
+		}
+	}
+	if stats := w.Stats(); stats.Written != 25 || stats.Dropped != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestAuditWriterSpillsWhenQueueIsFull(t *testing.T) {
+	store := &fakeAuditStore{}
+	w := newAuditWriter(AuditWriterConfig{QueueSize: 5, BatchSize: 10, FlushInterval: time.Hour, SpillDir: t.TempDir()}, store.insert)
+	// Not started, so nothing consumes the queue
+	w.Enqueue(auditEntries(8)...)
+
+	stats := w.Stats()
+	if stats.QueueDepth != 5 || stats.Spilled != 3 || stats.Dropped != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	w.replaySpill()
+	if got := len(store.written()); got != 3 {
+		t.Errorf("replayed %d entries, want 3", got)
+	}
+	if stats := w.Stats(); stats.SpillBytes != 0 || stats.Replayed != 3 {
+		t.Errorf("spill not cleared after replay: %+v", stats)
+	}
+}
+
+func TestAuditWriterDropsWithoutSpillDir(t *testing.T) {
+	w := newAuditWriter(AuditWriterConfig{QueueSize: 2, BatchSize: 10, FlushInterval: time.Hour}, (&fakeAuditStore{}).insert)
+	w.Enqueue(auditEntries(5)...)
+
+	if stats := w.Stats(); stats.Dropped != 3 {
+		t.Errorf("dropped %d entries, want 3", stats.Dropped)
+	}
+}
+
+func TestAuditWriterSpillsFailedBatchesAndReplaysThem(t *testing.T) {
+	store := &fakeAuditStore{fail: true}
+	w := newAuditWriter(AuditWriterConfig{QueueSize: 100, BatchSize: 4, FlushInterval: time.Hour, SpillDir: t.TempDir()}, store.insert)
+
+	w.flush(auditEntries(6))
+	if stats := w.Stats(); stats.FailedBatches != 1 || stats.Spilled != 6 {
+		t.Fatalf("unexpected stats after failed flush: %+v", stats)
+	}
+
+	// Still down: the spill file must survive a failed replay
+	w.replaySpill()
+	if len(store.written()) != 0 || w.Stats().SpillBytes == 0 {
+		t.Fatal("failed replay lost the spilled entries")
+	}
+
+	store.setFail(false)
+	w.replaySpill()
+	written := store.written()
+	if len(written) != 6 {
+		t.Fatalf("replayed %d entries, want 6", len(written))
+	}
+	if written[5].CreatedAt.Unix() != 5 {
+		t.Errorf("replay changed entry order or content: %+v", written[5])
+	}
+	if w.Stats().SpillBytes != 0 {
+		t.Error("spill bytes not cleared after replay")
+	}
+}
+
+func TestAuditWriterDeadLettersRowsPostgresRejects(t *testing.T) {
+	store := &fakeAuditStore{reject: "c"}
+	dir := t.TempDir()
+	w := newAuditWriter(AuditWriterConfig{QueueSize: 100, BatchSize: 10, FlushInterval: time.Hour, SpillDir: dir}, store.insert)
+
+	if !w.flush(auditEntries(6)) {
+		t.Fatal("flush reported failure for a batch with one rejected row")
+	}
+	if got := len(store.written()); got != 5 {
+		t.Fatalf("wrote %d entries, want the 5 valid ones", got)
+	}
+	stats := w.Stats()
+	if stats.DeadLettered != 1 || stats.Spilled != 0 || stats.SpillBytes != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	rejected, _, err := readAuditSpill(filepath.Join(dir, auditDeadLetterDir, auditDeadLetterFile))
+	if err != nil || len(rejected) != 1 || rejected[0].RequestID != "c" {
+		t.Fatalf("dead-letter file = %+v, %v", rejected, err)
+	}
+}
+
+func TestAuditWriterReplayDeadLettersRejectedRowsAndContinues(t *testing.T) {
+	store := &fakeAuditStore{fail: true}
+	dir := t.TempDir()
+	w := newAuditWriter(AuditWriterConfig{QueueSize: 100, BatchSize: 4, FlushInterval: time.Hour, SpillDir: dir}, store.insert)
+	w.flush(auditEntries(6))
+
+	// A second spill file behind the first must not be held up by its bad row
+	if err := os.WriteFile(filepath.Join(dir, "replay-0.ndjson"), []byte("{\"request_id\":\"z\"}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w.spillBytes.Store(w.spillDirSize())
+
+	store.mu.Lock()
+	store.fail, store.reject = false, "b"
+	store.mu.Unlock()
+	w.replaySpill()
+
+	if got := len(store.written()); got != 6 {
+		t.Fatalf("replayed %d entries, want 6", got)
+	}
+	if stats := w.Stats(); stats.DeadLettered != 1 || stats.SpillBytes != 0 {
+		t.Errorf("unexpected stats after replay: %+v", stats)
+	}
+}
+
+func TestTruncateStringKeepsValidUTF8(t *testing.T) {
+	for _, value := range []string{"héllo wörld", "Mozilla\xff\xfe/5.0", "日本語のユーザーエージェント"} {
+		for max := 0; max <= len(value)+1; max++ {
+			got := truncateString(value, max)
+			if !utf8.ValidString(got) || len(got) > max {
+				t.Fatalf("truncateString(%q, %d) = %q", value, max, got)
+			}
+		}
+	}
+}
@@ -1,9 +1,8 @@
 package middleware
 
 import (
-	"comment-review-platform/internal/config"
 	"comment-review-platform/internal/repository"
-	jwtpkg "comment-review-platform/pkg/jwt"
+	"comment-review-platform/internal/services"
 	"net/http"
 	"strings"
 	"sync"
@@ -14,6 +13,9 @@ import (
 var (
 	userRepo     *repository.UserRepository
 	userRepoOnce sync.Once
+
+	sessionService     *services.SessionService
+	sessionServiceOnce sync.Once
 )
 
 func getUserRepo() *repository.UserRepository {
@@ -23,6 +25,13 @@ func getUserRepo() *repository.UserRepository {
 	return userRepo
 }
 
+func getSessionService() *services.SessionService {
+	sessionServiceOnce.Do(func() {
+		sessionService = services.NewSessionService()
+	})
+	return sessionService
+}
+
 // AuthMiddleware validates JWT token
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -42,14 +51,14 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		token := parts[1]
-		claims, err := jwtpkg.ValidateToken(token, config.AppConfig.JWTSecret)
+		claims, err := getSessionService().ValidateAccessToken(c.Request.Context(), token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
 			return
 		}
 
-		user, err := getUserRepo().FindByID(claims.UserID)
+		user, err := getUserRepo().FindByID(c.Request.Context(), claims.UserID)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 			c.Abort()
@@ -65,6 +74,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
 
 		// Debug: log user info
 		println("🔍 Auth Debug - User:", claims.Username, "Role:", claims.Role, "ID:", claims.UserID)
@@ -91,6 +101,15 @@ func GetUsername(c *gin.Context) string {
 	return username.(string)
 }
 
+// GetSessionID retrieves the login session ID of the current access token
+func GetSessionID(c *gin.Context) string {
+	sessionID, exists := c.Get("session_id")
+	if !exists {
+		return ""
+	}
+	return sessionID.(string)
+}
+
 // GetRole retrieves role from context
 func GetRole(c *gin.Context) string {
 	role, exists := c.Get("role")
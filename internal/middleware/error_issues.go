@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/services"
+)
+
+// errorIssueTracker is the part of services.ErrorIssueService the audit
+// writer uses
+type errorIssueTracker interface {
+	Track(entries []models.AuditLogEntry) error
+}
+
+var errorIssueService errorIssueTracker
+
+// InitErrorIssueService makes the audit writer group the failed requests it
+// stores into error issues. Call it before InitAuditLogger.
+func InitErrorIssueService(service *services.ErrorIssueService) {
+	if service != nil {
+		errorIssueService = service
+	}
+}
+
+// trackErrorIssues hands the failed requests of a stored batch to the error
+// issue service. It runs after the batch is inserted, so entries that are
+// spilled and replayed later are only counted once they are stored.
+func trackErrorIssues(entries []AuditLog) {
+	if errorIssueService == nil {
+		return
+	}
+	failed := make([]models.AuditLogEntry, 0)
+	for _, entry := range entries {
+		if entry.StatusCode >= http.StatusBadRequest {
+			failed = append(failed, models.AuditLogEntry(entry))
+		}
+	}
+	if len(failed) == 0 {
+		return
+	}
+	if err := errorIssueService.Track(failed); err != nil {
+		log.Printf("⚠️  Error tracking error issues: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"comment-review-platform/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type fakeErrorIssueTracker struct {
+	mu      sync.Mutex
+	tracked []models.AuditLogEntry
+}
+
+func (f *fakeErrorIssueTracker) Track(entries []models.AuditLogEntry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tracked = append(f.tracked, entries...)
+	return nil
+}
+
+// TestTrackErrorIssuesOnMiddlewareEntries 测试中间件生成的审计条目在分组时带有入库 ID
+func TestTrackErrorIssuesOnMiddlewareEntries(t *testing.T) {
+	tracker := &fakeErrorIssueTracker{}
+	previousTracker, previousLogger := errorIssueService, auditLogger
+	errorIssueService = tracker
+	defer func() { errorIssueService, auditLogger = previousTracker, previousLogger }()
+
+	writer := newAuditWriter(AuditWriterConfig{QueueSize: 10, BatchSize: 10, FlushInterval: time.Hour}, func(entries []AuditLog) error {
+		trackErrorIssues(entries)
+		return nil
+	})
+	auditLogger = &AuditLogger{writer: writer}
+	writer.Start()
+
+	router := gin.New()
+	router.Use(TraceMiddleware(), AuditLogMiddleware())
+	router.POST("/api/tasks/:id/submit", func(c *gin.Context) {
+		AddAuditItems(c, AuditContext{ResourceType: "task", ResourceID: "1"}, AuditContext{ResourceType: "task", ResourceID: "2"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database unavailable"})
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/tasks/7/submit", nil))
+
+	if err := writer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	if len(tracker.tracked) != 3 {
+		t.Fatalf("tracked %d entries, want the request's and its 2 items", len(tracker.tracked))
+	}
+	seen := map[string]bool{}
+	for _, entry := range tracker.tracked {
+		if _, err := uuid.Parse(entry.ID); err != nil {
+			t.Errorf("entry %+v has no audit log ID to reference: %v", entry, err)
+		}
+		if seen[entry.ID] {
+			t.Errorf("audit log ID %s used twice", entry.ID)
+		}
+		seen[entry.ID] = true
+	}
+}
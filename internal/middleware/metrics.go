@@ -15,7 +15,8 @@ func InitMetricsService(service *services.MetricsService) {
 	metricsService = service
 }
 
-// MetricsMiddleware records basic API metrics.
+// MetricsMiddleware records basic API metrics for the dashboard and the
+// Prometheus endpoint.
 func MetricsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -25,10 +26,13 @@ func MetricsMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		path := c.FullPath()
+		latency := time.Since(start)
+		route := c.FullPath()
+		path := route
 		if path == "" {
 			path = c.Request.URL.Path
 		}
-		metricsService.Record(c.Request.Method, path, c.Writer.Status(), time.Since(start))
+		metricsService.Record(c.Request.Method, path, c.Writer.Status(), latency)
+		metricsService.ObserveHTTP(c.Request.Method, route, c.Writer.Status(), latency)
 	}
 }
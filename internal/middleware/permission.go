@@ -64,13 +64,11 @@ func RequireAnyPermission(permissionKeys ...string) gin.HandlerFunc {
 			return
 		}
 
-		// Check if user has any of the required permissions
-		for _, key := range permissionKeys {
-			hasPermission, err := getPermissionService().HasPermission(userID, key)
-			if err == nil && hasPermission {
-				c.Next()
-				return
-			}
+		// Check if user has any of the required permissions (single cached lookup)
+		hasPermission, err := getPermissionService().HasAnyPermission(userID, permissionKeys...)
+		if err == nil && hasPermission {
+			c.Next()
+			return
 		}
 
 		// User doesn't have any of the required permissions
@@ -81,3 +79,101 @@ func RequireAnyPermission(permissionKeys ...string) gin.HandlerFunc {
 		c.Abort()
 	}
 }
+
+const permissionScopesKeyPrefix = "permission_scopes:"
+
+// RequirePermissionInAnyScope checks if user has the permission globally or in
+// at least one scope. Handlers must then restrict data with GetPermissionScopes.
+func RequirePermissionInAnyScope(permissionKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		SetCheckedPermission(c, permissionKey)
+		userID := GetUserID(c)
+		if userID == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+
+		global, scopes, err := getPermissionService().PermissionScopes(userID, permissionKey)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			c.Abort()
+			return
+		}
+
+		if !global && len(scopes) == 0 {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":               "Insufficient permissions",
+				"required_permission": permissionKey,
+			})
+			c.Abort()
+			return
+		}
+
+		if !global {
+			c.Set(permissionScopesKeyPrefix+permissionKey, scopes)
+		}
+		c.Next()
+	}
+}
+
+// GetPermissionScopes returns the scope values of the given dimension that a
+// RequirePermissionInAnyScope check limited the request to. restricted is false
+// when the user holds the permission globally.
+func GetPermissionScopes(c *gin.Context, permissionKey, dimension string) (values []string, restricted bool) {
+	raw, ok := c.Get(permissionScopesKeyPrefix + permissionKey)
+	if !ok {
+		return nil, false
+	}
+	scopes, _ := raw.([]string)
+	prefix := dimension + ":"
+	for _, scope := range scopes {
+		if strings.HasPrefix(scope, prefix) {
+			values = append(values, strings.TrimPrefix(scope, prefix))
+		}
+	}
+	return values, true
+}
+
+// RequireVideoPoolPermission guards /video/:pool routes. The per-pool key
+// (queue.video.<pool>.<action>) grants access as before; the pool-agnostic key
+// (queue.video.<action>) grants access globally or when scoped to pool:<pool>.
+func RequireVideoPoolPermission(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pool := c.Param("pool")
+		legacyKey := "queue.video." + pool + "." + action
+		scopedKey := "queue.video." + action
+		scope := services.PermissionScope(services.PermissionScopePool, pool)
+		SetCheckedPermission(c, legacyKey)
+
+		userID := GetUserID(c)
+		if userID == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+
+		svc := getPermissionService()
+		hasPermission, err := svc.HasPermission(userID, legacyKey)
+		if err == nil && !hasPermission {
+			hasPermission, err = svc.HasScopedPermission(userID, scopedKey, scope)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			c.Abort()
+			return
+		}
+
+		if !hasPermission {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":               "Insufficient permissions",
+				"required_permission": legacyKey,
+				"required_scope":      scope,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
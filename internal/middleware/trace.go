@@ -1,10 +1,13 @@
 package middleware
 
 import (
-	"strings"
+	"fmt"
+	"net/http"
+
+	"comment-review-platform/internal/observability"
+	"comment-review-platform/pkg/tracing"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 const (
@@ -15,29 +18,76 @@ const (
 )
 
 func newTraceID() string {
-	return uuid.New().String()
+	return tracing.NewTraceID().String()
 }
 
-// TraceMiddleware ensures every request has a trace_id and exposes it in headers.
+// TraceMiddleware starts the server span of every request and exposes its
+// trace ID in headers. The trace continues an incoming W3C traceparent, or
+// else uses the client's X-Trace-Id when it is a valid trace ID, so the ID
+// shown to users, stored on audit entries and exported to the collector is
+// the same.
 func TraceMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		traceID := strings.TrimSpace(c.GetHeader(traceHeader))
-		if traceID == "" {
-			traceID = strings.TrimSpace(c.GetHeader(requestHeader))
-		}
-		if traceID == "" {
-			traceID = newTraceID()
+		ctx := c.Request.Context()
+		var opts []tracing.StartOption
+		if parent, ok := tracing.Extract(c.Request.Header); ok {
+			ctx = tracing.ContextWithRemoteSpanContext(ctx, parent)
+		} else if traceID, ok := tracing.ParseTraceID(c.GetHeader(traceHeader)); ok {
+			opts = append(opts, tracing.WithTraceID(traceID))
+		} else if traceID, ok := tracing.ParseTraceID(c.GetHeader(requestHeader)); ok {
+			opts = append(opts, tracing.WithTraceID(traceID))
 		}
+		opts = append(opts,
+			tracing.WithKind(tracing.KindServer),
+			tracing.WithAttributes(
+				tracing.String("http.request.method", c.Request.Method),
+				tracing.String("url.path", c.Request.URL.Path),
+				tracing.String("client.address", c.ClientIP()),
+				tracing.String("user_agent.original", c.Request.UserAgent()),
+			),
+		)
+		ctx, span := tracing.Start(ctx, c.Request.Method, opts...)
+		traceID := span.SpanContext().TraceID.String()
 
 		c.Set(traceIDKey, traceID)
 		c.Set(requestIDKey, traceID)
 		c.Writer.Header().Set(traceHeader, traceID)
 		c.Writer.Header().Set(requestHeader, traceID)
+		c.Request = c.Request.WithContext(observability.WithTraceID(ctx, traceID))
+
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				span.SetAttributes(tracing.Int("http.response.status_code", http.StatusInternalServerError))
+				span.SetStatus(tracing.StatusError, fmt.Sprintf("panic: %v", recovered))
+				span.End()
+				panic(recovered)
+			}
+			endServerSpan(c, span)
+		}()
 
 		c.Next()
 	}
 }
 
+// endServerSpan names the span after the matched route and records the
+// response status
+func endServerSpan(c *gin.Context, span *tracing.Span) {
+	if route := c.FullPath(); route != "" {
+		span.SetName(c.Request.Method + " " + route)
+		span.SetAttributes(tracing.String("http.route", route))
+	}
+	status := c.Writer.Status()
+	span.SetAttributes(tracing.Int("http.response.status_code", status))
+	if status >= http.StatusInternalServerError {
+		description := http.StatusText(status)
+		if last := c.Errors.Last(); last != nil {
+			description = last.Error()
+		}
+		span.SetStatus(tracing.StatusError, description)
+	}
+	span.End()
+}
+
 // GetTraceID retrieves trace_id from context.
 func GetTraceID(c *gin.Context) string {
 	if value, ok := c.Get(traceIDKey); ok {
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"comment-review-platform/internal/observability"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestTraceMiddleware_TraceIDs 测试请求的 TraceID 即 OpenTelemetry TraceID
+func TestTraceMiddleware_TraceIDs(t *testing.T) {
+	router := gin.New()
+	router.Use(TraceMiddleware())
+	router.GET("/ping", func(c *gin.Context) {
+		if got := observability.TraceID(c.Request.Context()); got != GetTraceID(c) {
+			t.Errorf("context trace ID %q differs from gin trace ID %q", got, GetTraceID(c))
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{
+			name:    "traceparent",
+			headers: map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "X-Trace-Id": "0af7651916cd43dd8448eb211c80319c"},
+			want:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:    "UUID X-Trace-Id",
+			headers: map[string]string{"X-Trace-Id": "0AF76519-16CD-43DD-8448-EB211C80319C"},
+			want:    "0af7651916cd43dd8448eb211c80319c",
+		},
+		{
+			name:    "X-Request-Id",
+			headers: map[string]string{"X-Request-Id": "0af7651916cd43dd8448eb211c80319c"},
+			want:    "0af7651916cd43dd8448eb211c80319c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if got := w.Header().Get(traceHeader); got != tt.want {
+				t.Errorf("X-Trace-Id = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set(traceHeader, "not-a-trace-id")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if got := w.Header().Get(traceHeader); len(got) != 32 || got == "not-a-trace-id" {
+		t.Errorf("invalid X-Trace-Id should be replaced by a new trace ID, got %q", got)
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"comment-review-platform/pkg/statemachine"
 	"encoding/json"
 	"time"
 )
@@ -167,17 +168,19 @@ type AuditLogQueryFilters struct {
 }
 
 type AuditLogQueryResponse struct {
-	Data       []AuditLogEntry `json:"data"`
-	Total      int             `json:"total"`
-	Page       int             `json:"page"`
-	PageSize   int             `json:"page_size"`
-	TotalPages int             `json:"total_pages"`
+	Data         []AuditLogEntry `json:"data"`
+	Total        int             `json:"total"`
+	Page         int             `json:"page"`
+	PageSize     int             `json:"page_size"`
+	TotalPages   int             `json:"total_pages"`
+	ArchivedDays int             `json:"archived_days,omitempty"` // days of the range read back from R2 archives
 }
 
 type AuditLogExportRequest struct {
 	StartTime        string   `json:"start_time" binding:"required"`
 	EndTime          string   `json:"end_time" binding:"required"`
 	Format           string   `json:"format" binding:"required,oneof=csv json xlsx"`
+	Compression      string   `json:"compression,omitempty" binding:"omitempty,oneof=none gzip"`
 	Fields           []string `json:"fields"`
 	UserID           *int     `json:"user_id,omitempty"`
 	Username         string   `json:"username,omitempty"`
@@ -198,27 +201,44 @@ type AuditLogExportRequest struct {
 	DeviceType       string   `json:"device_type,omitempty"`
 }
 
+// Audit log export job states
+const (
+	AuditExportQueued     = "queued"
+	AuditExportProcessing = "processing"
+	AuditExportCompleted  = "completed"
+	AuditExportFailed     = "failed"
+)
+
+// AuditLogExportResponse acknowledges an export job; its progress and
+// download link are reported by the export list
 type AuditLogExportResponse struct {
-	ExportID    string    `json:"export_id"`
-	DownloadURL string    `json:"download_url"`
-	ExpiresAt   time.Time `json:"expires_at"`
-	RowCount    int       `json:"row_count"`
+	ExportID    string `json:"export_id"`
+	Status      string `json:"status"`
+	Format      string `json:"format"`
+	Compression string `json:"compression"`
 }
 
 type AuditLogExportRecord struct {
-	ID           string          `json:"id"`
-	UserID       int             `json:"user_id"`
-	Username     string          `json:"username"`
-	ExportFormat string          `json:"export_format"`
-	Filters      json.RawMessage `json:"filters,omitempty"`
-	Fields       []string        `json:"fields,omitempty"`
-	Status       string          `json:"status"`
-	RowCount     int             `json:"row_count,omitempty"`
-	FileKey      *string         `json:"file_key,omitempty"`
-	DownloadURL  *string         `json:"download_url,omitempty"`
-	ExpiresAt    *time.Time      `json:"expires_at,omitempty"`
-	ErrorMessage *string         `json:"error_message,omitempty"`
-	CreatedAt    time.Time       `json:"created_at"`
+	ID            string          `json:"id"`
+	UserID        int             `json:"user_id"`
+	Username      string          `json:"username"`
+	ExportFormat  string          `json:"export_format"`
+	Compression   string          `json:"compression"`
+	Filters       json.RawMessage `json:"filters,omitempty"`
+	Fields        []string        `json:"fields,omitempty"`
+	Status        string          `json:"status"`
+	RowCount      int64           `json:"row_count,omitempty"`
+	TotalRows     *int64          `json:"total_rows,omitempty"`
+	ProcessedRows int64           `json:"processed_rows"`
+	SizeBytes     int64           `json:"size_bytes"`
+	FileKey       *string         `json:"file_key,omitempty"`
+	DownloadURL   *string         `json:"download_url,omitempty"`
+	ExpiresAt     *time.Time      `json:"expires_at,omitempty"`
+	ErrorMessage  *string         `json:"error_message,omitempty"`
+	StartedAt     *time.Time      `json:"started_at,omitempty"`
+	CompletedAt   *time.Time      `json:"completed_at,omitempty"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	CreatedAt     time.Time       `json:"created_at"`
 }
 
 type AuditLogExportListResponse struct {
@@ -229,6 +249,92 @@ type AuditLogExportListResponse struct {
 	TotalPages int                    `json:"total_pages"`
 }
 
+// Audit log hash chain. Entries are chained per day of created_at; signed
+// checkpoints pin the chain head and signed tombstones summarize deleted days.
+
+type AuditChainCheckpoint struct {
+	ID        int64     `json:"id"`
+	ChainDay  string    `json:"chain_day"`
+	Seq       int64     `json:"seq"`
+	EntryHash string    `json:"entry_hash"`
+	Signature string    `json:"signature"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AuditChainTombstone struct {
+	ID            int64     `json:"id"`
+	ChainDay      string    `json:"chain_day"`
+	FirstSeq      int64     `json:"first_seq"`
+	LastSeq       int64     `json:"last_seq"`
+	EntryCount    int64     `json:"entry_count"`
+	FirstPrevHash string    `json:"first_prev_hash"`
+	LastHash      string    `json:"last_hash"`
+	OldestAt      time.Time `json:"oldest_at"`
+	NewestAt      time.Time `json:"newest_at"`
+	Signature     string    `json:"signature"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type AuditChainVerifyRequest struct {
+	StartTime string `form:"start_time" binding:"required"`
+	EndTime   string `form:"end_time" binding:"required"`
+}
+
+type AuditChainBreak struct {
+	Day     string `json:"day"`
+	Seq     int64  `json:"seq"`
+	EntryID string `json:"entry_id,omitempty"`
+	Kind    string `json:"kind"`
+	Detail  string `json:"detail"`
+}
+
+type AuditChainDayResult struct {
+	Day         string `json:"day"`
+	Entries     int64  `json:"entries"`
+	LastSeq     int64  `json:"last_seq"`
+	HeadSeq     int64  `json:"head_seq"`
+	Checkpoints int    `json:"checkpoints"`
+	Tombstoned  int64  `json:"tombstoned"` // entries deleted by retention, covered by a signed tombstone
+	Valid       bool   `json:"valid"`
+}
+
+type AuditChainVerifyResponse struct {
+	Valid     bool                  `json:"valid"`
+	Signed    bool                  `json:"signed"` // false when no signing key is configured; signatures were not checked
+	Entries   int64                 `json:"entries"`
+	Unchained int64                 `json:"unchained"` // rows written before chaining, which cannot be verified
+	Days      []AuditChainDayResult `json:"days"`
+	Breaks    []AuditChainBreak     `json:"breaks"`
+	CheckedAt time.Time             `json:"checked_at"`
+}
+
+// AuditLogArchive is one gzip-compressed NDJSON object in R2 holding audit
+// logs that retention moved out of the hot table
+type AuditLogArchive struct {
+	ID         int64     `json:"id"`
+	ArchiveDay string    `json:"archive_day"`
+	Part       int       `json:"part"`
+	ObjectKey  string    `json:"object_key"`
+	EntryCount int64     `json:"entry_count"`
+	FirstSeq   *int64    `json:"first_seq,omitempty"` // chain range covered, nil for rows written before chaining
+	LastSeq    *int64    `json:"last_seq,omitempty"`
+	OldestAt   time.Time `json:"oldest_at"`
+	NewestAt   time.Time `json:"newest_at"`
+	SizeBytes  int64     `json:"size_bytes"`
+	SHA256     string    `json:"sha256"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AuditLogArchiveRecord is one line of an archive object: the entry as it
+// was stored plus its chain link, so archived days can still be verified
+type AuditLogArchiveRecord struct {
+	AuditLogEntry
+	ChainDay  string `json:"chain_day,omitempty"`
+	ChainSeq  int64  `json:"chain_seq,omitempty"`
+	PrevHash  string `json:"prev_hash,omitempty"`
+	EntryHash string `json:"entry_hash,omitempty"`
+}
+
 // Request/Response DTOs
 
 type RegisterRequest struct {
@@ -242,8 +348,32 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	SessionID    string    `json:"session_id"`
+	User         User      `json:"user"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// UserSession is a server-side login session backing a rotating refresh token.
+type UserSession struct {
+	ID               string     `json:"id"`
+	UserID           int        `json:"user_id"`
+	RefreshTokenHash string     `json:"-"`
+	AccessJTI        string     `json:"-"`
+	AccessExpiresAt  time.Time  `json:"-"`
+	UserAgent        *string    `json:"user_agent,omitempty"`
+	IPAddress        *string    `json:"ip_address,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	LastUsedAt       time.Time  `json:"last_used_at"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason    *string    `json:"revoked_reason,omitempty"`
+	Current          bool       `json:"current"`
 }
 
 type UpdateProfileRequest struct {
@@ -283,6 +413,68 @@ type BatchSubmitRequest struct {
 	Reviews []SubmitReviewRequest `json:"reviews" binding:"required,dive"`
 }
 
+// Comment cluster statuses
+const (
+	CommentClusterOpen    = "open"
+	CommentClusterDecided = "decided" // decided as a whole by a reviewer
+	CommentClusterClosed  = "closed"  // every member was reviewed on its own
+)
+
+// CommentCluster groups pending first review comments with near-identical text
+type CommentCluster struct {
+	ID                   int                    `json:"id"`
+	RepresentativeTaskID int                    `json:"representative_task_id"`
+	RepresentativeText   string                 `json:"representative_text"`
+	Status               string                 `json:"status"`
+	PendingCount         int                    `json:"pending_count"`
+	DecidedBy            *int                   `json:"decided_by,omitempty"`
+	DecidedAt            *time.Time             `json:"decided_at,omitempty"`
+	CreatedAt            time.Time              `json:"created_at"`
+	UpdatedAt            time.Time              `json:"updated_at"`
+	Members              []CommentClusterMember `json:"members,omitempty"`
+}
+
+// CommentClusterMember is a pending review task in a cluster
+type CommentClusterMember struct {
+	TaskID     int       `json:"task_id"`
+	CommentID  int64     `json:"comment_id"`
+	Text       string    `json:"text"`
+	Similarity float64   `json:"similarity"`
+	Status     string    `json:"status"`
+	ReviewerID *int      `json:"reviewer_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ListCommentClustersResponse struct {
+	Data       []CommentCluster `json:"data"`
+	Total      int              `json:"total"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"page_size"`
+	TotalPages int              `json:"total_pages"`
+}
+
+type DecideCommentClusterRequest struct {
+	IsApproved     bool     `json:"is_approved"`
+	Tags           []string `json:"tags"`
+	Reason         string   `json:"reason" binding:"max=2000"`
+	ExcludeTaskIDs []int    `json:"exclude_task_ids"`
+}
+
+// CommentClusterDecision is the review result created for one cluster member
+type CommentClusterDecision struct {
+	TaskID         int   `json:"task_id"`
+	CommentID      int64 `json:"comment_id"`
+	ReviewResultID int   `json:"review_result_id"`
+}
+
+type DecideCommentClusterResponse struct {
+	ClusterID     int                      `json:"cluster_id"`
+	ClusterStatus string                   `json:"cluster_status"`
+	Decisions     []CommentClusterDecision `json:"decisions"`
+	ExcludedCount int                      `json:"excluded_count"`
+	SkippedCount  int                      `json:"skipped_count"` // members claimed by another reviewer
+}
+
 type ApproveUserRequest struct {
 	Status string `json:"status" binding:"required,oneof=approved rejected"`
 }
@@ -669,23 +861,24 @@ type SearchTasksRequest struct {
 
 // TaskSearchResult represents a complete task with review result
 type TaskSearchResult struct {
-	ID                int        `json:"id"`
-	QueueName         string     `json:"queue_name"`
-	ContentType       string     `json:"content_type"`
-	ContentID         int64      `json:"content_id"`
-	ContentText       string     `json:"content_text"`
-	ReviewerID        *int       `json:"reviewer_id,omitempty"`
-	ReviewerUsername  *string    `json:"reviewer_username,omitempty"`
-	Status            string     `json:"status"`
-	ClaimedAt         *time.Time `json:"claimed_at,omitempty"`
-	CompletedAt       *time.Time `json:"completed_at,omitempty"`
-	CreatedAt         time.Time  `json:"created_at"`
-	Decision          *string    `json:"decision,omitempty"`
-	Tags              []string   `json:"tags,omitempty"`
-	Reason            *string    `json:"reason,omitempty"`
-	Pool              *string    `json:"pool,omitempty"`
-	OverallScore      *int       `json:"overall_score,omitempty"`
-	TrafficPoolResult *string    `json:"traffic_pool_result,omitempty"`
+	ID                int               `json:"id"`
+	QueueName         string            `json:"queue_name"`
+	ContentType       string            `json:"content_type"`
+	ContentID         int64             `json:"content_id"`
+	ContentText       string            `json:"content_text"`
+	ReviewerID        *int              `json:"reviewer_id,omitempty"`
+	ReviewerUsername  *string           `json:"reviewer_username,omitempty"`
+	Status            string            `json:"status"`
+	ClaimedAt         *time.Time        `json:"claimed_at,omitempty"`
+	CompletedAt       *time.Time        `json:"completed_at,omitempty"`
+	CreatedAt         time.Time         `json:"created_at"`
+	Decision          *string           `json:"decision,omitempty"`
+	Tags              []string          `json:"tags,omitempty"`
+	Reason            *string           `json:"reason,omitempty"`
+	Pool              *string           `json:"pool,omitempty"`
+	OverallScore      *int              `json:"overall_score,omitempty"`
+	TrafficPoolResult *string           `json:"traffic_pool_result,omitempty"`
+	Annotations       []VideoAnnotation `json:"annotations,omitempty"` // Video review tasks only
 }
 
 // SearchTasksResponse for paginated search results
@@ -793,6 +986,8 @@ type Notification struct {
 	CreatedBy int       `json:"created_by"`
 	CreatedAt time.Time `json:"created_at"`
 	IsGlobal  bool      `json:"is_global"`
+	// RecipientID is the only user who sees a notification that is not global
+	RecipientID *int `json:"recipient_id,omitempty"`
 }
 
 // UserNotification represents user's read status for notifications
@@ -854,35 +1049,196 @@ type BugReport struct {
 	PageURL      string                `json:"page_url,omitempty"`
 	UserAgent    string                `json:"user_agent,omitempty"`
 	Screenshots  []BugReportScreenshot `json:"screenshots"`
+	TraceIDs     []string              `json:"trace_ids"`
+	Status       string                `json:"status"`
+	AssigneeID   *int                  `json:"assignee_id,omitempty"`
+	DuplicateOf  *int                  `json:"duplicate_of,omitempty"`
+	Resolution   string                `json:"resolution,omitempty"`
+	ResolvedAt   *time.Time            `json:"resolved_at,omitempty"`
 	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
 }
 
 // BugReportAdminRecord is a row for admin listing
 type BugReportAdminRecord struct {
-	ID           int                   `json:"id"`
-	UserID       int                   `json:"user_id"`
-	Username     string                `json:"username"`
-	Title        string                `json:"title"`
-	Description  string                `json:"description"`
-	ErrorDetails string                `json:"error_details,omitempty"`
-	PageURL      string                `json:"page_url,omitempty"`
-	UserAgent    string                `json:"user_agent,omitempty"`
-	Screenshots  []BugReportScreenshot `json:"screenshots"`
-	CreatedAt    time.Time             `json:"created_at"`
+	ID               int                   `json:"id"`
+	UserID           int                   `json:"user_id"`
+	Username         string                `json:"username"`
+	Title            string                `json:"title"`
+	Description      string                `json:"description"`
+	ErrorDetails     string                `json:"error_details,omitempty"`
+	PageURL          string                `json:"page_url,omitempty"`
+	UserAgent        string                `json:"user_agent,omitempty"`
+	Screenshots      []BugReportScreenshot `json:"screenshots"`
+	TraceIDs         []string              `json:"trace_ids"`
+	ErrorSignature   string                `json:"error_signature,omitempty"`
+	Status           string                `json:"status"`
+	AssigneeID       *int                  `json:"assignee_id,omitempty"`
+	AssigneeUsername string                `json:"assignee_username,omitempty"`
+	DuplicateOf      *int                  `json:"duplicate_of,omitempty"`
+	Resolution       string                `json:"resolution,omitempty"`
+	ResolvedAt       *time.Time            `json:"resolved_at,omitempty"`
+	CommentCount     int                   `json:"comment_count"`
+	CreatedAt        time.Time             `json:"created_at"`
+	UpdatedAt        time.Time             `json:"updated_at"`
 }
 
 // BugReportAdminItem is the API response format for admin listing
 type BugReportAdminItem struct {
-	ID           int                       `json:"id"`
-	UserID       int                       `json:"user_id"`
-	Username     string                    `json:"username"`
-	Title        string                    `json:"title"`
-	Description  string                    `json:"description"`
-	ErrorDetails string                    `json:"error_details,omitempty"`
-	PageURL      string                    `json:"page_url,omitempty"`
-	UserAgent    string                    `json:"user_agent,omitempty"`
-	Screenshots  []BugReportScreenshotView `json:"screenshots"`
-	CreatedAt    time.Time                 `json:"created_at"`
+	ID               int                       `json:"id"`
+	UserID           int                       `json:"user_id"`
+	Username         string                    `json:"username"`
+	Title            string                    `json:"title"`
+	Description      string                    `json:"description"`
+	ErrorDetails     string                    `json:"error_details,omitempty"`
+	PageURL          string                    `json:"page_url,omitempty"`
+	UserAgent        string                    `json:"user_agent,omitempty"`
+	Screenshots      []BugReportScreenshotView `json:"screenshots"`
+	TraceIDs         []string                  `json:"trace_ids"`
+	ErrorSignature   string                    `json:"error_signature,omitempty"`
+	LinkedErrors     []BugReportErrorLink      `json:"linked_errors,omitempty"`
+	Status           string                    `json:"status"`
+	AssigneeID       *int                      `json:"assignee_id,omitempty"`
+	AssigneeUsername string                    `json:"assignee_username,omitempty"`
+	DuplicateOf      *int                      `json:"duplicate_of,omitempty"`
+	Resolution       string                    `json:"resolution,omitempty"`
+	ResolvedAt       *time.Time                `json:"resolved_at,omitempty"`
+	CommentCount     int                       `json:"comment_count"`
+	CreatedAt        time.Time                 `json:"created_at"`
+	UpdatedAt        time.Time                 `json:"updated_at"`
+}
+
+// BugReportErrorLink is a failed request linked to a bug report by trace ID,
+// copied from its audit log entry
+type BugReportErrorLink struct {
+	TraceID      string    `json:"trace_id"`
+	AuditLogID   string    `json:"audit_log_id"`
+	OccurredAt   time.Time `json:"occurred_at"`
+	Endpoint     string    `json:"endpoint,omitempty"`
+	HTTPMethod   string    `json:"http_method,omitempty"`
+	StatusCode   int       `json:"status_code"`
+	ErrorCode    string    `json:"error_code,omitempty"`
+	ErrorType    string    `json:"error_type,omitempty"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	ErrorStack   string    `json:"error_stack,omitempty"`
+}
+
+// BugReportErrorGroup summarizes the reports sharing an error signature
+type BugReportErrorGroup struct {
+	ErrorSignature  string    `json:"error_signature"`
+	ErrorCodes      []string  `json:"error_codes"`
+	ReportCount     int       `json:"report_count"`
+	OpenCount       int       `json:"open_count"`
+	ReporterCount   int       `json:"reporter_count"`
+	FirstReportedAt time.Time `json:"first_reported_at"`
+	LastReportedAt  time.Time `json:"last_reported_at"`
+	LatestReportID  int       `json:"latest_report_id"`
+}
+
+// BugReportErrorGroupResponse provides paginated error groups
+type BugReportErrorGroupResponse struct {
+	Data       []BugReportErrorGroup `json:"data"`
+	Total      int                   `json:"total"`
+	Page       int                   `json:"page"`
+	PageSize   int                   `json:"page_size"`
+	TotalPages int                   `json:"total_pages"`
+}
+
+// BugReportErrorGroupRequest pages through error groups, optionally only
+// those with open reports
+type BugReportErrorGroupRequest struct {
+	OpenOnly bool `form:"open_only"`
+	Page     int  `form:"page"`
+	PageSize int  `form:"page_size"`
+}
+
+// ErrorIssue groups the failed requests sharing a fingerprint
+type ErrorIssue struct {
+	ID                 int        `json:"id"`
+	Fingerprint        string     `json:"fingerprint"`
+	HTTPMethod         string     `json:"http_method"`
+	Endpoint           string     `json:"endpoint"`
+	ErrorCode          string     `json:"error_code"`
+	ErrorType          string     `json:"error_type,omitempty"`
+	StackFrames        []string   `json:"stack_frames"`
+	StatusCode         int        `json:"status_code"`
+	Title              string     `json:"title,omitempty"`
+	LastTraceID        string     `json:"last_trace_id,omitempty"`
+	Status             string     `json:"status"`
+	OccurrenceCount    int64      `json:"occurrence_count"`
+	AffectedUsers      int        `json:"affected_users"`
+	FirstSeenAt        time.Time  `json:"first_seen_at"`
+	LastSeenAt         time.Time  `json:"last_seen_at"`
+	ResolvedAt         *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy         *int       `json:"resolved_by,omitempty"`
+	ResolvedByUsername string     `json:"resolved_by_username,omitempty"`
+	RegressedAt        *time.Time `json:"regressed_at,omitempty"`
+	RegressionCount    int        `json:"regression_count"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// ErrorIssueOccurrence is one failed request grouped into an issue
+type ErrorIssueOccurrence struct {
+	AuditLogID   string    `json:"audit_log_id"`
+	TraceID      string    `json:"trace_id,omitempty"`
+	UserID       *int      `json:"user_id,omitempty"`
+	Username     string    `json:"username,omitempty"`
+	StatusCode   int       `json:"status_code"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	OccurredAt   time.Time `json:"occurred_at"`
+}
+
+// ErrorIssueDetail is an issue with its latest stack and recent occurrences
+type ErrorIssueDetail struct {
+	ErrorIssue
+	SampleStack       string                 `json:"sample_stack,omitempty"`
+	RecentOccurrences []ErrorIssueOccurrence `json:"recent_occurrences"`
+}
+
+// ErrorIssueListRequest filters and pages error issues. Sort is one of
+// last_seen (default), first_seen, occurrences or users.
+type ErrorIssueListRequest struct {
+	Status    string `form:"status"`
+	ErrorCode string `form:"error_code"`
+	Keyword   string `form:"keyword"`
+	Sort      string `form:"sort"`
+	Page      int    `form:"page"`
+	PageSize  int    `form:"page_size"`
+}
+
+// ErrorIssueListResponse provides paginated error issues
+type ErrorIssueListResponse struct {
+	Data       []ErrorIssue `json:"data"`
+	Total      int          `json:"total"`
+	Page       int          `json:"page"`
+	PageSize   int          `json:"page_size"`
+	TotalPages int          `json:"total_pages"`
+}
+
+// UpdateErrorIssueStatusRequest resolves, ignores or reopens an issue
+type UpdateErrorIssueStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=open resolved ignored"`
+}
+
+// BugReportDetail is a bug report with its comment threads
+type BugReportDetail struct {
+	BugReportAdminItem
+	Comments []BugReportComment `json:"comments"`
+}
+
+// BugReportComment is a comment on a bug report; Replies holds the comments
+// answering it, oldest first
+type BugReportComment struct {
+	ID             int                `json:"id"`
+	ReportID       int                `json:"report_id"`
+	ParentID       *int               `json:"parent_id,omitempty"`
+	AuthorID       int                `json:"author_id"`
+	AuthorUsername string             `json:"author_username"`
+	AuthorRole     string             `json:"author_role"`
+	Body           string             `json:"body"`
+	Internal       bool               `json:"internal"`
+	CreatedAt      time.Time          `json:"created_at"`
+	Replies        []BugReportComment `json:"replies"`
 }
 
 // BugReportListResponse provides paginated admin results
@@ -896,32 +1252,77 @@ type BugReportListResponse struct {
 
 // BugReportQueryRequest represents search and filter parameters for admin list
 type BugReportQueryRequest struct {
-	StartTime string `form:"start_time"`
-	EndTime   string `form:"end_time"`
-	UserID    int    `form:"user_id"`
-	Username  string `form:"username"`
-	Keyword   string `form:"keyword"`
-	Page      int    `form:"page"`
-	PageSize  int    `form:"page_size"`
+	StartTime  string `form:"start_time"`
+	EndTime    string `form:"end_time"`
+	UserID     int    `form:"user_id"`
+	Username   string `form:"username"`
+	Keyword    string `form:"keyword"`
+	Status     string `form:"status"`
+	AssigneeID int    `form:"assignee_id"`
+	// ErrorSignature lists the reports of one error group
+	ErrorSignature string `form:"error_signature"`
+	Page           int    `form:"page"`
+	PageSize       int    `form:"page_size"`
+}
+
+// MyBugReportsRequest pages through the current user's own bug reports
+type MyBugReportsRequest struct {
+	Status   string `form:"status"`
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
 }
 
 // BugReportExportRequest represents export parameters for admin
 type BugReportExportRequest struct {
-	StartTime string `json:"start_time"`
-	EndTime   string `json:"end_time"`
-	UserID    int    `json:"user_id"`
-	Username  string `json:"username"`
-	Keyword   string `json:"keyword"`
-	Format    string `json:"format"`
+	StartTime      string `json:"start_time"`
+	EndTime        string `json:"end_time"`
+	UserID         int    `json:"user_id"`
+	Username       string `json:"username"`
+	Keyword        string `json:"keyword"`
+	Status         string `json:"status"`
+	AssigneeID     int    `json:"assignee_id"`
+	ErrorSignature string `json:"error_signature"`
+	Format         string `json:"format"`
 }
 
 // BugReportQueryFilters is a parsed filter set for repository queries
 type BugReportQueryFilters struct {
-	StartTime *time.Time
-	EndTime   *time.Time
-	UserID    *int
-	Username  string
-	Keyword   string
+	StartTime      *time.Time
+	EndTime        *time.Time
+	UserID         *int
+	Username       string
+	Keyword        string
+	Status         string
+	AssigneeID     *int
+	ErrorSignature string
+	// PublicCommentsOnly leaves internal notes out of CommentCount
+	PublicCommentsOnly bool
+}
+
+// UpdateBugReportStatusRequest moves a bug report through triage; Resolution
+// is kept when the report is closed
+type UpdateBugReportStatusRequest struct {
+	Status     string `json:"status" binding:"required,oneof=new triaged in_progress resolved wontfix"`
+	Resolution string `json:"resolution" binding:"max=2000"`
+}
+
+// AssignBugReportRequest sets the admin handling a bug report; null unassigns it
+type AssignBugReportRequest struct {
+	AssigneeID *int `json:"assignee_id"`
+}
+
+// MarkBugReportDuplicateRequest links a bug report to the report it
+// duplicates; null removes the link
+type MarkBugReportDuplicateRequest struct {
+	DuplicateOf *int `json:"duplicate_of"`
+}
+
+// CreateBugReportCommentRequest adds a comment, or a reply when ParentID is
+// set. Internal notes can only be written by admins.
+type CreateBugReportCommentRequest struct {
+	Body     string `json:"body" binding:"required,max=5000"`
+	ParentID *int   `json:"parent_id"`
+	Internal bool   `json:"internal"`
 }
 
 // CreateBugReportInput captures sanitized bug report fields
@@ -931,6 +1332,9 @@ type CreateBugReportInput struct {
 	ErrorDetails string `json:"error_details"`
 	PageURL      string `json:"page_url"`
 	UserAgent    string `json:"user_agent"`
+	// TraceIDs are the trace IDs of the requests that recently failed in
+	// the reporter's browser
+	TraceIDs []string `json:"trace_ids"`
 }
 
 // SSEMessage represents a message sent via Server-Sent Events
@@ -1082,6 +1486,260 @@ type TikTokVideo struct {
 	Status       string     `json:"status"`         // 'pending', 'first_review_completed', 'second_review_completed'
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Container metadata probed from the moov box (NULL until probed)
+	Width      *int     `json:"width"`
+	Height     *int     `json:"height"`
+	VideoCodec *string  `json:"video_codec"`
+	FrameRate  *float64 `json:"frame_rate"`
+	Bitrate    *int64   `json:"bitrate"` // bits per second
+	HasAudio   *bool      `json:"has_audio"`
+	AudioCodec *string    `json:"audio_codec"`
+	ProbedAt   *time.Time `json:"probed_at,omitempty"`
+
+	// Poster frame and keyframe strip stored in R2; URLs are presigned on read
+	ThumbnailKey     *string `json:"thumbnail_key,omitempty"`
+	KeyframeStripKey *string `json:"keyframe_strip_key,omitempty"`
+	ThumbnailURL     *string `json:"thumbnail_url,omitempty"`
+	KeyframeStripURL *string `json:"keyframe_strip_url,omitempty"`
+
+	Duplicate *VideoDuplicate `json:"duplicate,omitempty"` // Set when the video re-uploads an earlier one
+}
+
+// Video fingerprint methods and duplicate actions
+const (
+	VideoFingerprintFrames    = "frames"
+	VideoFingerprintContainer = "container"
+	VideoDuplicateFlagged     = "flagged"
+	VideoDuplicateInherited   = "inherited"
+)
+
+// VideoFingerprint is the perceptual fingerprint of a video: dHashes of frames
+// sampled at fixed relative positions, and a container hash as a fallback
+type VideoFingerprint struct {
+	VideoID       int       `json:"video_id"`
+	Method        string    `json:"method"` // frames, container
+	FrameHashes   []uint64  `json:"-"`
+	ContainerHash *string   `json:"container_hash"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// VideoDuplicate links a video to the earlier import it duplicates
+type VideoDuplicate struct {
+	VideoID         int        `json:"video_id"`
+	OriginalVideoID int        `json:"original_video_id"`
+	Method          string     `json:"method"` // frames, container
+	Similarity      float64    `json:"similarity"`
+	Action          string     `json:"action"`           // flagged, inherited
+	InheritedStatus *string    `json:"inherited_status"` // status copied from the original
+	CreatedAt       time.Time  `json:"created_at"`
+	ResolvedAt      *time.Time `json:"resolved_at"`
+
+	// The original video and its latest review decision, for the reviewer
+	OriginalFilename   string  `json:"original_filename"`
+	OriginalStatus     string  `json:"original_status"`
+	OriginalIsApproved *bool   `json:"original_is_approved"`
+	OriginalReason     *string `json:"original_reason"`
+}
+
+// Comment moderation statuses
+const (
+	CommentStatusPending             = "pending"
+	CommentStatusPendingSecondReview = "pending_second_review"
+	CommentStatusApproved            = "approved"
+	CommentStatusRejected            = "rejected"
+)
+
+// Bug report statuses
+const (
+	BugReportStatusNew        = "new"
+	BugReportStatusTriaged    = "triaged"
+	BugReportStatusInProgress = "in_progress"
+	BugReportStatusResolved   = "resolved"
+	BugReportStatusWontfix    = "wontfix"
+)
+
+// Error issue statuses
+const (
+	ErrorIssueStatusOpen     = "open"
+	ErrorIssueStatusResolved = "resolved"
+	ErrorIssueStatusIgnored  = "ignored"
+)
+
+// Video statuses
+const (
+	VideoStatusPending               = "pending"
+	VideoStatusFirstReviewCompleted  = "first_review_completed"
+	VideoStatusSecondReviewCompleted = "second_review_completed"
+	VideoStatusNaturalPool           = "natural_pool"
+	VideoStatusRemovedViolation      = "removed_violation"
+	// VideoStatusPoolConfirmed stands for the terminal status of any traffic
+	// pool, which each pool configures for itself (default <name>_confirmed)
+	VideoStatusPoolConfirmed = "<pool>_confirmed"
+)
+
+// CommentStatusMachine holds the allowed moderation status changes of a
+// comment: first review approves it or hands it to second review, which
+// decides it for good
+var CommentStatusMachine = statemachine.New("comment", []string{
+	CommentStatusPending,
+	CommentStatusPendingSecondReview,
+	CommentStatusApproved,
+	CommentStatusRejected,
+}, []statemachine.Transition{
+	{From: CommentStatusPending, To: CommentStatusApproved},
+	{From: CommentStatusPending, To: CommentStatusPendingSecondReview},
+	{From: CommentStatusPendingSecondReview, To: CommentStatusApproved},
+	{From: CommentStatusPendingSecondReview, To: CommentStatusRejected},
+})
+
+// BugReportStatusMachine holds the allowed triage status changes of a bug
+// report. A closed report can only be reopened back to triaged.
+var BugReportStatusMachine = statemachine.New("bug_report", []string{
+	BugReportStatusNew,
+	BugReportStatusTriaged,
+	BugReportStatusInProgress,
+	BugReportStatusResolved,
+	BugReportStatusWontfix,
+}, []statemachine.Transition{
+	{From: BugReportStatusNew, To: BugReportStatusTriaged},
+	{From: BugReportStatusNew, To: BugReportStatusInProgress},
+	{From: BugReportStatusNew, To: BugReportStatusResolved},
+	{From: BugReportStatusNew, To: BugReportStatusWontfix},
+	{From: BugReportStatusTriaged, To: BugReportStatusInProgress},
+	{From: BugReportStatusTriaged, To: BugReportStatusResolved},
+	{From: BugReportStatusTriaged, To: BugReportStatusWontfix},
+	{From: BugReportStatusInProgress, To: BugReportStatusTriaged},
+	{From: BugReportStatusInProgress, To: BugReportStatusResolved},
+	{From: BugReportStatusInProgress, To: BugReportStatusWontfix},
+	{From: BugReportStatusResolved, To: BugReportStatusTriaged},
+	{From: BugReportStatusWontfix, To: BugReportStatusTriaged},
+})
+
+// ErrorIssueStatusMachine holds the allowed status changes of an error
+// issue. Resolved and ignored issues go back to open when reopened by an
+// admin; resolved ones also reopen on their own when they happen again.
+var ErrorIssueStatusMachine = statemachine.New("error_issue", []string{
+	ErrorIssueStatusOpen,
+	ErrorIssueStatusResolved,
+	ErrorIssueStatusIgnored,
+}, []statemachine.Transition{
+	{From: ErrorIssueStatusOpen, To: ErrorIssueStatusResolved},
+	{From: ErrorIssueStatusOpen, To: ErrorIssueStatusIgnored},
+	{From: ErrorIssueStatusResolved, To: ErrorIssueStatusOpen},
+	{From: ErrorIssueStatusResolved, To: ErrorIssueStatusIgnored},
+	{From: ErrorIssueStatusIgnored, To: ErrorIssueStatusOpen},
+})
+
+// VideoStatusMachine holds the allowed status changes of a video. Queue
+// tasks can be created for a video at any review stage, so the traffic pool
+// decisions are reachable from all of them; once a pool has decided, only a
+// violation can still take the video down.
+var VideoStatusMachine = statemachine.New("video", []string{
+	VideoStatusPending,
+	VideoStatusFirstReviewCompleted,
+	VideoStatusSecondReviewCompleted,
+	VideoStatusNaturalPool,
+	VideoStatusPoolConfirmed,
+	VideoStatusRemovedViolation,
+}, []statemachine.Transition{
+	{From: VideoStatusPending, To: VideoStatusFirstReviewCompleted},
+	{From: VideoStatusPending, To: VideoStatusSecondReviewCompleted},
+	{From: VideoStatusPending, To: VideoStatusNaturalPool},
+	{From: VideoStatusPending, To: VideoStatusPoolConfirmed},
+	{From: VideoStatusPending, To: VideoStatusRemovedViolation},
+	{From: VideoStatusFirstReviewCompleted, To: VideoStatusNaturalPool},
+	{From: VideoStatusFirstReviewCompleted, To: VideoStatusPoolConfirmed},
+	{From: VideoStatusFirstReviewCompleted, To: VideoStatusRemovedViolation},
+	{From: VideoStatusSecondReviewCompleted, To: VideoStatusNaturalPool},
+	{From: VideoStatusSecondReviewCompleted, To: VideoStatusPoolConfirmed},
+	{From: VideoStatusSecondReviewCompleted, To: VideoStatusRemovedViolation},
+	{From: VideoStatusNaturalPool, To: VideoStatusRemovedViolation},
+	{From: VideoStatusPoolConfirmed, To: VideoStatusRemovedViolation},
+})
+
+// VideoDecidedStatuses are the statuses in which a video's review is settled:
+// a traffic pool decision or a removal. VideoStatusPoolConfirmed stands for
+// every pool's terminal status, which only the video_pools table knows.
+var VideoDecidedStatuses = []string{
+	VideoStatusNaturalPool,
+	VideoStatusPoolConfirmed,
+	VideoStatusRemovedViolation,
+}
+
+// ModerationStatusGraph is the allowed status graph of one content type.
+// Aliases lists the concrete statuses a placeholder state stands for.
+type ModerationStatusGraph struct {
+	statemachine.Graph
+	Aliases map[string][]string `json:"aliases,omitempty"`
+}
+
+// Sources of a status change
+const (
+	StatusSourceFirstReview    = "first_review"
+	StatusSourceSecondReview   = "second_review"
+	StatusSourceQueue          = "queue"
+	StatusSourceReconciliation = "reconciliation"
+	StatusSourceDuplicate      = "duplicate"
+)
+
+// StatusChange describes who or what changes a comment's or video's status,
+// recorded in the status transition log
+type StatusChange struct {
+	Source  string
+	TaskID  *int // task of the source stage, if any
+	ActorID *int // reviewer, nil for system changes
+	Reason  string
+}
+
+// VideoStatusTransition is one entry of a video's status transition log
+type VideoStatusTransition struct {
+	ID         int64     `json:"id"`
+	VideoID    int       `json:"video_id"`
+	FromStatus *string   `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Source     string    `json:"source"`
+	TaskID     *int      `json:"task_id"`
+	ActorID    *int      `json:"actor_id"`
+	Reason     *string   `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Video timeline event types
+const (
+	VideoTimelineImported        = "imported"
+	VideoTimelineTaskCreated     = "task_created"
+	VideoTimelineTaskClaimed     = "task_claimed"
+	VideoTimelineReviewSubmitted = "review_submitted"
+	VideoTimelineStatusChanged   = "status_changed"
+	VideoTimelineDuplicateLinked = "duplicate_linked"
+)
+
+// VideoTimelineEvent is one step of a video's way through review
+type VideoTimelineEvent struct {
+	OccurredAt   time.Time `json:"occurred_at"`
+	Type         string    `json:"type"`
+	Stage        string    `json:"stage,omitempty"` // first_review, second_review, queue
+	Pool         *string   `json:"pool,omitempty"`
+	TaskID       *int      `json:"task_id,omitempty"`
+	ReviewerID   *int      `json:"reviewer_id,omitempty"`
+	ReviewerName *string   `json:"reviewer_name,omitempty"`
+	IsApproved   *bool     `json:"is_approved,omitempty"`
+	Decision     *string   `json:"decision,omitempty"` // queue review decision
+	OverallScore *int      `json:"overall_score,omitempty"`
+	Reason       *string   `json:"reason,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+	FromStatus   *string   `json:"from_status,omitempty"`
+	ToStatus     *string   `json:"to_status,omitempty"`
+	Source       *string   `json:"source,omitempty"`
+	RelatedID    *int      `json:"related_video_id,omitempty"` // original video of a duplicate link
+}
+
+// VideoTimeline is a video with every review event across all stages in
+// chronological order
+type VideoTimeline struct {
+	Video  *TikTokVideo         `json:"video"`
+	Events []VideoTimelineEvent `json:"events"`
 }
 
 // VideoQualityTag represents a predefined quality assessment tag
@@ -1131,7 +1789,38 @@ type VideoFirstReviewResult struct {
 	TrafficPoolResult *string           `json:"traffic_pool_result"` // recommended traffic pool category
 	Reason            *string           `json:"reason"`
 	CreatedAt         time.Time         `json:"created_at"`
-	Reviewer          *User             `json:"reviewer,omitempty"` // Optional joined data
+	Reviewer          *User             `json:"reviewer,omitempty"`    // Optional joined data
+	Annotations       []VideoAnnotation `json:"annotations,omitempty"` // Optional joined data
+}
+
+// Review stages a video annotation can belong to
+const (
+	VideoAnnotationStageFirstReview  = "first_review"
+	VideoAnnotationStageSecondReview = "second_review"
+	VideoAnnotationStageQueue        = "queue"
+)
+
+// VideoAnnotation marks a time range of a video with a tag and a note
+type VideoAnnotation struct {
+	ID           int       `json:"id"`
+	VideoID      int       `json:"video_id"`
+	ReviewStage  string    `json:"review_stage"` // first_review, second_review, queue
+	TaskID       int       `json:"task_id"`
+	ReviewerID   *int      `json:"reviewer_id"`
+	StartSeconds float64   `json:"start_seconds"`
+	EndSeconds   float64   `json:"end_seconds"`
+	Tag          string    `json:"tag"`
+	Note         string    `json:"note"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// VideoAnnotationInput is an annotation submitted with a review. A point in
+// time has end_seconds equal to start_seconds.
+type VideoAnnotationInput struct {
+	StartSeconds float64 `json:"start_seconds" binding:"min=0"`
+	EndSeconds   float64 `json:"end_seconds" binding:"min=0"`
+	Tag          string  `json:"tag" binding:"required,max=50"`
+	Note         string  `json:"note" binding:"max=1000"`
 }
 
 // VideoSecondReviewTask represents a second review task for a video
@@ -1159,6 +1848,7 @@ type VideoSecondReviewResult struct {
 	TrafficPoolResult *string           `json:"traffic_pool_result"` // recommended traffic pool category
 	Reason            *string           `json:"reason"`
 	CreatedAt         time.Time         `json:"created_at"`
+	Annotations       []VideoAnnotation `json:"annotations,omitempty"` // Optional joined data
 }
 
 // Request/Response DTOs for Video Review
@@ -1167,10 +1857,105 @@ type ImportVideosRequest struct {
 	R2PathPrefix string `json:"r2_path_prefix" binding:"required"`
 }
 
-type ImportVideosResponse struct {
-	ImportedCount int      `json:"imported_count"`
-	SkippedCount  int      `json:"skipped_count"`
-	Errors        []string `json:"errors"`
+// VideoImportJob is a background import of every video under an R2 prefix
+type VideoImportJob struct {
+	ID            int        `json:"id"`
+	R2PathPrefix  string     `json:"r2_path_prefix"`
+	Status        string     `json:"status"`           // pending, running, completed, failed, canceled
+	Cursor        *string    `json:"cursor,omitempty"` // last R2 key handled
+	ListedCount   int        `json:"listed_count"`
+	ImportedCount int        `json:"imported_count"`
+	SkippedCount  int        `json:"skipped_count"`
+	FailedCount   int        `json:"failed_count"`
+	LastError     *string    `json:"last_error,omitempty"`
+	CreatedBy     *int       `json:"created_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	HeartbeatAt   *time.Time `json:"heartbeat_at,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+}
+
+type VideoImportJobError struct {
+	ID           int       `json:"id"`
+	JobID        int       `json:"job_id"`
+	VideoKey     string    `json:"video_key"`
+	ErrorMessage string    `json:"error_message"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type ListVideoImportJobsRequest struct {
+	Page     int `form:"page"`
+	PageSize int `form:"page_size"`
+}
+
+type ListVideoImportJobsResponse struct {
+	Data       []VideoImportJob `json:"data"`
+	Total      int              `json:"total"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"page_size"`
+	TotalPages int              `json:"total_pages"`
+}
+
+type ListVideoImportJobErrorsResponse struct {
+	Data       []VideoImportJobError `json:"data"`
+	Total      int                   `json:"total"`
+	Page       int                   `json:"page"`
+	PageSize   int                   `json:"page_size"`
+	TotalPages int                   `json:"total_pages"`
+}
+
+type ProbeVideosRequest struct {
+	Limit   int `json:"limit" binding:"omitempty,min=1,max=1000"`
+	AfterID int `json:"after_id" binding:"omitempty,min=0"` // resume after this video ID
+}
+
+type ProbeVideosResponse struct {
+	ProbedCount int      `json:"probed_count"`
+	FailedCount int      `json:"failed_count"`
+	LastID      int      `json:"last_id"` // pass as after_id to continue
+	Errors      []string `json:"errors"`
+}
+
+type GenerateThumbnailsRequest struct {
+	Limit   int `json:"limit" binding:"omitempty,min=1,max=500"`
+	AfterID int `json:"after_id" binding:"omitempty,min=0"` // resume after this video ID
+}
+
+type GenerateThumbnailsResponse struct {
+	GeneratedCount int      `json:"generated_count"`
+	FailedCount    int      `json:"failed_count"`
+	LastID         int      `json:"last_id"` // pass as after_id to continue
+	Errors         []string `json:"errors"`
+}
+
+type FingerprintVideosRequest struct {
+	Limit   int `json:"limit" binding:"omitempty,min=1,max=500"`
+	AfterID int `json:"after_id" binding:"omitempty,min=0"` // resume after this video ID
+}
+
+type FingerprintVideosResponse struct {
+	FingerprintedCount int      `json:"fingerprinted_count"`
+	DuplicateCount     int      `json:"duplicate_count"`
+	InheritedCount     int      `json:"inherited_count"`
+	FailedCount        int      `json:"failed_count"`
+	LastID             int      `json:"last_id"` // pass as after_id to continue
+	Errors             []string `json:"errors"`
+}
+
+type ReconcileVideoReviewsRequest struct {
+	DryRun bool `form:"dry_run"` // Report what would be repaired without changing anything
+}
+
+// VideoReviewReconciliationReport counts the orphaned video review rows found
+// (and, unless DryRun, repaired) by one reconciliation run
+type VideoReviewReconciliationReport struct {
+	DryRun                       bool  `json:"dry_run"`
+	ResetFirstReviewTasks        int64 `json:"reset_first_review_tasks"`        // Completed without a result
+	CreatedSecondReviewTasks     int64 `json:"created_second_review_tasks"`     // Rejected without a second review task
+	RepairedFirstReviewStatuses  int64 `json:"repaired_first_review_statuses"`  // Approved but video still pending
+	ResetSecondReviewTasks       int64 `json:"reset_second_review_tasks"`       // Completed without a result
+	RepairedSecondReviewStatuses int64 `json:"repaired_second_review_statuses"` // Reviewed but video still pending
 }
 
 type ListVideosRequest struct {
@@ -1198,11 +1983,12 @@ type ClaimVideoFirstReviewTasksResponse struct {
 }
 
 type SubmitVideoFirstReviewRequest struct {
-	TaskID            int               `json:"task_id" binding:"required"`
-	IsApproved        bool              `json:"is_approved"`
-	QualityDimensions QualityDimensions `json:"quality_dimensions" binding:"required"`
-	TrafficPoolResult *string           `json:"traffic_pool_result"`
-	Reason            *string           `json:"reason" binding:"omitempty,max=2000"`
+	TaskID            int                    `json:"task_id" binding:"required"`
+	IsApproved        bool                   `json:"is_approved"`
+	QualityDimensions QualityDimensions      `json:"quality_dimensions" binding:"required"`
+	TrafficPoolResult *string                `json:"traffic_pool_result"`
+	Reason            *string                `json:"reason" binding:"omitempty,max=2000"`
+	Annotations       []VideoAnnotationInput `json:"annotations" binding:"omitempty,max=50,dive"`
 }
 
 type BatchSubmitVideoFirstReviewRequest struct {
@@ -1223,11 +2009,12 @@ type ClaimVideoSecondReviewTasksResponse struct {
 }
 
 type SubmitVideoSecondReviewRequest struct {
-	TaskID            int               `json:"task_id" binding:"required"`
-	IsApproved        bool              `json:"is_approved"`
-	QualityDimensions QualityDimensions `json:"quality_dimensions" binding:"required"`
-	TrafficPoolResult *string           `json:"traffic_pool_result"`
-	Reason            *string           `json:"reason" binding:"omitempty,max=2000"`
+	TaskID            int                    `json:"task_id" binding:"required"`
+	IsApproved        bool                   `json:"is_approved"`
+	QualityDimensions QualityDimensions      `json:"quality_dimensions" binding:"required"`
+	TrafficPoolResult *string                `json:"traffic_pool_result"`
+	Reason            *string                `json:"reason" binding:"omitempty,max=2000"`
+	Annotations       []VideoAnnotationInput `json:"annotations" binding:"omitempty,max=50,dive"`
 }
 
 type BatchSubmitVideoSecondReviewRequest struct {
@@ -1273,18 +2060,42 @@ type Permission struct {
 
 // UserPermission represents user-permission relationship
 type UserPermission struct {
+	ID            int        `json:"id"`
+	UserID        int        `json:"user_id"`
+	PermissionKey string     `json:"permission_key"`
+	GrantedAt     time.Time  `json:"granted_at"`
+	GrantedBy     *int       `json:"granted_by,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	Scopes        []string   `json:"scopes,omitempty"`
+}
+
+// PermissionGrant is the effective form of a user permission used for checks.
+// Empty Scopes means the grant applies globally; otherwise each scope has the
+// form "<dimension>:<value>", e.g. "pool:1m" or "module:user_management".
+type PermissionGrant struct {
+	PermissionKey string     `json:"permission_key"`
+	Scopes        []string   `json:"scopes,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+}
+
+// PermissionGrantExpiration records a time-bounded grant removed by the sweeper
+type PermissionGrantExpiration struct {
 	ID            int       `json:"id"`
 	UserID        int       `json:"user_id"`
 	PermissionKey string    `json:"permission_key"`
-	GrantedAt     time.Time `json:"granted_at"`
+	Scopes        []string  `json:"scopes,omitempty"`
 	GrantedBy     *int      `json:"granted_by,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	ExpiredAt     time.Time `json:"expired_at"`
 }
 
 // Permission management DTOs
 
 type GrantPermissionRequest struct {
-	UserID         int      `json:"user_id" binding:"required"`
-	PermissionKeys []string `json:"permission_keys" binding:"required,min=1"`
+	UserID         int        `json:"user_id" binding:"required"`
+	PermissionKeys []string   `json:"permission_keys" binding:"required,min=1"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"` // Optional: grant is removed after this time
+	Scopes         []string   `json:"scopes,omitempty"`     // Optional: e.g. ["pool:1m"], empty = global
 }
 
 type RevokePermissionRequest struct {
@@ -1314,23 +2125,25 @@ type ListPermissionsResponse struct {
 }
 
 type UserPermissionsResponse struct {
-	UserID      int      `json:"user_id"`
-	Permissions []string `json:"permissions"`
+	UserID      int               `json:"user_id"`
+	Permissions []string          `json:"permissions"`
+	Grants      []PermissionGrant `json:"grants"`
 }
 
 // Video Queue Pool System Models (Refactored from First/Second Review)
 
 // VideoQueueTask represents a video review task in a specific traffic pool
 type VideoQueueTask struct {
-	ID          int          `json:"id"`
-	VideoID     int          `json:"video_id"`
-	Pool        string       `json:"pool"` // "100k", "1m", "10m"
-	ReviewerID  *int         `json:"reviewer_id"`
-	Status      string       `json:"status"` // "pending", "in_progress", "completed"
-	ClaimedAt   *time.Time   `json:"claimed_at"`
-	CompletedAt *time.Time   `json:"completed_at"`
-	CreatedAt   time.Time    `json:"created_at"`
-	Video       *TikTokVideo `json:"video,omitempty"` // Optional joined data
+	ID          int               `json:"id"`
+	VideoID     int               `json:"video_id"`
+	Pool        string            `json:"pool"` // VideoPool.Name, e.g. "100k"
+	ReviewerID  *int              `json:"reviewer_id"`
+	Status      string            `json:"status"` // "pending", "in_progress", "completed"
+	ClaimedAt   *time.Time        `json:"claimed_at"`
+	CompletedAt *time.Time        `json:"completed_at"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Video       *TikTokVideo      `json:"video,omitempty"`       // Optional joined data
+	Annotations []VideoAnnotation `json:"annotations,omitempty"` // Earlier reviews' annotations of the video
 }
 
 // VideoQueueResult represents the simplified review result for a video queue task
@@ -1348,7 +2161,7 @@ type VideoQueueResult struct {
 // Request/Response DTOs for Video Queue Pool System
 
 type ClaimVideoQueueTasksRequest struct {
-	Count int `json:"count" binding:"required,min=1,max=50"`
+	Count int `json:"count" binding:"required,min=1"` // Capped by VideoPool.ClaimMaxCount
 }
 
 type ClaimVideoQueueTasksResponse struct {
@@ -1357,10 +2170,11 @@ type ClaimVideoQueueTasksResponse struct {
 }
 
 type SubmitVideoQueueReviewRequest struct {
-	TaskID         int      `json:"task_id" binding:"required"`
-	ReviewDecision string   `json:"review_decision" binding:"required,oneof=push_next_pool natural_pool remove_violation"`
-	Reason         string   `json:"reason" binding:"required,min=1,max=2000"`
-	Tags           []string `json:"tags" binding:"max=3"`
+	TaskID         int                    `json:"task_id" binding:"required"`
+	ReviewDecision string                 `json:"review_decision" binding:"required,oneof=push_next_pool natural_pool remove_violation"`
+	Reason         string                 `json:"reason" binding:"required,min=1,max=2000"`
+	Tags           []string               `json:"tags"` // Capped by VideoPool.MaxTags
+	Annotations    []VideoAnnotationInput `json:"annotations" binding:"omitempty,max=50,dive"`
 }
 
 type BatchSubmitVideoQueueReviewRequest struct {
@@ -1378,13 +2192,13 @@ type VideoQueueTag struct {
 	Description string    `json:"description"`
 	Category    string    `json:"category"` // 'content', 'technical', 'compliance', 'engagement'
 	Scope       string    `json:"scope"`    // 'video'
-	QueueID     *string   `json:"queue_id"` // '100k', '1m', '10m' or NULL for all queues
+	QueueID     *string   `json:"queue_id"` // VideoPool tag set, or NULL for all queues
 	IsActive    bool      `json:"is_active"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
 type GetVideoQueueTagsRequest struct {
-	Pool string `form:"pool" binding:"required,oneof=100k 1m 10m"`
+	Pool string `form:"pool" binding:"required,max=10"`
 }
 
 type GetVideoQueueTagsResponse struct {
@@ -1401,6 +2215,59 @@ type VideoQueuePoolStats struct {
 	AvgProcessTimeMinutes float64 `json:"avg_process_time_minutes"`
 }
 
+// VideoPool is one rung of the traffic-pool ladder. Pushing a video to the
+// next pool creates a task in NextPool; at the top of the ladder (no NextPool)
+// the video is set to TerminalStatus instead.
+type VideoPool struct {
+	Name           string    `json:"name"`
+	DisplayName    string    `json:"display_name"`
+	Description    string    `json:"description"`
+	SortOrder      int       `json:"sort_order"`
+	NextPool       *string   `json:"next_pool"`
+	TerminalStatus *string   `json:"terminal_status"`
+	ClaimMaxCount  int       `json:"claim_max_count"`
+	MaxTags        int       `json:"max_tags"`
+	TagSet         *string   `json:"tag_set"` // Pool whose tags are used, NULL for this pool's own
+	IsActive       bool      `json:"is_active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type CreateVideoPoolRequest struct {
+	Name           string  `json:"name" binding:"required,max=10"`
+	DisplayName    string  `json:"display_name" binding:"required,max=100"`
+	Description    string  `json:"description"`
+	SortOrder      int     `json:"sort_order"`
+	NextPool       *string `json:"next_pool"`
+	TerminalStatus *string `json:"terminal_status" binding:"omitempty,max=30"`
+	ClaimMaxCount  int     `json:"claim_max_count" binding:"omitempty,min=1,max=200"`
+	MaxTags        *int    `json:"max_tags" binding:"omitempty,min=0,max=20"`
+	TagSet         *string `json:"tag_set" binding:"omitempty,max=10"`
+	IsActive       *bool   `json:"is_active"`
+}
+
+// UpdateVideoPoolRequest updates the given fields. An empty next_pool,
+// terminal_status or tag_set clears it.
+type UpdateVideoPoolRequest struct {
+	DisplayName    *string `json:"display_name,omitempty" binding:"omitempty,max=100"`
+	Description    *string `json:"description,omitempty"`
+	SortOrder      *int    `json:"sort_order,omitempty"`
+	NextPool       *string `json:"next_pool,omitempty"`
+	TerminalStatus *string `json:"terminal_status,omitempty" binding:"omitempty,max=30"`
+	ClaimMaxCount  *int    `json:"claim_max_count,omitempty" binding:"omitempty,min=1,max=200"`
+	MaxTags        *int    `json:"max_tags,omitempty" binding:"omitempty,min=0,max=20"`
+	TagSet         *string `json:"tag_set,omitempty" binding:"omitempty,max=10"`
+	IsActive       *bool   `json:"is_active,omitempty"`
+}
+
+type ListVideoPoolsResponse struct {
+	Pools []VideoPool `json:"pools"`
+}
+
+type ListVideoQueuePoolStatsResponse struct {
+	Pools []VideoQueuePoolStats `json:"pools"`
+}
+
 type VideoQueueDecisionStats struct {
 	Pool               string  `json:"pool"`
 	ReviewDecision     string  `json:"review_decision"`
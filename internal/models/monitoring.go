@@ -43,3 +43,52 @@ type HealthResponse struct {
 	Timestamp    time.Time                   `json:"timestamp"`
 	Dependencies map[string]HealthDependency `json:"dependencies"`
 }
+
+type PermissionCacheStats struct {
+	LocalHits     int64   `json:"local_hits"`
+	RedisHits     int64   `json:"redis_hits"`
+	Misses        int64   `json:"misses"`
+	Invalidations int64   `json:"invalidations"`
+	Lookups       int64   `json:"lookups"`
+	HitRate       float64 `json:"hit_rate"`
+	LocalEntries  int     `json:"local_entries"`
+	TTLSeconds    int     `json:"ttl_seconds"`
+}
+
+// AuditWriterStats reports the batched audit log writer's backpressure
+type AuditWriterStats struct {
+	QueueDepth       int        `json:"queue_depth"`
+	QueueCapacity    int        `json:"queue_capacity"`
+	QueueUtilization float64    `json:"queue_utilization"`
+	BatchSize        int        `json:"batch_size"`
+	Enqueued         int64      `json:"enqueued"`
+	Written          int64      `json:"written"`
+	Spilled          int64      `json:"spilled"`       // sent to disk because the queue was full or an insert failed
+	Replayed         int64      `json:"replayed"`      // read back from disk and inserted
+	Dropped          int64      `json:"dropped"`       // lost because spilling was disabled or full
+	DeadLettered     int64      `json:"dead_lettered"` // rejected by Postgres on their own and set aside
+	FailedBatches    int64      `json:"failed_batches"`
+	SpillEnabled     bool       `json:"spill_enabled"`
+	SpillBytes       int64      `json:"spill_bytes"`
+	LastFlushMs      int64      `json:"last_flush_ms"`
+	LastFlushAt      *time.Time `json:"last_flush_at"`
+	LastError        string     `json:"last_error,omitempty"`
+}
+
+// QueueTaskCount is the number of tasks of a review queue, or of a video
+// pool, in one open status
+type QueueTaskCount struct {
+	Queue  string `json:"queue"`
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+// AIReviewJobProgress is the task progress of a scheduled or running AI
+// review job
+type AIReviewJobProgress struct {
+	JobID          int    `json:"job_id"`
+	Status         string `json:"status"`
+	TotalTasks     int    `json:"total_tasks"`
+	CompletedTasks int    `json:"completed_tasks"`
+	FailedTasks    int    `json:"failed_tasks"`
+}
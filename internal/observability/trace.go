@@ -0,0 +1,20 @@
+package observability
+
+import "context"
+
+type traceIDContextKey struct{}
+
+// WithTraceID returns a context carrying the request's trace ID, for code
+// below the HTTP layer that has no access to the gin context.
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDContextKey{}, traceID)
+}
+
+// TraceID returns the trace ID carried by ctx, or "".
+func TraceID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	traceID, _ := ctx.Value(traceIDContextKey{}).(string)
+	return traceID
+}
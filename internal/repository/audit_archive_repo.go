@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"comment-review-platform/internal/models"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// AuditArchiveRepository reads expired audit logs for archival and keeps the
+// manifest of archive objects in R2
+type AuditArchiveRepository struct {
+	db *sql.DB
+}
+
+func NewAuditArchiveRepository(db *sql.DB) *AuditArchiveRepository {
+	return &AuditArchiveRepository{db: db}
+}
+
+// ListExpiredDays returns the days before cutoff that still have hot rows
+func (r *AuditArchiveRepository) ListExpiredDays(cutoff string) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT DISTINCT created_at::date FROM audit_logs
+		WHERE created_at < $1::date
+		ORDER BY 1
+	`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []string{}
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		days = append(days, AuditChainDay(day))
+	}
+	return days, rows.Err()
+}
+
+// ListDayBatch returns up to limit hot rows of a day: rows written before
+// chaining first, then chained rows in sequence order, so every batch covers
+// a contiguous stretch of the chain
+func (r *AuditArchiveRepository) ListDayBatch(day string, limit int) ([]models.AuditLogArchiveRecord, error) {
+	rows, err := r.db.Query(`
+		SELECT
+			id, created_at, user_id, username, user_role,
+			action_type, action_category, action_description, result,
+			endpoint, http_method, status_code, request_id, session_id,
+			request_body, request_params, response_body, ip_address, user_agent,
+			geo_location, device_type, browser, os,
+			resource_type, resource_id, resource_ids, changes,
+			error_code, error_type, error_description, error_message, error_stack, duration_ms,
+			module_name, method_name, server_ip, server_port, page_url,
+			chain_day, chain_seq, prev_hash, entry_hash
+		FROM audit_logs
+		WHERE created_at >= $1::date AND created_at < $1::date + 1
+		ORDER BY chain_seq NULLS FIRST, created_at, id
+		LIMIT $2
+	`, day, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []models.AuditLogArchiveRecord{}
+	for rows.Next() {
+		var chainDay sql.NullTime
+		var chainSeq sql.NullInt64
+		var prevHash, entryHash sql.NullString
+		entry, err := scanAuditLogRow(archiveRowScanner{rows: rows, extra: []interface{}{&chainDay, &chainSeq, &prevHash, &entryHash}})
+		if err != nil {
+			return nil, err
+		}
+		record := models.AuditLogArchiveRecord{AuditLogEntry: entry, PrevHash: prevHash.String, EntryHash: entryHash.String}
+		if chainDay.Valid {
+			record.ChainDay = AuditChainDay(chainDay.Time)
+		}
+		if chainSeq.Valid {
+			record.ChainSeq = chainSeq.Int64
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// archiveRowScanner appends extra columns to scanAuditLogRow's targets
+type archiveRowScanner struct {
+	rows  *sql.Rows
+	extra []interface{}
+}
+
+func (s archiveRowScanner) Scan(dest ...interface{}) error {
+	return s.rows.Scan(append(dest, s.extra...)...)
+}
+
+// NextPart returns the part number for the next archive object of a day
+func (r *AuditArchiveRepository) NextPart(day string) (int, error) {
+	var part int
+	err := r.db.QueryRow(`
+		SELECT COALESCE(MAX(part), 0) + 1 FROM audit_log_archives WHERE archive_day = $1
+	`, day).Scan(&part)
+	return part, err
+}
+
+// CreateArchiveTx records an uploaded archive object in the manifest
+func (r *AuditArchiveRepository) CreateArchiveTx(tx *sql.Tx, a *models.AuditLogArchive) error {
+	return tx.QueryRow(`
+		INSERT INTO audit_log_archives (
+			archive_day, part, object_key, entry_count, first_seq, last_seq,
+			oldest_at, newest_at, size_bytes, sha256
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`, a.ArchiveDay, a.Part, a.ObjectKey, a.EntryCount, a.FirstSeq, a.LastSeq,
+		a.OldestAt, a.NewestAt, a.SizeBytes, a.SHA256).Scan(&a.ID, &a.CreatedAt)
+}
+
+// DeleteUnchainedTx deletes archived rows that were written before chaining
+func (r *AuditArchiveRepository) DeleteUnchainedTx(tx *sql.Tx, ids []string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result, err := tx.Exec(`
+		DELETE FROM audit_logs WHERE id = ANY($1::uuid[]) AND chain_seq IS NULL
+	`, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListArchives returns the archive objects holding entries in [start, end]
+func (r *AuditArchiveRepository) ListArchives(start, end time.Time) ([]models.AuditLogArchive, error) {
+	rows, err := r.db.Query(`
+		SELECT id, archive_day, part, object_key, entry_count, first_seq, last_seq,
+			oldest_at, newest_at, size_bytes, sha256, created_at
+		FROM audit_log_archives
+		WHERE oldest_at <= $2 AND newest_at >= $1
+		ORDER BY archive_day, part
+	`, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	archives := []models.AuditLogArchive{}
+	for rows.Next() {
+		var a models.AuditLogArchive
+		var day time.Time
+		var firstSeq, lastSeq sql.NullInt64
+		err := rows.Scan(&a.ID, &day, &a.Part, &a.ObjectKey, &a.EntryCount, &firstSeq, &lastSeq,
+			&a.OldestAt, &a.NewestAt, &a.SizeBytes, &a.SHA256, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		a.ArchiveDay = AuditChainDay(day)
+		if firstSeq.Valid {
+			a.FirstSeq = &firstSeq.Int64
+		}
+		if lastSeq.Valid {
+			a.LastSeq = &lastSeq.Int64
+		}
+		archives = append(archives, a)
+	}
+	return archives, rows.Err()
+}
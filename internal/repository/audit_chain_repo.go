@@ -0,0 +1,424 @@
+package repository
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/pkg/hashchain"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// auditLogColumnCount is the number of audit_logs columns written per
+	// entry; Postgres allows 65535 bind parameters per statement
+	auditLogColumnCount = 42
+	// MaxAuditInsertBatch is the most entries one insert can carry
+	MaxAuditInsertBatch = 65535 / auditLogColumnCount
+
+	auditChainDayLayout = "2006-01-02"
+)
+
+// AuditChainRepository writes audit logs into per-day hash chains and reads
+// them back for verification, checkpoints and retention
+type AuditChainRepository struct {
+	db *sql.DB
+}
+
+func NewAuditChainRepository(db *sql.DB) *AuditChainRepository {
+	return &AuditChainRepository{db: db}
+}
+
+// AuditChainRow is a chained audit_logs row with the content its hash covers
+type AuditChainRow struct {
+	ID       string
+	Seq      int64
+	PrevHash string
+	Hash     string
+	Content  []byte
+}
+
+// AuditChainHead is the last link of a day's chain
+type AuditChainHead struct {
+	Day  time.Time
+	Seq  int64
+	Hash string
+}
+
+// AuditChainDay returns the chain an entry created at t belongs to. Chains
+// follow the wall clock that created_at stores.
+func AuditChainDay(t time.Time) string {
+	return t.Format(auditChainDayLayout)
+}
+
+// auditEntryContent is the canonical content of an entry as audit_logs stores
+// it, so a row read back hashes the same as the entry that was written
+func auditEntryContent(e models.AuditLogEntry) ([]byte, error) {
+	return hashchain.Canonical(
+		e.CreatedAt, e.UserID, nullableString(e.Username), nullableString(e.UserRole),
+		nullableString(e.ActionType), nullableString(e.ActionCategory), nullableString(e.ActionDescription), nullableString(e.Result),
+		nullableString(e.Endpoint), nullableString(e.HTTPMethod), e.StatusCode, nullableString(e.RequestID), nullableString(e.SessionID),
+		e.RequestBody, e.RequestParams, e.ResponseBody, nullableString(e.IPAddress), nullableString(e.UserAgent),
+		nullableString(e.GeoLocation), nullableString(e.DeviceType), nullableString(e.Browser), nullableString(e.OS),
+		nullableString(e.ResourceType), nullableString(e.ResourceID), e.ResourceIDs, e.Changes,
+		nullableString(e.ErrorCode), nullableString(e.ErrorType), nullableString(e.ErrorDescription), nullableString(e.ErrorMessage), nullableString(e.ErrorStack), nullableInt(e.DurationMs),
+		nullableString(e.ModuleName), nullableString(e.MethodName), nullableString(e.ServerIP), nullableString(e.ServerPort), nullableString(e.PageURL),
+	)
+}
+
+// InsertEntries appends entries to their days' chains with one multi-row
+// insert. Each day's head row is locked for the transaction, so concurrent
+// writers, in this process or another, extend a chain one at a time. Entries
+// without an ID are given one, so callers can refer to the stored rows.
+func (r *AuditChainRepository) InsertEntries(entries []models.AuditLogEntry) error {
+	if r.db == nil || len(entries) == 0 {
+		return nil
+	}
+
+	byDay := map[string][]int{}
+	for i := range entries {
+		if entries[i].ID == "" {
+			entries[i].ID = uuid.NewString()
+		}
+		// Postgres keeps microseconds; hash exactly what is stored
+		entries[i].CreatedAt = entries[i].CreatedAt.Round(time.Microsecond)
+		day := AuditChainDay(entries[i].CreatedAt)
+		byDay[day] = append(byDay[day], i)
+	}
+	days := make([]string, 0, len(byDay))
+	for day := range byDay {
+		days = append(days, day)
+	}
+	// A fixed lock order keeps two batches spanning midnight from deadlocking
+	sort.Strings(days)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	links := make([]AuditChainRow, len(entries))
+	for _, day := range days {
+		if _, err := tx.Exec(`
+			INSERT INTO audit_log_chain_heads (chain_day, last_seq, last_hash)
+			VALUES ($1, 0, $2)
+			ON CONFLICT (chain_day) DO NOTHING
+		`, day, hashchain.Genesis(day)); err != nil {
+			return err
+		}
+		var head AuditChainHead
+		if err := tx.QueryRow(`
+			SELECT last_seq, last_hash FROM audit_log_chain_heads WHERE chain_day = $1 FOR UPDATE
+		`, day).Scan(&head.Seq, &head.Hash); err != nil {
+			return err
+		}
+
+		for _, i := range byDay[day] {
+			content, err := auditEntryContent(entries[i])
+			if err != nil {
+				return fmt.Errorf("audit entry %s: %w", entries[i].RequestID, err)
+			}
+			head.Seq++
+			hash := hashchain.Link(head.Hash, content)
+			links[i] = AuditChainRow{Seq: head.Seq, PrevHash: head.Hash, Hash: hash}
+			head.Hash = hash
+		}
+
+		if _, err := tx.Exec(`
+			UPDATE audit_log_chain_heads SET last_seq = $2, last_hash = $3, updated_at = NOW()
+			WHERE chain_day = $1
+		`, day, head.Seq, head.Hash); err != nil {
+			return err
+		}
+	}
+
+	var query strings.Builder
+	query.WriteString(`
+		INSERT INTO audit_logs (
+			id, created_at, user_id, username, user_role,
+			action_type, action_category, action_description, result,
+			endpoint, http_method, status_code, request_id, session_id,
+			request_body, request_params, response_body, ip_address, user_agent,
+			geo_location, device_type, browser, os,
+			resource_type, resource_id, resource_ids, changes,
+			error_code, error_type, error_description, error_message, error_stack, duration_ms,
+			module_name, method_name, server_ip, server_port, page_url,
+			chain_day, chain_seq, prev_hash, entry_hash
+		) VALUES `)
+
+	args := make([]interface{}, 0, len(entries)*auditLogColumnCount)
+	for i, e := range entries {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		for col := 1; col <= auditLogColumnCount; col++ {
+			if col > 1 {
+				query.WriteString(", ")
+			}
+			fmt.Fprintf(&query, "$%d", i*auditLogColumnCount+col)
+		}
+		query.WriteString(")")
+		args = append(args,
+			e.ID, e.CreatedAt, e.UserID, nullableString(e.Username), nullableString(e.UserRole),
+			nullableString(e.ActionType), nullableString(e.ActionCategory), nullableString(e.ActionDescription), nullableString(e.Result),
+			nullableString(e.Endpoint), nullableString(e.HTTPMethod), e.StatusCode, nullableString(e.RequestID), nullableString(e.SessionID),
+			nullableJSON(e.RequestBody), nullableJSON(e.RequestParams), nullableJSON(e.ResponseBody), nullableString(e.IPAddress), nullableString(e.UserAgent),
+			nullableString(e.GeoLocation), nullableString(e.DeviceType), nullableString(e.Browser), nullableString(e.OS),
+			nullableString(e.ResourceType), nullableString(e.ResourceID), nullableJSON(e.ResourceIDs), nullableJSON(e.Changes),
+			nullableString(e.ErrorCode), nullableString(e.ErrorType), nullableString(e.ErrorDescription), nullableString(e.ErrorMessage), nullableString(e.ErrorStack), nullableInt(e.DurationMs),
+			nullableString(e.ModuleName), nullableString(e.MethodName), nullableString(e.ServerIP), nullableString(e.ServerPort), nullableString(e.PageURL),
+			AuditChainDay(e.CreatedAt), links[i].Seq, links[i].PrevHash, links[i].Hash,
+		)
+	}
+	if _, err := tx.Exec(query.String(), args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// WalkDay streams a day's chained rows in sequence order
+func (r *AuditChainRepository) WalkDay(day string, fn func(AuditChainRow) error) error {
+	rows, err := r.db.Query(`
+		SELECT
+			id, created_at, user_id, username, user_role,
+			action_type, action_category, action_description, result,
+			endpoint, http_method, status_code, request_id, session_id,
+			request_body, request_params, response_body, ip_address, user_agent,
+			geo_location, device_type, browser, os,
+			resource_type, resource_id, resource_ids, changes,
+			error_code, error_type, error_description, error_message, error_stack, duration_ms,
+			module_name, method_name, server_ip, server_port, page_url,
+			chain_seq, prev_hash, entry_hash
+		FROM audit_logs
+		WHERE chain_day = $1 AND chain_seq IS NOT NULL
+		ORDER BY chain_seq
+	`, day)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row AuditChainRow
+		entry, err := scanAuditLogRow(chainRowScanner{rows: rows, row: &row})
+		if err != nil {
+			return err
+		}
+		row.ID = entry.ID
+		if row.Content, err = auditEntryContent(entry); err != nil {
+			return fmt.Errorf("audit log %s: %w", entry.ID, err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// chainRowScanner appends the chain columns to scanAuditLogRow's targets
+type chainRowScanner struct {
+	rows *sql.Rows
+	row  *AuditChainRow
+}
+
+func (s chainRowScanner) Scan(dest ...interface{}) error {
+	return s.rows.Scan(append(dest, &s.row.Seq, &s.row.PrevHash, &s.row.Hash)...)
+}
+
+// GetHead returns the last link of a day's chain, or nil if nothing was
+// ever written for the day
+func (r *AuditChainRepository) GetHead(day string) (*AuditChainHead, error) {
+	var head AuditChainHead
+	err := r.db.QueryRow(`
+		SELECT chain_day, last_seq, last_hash FROM audit_log_chain_heads WHERE chain_day = $1
+	`, day).Scan(&head.Day, &head.Seq, &head.Hash)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &head, nil
+}
+
+// ListUncheckpointedHeads returns the chain heads that moved past their
+// latest checkpoint
+func (r *AuditChainRepository) ListUncheckpointedHeads() ([]AuditChainHead, error) {
+	rows, err := r.db.Query(`
+		SELECT h.chain_day, h.last_seq, h.last_hash
+		FROM audit_log_chain_heads h
+		WHERE h.last_seq > COALESCE((SELECT MAX(c.seq) FROM audit_log_checkpoints c WHERE c.chain_day = h.chain_day), 0)
+		ORDER BY h.chain_day
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	heads := []AuditChainHead{}
+	for rows.Next() {
+		var head AuditChainHead
+		if err := rows.Scan(&head.Day, &head.Seq, &head.Hash); err != nil {
+			return nil, err
+		}
+		heads = append(heads, head)
+	}
+	return heads, rows.Err()
+}
+
+// CreateCheckpoint stores a signed checkpoint of a chain head
+func (r *AuditChainRepository) CreateCheckpoint(day string, seq int64, hash, signature string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO audit_log_checkpoints (chain_day, seq, entry_hash, signature)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chain_day, seq) DO NOTHING
+	`, day, seq, hash, signature)
+	return err
+}
+
+// ListCheckpoints returns a day's checkpoints in sequence order
+func (r *AuditChainRepository) ListCheckpoints(day string) ([]models.AuditChainCheckpoint, error) {
+	rows, err := r.db.Query(`
+		SELECT id, chain_day, seq, entry_hash, signature, created_at
+		FROM audit_log_checkpoints
+		WHERE chain_day = $1
+		ORDER BY seq
+	`, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checkpoints := []models.AuditChainCheckpoint{}
+	for rows.Next() {
+		var cp models.AuditChainCheckpoint
+		var chainDay time.Time
+		if err := rows.Scan(&cp.ID, &chainDay, &cp.Seq, &cp.EntryHash, &cp.Signature, &cp.CreatedAt); err != nil {
+			return nil, err
+		}
+		cp.ChainDay = AuditChainDay(chainDay)
+		checkpoints = append(checkpoints, cp)
+	}
+	return checkpoints, rows.Err()
+}
+
+// GetTombstone returns the retention summary of a day, or nil if the day
+// has not been deleted
+func (r *AuditChainRepository) GetTombstone(day string) (*models.AuditChainTombstone, error) {
+	var t models.AuditChainTombstone
+	var chainDay time.Time
+	err := r.db.QueryRow(`
+		SELECT id, chain_day, first_seq, last_seq, entry_count, first_prev_hash, last_hash,
+			oldest_at, newest_at, signature, created_at
+		FROM audit_log_tombstones
+		WHERE chain_day = $1
+	`, day).Scan(&t.ID, &chainDay, &t.FirstSeq, &t.LastSeq, &t.EntryCount, &t.FirstPrevHash, &t.LastHash,
+		&t.OldestAt, &t.NewestAt, &t.Signature, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.ChainDay = AuditChainDay(chainDay)
+	return &t, nil
+}
+
+// CountUnchained counts rows in [start, end) written before hash chaining
+func (r *AuditChainRepository) CountUnchained(start, end time.Time) (int64, error) {
+	var count int64
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM audit_logs
+		WHERE chain_seq IS NULL AND created_at >= $1 AND created_at < $2
+	`, start, end).Scan(&count)
+	return count, err
+}
+
+// SummarizeDayTx describes the chained rows of a day up to maxSeq for its
+// tombstone, or returns nil if there are none. The day's head is locked so no entry can be
+// appended while the day is summarized and deleted. An earlier tombstone of
+// the day is folded into the summary so the chain stays covered from genesis.
+func (r *AuditChainRepository) SummarizeDayTx(tx *sql.Tx, day string, maxSeq int64) (*models.AuditChainTombstone, error) {
+	var headSeq int64
+	if err := tx.QueryRow(`
+		SELECT last_seq FROM audit_log_chain_heads WHERE chain_day = $1 FOR UPDATE
+	`, day).Scan(&headSeq); err != nil {
+		return nil, err
+	}
+
+	t := models.AuditChainTombstone{ChainDay: day}
+	if err := tx.QueryRow(`
+		SELECT COUNT(*) FROM audit_logs WHERE chain_day = $1 AND chain_seq <= $2
+	`, day, maxSeq).Scan(&t.EntryCount); err != nil {
+		return nil, err
+	}
+	if t.EntryCount == 0 {
+		return nil, nil
+	}
+	err := tx.QueryRow(`
+		SELECT
+			MIN(chain_seq), MAX(chain_seq), MIN(created_at), MAX(created_at),
+			(SELECT prev_hash FROM audit_logs WHERE chain_day = $1 AND chain_seq <= $2 ORDER BY chain_seq LIMIT 1),
+			(SELECT entry_hash FROM audit_logs WHERE chain_day = $1 AND chain_seq <= $2 ORDER BY chain_seq DESC LIMIT 1)
+		FROM audit_logs
+		WHERE chain_day = $1 AND chain_seq <= $2
+	`, day, maxSeq).Scan(&t.FirstSeq, &t.LastSeq, &t.OldestAt, &t.NewestAt, &t.FirstPrevHash, &t.LastHash)
+	if err != nil {
+		return nil, err
+	}
+
+	var earlier models.AuditChainTombstone
+	err = tx.QueryRow(`
+		SELECT first_seq, entry_count, first_prev_hash, oldest_at
+		FROM audit_log_tombstones WHERE chain_day = $1
+	`, day).Scan(&earlier.FirstSeq, &earlier.EntryCount, &earlier.FirstPrevHash, &earlier.OldestAt)
+	if err == sql.ErrNoRows {
+		return &t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.FirstSeq = earlier.FirstSeq
+	t.FirstPrevHash = earlier.FirstPrevHash
+	t.EntryCount += earlier.EntryCount
+	t.OldestAt = earlier.OldestAt
+	return &t, nil
+}
+
+// SaveTombstoneTx stores a day's signed tombstone, replacing an earlier one
+func (r *AuditChainRepository) SaveTombstoneTx(tx *sql.Tx, t *models.AuditChainTombstone) error {
+	_, err := tx.Exec(`
+		INSERT INTO audit_log_tombstones (
+			chain_day, first_seq, last_seq, entry_count, first_prev_hash, last_hash,
+			oldest_at, newest_at, signature
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (chain_day) DO UPDATE SET
+			first_seq = EXCLUDED.first_seq,
+			last_seq = EXCLUDED.last_seq,
+			entry_count = EXCLUDED.entry_count,
+			first_prev_hash = EXCLUDED.first_prev_hash,
+			last_hash = EXCLUDED.last_hash,
+			oldest_at = EXCLUDED.oldest_at,
+			newest_at = EXCLUDED.newest_at,
+			signature = EXCLUDED.signature,
+			created_at = NOW()
+	`, t.ChainDay, t.FirstSeq, t.LastSeq, t.EntryCount, t.FirstPrevHash, t.LastHash, t.OldestAt, t.NewestAt, t.Signature)
+	return err
+}
+
+// DeleteDayTx deletes the chained rows of a day up to lastSeq
+func (r *AuditChainRepository) DeleteDayTx(tx *sql.Tx, day string, lastSeq int64) (int64, error) {
+	result, err := tx.Exec(`
+		DELETE FROM audit_logs WHERE chain_day = $1 AND chain_seq <= $2
+	`, day, lastSeq)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
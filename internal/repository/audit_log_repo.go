@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/lib/pq"
 )
@@ -20,12 +21,96 @@ func NewAuditLogRepository() *AuditLogRepository {
 	return &AuditLogRepository{db: database.DB}
 }
 
+// auditLogColumns are the audit_logs columns scanAuditLogRow reads, in order
+var auditLogColumns = []string{
+	"id", "created_at", "user_id", "username", "user_role",
+	"action_type", "action_category", "action_description", "result",
+	"endpoint", "http_method", "status_code", "request_id", "session_id",
+	"request_body", "request_params", "response_body", "ip_address", "user_agent",
+	"geo_location", "device_type", "browser", "os",
+	"resource_type", "resource_id", "resource_ids", "changes",
+	"error_code", "error_type", "error_description", "error_message", "error_stack", "duration_ms",
+	"module_name", "method_name", "server_ip", "server_port", "page_url",
+}
+
+// auditLogQueryer is satisfied by *sql.DB and *sql.Tx
+type auditLogQueryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 func (r *AuditLogRepository) ListLogs(filters models.AuditLogQueryFilters, page, pageSize int, sortBy, sortOrder string) ([]models.AuditLogEntry, int, error) {
+	return listAuditLogs(r.db, "audit_logs", filters, page, pageSize, sortBy, sortOrder)
+}
+
+// auditLogStreamBatch is the number of rows fetched from the export cursor
+// at a time
+const auditLogStreamBatch = 1000
+
+// StreamLogs calls fn for every hot entry matching filters, oldest first.
+// Rows are read through a server-side cursor, so memory use does not depend
+// on how many rows match.
+func (r *AuditLogRepository) StreamLogs(filters models.AuditLogQueryFilters, fn func(models.AuditLogEntry) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	whereClause, args := buildAuditLogWhere(filters)
+	_, err = tx.Exec(fmt.Sprintf(`
+		DECLARE audit_log_stream NO SCROLL CURSOR FOR
+		SELECT %s FROM audit_logs %s
+		ORDER BY created_at ASC, id ASC
+	`, strings.Join(auditLogColumns, ", "), whereClause), args...)
+	if err != nil {
+		return err
+	}
 
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM audit_logs %s", whereClause)
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM audit_log_stream", auditLogStreamBatch)
+	for {
+		rows, err := tx.Query(fetch)
+		if err != nil {
+			return err
+		}
+		fetched := 0
+		for rows.Next() {
+			fetched++
+			entry, err := scanAuditLogRow(rows)
+			if err == nil {
+				err = fn(entry)
+			}
+			if err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return err
+		}
+		rows.Close()
+		if fetched < auditLogStreamBatch {
+			break
+		}
+	}
+	return tx.Commit()
+}
+
+// CountLogs returns the number of hot entries matching filters
+func (r *AuditLogRepository) CountLogs(filters models.AuditLogQueryFilters) (int64, error) {
+	whereClause, args := buildAuditLogWhere(filters)
+	var total int64
+	err := r.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM audit_logs %s", whereClause), args...).Scan(&total)
+	return total, err
+}
+
+func listAuditLogs(q auditLogQueryer, source string, filters models.AuditLogQueryFilters, page, pageSize int, sortBy, sortOrder string) ([]models.AuditLogEntry, int, error) {
+	whereClause, args := buildAuditLogWhere(filters)
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s %s", source, whereClause)
 	var total int
-	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+	if err := q.QueryRow(countQuery, args...).Scan(&total); err != nil {
 		return nil, 0, err
 	}
 
@@ -50,15 +135,15 @@ func (r *AuditLogRepository) ListLogs(filters models.AuditLogQueryFilters, page,
 			resource_type, resource_id, resource_ids, changes,
 			error_code, error_type, error_description, error_message, error_stack, duration_ms,
 			module_name, method_name, server_ip, server_port, page_url
-		FROM audit_logs
+		FROM %s
 		%s
 		ORDER BY %s %s
 		LIMIT $%d OFFSET $%d
-	`, whereClause, orderColumn, orderDirection, len(args)+1, len(args)+2)
+	`, source, whereClause, orderColumn, orderDirection, len(args)+1, len(args)+2)
 
 	args = append(args, pageSize, offset)
 
-	rows, err := r.db.Query(dataQuery, args...)
+	rows, err := q.Query(dataQuery, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -99,32 +184,118 @@ func (r *AuditLogRepository) GetLogByID(id string) (*models.AuditLogEntry, error
 	return &entry, nil
 }
 
-func (r *AuditLogRepository) CreateExportRecord(userID int, username, format string, filters json.RawMessage, fields []string) (string, error) {
+// FindFailedByRequestIDs returns the failed (4xx/5xx) entries of the given
+// requests made by userID, or without a user, since the given time. Requests
+// that wrote several entries are returned once, by their earliest entry.
+func (r *AuditLogRepository) FindFailedByRequestIDs(requestIDs []string, userID int, since time.Time) ([]models.AuditLogEntry, error) {
+	if len(requestIDs) == 0 {
+		return []models.AuditLogEntry{}, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT DISTINCT ON (request_id) %s
+		FROM audit_logs
+		WHERE request_id = ANY($1)
+			AND status_code >= 400
+			AND (user_id = $2 OR user_id IS NULL)
+			AND created_at >= $3
+		ORDER BY request_id, created_at
+	`, strings.Join(auditLogColumns, ", "))
+
+	rows, err := r.db.Query(query, pq.Array(requestIDs), userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.AuditLogEntry, 0, len(requestIDs))
+	for rows.Next() {
+		entry, err := scanAuditLogRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (r *AuditLogRepository) CreateExportRecord(userID int, username, format, compression string, filters json.RawMessage, fields []string) (string, error) {
 	query := `
-		INSERT INTO audit_log_exports (user_id, username, export_format, filters, fields)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO audit_log_exports (user_id, username, export_format, compression, filters, fields, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id
 	`
 
 	var id string
-	err := r.db.QueryRow(query, userID, username, format, nullableJSON(filters), pq.Array(fields)).Scan(&id)
+	err := r.db.QueryRow(query, userID, username, format, compression, nullableJSON(filters), pq.Array(fields), models.AuditExportQueued).Scan(&id)
 	if err != nil {
 		return "", err
 	}
 	return id, nil
 }
 
-func (r *AuditLogRepository) UpdateExportRecord(id string, status string, rowCount int, fileKey string, expiresAt time.Time, errorMessage string) error {
+// StartExportRecord marks a queued export as running with the number of rows
+// it is going to write
+func (r *AuditLogRepository) StartExportRecord(id string, totalRows int64) error {
 	query := `
 		UPDATE audit_log_exports
-		SET status = $1, row_count = $2, file_key = $3, expires_at = $4, error_message = $5
+		SET status = $1, total_rows = $2, started_at = NOW(), updated_at = NOW()
+		WHERE id = $3
+	`
+	_, err := r.db.Exec(query, models.AuditExportProcessing, totalRows, id)
+	return err
+}
+
+// UpdateExportProgress records a running export's progress; it also serves
+// as the job's heartbeat
+func (r *AuditLogRepository) UpdateExportProgress(id string, processedRows, sizeBytes int64) error {
+	query := `
+		UPDATE audit_log_exports
+		SET processed_rows = $1, size_bytes = $2, updated_at = NOW()
+		WHERE id = $3
+	`
+	_, err := r.db.Exec(query, processedRows, sizeBytes, id)
+	return err
+}
+
+// CompleteExportRecord marks an export as uploaded
+func (r *AuditLogRepository) CompleteExportRecord(id string, rowCount, sizeBytes int64, fileKey string, expiresAt time.Time) error {
+	query := `
+		UPDATE audit_log_exports
+		SET status = $1, row_count = $2, processed_rows = $2, size_bytes = $3, file_key = $4, expires_at = $5,
+			completed_at = NOW(), updated_at = NOW()
 		WHERE id = $6
 	`
+	_, err := r.db.Exec(query, models.AuditExportCompleted, rowCount, sizeBytes, fileKey, expiresAt, id)
+	return err
+}
 
-	_, err := r.db.Exec(query, status, nullableInt(rowCount), nullableString(fileKey), nullableTime(expiresAt), nullableString(errorMessage), id)
+// FailExportRecord marks an export as failed
+func (r *AuditLogRepository) FailExportRecord(id string, errorMessage string) error {
+	query := `
+		UPDATE audit_log_exports
+		SET status = $1, error_message = $2, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $3
+	`
+	_, err := r.db.Exec(query, models.AuditExportFailed, errorMessage, id)
 	return err
 }
 
+// FailStaleExports fails unfinished exports whose job stopped reporting
+// progress before the given time, such as jobs cut off by a restart
+func (r *AuditLogRepository) FailStaleExports(before time.Time) (int64, error) {
+	query := `
+		UPDATE audit_log_exports
+		SET status = $1, error_message = 'export job stopped responding', completed_at = NOW(), updated_at = NOW()
+		WHERE status IN ($2, $3) AND updated_at < $4
+	`
+	result, err := r.db.Exec(query, models.AuditExportFailed, models.AuditExportQueued, models.AuditExportProcessing, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 func (r *AuditLogRepository) ListExports(userID int, page, pageSize int) ([]models.AuditLogExportRecord, int, error) {
 	offset := (page - 1) * pageSize
 
@@ -139,7 +310,9 @@ func (r *AuditLogRepository) ListExports(userID int, page, pageSize int) ([]mode
 	}
 
 	dataQuery := `
-		SELECT id, user_id, username, export_format, filters, fields, status, row_count, file_key, expires_at, error_message, created_at
+		SELECT id, user_id, username, export_format, compression, filters, fields, status,
+			row_count, total_rows, processed_rows, size_bytes, file_key, expires_at, error_message,
+			started_at, completed_at, updated_at, created_at
 		FROM audit_log_exports
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -157,9 +330,9 @@ func (r *AuditLogRepository) ListExports(userID int, page, pageSize int) ([]mode
 		var record models.AuditLogExportRecord
 		var filtersBytes []byte
 		var fields []string
-		var rowCount sql.NullInt64
+		var rowCount, totalRows sql.NullInt64
 		var fileKey sql.NullString
-		var expiresAt sql.NullTime
+		var expiresAt, startedAt, completedAt sql.NullTime
 		var errorMessage sql.NullString
 
 		err := rows.Scan(
@@ -167,13 +340,20 @@ func (r *AuditLogRepository) ListExports(userID int, page, pageSize int) ([]mode
 			&record.UserID,
 			&record.Username,
 			&record.ExportFormat,
+			&record.Compression,
 			&filtersBytes,
 			pq.Array(&fields),
 			&record.Status,
 			&rowCount,
+			&totalRows,
+			&record.ProcessedRows,
+			&record.SizeBytes,
 			&fileKey,
 			&expiresAt,
 			&errorMessage,
+			&startedAt,
+			&completedAt,
+			&record.UpdatedAt,
 			&record.CreatedAt,
 		)
 		if err != nil {
@@ -183,7 +363,10 @@ func (r *AuditLogRepository) ListExports(userID int, page, pageSize int) ([]mode
 		record.Filters = rawFromBytes(filtersBytes)
 		record.Fields = fields
 		if rowCount.Valid {
-			record.RowCount = int(rowCount.Int64)
+			record.RowCount = rowCount.Int64
+		}
+		if totalRows.Valid {
+			record.TotalRows = &totalRows.Int64
 		}
 		if fileKey.Valid {
 			record.FileKey = &fileKey.String
@@ -194,11 +377,17 @@ func (r *AuditLogRepository) ListExports(userID int, page, pageSize int) ([]mode
 		if errorMessage.Valid {
 			record.ErrorMessage = &errorMessage.String
 		}
+		if startedAt.Valid {
+			record.StartedAt = &startedAt.Time
+		}
+		if completedAt.Valid {
+			record.CompletedAt = &completedAt.Time
+		}
 
 		records = append(records, record)
 	}
 
-	return records, total, nil
+	return records, total, rows.Err()
 }
 
 func (r *AuditLogRepository) CountExportsSince(userID int, since time.Time) (int, error) {
@@ -335,6 +524,152 @@ func buildAuditLogWhere(filters models.AuditLogQueryFilters) (string, []interfac
 	return "WHERE " + strings.Join(conditions, " AND "), args
 }
 
+// MatchAuditLogFilters reports whether an entry read back from an archive
+// matches filters the way buildAuditLogWhere's conditions match a row.
+// created_at is compared as a wall clock, as the TIMESTAMP column is.
+func MatchAuditLogFilters(entry models.AuditLogEntry, filters models.AuditLogQueryFilters) bool {
+	at := auditWallClock(entry.CreatedAt)
+	if at.Before(auditWallClock(filters.StartTime)) || at.After(auditWallClock(filters.EndTime)) {
+		return false
+	}
+	if filters.UserID != nil && (entry.UserID == nil || *entry.UserID != *filters.UserID) {
+		return false
+	}
+	if filters.Username != "" && !matchILike(entry.Username, "%"+filters.Username+"%") {
+		return false
+	}
+	if filters.UserRole != "" && entry.UserRole != filters.UserRole {
+		return false
+	}
+	if len(filters.ActionTypes) > 0 && !containsString(filters.ActionTypes, entry.ActionType) {
+		return false
+	}
+	if len(filters.ActionCategories) > 0 && !containsString(filters.ActionCategories, entry.ActionCategory) {
+		return false
+	}
+	if filters.Result != "" && entry.Result != filters.Result {
+		return false
+	}
+	if filters.IPAddress != "" && !matchAuditPattern(entry.IPAddress, filters.IPAddress) {
+		return false
+	}
+	if filters.Endpoint != "" && !matchAuditPattern(entry.Endpoint, filters.Endpoint) {
+		return false
+	}
+	if filters.HTTPMethod != "" && entry.HTTPMethod != strings.ToUpper(filters.HTTPMethod) {
+		return false
+	}
+	if filters.StatusCode != nil && entry.StatusCode != *filters.StatusCode {
+		return false
+	}
+	if filters.ResourceType != "" && entry.ResourceType != filters.ResourceType {
+		return false
+	}
+	if filters.ResourceID != "" && entry.ResourceID != filters.ResourceID {
+		return false
+	}
+	if filters.Keyword != "" && !matchAuditKeyword(entry.ActionDescription+" "+entry.ErrorMessage, filters.Keyword) {
+		return false
+	}
+	// A zero duration is stored as NULL, which no duration bound matches
+	if filters.MinDurationMs != nil && (entry.DurationMs == 0 || entry.DurationMs < *filters.MinDurationMs) {
+		return false
+	}
+	if filters.MaxDurationMs != nil && (entry.DurationMs == 0 || entry.DurationMs > *filters.MaxDurationMs) {
+		return false
+	}
+	if filters.GeoLocation != "" && entry.GeoLocation != filters.GeoLocation {
+		return false
+	}
+	if filters.DeviceType != "" && entry.DeviceType != filters.DeviceType {
+		return false
+	}
+	return true
+}
+
+func auditWallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAuditPattern matches the IP address and endpoint filters: '*' is a
+// wildcard and makes the match case-insensitive, otherwise it is exact
+func matchAuditPattern(value, filter string) bool {
+	pattern := strings.ReplaceAll(filter, "*", "%")
+	if strings.Contains(pattern, "%") {
+		return matchILike(value, pattern)
+	}
+	return value == pattern
+}
+
+// matchILike implements ILIKE: '%' matches any run of characters, '_' one
+// character and '\' escapes the next one
+func matchILike(value, pattern string) bool {
+	v := []rune(strings.ToLower(value))
+	p := []rune(strings.ToLower(pattern))
+	vi, pi := 0, 0
+	starP, starV := -1, 0
+	for vi < len(v) {
+		if pi < len(p) {
+			switch {
+			case p[pi] == '%':
+				starP, starV = pi, vi
+				pi++
+				continue
+			case p[pi] == '\\' && pi+1 < len(p):
+				if p[pi+1] == v[vi] {
+					pi += 2
+					vi++
+					continue
+				}
+			case p[pi] == '_' || p[pi] == v[vi]:
+				pi++
+				vi++
+				continue
+			}
+		}
+		if starP < 0 {
+			return false
+		}
+		starV++
+		pi, vi = starP+1, starV
+	}
+	for pi < len(p) && p[pi] == '%' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// matchAuditKeyword approximates the 'simple' full-text match of the keyword
+// filter: every word of the keyword must be a word of the text
+func matchAuditKeyword(text, keyword string) bool {
+	words := map[string]bool{}
+	for _, word := range auditTextWords(text) {
+		words[word] = true
+	}
+	wanted := auditTextWords(keyword)
+	for _, word := range wanted {
+		if !words[word] {
+			return false
+		}
+	}
+	return len(wanted) > 0
+}
+
+func auditTextWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
 type auditLogScanner interface {
 	Scan(dest ...interface{}) error
 }
@@ -501,10 +836,3 @@ func nullableJSON(value json.RawMessage) interface{} {
 	}
 	return value
 }
-
-func nullableTime(value time.Time) interface{} {
-	if value.IsZero() {
-		return nil
-	}
-	return value
-}
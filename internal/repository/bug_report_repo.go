@@ -7,6 +7,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"github.com/lib/pq"
 )
 
 type BugReportRepository struct {
@@ -33,9 +35,9 @@ func (r *BugReportRepository) Create(report *models.BugReport) error {
 	}
 
 	query := `
-		INSERT INTO bug_reports (user_id, title, description, error_details, page_url, user_agent, screenshots)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, created_at`
+		INSERT INTO bug_reports (user_id, title, description, error_details, page_url, user_agent, screenshots, trace_ids)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, status, created_at, updated_at`
 
 	return r.db.QueryRow(
 		query,
@@ -46,7 +48,28 @@ func (r *BugReportRepository) Create(report *models.BugReport) error {
 		nullableString(report.PageURL),
 		nullableString(report.UserAgent),
 		screenshotsJSON,
-	).Scan(&report.ID, &report.CreatedAt)
+		pq.Array(report.TraceIDs),
+	).Scan(&report.ID, &report.Status, &report.CreatedAt, &report.UpdatedAt)
+}
+
+// bugReportRecordQuery selects admin records; the placeholder narrows the
+// comment count to public comments when needed
+const bugReportRecordQuery = `
+		SELECT
+			br.id, br.user_id, u.username, br.title, br.description,
+			br.error_details, br.page_url, br.user_agent, br.screenshots,
+			br.trace_ids, br.error_signature, br.status, br.assignee_id, a.username, br.duplicate_of, br.resolution, br.resolved_at,
+			(SELECT COUNT(*) FROM bug_report_comments c WHERE c.report_id = br.id%s),
+			br.created_at, br.updated_at
+		FROM bug_reports br
+		JOIN users u ON u.id = br.user_id
+		LEFT JOIN users a ON a.id = br.assignee_id`
+
+func bugReportRecordSelect(publicCommentsOnly bool) string {
+	if publicCommentsOnly {
+		return fmt.Sprintf(bugReportRecordQuery, " AND NOT c.internal")
+	}
+	return fmt.Sprintf(bugReportRecordQuery, "")
 }
 
 func (r *BugReportRepository) ListWithFilters(filters models.BugReportQueryFilters, page, pageSize int) ([]models.BugReportAdminRecord, int, error) {
@@ -59,15 +82,10 @@ func (r *BugReportRepository) ListWithFilters(filters models.BugReportQueryFilte
 	}
 
 	offset := (page - 1) * pageSize
-	query := fmt.Sprintf(`
-		SELECT
-			br.id, br.user_id, u.username, br.title, br.description,
-			br.error_details, br.page_url, br.user_agent, br.screenshots, br.created_at
-		FROM bug_reports br
-		JOIN users u ON u.id = br.user_id
+	query := fmt.Sprintf(`%s
 		%s
 		ORDER BY br.created_at DESC
-		LIMIT $%d OFFSET $%d`, whereClause, len(args)+1, len(args)+2)
+		LIMIT $%d OFFSET $%d`, bugReportRecordSelect(filters.PublicCommentsOnly), whereClause, len(args)+1, len(args)+2)
 
 	args = append(args, pageSize, offset)
 	rows, err := r.db.Query(query, args...)
@@ -78,39 +96,390 @@ func (r *BugReportRepository) ListWithFilters(filters models.BugReportQueryFilte
 
 	records := make([]models.BugReportAdminRecord, 0)
 	for rows.Next() {
-		var record models.BugReportAdminRecord
-		var title, errorDetails, pageURL, userAgent sql.NullString
-		var screenshotsJSON []byte
+		record, err := scanBugReportRecord(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		records = append(records, record)
+	}
+
+	return records, total, rows.Err()
+}
+
+// GetRecord loads one report; it returns sql.ErrNoRows if it does not exist
+func (r *BugReportRepository) GetRecord(id int, publicCommentsOnly bool) (*models.BugReportAdminRecord, error) {
+	query := bugReportRecordSelect(publicCommentsOnly) + ` WHERE br.id = $1`
+	record, err := scanBugReportRecord(r.db.QueryRow(query, id))
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+type bugReportScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBugReportRecord(scanner bugReportScanner) (models.BugReportAdminRecord, error) {
+	var record models.BugReportAdminRecord
+	var title, errorDetails, pageURL, userAgent, errorSignature, assigneeUsername, resolution sql.NullString
+	var assigneeID, duplicateOf sql.NullInt64
+	var resolvedAt sql.NullTime
+	var screenshotsJSON []byte
+
+	if err := scanner.Scan(
+		&record.ID,
+		&record.UserID,
+		&record.Username,
+		&title,
+		&record.Description,
+		&errorDetails,
+		&pageURL,
+		&userAgent,
+		&screenshotsJSON,
+		pq.Array(&record.TraceIDs),
+		&errorSignature,
+		&record.Status,
+		&assigneeID,
+		&assigneeUsername,
+		&duplicateOf,
+		&resolution,
+		&resolvedAt,
+		&record.CommentCount,
+		&record.CreatedAt,
+		&record.UpdatedAt,
+	); err != nil {
+		return record, err
+	}
+
+	record.Title = title.String
+	record.ErrorDetails = errorDetails.String
+	record.PageURL = pageURL.String
+	record.UserAgent = userAgent.String
+	record.ErrorSignature = errorSignature.String
+	if record.TraceIDs == nil {
+		record.TraceIDs = []string{}
+	}
+	record.AssigneeUsername = assigneeUsername.String
+	record.Resolution = resolution.String
+	if assigneeID.Valid {
+		id := int(assigneeID.Int64)
+		record.AssigneeID = &id
+	}
+	if duplicateOf.Valid {
+		id := int(duplicateOf.Int64)
+		record.DuplicateOf = &id
+	}
+	if resolvedAt.Valid {
+		record.ResolvedAt = &resolvedAt.Time
+	}
+	if len(screenshotsJSON) > 0 {
+		if err := json.Unmarshal(screenshotsJSON, &record.Screenshots); err != nil {
+			return record, err
+		}
+	}
+	return record, nil
+}
+
+// GetForUpdateTx locks a report's row and returns its triage fields; it
+// returns sql.ErrNoRows if the report does not exist
+func (r *BugReportRepository) GetForUpdateTx(tx *sql.Tx, id int) (*models.BugReport, error) {
+	query := `
+		SELECT id, user_id, COALESCE(title, ''), status, assignee_id, duplicate_of,
+			COALESCE(resolution, ''), resolved_at, created_at, updated_at
+		FROM bug_reports
+		WHERE id = $1
+		FOR UPDATE`
+
+	var report models.BugReport
+	var assigneeID, duplicateOf sql.NullInt64
+	var resolvedAt sql.NullTime
+	if err := tx.QueryRow(query, id).Scan(
+		&report.ID, &report.UserID, &report.Title, &report.Status, &assigneeID, &duplicateOf,
+		&report.Resolution, &resolvedAt, &report.CreatedAt, &report.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if assigneeID.Valid {
+		value := int(assigneeID.Int64)
+		report.AssigneeID = &value
+	}
+	if duplicateOf.Valid {
+		value := int(duplicateOf.Int64)
+		report.DuplicateOf = &value
+	}
+	if resolvedAt.Valid {
+		report.ResolvedAt = &resolvedAt.Time
+	}
+	return &report, nil
+}
+
+// UpdateTriageTx writes a report's status, assignee, duplicate link and
+// resolution
+func (r *BugReportRepository) UpdateTriageTx(tx *sql.Tx, report *models.BugReport) error {
+	query := `
+		UPDATE bug_reports
+		SET status = $2, assignee_id = $3, duplicate_of = $4, resolution = $5,
+			resolved_at = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`
+
+	return tx.QueryRow(
+		query,
+		report.ID,
+		report.Status,
+		report.AssigneeID,
+		report.DuplicateOf,
+		nullableString(report.Resolution),
+		report.ResolvedAt,
+	).Scan(&report.UpdatedAt)
+}
+
+// RepointDuplicatesTx moves the duplicates of one report onto another, so
+// duplicate links never form chains
+func (r *BugReportRepository) RepointDuplicatesTx(tx *sql.Tx, fromID, toID int) error {
+	_, err := tx.Exec(`
+		UPDATE bug_reports SET duplicate_of = $2, updated_at = NOW()
+		WHERE duplicate_of = $1`, fromID, toID)
+	return err
+}
+
+// ListDuplicateReporters returns the distinct reporters of the reports
+// linked as duplicates of id
+func (r *BugReportRepository) ListDuplicateReporters(id int) ([]int, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT user_id FROM bug_reports WHERE duplicate_of = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := make([]int, 0)
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// CreateComment stores a comment on a report
+func (r *BugReportRepository) CreateComment(comment *models.BugReportComment) error {
+	query := `
+		INSERT INTO bug_report_comments (report_id, parent_id, author_id, body, internal)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	return r.db.QueryRow(
+		query,
+		comment.ReportID,
+		comment.ParentID,
+		comment.AuthorID,
+		comment.Body,
+		comment.Internal,
+	).Scan(&comment.ID, &comment.CreatedAt)
+}
+
+// ListComments returns a report's comments oldest first, without internal
+// notes unless includeInternal is set
+func (r *BugReportRepository) ListComments(reportID int, includeInternal bool) ([]models.BugReportComment, error) {
+	query := `
+		SELECT c.id, c.report_id, c.parent_id, c.author_id, u.username, u.role,
+			c.body, c.internal, c.created_at
+		FROM bug_report_comments c
+		JOIN users u ON u.id = c.author_id
+		WHERE c.report_id = $1 AND ($2 OR NOT c.internal)
+		ORDER BY c.created_at, c.id`
 
+	rows, err := r.db.Query(query, reportID, includeInternal)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := make([]models.BugReportComment, 0)
+	for rows.Next() {
+		var comment models.BugReportComment
+		var parentID sql.NullInt64
 		if err := rows.Scan(
-			&record.ID,
-			&record.UserID,
-			&record.Username,
-			&title,
-			&record.Description,
-			&errorDetails,
-			&pageURL,
-			&userAgent,
-			&screenshotsJSON,
-			&record.CreatedAt,
+			&comment.ID, &comment.ReportID, &parentID, &comment.AuthorID, &comment.AuthorUsername,
+			&comment.AuthorRole, &comment.Body, &comment.Internal, &comment.CreatedAt,
 		); err != nil {
-			return nil, 0, err
+			return nil, err
+		}
+		if parentID.Valid {
+			value := int(parentID.Int64)
+			comment.ParentID = &value
 		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// GetCommentReport returns the report a comment belongs to and whether it
+// is an internal note; it returns sql.ErrNoRows if the comment does not exist
+func (r *BugReportRepository) GetCommentReport(commentID int) (int, bool, error) {
+	var reportID int
+	var internal bool
+	err := r.db.QueryRow(`SELECT report_id, internal FROM bug_report_comments WHERE id = $1`, commentID).
+		Scan(&reportID, &internal)
+	return reportID, internal, err
+}
+
+// InsertErrorLinks links failed requests to a report; traces that are
+// already linked are skipped
+func (r *BugReportRepository) InsertErrorLinks(reportID int, links []models.BugReportErrorLink) error {
+	if len(links) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO bug_report_errors (
+			report_id, trace_id, audit_log_id, occurred_at, endpoint, http_method,
+			status_code, error_code, error_type, error_message, error_stack
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (report_id, trace_id) DO NOTHING`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
 
-		record.Title = title.String
-		record.ErrorDetails = errorDetails.String
-		record.PageURL = pageURL.String
-		record.UserAgent = userAgent.String
-		if len(screenshotsJSON) > 0 {
-			if err := json.Unmarshal(screenshotsJSON, &record.Screenshots); err != nil {
-				return nil, 0, err
-			}
+	for _, link := range links {
+		if _, err := stmt.Exec(
+			reportID,
+			link.TraceID,
+			link.AuditLogID,
+			link.OccurredAt,
+			nullableString(link.Endpoint),
+			nullableString(link.HTTPMethod),
+			link.StatusCode,
+			nullableString(link.ErrorCode),
+			nullableString(link.ErrorType),
+			nullableString(link.ErrorMessage),
+			nullableString(link.ErrorStack),
+		); err != nil {
+			return err
 		}
+	}
+	return tx.Commit()
+}
 
-		records = append(records, record)
+// ListErrorLinks returns the linked errors of the given reports, oldest
+// first, keyed by report. Stacks are only loaded when withStack is set.
+func (r *BugReportRepository) ListErrorLinks(reportIDs []int, withStack bool) (map[int][]models.BugReportErrorLink, error) {
+	links := make(map[int][]models.BugReportErrorLink, len(reportIDs))
+	if len(reportIDs) == 0 {
+		return links, nil
+	}
+
+	stackColumn := "NULL"
+	if withStack {
+		stackColumn = "error_stack"
 	}
+	query := fmt.Sprintf(`
+		SELECT report_id, trace_id, audit_log_id, occurred_at, endpoint, http_method,
+			status_code, error_code, error_type, error_message, %s
+		FROM bug_report_errors
+		WHERE report_id = ANY($1)
+		ORDER BY occurred_at, id`, stackColumn)
+
+	rows, err := r.db.Query(query, pq.Array(reportIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	return records, total, nil
+	for rows.Next() {
+		var reportID int
+		var link models.BugReportErrorLink
+		var endpoint, method, errorCode, errorType, errorMessage, errorStack sql.NullString
+		var statusCode sql.NullInt64
+		if err := rows.Scan(
+			&reportID, &link.TraceID, &link.AuditLogID, &link.OccurredAt, &endpoint, &method,
+			&statusCode, &errorCode, &errorType, &errorMessage, &errorStack,
+		); err != nil {
+			return nil, err
+		}
+		link.Endpoint = endpoint.String
+		link.HTTPMethod = method.String
+		link.StatusCode = int(statusCode.Int64)
+		link.ErrorCode = errorCode.String
+		link.ErrorType = errorType.String
+		link.ErrorMessage = errorMessage.String
+		link.ErrorStack = errorStack.String
+		links[reportID] = append(links[reportID], link)
+	}
+	return links, rows.Err()
+}
+
+// SetErrorSignature stores the signature reports are grouped by; an empty
+// signature leaves the report ungrouped
+func (r *BugReportRepository) SetErrorSignature(reportID int, signature string) error {
+	_, err := r.db.Exec(`UPDATE bug_reports SET error_signature = $2 WHERE id = $1`, reportID, nullableString(signature))
+	return err
+}
+
+// ListErrorGroups pages through the error signatures shared by reports,
+// the most recently reported first
+func (r *BugReportRepository) ListErrorGroups(openOnly bool, page, pageSize int) ([]models.BugReportErrorGroup, int, error) {
+	having := ""
+	if openOnly {
+		having = "HAVING COUNT(*) FILTER (WHERE status NOT IN ('resolved', 'wontfix')) > 0"
+	}
+
+	countQuery := fmt.Sprintf(`
+		SELECT COUNT(*) FROM (
+			SELECT error_signature FROM bug_reports
+			WHERE error_signature IS NOT NULL
+			GROUP BY error_signature %s
+		) groups`, having)
+	total := 0
+	if err := r.db.QueryRow(countQuery).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			error_signature,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE status NOT IN ('resolved', 'wontfix')),
+			COUNT(DISTINCT user_id),
+			MIN(created_at),
+			MAX(created_at),
+			(ARRAY_AGG(id ORDER BY created_at DESC))[1]
+		FROM bug_reports
+		WHERE error_signature IS NOT NULL
+		GROUP BY error_signature
+		%s
+		ORDER BY MAX(created_at) DESC
+		LIMIT $1 OFFSET $2`, having)
+
+	rows, err := r.db.Query(query, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	groups := make([]models.BugReportErrorGroup, 0)
+	for rows.Next() {
+		var group models.BugReportErrorGroup
+		if err := rows.Scan(
+			&group.ErrorSignature, &group.ReportCount, &group.OpenCount, &group.ReporterCount,
+			&group.FirstReportedAt, &group.LastReportedAt, &group.LatestReportID,
+		); err != nil {
+			return nil, 0, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, total, rows.Err()
 }
 
 func buildBugReportFilters(filters models.BugReportQueryFilters) (string, []interface{}) {
@@ -152,6 +521,24 @@ func buildBugReportFilters(filters models.BugReportQueryFilters) (string, []inte
 	if filters.EndTime != nil {
 		conditions = append(conditions, fmt.Sprintf("br.created_at <= $%d", index))
 		args = append(args, *filters.EndTime)
+		index++
+	}
+
+	if filters.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("br.status = $%d", index))
+		args = append(args, filters.Status)
+		index++
+	}
+
+	if filters.AssigneeID != nil {
+		conditions = append(conditions, fmt.Sprintf("br.assignee_id = $%d", index))
+		args = append(args, *filters.AssigneeID)
+		index++
+	}
+
+	if filters.ErrorSignature != "" {
+		conditions = append(conditions, fmt.Sprintf("br.error_signature = $%d", index))
+		args = append(args, filters.ErrorSignature)
 	}
 
 	if len(conditions) == 0 {
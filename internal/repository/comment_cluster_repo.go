@@ -0,0 +1,442 @@
+package repository
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/pkg/database"
+	"comment-review-platform/pkg/textsim"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type CommentClusterRepository struct {
+	db *sql.DB
+}
+
+func NewCommentClusterRepository() *CommentClusterRepository {
+	return &CommentClusterRepository{db: database.DB}
+}
+
+// SignedComment is a pending first review task with its comment's signature
+type SignedComment struct {
+	TaskID    int
+	CommentID int64
+	Text      string
+	Signature textsim.Signature
+}
+
+// ClusterSeed is an open cluster's representative signature
+type ClusterSeed struct {
+	ID        int
+	Signature textsim.Signature
+}
+
+// ListUnsignedPendingTasks returns pending first review tasks whose comment
+// has no signature yet, oldest first
+func (r *CommentClusterRepository) ListUnsignedPendingTasks(limit int) ([]SignedComment, error) {
+	query := `
+		SELECT t.id, t.comment_id, COALESCE(c.text, '')
+		FROM review_tasks t
+		JOIN comment c ON c.id = t.comment_id
+		WHERE t.status = 'pending'
+		  AND NOT EXISTS (SELECT 1 FROM comment_signatures s WHERE s.task_id = t.id)
+		ORDER BY t.id
+		LIMIT $1
+	`
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []SignedComment{}
+	for rows.Next() {
+		var comment SignedComment
+		if err := rows.Scan(&comment.TaskID, &comment.CommentID, &comment.Text); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// SaveSignatures stores the signatures of the given comments
+func (r *CommentClusterRepository) SaveSignatures(comments []SignedComment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+	taskIDs := make([]int, len(comments))
+	commentIDs := make([]int64, len(comments))
+	signatures := make([]string, len(comments))
+	for i, comment := range comments {
+		taskIDs[i], commentIDs[i] = comment.TaskID, comment.CommentID
+		signatures[i] = signatureLiteral(comment.Signature)
+	}
+	query := `
+		INSERT INTO comment_signatures (task_id, comment_id, minhash, created_at)
+		SELECT s.task_id, s.comment_id, s.minhash::integer[], NOW()
+		FROM unnest($1::integer[], $2::bigint[], $3::text[]) AS s(task_id, comment_id, minhash)
+		ON CONFLICT (task_id) DO NOTHING
+	`
+	_, err := r.db.Exec(query, pq.Array(taskIDs), pq.Array(commentIDs), pq.Array(signatures))
+	return err
+}
+
+// ListOpenClusterSeeds returns the representative signature of every open cluster
+func (r *CommentClusterRepository) ListOpenClusterSeeds() ([]ClusterSeed, error) {
+	rows, err := r.db.Query(`SELECT id, minhash FROM comment_clusters WHERE status = 'open' ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seeds := []ClusterSeed{}
+	for rows.Next() {
+		var seed ClusterSeed
+		var minhash []int64
+		if err := rows.Scan(&seed.ID, pq.Array(&minhash)); err != nil {
+			return nil, err
+		}
+		seed.Signature = toSignature(minhash)
+		seeds = append(seeds, seed)
+	}
+	return seeds, rows.Err()
+}
+
+// ListUnclusteredPending returns signed comments created after since that are
+// still pending and belong to no cluster, oldest first
+func (r *CommentClusterRepository) ListUnclusteredPending(since time.Time, limit int) ([]SignedComment, error) {
+	query := `
+		SELECT s.task_id, s.comment_id, COALESCE(c.text, ''), s.minhash
+		FROM comment_signatures s
+		JOIN review_tasks t ON t.id = s.task_id
+		JOIN comment c ON c.id = s.comment_id
+		WHERE s.cluster_id IS NULL
+		  AND s.excluded_at IS NULL
+		  AND s.created_at >= $1
+		  AND t.status = 'pending'
+		ORDER BY s.task_id
+		LIMIT $2
+	`
+	rows, err := r.db.Query(query, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []SignedComment{}
+	for rows.Next() {
+		var comment SignedComment
+		var minhash []int64
+		if err := rows.Scan(&comment.TaskID, &comment.CommentID, &comment.Text, pq.Array(&minhash)); err != nil {
+			return nil, err
+		}
+		comment.Signature = toSignature(minhash)
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// CreateCluster opens a cluster represented by the given comment
+func (r *CommentClusterRepository) CreateCluster(representative SignedComment) (int, error) {
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO comment_clusters (representative_task_id, representative_text, minhash, status, created_at, updated_at)
+		VALUES ($1, $2, $3::integer[], 'open', NOW(), NOW())
+		RETURNING id
+	`, representative.TaskID, representative.Text, signatureLiteral(representative.Signature)).Scan(&id)
+	return id, err
+}
+
+// AssignMembers adds tasks to a cluster with their similarity to its representative
+func (r *CommentClusterRepository) AssignMembers(clusterID int, taskIDs []int, similarities []float64) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE comment_signatures s
+		SET cluster_id = $1, similarity = m.similarity
+		FROM unnest($2::integer[], $3::numeric[]) AS m(task_id, similarity)
+		WHERE s.task_id = m.task_id AND s.cluster_id IS NULL
+	`, clusterID, pq.Array(taskIDs), pq.Array(similarities))
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE comment_clusters SET updated_at = NOW() WHERE id = $1`, clusterID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CloseReviewedClusters closes open clusters none of whose members are still
+// pending, i.e. every member was reviewed on its own. Returns the number closed.
+func (r *CommentClusterRepository) CloseReviewedClusters() (int64, error) {
+	result, err := r.db.Exec(`
+		UPDATE comment_clusters cl
+		SET status = 'closed', updated_at = NOW()
+		WHERE cl.status = 'open'
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM comment_signatures s
+			JOIN review_tasks t ON t.id = s.task_id
+			WHERE s.cluster_id = cl.id AND s.excluded_at IS NULL AND t.status <> 'completed'
+		  )
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListOpenClusters returns open clusters with at least minSize members still
+// pending, largest first
+func (r *CommentClusterRepository) ListOpenClusters(minSize, page, pageSize int) ([]models.CommentCluster, int, error) {
+	base := `
+		FROM comment_clusters cl
+		JOIN LATERAL (
+			SELECT COUNT(*) AS pending_count
+			FROM comment_signatures s
+			JOIN review_tasks t ON t.id = s.task_id
+			WHERE s.cluster_id = cl.id AND s.excluded_at IS NULL AND t.status <> 'completed'
+		) m ON TRUE
+		WHERE cl.status = 'open' AND m.pending_count >= $1
+	`
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) `+base, minSize).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT cl.id, cl.representative_task_id, cl.representative_text, cl.status, m.pending_count,
+			cl.decided_by, cl.decided_at, cl.created_at, cl.updated_at
+	` + base + `
+		ORDER BY m.pending_count DESC, cl.id
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.Query(query, minSize, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	clusters := []models.CommentCluster{}
+	for rows.Next() {
+		var cl models.CommentCluster
+		if err := rows.Scan(
+			&cl.ID, &cl.RepresentativeTaskID, &cl.RepresentativeText, &cl.Status, &cl.PendingCount,
+			&cl.DecidedBy, &cl.DecidedAt, &cl.CreatedAt, &cl.UpdatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		clusters = append(clusters, cl)
+	}
+	return clusters, total, rows.Err()
+}
+
+// GetCluster returns a cluster with its members that are not yet completed or
+// excluded, most similar first
+func (r *CommentClusterRepository) GetCluster(id int) (*models.CommentCluster, error) {
+	var cl models.CommentCluster
+	err := r.db.QueryRow(`
+		SELECT id, representative_task_id, representative_text, status, decided_by, decided_at, created_at, updated_at
+		FROM comment_clusters
+		WHERE id = $1
+	`, id).Scan(&cl.ID, &cl.RepresentativeTaskID, &cl.RepresentativeText, &cl.Status,
+		&cl.DecidedBy, &cl.DecidedAt, &cl.CreatedAt, &cl.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT t.id, t.comment_id, COALESCE(c.text, ''), COALESCE(s.similarity, 1), t.status, t.reviewer_id, t.created_at
+		FROM comment_signatures s
+		JOIN review_tasks t ON t.id = s.task_id
+		JOIN comment c ON c.id = s.comment_id
+		WHERE s.cluster_id = $1 AND s.excluded_at IS NULL AND t.status <> 'completed'
+		ORDER BY s.similarity DESC NULLS FIRST, t.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cl.Members = []models.CommentClusterMember{}
+	for rows.Next() {
+		var m models.CommentClusterMember
+		if err := rows.Scan(&m.TaskID, &m.CommentID, &m.Text, &m.Similarity, &m.Status, &m.ReviewerID, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		cl.Members = append(cl.Members, m)
+	}
+	cl.PendingCount = len(cl.Members)
+	return &cl, rows.Err()
+}
+
+// LockClusterTx locks a cluster row for a decision and returns its status
+func (r *CommentClusterRepository) LockClusterTx(tx *sql.Tx, id int) (string, error) {
+	var status string
+	err := tx.QueryRow(`SELECT status FROM comment_clusters WHERE id = $1 FOR UPDATE`, id).Scan(&status)
+	return status, err
+}
+
+// ClusterMemberLock is a member task locked for a cluster decision
+type ClusterMemberLock struct {
+	TaskID    int
+	CommentID int64
+	Status    string
+}
+
+// LockDecidableMembersTx locks the cluster's members a reviewer may decide:
+// pending tasks, and tasks that reviewer has claimed. Members claimed by
+// someone else, or locked by a concurrent submit, are counted as skipped.
+func (r *CommentClusterRepository) LockDecidableMembersTx(tx *sql.Tx, clusterID, reviewerID int, excludeTaskIDs []int) ([]ClusterMemberLock, int, error) {
+	rows, err := tx.Query(`
+		SELECT t.id, t.comment_id, t.status
+		FROM comment_signatures s
+		JOIN review_tasks t ON t.id = s.task_id
+		WHERE s.cluster_id = $1
+		  AND s.excluded_at IS NULL
+		  AND NOT (t.id = ANY($3::integer[]))
+		  AND (t.status = 'pending' OR (t.status = 'in_progress' AND t.reviewer_id = $2))
+		ORDER BY t.id
+		FOR UPDATE OF t SKIP LOCKED
+	`, clusterID, reviewerID, pq.Array(excludeTaskIDs))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	members := []ClusterMemberLock{}
+	for rows.Next() {
+		var m ClusterMemberLock
+		if err := rows.Scan(&m.TaskID, &m.CommentID, &m.Status); err != nil {
+			return nil, 0, err
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var open int
+	err = tx.QueryRow(`
+		SELECT COUNT(*)
+		FROM comment_signatures s
+		JOIN review_tasks t ON t.id = s.task_id
+		WHERE s.cluster_id = $1
+		  AND s.excluded_at IS NULL
+		  AND NOT (t.id = ANY($2::integer[]))
+		  AND t.status <> 'completed'
+	`, clusterID, pq.Array(excludeTaskIDs)).Scan(&open)
+	if err != nil {
+		return nil, 0, err
+	}
+	return members, open - len(members), nil
+}
+
+// CompleteTasksTx completes the given first review tasks on behalf of a
+// reviewer, claiming the ones that were still pending
+func (r *CommentClusterRepository) CompleteTasksTx(tx *sql.Tx, reviewerID int, taskIDs []int) error {
+	_, err := tx.Exec(`
+		UPDATE review_tasks
+		SET status = 'completed', reviewer_id = $1, claimed_at = COALESCE(claimed_at, NOW()), completed_at = NOW()
+		WHERE id = ANY($2::integer[]) AND status <> 'completed'
+	`, reviewerID, pq.Array(taskIDs))
+	return err
+}
+
+// CreateReviewResultsTx creates one review result per task with the same
+// decision and returns the new result IDs keyed by task ID. Tasks that
+// already have a result are left out.
+func (r *CommentClusterRepository) CreateReviewResultsTx(tx *sql.Tx, reviewerID int, taskIDs []int, isApproved bool, tags []string, reason string) (map[int]int, error) {
+	rows, err := tx.Query(`
+		INSERT INTO review_results (task_id, reviewer_id, is_approved, tags, reason, created_at)
+		SELECT t.task_id, $2, $3, $4, $5, NOW()
+		FROM unnest($1::integer[]) AS t(task_id)
+		ON CONFLICT (task_id) DO NOTHING
+		RETURNING id, task_id
+	`, pq.Array(taskIDs), reviewerID, isApproved, pq.Array(tags), reason)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := map[int]int{}
+	for rows.Next() {
+		var id, taskID int
+		if err := rows.Scan(&id, &taskID); err != nil {
+			return nil, err
+		}
+		results[taskID] = id
+	}
+	return results, rows.Err()
+}
+
+// ExcludeMembersTx takes the given tasks out of the cluster's decisions;
+// they stay in the queue to be reviewed on their own
+func (r *CommentClusterRepository) ExcludeMembersTx(tx *sql.Tx, clusterID int, taskIDs []int) (int64, error) {
+	if len(taskIDs) == 0 {
+		return 0, nil
+	}
+	result, err := tx.Exec(`
+		UPDATE comment_signatures
+		SET excluded_at = NOW()
+		WHERE cluster_id = $1 AND task_id = ANY($2::integer[]) AND excluded_at IS NULL
+	`, clusterID, pq.Array(taskIDs))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// FinishDecisionTx marks the cluster decided by reviewerID once none of its
+// members are left open, and returns the cluster's resulting status
+func (r *CommentClusterRepository) FinishDecisionTx(tx *sql.Tx, clusterID, reviewerID int) (string, error) {
+	var status string
+	err := tx.QueryRow(`
+		WITH remaining AS (
+			SELECT EXISTS (
+				SELECT 1
+				FROM comment_signatures s
+				JOIN review_tasks t ON t.id = s.task_id
+				WHERE s.cluster_id = $1 AND s.excluded_at IS NULL AND t.status <> 'completed'
+			) AS open
+		)
+		UPDATE comment_clusters cl
+		SET status = CASE WHEN r.open THEN cl.status ELSE 'decided' END,
+			decided_by = CASE WHEN r.open THEN cl.decided_by ELSE $2 END,
+			decided_at = CASE WHEN r.open THEN cl.decided_at ELSE NOW() END,
+			updated_at = NOW()
+		FROM remaining r
+		WHERE cl.id = $1
+		RETURNING cl.status
+	`, clusterID, reviewerID).Scan(&status)
+	return status, err
+}
+
+// PostgreSQL has no unsigned integers; MinHash values are stored bit-for-bit
+// as INTEGER and passed as array literals so a batch fits one unnest
+func signatureLiteral(sig textsim.Signature) string {
+	values := make([]string, len(sig))
+	for i, v := range sig {
+		values[i] = fmt.Sprint(int32(v))
+	}
+	return "{" + strings.Join(values, ",") + "}"
+}
+
+func toSignature(values []int64) textsim.Signature {
+	var sig textsim.Signature
+	for i := 0; i < len(values) && i < len(sig); i++ {
+		sig[i] = uint32(int32(values[i]))
+	}
+	return sig
+}
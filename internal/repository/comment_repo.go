@@ -1,8 +1,13 @@
 package repository
 
 import (
+	"comment-review-platform/internal/models"
 	"comment-review-platform/pkg/database"
+	"context"
 	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
 )
 
 type CommentRepository struct {
@@ -13,24 +18,141 @@ func NewCommentRepository() *CommentRepository {
 	return &CommentRepository{db: database.DB}
 }
 
-// UpdateModerationStatus updates the moderation status for a comment.
-func (r *CommentRepository) UpdateModerationStatus(commentID int64, status string) error {
+// CommentStatusUpdate is one comment of a bulk moderation status change,
+// with the review task that decided it
+type CommentStatusUpdate struct {
+	CommentID int64
+	TaskID    int
+}
+
+// GetModerationStatusTx returns a comment's current moderation status within a transaction
+func (r *CommentRepository) GetModerationStatusTx(ctx context.Context, tx *sql.Tx, commentID int64) (string, error) {
+	var status string
+	err := tx.QueryRowContext(ctx, `SELECT moderation_status FROM comment WHERE id = $1`, commentID).Scan(&status)
+	return status, err
+}
+
+// UpdateModerationStatus updates the moderation status for a comment and logs the transition.
+func (r *CommentRepository) UpdateModerationStatus(commentID int64, status string, change models.StatusChange) error {
+	return updateModerationStatus(r.db, commentID, status, change)
+}
+
+// UpdateModerationStatusTx updates the moderation status within a transaction and logs the transition.
+func (r *CommentRepository) UpdateModerationStatusTx(ctx context.Context, tx *sql.Tx, commentID int64, status string, change models.StatusChange) error {
+	return updateModerationStatus(contextExecutor{ctx: ctx, db: tx}, commentID, status, change)
+}
+
+// updateModerationStatus validates a comment's status change against
+// models.CommentStatusMachine, then sets the status and records the
+// transition in the same statement. Writing the current status again is a no-op.
+func updateModerationStatus(db reviewResultExecutor, commentID int64, status string, change models.StatusChange) error {
+	var from string
+	if err := db.QueryRow(`SELECT moderation_status FROM comment WHERE id = $1 FOR UPDATE`, commentID).Scan(&from); err != nil {
+		return err
+	}
+	if from == status {
+		return nil
+	}
+	if err := models.CommentStatusMachine.Validate(from, status); err != nil {
+		return err
+	}
+
+	// Only write if the status is still the one validated against; outside a
+	// transaction the row lock above is already released
 	query := `
-		UPDATE comment
-		SET moderation_status = $1
-		WHERE id = $2
+		WITH updated AS (
+			UPDATE comment
+			SET moderation_status = $2
+			WHERE id = $1 AND moderation_status = $3
+			RETURNING id
+		)
+		INSERT INTO comment_status_transitions (comment_id, from_status, to_status, source, task_id, actor_id, reason, created_at)
+		SELECT id, $3, $2, $4, $5, $6, NULLIF($7, ''), NOW()
+		FROM updated
 	`
-	_, err := r.db.Exec(query, status, commentID)
-	return err
+	result, err := db.Exec(query, commentID, status, from, change.Source, change.TaskID, change.ActorID, change.Reason)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrStatusChanged
+	}
+	return nil
 }
 
-// UpdateModerationStatusTx updates the moderation status within a transaction.
-func (r *CommentRepository) UpdateModerationStatusTx(tx *sql.Tx, commentID int64, status string) error {
+// UpdateModerationStatusesTx sets the same moderation status on several
+// comments within a transaction and logs each transition with the task that
+// decided it; change.TaskID is ignored. Nothing is written unless every
+// comment may move to the status.
+func (r *CommentRepository) UpdateModerationStatusesTx(tx *sql.Tx, updates []CommentStatusUpdate, status string, change models.StatusChange) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	commentIDs := make([]int64, len(updates))
+	for i, u := range updates {
+		commentIDs[i] = u.CommentID
+	}
+
+	rows, err := tx.Query(`SELECT id, moderation_status FROM comment WHERE id = ANY($1::bigint[]) FOR UPDATE`, pq.Array(commentIDs))
+	if err != nil {
+		return err
+	}
+	current := make(map[int64]string, len(updates))
+	for rows.Next() {
+		var id int64
+		var from string
+		if err := rows.Scan(&id, &from); err != nil {
+			rows.Close()
+			return err
+		}
+		current[id] = from
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var changedComments, changedTasks []int64
+	var fromStatuses []string
+	for _, u := range updates {
+		from, ok := current[u.CommentID]
+		if !ok {
+			return fmt.Errorf("comment %d: %w", u.CommentID, sql.ErrNoRows)
+		}
+		if from == status {
+			continue
+		}
+		if err := models.CommentStatusMachine.Validate(from, status); err != nil {
+			return fmt.Errorf("comment %d: %w", u.CommentID, err)
+		}
+		changedComments = append(changedComments, u.CommentID)
+		changedTasks = append(changedTasks, int64(u.TaskID))
+		fromStatuses = append(fromStatuses, from)
+	}
+	if len(changedComments) == 0 {
+		return nil
+	}
+
 	query := `
-		UPDATE comment
-		SET moderation_status = $1
-		WHERE id = $2
+		WITH changes AS (
+			SELECT * FROM unnest($1::bigint[], $2::bigint[], $3::text[]) AS c(comment_id, task_id, from_status)
+		), updated AS (
+			UPDATE comment m
+			SET moderation_status = $4
+			FROM changes c
+			WHERE m.id = c.comment_id
+			RETURNING m.id
+		)
+		INSERT INTO comment_status_transitions (comment_id, from_status, to_status, source, task_id, actor_id, reason, created_at)
+		SELECT c.comment_id, c.from_status, $4, $5, c.task_id, $6, NULLIF($7, ''), NOW()
+		FROM changes c
+		JOIN updated u ON u.id = c.comment_id
 	`
-	_, err := tx.Exec(query, status, commentID)
+	_, err = tx.Exec(query, pq.Array(changedComments), pq.Array(changedTasks), pq.Array(fromStatuses),
+		status, change.Source, change.ActorID, change.Reason)
 	return err
 }
@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/pkg/database"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type ErrorIssueRepository struct {
+	db *sql.DB
+}
+
+func NewErrorIssueRepository() *ErrorIssueRepository {
+	return &ErrorIssueRepository{db: database.DB}
+}
+
+// ErrorIssueGroup is the occurrences of one fingerprint within a batch of
+// audit entries; the descriptive fields come from its latest occurrence
+type ErrorIssueGroup struct {
+	Fingerprint string
+	HTTPMethod  string
+	Endpoint    string
+	ErrorCode   string
+	ErrorType   string
+	StackFrames []string
+	StatusCode  int
+	Title       string
+	SampleStack string
+	LastTraceID string
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+	Occurrences []models.ErrorIssueOccurrence
+}
+
+// errorIssueRegressed holds when an upsert reopens an issue: it was resolved
+// and failed again after being resolved
+const errorIssueRegressed = `error_issues.status = 'resolved' AND EXCLUDED.last_seen_at > error_issues.resolved_at`
+
+// UpsertGroupTx adds a group's occurrences to its issue, creating the issue
+// on first sight. A resolved issue that failed again since it was resolved
+// is reopened; regressed reports whether that happened.
+func (r *ErrorIssueRepository) UpsertGroupTx(tx *sql.Tx, group ErrorIssueGroup) (id int, regressed bool, err error) {
+	query := fmt.Sprintf(`
+		INSERT INTO error_issues (
+			fingerprint, http_method, endpoint, error_code, error_type, stack_frames, status_code,
+			title, sample_stack, last_trace_id, occurrence_count, first_seen_at, last_seen_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (fingerprint) DO UPDATE SET
+			error_type = COALESCE(EXCLUDED.error_type, error_issues.error_type),
+			status_code = EXCLUDED.status_code,
+			title = COALESCE(EXCLUDED.title, error_issues.title),
+			sample_stack = COALESCE(EXCLUDED.sample_stack, error_issues.sample_stack),
+			last_trace_id = COALESCE(EXCLUDED.last_trace_id, error_issues.last_trace_id),
+			occurrence_count = error_issues.occurrence_count + EXCLUDED.occurrence_count,
+			first_seen_at = LEAST(error_issues.first_seen_at, EXCLUDED.first_seen_at),
+			last_seen_at = GREATEST(error_issues.last_seen_at, EXCLUDED.last_seen_at),
+			status = CASE WHEN %[1]s THEN 'open' ELSE error_issues.status END,
+			resolved_at = CASE WHEN %[1]s THEN NULL ELSE error_issues.resolved_at END,
+			resolved_by = CASE WHEN %[1]s THEN NULL ELSE error_issues.resolved_by END,
+			regressed_at = CASE WHEN %[1]s THEN NOW() ELSE error_issues.regressed_at END,
+			regression_count = error_issues.regression_count + CASE WHEN %[1]s THEN 1 ELSE 0 END,
+			updated_at = NOW()
+		RETURNING id, COALESCE(regressed_at = NOW(), FALSE)`, errorIssueRegressed)
+
+	err = tx.QueryRow(
+		query,
+		group.Fingerprint,
+		group.HTTPMethod,
+		group.Endpoint,
+		group.ErrorCode,
+		nullableString(group.ErrorType),
+		pq.Array(group.StackFrames),
+		group.StatusCode,
+		nullableString(group.Title),
+		nullableString(group.SampleStack),
+		nullableString(group.LastTraceID),
+		len(group.Occurrences),
+		group.FirstSeenAt,
+		group.LastSeenAt,
+	).Scan(&id, &regressed)
+	return id, regressed, err
+}
+
+// InsertOccurrencesTx records an issue's occurrences and trims them to the
+// most recent keep
+func (r *ErrorIssueRepository) InsertOccurrencesTx(tx *sql.Tx, issueID int, occurrences []models.ErrorIssueOccurrence, keep int) error {
+	if len(occurrences) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO error_issue_occurrences (
+			issue_id, audit_log_id, trace_id, user_id, username, status_code, error_message, occurred_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, occurrence := range occurrences {
+		if _, err := stmt.Exec(
+			issueID,
+			occurrence.AuditLogID,
+			nullableString(occurrence.TraceID),
+			occurrence.UserID,
+			nullableString(occurrence.Username),
+			occurrence.StatusCode,
+			nullableString(occurrence.ErrorMessage),
+			occurrence.OccurredAt,
+		); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM error_issue_occurrences
+		WHERE issue_id = $1 AND id NOT IN (
+			SELECT id FROM error_issue_occurrences
+			WHERE issue_id = $1
+			ORDER BY occurred_at DESC, id DESC
+			LIMIT $2
+		)`, issueID, keep)
+	return err
+}
+
+// AddAffectedUsersTx records the users an issue affected and refreshes its
+// distinct user count
+func (r *ErrorIssueRepository) AddAffectedUsersTx(tx *sql.Tx, issueID int, userIDs []int, seenAt time.Time) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO error_issue_users (issue_id, user_id, first_seen_at)
+		SELECT $1, UNNEST($2::int[]), $3
+		ON CONFLICT (issue_id, user_id) DO NOTHING`, issueID, pq.Array(userIDs), seenAt); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(`
+		UPDATE error_issues
+		SET affected_users = (SELECT COUNT(*) FROM error_issue_users WHERE issue_id = $1)
+		WHERE id = $1`, issueID)
+	return err
+}
+
+// errorIssueSortColumns maps the accepted sort keys to their ORDER BY
+var errorIssueSortColumns = map[string]string{
+	"last_seen":   "ei.last_seen_at DESC",
+	"first_seen":  "ei.first_seen_at DESC",
+	"occurrences": "ei.occurrence_count DESC, ei.last_seen_at DESC",
+	"users":       "ei.affected_users DESC, ei.last_seen_at DESC",
+}
+
+const errorIssueSelect = `
+		SELECT
+			ei.id, ei.fingerprint, ei.http_method, ei.endpoint, ei.error_code, ei.error_type,
+			ei.stack_frames, ei.status_code, ei.title, ei.last_trace_id, ei.status,
+			ei.occurrence_count, ei.affected_users, ei.first_seen_at, ei.last_seen_at,
+			ei.resolved_at, ei.resolved_by, u.username, ei.regressed_at, ei.regression_count, ei.updated_at
+		FROM error_issues ei
+		LEFT JOIN users u ON u.id = ei.resolved_by`
+
+// List pages through issues matching filters
+func (r *ErrorIssueRepository) List(filters models.ErrorIssueListRequest, page, pageSize int) ([]models.ErrorIssue, int, error) {
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+	if filters.Status != "" {
+		args = append(args, filters.Status)
+		conditions = append(conditions, fmt.Sprintf("ei.status = $%d", len(args)))
+	}
+	if filters.ErrorCode != "" {
+		args = append(args, filters.ErrorCode)
+		conditions = append(conditions, fmt.Sprintf("ei.error_code = $%d", len(args)))
+	}
+	if filters.Keyword != "" {
+		args = append(args, "%"+filters.Keyword+"%")
+		conditions = append(conditions, fmt.Sprintf("(ei.endpoint ILIKE $%[1]d OR ei.title ILIKE $%[1]d)", len(args)))
+	}
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	total := 0
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM error_issues ei `+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	orderBy, ok := errorIssueSortColumns[filters.Sort]
+	if !ok {
+		orderBy = errorIssueSortColumns["last_seen"]
+	}
+	query := fmt.Sprintf(`%s
+		%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d`, errorIssueSelect, whereClause, orderBy, len(args)+1, len(args)+2)
+	args = append(args, pageSize, (page-1)*pageSize)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	issues := make([]models.ErrorIssue, 0)
+	for rows.Next() {
+		issue, err := scanErrorIssue(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		issues = append(issues, issue)
+	}
+	return issues, total, rows.Err()
+}
+
+// Get loads an issue with its latest stack; it returns sql.ErrNoRows if it
+// does not exist
+func (r *ErrorIssueRepository) Get(id int) (*models.ErrorIssueDetail, error) {
+	query := strings.Replace(errorIssueSelect, "ei.updated_at", "ei.updated_at, ei.sample_stack", 1) + ` WHERE ei.id = $1`
+	var sampleStack sql.NullString
+	issue, err := scanErrorIssue(r.db.QueryRow(query, id), &sampleStack)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ErrorIssueDetail{ErrorIssue: issue, SampleStack: sampleStack.String}, nil
+}
+
+// ListOccurrences returns an issue's most recent occurrences, newest first
+func (r *ErrorIssueRepository) ListOccurrences(issueID, limit int) ([]models.ErrorIssueOccurrence, error) {
+	rows, err := r.db.Query(`
+		SELECT audit_log_id, trace_id, user_id, username, status_code, error_message, occurred_at
+		FROM error_issue_occurrences
+		WHERE issue_id = $1
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT $2`, issueID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	occurrences := make([]models.ErrorIssueOccurrence, 0)
+	for rows.Next() {
+		var occurrence models.ErrorIssueOccurrence
+		var traceID, username, errorMessage sql.NullString
+		var userID sql.NullInt64
+		if err := rows.Scan(
+			&occurrence.AuditLogID, &traceID, &userID, &username, &occurrence.StatusCode,
+			&errorMessage, &occurrence.OccurredAt,
+		); err != nil {
+			return nil, err
+		}
+		occurrence.TraceID = traceID.String
+		occurrence.Username = username.String
+		occurrence.ErrorMessage = errorMessage.String
+		if userID.Valid {
+			value := int(userID.Int64)
+			occurrence.UserID = &value
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+	return occurrences, rows.Err()
+}
+
+// GetForUpdateTx locks an issue's row and returns it; it returns
+// sql.ErrNoRows if the issue does not exist
+func (r *ErrorIssueRepository) GetForUpdateTx(tx *sql.Tx, id int) (*models.ErrorIssue, error) {
+	issue, err := scanErrorIssue(tx.QueryRow(errorIssueSelect+` WHERE ei.id = $1 FOR UPDATE OF ei`, id))
+	if err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// UpdateStatusTx writes an issue's status and resolution fields
+func (r *ErrorIssueRepository) UpdateStatusTx(tx *sql.Tx, issue *models.ErrorIssue) error {
+	return tx.QueryRow(`
+		UPDATE error_issues
+		SET status = $2, resolved_at = $3, resolved_by = $4, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`,
+		issue.ID, issue.Status, issue.ResolvedAt, issue.ResolvedBy,
+	).Scan(&issue.UpdatedAt)
+}
+
+type errorIssueScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanErrorIssue scans a row of errorIssueSelect; extra receives any columns
+// selected after it
+func scanErrorIssue(scanner errorIssueScanner, extra ...interface{}) (models.ErrorIssue, error) {
+	var issue models.ErrorIssue
+	var errorType, title, lastTraceID, resolvedByUsername sql.NullString
+	var resolvedBy sql.NullInt64
+	var resolvedAt, regressedAt sql.NullTime
+
+	dest := []interface{}{
+		&issue.ID,
+		&issue.Fingerprint,
+		&issue.HTTPMethod,
+		&issue.Endpoint,
+		&issue.ErrorCode,
+		&errorType,
+		pq.Array(&issue.StackFrames),
+		&issue.StatusCode,
+		&title,
+		&lastTraceID,
+		&issue.Status,
+		&issue.OccurrenceCount,
+		&issue.AffectedUsers,
+		&issue.FirstSeenAt,
+		&issue.LastSeenAt,
+		&resolvedAt,
+		&resolvedBy,
+		&resolvedByUsername,
+		&regressedAt,
+		&issue.RegressionCount,
+		&issue.UpdatedAt,
+	}
+	if err := scanner.Scan(append(dest, extra...)...); err != nil {
+		return issue, err
+	}
+
+	issue.ErrorType = errorType.String
+	issue.Title = title.String
+	issue.LastTraceID = lastTraceID.String
+	issue.ResolvedByUsername = resolvedByUsername.String
+	if issue.StackFrames == nil {
+		issue.StackFrames = []string{}
+	}
+	if resolvedAt.Valid {
+		issue.ResolvedAt = &resolvedAt.Time
+	}
+	if resolvedBy.Valid {
+		value := int(resolvedBy.Int64)
+		issue.ResolvedBy = &value
+	}
+	if regressedAt.Valid {
+		issue.RegressedAt = &regressedAt.Time
+	}
+	return issue, nil
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"comment-review-platform/pkg/database"
+	"database/sql"
+)
+
+type IdentityRepository struct {
+	db *sql.DB
+}
+
+func NewIdentityRepository() *IdentityRepository {
+	return &IdentityRepository{db: database.DB}
+}
+
+// FindUserID returns the platform user linked to an external identity
+func (r *IdentityRepository) FindUserID(issuer, subject string) (int, error) {
+	var userID int
+	err := r.db.QueryRow(
+		`SELECT user_id FROM user_identities WHERE issuer = $1 AND subject = $2`,
+		issuer, subject,
+	).Scan(&userID)
+	return userID, err
+}
+
+// Link binds an external identity to a platform user
+func (r *IdentityRepository) Link(userID int, issuer, subject, email string) error {
+	query := `
+		INSERT INTO user_identities (user_id, issuer, subject, email)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (issuer, subject) DO NOTHING`
+	_, err := r.db.Exec(query, userID, issuer, subject, nullableString(email))
+	return err
+}
+
+// TouchLogin records a successful login through an identity
+func (r *IdentityRepository) TouchLogin(issuer, subject, email string) error {
+	query := `
+		UPDATE user_identities
+		SET last_login_at = NOW(), email = COALESCE($3, email)
+		WHERE issuer = $1 AND subject = $2`
+	_, err := r.db.Exec(query, issuer, subject, nullableString(email))
+	return err
+}
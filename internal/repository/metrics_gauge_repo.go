@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"comment-review-platform/internal/models"
+	"context"
+	"database/sql"
+)
+
+// MetricsGaugeRepository reads the business gauges exposed on /metrics
+type MetricsGaugeRepository struct {
+	db *sql.DB
+}
+
+func NewMetricsGaugeRepository(db *sql.DB) *MetricsGaugeRepository {
+	return &MetricsGaugeRepository{db: db}
+}
+
+// reviewQueues are the task tables behind each review queue, named as in the
+// task queue stats
+var reviewQueues = []struct {
+	Name  string
+	Table string
+}{
+	{"comment_first_review", "review_tasks"},
+	{"comment_second_review", "second_review_tasks"},
+	{"ai_human_diff", "ai_human_diff_tasks"},
+	{"quality_check", "quality_check_tasks"},
+	{"video_first_review", "video_first_review_tasks"},
+	{"video_second_review", "video_second_review_tasks"},
+}
+
+var openTaskStatuses = []string{"pending", "in_progress"}
+
+// QueueTaskCounts counts the pending and in-progress tasks of each review
+// queue, reporting zero for empty ones
+func (r *MetricsGaugeRepository) QueueTaskCounts(ctx context.Context) ([]models.QueueTaskCount, error) {
+	query := ""
+	for i, queue := range reviewQueues {
+		if i > 0 {
+			query += " UNION ALL "
+		}
+		query += `SELECT '` + queue.Name + `', status, COUNT(*) FROM ` + queue.Table +
+			` WHERE status IN ('pending', 'in_progress') GROUP BY status`
+	}
+	found, err := r.queryTaskCounts(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]int, len(found))
+	for _, count := range found {
+		byKey[count.Queue+"/"+count.Status] = count.Count
+	}
+	counts := make([]models.QueueTaskCount, 0, len(reviewQueues)*len(openTaskStatuses))
+	for _, queue := range reviewQueues {
+		for _, status := range openTaskStatuses {
+			counts = append(counts, models.QueueTaskCount{Queue: queue.Name, Status: status, Count: byKey[queue.Name+"/"+status]})
+		}
+	}
+	return counts, nil
+}
+
+// PoolTaskCounts counts the pending and in-progress video queue tasks of
+// every traffic pool, including pools without tasks
+func (r *MetricsGaugeRepository) PoolTaskCounts(ctx context.Context) ([]models.QueueTaskCount, error) {
+	return r.queryTaskCounts(ctx, `
+		SELECT p.name, s.status, COUNT(t.id)
+		FROM video_pools p
+		CROSS JOIN (VALUES ('pending'), ('in_progress')) AS s(status)
+		LEFT JOIN video_queue_tasks t ON t.pool = p.name AND t.status = s.status
+		GROUP BY p.name, s.status
+		ORDER BY p.name, s.status`)
+}
+
+func (r *MetricsGaugeRepository) queryTaskCounts(ctx context.Context, query string) ([]models.QueueTaskCount, error) {
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]models.QueueTaskCount, 0)
+	for rows.Next() {
+		var count models.QueueTaskCount
+		if err := rows.Scan(&count.Queue, &count.Status, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+// ActiveAIReviewJobs returns the progress of scheduled and running AI review
+// jobs
+func (r *MetricsGaugeRepository) ActiveAIReviewJobs(ctx context.Context) ([]models.AIReviewJobProgress, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, status, total_tasks, completed_tasks, failed_tasks
+		FROM ai_review_jobs
+		WHERE status IN ('scheduled', 'running')
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := make([]models.AIReviewJobProgress, 0)
+	for rows.Next() {
+		var job models.AIReviewJobProgress
+		if err := rows.Scan(&job.JobID, &job.Status, &job.TotalTasks, &job.CompletedTasks, &job.FailedTasks); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
@@ -18,8 +18,8 @@ func NewNotificationRepository(db *sql.DB) *NotificationRepository {
 // Create creates a new notification
 func (r *NotificationRepository) Create(notification *models.Notification) error {
 	query := `
-		INSERT INTO notifications (title, content, type, created_by, is_global)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO notifications (title, content, type, created_by, is_global, recipient_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, created_at`
 
 	err := r.db.QueryRow(
@@ -29,6 +29,7 @@ func (r *NotificationRepository) Create(notification *models.Notification) error
 		notification.Type,
 		notification.CreatedBy,
 		notification.IsGlobal,
+		notification.RecipientID,
 	).Scan(&notification.ID, &notification.CreatedAt)
 
 	return err
@@ -43,7 +44,7 @@ func (r *NotificationRepository) GetUnreadByUser(userID int, limit int) ([]model
 			un.read_at
 		FROM notifications n
 		LEFT JOIN user_notifications un ON n.id = un.notification_id AND un.user_id = $1
-		WHERE n.is_global = true AND (un.is_read = false OR un.is_read IS NULL)
+		WHERE (n.is_global = true OR n.recipient_id = $1) AND (un.is_read = false OR un.is_read IS NULL)
 		ORDER BY n.created_at DESC
 		LIMIT $2`
 
@@ -76,7 +77,7 @@ func (r *NotificationRepository) GetUnreadCount(userID int) (int, error) {
 		SELECT COUNT(*)
 		FROM notifications n
 		LEFT JOIN user_notifications un ON n.id = un.notification_id AND un.user_id = $1
-		WHERE n.is_global = true AND (un.is_read = false OR un.is_read IS NULL)`
+		WHERE (n.is_global = true OR n.recipient_id = $1) AND (un.is_read = false OR un.is_read IS NULL)`
 
 	var count int
 	err := r.db.QueryRow(query, userID).Scan(&count)
@@ -104,7 +105,7 @@ func (r *NotificationRepository) GetRecent(userID int, limit, offset int) ([]mod
 			un.read_at
 		FROM notifications n
 		LEFT JOIN user_notifications un ON n.id = un.notification_id AND un.user_id = $1
-		WHERE n.is_global = true
+		WHERE n.is_global = true OR n.recipient_id = $1
 		ORDER BY n.created_at DESC
 		LIMIT $2 OFFSET $3`
 
@@ -131,25 +132,25 @@ func (r *NotificationRepository) GetRecent(userID int, limit, offset int) ([]mod
 	return notifications, nil
 }
 
-// GetTotalCount returns total count of notifications for pagination
-func (r *NotificationRepository) GetTotalCount() (int, error) {
-	query := `SELECT COUNT(*) FROM notifications WHERE is_global = true`
+// GetTotalCount returns the count of notifications a user can see, for pagination
+func (r *NotificationRepository) GetTotalCount(userID int) (int, error) {
+	query := `SELECT COUNT(*) FROM notifications WHERE is_global = true OR recipient_id = $1`
 
 	var count int
-	err := r.db.QueryRow(query).Scan(&count)
+	err := r.db.QueryRow(query, userID).Scan(&count)
 	return count, err
 }
 
 // GetByID retrieves a notification by ID
 func (r *NotificationRepository) GetByID(id int) (*models.Notification, error) {
 	query := `
-		SELECT id, title, content, type, created_by, created_at, is_global
+		SELECT id, title, content, type, created_by, created_at, is_global, recipient_id
 		FROM notifications
 		WHERE id = $1`
 
 	var n models.Notification
 	err := r.db.QueryRow(query, id).Scan(
-		&n.ID, &n.Title, &n.Content, &n.Type, &n.CreatedBy, &n.CreatedAt, &n.IsGlobal,
+		&n.ID, &n.Title, &n.Content, &n.Type, &n.CreatedBy, &n.CreatedAt, &n.IsGlobal, &n.RecipientID,
 	)
 
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/lib/pq"
 )
@@ -68,12 +69,13 @@ func (r *PermissionRepository) GetPermissionByKey(key string) (*models.Permissio
 	return &p, nil
 }
 
-// GetUserPermissions retrieves all permission keys for a user
+// GetUserPermissions retrieves all unexpired permission keys for a user
 func (r *PermissionRepository) GetUserPermissions(userID int) ([]string, error) {
 	query := `
 		SELECT permission_key
 		FROM user_permissions
 		WHERE user_id = $1
+		  AND (expires_at IS NULL OR expires_at > NOW())
 		ORDER BY permission_key
 	`
 
@@ -95,13 +97,52 @@ func (r *PermissionRepository) GetUserPermissions(userID int) ([]string, error)
 	return permissions, nil
 }
 
-// HasPermission checks if a user has a specific permission
+// GetUserPermissionGrants retrieves all unexpired grants (with scope and expiry) for a user
+func (r *PermissionRepository) GetUserPermissionGrants(userID int) ([]models.PermissionGrant, error) {
+	query := `
+		SELECT permission_key, COALESCE(scopes, '{}'), expires_at
+		FROM user_permissions
+		WHERE user_id = $1
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY permission_key
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user permission grants: %w", err)
+	}
+	defer rows.Close()
+
+	grants := []models.PermissionGrant{}
+	for rows.Next() {
+		var grant models.PermissionGrant
+		var scopes pq.StringArray
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&grant.PermissionKey, &scopes, &expiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan permission grant: %w", err)
+		}
+		if len(scopes) > 0 {
+			grant.Scopes = []string(scopes)
+		}
+		if expiresAt.Valid {
+			expires := expiresAt.Time
+			grant.ExpiresAt = &expires
+		}
+		grants = append(grants, grant)
+	}
+
+	return grants, nil
+}
+
+// HasPermission checks if a user has a specific, unexpired, global permission
 func (r *PermissionRepository) HasPermission(userID int, permissionKey string) (bool, error) {
 	query := `
 		SELECT EXISTS(
 			SELECT 1
 			FROM user_permissions
 			WHERE user_id = $1 AND permission_key = $2
+			  AND (expires_at IS NULL OR expires_at > NOW())
+			  AND COALESCE(cardinality(scopes), 0) = 0
 		)
 	`
 
@@ -114,8 +155,9 @@ func (r *PermissionRepository) HasPermission(userID int, permissionKey string) (
 	return exists, nil
 }
 
-// GrantPermissions grants multiple permissions to a user
-func (r *PermissionRepository) GrantPermissions(userID int, permissionKeys []string, grantedBy *int) error {
+// GrantPermissions grants multiple permissions to a user.
+// Re-granting an existing key replaces its expiry and scopes.
+func (r *PermissionRepository) GrantPermissions(userID int, permissionKeys []string, grantedBy *int, expiresAt *time.Time, scopes []string) error {
 	if len(permissionKeys) == 0 {
 		return nil
 	}
@@ -129,11 +171,19 @@ func (r *PermissionRepository) GrantPermissions(userID int, permissionKeys []str
 
 	// Insert permissions
 	query := `
-		INSERT INTO user_permissions (user_id, permission_key, granted_by)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (user_id, permission_key) DO NOTHING
+		INSERT INTO user_permissions (user_id, permission_key, granted_by, expires_at, scopes)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, permission_key) DO UPDATE
+		SET granted_by = EXCLUDED.granted_by,
+			expires_at = EXCLUDED.expires_at,
+			scopes = EXCLUDED.scopes
 	`
 
+	var scopesValue interface{}
+	if len(scopes) > 0 {
+		scopesValue = pq.Array(scopes)
+	}
+
 	stmt, err := tx.Prepare(query)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
@@ -141,7 +191,7 @@ func (r *PermissionRepository) GrantPermissions(userID int, permissionKeys []str
 	defer stmt.Close()
 
 	for _, key := range permissionKeys {
-		_, err := stmt.Exec(userID, key, grantedBy)
+		_, err := stmt.Exec(userID, key, grantedBy, expiresAt, scopesValue)
 		if err != nil {
 			return fmt.Errorf("failed to grant permission %s: %w", key, err)
 		}
@@ -174,6 +224,81 @@ func (r *PermissionRepository) RevokePermissions(userID int, permissionKeys []st
 	return nil
 }
 
+// ExpireGrants removes grants whose expires_at has passed and records them in
+// user_permission_expirations. It returns the affected user IDs.
+func (r *PermissionRepository) ExpireGrants() ([]int, error) {
+	query := `
+		WITH expired AS (
+			DELETE FROM user_permissions
+			WHERE expires_at IS NOT NULL AND expires_at <= NOW()
+			RETURNING user_id, permission_key, scopes, granted_by, expires_at
+		), recorded AS (
+			INSERT INTO user_permission_expirations (user_id, permission_key, scopes, granted_by, expires_at)
+			SELECT user_id, permission_key, scopes, granted_by, expires_at FROM expired
+			RETURNING user_id
+		)
+		SELECT DISTINCT user_id FROM recorded
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire permission grants: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan expired grant user: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// ListGrantExpirations retrieves recorded grant expirations, optionally for a single user
+func (r *PermissionRepository) ListGrantExpirations(userID *int, limit int) ([]models.PermissionGrantExpiration, error) {
+	if limit < 1 || limit > 500 {
+		limit = 100
+	}
+
+	query := `
+		SELECT id, user_id, permission_key, COALESCE(scopes, '{}'), granted_by, expires_at, expired_at
+		FROM user_permission_expirations
+		WHERE ($1::int IS NULL OR user_id = $1)
+		ORDER BY expired_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query grant expirations: %w", err)
+	}
+	defer rows.Close()
+
+	expirations := []models.PermissionGrantExpiration{}
+	for rows.Next() {
+		var item models.PermissionGrantExpiration
+		var scopes pq.StringArray
+		var grantedBy sql.NullInt64
+		if err := rows.Scan(&item.ID, &item.UserID, &item.PermissionKey, &scopes, &grantedBy, &item.ExpiresAt, &item.ExpiredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan grant expiration: %w", err)
+		}
+		if len(scopes) > 0 {
+			item.Scopes = []string(scopes)
+		}
+		if grantedBy.Valid {
+			value := int(grantedBy.Int64)
+			item.GrantedBy = &value
+		}
+		expirations = append(expirations, item)
+	}
+
+	return expirations, nil
+}
+
 // ListPermissions retrieves permissions with filtering and pagination
 func (r *PermissionRepository) ListPermissions(resource, category, search string, page, pageSize int) ([]models.Permission, int, error) {
 	// Build WHERE clause
@@ -3,6 +3,7 @@ package repository
 import (
 	"comment-review-platform/internal/models"
 	"comment-review-platform/pkg/database"
+	"context"
 	"database/sql"
 	"strings"
 	"time"
@@ -24,8 +25,8 @@ func (r *SecondReviewRepository) CreateSecondReviewTask(firstReviewResultID int,
 }
 
 // CreateSecondReviewTaskTx creates a new second review task within a transaction
-func (r *SecondReviewRepository) CreateSecondReviewTaskTx(tx *sql.Tx, firstReviewResultID int, commentID int64) (bool, error) {
-	return createSecondReviewTask(tx, firstReviewResultID, commentID)
+func (r *SecondReviewRepository) CreateSecondReviewTaskTx(ctx context.Context, tx *sql.Tx, firstReviewResultID int, commentID int64) (bool, error) {
+	return createSecondReviewTask(contextExecutor{ctx: ctx, db: tx}, firstReviewResultID, commentID)
 }
 
 type secondReviewExecutor interface {
@@ -49,6 +50,36 @@ func createSecondReviewTask(db secondReviewExecutor, firstReviewResultID int, co
 	return rowsAffected > 0, nil
 }
 
+// CreateSecondReviewTasksTx creates second review tasks for several first
+// review results within a transaction and returns the comment IDs that got one
+func (r *SecondReviewRepository) CreateSecondReviewTasksTx(tx *sql.Tx, firstReviewResultIDs []int, commentIDs []int64) ([]int64, error) {
+	if len(firstReviewResultIDs) == 0 {
+		return nil, nil
+	}
+	query := `
+		INSERT INTO second_review_tasks (first_review_result_id, comment_id, status, created_at)
+		SELECT t.first_review_result_id, t.comment_id, 'pending', NOW()
+		FROM unnest($1::integer[], $2::bigint[]) AS t(first_review_result_id, comment_id)
+		ON CONFLICT (first_review_result_id) DO NOTHING
+		RETURNING comment_id
+	`
+	rows, err := tx.Query(query, pq.Array(firstReviewResultIDs), pq.Array(commentIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var created []int64
+	for rows.Next() {
+		var commentID int64
+		if err := rows.Scan(&commentID); err != nil {
+			return nil, err
+		}
+		created = append(created, commentID)
+	}
+	return created, rows.Err()
+}
+
 // GetCommentIDByTaskID retrieves the comment ID for a second review task.
 func (r *SecondReviewRepository) GetCommentIDByTaskID(taskID int) (int64, error) {
 	query := `SELECT comment_id FROM second_review_tasks WHERE id = $1`
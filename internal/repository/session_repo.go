@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/pkg/database"
+	"database/sql"
+	"time"
+)
+
+type SessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository() *SessionRepository {
+	return &SessionRepository{db: database.DB}
+}
+
+const sessionColumns = `
+	id, user_id, refresh_token_hash, access_jti, access_expires_at,
+	user_agent, ip_address, created_at, last_used_at, expires_at,
+	revoked_at, revoked_reason`
+
+func scanSession(scanner interface{ Scan(...interface{}) error }) (*models.UserSession, error) {
+	var session models.UserSession
+	var userAgent, ipAddress, revokedReason sql.NullString
+	var revokedAt sql.NullTime
+	if err := scanner.Scan(
+		&session.ID, &session.UserID, &session.RefreshTokenHash, &session.AccessJTI, &session.AccessExpiresAt,
+		&userAgent, &ipAddress, &session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt,
+		&revokedAt, &revokedReason,
+	); err != nil {
+		return nil, err
+	}
+	if userAgent.Valid {
+		session.UserAgent = &userAgent.String
+	}
+	if ipAddress.Valid {
+		session.IPAddress = &ipAddress.String
+	}
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	if revokedReason.Valid {
+		session.RevokedReason = &revokedReason.String
+	}
+	return &session, nil
+}
+
+// Create inserts a new login session
+func (r *SessionRepository) Create(session *models.UserSession) error {
+	query := `
+		INSERT INTO user_sessions (
+			id, user_id, refresh_token_hash, access_jti, access_expires_at,
+			user_agent, ip_address, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at, last_used_at`
+	return r.db.QueryRow(
+		query,
+		session.ID,
+		session.UserID,
+		session.RefreshTokenHash,
+		session.AccessJTI,
+		session.AccessExpiresAt,
+		session.UserAgent,
+		session.IPAddress,
+		session.ExpiresAt,
+	).Scan(&session.CreatedAt, &session.LastUsedAt)
+}
+
+// FindByID finds a session by ID
+func (r *SessionRepository) FindByID(id string) (*models.UserSession, error) {
+	query := `SELECT ` + sessionColumns + ` FROM user_sessions WHERE id = $1`
+	return scanSession(r.db.QueryRow(query, id))
+}
+
+// FindByRefreshHash finds the session whose current refresh token has the given hash
+func (r *SessionRepository) FindByRefreshHash(hash string) (*models.UserSession, error) {
+	query := `SELECT ` + sessionColumns + ` FROM user_sessions WHERE refresh_token_hash = $1`
+	return scanSession(r.db.QueryRow(query, hash))
+}
+
+// FindByPreviousHash finds the session whose previous (already rotated) refresh token has the given hash
+func (r *SessionRepository) FindByPreviousHash(hash string) (*models.UserSession, error) {
+	query := `SELECT ` + sessionColumns + ` FROM user_sessions WHERE previous_token_hash = $1`
+	return scanSession(r.db.QueryRow(query, hash))
+}
+
+// Rotate swaps the refresh token of an active session. It only succeeds when the
+// presented hash is still current, so two concurrent refreshes cannot both win.
+func (r *SessionRepository) Rotate(id, currentHash, newHash, accessJTI string, accessExpiresAt time.Time, userAgent, ipAddress *string) (bool, error) {
+	query := `
+		UPDATE user_sessions
+		SET previous_token_hash = refresh_token_hash,
+			refresh_token_hash = $3,
+			access_jti = $4,
+			access_expires_at = $5,
+			user_agent = COALESCE($6, user_agent),
+			ip_address = COALESCE($7, ip_address),
+			last_used_at = NOW()
+		WHERE id = $1 AND refresh_token_hash = $2 AND revoked_at IS NULL AND expires_at > NOW()`
+	result, err := r.db.Exec(query, id, currentHash, newHash, accessJTI, accessExpiresAt, userAgent, ipAddress)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// ListActiveByUser lists unrevoked, unexpired sessions of a user, most recently used first
+func (r *SessionRepository) ListActiveByUser(userID int) ([]models.UserSession, error) {
+	query := `SELECT ` + sessionColumns + `
+		FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]models.UserSession, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, rows.Err()
+}
+
+// Revoke revokes one session and returns it, or sql.ErrNoRows if it was already revoked
+func (r *SessionRepository) Revoke(id, reason string) (*models.UserSession, error) {
+	query := `
+		UPDATE user_sessions
+		SET revoked_at = NOW(), revoked_reason = $2
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING ` + sessionColumns
+	return scanSession(r.db.QueryRow(query, id, reason))
+}
+
+// RevokeAllByUser revokes every active session of a user and returns the revoked rows
+func (r *SessionRepository) RevokeAllByUser(userID int, reason string) ([]models.UserSession, error) {
+	query := `
+		UPDATE user_sessions
+		SET revoked_at = NOW(), revoked_reason = $2
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		RETURNING ` + sessionColumns
+	rows, err := r.db.Query(query, userID, reason)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]models.UserSession, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, rows.Err()
+}
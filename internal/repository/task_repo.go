@@ -3,6 +3,7 @@ package repository
 import (
 	"comment-review-platform/internal/models"
 	"comment-review-platform/pkg/database"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -28,6 +29,24 @@ type reviewResultExecutor interface {
 	QueryRow(query string, args ...interface{}) *sql.Row
 }
 
+// contextExecutor runs the statements of the shared helpers with ctx, so
+// they are traced under the span of the request that issued them
+type contextExecutor struct {
+	ctx context.Context
+	db  interface {
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+		QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	}
+}
+
+func (e contextExecutor) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return e.db.ExecContext(e.ctx, query, args...)
+}
+
+func (e contextExecutor) QueryRow(query string, args ...interface{}) *sql.Row {
+	return e.db.QueryRowContext(e.ctx, query, args...)
+}
+
 // CreateTask creates a new review task
 func (r *TaskRepository) CreateTask(commentID int64) error {
 	query := `
@@ -39,8 +58,8 @@ func (r *TaskRepository) CreateTask(commentID int64) error {
 }
 
 // ClaimTasks claims pending tasks for a reviewer
-func (r *TaskRepository) ClaimTasks(reviewerID int, limit int) ([]models.ReviewTask, error) {
-	tx, err := r.db.Begin()
+func (r *TaskRepository) ClaimTasks(ctx context.Context, reviewerID int, limit int) ([]models.ReviewTask, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -55,7 +74,7 @@ func (r *TaskRepository) ClaimTasks(reviewerID int, limit int) ([]models.ReviewT
 		LIMIT $1
 		FOR UPDATE SKIP LOCKED
 	`
-	rows, err := tx.Query(query, limit)
+	rows, err := tx.QueryContext(ctx, query, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +103,7 @@ func (r *TaskRepository) ClaimTasks(reviewerID int, limit int) ([]models.ReviewT
 		SET status = 'in_progress', reviewer_id = $1, claimed_at = $2
 		WHERE id = ANY($3)
 	`
-	_, err = tx.Exec(updateQuery, reviewerID, now, pq.Array(taskIDs))
+	_, err = tx.ExecContext(ctx, updateQuery, reviewerID, now, pq.Array(taskIDs))
 	if err != nil {
 		return nil, err
 	}
@@ -95,11 +114,11 @@ func (r *TaskRepository) ClaimTasks(reviewerID int, limit int) ([]models.ReviewT
 	}
 
 	// Fetch full task details with comments
-	return r.FindTasksWithComments(taskIDs)
+	return r.FindTasksWithComments(ctx, taskIDs)
 }
 
 // FindTasksWithComments finds tasks with their associated comments
-func (r *TaskRepository) FindTasksWithComments(taskIDs []int) ([]models.ReviewTask, error) {
+func (r *TaskRepository) FindTasksWithComments(ctx context.Context, taskIDs []int) ([]models.ReviewTask, error) {
 	query := `
 		SELECT 
 			rt.id, rt.comment_id, rt.reviewer_id, rt.status, 
@@ -110,7 +129,7 @@ func (r *TaskRepository) FindTasksWithComments(taskIDs []int) ([]models.ReviewTa
 		WHERE rt.id = ANY($1)
 		ORDER BY rt.id
 	`
-	rows, err := r.db.Query(query, pq.Array(taskIDs))
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(taskIDs))
 	if err != nil {
 		return nil, err
 	}
@@ -135,7 +154,7 @@ func (r *TaskRepository) FindTasksWithComments(taskIDs []int) ([]models.ReviewTa
 }
 
 // GetMyTasks gets all in-progress tasks for a reviewer
-func (r *TaskRepository) GetMyTasks(reviewerID int) ([]models.ReviewTask, error) {
+func (r *TaskRepository) GetMyTasks(ctx context.Context, reviewerID int) ([]models.ReviewTask, error) {
 	query := `
 		SELECT 
 			rt.id, rt.comment_id, rt.reviewer_id, rt.status, 
@@ -146,7 +165,7 @@ func (r *TaskRepository) GetMyTasks(reviewerID int) ([]models.ReviewTask, error)
 		WHERE rt.reviewer_id = $1 AND rt.status = 'in_progress'
 		ORDER BY rt.claimed_at DESC
 	`
-	rows, err := r.db.Query(query, reviewerID)
+	rows, err := r.db.QueryContext(ctx, query, reviewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -195,23 +214,23 @@ func (r *TaskRepository) CompleteTask(taskID, reviewerID int) error {
 }
 
 // GetCommentIDTx retrieves a task's comment ID within a transaction
-func (r *TaskRepository) GetCommentIDTx(tx *sql.Tx, taskID int) (int64, error) {
+func (r *TaskRepository) GetCommentIDTx(ctx context.Context, tx *sql.Tx, taskID int) (int64, error) {
 	query := `SELECT comment_id FROM review_tasks WHERE id = $1`
 	var commentID int64
-	if err := tx.QueryRow(query, taskID).Scan(&commentID); err != nil {
+	if err := tx.QueryRowContext(ctx, query, taskID).Scan(&commentID); err != nil {
 		return 0, err
 	}
 	return commentID, nil
 }
 
 // CompleteTaskTx marks a task as completed within a transaction
-func (r *TaskRepository) CompleteTaskTx(tx *sql.Tx, taskID, reviewerID int) error {
+func (r *TaskRepository) CompleteTaskTx(ctx context.Context, tx *sql.Tx, taskID, reviewerID int) error {
 	query := `
 		UPDATE review_tasks
 		SET status = 'completed', completed_at = NOW()
 		WHERE id = $1 AND reviewer_id = $2 AND status = 'in_progress'
 	`
-	result, err := tx.Exec(query, taskID, reviewerID)
+	result, err := tx.ExecContext(ctx, query, taskID, reviewerID)
 	if err != nil {
 		return err
 	}
@@ -233,8 +252,8 @@ func (r *TaskRepository) CreateReviewResult(result *models.ReviewResult) (bool,
 }
 
 // CreateReviewResultTx creates a review result within a transaction
-func (r *TaskRepository) CreateReviewResultTx(tx *sql.Tx, result *models.ReviewResult) (bool, error) {
-	return createReviewResult(tx, result)
+func (r *TaskRepository) CreateReviewResultTx(ctx context.Context, tx *sql.Tx, result *models.ReviewResult) (bool, error) {
+	return createReviewResult(contextExecutor{ctx: ctx, db: tx}, result)
 }
 
 func createReviewResult(db reviewResultExecutor, result *models.ReviewResult) (bool, error) {
@@ -342,13 +361,13 @@ func (r *TaskRepository) ResetTask(taskID int) error {
 }
 
 // ReturnTasks returns multiple tasks back to pending status for a specific reviewer
-func (r *TaskRepository) ReturnTasks(taskIDs []int, reviewerID int) (int, error) {
+func (r *TaskRepository) ReturnTasks(ctx context.Context, taskIDs []int, reviewerID int) (int, error) {
 	query := `
 		UPDATE review_tasks
 		SET status = 'pending', reviewer_id = NULL, claimed_at = NULL
 		WHERE id = ANY($1) AND reviewer_id = $2 AND status = 'in_progress'
 	`
-	result, err := r.db.Exec(query, pq.Array(taskIDs), reviewerID)
+	result, err := r.db.ExecContext(ctx, query, pq.Array(taskIDs), reviewerID)
 	if err != nil {
 		return 0, err
 	}
@@ -3,6 +3,7 @@ package repository
 import (
 	"comment-review-platform/internal/models"
 	"comment-review-platform/pkg/database"
+	"context"
 	"database/sql"
 	"errors"
 )
@@ -72,7 +73,7 @@ func (r *UserRepository) FindByUsername(username string) (*models.User, error) {
 }
 
 // FindByID finds a user by ID
-func (r *UserRepository) FindByID(id int) (*models.User, error) {
+func (r *UserRepository) FindByID(ctx context.Context, id int) (*models.User, error) {
 	query := `
 		SELECT id, username, password, email, email_verified, role, status,
 			avatar_key, gender, signature, office_location, department, school, company, direct_manager,
@@ -90,7 +91,7 @@ func (r *UserRepository) FindByID(id int) (*models.User, error) {
 	var school *string
 	var company *string
 	var directManager *string
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID, &user.Username, &user.Password, &emailPtr, &user.EmailVerified, &user.Role, &user.Status,
 		&avatarKey, &gender, &signature, &officeLocation, &department, &school, &company, &directManager,
 		&user.CreatedAt, &user.UpdatedAt,
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/pkg/database"
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type VideoAnnotationRepository struct {
+	db *sql.DB
+}
+
+func NewVideoAnnotationRepository() *VideoAnnotationRepository {
+	return &VideoAnnotationRepository{db: database.DB}
+}
+
+// CreateAnnotations stores the annotations of one review task
+func (r *VideoAnnotationRepository) CreateAnnotations(videoID int, stage string, taskID, reviewerID int, annotations []models.VideoAnnotationInput) error {
+	return createVideoAnnotations(r.db, videoID, stage, taskID, reviewerID, annotations)
+}
+
+// CreateAnnotationsTx stores the annotations of one review task within a transaction
+func (r *VideoAnnotationRepository) CreateAnnotationsTx(ctx context.Context, tx *sql.Tx, videoID int, stage string, taskID, reviewerID int, annotations []models.VideoAnnotationInput) error {
+	return createVideoAnnotations(contextExecutor{ctx: ctx, db: tx}, videoID, stage, taskID, reviewerID, annotations)
+}
+
+func createVideoAnnotations(db reviewResultExecutor, videoID int, stage string, taskID, reviewerID int, annotations []models.VideoAnnotationInput) error {
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	starts := make([]float64, len(annotations))
+	ends := make([]float64, len(annotations))
+	tags := make([]string, len(annotations))
+	notes := make([]string, len(annotations))
+	for i, a := range annotations {
+		starts[i], ends[i], tags[i], notes[i] = a.StartSeconds, a.EndSeconds, a.Tag, a.Note
+	}
+
+	query := `
+		INSERT INTO video_review_annotations (video_id, review_stage, task_id, reviewer_id, start_seconds, end_seconds, tag, note, created_at)
+		SELECT $1, $2, $3, $4, a.start_seconds, a.end_seconds, a.tag, a.note, NOW()
+		FROM unnest($5::numeric[], $6::numeric[], $7::text[], $8::text[]) AS a(start_seconds, end_seconds, tag, note)
+	`
+	_, err := db.Exec(query, videoID, stage, taskID, reviewerID,
+		pq.Array(starts), pq.Array(ends), pq.Array(tags), pq.Array(notes))
+	return err
+}
+
+const videoAnnotationColumns = `
+	id, video_id, review_stage, task_id, reviewer_id, start_seconds, end_seconds, tag, note, created_at
+`
+
+// ListByTasks returns the annotations of the given tasks of one review stage, keyed by task ID
+func (r *VideoAnnotationRepository) ListByTasks(stage string, taskIDs []int) (map[int][]models.VideoAnnotation, error) {
+	result := map[int][]models.VideoAnnotation{}
+	if len(taskIDs) == 0 {
+		return result, nil
+	}
+	query := `SELECT ` + videoAnnotationColumns + `
+		FROM video_review_annotations
+		WHERE review_stage = $1 AND task_id = ANY($2)
+		ORDER BY start_seconds, id`
+	annotations, err := r.list(query, stage, pq.Array(taskIDs))
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range annotations {
+		result[a.TaskID] = append(result[a.TaskID], a)
+	}
+	return result, nil
+}
+
+// ListByVideos returns every stage's annotations of the given videos, keyed by video ID
+func (r *VideoAnnotationRepository) ListByVideos(videoIDs []int) (map[int][]models.VideoAnnotation, error) {
+	result := map[int][]models.VideoAnnotation{}
+	if len(videoIDs) == 0 {
+		return result, nil
+	}
+	query := `SELECT ` + videoAnnotationColumns + `
+		FROM video_review_annotations
+		WHERE video_id = ANY($1)
+		ORDER BY start_seconds, id`
+	annotations, err := r.list(query, pq.Array(videoIDs))
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range annotations {
+		result[a.VideoID] = append(result[a.VideoID], a)
+	}
+	return result, nil
+}
+
+func (r *VideoAnnotationRepository) list(query string, args ...interface{}) ([]models.VideoAnnotation, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	annotations := []models.VideoAnnotation{}
+	for rows.Next() {
+		var a models.VideoAnnotation
+		if err := rows.Scan(
+			&a.ID, &a.VideoID, &a.ReviewStage, &a.TaskID, &a.ReviewerID,
+			&a.StartSeconds, &a.EndSeconds, &a.Tag, &a.Note, &a.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations, rows.Err()
+}
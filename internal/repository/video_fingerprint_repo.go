@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/pkg/database"
+	"comment-review-platform/pkg/phash"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type VideoFingerprintRepository struct {
+	db *sql.DB
+}
+
+func NewVideoFingerprintRepository() *VideoFingerprintRepository {
+	return &VideoFingerprintRepository{db: database.DB}
+}
+
+// SaveFingerprint stores a video's fingerprint, rebuilds its band index and
+// marks the video as fingerprinted
+func (r *VideoFingerprintRepository) SaveFingerprint(fp *models.VideoFingerprint) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO video_fingerprints (video_id, method, frame_hashes, container_hash, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (video_id) DO UPDATE
+		SET method = EXCLUDED.method, frame_hashes = EXCLUDED.frame_hashes,
+			container_hash = EXCLUDED.container_hash, created_at = NOW()
+		RETURNING created_at
+	`, fp.VideoID, fp.Method, pq.Array(toInt64s(fp.FrameHashes)), fp.ContainerHash).Scan(&fp.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM video_fingerprint_bands WHERE video_id = $1`, fp.VideoID); err != nil {
+		return err
+	}
+	bands, values := fingerprintBands(fp.FrameHashes)
+	if len(bands) > 0 {
+		_, err := tx.Exec(`
+			INSERT INTO video_fingerprint_bands (band, value, video_id)
+			SELECT b.band, b.value, $1
+			FROM unnest($2::smallint[], $3::integer[]) AS b(band, value)
+			ON CONFLICT DO NOTHING
+		`, fp.VideoID, pq.Array(bands), pq.Array(values))
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE tiktok_videos SET fingerprinted_at = NOW() WHERE id = $1`, fp.VideoID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FindCandidates returns fingerprints of other videos sharing the container
+// hash with fp, plus the limit videos sharing the most frame hash bands with
+// it. Ranking by shared bands keeps videos that only share a common band,
+// such as the flat band of a letterbox, from crowding out real matches.
+func (r *VideoFingerprintRepository) FindCandidates(fp *models.VideoFingerprint, limit int) ([]models.VideoFingerprint, error) {
+	bands, values := fingerprintBands(fp.FrameHashes)
+	query := `
+		WITH query_bands AS (
+			SELECT DISTINCT q.band, q.value
+			FROM unnest($2::smallint[], $3::integer[]) AS q(band, value)
+		), band_matches AS (
+			SELECT b.video_id, COUNT(*) AS shared
+			FROM video_fingerprint_bands b
+			JOIN query_bands q ON b.band = q.band AND b.value = q.value
+			WHERE b.video_id <> $1
+			GROUP BY b.video_id
+			ORDER BY shared DESC, b.video_id
+			LIMIT $5
+		), candidates AS (
+			SELECT video_id, shared FROM band_matches
+			UNION ALL
+			SELECT video_id, 0 FROM video_fingerprints
+			WHERE $4::varchar IS NOT NULL AND container_hash = $4 AND video_id <> $1
+		)
+		SELECT f.video_id, f.method, f.frame_hashes, f.container_hash, f.created_at
+		FROM (SELECT video_id, MAX(shared) AS shared FROM candidates GROUP BY video_id) c
+		JOIN video_fingerprints f ON f.video_id = c.video_id
+		ORDER BY c.shared DESC, f.video_id
+	`
+	rows, err := r.db.Query(query, fp.VideoID, pq.Array(bands), pq.Array(values), fp.ContainerHash, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []models.VideoFingerprint{}
+	for rows.Next() {
+		var candidate models.VideoFingerprint
+		var hashes []int64
+		if err := rows.Scan(&candidate.VideoID, &candidate.Method, pq.Array(&hashes), &candidate.ContainerHash, &candidate.CreatedAt); err != nil {
+			return nil, err
+		}
+		candidate.FrameHashes = toUint64s(hashes)
+		candidates = append(candidates, candidate)
+	}
+	return candidates, rows.Err()
+}
+
+// GetOriginalVideoID returns the video that videoID duplicates, if any
+func (r *VideoFingerprintRepository) GetOriginalVideoID(videoID int) (int, bool, error) {
+	var originalID int
+	err := r.db.QueryRow(`SELECT original_video_id FROM video_duplicates WHERE video_id = $1`, videoID).Scan(&originalID)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return originalID, true, nil
+}
+
+// CreateDuplicate records a flagged duplicate. A video already linked to an
+// original keeps its first link; created reports whether a row was added.
+func (r *VideoFingerprintRepository) CreateDuplicate(duplicate *models.VideoDuplicate) (bool, error) {
+	err := r.db.QueryRow(`
+		INSERT INTO video_duplicates (video_id, original_video_id, method, similarity, action, created_at)
+		VALUES ($1, $2, $3, $4, 'flagged', NOW())
+		ON CONFLICT (video_id) DO NOTHING
+		RETURNING action, created_at
+	`, duplicate.VideoID, duplicate.OriginalVideoID, duplicate.Method, duplicate.Similarity).Scan(&duplicate.Action, &duplicate.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// decidedVideoStatuses matches o.status against models.VideoDecidedStatuses,
+// given as the query parameter it is formatted with, and the terminal status
+// of every traffic pool the placeholder stands for
+const decidedVideoStatuses = `(o.status = ANY($%d) OR o.status IN (
+	SELECT COALESCE(NULLIF(terminal_status, ''), name || '_confirmed') FROM video_pools
+))`
+
+// ListInheritableDuplicates returns flagged duplicates still waiting for an
+// unclaimed first review whose original has reached a decided status
+func (r *VideoFingerprintRepository) ListInheritableDuplicates(minSimilarity float64) ([]int, error) {
+	query := `
+		SELECT d.video_id
+		FROM video_duplicates d
+		JOIN tiktok_videos o ON o.id = d.original_video_id
+		JOIN tiktok_videos v ON v.id = d.video_id
+		WHERE d.action = 'flagged'
+		  AND d.similarity >= $1
+		  AND ` + fmt.Sprintf(decidedVideoStatuses, 2) + `
+		  AND v.status = 'pending'
+		  AND EXISTS (
+			SELECT 1 FROM video_first_review_tasks t
+			WHERE t.video_id = d.video_id AND t.status = 'pending'
+		  )
+		ORDER BY d.video_id
+	`
+	rows, err := r.db.Query(query, minSimilarity, pq.Array(models.VideoDecidedStatuses))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	videoIDs := []int{}
+	for rows.Next() {
+		var videoID int
+		if err := rows.Scan(&videoID); err != nil {
+			return nil, err
+		}
+		videoIDs = append(videoIDs, videoID)
+	}
+	return videoIDs, rows.Err()
+}
+
+// InheritDecision gives a flagged duplicate its original's status once the
+// original has reached a decided status, and removes the duplicate's
+// still-unclaimed first review task. Intermediate review statuses are not
+// inherited as they expect review tasks the duplicate would never get. The
+// status change is validated like any other; a task a reviewer already holds
+// is left alone. Reports whether the duplicate was resolved.
+func (r *VideoFingerprintRepository) InheritDecision(videoID int, minSimilarity float64) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var taskID, originalID int
+	var status string
+	query := `
+		SELECT t.id, d.original_video_id, o.status
+		FROM video_duplicates d
+		JOIN tiktok_videos o ON o.id = d.original_video_id
+		JOIN tiktok_videos v ON v.id = d.video_id
+		JOIN video_first_review_tasks t ON t.video_id = d.video_id AND t.status = 'pending'
+		WHERE d.video_id = $1
+		  AND d.action = 'flagged'
+		  AND d.similarity >= $2
+		  AND ` + fmt.Sprintf(decidedVideoStatuses, 3) + `
+		  AND v.status = 'pending'
+		LIMIT 1
+		FOR UPDATE OF t SKIP LOCKED
+	`
+	err = tx.QueryRow(query, videoID, minSimilarity, pq.Array(models.VideoDecidedStatuses)).Scan(&taskID, &originalID, &status)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM video_first_review_tasks WHERE id = $1`, taskID); err != nil {
+		return false, err
+	}
+	change := models.StatusChange{
+		Source: models.StatusSourceDuplicate,
+		Reason: fmt.Sprintf("inherited from video %d", originalID),
+	}
+	if err := updateVideoStatus(tx, videoID, status, change); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`
+		UPDATE video_duplicates
+		SET action = 'inherited', inherited_status = $2, resolved_at = NOW()
+		WHERE video_id = $1
+	`, videoID, status); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListByVideos returns the duplicate links of the given videos with the
+// original's latest review decision, keyed by video ID
+func (r *VideoFingerprintRepository) ListByVideos(videoIDs []int) (map[int]*models.VideoDuplicate, error) {
+	result := map[int]*models.VideoDuplicate{}
+	if len(videoIDs) == 0 {
+		return result, nil
+	}
+	query := `
+		SELECT d.video_id, d.original_video_id, d.method, d.similarity, d.action, d.inherited_status,
+			d.created_at, d.resolved_at, o.filename, o.status,
+			COALESCE(sr.is_approved, fr.is_approved), COALESCE(sr.reason, fr.reason)
+		FROM video_duplicates d
+		JOIN tiktok_videos o ON o.id = d.original_video_id
+		LEFT JOIN LATERAL (
+			SELECT r.is_approved, r.reason
+			FROM video_first_review_results r
+			JOIN video_first_review_tasks t ON t.id = r.task_id
+			WHERE t.video_id = o.id
+			ORDER BY r.created_at DESC
+			LIMIT 1
+		) fr ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT r.is_approved, r.reason
+			FROM video_second_review_results r
+			JOIN video_second_review_tasks t ON t.id = r.second_task_id
+			WHERE t.video_id = o.id
+			ORDER BY r.created_at DESC
+			LIMIT 1
+		) sr ON TRUE
+		WHERE d.video_id = ANY($1)
+	`
+	rows, err := r.db.Query(query, pq.Array(videoIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d models.VideoDuplicate
+		if err := rows.Scan(
+			&d.VideoID, &d.OriginalVideoID, &d.Method, &d.Similarity, &d.Action, &d.InheritedStatus,
+			&d.CreatedAt, &d.ResolvedAt, &d.OriginalFilename, &d.OriginalStatus,
+			&d.OriginalIsApproved, &d.OriginalReason,
+		); err != nil {
+			return nil, err
+		}
+		result[d.VideoID] = &d
+	}
+	return result, rows.Err()
+}
+
+// ListUnfingerprintedVideos returns videos after afterID that have no fingerprint yet
+func (r *VideoFingerprintRepository) ListUnfingerprintedVideos(afterID, limit int) ([]models.TikTokVideo, error) {
+	query := `
+		SELECT id, video_key, filename, file_size, duration
+		FROM tiktok_videos
+		WHERE fingerprinted_at IS NULL AND id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+	rows, err := r.db.Query(query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	videos := make([]models.TikTokVideo, 0)
+	for rows.Next() {
+		var video models.TikTokVideo
+		if err := rows.Scan(&video.ID, &video.VideoKey, &video.Filename, &video.FileSize, &video.Duration); err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+	return videos, rows.Err()
+}
+
+// fingerprintBands flattens the band index entries of the given frame hashes
+func fingerprintBands(hashes []uint64) (bands []int, values []int) {
+	for _, hash := range hashes {
+		for i := 0; i < phash.Bands; i++ {
+			bands = append(bands, i)
+			values = append(values, phash.Band(hash, i))
+		}
+	}
+	return bands, values
+}
+
+// PostgreSQL has no unsigned integers; hashes are stored bit-for-bit as BIGINT
+func toInt64s(values []uint64) []int64 {
+	result := make([]int64, len(values))
+	for i, v := range values {
+		result[i] = int64(v)
+	}
+	return result
+}
+
+func toUint64s(values []int64) []uint64 {
+	result := make([]uint64, len(values))
+	for i, v := range values {
+		result[i] = uint64(v)
+	}
+	return result
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"comment-review-platform/internal/models"
 	"comment-review-platform/pkg/database"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"time"
@@ -94,7 +95,8 @@ func (r *VideoFirstReviewRepository) FindFirstReviewTasksWithVideos(taskIDs []in
 		SELECT 
 			vfrt.id, vfrt.video_id, vfrt.reviewer_id, vfrt.status, 
 			vfrt.claimed_at, vfrt.completed_at, vfrt.created_at,
-			tv.id, tv.video_key, tv.filename, tv.file_size, tv.duration, tv.upload_time, tv.video_url, tv.url_expires_at, tv.status, tv.created_at, tv.updated_at
+			tv.id, tv.video_key, tv.filename, tv.file_size, tv.duration, tv.upload_time, tv.video_url, tv.url_expires_at, tv.status, tv.created_at, tv.updated_at,
+			tv.width, tv.height, tv.video_codec, tv.frame_rate, tv.bitrate, tv.has_audio, tv.audio_codec, tv.thumbnail_key, tv.keyframe_strip_key
 		FROM video_first_review_tasks vfrt
 		INNER JOIN tiktok_videos tv ON vfrt.video_id = tv.id
 		WHERE vfrt.id = ANY($1)
@@ -114,6 +116,7 @@ func (r *VideoFirstReviewRepository) FindFirstReviewTasksWithVideos(taskIDs []in
 			&task.ID, &task.VideoID, &task.ReviewerID, &task.Status,
 			&task.ClaimedAt, &task.CompletedAt, &task.CreatedAt,
 			&video.ID, &video.VideoKey, &video.Filename, &video.FileSize, &video.Duration, &video.UploadTime, &video.VideoURL, &video.URLExpiresAt, &video.Status, &video.CreatedAt, &video.UpdatedAt,
+			&video.Width, &video.Height, &video.VideoCodec, &video.FrameRate, &video.Bitrate, &video.HasAudio, &video.AudioCodec, &video.ThumbnailKey, &video.KeyframeStripKey,
 		)
 		if err != nil {
 			return nil, err
@@ -130,7 +133,8 @@ func (r *VideoFirstReviewRepository) GetMyFirstReviewTasks(reviewerID int) ([]mo
 		SELECT 
 			vfrt.id, vfrt.video_id, vfrt.reviewer_id, vfrt.status, 
 			vfrt.claimed_at, vfrt.completed_at, vfrt.created_at,
-			tv.id, tv.video_key, tv.filename, tv.file_size, tv.duration, tv.upload_time, tv.video_url, tv.url_expires_at, tv.status, tv.created_at, tv.updated_at
+			tv.id, tv.video_key, tv.filename, tv.file_size, tv.duration, tv.upload_time, tv.video_url, tv.url_expires_at, tv.status, tv.created_at, tv.updated_at,
+			tv.width, tv.height, tv.video_codec, tv.frame_rate, tv.bitrate, tv.has_audio, tv.audio_codec, tv.thumbnail_key, tv.keyframe_strip_key
 		FROM video_first_review_tasks vfrt
 		INNER JOIN tiktok_videos tv ON vfrt.video_id = tv.id
 		WHERE vfrt.reviewer_id = $1 AND vfrt.status = 'in_progress'
@@ -150,6 +154,7 @@ func (r *VideoFirstReviewRepository) GetMyFirstReviewTasks(reviewerID int) ([]mo
 			&task.ID, &task.VideoID, &task.ReviewerID, &task.Status,
 			&task.ClaimedAt, &task.CompletedAt, &task.CreatedAt,
 			&video.ID, &video.VideoKey, &video.Filename, &video.FileSize, &video.Duration, &video.UploadTime, &video.VideoURL, &video.URLExpiresAt, &video.Status, &video.CreatedAt, &video.UpdatedAt,
+			&video.Width, &video.Height, &video.VideoCodec, &video.FrameRate, &video.Bitrate, &video.HasAudio, &video.AudioCodec, &video.ThumbnailKey, &video.KeyframeStripKey,
 		)
 		if err != nil {
 			return nil, err
@@ -160,14 +165,33 @@ func (r *VideoFirstReviewRepository) GetMyFirstReviewTasks(reviewerID int) ([]mo
 	return tasks, nil
 }
 
+// GetVideoIDTx retrieves a first review task's video ID within a transaction
+func (r *VideoFirstReviewRepository) GetVideoIDTx(ctx context.Context, tx *sql.Tx, taskID int) (int, error) {
+	query := `SELECT video_id FROM video_first_review_tasks WHERE id = $1`
+	var videoID int
+	if err := tx.QueryRowContext(ctx, query, taskID).Scan(&videoID); err != nil {
+		return 0, err
+	}
+	return videoID, nil
+}
+
 // CompleteFirstReviewTask marks a first review task as completed
 func (r *VideoFirstReviewRepository) CompleteFirstReviewTask(taskID, reviewerID int) error {
+	return completeFirstReviewTask(r.db, taskID, reviewerID)
+}
+
+// CompleteFirstReviewTaskTx marks a first review task as completed within a transaction
+func (r *VideoFirstReviewRepository) CompleteFirstReviewTaskTx(ctx context.Context, tx *sql.Tx, taskID, reviewerID int) error {
+	return completeFirstReviewTask(contextExecutor{ctx: ctx, db: tx}, taskID, reviewerID)
+}
+
+func completeFirstReviewTask(db reviewResultExecutor, taskID, reviewerID int) error {
 	query := `
 		UPDATE video_first_review_tasks
 		SET status = 'completed', completed_at = COALESCE(completed_at, NOW())
 		WHERE id = $1 AND reviewer_id = $2 AND status IN ('in_progress', 'completed')
 	`
-	result, err := r.db.Exec(query, taskID, reviewerID)
+	result, err := db.Exec(query, taskID, reviewerID)
 	if err != nil {
 		return err
 	}
@@ -186,6 +210,15 @@ func (r *VideoFirstReviewRepository) CompleteFirstReviewTask(taskID, reviewerID
 
 // CreateFirstReviewResult creates a first review result
 func (r *VideoFirstReviewRepository) CreateFirstReviewResult(result *models.VideoFirstReviewResult) (bool, error) {
+	return createFirstReviewResult(r.db, result)
+}
+
+// CreateFirstReviewResultTx creates a first review result within a transaction
+func (r *VideoFirstReviewRepository) CreateFirstReviewResultTx(ctx context.Context, tx *sql.Tx, result *models.VideoFirstReviewResult) (bool, error) {
+	return createFirstReviewResult(contextExecutor{ctx: ctx, db: tx}, result)
+}
+
+func createFirstReviewResult(db reviewResultExecutor, result *models.VideoFirstReviewResult) (bool, error) {
 	// Convert QualityDimensions to JSON
 	qualityDimensionsJSON, err := json.Marshal(result.QualityDimensions)
 	if err != nil {
@@ -198,7 +231,7 @@ func (r *VideoFirstReviewRepository) CreateFirstReviewResult(result *models.Vide
 		ON CONFLICT (task_id) DO NOTHING
 		RETURNING id, created_at
 	`
-	err = r.db.QueryRow(query, result.TaskID, result.ReviewerID, result.IsApproved,
+	err = db.QueryRow(query, result.TaskID, result.ReviewerID, result.IsApproved,
 		qualityDimensionsJSON, result.OverallScore, result.TrafficPoolResult, result.Reason).Scan(&result.ID, &result.CreatedAt)
 	if err == nil {
 		return true, nil
@@ -215,7 +248,7 @@ func (r *VideoFirstReviewRepository) CreateFirstReviewResult(result *models.Vide
 	var qualityJSON []byte
 	var trafficPool sql.NullString
 	var reason sql.NullString
-	err = r.db.QueryRow(existingQuery, result.TaskID).Scan(
+	err = db.QueryRow(existingQuery, result.TaskID).Scan(
 		&result.ID,
 		&result.ReviewerID,
 		&result.IsApproved,
@@ -299,3 +332,38 @@ func (r *VideoFirstReviewRepository) ResetFirstReviewTask(taskID int) error {
 	_, err := r.db.Exec(query, taskID)
 	return err
 }
+
+// ResetCompletedTasksWithoutResultTx returns completed first review tasks that
+// never got a result back to pending, so the video is reviewed again. Tasks
+// completed within grace are skipped.
+func (r *VideoFirstReviewRepository) ResetCompletedTasksWithoutResultTx(tx *sql.Tx, grace time.Duration) (int64, error) {
+	query := `
+		UPDATE video_first_review_tasks t
+		SET status = 'pending', reviewer_id = NULL, claimed_at = NULL, completed_at = NULL
+		WHERE t.status = 'completed'
+		  AND t.completed_at < NOW() - INTERVAL '1 second' * $1
+		  AND NOT EXISTS (SELECT 1 FROM video_first_review_results r WHERE r.task_id = t.id)
+	`
+	result, err := tx.Exec(query, int(grace.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// RepairApprovedVideoStatusesTx marks videos with an approved first review
+// that are still pending as first_review_completed
+func (r *VideoFirstReviewRepository) RepairApprovedVideoStatusesTx(tx *sql.Tx) (int64, error) {
+	query := `
+		SELECT DISTINCT ON (v.id) v.id, t.id
+		FROM tiktok_videos v
+		JOIN video_first_review_tasks t ON t.video_id = v.id
+		JOIN video_first_review_results r ON r.task_id = t.id
+		WHERE t.status = 'completed'
+		  AND r.is_approved = TRUE
+		  AND v.status = 'pending'
+		ORDER BY v.id, t.id
+	`
+	return repairVideoStatusesTx(tx, query, models.VideoStatusFirstReviewCompleted,
+		models.StatusSourceReconciliation, "approved first review left the video pending")
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/pkg/database"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrVideoImportLeaseLost is returned to a worker whose claim on a job was
+// taken over by another worker
+var ErrVideoImportLeaseLost = errors.New("import job was claimed by another worker")
+
+type VideoImportRepository struct {
+	db *sql.DB
+}
+
+func NewVideoImportRepository() *VideoImportRepository {
+	return &VideoImportRepository{db: database.DB}
+}
+
+const videoImportJobColumns = `
+	id, r2_path_prefix, status, cursor, listed_count, imported_count, skipped_count, failed_count,
+	last_error, created_by, created_at, updated_at, started_at, heartbeat_at, completed_at
+`
+
+func scanVideoImportJob(row interface{ Scan(...interface{}) error }) (*models.VideoImportJob, error) {
+	var job models.VideoImportJob
+	var cursor, lastError sql.NullString
+	var createdBy sql.NullInt64
+	var startedAt, heartbeatAt, completedAt sql.NullTime
+	err := row.Scan(
+		&job.ID, &job.R2PathPrefix, &job.Status, &cursor,
+		&job.ListedCount, &job.ImportedCount, &job.SkippedCount, &job.FailedCount,
+		&lastError, &createdBy, &job.CreatedAt, &job.UpdatedAt,
+		&startedAt, &heartbeatAt, &completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if cursor.Valid {
+		job.Cursor = &cursor.String
+	}
+	if lastError.Valid {
+		job.LastError = &lastError.String
+	}
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		job.CreatedBy = &id
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if heartbeatAt.Valid {
+		job.HeartbeatAt = &heartbeatAt.Time
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	return &job, nil
+}
+
+func (r *VideoImportRepository) CreateJob(job *models.VideoImportJob) error {
+	query := `
+		INSERT INTO video_import_jobs (r2_path_prefix, status, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRow(query, job.R2PathPrefix, job.Status, job.CreatedBy).
+		Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
+}
+
+func (r *VideoImportRepository) GetJobByID(id int) (*models.VideoImportJob, error) {
+	query := `SELECT ` + videoImportJobColumns + ` FROM video_import_jobs WHERE id = $1`
+	return scanVideoImportJob(r.db.QueryRow(query, id))
+}
+
+func (r *VideoImportRepository) ListJobs(page, pageSize int) ([]models.VideoImportJob, int, error) {
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM video_import_jobs`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT ` + videoImportJobColumns + `
+		FROM video_import_jobs
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`
+	rows, err := r.db.Query(query, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	jobs := make([]models.VideoImportJob, 0)
+	for rows.Next() {
+		job, err := scanVideoImportJob(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, total, rows.Err()
+}
+
+// UpdateJobStatus moves a job to status if it is currently in one of allowed.
+// Leaving running clears the heartbeat; completedAt is set for terminal states.
+func (r *VideoImportRepository) UpdateJobStatus(jobID int, status string, allowed []string, lastError *string, completedAt *time.Time) (bool, error) {
+	query := `
+		UPDATE video_import_jobs
+		SET status = $1,
+		    last_error = $2,
+		    completed_at = $3,
+		    heartbeat_at = NULL,
+		    updated_at = NOW()
+		WHERE id = $4 AND status = ANY($5)
+	`
+	result, err := r.db.Exec(query, status, lastError, completedAt, jobID, pq.Array(allowed))
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return updated > 0, nil
+}
+
+// ClaimJob moves a pending job to running and starts a new lease. Only one
+// worker can win the claim; it passes the returned lease to every later
+// update, which fails once another worker has claimed the job.
+func (r *VideoImportRepository) ClaimJob(jobID int) (lease int64, claimed bool, err error) {
+	query := `
+		UPDATE video_import_jobs
+		SET status = 'running',
+		    lease = lease + 1,
+		    started_at = COALESCE(started_at, NOW()),
+		    heartbeat_at = NOW(),
+		    updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+		RETURNING lease
+	`
+	err = r.db.QueryRow(query, jobID).Scan(&lease)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return lease, true, nil
+}
+
+// RecordProgress advances the cursor, adds the counter deltas and refreshes the
+// heartbeat in one statement. It returns the job status afterwards so the
+// worker notices a cancellation, or ErrVideoImportLeaseLost when the job was
+// claimed by another worker.
+func (r *VideoImportRepository) RecordProgress(jobID int, lease int64, cursor string, listed, imported, skipped, failed int) (string, error) {
+	query := `
+		UPDATE video_import_jobs
+		SET cursor = $3,
+		    listed_count = listed_count + $4,
+		    imported_count = imported_count + $5,
+		    skipped_count = skipped_count + $6,
+		    failed_count = failed_count + $7,
+		    heartbeat_at = CASE WHEN status = 'running' THEN NOW() ELSE heartbeat_at END,
+		    updated_at = NOW()
+		WHERE id = $1 AND lease = $2
+		RETURNING status
+	`
+	var status string
+	err := r.db.QueryRow(query, jobID, lease, cursor, listed, imported, skipped, failed).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", ErrVideoImportLeaseLost
+	}
+	return status, err
+}
+
+// Heartbeat refreshes the heartbeat of a running job while one item takes
+// long, so the job is not taken over as stale
+func (r *VideoImportRepository) Heartbeat(jobID int, lease int64) error {
+	result, err := r.db.Exec(`
+		UPDATE video_import_jobs
+		SET heartbeat_at = NOW()
+		WHERE id = $1 AND lease = $2 AND status = 'running'
+	`, jobID, lease)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrVideoImportLeaseLost
+	}
+	return nil
+}
+
+// FinishJob moves a running job to its final status if the worker still
+// holds the lease
+func (r *VideoImportRepository) FinishJob(jobID int, lease int64, status string, lastError *string, completedAt time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE video_import_jobs
+		SET status = $3,
+		    last_error = $4,
+		    completed_at = $5,
+		    heartbeat_at = NULL,
+		    updated_at = NOW()
+		WHERE id = $1 AND lease = $2 AND status = 'running'
+	`, jobID, lease, status, lastError, completedAt)
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return updated > 0, nil
+}
+
+// ResetStaleJobs returns jobs no worker is handling to pending so they can be
+// claimed again, and returns their IDs: running jobs whose heartbeat is older
+// than staleAfter, and pending jobs no worker claimed within staleAfter, e.g.
+// because the process stopped before it started them
+func (r *VideoImportRepository) ResetStaleJobs(staleAfter time.Duration) ([]int, error) {
+	query := `
+		UPDATE video_import_jobs
+		SET status = 'pending', heartbeat_at = NULL, updated_at = NOW()
+		WHERE (status = 'running' AND (heartbeat_at IS NULL OR heartbeat_at < $1))
+		   OR (status = 'pending' AND updated_at < $1)
+		RETURNING id
+	`
+	rows, err := r.db.Query(query, time.Now().Add(-staleAfter))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *VideoImportRepository) CreateJobError(jobID int, videoKey, message string) error {
+	query := `
+		INSERT INTO video_import_job_errors (job_id, video_key, error_message, created_at)
+		VALUES ($1, $2, $3, NOW())
+	`
+	_, err := r.db.Exec(query, jobID, videoKey, message)
+	return err
+}
+
+func (r *VideoImportRepository) ListJobErrors(jobID, page, pageSize int) ([]models.VideoImportJobError, int, error) {
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM video_import_job_errors WHERE job_id = $1`, jobID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT id, job_id, video_key, error_message, created_at
+		FROM video_import_job_errors
+		WHERE job_id = $1
+		ORDER BY id
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.Query(query, jobID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items := make([]models.VideoImportJobError, 0)
+	for rows.Next() {
+		var item models.VideoImportJobError
+		if err := rows.Scan(&item.ID, &item.JobID, &item.VideoKey, &item.ErrorMessage, &item.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		items = append(items, item)
+	}
+	return items, total, rows.Err()
+}
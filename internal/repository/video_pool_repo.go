@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/pkg/database"
+	"database/sql"
+)
+
+type VideoPoolRepository struct {
+	db *sql.DB
+}
+
+func NewVideoPoolRepository() *VideoPoolRepository {
+	return &VideoPoolRepository{db: database.DB}
+}
+
+const videoPoolColumns = `
+	name, display_name, description, sort_order, next_pool, terminal_status,
+	claim_max_count, max_tags, tag_set, is_active, created_at, updated_at
+`
+
+func scanVideoPool(row interface{ Scan(...interface{}) error }) (*models.VideoPool, error) {
+	var pool models.VideoPool
+	err := row.Scan(
+		&pool.Name, &pool.DisplayName, &pool.Description, &pool.SortOrder,
+		&pool.NextPool, &pool.TerminalStatus, &pool.ClaimMaxCount, &pool.MaxTags,
+		&pool.TagSet, &pool.IsActive, &pool.CreatedAt, &pool.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &pool, nil
+}
+
+// ListPools returns every configured pool, active or not, in ladder order
+func (r *VideoPoolRepository) ListPools() ([]models.VideoPool, error) {
+	query := `SELECT ` + videoPoolColumns + ` FROM video_pools ORDER BY sort_order, name`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pools := []models.VideoPool{}
+	for rows.Next() {
+		pool, err := scanVideoPool(rows)
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, *pool)
+	}
+	return pools, rows.Err()
+}
+
+func (r *VideoPoolRepository) CreatePool(pool *models.VideoPool) error {
+	query := `
+		INSERT INTO video_pools (
+			name, display_name, description, sort_order, next_pool, terminal_status,
+			claim_max_count, max_tags, tag_set, is_active, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
+		RETURNING created_at, updated_at
+	`
+	return r.db.QueryRow(query,
+		pool.Name, pool.DisplayName, pool.Description, pool.SortOrder, pool.NextPool, pool.TerminalStatus,
+		pool.ClaimMaxCount, pool.MaxTags, pool.TagSet, pool.IsActive,
+	).Scan(&pool.CreatedAt, &pool.UpdatedAt)
+}
+
+// UpdatePool overwrites every editable field of the pool
+func (r *VideoPoolRepository) UpdatePool(pool *models.VideoPool) error {
+	query := `
+		UPDATE video_pools
+		SET display_name = $2, description = $3, sort_order = $4, next_pool = $5, terminal_status = $6,
+		    claim_max_count = $7, max_tags = $8, tag_set = $9, is_active = $10, updated_at = NOW()
+		WHERE name = $1
+		RETURNING updated_at
+	`
+	return r.db.QueryRow(query,
+		pool.Name, pool.DisplayName, pool.Description, pool.SortOrder, pool.NextPool, pool.TerminalStatus,
+		pool.ClaimMaxCount, pool.MaxTags, pool.TagSet, pool.IsActive,
+	).Scan(&pool.UpdatedAt)
+}
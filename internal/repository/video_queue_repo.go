@@ -3,8 +3,8 @@ package repository
 import (
 	"comment-review-platform/internal/models"
 	"comment-review-platform/pkg/database"
+	"context"
 	"database/sql"
-	"errors"
 
 	"github.com/lib/pq"
 )
@@ -43,6 +43,15 @@ func scanVideoQueueTaskWithVideo(rows *sql.Rows) (models.VideoQueueTask, error)
 		&video.Status,
 		&video.CreatedAt,
 		&video.UpdatedAt,
+		&video.Width,
+		&video.Height,
+		&video.VideoCodec,
+		&video.FrameRate,
+		&video.Bitrate,
+		&video.HasAudio,
+		&video.AudioCodec,
+		&video.ThumbnailKey,
+		&video.KeyframeStripKey,
 	)
 	if err != nil {
 		return models.VideoQueueTask{}, err
@@ -54,12 +63,21 @@ func scanVideoQueueTaskWithVideo(rows *sql.Rows) (models.VideoQueueTask, error)
 
 // CreateQueueTask creates a new video queue task for a specific pool
 func (r *VideoQueueRepository) CreateQueueTask(videoID int, pool string) (bool, error) {
+	return createQueueTask(r.db, videoID, pool)
+}
+
+// CreateQueueTaskTx creates a pending task in a pool within a transaction
+func (r *VideoQueueRepository) CreateQueueTaskTx(ctx context.Context, tx *sql.Tx, videoID int, pool string) (bool, error) {
+	return createQueueTask(contextExecutor{ctx: ctx, db: tx}, videoID, pool)
+}
+
+func createQueueTask(db reviewResultExecutor, videoID int, pool string) (bool, error) {
 	query := `
 		INSERT INTO video_queue_tasks (video_id, pool, status)
 		VALUES ($1, $2, 'pending')
 		ON CONFLICT (video_id, pool) DO NOTHING
 	`
-	result, err := r.db.Exec(query, videoID, pool)
+	result, err := db.Exec(query, videoID, pool)
 	if err != nil {
 		return false, err
 	}
@@ -71,8 +89,8 @@ func (r *VideoQueueRepository) CreateQueueTask(videoID int, pool string) (bool,
 }
 
 // ClaimQueueTasks claims pending tasks from a specific pool for a reviewer
-func (r *VideoQueueRepository) ClaimQueueTasks(pool string, reviewerID int, count int) ([]models.VideoQueueTask, error) {
-	tx, err := r.db.Begin()
+func (r *VideoQueueRepository) ClaimQueueTasks(ctx context.Context, pool string, reviewerID int, count int) ([]models.VideoQueueTask, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -96,12 +114,13 @@ func (r *VideoQueueRepository) ClaimQueueTasks(pool string, reviewerID int, coun
 		SELECT
 			c.id, c.video_id, c.pool, c.reviewer_id, c.status, c.claimed_at, c.completed_at, c.created_at,
 			v.id, v.video_key, v.filename, v.file_size, v.duration, v.upload_time,
-			v.video_url, v.url_expires_at, v.status, v.created_at, v.updated_at
+			v.video_url, v.url_expires_at, v.status, v.created_at, v.updated_at,
+			v.width, v.height, v.video_codec, v.frame_rate, v.bitrate, v.has_audio, v.audio_codec, v.thumbnail_key, v.keyframe_strip_key
 		FROM claimed c
 		JOIN tiktok_videos v ON v.id = c.video_id
 	`
 
-	rows, err := tx.Query(query, reviewerID, pool, count)
+	rows, err := tx.QueryContext(ctx, query, reviewerID, pool, count)
 	if err != nil {
 		return nil, err
 	}
@@ -127,19 +146,20 @@ func (r *VideoQueueRepository) ClaimQueueTasks(pool string, reviewerID int, coun
 }
 
 // GetMyQueueTasks retrieves in-progress tasks for a reviewer in a specific pool
-func (r *VideoQueueRepository) GetMyQueueTasks(pool string, reviewerID int) ([]models.VideoQueueTask, error) {
+func (r *VideoQueueRepository) GetMyQueueTasks(ctx context.Context, pool string, reviewerID int) ([]models.VideoQueueTask, error) {
 	query := `
 		SELECT
 			t.id, t.video_id, t.pool, t.reviewer_id, t.status, t.claimed_at, t.completed_at, t.created_at,
 			v.id, v.video_key, v.filename, v.file_size, v.duration, v.upload_time,
-			v.video_url, v.url_expires_at, v.status, v.created_at, v.updated_at
+			v.video_url, v.url_expires_at, v.status, v.created_at, v.updated_at,
+			v.width, v.height, v.video_codec, v.frame_rate, v.bitrate, v.has_audio, v.audio_codec, v.thumbnail_key, v.keyframe_strip_key
 		FROM video_queue_tasks t
 		JOIN tiktok_videos v ON v.id = t.video_id
 		WHERE t.pool = $1 AND t.reviewer_id = $2 AND t.status = 'in_progress'
 		ORDER BY t.claimed_at ASC
 	`
 
-	rows, err := r.db.Query(query, pool, reviewerID)
+	rows, err := r.db.QueryContext(ctx, query, pool, reviewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -161,7 +181,7 @@ func (r *VideoQueueRepository) GetMyQueueTasks(pool string, reviewerID int) ([]m
 }
 
 // CountMyQueueTasks returns the number of in-progress tasks for a reviewer in a pool
-func (r *VideoQueueRepository) CountMyQueueTasks(pool string, reviewerID int) (int, error) {
+func (r *VideoQueueRepository) CountMyQueueTasks(ctx context.Context, pool string, reviewerID int) (int, error) {
 	query := `
 		SELECT COUNT(*)
 		FROM video_queue_tasks
@@ -169,7 +189,7 @@ func (r *VideoQueueRepository) CountMyQueueTasks(pool string, reviewerID int) (i
 	`
 
 	var count int
-	err := r.db.QueryRow(query, pool, reviewerID).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, pool, reviewerID).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
@@ -179,13 +199,22 @@ func (r *VideoQueueRepository) CountMyQueueTasks(pool string, reviewerID int) (i
 
 // CompleteQueueTask marks a task as completed
 func (r *VideoQueueRepository) CompleteQueueTask(taskID int, reviewerID int) error {
+	return completeQueueTask(r.db, taskID, reviewerID)
+}
+
+// CompleteQueueTaskTx marks a task as completed within a transaction
+func (r *VideoQueueRepository) CompleteQueueTaskTx(ctx context.Context, tx *sql.Tx, taskID int, reviewerID int) error {
+	return completeQueueTask(contextExecutor{ctx: ctx, db: tx}, taskID, reviewerID)
+}
+
+func completeQueueTask(db reviewResultExecutor, taskID int, reviewerID int) error {
 	query := `
 		UPDATE video_queue_tasks
 		SET status = 'completed', completed_at = COALESCE(completed_at, NOW())
 		WHERE id = $1 AND reviewer_id = $2 AND status IN ('in_progress', 'completed')
 	`
 
-	result, err := r.db.Exec(query, taskID, reviewerID)
+	result, err := db.Exec(query, taskID, reviewerID)
 	if err != nil {
 		return err
 	}
@@ -196,7 +225,7 @@ func (r *VideoQueueRepository) CompleteQueueTask(taskID int, reviewerID int) err
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("task not found or already completed")
+		return sql.ErrNoRows
 	}
 
 	return nil
@@ -204,11 +233,16 @@ func (r *VideoQueueRepository) CompleteQueueTask(taskID int, reviewerID int) err
 
 // CreateQueueResult creates a review result for a queue task
 func (r *VideoQueueRepository) CreateQueueResult(result *models.VideoQueueResult) (bool, error) {
-	// Validate tags (max 3)
-	if len(result.Tags) > 3 {
-		return false, errors.New("maximum 3 tags allowed")
-	}
+	return createQueueResult(r.db, result)
+}
 
+// CreateQueueResultTx creates a review result for a queue task within a transaction
+func (r *VideoQueueRepository) CreateQueueResultTx(ctx context.Context, tx *sql.Tx, result *models.VideoQueueResult) (bool, error) {
+	return createQueueResult(contextExecutor{ctx: ctx, db: tx}, result)
+}
+
+func createQueueResult(db reviewResultExecutor, result *models.VideoQueueResult) (bool, error) {
+	// Tag count is validated by the service against the pool's max_tags
 	query := `
 		INSERT INTO video_queue_results (task_id, reviewer_id, review_decision, reason, tags, created_at)
 		VALUES ($1, $2, $3, $4, $5, NOW())
@@ -216,7 +250,7 @@ func (r *VideoQueueRepository) CreateQueueResult(result *models.VideoQueueResult
 		RETURNING id, created_at
 	`
 
-	err := r.db.QueryRow(
+	err := db.QueryRow(
 		query,
 		result.TaskID,
 		result.ReviewerID,
@@ -238,7 +272,7 @@ func (r *VideoQueueRepository) CreateQueueResult(result *models.VideoQueueResult
 		WHERE task_id = $1
 	`
 	var tags []string
-	err = r.db.QueryRow(existingQuery, result.TaskID).Scan(
+	err = db.QueryRow(existingQuery, result.TaskID).Scan(
 		&result.ID,
 		&result.ReviewerID,
 		&result.ReviewDecision,
@@ -254,14 +288,14 @@ func (r *VideoQueueRepository) CreateQueueResult(result *models.VideoQueueResult
 }
 
 // ReturnQueueTasks returns tasks back to pending status
-func (r *VideoQueueRepository) ReturnQueueTasks(taskIDs []int, reviewerID int) (int, error) {
+func (r *VideoQueueRepository) ReturnQueueTasks(ctx context.Context, taskIDs []int, reviewerID int) (int, error) {
 	query := `
 		UPDATE video_queue_tasks
 		SET status = 'pending', reviewer_id = NULL, claimed_at = NULL
 		WHERE id = ANY($1) AND reviewer_id = $2 AND status = 'in_progress'
 	`
 
-	result, err := r.db.Exec(query, pq.Array(taskIDs), reviewerID)
+	result, err := r.db.ExecContext(ctx, query, pq.Array(taskIDs), reviewerID)
 	if err != nil {
 		return 0, err
 	}
@@ -324,6 +358,16 @@ func (r *VideoQueueRepository) FindExpiredQueueTasks(pool string, timeoutMinutes
 	return tasks, nil
 }
 
+// GetVideoIDTx retrieves a queue task's video ID within a transaction
+func (r *VideoQueueRepository) GetVideoIDTx(ctx context.Context, tx *sql.Tx, taskID int) (int, error) {
+	query := `SELECT video_id FROM video_queue_tasks WHERE id = $1`
+	var videoID int
+	if err := tx.QueryRowContext(ctx, query, taskID).Scan(&videoID); err != nil {
+		return 0, err
+	}
+	return videoID, nil
+}
+
 // GetTaskByID retrieves a task by ID
 func (r *VideoQueueRepository) GetTaskByID(taskID int) (*models.VideoQueueTask, error) {
 	query := `
@@ -441,7 +485,8 @@ func (r *VideoQueueRepository) GetQueuePoolStats(pool string) (*models.VideoQueu
 func (r *VideoQueueRepository) getVideoByID(videoID int) (*models.TikTokVideo, error) {
 	query := `
 		SELECT id, video_key, filename, file_size, duration, upload_time,
-		       video_url, url_expires_at, status, created_at, updated_at
+		       video_url, url_expires_at, status, created_at, updated_at,
+		       width, height, video_codec, frame_rate, bitrate, has_audio, audio_codec, thumbnail_key, keyframe_strip_key
 		FROM tiktok_videos
 		WHERE id = $1
 	`
@@ -459,6 +504,15 @@ func (r *VideoQueueRepository) getVideoByID(videoID int) (*models.TikTokVideo, e
 		&video.Status,
 		&video.CreatedAt,
 		&video.UpdatedAt,
+		&video.Width,
+		&video.Height,
+		&video.VideoCodec,
+		&video.FrameRate,
+		&video.Bitrate,
+		&video.HasAudio,
+		&video.AudioCodec,
+		&video.ThumbnailKey,
+		&video.KeyframeStripKey,
 	)
 
 	if err != nil {
@@ -468,16 +522,14 @@ func (r *VideoQueueRepository) getVideoByID(videoID int) (*models.TikTokVideo, e
 	return &video, nil
 }
 
-// UpdateVideoStatus updates the status of a video
-func (r *VideoQueueRepository) UpdateVideoStatus(videoID int, status string) error {
-	query := `
-		UPDATE tiktok_videos
-		SET status = $1, updated_at = NOW()
-		WHERE id = $2
-	`
+// UpdateVideoStatus updates the status of a video and logs the transition
+func (r *VideoQueueRepository) UpdateVideoStatus(videoID int, status string, change models.StatusChange) error {
+	return updateVideoStatus(r.db, videoID, status, change)
+}
 
-	_, err := r.db.Exec(query, status, videoID)
-	return err
+// UpdateVideoStatusTx updates the status of a video within a transaction
+func (r *VideoQueueRepository) UpdateVideoStatusTx(ctx context.Context, tx *sql.Tx, videoID int, status string, change models.StatusChange) error {
+	return updateVideoStatus(contextExecutor{ctx: ctx, db: tx}, videoID, status, change)
 }
 
 // GetPendingTaskCount returns the number of pending tasks in a pool
@@ -3,7 +3,10 @@ package repository
 import (
 	"comment-review-platform/internal/models"
 	"comment-review-platform/pkg/database"
+	"comment-review-platform/pkg/statemachine"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -17,20 +20,134 @@ func NewVideoRepository() *VideoRepository {
 	return &VideoRepository{db: database.DB}
 }
 
-// CreateVideo creates a new video record
-func (r *VideoRepository) CreateVideo(video *models.TikTokVideo) error {
+// CreateVideo creates a video record together with its first review task in
+// one transaction. It is idempotent on video_key: when the key already exists
+// nothing is written and created is false.
+func (r *VideoRepository) CreateVideo(video *models.TikTokVideo) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO tiktok_videos (video_key, filename, file_size, duration, upload_time, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		INSERT INTO tiktok_videos (
+			video_key, filename, file_size, duration, upload_time, status,
+			width, height, video_codec, frame_rate, bitrate, has_audio, audio_codec, probed_at,
+			created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW(), NOW())
+		ON CONFLICT (video_key) DO NOTHING
 		RETURNING id, created_at, updated_at
 	`
-	return r.db.QueryRow(query, video.VideoKey, video.Filename, video.FileSize, video.Duration, video.UploadTime, video.Status).Scan(&video.ID, &video.CreatedAt, &video.UpdatedAt)
+	err = tx.QueryRow(
+		query,
+		video.VideoKey, video.Filename, video.FileSize, video.Duration, video.UploadTime, video.Status,
+		video.Width, video.Height, video.VideoCodec, video.FrameRate, video.Bitrate, video.HasAudio, video.AudioCodec, video.ProbedAt,
+	).Scan(&video.ID, &video.CreatedAt, &video.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO video_first_review_tasks (video_id, status, created_at)
+		VALUES ($1, 'pending', NOW())
+	`, video.ID); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UpdateVideoProbe stores probed container metadata for a video
+func (r *VideoRepository) UpdateVideoProbe(video *models.TikTokVideo) error {
+	query := `
+		UPDATE tiktok_videos
+		SET duration = $2, width = $3, height = $4, video_codec = $5, frame_rate = $6,
+			bitrate = $7, has_audio = $8, audio_codec = $9, probed_at = $10, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(
+		query, video.ID, video.Duration, video.Width, video.Height, video.VideoCodec, video.FrameRate,
+		video.Bitrate, video.HasAudio, video.AudioCodec, video.ProbedAt,
+	)
+	return err
+}
+
+// ListUnprobedVideos returns videos after afterID whose container metadata has not been probed yet
+func (r *VideoRepository) ListUnprobedVideos(afterID, limit int) ([]models.TikTokVideo, error) {
+	query := `
+		SELECT id, video_key, filename, file_size
+		FROM tiktok_videos
+		WHERE probed_at IS NULL AND id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+	rows, err := r.db.Query(query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	videos := make([]models.TikTokVideo, 0)
+	for rows.Next() {
+		var video models.TikTokVideo
+		if err := rows.Scan(&video.ID, &video.VideoKey, &video.Filename, &video.FileSize); err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+	return videos, rows.Err()
+}
+
+// UpdateVideoThumbnails stores the R2 keys of a video's generated thumbnails
+func (r *VideoRepository) UpdateVideoThumbnails(id int, thumbnailKey string, keyframeStripKey *string) error {
+	query := `
+		UPDATE tiktok_videos
+		SET thumbnail_key = $2, keyframe_strip_key = $3, thumbnails_generated_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, id, thumbnailKey, keyframeStripKey)
+	return err
+}
+
+// ListVideosWithoutThumbnails returns videos after afterID that have no thumbnails yet
+func (r *VideoRepository) ListVideosWithoutThumbnails(afterID, limit int) ([]models.TikTokVideo, error) {
+	query := `
+		SELECT id, video_key, filename, file_size, duration
+		FROM tiktok_videos
+		WHERE thumbnails_generated_at IS NULL AND id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+	rows, err := r.db.Query(query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	videos := make([]models.TikTokVideo, 0)
+	for rows.Next() {
+		var video models.TikTokVideo
+		if err := rows.Scan(&video.ID, &video.VideoKey, &video.Filename, &video.FileSize, &video.Duration); err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+	return videos, rows.Err()
 }
 
 // GetVideoByID retrieves a video by ID
 func (r *VideoRepository) GetVideoByID(id int) (*models.TikTokVideo, error) {
 	query := `
-		SELECT id, video_key, filename, file_size, duration, upload_time, video_url, url_expires_at, status, created_at, updated_at
+		SELECT id, video_key, filename, file_size, duration, upload_time, video_url, url_expires_at, status, created_at, updated_at,
+			width, height, video_codec, frame_rate, bitrate, has_audio, audio_codec, thumbnail_key, keyframe_strip_key
 		FROM tiktok_videos
 		WHERE id = $1
 	`
@@ -39,6 +156,7 @@ func (r *VideoRepository) GetVideoByID(id int) (*models.TikTokVideo, error) {
 		&video.ID, &video.VideoKey, &video.Filename, &video.FileSize, &video.Duration,
 		&video.UploadTime, &video.VideoURL, &video.URLExpiresAt, &video.Status,
 		&video.CreatedAt, &video.UpdatedAt,
+		&video.Width, &video.Height, &video.VideoCodec, &video.FrameRate, &video.Bitrate, &video.HasAudio, &video.AudioCodec, &video.ThumbnailKey, &video.KeyframeStripKey,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -52,7 +170,8 @@ func (r *VideoRepository) GetVideoByID(id int) (*models.TikTokVideo, error) {
 // GetVideoByKey retrieves a video by R2 key
 func (r *VideoRepository) GetVideoByKey(videoKey string) (*models.TikTokVideo, error) {
 	query := `
-		SELECT id, video_key, filename, file_size, duration, upload_time, video_url, url_expires_at, status, created_at, updated_at
+		SELECT id, video_key, filename, file_size, duration, upload_time, video_url, url_expires_at, status, created_at, updated_at,
+			width, height, video_codec, frame_rate, bitrate, has_audio, audio_codec, thumbnail_key, keyframe_strip_key
 		FROM tiktok_videos
 		WHERE video_key = $1
 	`
@@ -61,6 +180,7 @@ func (r *VideoRepository) GetVideoByKey(videoKey string) (*models.TikTokVideo, e
 		&video.ID, &video.VideoKey, &video.Filename, &video.FileSize, &video.Duration,
 		&video.UploadTime, &video.VideoURL, &video.URLExpiresAt, &video.Status,
 		&video.CreatedAt, &video.UpdatedAt,
+		&video.Width, &video.Height, &video.VideoCodec, &video.FrameRate, &video.Bitrate, &video.HasAudio, &video.AudioCodec, &video.ThumbnailKey, &video.KeyframeStripKey,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -106,7 +226,8 @@ func (r *VideoRepository) ListVideos(req models.ListVideosRequest) ([]models.Tik
 	// Get paginated results
 	offset := (req.Page - 1) * req.PageSize
 	query := fmt.Sprintf(`
-		SELECT id, video_key, filename, file_size, duration, upload_time, video_url, url_expires_at, status, created_at, updated_at
+		SELECT id, video_key, filename, file_size, duration, upload_time, video_url, url_expires_at, status, created_at, updated_at,
+			width, height, video_codec, frame_rate, bitrate, has_audio, audio_codec, thumbnail_key, keyframe_strip_key
 		FROM tiktok_videos
 		%s
 		ORDER BY created_at DESC
@@ -127,6 +248,7 @@ func (r *VideoRepository) ListVideos(req models.ListVideosRequest) ([]models.Tik
 			&video.ID, &video.VideoKey, &video.Filename, &video.FileSize, &video.Duration,
 			&video.UploadTime, &video.VideoURL, &video.URLExpiresAt, &video.Status,
 			&video.CreatedAt, &video.UpdatedAt,
+			&video.Width, &video.Height, &video.VideoCodec, &video.FrameRate, &video.Bitrate, &video.HasAudio, &video.AudioCodec, &video.ThumbnailKey, &video.KeyframeStripKey,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan video: %w", err)
@@ -152,15 +274,115 @@ func (r *VideoRepository) UpdateVideoURL(id int, videoURL string, expiresAt time
 	return err
 }
 
-// UpdateVideoStatus updates the video status
-func (r *VideoRepository) UpdateVideoStatus(id int, status string) error {
+// UpdateVideoStatus updates the video status and logs the transition
+func (r *VideoRepository) UpdateVideoStatus(id int, status string, change models.StatusChange) error {
+	return updateVideoStatus(r.db, id, status, change)
+}
+
+// UpdateVideoStatusTx updates video status within a transaction and logs the transition
+func (r *VideoRepository) UpdateVideoStatusTx(ctx context.Context, tx *sql.Tx, id int, status string, change models.StatusChange) error {
+	return updateVideoStatus(contextExecutor{ctx: ctx, db: tx}, id, status, change)
+}
+
+// ErrStatusChanged is returned when a status changed between being read and
+// being written, so the transition was validated against a stale status
+var ErrStatusChanged = errors.New("status changed concurrently, please retry")
+
+// updateVideoStatus validates a video's status change against
+// models.VideoStatusMachine, then sets the status and records the transition
+// in the same statement. Writing the current status again is a no-op.
+func updateVideoStatus(db reviewResultExecutor, id int, status string, change models.StatusChange) error {
+	var from string
+	var fromPool, toPool bool
+	err := db.QueryRow(`
+		WITH pool_statuses AS (
+			SELECT COALESCE(NULLIF(terminal_status, ''), name || '_confirmed') AS status FROM video_pools
+		)
+		SELECT v.status,
+			v.status IN (SELECT status FROM pool_statuses),
+			$2 IN (SELECT status FROM pool_statuses)
+		FROM tiktok_videos v
+		WHERE v.id = $1
+		FOR UPDATE OF v
+	`, id, status).Scan(&from, &fromPool, &toPool)
+	if err != nil {
+		return err
+	}
+	if from == status {
+		return nil
+	}
+	if err := models.VideoStatusMachine.Validate(videoStatusNode(from, fromPool), videoStatusNode(status, toPool)); err != nil {
+		// Report the actual statuses rather than the pool placeholder
+		return &statemachine.TransitionError{Machine: models.VideoStatusMachine.Name(), From: from, To: status}
+	}
+
+	// Only write if the status is still the one validated against; outside a
+	// transaction the row lock above is already released
 	query := `
-		UPDATE tiktok_videos
-		SET status = $2, updated_at = NOW()
-		WHERE id = $1
+		WITH updated AS (
+			UPDATE tiktok_videos
+			SET status = $2, updated_at = NOW()
+			WHERE id = $1 AND status = $3
+			RETURNING id
+		)
+		INSERT INTO video_status_transitions (video_id, from_status, to_status, source, task_id, actor_id, reason, created_at)
+		SELECT id, $3, $2, $4, $5, $6, NULLIF($7, ''), NOW()
+		FROM updated
 	`
-	_, err := r.db.Exec(query, id, status)
-	return err
+	result, err := db.Exec(query, id, status, from, change.Source, change.TaskID, change.ActorID, change.Reason)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
+
+// repairVideoStatusesTx sets status on each video returned by candidates, a
+// query selecting (video_id, task_id) rows, through updateVideoStatus so every
+// repair is validated and logged like a review's. Returns the videos changed.
+func repairVideoStatusesTx(tx *sql.Tx, candidates, status, source, reason string) (int64, error) {
+	rows, err := tx.Query(candidates)
+	if err != nil {
+		return 0, err
+	}
+	type candidate struct{ videoID, taskID int }
+	var repairs []candidate
+	for rows.Next() {
+		var c candidate
+		if err := rows.Scan(&c.videoID, &c.taskID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		repairs = append(repairs, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, c := range repairs {
+		taskID := c.taskID
+		change := models.StatusChange{Source: source, TaskID: &taskID, Reason: reason}
+		if err := updateVideoStatus(tx, c.videoID, status, change); err != nil {
+			return 0, fmt.Errorf("video %d: %w", c.videoID, err)
+		}
+	}
+	return int64(len(repairs)), nil
+}
+
+// videoStatusNode maps a traffic pool's terminal status to the placeholder
+// state the video status machine declares for all of them
+func videoStatusNode(status string, isPoolTerminal bool) string {
+	if isPoolTerminal && !models.VideoStatusMachine.Has(status) {
+		return models.VideoStatusPoolConfirmed
+	}
+	return status
 }
 
 // GetVideoQualityTags retrieves quality tags by category
@@ -3,6 +3,7 @@ package repository
 import (
 	"comment-review-platform/internal/models"
 	"comment-review-platform/pkg/database"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"time"
@@ -20,12 +21,21 @@ func NewVideoSecondReviewRepository() *VideoSecondReviewRepository {
 
 // CreateSecondReviewTask creates a new second review task
 func (r *VideoSecondReviewRepository) CreateSecondReviewTask(firstReviewResultID, videoID int) (bool, error) {
+	return createVideoSecondReviewTask(r.db, firstReviewResultID, videoID)
+}
+
+// CreateSecondReviewTaskTx creates a second review task within a transaction
+func (r *VideoSecondReviewRepository) CreateSecondReviewTaskTx(ctx context.Context, tx *sql.Tx, firstReviewResultID, videoID int) (bool, error) {
+	return createVideoSecondReviewTask(contextExecutor{ctx: ctx, db: tx}, firstReviewResultID, videoID)
+}
+
+func createVideoSecondReviewTask(db reviewResultExecutor, firstReviewResultID, videoID int) (bool, error) {
 	query := `
 		INSERT INTO video_second_review_tasks (first_review_result_id, video_id, status, created_at)
 		VALUES ($1, $2, 'pending', NOW())
 		ON CONFLICT (first_review_result_id) DO NOTHING
 	`
-	result, err := r.db.Exec(query, firstReviewResultID, videoID)
+	result, err := db.Exec(query, firstReviewResultID, videoID)
 	if err != nil {
 		return false, err
 	}
@@ -103,6 +113,7 @@ func (r *VideoSecondReviewRepository) FindSecondReviewTasksWithDetails(taskIDs [
 			vsrt.id, vsrt.first_review_result_id, vsrt.video_id, vsrt.reviewer_id, vsrt.status, 
 			vsrt.claimed_at, vsrt.completed_at, vsrt.created_at,
 			tv.id, tv.video_key, tv.filename, tv.file_size, tv.duration, tv.upload_time, tv.video_url, tv.url_expires_at, tv.status, tv.created_at, tv.updated_at,
+			tv.width, tv.height, tv.video_codec, tv.frame_rate, tv.bitrate, tv.has_audio, tv.audio_codec, tv.thumbnail_key, tv.keyframe_strip_key,
 			vfrr.id, vfrr.task_id, vfrr.reviewer_id, vfrr.is_approved, vfrr.quality_dimensions, vfrr.overall_score, vfrr.traffic_pool_result, vfrr.reason, vfrr.created_at
 		FROM video_second_review_tasks vsrt
 		INNER JOIN tiktok_videos tv ON vsrt.video_id = tv.id
@@ -127,6 +138,7 @@ func (r *VideoSecondReviewRepository) FindSecondReviewTasksWithDetails(taskIDs [
 			&task.ID, &task.FirstReviewResultID, &task.VideoID, &task.ReviewerID, &task.Status,
 			&task.ClaimedAt, &task.CompletedAt, &task.CreatedAt,
 			&video.ID, &video.VideoKey, &video.Filename, &video.FileSize, &video.Duration, &video.UploadTime, &video.VideoURL, &video.URLExpiresAt, &video.Status, &video.CreatedAt, &video.UpdatedAt,
+			&video.Width, &video.Height, &video.VideoCodec, &video.FrameRate, &video.Bitrate, &video.HasAudio, &video.AudioCodec, &video.ThumbnailKey, &video.KeyframeStripKey,
 			&firstResult.ID, &firstResult.TaskID, &firstResult.ReviewerID, &firstResult.IsApproved, &qualityDimensionsJSON, &firstResult.OverallScore, &firstResult.TrafficPoolResult, &firstResult.Reason, &firstResult.CreatedAt,
 		)
 		if err != nil {
@@ -152,6 +164,7 @@ func (r *VideoSecondReviewRepository) GetMySecondReviewTasks(reviewerID int) ([]
 			vsrt.id, vsrt.first_review_result_id, vsrt.video_id, vsrt.reviewer_id, vsrt.status, 
 			vsrt.claimed_at, vsrt.completed_at, vsrt.created_at,
 			tv.id, tv.video_key, tv.filename, tv.file_size, tv.duration, tv.upload_time, tv.video_url, tv.url_expires_at, tv.status, tv.created_at, tv.updated_at,
+			tv.width, tv.height, tv.video_codec, tv.frame_rate, tv.bitrate, tv.has_audio, tv.audio_codec, tv.thumbnail_key, tv.keyframe_strip_key,
 			vfrr.id, vfrr.task_id, vfrr.reviewer_id, vfrr.is_approved, vfrr.quality_dimensions, vfrr.overall_score, vfrr.traffic_pool_result, vfrr.reason, vfrr.created_at
 		FROM video_second_review_tasks vsrt
 		INNER JOIN tiktok_videos tv ON vsrt.video_id = tv.id
@@ -176,6 +189,7 @@ func (r *VideoSecondReviewRepository) GetMySecondReviewTasks(reviewerID int) ([]
 			&task.ID, &task.FirstReviewResultID, &task.VideoID, &task.ReviewerID, &task.Status,
 			&task.ClaimedAt, &task.CompletedAt, &task.CreatedAt,
 			&video.ID, &video.VideoKey, &video.Filename, &video.FileSize, &video.Duration, &video.UploadTime, &video.VideoURL, &video.URLExpiresAt, &video.Status, &video.CreatedAt, &video.UpdatedAt,
+			&video.Width, &video.Height, &video.VideoCodec, &video.FrameRate, &video.Bitrate, &video.HasAudio, &video.AudioCodec, &video.ThumbnailKey, &video.KeyframeStripKey,
 			&firstResult.ID, &firstResult.TaskID, &firstResult.ReviewerID, &firstResult.IsApproved, &qualityDimensionsJSON, &firstResult.OverallScore, &firstResult.TrafficPoolResult, &firstResult.Reason, &firstResult.CreatedAt,
 		)
 		if err != nil {
@@ -194,14 +208,33 @@ func (r *VideoSecondReviewRepository) GetMySecondReviewTasks(reviewerID int) ([]
 	return tasks, nil
 }
 
+// GetVideoIDTx retrieves a second review task's video ID within a transaction
+func (r *VideoSecondReviewRepository) GetVideoIDTx(ctx context.Context, tx *sql.Tx, taskID int) (int, error) {
+	query := `SELECT video_id FROM video_second_review_tasks WHERE id = $1`
+	var videoID int
+	if err := tx.QueryRowContext(ctx, query, taskID).Scan(&videoID); err != nil {
+		return 0, err
+	}
+	return videoID, nil
+}
+
 // CompleteSecondReviewTask marks a second review task as completed
 func (r *VideoSecondReviewRepository) CompleteSecondReviewTask(taskID, reviewerID int) error {
+	return completeSecondReviewTask(r.db, taskID, reviewerID)
+}
+
+// CompleteSecondReviewTaskTx marks a second review task as completed within a transaction
+func (r *VideoSecondReviewRepository) CompleteSecondReviewTaskTx(ctx context.Context, tx *sql.Tx, taskID, reviewerID int) error {
+	return completeSecondReviewTask(contextExecutor{ctx: ctx, db: tx}, taskID, reviewerID)
+}
+
+func completeSecondReviewTask(db reviewResultExecutor, taskID, reviewerID int) error {
 	query := `
 		UPDATE video_second_review_tasks
 		SET status = 'completed', completed_at = COALESCE(completed_at, NOW())
 		WHERE id = $1 AND reviewer_id = $2 AND status IN ('in_progress', 'completed')
 	`
-	result, err := r.db.Exec(query, taskID, reviewerID)
+	result, err := db.Exec(query, taskID, reviewerID)
 	if err != nil {
 		return err
 	}
@@ -220,6 +253,15 @@ func (r *VideoSecondReviewRepository) CompleteSecondReviewTask(taskID, reviewerI
 
 // CreateSecondReviewResult creates a second review result
 func (r *VideoSecondReviewRepository) CreateSecondReviewResult(result *models.VideoSecondReviewResult) (bool, error) {
+	return createSecondReviewResult(r.db, result)
+}
+
+// CreateSecondReviewResultTx creates a second review result within a transaction
+func (r *VideoSecondReviewRepository) CreateSecondReviewResultTx(ctx context.Context, tx *sql.Tx, result *models.VideoSecondReviewResult) (bool, error) {
+	return createSecondReviewResult(contextExecutor{ctx: ctx, db: tx}, result)
+}
+
+func createSecondReviewResult(db reviewResultExecutor, result *models.VideoSecondReviewResult) (bool, error) {
 	// Convert QualityDimensions to JSON
 	qualityDimensionsJSON, err := json.Marshal(result.QualityDimensions)
 	if err != nil {
@@ -232,7 +274,7 @@ func (r *VideoSecondReviewRepository) CreateSecondReviewResult(result *models.Vi
 		ON CONFLICT (second_task_id) DO NOTHING
 		RETURNING id, created_at
 	`
-	err = r.db.QueryRow(query, result.SecondTaskID, result.ReviewerID, result.IsApproved,
+	err = db.QueryRow(query, result.SecondTaskID, result.ReviewerID, result.IsApproved,
 		qualityDimensionsJSON, result.OverallScore, result.TrafficPoolResult, result.Reason).Scan(&result.ID, &result.CreatedAt)
 	if err == nil {
 		return true, nil
@@ -249,7 +291,7 @@ func (r *VideoSecondReviewRepository) CreateSecondReviewResult(result *models.Vi
 	var qualityJSON []byte
 	var trafficPool sql.NullString
 	var reason sql.NullString
-	err = r.db.QueryRow(existingQuery, result.SecondTaskID).Scan(
+	err = db.QueryRow(existingQuery, result.SecondTaskID).Scan(
 		&result.ID,
 		&result.ReviewerID,
 		&result.IsApproved,
@@ -333,3 +375,67 @@ func (r *VideoSecondReviewRepository) ResetSecondReviewTask(taskID int) error {
 	_, err := r.db.Exec(query, taskID)
 	return err
 }
+
+// CreateMissingSecondReviewTasksTx creates the second review task of every
+// rejected first review that has none and returns the affected video IDs
+func (r *VideoSecondReviewRepository) CreateMissingSecondReviewTasksTx(tx *sql.Tx) ([]int, error) {
+	query := `
+		INSERT INTO video_second_review_tasks (first_review_result_id, video_id, status, created_at)
+		SELECT r.id, t.video_id, 'pending', NOW()
+		FROM video_first_review_results r
+		JOIN video_first_review_tasks t ON t.id = r.task_id
+		WHERE r.is_approved = FALSE
+		  AND NOT EXISTS (SELECT 1 FROM video_second_review_tasks s WHERE s.first_review_result_id = r.id)
+		ON CONFLICT (first_review_result_id) DO NOTHING
+		RETURNING video_id
+	`
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	videoIDs := []int{}
+	for rows.Next() {
+		var videoID int
+		if err := rows.Scan(&videoID); err != nil {
+			return nil, err
+		}
+		videoIDs = append(videoIDs, videoID)
+	}
+	return videoIDs, rows.Err()
+}
+
+// ResetCompletedTasksWithoutResultTx returns completed second review tasks
+// that never got a result back to pending. Tasks completed within grace are
+// skipped.
+func (r *VideoSecondReviewRepository) ResetCompletedTasksWithoutResultTx(tx *sql.Tx, grace time.Duration) (int64, error) {
+	query := `
+		UPDATE video_second_review_tasks t
+		SET status = 'pending', reviewer_id = NULL, claimed_at = NULL, completed_at = NULL
+		WHERE t.status = 'completed'
+		  AND t.completed_at < NOW() - INTERVAL '1 second' * $1
+		  AND NOT EXISTS (SELECT 1 FROM video_second_review_results r WHERE r.second_task_id = t.id)
+	`
+	result, err := tx.Exec(query, int(grace.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// RepairReviewedVideoStatusesTx marks videos with a second review result that
+// are still pending as second_review_completed
+func (r *VideoSecondReviewRepository) RepairReviewedVideoStatusesTx(tx *sql.Tx) (int64, error) {
+	query := `
+		SELECT DISTINCT ON (v.id) v.id, t.id
+		FROM tiktok_videos v
+		JOIN video_second_review_tasks t ON t.video_id = v.id
+		JOIN video_second_review_results r ON r.second_task_id = t.id
+		WHERE t.status = 'completed'
+		  AND v.status = 'pending'
+		ORDER BY v.id, t.id
+	`
+	return repairVideoStatusesTx(tx, query, models.VideoStatusSecondReviewCompleted,
+		models.StatusSourceReconciliation, "second review result left the video pending")
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/pkg/database"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// VideoTimelineRepository reads the rows of every video review table that
+// make up a video's review timeline
+type VideoTimelineRepository struct {
+	db *sql.DB
+}
+
+func NewVideoTimelineRepository() *VideoTimelineRepository {
+	return &VideoTimelineRepository{db: database.DB}
+}
+
+// VideoReviewTaskRecord is a review task of any stage with its result, if any
+type VideoReviewTaskRecord struct {
+	Stage        string
+	Pool         *string
+	TaskID       int
+	ReviewerID   *int
+	ClaimedAt    *time.Time
+	CreatedAt    time.Time
+	ResultAt     *time.Time
+	ResultBy     *int
+	ResultByName *string
+	IsApproved   *bool
+	Decision     *string
+	OverallScore *int
+	Reason       *string
+	Tags         []string
+}
+
+// ListFirstReviewTasks returns the video's first review tasks with their results
+func (r *VideoTimelineRepository) ListFirstReviewTasks(videoID int) ([]VideoReviewTaskRecord, error) {
+	query := `
+		SELECT t.id, t.reviewer_id, t.claimed_at, t.created_at,
+			r.created_at, r.reviewer_id, u.username, r.is_approved, r.overall_score, r.reason, r.quality_dimensions
+		FROM video_first_review_tasks t
+		LEFT JOIN video_first_review_results r ON r.task_id = t.id
+		LEFT JOIN users u ON u.id = r.reviewer_id
+		WHERE t.video_id = $1
+		ORDER BY t.id
+	`
+	return r.listScoredTasks(models.VideoAnnotationStageFirstReview, query, videoID)
+}
+
+// ListSecondReviewTasks returns the video's second review tasks with their results
+func (r *VideoTimelineRepository) ListSecondReviewTasks(videoID int) ([]VideoReviewTaskRecord, error) {
+	query := `
+		SELECT t.id, t.reviewer_id, t.claimed_at, t.created_at,
+			r.created_at, r.reviewer_id, u.username, r.is_approved, r.overall_score, r.reason, r.quality_dimensions
+		FROM video_second_review_tasks t
+		LEFT JOIN video_second_review_results r ON r.second_task_id = t.id
+		LEFT JOIN users u ON u.id = r.reviewer_id
+		WHERE t.video_id = $1
+		ORDER BY t.id
+	`
+	return r.listScoredTasks(models.VideoAnnotationStageSecondReview, query, videoID)
+}
+
+// listScoredTasks scans first or second review rows, whose results carry
+// quality dimensions instead of a tag list
+func (r *VideoTimelineRepository) listScoredTasks(stage, query string, videoID int) ([]VideoReviewTaskRecord, error) {
+	rows, err := r.db.Query(query, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []VideoReviewTaskRecord{}
+	for rows.Next() {
+		record := VideoReviewTaskRecord{Stage: stage}
+		var dimensions []byte
+		if err := rows.Scan(
+			&record.TaskID, &record.ReviewerID, &record.ClaimedAt, &record.CreatedAt,
+			&record.ResultAt, &record.ResultBy, &record.ResultByName, &record.IsApproved,
+			&record.OverallScore, &record.Reason, &dimensions,
+		); err != nil {
+			return nil, err
+		}
+		if len(dimensions) > 0 {
+			var qd models.QualityDimensions
+			if err := json.Unmarshal(dimensions, &qd); err != nil {
+				return nil, err
+			}
+			record.Tags = qualityDimensionTags(qd)
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// ListQueueTasks returns the video's traffic pool tasks with their results
+func (r *VideoTimelineRepository) ListQueueTasks(videoID int) ([]VideoReviewTaskRecord, error) {
+	query := `
+		SELECT t.id, t.pool, t.reviewer_id, t.claimed_at, t.created_at,
+			r.created_at, r.reviewer_id, u.username, r.review_decision, r.reason, r.tags
+		FROM video_queue_tasks t
+		LEFT JOIN video_queue_results r ON r.task_id = t.id
+		LEFT JOIN users u ON u.id = r.reviewer_id
+		WHERE t.video_id = $1
+		ORDER BY t.id
+	`
+	rows, err := r.db.Query(query, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []VideoReviewTaskRecord{}
+	for rows.Next() {
+		record := VideoReviewTaskRecord{Stage: models.VideoAnnotationStageQueue}
+		var pool string
+		if err := rows.Scan(
+			&record.TaskID, &pool, &record.ReviewerID, &record.ClaimedAt, &record.CreatedAt,
+			&record.ResultAt, &record.ResultBy, &record.ResultByName, &record.Decision, &record.Reason,
+			pq.Array(&record.Tags),
+		); err != nil {
+			return nil, err
+		}
+		record.Pool = &pool
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// ListStatusTransitions returns the video's logged status changes, oldest first
+func (r *VideoTimelineRepository) ListStatusTransitions(videoID int) ([]models.VideoStatusTransition, error) {
+	query := `
+		SELECT id, video_id, from_status, to_status, source, task_id, actor_id, reason, created_at
+		FROM video_status_transitions
+		WHERE video_id = $1
+		ORDER BY created_at, id
+	`
+	rows, err := r.db.Query(query, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transitions := []models.VideoStatusTransition{}
+	for rows.Next() {
+		var t models.VideoStatusTransition
+		if err := rows.Scan(&t.ID, &t.VideoID, &t.FromStatus, &t.ToStatus, &t.Source, &t.TaskID, &t.ActorID, &t.Reason, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, t)
+	}
+	return transitions, rows.Err()
+}
+
+// GetUsernames returns the usernames of the given users, keyed by ID
+func (r *VideoTimelineRepository) GetUsernames(userIDs []int) (map[int]string, error) {
+	names := map[int]string{}
+	if len(userIDs) == 0 {
+		return names, nil
+	}
+	rows, err := r.db.Query(`SELECT id, username FROM users WHERE id = ANY($1)`, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
+
+// qualityDimensionTags flattens the tags of all quality dimensions
+func qualityDimensionTags(qd models.QualityDimensions) []string {
+	var tags []string
+	for _, d := range []models.QualityDimension{qd.ContentQuality, qd.TechnicalQuality, qd.Compliance, qd.EngagementPotential} {
+		tags = append(tags, d.Tags...)
+	}
+	return tags
+}
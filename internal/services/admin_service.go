@@ -1,6 +1,7 @@
 package services
 
 import (
+	"comment-review-platform/internal/auditevent"
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/repository"
 	redispkg "comment-review-platform/pkg/redis"
@@ -9,6 +10,7 @@ import (
 	"encoding/json"
 	"errors"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,14 +19,18 @@ import (
 )
 
 type AdminService struct {
-	userRepo *repository.UserRepository
-	tagRepo  *repository.TagRepository
+	userRepo        *repository.UserRepository
+	tagRepo         *repository.TagRepository
+	permissionCache *PermissionCache
+	sessionService  *SessionService
 }
 
 func NewAdminService() *AdminService {
 	return &AdminService{
-		userRepo: repository.NewUserRepository(),
-		tagRepo:  repository.NewTagRepository(),
+		userRepo:        repository.NewUserRepository(),
+		tagRepo:         repository.NewTagRepository(),
+		permissionCache: getPermissionCache(),
+		sessionService:  NewSessionService(),
 	}
 }
 
@@ -38,9 +44,37 @@ func (s *AdminService) GetAllUsers() ([]models.User, error) {
 	return s.userRepo.FindAllUsers()
 }
 
-// ApproveUser approves or rejects a user
-func (s *AdminService) ApproveUser(userID int, status string) error {
-	return s.userRepo.UpdateStatus(userID, status)
+// ApproveUser approves or rejects a user. Moving a user out of "approved"
+// revokes all of their sessions so existing tokens stop working immediately.
+func (s *AdminService) ApproveUser(ctx context.Context, actorID, userID int, status string) error {
+	before, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdateStatus(userID, status); err != nil {
+		return err
+	}
+	after := *before
+	after.Status = status
+	auditevent.Record(ctx, auditevent.Event{
+		Action:       auditevent.UserStatusChange,
+		ResourceType: "user",
+		ResourceID:   strconv.Itoa(userID),
+		ActorID:      &actorID,
+		Before:       before,
+		After:        &after,
+	})
+	if status != "approved" {
+		if _, err := s.sessionService.RevokeUserSessions(userID, SessionRevokeStatusChanged); err != nil {
+			log.Printf("⚠️  Failed to revoke sessions for user %d: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// ForceLogout revokes every active session of a user
+func (s *AdminService) ForceLogout(userID int) (int, error) {
+	return s.sessionService.RevokeUserSessions(userID, SessionRevokeAdmin)
 }
 
 func (s *AdminService) CreateUser(req models.CreateUserRequest) (*models.User, error) {
@@ -111,7 +145,16 @@ func (s *AdminService) CreateUser(req models.CreateUserRequest) (*models.User, e
 }
 
 func (s *AdminService) DeleteUser(userID int) error {
-	return s.userRepo.DeleteByID(userID)
+	// Revoke first: deleting the user cascades away the session rows that hold
+	// the access token IDs we need to denylist.
+	if _, err := s.sessionService.RevokeUserSessions(userID, SessionRevokeUserDeleted); err != nil {
+		return err
+	}
+	if err := s.userRepo.DeleteByID(userID); err != nil {
+		return err
+	}
+	s.permissionCache.Invalidate(userID)
+	return nil
 }
 
 // GetAllTags retrieves all tags
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"comment-review-platform/pkg/r2"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// auditArchiveBatchSize bounds the rows held in memory per archive object
+	auditArchiveBatchSize = 50000
+	auditArchiveKeyPrefix = "audit-archive"
+	// maxAuditArchiveLineBytes bounds one archived entry; entries are capped
+	// far below it when they are written
+	maxAuditArchiveLineBytes = 16 << 20
+)
+
+var ErrAuditArchiveUnavailable = errors.New("audit log archive storage (R2) is not configured")
+
+// AuditArchiveService moves audit logs past the hot retention window into
+// gzip-compressed NDJSON objects in R2, recorded in a manifest table, and
+// reads them back for queries that reach into archived ranges
+type AuditArchiveService struct {
+	db    *sql.DB
+	repo  *repository.AuditArchiveRepository
+	chain *AuditChainService
+	r2    *r2.R2Service
+}
+
+func NewAuditArchiveService(db *sql.DB, signingKey string) *AuditArchiveService {
+	r2Service, err := r2.NewR2Service()
+	if err != nil {
+		r2Service = nil
+	}
+	return &AuditArchiveService{
+		db:    db,
+		repo:  repository.NewAuditArchiveRepository(db),
+		chain: NewAuditChainService(db, signingKey),
+		r2:    r2Service,
+	}
+}
+
+// ArchiveExpired archives and then deletes the hot rows of every day before
+// the retention window. Without R2 nothing is deleted.
+func (s *AuditArchiveService) ArchiveExpired(retentionDays int) (int64, error) {
+	if s.r2 == nil {
+		return 0, ErrAuditArchiveUnavailable
+	}
+
+	cutoff := repository.AuditChainDay(time.Now().AddDate(0, 0, -retentionDays))
+	days, err := s.repo.ListExpiredDays(cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	var archived int64
+	for _, day := range days {
+		for {
+			n, err := s.archiveBatch(day)
+			if err != nil {
+				return archived, fmt.Errorf("archive %s: %w", day, err)
+			}
+			archived += int64(n)
+			if n < auditArchiveBatchSize {
+				break
+			}
+		}
+	}
+	return archived, nil
+}
+
+// archiveBatch uploads the next batch of a day's rows as one archive part and
+// deletes them from the hot table in the transaction that records the part.
+// A failed transaction leaves an unreferenced object that the retry overwrites.
+func (s *AuditArchiveService) archiveBatch(day string) (int, error) {
+	records, err := s.repo.ListDayBatch(day, auditArchiveBatchSize)
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeAuditArchive(&buf, records); err != nil {
+		return 0, err
+	}
+	part, err := s.repo.NextPart(day)
+	if err != nil {
+		return 0, err
+	}
+	archive := summarizeAuditArchive(day, part, records, buf.Bytes())
+	if err := s.r2.UploadObject(context.Background(), archive.ObjectKey, buf.Bytes(), "application/gzip"); err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := s.repo.CreateArchiveTx(tx, archive); err != nil {
+		return 0, err
+	}
+	var unchained []string
+	for _, record := range records {
+		if record.ChainSeq == 0 {
+			unchained = append(unchained, record.ID)
+		}
+	}
+	deleted, err := s.repo.DeleteUnchainedTx(tx, unchained)
+	if err != nil {
+		return 0, err
+	}
+	if archive.LastSeq != nil {
+		n, err := s.chain.TombstoneTx(tx, day, *archive.LastSeq)
+		if err != nil {
+			return 0, err
+		}
+		deleted += n
+	}
+	// Anything else would archive the leftover rows a second time
+	if deleted != int64(len(records)) {
+		return 0, fmt.Errorf("archived %d entries but would delete %d", len(records), deleted)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	log.Printf("Archived %d audit logs of %s to %s", len(records), day, archive.ObjectKey)
+	return len(records), nil
+}
+
+// ScanRange passes the archived entries matching filters to fn one archive
+// object at a time, oldest first within each archive, so memory use is bound
+// by one archive rather than the range. It returns the number of archived
+// days the range touched.
+func (s *AuditArchiveService) ScanRange(filters models.AuditLogQueryFilters, fn func([]models.AuditLogEntry) error) (int, error) {
+	archives, err := s.repo.ListArchives(filters.StartTime, filters.EndTime)
+	if err != nil || len(archives) == 0 {
+		return 0, err
+	}
+	if s.r2 == nil {
+		return 0, ErrAuditArchiveUnavailable
+	}
+
+	days := map[string]struct{}{}
+	for _, archive := range archives {
+		days[archive.ArchiveDay] = struct{}{}
+		var entries []models.AuditLogEntry
+		err := s.readArchive(archive, func(record models.AuditLogArchiveRecord) {
+			if repository.MatchAuditLogFilters(record.AuditLogEntry, filters) {
+				entries = append(entries, record.AuditLogEntry)
+			}
+		})
+		if err != nil {
+			return 0, err
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		// Parts are cut in chain order, which is close to but not exactly
+		// creation order
+		sort.SliceStable(entries, func(i, j int) bool {
+			if !entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+				return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+			}
+			return entries[i].ID < entries[j].ID
+		})
+		if err := fn(entries); err != nil {
+			return 0, err
+		}
+	}
+	return len(days), nil
+}
+
+// FindEntry looks up an archived entry by ID among the archives of the day it
+// was created on; it returns sql.ErrNoRows if no archive holds it
+func (s *AuditArchiveService) FindEntry(id string, createdAt time.Time) (*models.AuditLogEntry, error) {
+	dayStart := time.Date(createdAt.Year(), createdAt.Month(), createdAt.Day(), 0, 0, 0, 0, createdAt.Location())
+	archives, err := s.repo.ListArchives(dayStart, dayStart.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	if len(archives) > 0 && s.r2 == nil {
+		return nil, ErrAuditArchiveUnavailable
+	}
+
+	var found *models.AuditLogEntry
+	for _, archive := range archives {
+		err := s.readArchive(archive, func(record models.AuditLogArchiveRecord) {
+			if found == nil && record.ID == id {
+				entry := record.AuditLogEntry
+				found = &entry
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		if found != nil {
+			return found, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// ListArchives returns the manifest entries of archives overlapping a range
+func (s *AuditArchiveService) ListArchives(start, end time.Time) ([]models.AuditLogArchive, error) {
+	return s.repo.ListArchives(start, end)
+}
+
+// readArchive streams one archive object and checks it against the checksum
+// recorded when it was written
+func (s *AuditArchiveService) readArchive(archive models.AuditLogArchive, fn func(models.AuditLogArchiveRecord)) error {
+	body, err := s.r2.DownloadObject(context.Background(), archive.ObjectKey)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	hash := sha256.New()
+	tee := io.TeeReader(body, hash)
+	if err := readAuditArchive(tee, fn); err != nil {
+		return fmt.Errorf("archive %s: %w", archive.ObjectKey, err)
+	}
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		return err
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != archive.SHA256 {
+		return fmt.Errorf("archive %s does not match its manifest checksum", archive.ObjectKey)
+	}
+	return nil
+}
+
+func auditArchiveKey(day string, part int) string {
+	return fmt.Sprintf("%s/%s/%s/part-%04d.ndjson.gz", auditArchiveKeyPrefix, strings.ReplaceAll(day[:7], "-", "/"), day, part)
+}
+
+// summarizeAuditArchive builds the manifest entry of an encoded archive part
+func summarizeAuditArchive(day string, part int, records []models.AuditLogArchiveRecord, data []byte) *models.AuditLogArchive {
+	sum := sha256.Sum256(data)
+	archive := &models.AuditLogArchive{
+		ArchiveDay: day,
+		Part:       part,
+		ObjectKey:  auditArchiveKey(day, part),
+		EntryCount: int64(len(records)),
+		SizeBytes:  int64(len(data)),
+		SHA256:     hex.EncodeToString(sum[:]),
+	}
+	for i, record := range records {
+		if i == 0 || record.CreatedAt.Before(archive.OldestAt) {
+			archive.OldestAt = record.CreatedAt
+		}
+		if i == 0 || record.CreatedAt.After(archive.NewestAt) {
+			archive.NewestAt = record.CreatedAt
+		}
+		if record.ChainSeq == 0 {
+			continue
+		}
+		seq := record.ChainSeq
+		if archive.FirstSeq == nil || seq < *archive.FirstSeq {
+			archive.FirstSeq = &seq
+		}
+		if archive.LastSeq == nil || seq > *archive.LastSeq {
+			last := seq
+			archive.LastSeq = &last
+		}
+	}
+	return archive
+}
+
+// writeAuditArchive encodes records as gzip-compressed NDJSON
+func writeAuditArchive(w io.Writer, records []models.AuditLogArchiveRecord) error {
+	gz := gzip.NewWriter(w)
+	encoder := json.NewEncoder(gz)
+	encoder.SetEscapeHTML(false)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return gz.Close()
+}
+
+// readAuditArchive decodes gzip-compressed NDJSON written by writeAuditArchive
+func readAuditArchive(r io.Reader, fn func(models.AuditLogArchiveRecord)) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 64*1024), maxAuditArchiveLineBytes)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var record models.AuditLogArchiveRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return err
+		}
+		fn(record)
+	}
+	return scanner.Err()
+}
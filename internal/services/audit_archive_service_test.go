@@ -0,0 +1,152 @@
+package services
+
+import (
+	"bytes"
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func archiveRecord(id string, seq int64, at time.Time) models.AuditLogArchiveRecord {
+	return models.AuditLogArchiveRecord{
+		AuditLogEntry: models.AuditLogEntry{
+			ID:          id,
+			CreatedAt:   at,
+			RequestBody: json.RawMessage(`{"comment_id":7}`),
+			StatusCode:  200,
+		},
+		ChainSeq: seq,
+	}
+}
+
+func TestAuditArchiveRoundTrip(t *testing.T) {
+	at := time.Date(2026, 7, 1, 9, 0, 0, 123456000, time.UTC)
+	records := []models.AuditLogArchiveRecord{
+		archiveRecord("legacy", 0, at),
+		archiveRecord("a", 1, at.Add(time.Minute)),
+	}
+
+	var buf bytes.Buffer
+	if err := writeAuditArchive(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+	var got []models.AuditLogArchiveRecord
+	if err := readAuditArchive(bytes.NewReader(buf.Bytes()), func(r models.AuditLogArchiveRecord) {
+		got = append(got, r)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("read %d records, want 2", len(got))
+	}
+	if got[1].ID != "a" || got[1].ChainSeq != 1 || !got[1].CreatedAt.Equal(records[1].CreatedAt) {
+		t.Errorf("record changed in round trip: %+v", got[1])
+	}
+	if string(got[0].RequestBody) != `{"comment_id":7}` {
+		t.Errorf("request body changed: %s", got[0].RequestBody)
+	}
+}
+
+func TestSummarizeAuditArchive(t *testing.T) {
+	at := time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC)
+	records := []models.AuditLogArchiveRecord{
+		archiveRecord("legacy", 0, at.Add(time.Hour)),
+		archiveRecord("a", 4, at),
+		archiveRecord("b", 5, at.Add(2*time.Hour)),
+	}
+
+	archive := summarizeAuditArchive("2026-07-01", 3, records, []byte("data"))
+	if archive.ObjectKey != "audit-archive/2026/07/2026-07-01/part-0003.ndjson.gz" {
+		t.Errorf("unexpected object key %s", archive.ObjectKey)
+	}
+	if archive.FirstSeq == nil || *archive.FirstSeq != 4 || archive.LastSeq == nil || *archive.LastSeq != 5 {
+		t.Errorf("unexpected chain range %v-%v", archive.FirstSeq, archive.LastSeq)
+	}
+	if !archive.OldestAt.Equal(at) || !archive.NewestAt.Equal(at.Add(2*time.Hour)) {
+		t.Errorf("unexpected time range %v-%v", archive.OldestAt, archive.NewestAt)
+	}
+	if archive.EntryCount != 3 || archive.SizeBytes != 4 {
+		t.Errorf("unexpected counts: %+v", archive)
+	}
+}
+
+func TestSummarizeAuditArchiveWithoutChainedRows(t *testing.T) {
+	archive := summarizeAuditArchive("2026-07-01", 1, []models.AuditLogArchiveRecord{archiveRecord("legacy", 0, time.Now())}, nil)
+	if archive.FirstSeq != nil || archive.LastSeq != nil {
+		t.Error("unchained part must not claim a chain range")
+	}
+}
+
+func TestMatchAuditLogFiltersOnArchivedEntries(t *testing.T) {
+	at := time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC)
+	userID := 7
+	entry := models.AuditLogEntry{
+		ID:                "a",
+		CreatedAt:         at,
+		UserID:            &userID,
+		Username:          "Alice_Admin",
+		ActionType:        "update",
+		Endpoint:          "/api/admin/users/7",
+		IPAddress:         "10.0.3.4",
+		HTTPMethod:        "PUT",
+		StatusCode:        500,
+		ActionDescription: "Update user role",
+		ErrorMessage:      "pq: deadlock detected",
+		DurationMs:        120,
+	}
+	window := models.AuditLogQueryFilters{StartTime: at.Add(-time.Hour), EndTime: at.Add(time.Hour)}
+	with := func(change func(*models.AuditLogQueryFilters)) models.AuditLogQueryFilters {
+		filters := window
+		change(&filters)
+		return filters
+	}
+	minMs, maxMs := 100, 110
+
+	tests := []struct {
+		name    string
+		filters models.AuditLogQueryFilters
+		want    bool
+	}{
+		{"window", window, true},
+		{"before window", with(func(f *models.AuditLogQueryFilters) { f.StartTime = at.Add(time.Minute) }), false},
+		{"user", with(func(f *models.AuditLogQueryFilters) { f.UserID = &userID }), true},
+		{"username substring ignores case", with(func(f *models.AuditLogQueryFilters) { f.Username = "alice" }), true},
+		{"username underscore is a wildcard", with(func(f *models.AuditLogQueryFilters) { f.Username = "e_a" }), true},
+		{"action types", with(func(f *models.AuditLogQueryFilters) { f.ActionTypes = []string{"create", "delete"} }), false},
+		{"endpoint wildcard", with(func(f *models.AuditLogQueryFilters) { f.Endpoint = "/API/admin/*" }), true},
+		{"endpoint exact", with(func(f *models.AuditLogQueryFilters) { f.Endpoint = "/api/admin" }), false},
+		{"ip wildcard", with(func(f *models.AuditLogQueryFilters) { f.IPAddress = "10.0.*" }), true},
+		{"method ignores case", with(func(f *models.AuditLogQueryFilters) { f.HTTPMethod = "put" }), true},
+		{"keyword words", with(func(f *models.AuditLogQueryFilters) { f.Keyword = "Deadlock user" }), true},
+		{"keyword missing word", with(func(f *models.AuditLogQueryFilters) { f.Keyword = "deadlock timeout" }), false},
+		{"min duration", with(func(f *models.AuditLogQueryFilters) { f.MinDurationMs = &minMs }), true},
+		{"max duration", with(func(f *models.AuditLogQueryFilters) { f.MaxDurationMs = &maxMs }), false},
+	}
+	for _, tt := range tests {
+		if got := repository.MatchAuditLogFilters(entry, tt.filters); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTopAuditLogsKeepsPageOrder(t *testing.T) {
+	at := time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC)
+	entries := []models.AuditLogEntry{
+		{ID: "a", CreatedAt: at, DurationMs: 30},
+		{ID: "b", CreatedAt: at.Add(2 * time.Minute)},
+		{ID: "c", CreatedAt: at.Add(time.Minute), DurationMs: 90},
+	}
+
+	newest := topAuditLogs(append([]models.AuditLogEntry(nil), entries...), 2, auditLogOrder("created_at", "desc"))
+	if len(newest) != 2 || newest[0].ID != "b" || newest[1].ID != "c" {
+		t.Errorf("newest first = %+v", newest)
+	}
+	// NULL durations sort last ascending, as in Postgres
+	fastest := topAuditLogs(append([]models.AuditLogEntry(nil), entries...), 3, auditLogOrder("duration_ms", "asc"))
+	if fastest[0].ID != "a" || fastest[1].ID != "c" || fastest[2].ID != "b" {
+		t.Errorf("fastest first = %+v", fastest)
+	}
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"comment-review-platform/pkg/hashchain"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const auditVerifyMaxDays = 31
+
+// Break kinds the chain service reports on top of hashchain's
+const (
+	AuditBreakCheckpointMismatch = "checkpoint_mismatch"
+	AuditBreakBadSignature       = "bad_signature"
+	AuditBreakTruncated          = "truncated"
+)
+
+var ErrInvalidAuditVerifyRange = errors.New("invalid verification range")
+
+// AuditChainService checkpoints, verifies and expires the hash-chained audit
+// log. Checkpoints and tombstones are signed with a key that is not stored in
+// the database, so rewriting a chain also requires forging its signatures.
+type AuditChainService struct {
+	repo *repository.AuditChainRepository
+	key  []byte
+}
+
+func NewAuditChainService(db *sql.DB, signingKey string) *AuditChainService {
+	return &AuditChainService{
+		repo: repository.NewAuditChainRepository(db),
+		key:  []byte(signingKey),
+	}
+}
+
+func (s *AuditChainService) signed() bool {
+	return len(s.key) > 0
+}
+
+func (s *AuditChainService) sign(parts ...string) string {
+	if !s.signed() {
+		return ""
+	}
+	return hashchain.Sign(s.key, parts...)
+}
+
+func checkpointParts(day string, seq int64, hash string) []string {
+	return []string{"checkpoint", day, strconv.FormatInt(seq, 10), hash}
+}
+
+func tombstoneParts(t *models.AuditChainTombstone) []string {
+	return []string{
+		"tombstone", t.ChainDay,
+		strconv.FormatInt(t.FirstSeq, 10), strconv.FormatInt(t.LastSeq, 10), strconv.FormatInt(t.EntryCount, 10),
+		t.FirstPrevHash, t.LastHash,
+	}
+}
+
+// Checkpoint records a signed checkpoint for every chain head that moved
+// since its last checkpoint
+func (s *AuditChainService) Checkpoint() (int, error) {
+	heads, err := s.repo.ListUncheckpointedHeads()
+	if err != nil {
+		return 0, err
+	}
+	for i, head := range heads {
+		day := repository.AuditChainDay(head.Day)
+		if err := s.repo.CreateCheckpoint(day, head.Seq, head.Hash, s.sign(checkpointParts(day, head.Seq, head.Hash)...)); err != nil {
+			return i, err
+		}
+	}
+	return len(heads), nil
+}
+
+// Verify walks the chains of every day in the range and reports each entry
+// that was edited, deleted or reordered, checkpoints that no longer match,
+// and chains cut short after their last checkpoint
+func (s *AuditChainService) Verify(req models.AuditChainVerifyRequest) (*models.AuditChainVerifyResponse, error) {
+	start, err := parseTime(req.StartTime)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAuditVerifyRange, err)
+	}
+	end, err := parseTime(req.EndTime)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAuditVerifyRange, err)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("%w: end_time must be after start_time", ErrInvalidAuditVerifyRange)
+	}
+	first := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	last := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	if last.Sub(first) >= auditVerifyMaxDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: cannot exceed %d days", ErrInvalidAuditVerifyRange, auditVerifyMaxDays)
+	}
+
+	response := &models.AuditChainVerifyResponse{
+		Signed:    s.signed(),
+		Days:      []models.AuditChainDayResult{},
+		Breaks:    []models.AuditChainBreak{},
+		CheckedAt: time.Now(),
+	}
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		day := repository.AuditChainDay(d)
+		result, breaks, err := s.verifyDay(day)
+		if err != nil {
+			return nil, fmt.Errorf("verify %s: %w", day, err)
+		}
+		if result == nil {
+			continue
+		}
+		response.Days = append(response.Days, *result)
+		response.Breaks = append(response.Breaks, breaks...)
+		response.Entries += result.Entries
+	}
+
+	unchained, err := s.repo.CountUnchained(start, end)
+	if err != nil {
+		return nil, err
+	}
+	response.Unchained = unchained
+	response.Valid = len(response.Breaks) == 0
+	return response, nil
+}
+
+// verifyDay checks one day's chain; it returns nil if the day has no chain
+func (s *AuditChainService) verifyDay(day string) (*models.AuditChainDayResult, []models.AuditChainBreak, error) {
+	head, err := s.repo.GetHead(day)
+	if err != nil {
+		return nil, nil, err
+	}
+	tombstone, err := s.repo.GetTombstone(day)
+	if err != nil {
+		return nil, nil, err
+	}
+	checkpoints, err := s.repo.ListCheckpoints(day)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &models.AuditChainDayResult{Day: day, Checkpoints: len(checkpoints)}
+	breaks := []models.AuditChainBreak{}
+	report := func(seq int64, entryID, kind, detail string) {
+		breaks = append(breaks, models.AuditChainBreak{Day: day, Seq: seq, EntryID: entryID, Kind: kind, Detail: detail})
+	}
+
+	// A tombstone stands in for the deleted start of the chain
+	walker := hashchain.NewWalker(0, hashchain.Genesis(day))
+	if tombstone != nil {
+		result.Tombstoned = tombstone.EntryCount
+		if s.signed() && !hashchain.VerifySignature(s.key, tombstone.Signature, tombstoneParts(tombstone)...) {
+			report(tombstone.LastSeq, "", AuditBreakBadSignature, "tombstone signature is invalid")
+		}
+		if tombstone.FirstSeq != 1 || tombstone.FirstPrevHash != hashchain.Genesis(day) {
+			report(tombstone.FirstSeq, "", hashchain.BreakLink, "tombstone does not start at the chain's genesis")
+		}
+		walker = hashchain.NewWalker(tombstone.LastSeq, tombstone.LastHash)
+	}
+
+	bySeq := make(map[int64]models.AuditChainCheckpoint, len(checkpoints))
+	for _, cp := range checkpoints {
+		bySeq[cp.Seq] = cp
+		if s.signed() && !hashchain.VerifySignature(s.key, cp.Signature, checkpointParts(day, cp.Seq, cp.EntryHash)...) {
+			report(cp.Seq, "", AuditBreakBadSignature, "checkpoint signature is invalid")
+		}
+		if tombstone != nil && cp.Seq == tombstone.LastSeq && cp.EntryHash != tombstone.LastHash {
+			report(cp.Seq, "", AuditBreakCheckpointMismatch, "tombstone does not end at the checkpointed hash")
+		}
+	}
+
+	err = s.repo.WalkDay(day, func(row repository.AuditChainRow) error {
+		result.Entries++
+		for _, b := range walker.Next(row.Seq, row.PrevHash, row.Hash, row.Content) {
+			report(b.Seq, row.ID, b.Kind, b.Detail)
+		}
+		if cp, ok := bySeq[row.Seq]; ok && cp.EntryHash != row.Hash {
+			report(row.Seq, row.ID, AuditBreakCheckpointMismatch, "entry hash differs from the checkpoint taken at "+cp.CreatedAt.Format(time.RFC3339))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lastSeq, lastHash := walker.Last()
+	result.LastSeq = lastSeq
+	if head != nil {
+		result.HeadSeq = head.Seq
+		if head.Seq > lastSeq {
+			report(lastSeq+1, "", AuditBreakTruncated, fmt.Sprintf("chain head is at sequence %d but the chain ends at %d", head.Seq, lastSeq))
+		} else if head.Seq == lastSeq && head.Hash != lastHash {
+			report(lastSeq, "", hashchain.BreakLink, "chain head hash does not match the last entry")
+		}
+	}
+	if len(checkpoints) > 0 {
+		if latest := checkpoints[len(checkpoints)-1]; latest.Seq > lastSeq && (head == nil || head.Seq <= lastSeq) {
+			report(lastSeq+1, "", AuditBreakTruncated, fmt.Sprintf("checkpoint at sequence %d is past the end of the chain at %d", latest.Seq, lastSeq))
+		}
+	}
+
+	if head == nil && tombstone == nil && result.Entries == 0 && len(checkpoints) == 0 {
+		return nil, nil, nil
+	}
+	result.Valid = len(breaks) == 0
+	return result, breaks, nil
+}
+
+// TombstoneTx deletes a day's chained entries up to lastSeq and leaves a
+// signed tombstone recording the range they covered, so removal by retention
+// stays distinguishable from tampering
+func (s *AuditChainService) TombstoneTx(tx *sql.Tx, day string, lastSeq int64) (int64, error) {
+	tombstone, err := s.repo.SummarizeDayTx(tx, day, lastSeq)
+	if err != nil {
+		return 0, err
+	}
+	if tombstone == nil {
+		return 0, nil
+	}
+	tombstone.Signature = s.sign(tombstoneParts(tombstone)...)
+	if err := s.repo.SaveTombstoneTx(tx, tombstone); err != nil {
+		return 0, err
+	}
+	return s.repo.DeleteDayTx(tx, day, tombstone.LastSeq)
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"bufio"
+	"comment-review-platform/internal/models"
+	"comment-review-platform/pkg/xlsx"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+const (
+	auditExportCompressionNone = "none"
+	auditExportCompressionGzip = "gzip"
+	// auditExportConcurrency bounds the export jobs running at once; later
+	// jobs wait in the queued state
+	auditExportConcurrency = 2
+	// Progress is saved every auditExportProgressRows rows or
+	// auditExportProgressInterval, whichever comes first
+	auditExportProgressRows     = 5000
+	auditExportProgressInterval = 2 * time.Second
+	// auditExportStaleAfter is how long an unfinished job may go without
+	// saving progress before it is considered dead
+	auditExportStaleAfter = 10 * time.Minute
+)
+
+var auditExportSlots = make(chan struct{}, auditExportConcurrency)
+
+type auditExportJob struct {
+	id          string
+	key         string
+	format      string
+	compression string
+	fields      []string
+	filters     models.AuditLogQueryFilters
+}
+
+func auditExportKey(userID int, exportID, format, compression string) string {
+	key := fmt.Sprintf("audit-exports/%d/%s.%s", userID, exportID, format)
+	if compression == auditExportCompressionGzip {
+		key += ".gz"
+	}
+	return key
+}
+
+func auditExportContentType(format, compression string) string {
+	if compression == auditExportCompressionGzip {
+		return "application/gzip"
+	}
+	switch format {
+	case "json":
+		return "application/json"
+	case "xlsx":
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv"
+	}
+}
+
+// runExport runs an export job to completion and records how it ended
+func (s *AuditLogService) runExport(job auditExportJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.failExport(job, fmt.Errorf("export panicked: %v", r))
+		}
+	}()
+
+	// Keep the heartbeat going while waiting for a slot
+	heartbeat := time.NewTicker(auditExportStaleAfter / 4)
+	defer heartbeat.Stop()
+waiting:
+	for {
+		select {
+		case auditExportSlots <- struct{}{}:
+			break waiting
+		case <-heartbeat.C:
+			if err := s.repo.UpdateExportProgress(job.id, 0, 0); err != nil {
+				log.Printf("⚠️  Error updating audit export %s: %v", job.id, err)
+			}
+		}
+	}
+	defer func() { <-auditExportSlots }()
+
+	rows, size, err := s.streamExport(job)
+	if err != nil {
+		s.failExport(job, err)
+		return
+	}
+
+	expiresAt := time.Now().Add(auditExportExpiration)
+	if err := s.repo.CompleteExportRecord(job.id, rows, size, job.key, expiresAt); err != nil {
+		log.Printf("⚠️  Error completing audit export %s: %v", job.id, err)
+		return
+	}
+	log.Printf("Exported %d audit logs (%d bytes) to %s", rows, size, job.key)
+}
+
+func (s *AuditLogService) failExport(job auditExportJob, err error) {
+	log.Printf("⚠️  Audit export %s failed: %v", job.id, err)
+	if err := s.repo.FailExportRecord(job.id, err.Error()); err != nil {
+		log.Printf("⚠️  Error recording failed audit export %s: %v", job.id, err)
+	}
+}
+
+// streamExport streams the job's rows through the encoder and optional gzip
+// into a multipart upload. Archived entries come first, read from R2 one
+// archive at a time, then the hot rows. It returns the rows written and the
+// object size.
+func (s *AuditLogService) streamExport(job auditExportJob) (int64, int64, error) {
+	// Count first so progress has a total; archives are read twice rather
+	// than held in memory
+	var total int64
+	if _, err := s.archive.ScanRange(job.filters, func(entries []models.AuditLogEntry) error {
+		total += int64(len(entries))
+		return nil
+	}); err != nil {
+		return 0, 0, err
+	}
+	hotTotal, err := s.repo.CountLogs(job.filters)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := s.repo.StartExportRecord(job.id, total+hotTotal); err != nil {
+		return 0, 0, err
+	}
+
+	upload, err := s.r2.NewMultipartWriter(job.key, auditExportContentType(job.format, job.compression))
+	if err != nil {
+		return 0, 0, err
+	}
+	uploaded := false
+	defer func() {
+		if !uploaded {
+			if err := upload.Abort(); err != nil {
+				log.Printf("⚠️  %v", err)
+			}
+		}
+	}()
+
+	var out io.Writer = upload
+	var gz *gzip.Writer
+	if job.compression == auditExportCompressionGzip {
+		gz = gzip.NewWriter(upload)
+		out = gz
+	}
+	encoder, err := newAuditExportEncoder(job.format, job.fields, out)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var rows int64
+	lastProgress := time.Now()
+	write := func(entry models.AuditLogEntry) error {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+		rows++
+		if rows%auditExportProgressRows == 0 || time.Since(lastProgress) >= auditExportProgressInterval {
+			lastProgress = time.Now()
+			return s.repo.UpdateExportProgress(job.id, rows, upload.Size())
+		}
+		return nil
+	}
+	_, err = s.archive.ScanRange(job.filters, func(entries []models.AuditLogEntry) error {
+		for _, entry := range entries {
+			if err := write(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := s.repo.StreamLogs(job.filters, write); err != nil {
+		return 0, 0, err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return 0, 0, err
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return 0, 0, err
+		}
+	}
+	if err := upload.Close(); err != nil {
+		return 0, 0, err
+	}
+	uploaded = true
+	return rows, upload.Size(), nil
+}
+
+// auditExportEncoder writes audit log entries in one export format
+type auditExportEncoder interface {
+	Encode(entry models.AuditLogEntry) error
+	// Close writes whatever ends the document; it leaves w open
+	Close() error
+}
+
+func newAuditExportEncoder(format string, fields []string, w io.Writer) (auditExportEncoder, error) {
+	resolvers := exportFieldResolvers()
+	switch format {
+	case "json":
+		buffered := bufio.NewWriter(w)
+		if _, err := buffered.WriteString("["); err != nil {
+			return nil, err
+		}
+		return &jsonExportEncoder{w: buffered, fields: fields, resolvers: resolvers}, nil
+	case "xlsx":
+		writer := xlsx.NewWriter(w, "audit_logs")
+		header := make([]interface{}, len(fields))
+		for i, field := range fields {
+			header[i] = field
+		}
+		if err := writer.SetHeader(header...); err != nil {
+			return nil, err
+		}
+		return &xlsxExportEncoder{w: writer, fields: fields, resolvers: resolvers}, nil
+	default:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(fields); err != nil {
+			return nil, err
+		}
+		return &csvExportEncoder{w: writer, fields: fields, resolvers: resolvers}, nil
+	}
+}
+
+type csvExportEncoder struct {
+	w         *csv.Writer
+	fields    []string
+	resolvers map[string]func(models.AuditLogEntry) interface{}
+}
+
+func (e *csvExportEncoder) Encode(entry models.AuditLogEntry) error {
+	row := make([]string, len(e.fields))
+	for i, field := range e.fields {
+		row[i] = formatExportValue(e.resolvers[field](entry))
+	}
+	return e.w.Write(row)
+}
+
+func (e *csvExportEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonExportEncoder writes a JSON array one element at a time
+type jsonExportEncoder struct {
+	w         *bufio.Writer
+	fields    []string
+	resolvers map[string]func(models.AuditLogEntry) interface{}
+	count     int
+}
+
+func (e *jsonExportEncoder) Encode(entry models.AuditLogEntry) error {
+	record := make(map[string]interface{}, len(e.fields))
+	for _, field := range e.fields {
+		record[field] = e.resolvers[field](entry)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if e.count > 0 {
+		if err := e.w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	e.count++
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonExportEncoder) Close() error {
+	if _, err := e.w.WriteString("]"); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+type xlsxExportEncoder struct {
+	w         *xlsx.Writer
+	fields    []string
+	resolvers map[string]func(models.AuditLogEntry) interface{}
+}
+
+func (e *xlsxExportEncoder) Encode(entry models.AuditLogEntry) error {
+	row := make([]interface{}, len(e.fields))
+	for i, field := range e.fields {
+		switch value := e.resolvers[field](entry).(type) {
+		case int, int64, float64:
+			row[i] = value
+		default:
+			row[i] = formatExportValue(value)
+		}
+	}
+	return e.w.WriteRow(row...)
+}
+
+func (e *xlsxExportEncoder) Close() error {
+	return e.w.Close()
+}
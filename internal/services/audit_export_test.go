@@ -0,0 +1,111 @@
+package services
+
+import (
+	"bytes"
+	"comment-review-platform/internal/models"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func exportEntries() []models.AuditLogEntry {
+	at := time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC)
+	return []models.AuditLogEntry{
+		{ID: "a", CreatedAt: at, Username: "alice", StatusCode: 200, Changes: json.RawMessage(`{"after":{"status":"approved"}}`)},
+		{ID: "b", CreatedAt: at.Add(time.Minute), Username: "bob, jr", StatusCode: 500},
+	}
+}
+
+func encodeExport(t *testing.T, format string, fields []string, w io.Writer) {
+	t.Helper()
+	encoder, err := newAuditExportEncoder(format, fields, w)
+	if err != nil {
+		t.Fatalf("newAuditExportEncoder: %v", err)
+	}
+	for _, entry := range exportEntries() {
+		if err := encoder.Encode(entry); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestCSVExportEncoderThroughGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	encodeExport(t, "csv", []string{"id", "username", "status_code", "changes"}, gz)
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip Close: %v", err)
+	}
+
+	reader, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	rows, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header and 2 entries", len(rows))
+	}
+	if rows[1][3] != `{"after":{"status":"approved"}}` {
+		t.Fatalf("changes column = %q, want the JSON document", rows[1][3])
+	}
+	if rows[2][1] != "bob, jr" || rows[2][2] != "500" {
+		t.Fatalf("second row = %v", rows[2])
+	}
+}
+
+func TestJSONExportEncoderWritesArray(t *testing.T) {
+	var buf bytes.Buffer
+	encodeExport(t, "json", []string{"id", "status_code"}, &buf)
+
+	var records []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buf.String(), err)
+	}
+	if len(records) != 2 || records[1]["id"] != "b" || records[1]["status_code"] != float64(500) {
+		t.Fatalf("records = %v", records)
+	}
+
+	var empty bytes.Buffer
+	encoder, _ := newAuditExportEncoder("json", []string{"id"}, &empty)
+	if err := encoder.Close(); err != nil || empty.String() != "[]" {
+		t.Fatalf("empty export = %q, %v; want []", empty.String(), err)
+	}
+}
+
+func TestXLSXExportEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	encodeExport(t, "xlsx", []string{"id", "username", "status_code"}, &buf)
+
+	file, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatalf("OpenReader: %v", err)
+	}
+	defer file.Close()
+	rows, err := file.GetRows("audit_logs")
+	if err != nil {
+		t.Fatalf("GetRows: %v", err)
+	}
+	if len(rows) != 3 || rows[0][0] != "id" || rows[2][1] != "bob, jr" || rows[2][2] != "500" {
+		t.Fatalf("rows = %v", rows)
+	}
+}
+
+func TestAuditExportKey(t *testing.T) {
+	if key := auditExportKey(7, "abc", "csv", auditExportCompressionGzip); key != "audit-exports/7/abc.csv.gz" {
+		t.Fatalf("key = %q", key)
+	}
+	if key := auditExportKey(7, "abc", "xlsx", auditExportCompressionNone); key != "audit-exports/7/abc.xlsx" {
+		t.Fatalf("key = %q", key)
+	}
+}
@@ -1,29 +1,29 @@
 package services
 
 import (
-	"bytes"
+	"comment-review-platform/internal/config"
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/repository"
+	"comment-review-platform/pkg/database"
 	"comment-review-platform/pkg/r2"
-	"encoding/csv"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
-
-	"github.com/xuri/excelize/v2"
 )
 
 const (
-	auditExportMaxRows    = 100000
 	auditExportDailyLimit = 20
 	auditExportExpiration = 7 * 24 * time.Hour
 )
 
 type AuditLogService struct {
-	repo *repository.AuditLogRepository
-	r2   *r2.R2Service
+	repo    *repository.AuditLogRepository
+	r2      *r2.R2Service
+	archive *AuditArchiveService
 }
 
 func NewAuditLogService() *AuditLogService {
@@ -32,8 +32,9 @@ func NewAuditLogService() *AuditLogService {
 		r2Service = nil
 	}
 	return &AuditLogService{
-		repo: repository.NewAuditLogRepository(),
-		r2:   r2Service,
+		repo:    repository.NewAuditLogRepository(),
+		r2:      r2Service,
+		archive: NewAuditArchiveService(database.DB, config.AppConfig.AuditChainSigningKey),
 	}
 }
 
@@ -58,28 +59,119 @@ func (s *AuditLogService) QueryLogs(req models.AuditLogQueryRequest) (*models.Au
 	sortBy := strings.TrimSpace(req.SortBy)
 	sortOrder := strings.TrimSpace(req.SortOrder)
 
-	entries, total, err := s.repo.ListLogs(filters, page, pageSize, sortBy, sortOrder)
+	entries, total, archivedDays, err := s.listLogs(filters, page, pageSize, sortBy, sortOrder)
 	if err != nil {
 		return nil, err
 	}
 
 	totalPages := (total + pageSize - 1) / pageSize
 	return &models.AuditLogQueryResponse{
-		Data:       entries,
-		Total:      total,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: totalPages,
+		Data:         entries,
+		Total:        total,
+		Page:         page,
+		PageSize:     pageSize,
+		TotalPages:   totalPages,
+		ArchivedDays: archivedDays,
 	}, nil
 }
 
-func (s *AuditLogService) GetLogByID(id string) (*models.AuditLogEntry, error) {
+// listLogs queries the hot table and, when the range reaches past retention,
+// the archives in R2 as well. Archived entries are filtered while each archive
+// streams by, keeping only the best offset+pageSize of them in sort order;
+// these are merged with as many hot rows to cut out the page.
+func (s *AuditLogService) listLogs(filters models.AuditLogQueryFilters, page, pageSize int, sortBy, sortOrder string) ([]models.AuditLogEntry, int, int, error) {
+	limit := page * pageSize
+	less := auditLogOrder(sortBy, sortOrder)
+
+	var archived []models.AuditLogEntry
+	archivedTotal := 0
+	archivedDays, err := s.archive.ScanRange(filters, func(entries []models.AuditLogEntry) error {
+		archivedTotal += len(entries)
+		archived = topAuditLogs(append(archived, entries...), limit, less)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if archivedTotal == 0 {
+		entries, total, err := s.repo.ListLogs(filters, page, pageSize, sortBy, sortOrder)
+		return entries, total, archivedDays, err
+	}
+
+	hot, hotTotal, err := s.repo.ListLogs(filters, 1, limit, sortBy, sortOrder)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	merged := topAuditLogs(append(hot, archived...), limit, less)
+	offset := (page - 1) * pageSize
+	if offset > len(merged) {
+		offset = len(merged)
+	}
+	return merged[offset:], hotTotal + archivedTotal, archivedDays, nil
+}
+
+// auditLogOrder returns the order listAuditLogs sorts by. NULL durations,
+// stored for a zero duration, sort as the largest value as in Postgres.
+func auditLogOrder(sortBy, sortOrder string) func(a, b models.AuditLogEntry) bool {
+	desc := strings.ToLower(sortOrder) != "asc"
+	return func(a, b models.AuditLogEntry) bool {
+		if sortBy == "duration_ms" && a.DurationMs != b.DurationMs {
+			switch {
+			case a.DurationMs == 0:
+				return desc
+			case b.DurationMs == 0:
+				return !desc
+			}
+			return (a.DurationMs < b.DurationMs) != desc
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt) != desc
+		}
+		return (a.ID < b.ID) != desc
+	}
+}
+
+// topAuditLogs sorts entries and keeps the first limit of them
+func topAuditLogs(entries []models.AuditLogEntry, limit int, less func(a, b models.AuditLogEntry) bool) []models.AuditLogEntry {
+	sort.SliceStable(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// GetLogByID returns an entry from the hot table, or from the archive of the
+// day given by createdAt once retention has moved it there
+func (s *AuditLogService) GetLogByID(id, createdAt string) (*models.AuditLogEntry, error) {
 	if strings.TrimSpace(id) == "" {
 		return nil, errors.New("invalid audit log id")
 	}
-	return s.repo.GetLogByID(id)
+	entry, err := s.repo.GetLogByID(id)
+	if err != sql.ErrNoRows || strings.TrimSpace(createdAt) == "" {
+		return entry, err
+	}
+	at, err := parseTime(createdAt)
+	if err != nil {
+		return nil, err
+	}
+	return s.archive.FindEntry(id, at)
 }
 
+// ListArchives returns the archive objects holding entries in the range
+func (s *AuditLogService) ListArchives(startTime, endTime string) ([]models.AuditLogArchive, error) {
+	start, err := parseTime(startTime)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseTime(endTime)
+	if err != nil {
+		return nil, err
+	}
+	return s.archive.ListArchives(start, end)
+}
+
+// ExportLogs queues a background job that streams the matching logs to R2.
+// Progress and the download link show up in ListExports.
 func (s *AuditLogService) ExportLogs(userID int, username, role string, req models.AuditLogExportRequest) (*models.AuditLogExportResponse, error) {
 	if s.r2 == nil {
 		return nil, errors.New("R2 storage is not configured")
@@ -101,55 +193,34 @@ func (s *AuditLogService) ExportLogs(userID int, username, role string, req mode
 		}
 	}
 
+	format := strings.ToLower(req.Format)
+	compression := strings.ToLower(strings.TrimSpace(req.Compression))
+	if compression == "" {
+		compression = auditExportCompressionNone
+	}
 	filtersJSON, _ := json.Marshal(req)
 	fields := normalizeExportFields(req.Fields)
 
-	exportID, err := s.repo.CreateExportRecord(userID, username, req.Format, filtersJSON, fields)
-	if err != nil {
-		return nil, err
-	}
-
-	defer func() {
-		if err != nil {
-			_ = s.repo.UpdateExportRecord(exportID, "failed", 0, "", time.Time{}, err.Error())
-		}
-	}()
-
-	entries, total, err := s.repo.ListLogs(filters, 1, auditExportMaxRows, "created_at", "asc")
+	exportID, err := s.repo.CreateExportRecord(userID, username, format, compression, filtersJSON, fields)
 	if err != nil {
 		return nil, err
 	}
-	if total > auditExportMaxRows {
-		err = fmt.Errorf("export rows exceed limit (%d)", auditExportMaxRows)
-		return nil, err
-	}
 
-	data, contentType, err := buildExportFile(req.Format, fields, entries)
-	if err != nil {
-		return nil, err
-	}
-
-	ext := strings.ToLower(req.Format)
-	key := fmt.Sprintf("audit-exports/%d/%s.%s", userID, exportID, ext)
-	if err := s.r2.UploadObject(key, data, contentType); err != nil {
-		return nil, err
-	}
-
-	expiresAt := time.Now().Add(auditExportExpiration)
-	downloadURL, err := s.r2.GeneratePresignedURL(key, auditExportExpiration)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := s.repo.UpdateExportRecord(exportID, "completed", len(entries), key, expiresAt, ""); err != nil {
-		return nil, err
+	job := auditExportJob{
+		id:          exportID,
+		key:         auditExportKey(userID, exportID, format, compression),
+		format:      format,
+		compression: compression,
+		fields:      fields,
+		filters:     filters,
 	}
+	go s.runExport(job)
 
 	return &models.AuditLogExportResponse{
 		ExportID:    exportID,
-		DownloadURL: downloadURL,
-		ExpiresAt:   expiresAt,
-		RowCount:    len(entries),
+		Status:      models.AuditExportQueued,
+		Format:      format,
+		Compression: compression,
 	}, nil
 }
 
@@ -164,6 +235,10 @@ func (s *AuditLogService) ListExports(userID int, page, pageSize int) (*models.A
 		pageSize = 100
 	}
 
+	if _, err := s.repo.FailStaleExports(time.Now().Add(-auditExportStaleAfter)); err != nil {
+		return nil, err
+	}
+
 	records, total, err := s.repo.ListExports(userID, page, pageSize)
 	if err != nil {
 		return nil, err
@@ -299,111 +374,6 @@ func normalizeExportFields(fields []string) []string {
 	return clean
 }
 
-func buildExportFile(format string, fields []string, entries []models.AuditLogEntry) ([]byte, string, error) {
-	switch strings.ToLower(format) {
-	case "json":
-		return buildJSONExport(fields, entries)
-	case "xlsx":
-		return buildXLSXExport(fields, entries)
-	case "csv":
-		fallthrough
-	default:
-		return buildCSVExport(fields, entries)
-	}
-}
-
-func buildCSVExport(fields []string, entries []models.AuditLogEntry) ([]byte, string, error) {
-	var buffer bytes.Buffer
-	writer := csv.NewWriter(&buffer)
-
-	if err := writer.Write(fields); err != nil {
-		return nil, "", err
-	}
-
-	resolvers := exportFieldResolvers()
-	for _, entry := range entries {
-		row := make([]string, len(fields))
-		for i, field := range fields {
-			row[i] = formatExportValue(resolvers[field](entry))
-		}
-		if err := writer.Write(row); err != nil {
-			return nil, "", err
-		}
-	}
-
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return nil, "", err
-	}
-
-	return buffer.Bytes(), "text/csv", nil
-}
-
-func buildJSONExport(fields []string, entries []models.AuditLogEntry) ([]byte, string, error) {
-	resolvers := exportFieldResolvers()
-	records := make([]map[string]interface{}, 0, len(entries))
-	for _, entry := range entries {
-		record := make(map[string]interface{}, len(fields))
-		for _, field := range fields {
-			record[field] = resolvers[field](entry)
-		}
-		records = append(records, record)
-	}
-
-	data, err := json.Marshal(records)
-	if err != nil {
-		return nil, "", err
-	}
-	return data, "application/json", nil
-}
-
-func buildXLSXExport(fields []string, entries []models.AuditLogEntry) ([]byte, string, error) {
-	file := excelize.NewFile()
-	defer func() {
-		_ = file.Close()
-	}()
-
-	sheet := "Sheet1"
-	stream, err := file.NewStreamWriter(sheet)
-	if err != nil {
-		return nil, "", err
-	}
-
-	header := make([]interface{}, len(fields))
-	for i, field := range fields {
-		header[i] = field
-	}
-	if err := stream.SetRow("A1", header); err != nil {
-		return nil, "", err
-	}
-
-	resolvers := exportFieldResolvers()
-	for idx, entry := range entries {
-		row := make([]interface{}, len(fields))
-		for i, field := range fields {
-			row[i] = formatExportValue(resolvers[field](entry))
-		}
-		cell, err := excelize.CoordinatesToCellName(1, idx+2)
-		if err != nil {
-			return nil, "", err
-		}
-		if err := stream.SetRow(cell, row); err != nil {
-			return nil, "", err
-		}
-	}
-
-	if err := stream.Flush(); err != nil {
-		return nil, "", err
-	}
-
-	buffer, err := file.WriteToBuffer()
-	if err != nil {
-		return nil, "", err
-	}
-
-	return buffer.Bytes(), "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
-}
-
 func exportFieldResolvers() map[string]func(models.AuditLogEntry) interface{} {
 	return map[string]func(models.AuditLogEntry) interface{}{
 		"id":         func(e models.AuditLogEntry) interface{} { return e.ID },
@@ -475,6 +445,10 @@ func formatExportValue(value interface{}) string {
 		if value == nil {
 			return ""
 		}
+		// Objects and arrays from JSON columns
+		if data, err := json.Marshal(value); err == nil {
+			return string(data)
+		}
 		return fmt.Sprintf("%v", value)
 	}
 }
@@ -3,6 +3,7 @@ package services
 import (
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/repository"
+	"context"
 	"errors"
 
 	"golang.org/x/crypto/bcrypt"
@@ -71,8 +72,8 @@ func (s *AuthService) Login(username, password string) (*models.User, error) {
 }
 
 // GetUserByID retrieves a user by ID
-func (s *AuthService) GetUserByID(id int) (*models.User, error) {
-	return s.userRepo.FindByID(id)
+func (s *AuthService) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	return s.userRepo.FindByID(ctx, id)
 }
 
 // GetUserByEmail retrieves a user by email
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// bugReportMaxTraceIDs bounds the trace IDs kept per report; the
+	// frontend sends the most recent first
+	bugReportMaxTraceIDs = 10
+	bugReportMaxTraceLen = 100
+	// Failed requests older than bugReportTraceLookback before the report
+	// are not linked
+	bugReportTraceLookback = 24 * time.Hour
+	// Audit logs are written in batches, so traces that failed just before
+	// the report may not be stored yet; they are looked up once more after
+	// bugReportTraceRetryDelay
+	bugReportTraceRetryDelay = 5 * time.Second
+	bugReportSignatureSep    = ","
+)
+
+// normalizeTraceIDs trims, splits comma-separated values and de-duplicates
+// the submitted trace IDs, dropping any that cannot be a trace ID
+func normalizeTraceIDs(values []string) []string {
+	seen := make(map[string]bool)
+	traceIDs := make([]string, 0)
+	for _, value := range values {
+		for _, traceID := range strings.Split(value, ",") {
+			traceID = strings.TrimSpace(traceID)
+			if traceID == "" || seen[traceID] || !isTraceID(traceID) {
+				continue
+			}
+			seen[traceID] = true
+			traceIDs = append(traceIDs, traceID)
+			if len(traceIDs) == bugReportMaxTraceIDs {
+				return traceIDs
+			}
+		}
+	}
+	return traceIDs
+}
+
+func isTraceID(value string) bool {
+	if len(value) > bugReportMaxTraceLen {
+		return false
+	}
+	for _, r := range value {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func errorLinkFromAuditEntry(entry models.AuditLogEntry) models.BugReportErrorLink {
+	return models.BugReportErrorLink{
+		TraceID:      entry.RequestID,
+		AuditLogID:   entry.ID,
+		OccurredAt:   entry.CreatedAt,
+		Endpoint:     entry.Endpoint,
+		HTTPMethod:   entry.HTTPMethod,
+		StatusCode:   entry.StatusCode,
+		ErrorCode:    entry.ErrorCode,
+		ErrorType:    entry.ErrorType,
+		ErrorMessage: entry.ErrorMessage,
+		ErrorStack:   entry.ErrorStack,
+	}
+}
+
+// bugReportErrorSignature is the sorted set of error codes of a report's
+// linked errors; reports with the same signature are grouped together.
+// Errors without a code count by their HTTP status.
+func bugReportErrorSignature(links []models.BugReportErrorLink) string {
+	seen := make(map[string]bool)
+	codes := make([]string, 0, len(links))
+	for _, link := range links {
+		code := strings.TrimSpace(link.ErrorCode)
+		if code == "" {
+			code = fmt.Sprintf("HTTP_%d", link.StatusCode)
+		}
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	return strings.Join(codes, bugReportSignatureSep)
+}
+
+// linkServerErrors copies the report's failed requests from the audit log
+// and refreshes its error signature. It returns how many of the report's
+// traces are linked.
+func (s *BugReportService) linkServerErrors(report *models.BugReport) (int, error) {
+	entries, err := s.auditRepo.FindFailedByRequestIDs(report.TraceIDs, report.UserID, report.CreatedAt.Add(-bugReportTraceLookback))
+	if err != nil {
+		return 0, err
+	}
+	links := make([]models.BugReportErrorLink, 0, len(entries))
+	for _, entry := range entries {
+		links = append(links, errorLinkFromAuditEntry(entry))
+	}
+	if err := s.repo.InsertErrorLinks(report.ID, links); err != nil {
+		return 0, err
+	}
+
+	linked, err := s.repo.ListErrorLinks([]int{report.ID}, false)
+	if err != nil {
+		return 0, err
+	}
+	if len(linked[report.ID]) > 0 {
+		if err := s.repo.SetErrorSignature(report.ID, bugReportErrorSignature(linked[report.ID])); err != nil {
+			return 0, err
+		}
+	}
+	return len(linked[report.ID]), nil
+}
+
+// linkServerErrorsOnCreate links a new report's traces, retrying once later
+// for traces whose audit entries were not written yet. Traces that never
+// show up were not failures, or failed before reaching the server.
+func (s *BugReportService) linkServerErrorsOnCreate(report models.BugReport) {
+	if len(report.TraceIDs) == 0 {
+		return
+	}
+	linked, err := s.linkServerErrors(&report)
+	if err != nil {
+		log.Printf("⚠️  Error linking server errors to bug report %d: %v", report.ID, err)
+	}
+	if err == nil && linked == len(report.TraceIDs) {
+		return
+	}
+	time.AfterFunc(bugReportTraceRetryDelay, func() {
+		if _, err := s.linkServerErrors(&report); err != nil {
+			log.Printf("⚠️  Error linking server errors to bug report %d: %v", report.ID, err)
+		}
+	})
+}
+
+// attachErrorLinks fills in the linked errors of admin items
+func (s *BugReportService) attachErrorLinks(items []models.BugReportAdminItem, withStack bool) error {
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	links, err := s.repo.ListErrorLinks(ids, withStack)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].LinkedErrors = links[items[i].ID]
+	}
+	return nil
+}
+
+// ListErrorGroups pages through the groups of reports that share an error
+// signature
+func (s *BugReportService) ListErrorGroups(req models.BugReportErrorGroupRequest) (*models.BugReportErrorGroupResponse, error) {
+	page := req.Page
+	pageSize := req.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	groups, total, err := s.repo.ListErrorGroups(req.OpenOnly, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		groups[i].ErrorCodes = strings.Split(groups[i].ErrorSignature, bugReportSignatureSep)
+	}
+
+	return &models.BugReportErrorGroupResponse{
+		Data:       groups,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}, nil
+}
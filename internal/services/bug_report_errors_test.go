@@ -0,0 +1,51 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTraceIDs(t *testing.T) {
+	got := normalizeTraceIDs([]string{
+		" 7f3c-01 , 7f3c-02",
+		"7f3c-01",
+		"",
+		"bad id with spaces",
+		"<script>",
+		strings.Repeat("a", bugReportMaxTraceLen+1),
+		"abc_DEF-9",
+	})
+	want := []string{"7f3c-01", "7f3c-02", "abc_DEF-9"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("normalizeTraceIDs = %v, want %v", got, want)
+	}
+
+	many := make([]string, 0, bugReportMaxTraceIDs+5)
+	for i := 0; i < bugReportMaxTraceIDs+5; i++ {
+		many = append(many, strings.Repeat("t", i+1))
+	}
+	if got := normalizeTraceIDs(many); len(got) != bugReportMaxTraceIDs || got[0] != "t" {
+		t.Fatalf("normalizeTraceIDs kept %d ids starting %q, want the first %d", len(got), got[0], bugReportMaxTraceIDs)
+	}
+}
+
+func TestBugReportErrorSignature(t *testing.T) {
+	links := []models.BugReportErrorLink{
+		{ErrorCode: "SUBMIT_FAILED", StatusCode: 400},
+		{ErrorCode: "INTERNAL_ERROR", StatusCode: 500},
+		{ErrorCode: "SUBMIT_FAILED", StatusCode: 400},
+		{StatusCode: 502},
+	}
+	if got := bugReportErrorSignature(links); got != "HTTP_502,INTERNAL_ERROR,SUBMIT_FAILED" {
+		t.Fatalf("signature = %q", got)
+	}
+
+	reordered := []models.BugReportErrorLink{links[3], links[1], links[0]}
+	if bugReportErrorSignature(reordered) != bugReportErrorSignature(links) {
+		t.Fatal("signature should not depend on link order")
+	}
+	if got := bugReportErrorSignature(nil); got != "" {
+		t.Fatalf("signature of no links = %q, want empty", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/repository"
 	"comment-review-platform/pkg/r2"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
@@ -39,11 +40,14 @@ var (
 
 type BugReportService struct {
 	repo             *repository.BugReportRepository
+	auditRepo        *repository.AuditLogRepository
+	userRepo         *repository.UserRepository
+	notifications    *NotificationService
 	r2               *r2.R2Service
 	screenshotPrefix string
 }
 
-func NewBugReportService() *BugReportService {
+func NewBugReportService(notifications *NotificationService) *BugReportService {
 	r2Service, err := r2.NewR2Service()
 	if err != nil {
 		r2Service = nil
@@ -51,12 +55,15 @@ func NewBugReportService() *BugReportService {
 
 	return &BugReportService{
 		repo:             repository.NewBugReportRepository(),
+		auditRepo:        repository.NewAuditLogRepository(),
+		userRepo:         repository.NewUserRepository(),
+		notifications:    notifications,
 		r2:               r2Service,
 		screenshotPrefix: normalizeBugReportPrefix(config.AppConfig.R2BugReportPathPrefix),
 	}
 }
 
-func (s *BugReportService) CreateBugReport(userID int, input models.CreateBugReportInput, files []*multipart.FileHeader) (*models.BugReport, error) {
+func (s *BugReportService) CreateBugReport(ctx context.Context, userID int, input models.CreateBugReportInput, files []*multipart.FileHeader) (*models.BugReport, error) {
 	description := strings.TrimSpace(input.Description)
 	if description == "" {
 		return nil, errors.New("请填写问题描述")
@@ -80,7 +87,7 @@ func (s *BugReportService) CreateBugReport(userID int, input models.CreateBugRep
 
 	screenshots := make([]models.BugReportScreenshot, 0, len(files))
 	for _, fileHeader := range files {
-		screenshot, err := s.uploadScreenshot(userID, fileHeader)
+		screenshot, err := s.uploadScreenshot(ctx, userID, fileHeader)
 		if err != nil {
 			return nil, err
 		}
@@ -95,11 +102,13 @@ func (s *BugReportService) CreateBugReport(userID int, input models.CreateBugRep
 		PageURL:      strings.TrimSpace(input.PageURL),
 		UserAgent:    strings.TrimSpace(input.UserAgent),
 		Screenshots:  screenshots,
+		TraceIDs:     normalizeTraceIDs(input.TraceIDs),
 	}
 
 	if err := s.repo.Create(&report); err != nil {
 		return nil, err
 	}
+	s.linkServerErrorsOnCreate(report)
 
 	return &report, nil
 }
@@ -117,11 +126,19 @@ func (s *BugReportService) ListBugReports(req models.BugReportQueryRequest) (*mo
 		pageSize = 100
 	}
 
-	filters, err := parseBugReportFilters(req.StartTime, req.EndTime, req.UserID, req.Username, req.Keyword)
+	filters, err := parseBugReportFilters(req.StartTime, req.EndTime, req.UserID, req.Username, req.Keyword, req.Status, req.AssigneeID)
 	if err != nil {
 		return nil, err
 	}
 
+	filters.ErrorSignature = strings.TrimSpace(req.ErrorSignature)
+
+	return s.listBugReports(filters, page, pageSize, true)
+}
+
+// listBugReports pages through reports; withErrors attaches their linked
+// server errors, without stacks
+func (s *BugReportService) listBugReports(filters models.BugReportQueryFilters, page, pageSize int, withErrors bool) (*models.BugReportListResponse, error) {
 	records, total, err := s.repo.ListWithFilters(filters, page, pageSize)
 	if err != nil {
 		return nil, err
@@ -129,34 +146,12 @@ func (s *BugReportService) ListBugReports(req models.BugReportQueryRequest) (*mo
 
 	items := make([]models.BugReportAdminItem, 0, len(records))
 	for _, record := range records {
-		shots := make([]models.BugReportScreenshotView, 0, len(record.Screenshots))
-		for _, shot := range record.Screenshots {
-			view := models.BugReportScreenshotView{
-				Key:         shot.Key,
-				Filename:    shot.Filename,
-				Size:        shot.Size,
-				ContentType: shot.ContentType,
-			}
-			if s.r2 != nil && shot.Key != "" {
-				if url, err := s.r2.GeneratePresignedURL(shot.Key, bugReportPreviewExpiration); err == nil {
-					view.URL = url
-				}
-			}
-			shots = append(shots, view)
+		items = append(items, s.buildAdminItem(record))
+	}
+	if withErrors {
+		if err := s.attachErrorLinks(items, false); err != nil {
+			return nil, err
 		}
-
-		items = append(items, models.BugReportAdminItem{
-			ID:           record.ID,
-			UserID:       record.UserID,
-			Username:     record.Username,
-			Title:        record.Title,
-			Description:  record.Description,
-			ErrorDetails: record.ErrorDetails,
-			PageURL:      record.PageURL,
-			UserAgent:    record.UserAgent,
-			Screenshots:  shots,
-			CreatedAt:    record.CreatedAt,
-		})
 	}
 
 	totalPages := (total + pageSize - 1) / pageSize
@@ -169,6 +164,48 @@ func (s *BugReportService) ListBugReports(req models.BugReportQueryRequest) (*mo
 	}, nil
 }
 
+// buildAdminItem converts a record for the API, presigning its screenshots
+func (s *BugReportService) buildAdminItem(record models.BugReportAdminRecord) models.BugReportAdminItem {
+	shots := make([]models.BugReportScreenshotView, 0, len(record.Screenshots))
+	for _, shot := range record.Screenshots {
+		view := models.BugReportScreenshotView{
+			Key:         shot.Key,
+			Filename:    shot.Filename,
+			Size:        shot.Size,
+			ContentType: shot.ContentType,
+		}
+		if s.r2 != nil && shot.Key != "" {
+			if url, err := s.r2.GeneratePresignedURL(shot.Key, bugReportPreviewExpiration); err == nil {
+				view.URL = url
+			}
+		}
+		shots = append(shots, view)
+	}
+
+	return models.BugReportAdminItem{
+		ID:               record.ID,
+		UserID:           record.UserID,
+		Username:         record.Username,
+		Title:            record.Title,
+		Description:      record.Description,
+		ErrorDetails:     record.ErrorDetails,
+		PageURL:          record.PageURL,
+		UserAgent:        record.UserAgent,
+		Screenshots:      shots,
+		TraceIDs:         record.TraceIDs,
+		ErrorSignature:   record.ErrorSignature,
+		Status:           record.Status,
+		AssigneeID:       record.AssigneeID,
+		AssigneeUsername: record.AssigneeUsername,
+		DuplicateOf:      record.DuplicateOf,
+		Resolution:       record.Resolution,
+		ResolvedAt:       record.ResolvedAt,
+		CommentCount:     record.CommentCount,
+		CreatedAt:        record.CreatedAt,
+		UpdatedAt:        record.UpdatedAt,
+	}
+}
+
 func (s *BugReportService) ExportBugReports(req models.BugReportExportRequest) ([]byte, string, string, error) {
 	format := strings.ToLower(strings.TrimSpace(req.Format))
 	if format == "" {
@@ -178,10 +215,11 @@ func (s *BugReportService) ExportBugReports(req models.BugReportExportRequest) (
 		return nil, "", "", errors.New("仅支持 csv 或 json 格式导出")
 	}
 
-	filters, err := parseBugReportFilters(req.StartTime, req.EndTime, req.UserID, req.Username, req.Keyword)
+	filters, err := parseBugReportFilters(req.StartTime, req.EndTime, req.UserID, req.Username, req.Keyword, req.Status, req.AssigneeID)
 	if err != nil {
 		return nil, "", "", err
 	}
+	filters.ErrorSignature = strings.TrimSpace(req.ErrorSignature)
 
 	records, total, err := s.repo.ListWithFilters(filters, 1, bugReportExportMaxRows)
 	if err != nil {
@@ -215,7 +253,8 @@ func buildBugReportCSV(records []models.BugReportAdminRecord) ([]byte, error) {
 
 	header := []string{
 		"id", "user_id", "username", "title", "description", "error_details",
-		"page_url", "user_agent", "screenshots", "created_at",
+		"page_url", "user_agent", "screenshots", "trace_ids", "error_signature", "status", "assignee_id", "assignee_username",
+		"duplicate_of", "resolution", "comment_count", "created_at", "updated_at", "resolved_at",
 	}
 	if err := writer.Write(header); err != nil {
 		return nil, err
@@ -233,7 +272,17 @@ func buildBugReportCSV(records []models.BugReportAdminRecord) ([]byte, error) {
 			record.PageURL,
 			record.UserAgent,
 			string(screenshotsJSON),
+			strings.Join(record.TraceIDs, " "),
+			record.ErrorSignature,
+			record.Status,
+			formatOptionalInt(record.AssigneeID),
+			record.AssigneeUsername,
+			formatOptionalInt(record.DuplicateOf),
+			record.Resolution,
+			fmt.Sprintf("%d", record.CommentCount),
 			record.CreatedAt.Format(time.RFC3339),
+			record.UpdatedAt.Format(time.RFC3339),
+			formatOptionalTime(record.ResolvedAt),
 		}
 		if err := writer.Write(row); err != nil {
 			return nil, err
@@ -247,15 +296,36 @@ func buildBugReportCSV(records []models.BugReportAdminRecord) ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
-func parseBugReportFilters(startTime, endTime string, userID int, username, keyword string) (models.BugReportQueryFilters, error) {
+func formatOptionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d", *value)
+}
+
+func formatOptionalTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.Format(time.RFC3339)
+}
+
+func parseBugReportFilters(startTime, endTime string, userID int, username, keyword, status string, assigneeID int) (models.BugReportQueryFilters, error) {
 	filters := models.BugReportQueryFilters{
 		Username: strings.TrimSpace(username),
 		Keyword:  strings.TrimSpace(keyword),
+		Status:   strings.TrimSpace(status),
 	}
 
 	if userID > 0 {
 		filters.UserID = &userID
 	}
+	if assigneeID > 0 {
+		filters.AssigneeID = &assigneeID
+	}
+	if filters.Status != "" && !models.BugReportStatusMachine.Has(filters.Status) {
+		return filters, fmt.Errorf("无效的反馈状态：%s", status)
+	}
 
 	start, err := parseBugReportTime(startTime)
 	if err != nil {
@@ -293,7 +363,7 @@ func parseBugReportTime(value string) (*time.Time, error) {
 	return nil, fmt.Errorf("时间格式错误：%s", value)
 }
 
-func (s *BugReportService) uploadScreenshot(userID int, fileHeader *multipart.FileHeader) (models.BugReportScreenshot, error) {
+func (s *BugReportService) uploadScreenshot(ctx context.Context, userID int, fileHeader *multipart.FileHeader) (models.BugReportScreenshot, error) {
 	if fileHeader == nil {
 		return models.BugReportScreenshot{}, errors.New("截图文件无效")
 	}
@@ -329,7 +399,7 @@ func (s *BugReportService) uploadScreenshot(userID int, fileHeader *multipart.Fi
 	}
 
 	key := fmt.Sprintf("%s%d/%s%s", s.screenshotPrefix, userID, uuid.NewString(), ext)
-	if err := s.r2.UploadObject(key, data, contentType); err != nil {
+	if err := s.r2.UploadObject(ctx, key, data, contentType); err != nil {
 		return models.BugReportScreenshot{}, err
 	}
 
@@ -0,0 +1,402 @@
+package services
+
+import (
+	"comment-review-platform/internal/auditevent"
+	"comment-review-platform/internal/models"
+	"comment-review-platform/pkg/database"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrBugReportNotFound         = errors.New("bug report not found")
+	ErrBugReportForbidden        = errors.New("bug report belongs to another user")
+	ErrBugReportAssigneeNotAdmin = errors.New("bug reports can only be assigned to admins")
+	ErrBugReportInvalidDuplicate = errors.New("invalid duplicate link")
+	ErrBugReportInvalidParent    = errors.New("invalid parent comment")
+)
+
+var bugReportStatusLabels = map[string]string{
+	models.BugReportStatusNew:        "待处理",
+	models.BugReportStatusTriaged:    "已确认",
+	models.BugReportStatusInProgress: "处理中",
+	models.BugReportStatusResolved:   "已解决",
+	models.BugReportStatusWontfix:    "不予处理",
+}
+
+// bugReportTriageState is the part of a report that triage changes, as
+// recorded in the audit log
+type bugReportTriageState struct {
+	Status      string `json:"status"`
+	AssigneeID  *int   `json:"assignee_id"`
+	DuplicateOf *int   `json:"duplicate_of"`
+	Resolution  string `json:"resolution,omitempty"`
+}
+
+func triageState(report *models.BugReport) bugReportTriageState {
+	return bugReportTriageState{
+		Status:      report.Status,
+		AssigneeID:  report.AssigneeID,
+		DuplicateOf: report.DuplicateOf,
+		Resolution:  report.Resolution,
+	}
+}
+
+func isBugReportClosed(status string) bool {
+	return status == models.BugReportStatusResolved || status == models.BugReportStatusWontfix
+}
+
+// applyBugReportStatus moves report to status. Closing a report stamps
+// resolved_at and keeps the resolution note; reopening it clears both along
+// with any duplicate link, since the report is being worked on again.
+func applyBugReportStatus(report *models.BugReport, status, resolution string, now time.Time) error {
+	if err := models.BugReportStatusMachine.Validate(report.Status, status); err != nil {
+		return err
+	}
+
+	if isBugReportClosed(status) {
+		if report.Status != status || report.ResolvedAt == nil {
+			report.ResolvedAt = &now
+		}
+		if resolution = strings.TrimSpace(resolution); resolution != "" {
+			report.Resolution = resolution
+		}
+	} else {
+		report.ResolvedAt = nil
+		report.Resolution = ""
+		report.DuplicateOf = nil
+	}
+	report.Status = status
+	return nil
+}
+
+// duplicateRoot returns the report that reportID should be linked to when
+// marked as a duplicate of target. Links always point at a report that is
+// not itself a duplicate, so a target that is one is followed to its
+// original; linking a report to itself or its own duplicate is refused.
+func duplicateRoot(reportID int, target *models.BugReport) (int, error) {
+	root := target.ID
+	if target.DuplicateOf != nil {
+		root = *target.DuplicateOf
+	}
+	if root == reportID {
+		return 0, fmt.Errorf("%w: report #%d cannot duplicate itself", ErrBugReportInvalidDuplicate, reportID)
+	}
+	return root, nil
+}
+
+// threadBugReportComments nests replies under their parents. Comments come
+// oldest first and keep that order at every level; a reply whose parent is
+// missing, such as a reply to a hidden internal note, is dropped.
+func threadBugReportComments(comments []models.BugReportComment) []models.BugReportComment {
+	children := make(map[int][]int, len(comments))
+	roots := make([]int, 0)
+	for i, comment := range comments {
+		if comment.ParentID == nil {
+			roots = append(roots, i)
+			continue
+		}
+		children[*comment.ParentID] = append(children[*comment.ParentID], i)
+	}
+
+	var build func(index int) models.BugReportComment
+	build = func(index int) models.BugReportComment {
+		comment := comments[index]
+		comment.Replies = make([]models.BugReportComment, 0, len(children[comment.ID]))
+		for _, child := range children[comment.ID] {
+			comment.Replies = append(comment.Replies, build(child))
+		}
+		return comment
+	}
+
+	threads := make([]models.BugReportComment, 0, len(roots))
+	for _, index := range roots {
+		threads = append(threads, build(index))
+	}
+	return threads
+}
+
+// lockBugReport locks a report inside tx, mapping a missing row to
+// ErrBugReportNotFound
+func (s *BugReportService) lockBugReport(tx *sql.Tx, id int) (*models.BugReport, error) {
+	report, err := s.repo.GetForUpdateTx(tx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: #%d", ErrBugReportNotFound, id)
+	}
+	return report, err
+}
+
+// UpdateStatus moves a report through triage and notifies its reporter
+func (s *BugReportService) UpdateStatus(ctx context.Context, actorID, id int, req models.UpdateBugReportStatusRequest) (*models.BugReport, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report, err := s.lockBugReport(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := triageState(report)
+	if err := applyBugReportStatus(report, req.Status, req.Resolution, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateTriageTx(tx, report); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.recordTriage(ctx, auditevent.BugReportStatusChange, actorID, report.ID, before, triageState(report))
+	if before.Status != report.Status {
+		s.notifyStatusChange(actorID, report, before.Status)
+	}
+	return report, nil
+}
+
+// Assign hands a report to an admin, or unassigns it when assigneeID is nil
+func (s *BugReportService) Assign(ctx context.Context, actorID, id int, assigneeID *int) (*models.BugReport, error) {
+	if assigneeID != nil {
+		assignee, err := s.userRepo.FindByID(ctx, *assigneeID)
+		if err != nil || assignee.Role != "admin" {
+			return nil, fmt.Errorf("%w: user #%d", ErrBugReportAssigneeNotAdmin, *assigneeID)
+		}
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report, err := s.lockBugReport(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := triageState(report)
+	report.AssigneeID = assigneeID
+	if err := s.repo.UpdateTriageTx(tx, report); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.recordTriage(ctx, auditevent.BugReportAssign, actorID, report.ID, before, triageState(report))
+	return report, nil
+}
+
+// MarkDuplicate links a report to the report it duplicates and closes it as
+// wontfix if it was still open. A nil duplicateOf removes the link and
+// leaves the status alone.
+func (s *BugReportService) MarkDuplicate(ctx context.Context, actorID, id int, duplicateOf *int) (*models.BugReport, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report, err := s.lockBugReport(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := triageState(report)
+
+	if duplicateOf == nil {
+		report.DuplicateOf = nil
+	} else {
+		target, err := s.lockBugReport(tx, *duplicateOf)
+		if err != nil {
+			return nil, err
+		}
+		root, err := duplicateRoot(report.ID, target)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repo.RepointDuplicatesTx(tx, report.ID, root); err != nil {
+			return nil, err
+		}
+		if !isBugReportClosed(report.Status) {
+			resolution := fmt.Sprintf("与反馈 #%d 重复", root)
+			if err := applyBugReportStatus(report, models.BugReportStatusWontfix, resolution, time.Now()); err != nil {
+				return nil, err
+			}
+		}
+		report.DuplicateOf = &root
+	}
+
+	if err := s.repo.UpdateTriageTx(tx, report); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.recordTriage(ctx, auditevent.BugReportDuplicate, actorID, report.ID, before, triageState(report))
+	if before.Status != report.Status {
+		s.notifyStatusChange(actorID, report, before.Status)
+	}
+	return report, nil
+}
+
+func (s *BugReportService) recordTriage(ctx context.Context, action auditevent.Action, actorID, id int, before, after bugReportTriageState) {
+	auditevent.Record(ctx, auditevent.Event{
+		Action:       action,
+		ResourceType: "bug_report",
+		ResourceID:   strconv.Itoa(id),
+		ActorID:      &actorID,
+		Before:       before,
+		After:        after,
+	})
+}
+
+// notifyStatusChange tells the reporter their report changed status. When a
+// report is resolved, the reporters of its duplicates are told as well.
+func (s *BugReportService) notifyStatusChange(actorID int, report *models.BugReport, from string) {
+	if s.notifications == nil {
+		return
+	}
+
+	name := fmt.Sprintf("#%d", report.ID)
+	if report.Title != "" {
+		name += "「" + report.Title + "」"
+	}
+	content := fmt.Sprintf("你提交的错误反馈 %s 状态已由「%s」变为「%s」", name,
+		bugReportStatusLabels[from], bugReportStatusLabels[report.Status])
+	if report.Resolution != "" && isBugReportClosed(report.Status) {
+		content += "。处理说明：" + report.Resolution
+	}
+	notificationType := "info"
+	if report.Status == models.BugReportStatusResolved {
+		notificationType = "success"
+	}
+	if _, err := s.notifications.NotifyUser(report.UserID, "错误反馈状态更新", content, notificationType, actorID); err != nil {
+		log.Printf("⚠️  Error notifying reporter of bug report %d: %v", report.ID, err)
+	}
+
+	if report.Status != models.BugReportStatusResolved {
+		return
+	}
+	reporters, err := s.repo.ListDuplicateReporters(report.ID)
+	if err != nil {
+		log.Printf("⚠️  Error listing duplicates of bug report %d: %v", report.ID, err)
+		return
+	}
+	duplicateContent := fmt.Sprintf("与你提交的错误反馈重复的问题 %s 已解决", name)
+	for _, userID := range reporters {
+		if userID == report.UserID {
+			continue
+		}
+		if _, err := s.notifications.NotifyUser(userID, "错误反馈状态更新", duplicateContent, "success", actorID); err != nil {
+			log.Printf("⚠️  Error notifying duplicate reporter %d of bug report %d: %v", userID, report.ID, err)
+		}
+	}
+}
+
+// GetReport returns a report with its comment threads. Reporters can only
+// read their own reports and do not see internal notes.
+func (s *BugReportService) GetReport(userID int, isAdmin bool, id int) (*models.BugReportDetail, error) {
+	record, err := s.repo.GetRecord(id, !isAdmin)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: #%d", ErrBugReportNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && record.UserID != userID {
+		return nil, ErrBugReportForbidden
+	}
+
+	comments, err := s.repo.ListComments(id, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	item := s.buildAdminItem(*record)
+	if isAdmin {
+		items := []models.BugReportAdminItem{item}
+		if err := s.attachErrorLinks(items, true); err != nil {
+			return nil, err
+		}
+		item = items[0]
+	}
+
+	return &models.BugReportDetail{
+		BugReportAdminItem: item,
+		Comments:           threadBugReportComments(comments),
+	}, nil
+}
+
+// AddComment adds a comment or reply to a report. Reporters can comment on
+// their own reports only, and only admins can write or answer internal notes.
+func (s *BugReportService) AddComment(userID int, isAdmin bool, reportID int, req models.CreateBugReportCommentRequest) (*models.BugReportComment, error) {
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return nil, errors.New("请填写评论内容")
+	}
+
+	record, err := s.repo.GetRecord(reportID, !isAdmin)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: #%d", ErrBugReportNotFound, reportID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && record.UserID != userID {
+		return nil, ErrBugReportForbidden
+	}
+
+	if req.ParentID != nil {
+		parentReportID, parentInternal, err := s.repo.GetCommentReport(*req.ParentID)
+		if errors.Is(err, sql.ErrNoRows) || parentReportID != reportID || (parentInternal && !isAdmin) {
+			return nil, fmt.Errorf("%w: #%d", ErrBugReportInvalidParent, *req.ParentID)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	comment := &models.BugReportComment{
+		ReportID: reportID,
+		ParentID: req.ParentID,
+		AuthorID: userID,
+		Body:     body,
+		Internal: req.Internal && isAdmin,
+		Replies:  []models.BugReportComment{},
+	}
+	if err := s.repo.CreateComment(comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// ListMyReports pages through the reports the user submitted
+func (s *BugReportService) ListMyReports(userID int, req models.MyBugReportsRequest) (*models.BugReportListResponse, error) {
+	page := req.Page
+	pageSize := req.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	filters, err := parseBugReportFilters("", "", userID, "", "", req.Status, 0)
+	if err != nil {
+		return nil, err
+	}
+	filters.PublicCommentsOnly = true
+
+	return s.listBugReports(filters, page, pageSize, false)
+}
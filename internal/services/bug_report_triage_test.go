@@ -0,0 +1,81 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/pkg/statemachine"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestApplyBugReportStatus(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	report := &models.BugReport{ID: 1, Status: models.BugReportStatusNew}
+
+	if err := applyBugReportStatus(report, models.BugReportStatusResolved, "  fixed in 2.3 ", now); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if report.ResolvedAt == nil || !report.ResolvedAt.Equal(now) || report.Resolution != "fixed in 2.3" {
+		t.Fatalf("resolved report = %+v", report)
+	}
+
+	err := applyBugReportStatus(report, models.BugReportStatusInProgress, "", now)
+	if !errors.Is(err, statemachine.ErrInvalidTransition) {
+		t.Fatalf("resolved -> in_progress error = %v, want ErrInvalidTransition", err)
+	}
+
+	duplicateOf := 9
+	report.DuplicateOf = &duplicateOf
+	if err := applyBugReportStatus(report, models.BugReportStatusTriaged, "", now); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if report.ResolvedAt != nil || report.Resolution != "" || report.DuplicateOf != nil {
+		t.Fatalf("reopened report kept closing fields: %+v", report)
+	}
+}
+
+func TestDuplicateRoot(t *testing.T) {
+	root, err := duplicateRoot(1, &models.BugReport{ID: 2})
+	if err != nil || root != 2 {
+		t.Fatalf("duplicateRoot = %d, %v; want 2", root, err)
+	}
+
+	original := 3
+	if root, err := duplicateRoot(1, &models.BugReport{ID: 2, DuplicateOf: &original}); err != nil || root != 3 {
+		t.Fatalf("duplicate of a duplicate = %d, %v; want its original 3", root, err)
+	}
+
+	if _, err := duplicateRoot(1, &models.BugReport{ID: 1}); !errors.Is(err, ErrBugReportInvalidDuplicate) {
+		t.Fatalf("self link error = %v", err)
+	}
+	self := 1
+	if _, err := duplicateRoot(1, &models.BugReport{ID: 2, DuplicateOf: &self}); !errors.Is(err, ErrBugReportInvalidDuplicate) {
+		t.Fatalf("cycle error = %v", err)
+	}
+}
+
+func TestThreadBugReportComments(t *testing.T) {
+	parent := func(id int) *int { return &id }
+	threads := threadBugReportComments([]models.BugReportComment{
+		{ID: 1, Body: "first"},
+		{ID: 2, ParentID: parent(1), Body: "reply"},
+		{ID: 3, Body: "second"},
+		{ID: 4, ParentID: parent(2), Body: "nested"},
+		{ID: 5, ParentID: parent(99), Body: "orphan"},
+		{ID: 6, ParentID: parent(1), Body: "later reply"},
+	})
+
+	if len(threads) != 2 || threads[0].ID != 1 || threads[1].ID != 3 {
+		t.Fatalf("threads = %+v", threads)
+	}
+	replies := threads[0].Replies
+	if len(replies) != 2 || replies[0].ID != 2 || replies[1].ID != 6 {
+		t.Fatalf("replies = %+v", replies)
+	}
+	if len(replies[0].Replies) != 1 || replies[0].Replies[0].ID != 4 {
+		t.Fatalf("nested replies = %+v", replies[0].Replies)
+	}
+	if threads[1].Replies == nil {
+		t.Fatal("Replies should be an empty slice, not nil")
+	}
+}
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"comment-review-platform/pkg/database"
+	"comment-review-platform/pkg/textsim"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	// commentClusterMinSimilarity is the estimated Jaccard similarity of two
+	// normalized comments' shingles above which they share a cluster
+	commentClusterMinSimilarity = 0.7
+	commentSignatureBatchSize   = 5000
+	// commentClusterWindow bounds how far back unclustered comments are kept
+	// as seeds for new clusters; spam waves arrive within hours
+	commentClusterWindow     = 24 * time.Hour
+	commentClusterPoolLimit  = 20000
+	commentClusterMinMembers = 2
+)
+
+var (
+	ErrCommentClusterNotFound = errors.New("comment cluster not found")
+	ErrCommentClusterNotOpen  = errors.New("comment cluster has already been decided")
+	ErrCommentClusterEmpty    = errors.New("no cluster members left to decide")
+)
+
+// CommentClusterService groups pending first review comments with
+// near-identical text, such as spam waves, and lets a reviewer decide a whole
+// cluster at once. Every member still gets its own review result.
+type CommentClusterService struct {
+	repo             *repository.CommentClusterRepository
+	commentRepo      *repository.CommentRepository
+	secondReviewRepo *repository.SecondReviewRepository
+	tasks            *TaskService
+}
+
+func NewCommentClusterService() *CommentClusterService {
+	return &CommentClusterService{
+		repo:             repository.NewCommentClusterRepository(),
+		commentRepo:      repository.NewCommentRepository(),
+		secondReviewRepo: repository.NewSecondReviewRepository(),
+		tasks:            NewTaskService(),
+	}
+}
+
+// ClusterPending signs newly pending comments and groups them into clusters:
+// a comment joins the open cluster whose representative it matches, and
+// unclustered comments that match each other open a new cluster.
+func (s *CommentClusterService) ClusterPending() error {
+	if closed, err := s.repo.CloseReviewedClusters(); err != nil {
+		log.Printf("Error closing reviewed comment clusters: %v", err)
+	} else if closed > 0 {
+		log.Printf("Closed %d comment clusters reviewed member by member", closed)
+	}
+
+	unsigned, err := s.repo.ListUnsignedPendingTasks(commentSignatureBatchSize)
+	if err != nil {
+		return fmt.Errorf("list unsigned comments: %w", err)
+	}
+	for i := range unsigned {
+		unsigned[i].Signature = commentSignature(unsigned[i].Text)
+	}
+	if err := s.repo.SaveSignatures(unsigned); err != nil {
+		return fmt.Errorf("save comment signatures: %w", err)
+	}
+
+	seeds, err := s.repo.ListOpenClusterSeeds()
+	if err != nil {
+		return fmt.Errorf("list open clusters: %w", err)
+	}
+	pool, err := s.repo.ListUnclusteredPending(time.Now().Add(-commentClusterWindow), commentClusterPoolLimit)
+	if err != nil {
+		return fmt.Errorf("list unclustered comments: %w", err)
+	}
+
+	plan := planCommentClusters(seeds, pool, commentClusterMinSimilarity)
+	joined := 0
+	for clusterID, members := range plan.joins {
+		if err := s.assign(clusterID, members); err != nil {
+			return err
+		}
+		joined += len(members)
+	}
+	for _, members := range plan.groups {
+		clusterID, err := s.repo.CreateCluster(members[0].comment)
+		if err != nil {
+			return fmt.Errorf("create comment cluster: %w", err)
+		}
+		if err := s.assign(clusterID, members); err != nil {
+			return err
+		}
+	}
+
+	if len(unsigned) > 0 || joined > 0 || len(plan.groups) > 0 {
+		log.Printf("Comment clustering: %d signed, %d joined open clusters, %d new clusters",
+			len(unsigned), joined, len(plan.groups))
+	}
+	return nil
+}
+
+func (s *CommentClusterService) assign(clusterID int, members []clusterJoin) error {
+	taskIDs := make([]int, len(members))
+	similarities := make([]float64, len(members))
+	for i, m := range members {
+		taskIDs[i], similarities[i] = m.comment.TaskID, m.similarity
+	}
+	if err := s.repo.AssignMembers(clusterID, taskIDs, similarities); err != nil {
+		return fmt.Errorf("assign comments to cluster %d: %w", clusterID, err)
+	}
+	return nil
+}
+
+// ListClusters returns open clusters with at least minSize open members
+func (s *CommentClusterService) ListClusters(minSize, page, pageSize int) (*models.ListCommentClustersResponse, error) {
+	if minSize < commentClusterMinMembers {
+		minSize = commentClusterMinMembers
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	clusters, total, err := s.repo.ListOpenClusters(minSize, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ListCommentClustersResponse{
+		Data:       clusters,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}, nil
+}
+
+// GetCluster returns a cluster with its open members
+func (s *CommentClusterService) GetCluster(id int) (*models.CommentCluster, error) {
+	cluster, err := s.repo.GetCluster(id)
+	if err == sql.ErrNoRows {
+		return nil, ErrCommentClusterNotFound
+	}
+	return cluster, err
+}
+
+// DecideCluster applies one decision to every open member of a cluster except
+// the excluded ones, in one transaction. Each member gets its own review
+// result and, when rejected, its own second review task, exactly as if it had
+// been submitted alone. Members another reviewer has claimed are skipped.
+func (s *CommentClusterService) DecideCluster(reviewerID, clusterID int, req models.DecideCommentClusterRequest) (*models.DecideCommentClusterResponse, error) {
+	if err := validateTags(s.tasks.tagRepo, "comment", req.Tags); err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	status, err := s.repo.LockClusterTx(tx, clusterID)
+	if err == sql.ErrNoRows {
+		return nil, ErrCommentClusterNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != models.CommentClusterOpen {
+		return nil, ErrCommentClusterNotOpen
+	}
+
+	excluded, err := s.repo.ExcludeMembersTx(tx, clusterID, req.ExcludeTaskIDs)
+	if err != nil {
+		return nil, err
+	}
+	members, skipped, err := s.repo.LockDecidableMembersTx(tx, clusterID, reviewerID, req.ExcludeTaskIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, ErrCommentClusterEmpty
+	}
+
+	taskIDs := make([]int, len(members))
+	for i, m := range members {
+		taskIDs[i] = m.TaskID
+	}
+	if err := s.repo.CompleteTasksTx(tx, reviewerID, taskIDs); err != nil {
+		return nil, err
+	}
+	resultIDs, err := s.repo.CreateReviewResultsTx(tx, reviewerID, taskIDs, req.IsApproved, req.Tags, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := make([]models.CommentClusterDecision, 0, len(members))
+	commentIDs := make([]int64, 0, len(members))
+	statusUpdates := make([]repository.CommentStatusUpdate, 0, len(members))
+	decidedResultIDs := make([]int, 0, len(members))
+	for _, m := range members {
+		resultID, ok := resultIDs[m.TaskID]
+		if !ok {
+			continue
+		}
+		decisions = append(decisions, models.CommentClusterDecision{TaskID: m.TaskID, CommentID: m.CommentID, ReviewResultID: resultID})
+		commentIDs = append(commentIDs, m.CommentID)
+		statusUpdates = append(statusUpdates, repository.CommentStatusUpdate{CommentID: m.CommentID, TaskID: m.TaskID})
+		decidedResultIDs = append(decidedResultIDs, resultID)
+	}
+
+	var secondReviewComments []int64
+	change := models.StatusChange{
+		Source:  models.StatusSourceFirstReview,
+		ActorID: &reviewerID,
+		Reason:  fmt.Sprintf("comment cluster %d", clusterID),
+	}
+	if req.Reason != "" {
+		change.Reason += ": " + req.Reason
+	}
+	if req.IsApproved {
+		if err := s.commentRepo.UpdateModerationStatusesTx(tx, statusUpdates, models.CommentStatusApproved, change); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := s.commentRepo.UpdateModerationStatusesTx(tx, statusUpdates, models.CommentStatusPendingSecondReview, change); err != nil {
+			return nil, err
+		}
+		secondReviewComments, err = s.secondReviewRepo.CreateSecondReviewTasksTx(tx, decidedResultIDs, commentIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	clusterStatus, err := s.repo.FinishDecisionTx(tx, clusterID, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.afterDecision(reviewerID, members, decisions, secondReviewComments, req)
+
+	return &models.DecideCommentClusterResponse{
+		ClusterID:     clusterID,
+		ClusterStatus: clusterStatus,
+		Decisions:     decisions,
+		ExcludedCount: int(excluded),
+		SkippedCount:  skipped,
+	}, nil
+}
+
+// afterDecision runs the side effects SubmitReview runs after its commit for
+// every decided member
+func (s *CommentClusterService) afterDecision(reviewerID int, members []repository.ClusterMemberLock, decisions []models.CommentClusterDecision, secondReviewComments []int64, req models.DecideCommentClusterRequest) {
+	for _, d := range decisions {
+		if err := s.tasks.diffRepo.CreateTaskIfMismatchWithHumanResult(d.TaskID, d.ReviewResultID, req.IsApproved); err != nil {
+			log.Printf("Error creating AI diff task for review task %d: %v", d.TaskID, err)
+		}
+	}
+
+	if len(secondReviewComments) > 0 {
+		values := make([]interface{}, len(secondReviewComments))
+		for i, commentID := range secondReviewComments {
+			values[i] = commentID
+		}
+		if err := s.tasks.rdb.LPush(s.tasks.ctx, "review:queue:second", values...).Err(); err != nil {
+			log.Printf("Redis error pushing to second review queue: %v", err)
+		}
+	}
+
+	// Members the reviewer had claimed also leave their claimed set
+	userClaimedKey := fmt.Sprintf("task:claimed:%d", reviewerID)
+	pipe := s.tasks.rdb.Pipeline()
+	for _, m := range members {
+		if m.Status == "in_progress" {
+			pipe.SRem(s.tasks.ctx, userClaimedKey, m.TaskID)
+			pipe.Del(s.tasks.ctx, fmt.Sprintf("task:lock:%d", m.TaskID))
+		}
+	}
+	if _, err := pipe.Exec(s.tasks.ctx); err != nil {
+		log.Printf("Redis error when deciding comment cluster: %v", err)
+	}
+
+	for _, d := range decisions {
+		s.tasks.updateStats(s.tasks.ctx, &models.ReviewResult{
+			ID:         d.ReviewResultID,
+			TaskID:     d.TaskID,
+			ReviewerID: reviewerID,
+			IsApproved: req.IsApproved,
+			Tags:       req.Tags,
+			Reason:     req.Reason,
+		})
+	}
+}
+
+// commentSignature signs a comment's normalized text. Comments with nothing
+// left after normalization, e.g. only emoji, are signed as written so that
+// identical ones still cluster.
+func commentSignature(text string) textsim.Signature {
+	normalized := textsim.Normalize(text)
+	if normalized == "" {
+		normalized = strings.Join(strings.Fields(text), "")
+	}
+	return textsim.Sign(normalized)
+}
+
+type clusterJoin struct {
+	comment    repository.SignedComment
+	similarity float64
+}
+
+type commentClusterPlan struct {
+	joins  map[int][]clusterJoin // open cluster ID -> comments joining it
+	groups [][]clusterJoin       // new clusters; the first comment represents it
+}
+
+// planCommentClusters assigns each comment in pool to the most similar open
+// cluster, else to the first new group whose leader it matches, else makes it
+// the leader of a new group. Candidates come from LSH buckets, so only
+// comments sharing a signature band are ever compared. Groups smaller than
+// commentClusterMinMembers are dropped.
+func planCommentClusters(seeds []repository.ClusterSeed, pool []repository.SignedComment, minSimilarity float64) commentClusterPlan {
+	seedIndex := map[uint64][]int{}
+	for i, seed := range seeds {
+		for _, key := range textsim.BandKeys(seed.Signature) {
+			seedIndex[key] = append(seedIndex[key], i)
+		}
+	}
+
+	plan := commentClusterPlan{joins: map[int][]clusterJoin{}}
+	var groups [][]clusterJoin
+	groupIndex := map[uint64][]int{}
+
+	for _, comment := range pool {
+		keys := textsim.BandKeys(comment.Signature)
+
+		if i, similarity := bestCandidate(keys, seedIndex, minSimilarity, func(i int) textsim.Signature {
+			return seeds[i].Signature
+		}, comment.Signature); i >= 0 {
+			plan.joins[seeds[i].ID] = append(plan.joins[seeds[i].ID], clusterJoin{comment, similarity})
+			continue
+		}
+
+		if g, similarity := bestCandidate(keys, groupIndex, minSimilarity, func(g int) textsim.Signature {
+			return groups[g][0].comment.Signature
+		}, comment.Signature); g >= 0 {
+			groups[g] = append(groups[g], clusterJoin{comment, similarity})
+			continue
+		}
+
+		groups = append(groups, []clusterJoin{{comment, 1}})
+		for _, key := range keys {
+			groupIndex[key] = append(groupIndex[key], len(groups)-1)
+		}
+	}
+
+	for _, group := range groups {
+		if len(group) >= commentClusterMinMembers {
+			plan.groups = append(plan.groups, group)
+		}
+	}
+	return plan
+}
+
+// bestCandidate returns the indexed entry most similar to sig among those
+// sharing one of its band keys, or -1 when none reaches minSimilarity
+func bestCandidate(keys [textsim.Bands]uint64, index map[uint64][]int, minSimilarity float64, signatureOf func(int) textsim.Signature, sig textsim.Signature) (int, float64) {
+	best, bestSimilarity := -1, 0.0
+	seen := map[int]bool{}
+	for _, key := range keys {
+		for _, i := range index[key] {
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			if similarity := textsim.Similarity(signatureOf(i), sig); similarity >= minSimilarity && similarity > bestSimilarity {
+				best, bestSimilarity = i, similarity
+			}
+		}
+	}
+	return best, bestSimilarity
+}
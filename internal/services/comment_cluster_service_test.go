@@ -0,0 +1,56 @@
+package services
+
+import (
+	"comment-review-platform/internal/repository"
+	"testing"
+)
+
+func signedComment(taskID int, text string) repository.SignedComment {
+	return repository.SignedComment{TaskID: taskID, CommentID: int64(taskID), Text: text, Signature: commentSignature(text)}
+}
+
+func TestPlanCommentClustersGroupsVariants(t *testing.T) {
+	pool := []repository.SignedComment{
+		signedComment(1, "关注我的主页，每天免费领取会员，名额有限先到先得"),
+		signedComment(2, "这个视频拍得真好，背景音乐是什么歌？"),
+		signedComment(3, "关注我的主页!!每天免费领取会员~名额有限 先到先得"),
+		signedComment(4, "关注我的主页 每天免费领取会员 名额有限先到先得 😀😀"),
+	}
+	plan := planCommentClusters(nil, pool, commentClusterMinSimilarity)
+
+	if len(plan.groups) != 1 {
+		t.Fatalf("groups = %d, want 1", len(plan.groups))
+	}
+	group := plan.groups[0]
+	if len(group) != 3 || group[0].comment.TaskID != 1 || group[1].comment.TaskID != 3 || group[2].comment.TaskID != 4 {
+		t.Fatalf("unexpected group members: %+v", group)
+	}
+	if group[0].similarity != 1 {
+		t.Errorf("representative similarity = %v, want 1", group[0].similarity)
+	}
+}
+
+func TestPlanCommentClustersJoinsOpenCluster(t *testing.T) {
+	seed := repository.ClusterSeed{ID: 42, Signature: commentSignature("限时抢购，点击链接领取优惠券 http://a.example")}
+	pool := []repository.SignedComment{
+		signedComment(7, "限时抢购!点击链接领取优惠券 http://b.example"),
+		signedComment(8, "完全无关的一条普通评论"),
+	}
+	plan := planCommentClusters([]repository.ClusterSeed{seed}, pool, commentClusterMinSimilarity)
+
+	if got := plan.joins[42]; len(got) != 1 || got[0].comment.TaskID != 7 {
+		t.Fatalf("joins[42] = %+v, want task 7", got)
+	}
+	if len(plan.groups) != 0 {
+		t.Errorf("singletons should not form clusters, got %d groups", len(plan.groups))
+	}
+}
+
+func TestCommentSignatureEmojiOnly(t *testing.T) {
+	if commentSignature("😀😀") != commentSignature(" 😀😀 ") {
+		t.Error("identical emoji-only comments should share a signature")
+	}
+	if commentSignature("😀😀") == commentSignature("👍👍") {
+		t.Error("different emoji-only comments should not share a signature")
+	}
+}
@@ -0,0 +1,389 @@
+package services
+
+import (
+	"comment-review-platform/internal/auditevent"
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"comment-review-platform/pkg/database"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrErrorIssueNotFound = errors.New("error issue not found")
+
+const (
+	// errorIssueStackFrames is how many of the innermost application frames
+	// of a panic take part in the fingerprint
+	errorIssueStackFrames = 3
+	// errorIssueKeptOccurrences bounds the occurrences stored per issue;
+	// older ones remain reachable through the audit log
+	errorIssueKeptOccurrences  = 100
+	errorIssueShownOccurrences = 20
+	errorIssueMaxTitleLen      = 500
+)
+
+// errorIssueSkippedFrames are the stack frames of the panic machinery and of
+// the stack capture itself, which say nothing about where the error is
+var errorIssueSkippedFrames = []string{
+	"runtime.",
+	"runtime/debug.",
+	"comment-review-platform/internal/observability.",
+}
+
+type ErrorIssueService struct {
+	repo *repository.ErrorIssueRepository
+}
+
+func NewErrorIssueService() *ErrorIssueService {
+	return &ErrorIssueService{repo: repository.NewErrorIssueRepository()}
+}
+
+// normalizeErrorEndpoint reduces an endpoint to its route shape. Matched
+// routes are already patterns such as /api/tasks/:id; unmatched paths have
+// their query dropped and ID-like segments replaced with :id, so that every
+// 404 on /api/tasks/<n> lands in one issue.
+func normalizeErrorEndpoint(endpoint string) string {
+	if i := strings.IndexAny(endpoint, "?#"); i >= 0 {
+		endpoint = endpoint[:i]
+	}
+	segments := strings.Split(endpoint, "/")
+	for i, segment := range segments {
+		if isIDSegment(segment) {
+			segments[i] = ":id"
+		}
+	}
+	endpoint = strings.Join(segments, "/")
+	if endpoint == "" {
+		return "/"
+	}
+	return endpoint
+}
+
+// isIDSegment reports whether a path segment is a number, a UUID or a long
+// hex token
+func isIDSegment(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	digits, hexChars := true, true
+	for _, r := range segment {
+		isDigit := r >= '0' && r <= '9'
+		isHex := isDigit || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F' || r == '-'
+		digits = digits && isDigit
+		hexChars = hexChars && isHex
+	}
+	return digits || hexChars && len(segment) >= 16
+}
+
+// topStackFrames returns the innermost n function names of a debug.Stack
+// trace. For a recovered panic that is where the panic was raised, not the
+// recovery middleware that captured the stack. File paths and line numbers
+// are left out so the fingerprint survives unrelated edits and redeploys.
+func topStackFrames(stack string, n int) []string {
+	frames := make([]string, 0, n)
+	for _, line := range strings.Split(stack, "\n") {
+		if line == "" || strings.HasPrefix(line, "\t") ||
+			strings.HasPrefix(line, "goroutine ") || strings.HasPrefix(line, "created by ") {
+			continue
+		}
+		name := line
+		if i := strings.LastIndex(name, "("); i > 0 && strings.HasSuffix(name, ")") {
+			name = name[:i]
+		}
+		if name == "panic" {
+			frames = frames[:0]
+			continue
+		}
+		if isSkippedFrame(name) {
+			continue
+		}
+		frames = append(frames, name)
+	}
+	if len(frames) > n {
+		frames = frames[:n]
+	}
+	return frames
+}
+
+func isSkippedFrame(name string) bool {
+	for _, prefix := range errorIssueSkippedFrames {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorIssueCode is the error code an entry is grouped by; entries without
+// one count by their HTTP status
+func errorIssueCode(entry models.AuditLogEntry) string {
+	if code := strings.TrimSpace(entry.ErrorCode); code != "" {
+		return code
+	}
+	return fmt.Sprintf("HTTP_%d", entry.StatusCode)
+}
+
+// errorIssueFingerprint identifies the issue of a failed request
+func errorIssueFingerprint(method, endpoint, code string, frames []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(append([]string{method, endpoint, code}, frames...), "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+func errorIssueTitle(entry models.AuditLogEntry) string {
+	title := strings.TrimSpace(entry.ErrorMessage)
+	if title == "" {
+		title = strings.TrimSpace(entry.ErrorDescription)
+	}
+	if len(title) <= errorIssueMaxTitleLen {
+		return title
+	}
+	title = title[:errorIssueMaxTitleLen]
+	for !utf8.ValidString(title) {
+		title = title[:len(title)-1]
+	}
+	return title
+}
+
+// groupErrorIssues groups the failed requests among entries by fingerprint,
+// in order of first appearance. Each group describes its issue by its latest
+// occurrence.
+func groupErrorIssues(entries []models.AuditLogEntry) []repository.ErrorIssueGroup {
+	index := make(map[string]int)
+	groups := make([]repository.ErrorIssueGroup, 0)
+	for _, entry := range entries {
+		if entry.StatusCode < http.StatusBadRequest {
+			continue
+		}
+
+		method := strings.ToUpper(entry.HTTPMethod)
+		endpoint := normalizeErrorEndpoint(entry.Endpoint)
+		code := errorIssueCode(entry)
+		frames := topStackFrames(entry.ErrorStack, errorIssueStackFrames)
+		fingerprint := errorIssueFingerprint(method, endpoint, code, frames)
+
+		i, ok := index[fingerprint]
+		if !ok {
+			i = len(groups)
+			index[fingerprint] = i
+			groups = append(groups, repository.ErrorIssueGroup{
+				Fingerprint: fingerprint,
+				HTTPMethod:  method,
+				Endpoint:    endpoint,
+				ErrorCode:   code,
+				StackFrames: frames,
+				FirstSeenAt: entry.CreatedAt,
+				LastSeenAt:  entry.CreatedAt,
+			})
+		}
+		group := &groups[i]
+
+		if entry.CreatedAt.Before(group.FirstSeenAt) {
+			group.FirstSeenAt = entry.CreatedAt
+		}
+		if !entry.CreatedAt.Before(group.LastSeenAt) {
+			group.LastSeenAt = entry.CreatedAt
+			group.ErrorType = entry.ErrorType
+			group.StatusCode = entry.StatusCode
+			group.Title = errorIssueTitle(entry)
+			group.LastTraceID = entry.RequestID
+			if entry.ErrorStack != "" {
+				group.SampleStack = entry.ErrorStack
+			}
+		}
+		group.Occurrences = append(group.Occurrences, models.ErrorIssueOccurrence{
+			AuditLogID:   entry.ID,
+			TraceID:      entry.RequestID,
+			UserID:       entry.UserID,
+			Username:     entry.Username,
+			StatusCode:   entry.StatusCode,
+			ErrorMessage: errorIssueTitle(entry),
+			OccurredAt:   entry.CreatedAt,
+		})
+	}
+	return groups
+}
+
+// affectedUserIDs returns the distinct users among a group's occurrences;
+// anonymous requests are not counted
+func affectedUserIDs(occurrences []models.ErrorIssueOccurrence) []int {
+	seen := make(map[int]bool)
+	userIDs := make([]int, 0)
+	for _, occurrence := range occurrences {
+		if occurrence.UserID == nil || seen[*occurrence.UserID] {
+			continue
+		}
+		seen[*occurrence.UserID] = true
+		userIDs = append(userIDs, *occurrence.UserID)
+	}
+	return userIDs
+}
+
+// Track adds the failed requests among stored audit entries to their issues.
+// Resolved issues that fail again are reopened and recorded as regressions.
+func (s *ErrorIssueService) Track(entries []models.AuditLogEntry) error {
+	groups := groupErrorIssues(entries)
+	if len(groups) == 0 {
+		return nil
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	regressed := make([]repository.ErrorIssueGroup, 0)
+	regressedIDs := make([]int, 0)
+	for _, group := range groups {
+		id, reopened, err := s.repo.UpsertGroupTx(tx, group)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.InsertOccurrencesTx(tx, id, group.Occurrences, errorIssueKeptOccurrences); err != nil {
+			return err
+		}
+		if err := s.repo.AddAffectedUsersTx(tx, id, affectedUserIDs(group.Occurrences), group.FirstSeenAt); err != nil {
+			return err
+		}
+		if reopened {
+			regressed = append(regressed, group)
+			regressedIDs = append(regressedIDs, id)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for i, group := range regressed {
+		log.Printf("⚠️  Error issue #%d regressed: %s %s %s", regressedIDs[i], group.HTTPMethod, group.Endpoint, group.ErrorCode)
+		auditevent.Record(context.Background(), auditevent.Event{
+			Action:       auditevent.ErrorIssueRegression,
+			ResourceType: "error_issue",
+			ResourceID:   strconv.Itoa(regressedIDs[i]),
+			Before:       errorIssueState{Status: models.ErrorIssueStatusResolved},
+			After:        errorIssueState{Status: models.ErrorIssueStatusOpen, TraceID: group.LastTraceID},
+		})
+	}
+	return nil
+}
+
+// errorIssueState is the part of an issue that status changes touch, as
+// recorded in the audit log
+type errorIssueState struct {
+	Status  string `json:"status"`
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+// applyErrorIssueStatus moves issue to status. Resolving stamps who resolved
+// it and when; a later failure after that time counts as a regression.
+// Ignoring or reopening clears the resolution.
+func applyErrorIssueStatus(issue *models.ErrorIssue, status string, actorID int, now time.Time) error {
+	if err := models.ErrorIssueStatusMachine.Validate(issue.Status, status); err != nil {
+		return err
+	}
+	if status == models.ErrorIssueStatusResolved {
+		if issue.Status != status {
+			issue.ResolvedAt = &now
+			issue.ResolvedBy = &actorID
+		}
+	} else {
+		issue.ResolvedAt = nil
+		issue.ResolvedBy = nil
+	}
+	issue.Status = status
+	return nil
+}
+
+// List pages through error issues
+func (s *ErrorIssueService) List(req models.ErrorIssueListRequest) (*models.ErrorIssueListResponse, error) {
+	page := req.Page
+	pageSize := req.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+	req.Keyword = strings.TrimSpace(req.Keyword)
+
+	issues, total, err := s.repo.List(req, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ErrorIssueListResponse{
+		Data:       issues,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}, nil
+}
+
+// Get returns an issue with its latest stack and recent occurrences
+func (s *ErrorIssueService) Get(id int) (*models.ErrorIssueDetail, error) {
+	detail, err := s.repo.Get(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: #%d", ErrErrorIssueNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	detail.RecentOccurrences, err = s.repo.ListOccurrences(id, errorIssueShownOccurrences)
+	if err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// UpdateStatus resolves, ignores or reopens an issue
+func (s *ErrorIssueService) UpdateStatus(ctx context.Context, actorID, id int, req models.UpdateErrorIssueStatusRequest) (*models.ErrorIssue, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	issue, err := s.repo.GetForUpdateTx(tx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: #%d", ErrErrorIssueNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	before := errorIssueState{Status: issue.Status}
+	if err := applyErrorIssueStatus(issue, req.Status, actorID, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateStatusTx(tx, issue); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	auditevent.Record(ctx, auditevent.Event{
+		Action:       auditevent.ErrorIssueStatusChange,
+		ResourceType: "error_issue",
+		ResourceID:   strconv.Itoa(issue.ID),
+		ActorID:      &actorID,
+		Before:       before,
+		After:        errorIssueState{Status: issue.Status},
+	})
+	return issue, nil
+}
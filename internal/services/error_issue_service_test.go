@@ -0,0 +1,131 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/pkg/statemachine"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const recoveredPanicStack = `goroutine 42 [running]:
+runtime/debug.Stack()
+	/usr/local/go/src/runtime/debug/stack.go:26 +0x5e
+comment-review-platform/internal/observability.ErrorWithStack({0xc0001, 0x24}, {0x1d2e0a0, 0xc0002})
+	/app/internal/observability/logger.go:19 +0x25
+comment-review-platform/internal/middleware.RecoveryMiddleware.func1.1()
+	/app/internal/middleware/recovery.go:20 +0x9c
+panic({0x1a2b3c0?, 0x2f1e5d0?})
+	/usr/local/go/src/runtime/panic.go:785 +0x132
+comment-review-platform/internal/services.(*TaskService).SubmitReview(0xc0003, 0x7, {0xc0004, 0x10})
+	/app/internal/services/task_service.go:212 +0x1f4
+comment-review-platform/internal/handlers.(*TaskHandler).SubmitReview(0xc0005, 0xc0006)
+	/app/internal/handlers/task.go:88 +0x12a
+github.com/gin-gonic/gin.(*Context).Next(...)
+	/go/pkg/mod/github.com/gin-gonic/gin@v1.10.0/context.go:185
+comment-review-platform/internal/middleware.AuthMiddleware.func1(0xc0006)
+	/app/internal/middleware/auth.go:60 +0x2b1
+created by net/http.(*Server).Serve in goroutine 1
+	/usr/local/go/src/net/http/server.go:3285 +0x4b4
+`
+
+func TestNormalizeErrorEndpoint(t *testing.T) {
+	cases := map[string]string{
+		"/api/tasks/:id":                                    "/api/tasks/:id",
+		"/api/tasks/123/submit?force=1":                     "/api/tasks/:id/submit",
+		"/api/exports/0b6f9a52-3c1e-4f4a-9f1b-2a7d5e8c9d01": "/api/exports/:id",
+		"/api/files/deadbeefcafebabe0123":                   "/api/files/:id",
+		"/api/v1/docs/abc":                                  "/api/v1/docs/abc",
+		"":                                                  "/",
+	}
+	for endpoint, want := range cases {
+		if got := normalizeErrorEndpoint(endpoint); got != want {
+			t.Errorf("normalizeErrorEndpoint(%q) = %q, want %q", endpoint, got, want)
+		}
+	}
+}
+
+func TestTopStackFrames(t *testing.T) {
+	got := topStackFrames(recoveredPanicStack, 3)
+	want := []string{
+		"comment-review-platform/internal/services.(*TaskService).SubmitReview",
+		"comment-review-platform/internal/handlers.(*TaskHandler).SubmitReview",
+		"github.com/gin-gonic/gin.(*Context).Next",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("topStackFrames = %v, want %v", got, want)
+	}
+
+	moved := strings.ReplaceAll(recoveredPanicStack, "task_service.go:212", "task_service.go:240")
+	if strings.Join(topStackFrames(moved, 3), "|") != strings.Join(got, "|") {
+		t.Fatal("frames should not depend on line numbers")
+	}
+	if frames := topStackFrames("", 3); len(frames) != 0 {
+		t.Fatalf("frames of an empty stack = %v", frames)
+	}
+}
+
+func TestGroupErrorIssues(t *testing.T) {
+	base := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	user := func(id int) *int { return &id }
+	groups := groupErrorIssues([]models.AuditLogEntry{
+		{ID: "a", RequestID: "t1", HTTPMethod: "get", Endpoint: "/api/tasks/12", StatusCode: 404, ErrorCode: "NOT_FOUND", UserID: user(1), CreatedAt: base},
+		{ID: "b", RequestID: "t2", HTTPMethod: "GET", Endpoint: "/api/tasks/99", StatusCode: 404, ErrorCode: "NOT_FOUND", UserID: user(2), CreatedAt: base.Add(time.Minute), ErrorMessage: "task 99 not found"},
+		{ID: "c", RequestID: "t3", HTTPMethod: "POST", Endpoint: "/api/tasks/:id/submit", StatusCode: 500, ErrorStack: recoveredPanicStack, CreatedAt: base},
+		{ID: "d", RequestID: "t4", HTTPMethod: "GET", Endpoint: "/api/tasks/12", StatusCode: 200, CreatedAt: base},
+		{ID: "e", RequestID: "t5", HTTPMethod: "GET", Endpoint: "/api/tasks/5", StatusCode: 404, ErrorCode: "NOT_FOUND", UserID: user(1), CreatedAt: base.Add(-time.Minute)},
+	})
+
+	if len(groups) != 2 {
+		t.Fatalf("groups = %+v, want 2", groups)
+	}
+	notFound := groups[0]
+	if notFound.Endpoint != "/api/tasks/:id" || notFound.HTTPMethod != "GET" || len(notFound.Occurrences) != 3 {
+		t.Fatalf("not found group = %+v", notFound)
+	}
+	if !notFound.FirstSeenAt.Equal(base.Add(-time.Minute)) || !notFound.LastSeenAt.Equal(base.Add(time.Minute)) {
+		t.Fatalf("seen range = %v .. %v", notFound.FirstSeenAt, notFound.LastSeenAt)
+	}
+	if notFound.LastTraceID != "t2" || notFound.Title != "task 99 not found" {
+		t.Fatalf("group should describe its latest occurrence: %+v", notFound)
+	}
+	if users := affectedUserIDs(notFound.Occurrences); len(users) != 2 {
+		t.Fatalf("affected users = %v, want 2 distinct", users)
+	}
+
+	panicked := groups[1]
+	if panicked.ErrorCode != "HTTP_500" || len(panicked.StackFrames) != 3 || panicked.SampleStack == "" {
+		t.Fatalf("panic group = %+v", panicked)
+	}
+	if users := affectedUserIDs(panicked.Occurrences); len(users) != 0 {
+		t.Fatal("anonymous requests should not count as affected users")
+	}
+}
+
+func TestApplyErrorIssueStatus(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	issue := &models.ErrorIssue{ID: 1, Status: models.ErrorIssueStatusOpen}
+
+	if err := applyErrorIssueStatus(issue, models.ErrorIssueStatusResolved, 7, now); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if issue.ResolvedAt == nil || !issue.ResolvedAt.Equal(now) || issue.ResolvedBy == nil || *issue.ResolvedBy != 7 {
+		t.Fatalf("resolved issue = %+v", issue)
+	}
+
+	if err := applyErrorIssueStatus(issue, models.ErrorIssueStatusIgnored, 7, now); err != nil {
+		t.Fatalf("ignore: %v", err)
+	}
+	if issue.ResolvedAt != nil || issue.ResolvedBy != nil {
+		t.Fatalf("ignored issue kept its resolution: %+v", issue)
+	}
+
+	err := applyErrorIssueStatus(issue, models.ErrorIssueStatusResolved, 7, now)
+	if !errors.Is(err, statemachine.ErrInvalidTransition) {
+		t.Fatalf("ignored -> resolved error = %v, want ErrInvalidTransition", err)
+	}
+	if err := applyErrorIssueStatus(issue, models.ErrorIssueStatusOpen, 7, now); err != nil || issue.Status != models.ErrorIssueStatusOpen {
+		t.Fatalf("reopen: %v, status %q", err, issue.Status)
+	}
+}
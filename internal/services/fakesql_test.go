@@ -0,0 +1,180 @@
+package services
+
+import (
+	"comment-review-platform/pkg/database"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSQL is a database/sql driver for service tests that need a
+// transaction: it answers statements from canned responses and records every
+// statement, commit and rollback.
+type fakeSQL struct {
+	mu         sync.Mutex
+	responses  []fakeSQLResponse
+	statements []fakeSQLStatement
+}
+
+// fakeSQLResponse answers every statement containing match. Queries return
+// rows, execs report affected rows; err fails the statement instead.
+type fakeSQLResponse struct {
+	match    string
+	columns  []string
+	rows     [][]driver.Value
+	affected int64
+	err      error
+}
+
+type fakeSQLStatement struct {
+	query string
+	args  []driver.Value
+}
+
+var (
+	fakeSQLMu        sync.Mutex
+	fakeSQLInstances = map[string]*fakeSQL{}
+)
+
+func init() {
+	sql.Register("services-fake", fakeSQLDriver{})
+}
+
+// openFakeSQL points database.DB at a fake answering with responses until the
+// test ends. Repositories read database.DB when constructed, so build them after.
+func openFakeSQL(t *testing.T, responses ...fakeSQLResponse) *fakeSQL {
+	t.Helper()
+	fake := &fakeSQL{responses: responses}
+	fakeSQLMu.Lock()
+	fakeSQLInstances[t.Name()] = fake
+	fakeSQLMu.Unlock()
+
+	db, err := sql.Open("services-fake", t.Name())
+	if err != nil {
+		t.Fatalf("open fake database: %v", err)
+	}
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = previous
+		db.Close()
+		fakeSQLMu.Lock()
+		delete(fakeSQLInstances, t.Name())
+		fakeSQLMu.Unlock()
+	})
+	return fake
+}
+
+// executed returns the statements containing match, in order
+func (f *fakeSQL) executed(match string) []fakeSQLStatement {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var found []fakeSQLStatement
+	for _, stmt := range f.statements {
+		if strings.Contains(stmt.query, match) {
+			found = append(found, stmt)
+		}
+	}
+	return found
+}
+
+func (f *fakeSQL) committed() bool  { return len(f.executed("COMMIT")) > 0 }
+func (f *fakeSQL) rolledBack() bool { return len(f.executed("ROLLBACK")) > 0 }
+
+func (f *fakeSQL) record(query string, args []driver.NamedValue) *fakeSQLResponse {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	f.statements = append(f.statements, fakeSQLStatement{query: query, args: values})
+	for i := range f.responses {
+		if strings.Contains(query, f.responses[i].match) {
+			return &f.responses[i]
+		}
+	}
+	return nil
+}
+
+type fakeSQLDriver struct{}
+
+func (fakeSQLDriver) Open(name string) (driver.Conn, error) {
+	fakeSQLMu.Lock()
+	defer fakeSQLMu.Unlock()
+	return &fakeSQLConn{fake: fakeSQLInstances[name]}, nil
+}
+
+type fakeSQLConn struct {
+	fake *fakeSQL
+}
+
+func (c *fakeSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c *fakeSQLConn) Close() error { return nil }
+
+func (c *fakeSQLConn) Begin() (driver.Tx, error) {
+	c.fake.record("BEGIN", nil)
+	return fakeSQLTx{fake: c.fake}, nil
+}
+
+func (c *fakeSQLConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	response := c.fake.record(query, args)
+	if response == nil {
+		return driver.RowsAffected(1), nil
+	}
+	if response.err != nil {
+		return nil, response.err
+	}
+	return driver.RowsAffected(response.affected), nil
+}
+
+func (c *fakeSQLConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	response := c.fake.record(query, args)
+	if response == nil {
+		return &fakeSQLRows{}, nil
+	}
+	if response.err != nil {
+		return nil, response.err
+	}
+	return &fakeSQLRows{columns: response.columns, rows: response.rows}, nil
+}
+
+type fakeSQLTx struct {
+	fake *fakeSQL
+}
+
+func (tx fakeSQLTx) Commit() error {
+	tx.fake.record("COMMIT", nil)
+	return nil
+}
+
+func (tx fakeSQLTx) Rollback() error {
+	tx.fake.record("ROLLBACK", nil)
+	return nil
+}
+
+type fakeSQLRows struct {
+	columns []string
+	rows    [][]driver.Value
+	next    int
+}
+
+func (r *fakeSQLRows) Columns() []string { return r.columns }
+
+func (r *fakeSQLRows) Close() error { return nil }
+
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"comment-review-platform/internal/repository"
+	"comment-review-platform/pkg/prom"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// metricsCollectTimeout bounds each business gauge query run during a scrape
+const metricsCollectTimeout = 3 * time.Second
+
+// unmatchedRoute labels requests that matched no route, so that scanners
+// probing random paths cannot create unbounded series
+const unmatchedRoute = "unmatched"
+
+// ObserveHTTP records a served request in the Prometheus HTTP metrics. route
+// is the matched route pattern, empty when no route matched.
+func (s *MetricsService) ObserveHTTP(method, route string, status int, latency time.Duration) {
+	if s == nil || s.registry == nil {
+		return
+	}
+	if route == "" {
+		route = unmatchedRoute
+	}
+	method = strings.ToUpper(strings.TrimSpace(method))
+	s.httpRequests.Inc(method, route, strconv.Itoa(status))
+	s.httpDurations.Observe(latency.Seconds(), method, route)
+}
+
+// WritePrometheus renders all registered metrics in the Prometheus text format
+func (s *MetricsService) WritePrometheus(w io.Writer) error {
+	if s == nil || s.registry == nil {
+		return nil
+	}
+	return s.registry.Write(w)
+}
+
+func (s *MetricsService) registerHTTPMetrics() {
+	s.httpRequests = s.registry.NewCounterVec("http_requests_total",
+		"HTTP requests served, by method, route and status code.", "method", "route", "status")
+	s.httpDurations = s.registry.NewHistogramVec("http_request_duration_seconds",
+		"HTTP request latency in seconds, by method and route.", prom.DefaultBuckets, "method", "route")
+}
+
+// memStatsSnapshot shares one runtime.ReadMemStats call between the families
+// of a scrape; reading stops the world, so it is not repeated per family
+type memStatsSnapshot struct {
+	mu     sync.Mutex
+	readAt time.Time
+	stats  runtime.MemStats
+}
+
+func (m *memStatsSnapshot) read() runtime.MemStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if time.Since(m.readAt) > time.Second {
+		runtime.ReadMemStats(&m.stats)
+		m.readAt = time.Now()
+	}
+	return m.stats
+}
+
+func (s *MetricsService) registerRuntimeMetrics() {
+	s.memStats = &memStatsSnapshot{}
+	startTime := float64(time.Now().Unix())
+	threads := pprof.Lookup("threadcreate")
+
+	s.registry.RegisterFunc("go_info", "Go version the server was built with.", prom.GaugeType, func() []prom.Sample {
+		return []prom.Sample{{Labels: []prom.Label{{Name: "version", Value: runtime.Version()}}, Value: 1}}
+	})
+	s.registry.GaugeFunc("go_goroutines", "Number of goroutines.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+	s.registry.GaugeFunc("go_threads", "Number of OS threads created.", func() float64 {
+		return float64(threads.Count())
+	})
+	s.registry.GaugeFunc("go_memstats_heap_alloc_bytes", "Bytes of allocated heap objects.", func() float64 {
+		return float64(s.memStats.read().HeapAlloc)
+	})
+	s.registry.GaugeFunc("go_memstats_heap_inuse_bytes", "Bytes in in-use heap spans.", func() float64 {
+		return float64(s.memStats.read().HeapInuse)
+	})
+	s.registry.GaugeFunc("go_memstats_heap_objects", "Number of allocated heap objects.", func() float64 {
+		return float64(s.memStats.read().HeapObjects)
+	})
+	s.registry.GaugeFunc("go_memstats_stack_inuse_bytes", "Bytes in stack spans.", func() float64 {
+		return float64(s.memStats.read().StackInuse)
+	})
+	s.registry.GaugeFunc("go_memstats_sys_bytes", "Bytes of memory obtained from the OS.", func() float64 {
+		return float64(s.memStats.read().Sys)
+	})
+	s.registry.CounterFunc("go_gc_cycles_total", "Completed GC cycles.", func() float64 {
+		return float64(s.memStats.read().NumGC)
+	})
+	s.registry.CounterFunc("go_gc_pause_seconds_total", "Total time spent in GC stop-the-world pauses.", func() float64 {
+		return float64(s.memStats.read().PauseTotalNs) / float64(time.Second)
+	})
+	s.registry.GaugeFunc("process_start_time_seconds", "Start time of the process since the Unix epoch.", func() float64 {
+		return startTime
+	})
+}
+
+func (s *MetricsService) registerRedisPoolMetrics(rdb *redis.Client) {
+	s.registry.CounterFunc("redis_pool_hits_total", "Times a free connection was found in the Redis pool.", func() float64 {
+		return float64(rdb.PoolStats().Hits)
+	})
+	s.registry.CounterFunc("redis_pool_misses_total", "Times a free connection was not found in the Redis pool.", func() float64 {
+		return float64(rdb.PoolStats().Misses)
+	})
+	s.registry.CounterFunc("redis_pool_timeouts_total", "Times a wait for a Redis connection timed out.", func() float64 {
+		return float64(rdb.PoolStats().Timeouts)
+	})
+	s.registry.CounterFunc("redis_pool_stale_connections_total", "Stale Redis connections removed from the pool.", func() float64 {
+		return float64(rdb.PoolStats().StaleConns)
+	})
+	s.registry.GaugeFunc("redis_pool_connections", "Connections in the Redis pool.", func() float64 {
+		return float64(rdb.PoolStats().TotalConns)
+	})
+	s.registry.GaugeFunc("redis_pool_idle_connections", "Idle connections in the Redis pool.", func() float64 {
+		return float64(rdb.PoolStats().IdleConns)
+	})
+}
+
+// RegisterDBMetrics exposes the database pool stats and the business gauges
+// read from the database: open tasks per review queue and video pool, and
+// the progress of active AI review jobs
+func (s *MetricsService) RegisterDBMetrics(db *sql.DB) {
+	if s == nil || s.registry == nil || db == nil {
+		return
+	}
+
+	s.registry.GaugeFunc("db_pool_max_open_connections", "Maximum number of open database connections.", func() float64 {
+		return float64(db.Stats().MaxOpenConnections)
+	})
+	s.registry.GaugeFunc("db_pool_open_connections", "Open database connections, in use and idle.", func() float64 {
+		return float64(db.Stats().OpenConnections)
+	})
+	s.registry.GaugeFunc("db_pool_in_use_connections", "Database connections currently in use.", func() float64 {
+		return float64(db.Stats().InUse)
+	})
+	s.registry.GaugeFunc("db_pool_idle_connections", "Idle database connections.", func() float64 {
+		return float64(db.Stats().Idle)
+	})
+	s.registry.CounterFunc("db_pool_wait_count_total", "Times a query waited for a database connection.", func() float64 {
+		return float64(db.Stats().WaitCount)
+	})
+	s.registry.CounterFunc("db_pool_wait_duration_seconds_total", "Total time spent waiting for database connections.", func() float64 {
+		return db.Stats().WaitDuration.Seconds()
+	})
+	s.registry.CounterFunc("db_pool_max_idle_closed_total", "Database connections closed due to the idle connection limit.", func() float64 {
+		return float64(db.Stats().MaxIdleClosed)
+	})
+	s.registry.CounterFunc("db_pool_max_idle_time_closed_total", "Database connections closed due to the idle time limit.", func() float64 {
+		return float64(db.Stats().MaxIdleTimeClosed)
+	})
+	s.registry.CounterFunc("db_pool_max_lifetime_closed_total", "Database connections closed due to the connection lifetime limit.", func() float64 {
+		return float64(db.Stats().MaxLifetimeClosed)
+	})
+
+	gauges := repository.NewMetricsGaugeRepository(db)
+	collectErrors := s.registry.NewCounterVec("metrics_collect_errors_total",
+		"Business gauge queries that failed during a scrape, by metric.", "metric")
+	// collect runs one gauge query; a failed query is logged and leaves its
+	// family out of the scrape rather than reporting stale or zero values
+	collect := func(metric string, query func(ctx context.Context) ([]prom.Sample, error)) func() []prom.Sample {
+		return func() []prom.Sample {
+			ctx, cancel := context.WithTimeout(context.Background(), metricsCollectTimeout)
+			defer cancel()
+			samples, err := query(ctx)
+			if err != nil {
+				collectErrors.Inc(metric)
+				log.Printf("⚠️  Error collecting %s: %v", metric, err)
+				return nil
+			}
+			return samples
+		}
+	}
+
+	s.registry.RegisterFunc("review_queue_tasks", "Pending and in-progress tasks per review queue.", prom.GaugeType,
+		collect("review_queue_tasks", func(ctx context.Context) ([]prom.Sample, error) {
+			counts, err := gauges.QueueTaskCounts(ctx)
+			if err != nil {
+				return nil, err
+			}
+			samples := make([]prom.Sample, 0, len(counts))
+			for _, count := range counts {
+				samples = append(samples, prom.Sample{
+					Labels: []prom.Label{{Name: "queue", Value: count.Queue}, {Name: "status", Value: count.Status}},
+					Value:  float64(count.Count),
+				})
+			}
+			return samples, nil
+		}))
+
+	s.registry.RegisterFunc("video_pool_tasks", "Pending and in-progress video queue tasks per traffic pool.", prom.GaugeType,
+		collect("video_pool_tasks", func(ctx context.Context) ([]prom.Sample, error) {
+			counts, err := gauges.PoolTaskCounts(ctx)
+			if err != nil {
+				return nil, err
+			}
+			samples := make([]prom.Sample, 0, len(counts))
+			for _, count := range counts {
+				samples = append(samples, prom.Sample{
+					Labels: []prom.Label{{Name: "pool", Value: count.Queue}, {Name: "status", Value: count.Status}},
+					Value:  float64(count.Count),
+				})
+			}
+			return samples, nil
+		}))
+
+	s.registry.RegisterFunc("ai_review_job_tasks", "Tasks of scheduled and running AI review jobs, by outcome (total, completed, failed).", prom.GaugeType,
+		collect("ai_review_job_tasks", func(ctx context.Context) ([]prom.Sample, error) {
+			jobs, err := gauges.ActiveAIReviewJobs(ctx)
+			if err != nil {
+				return nil, err
+			}
+			samples := make([]prom.Sample, 0, len(jobs)*3)
+			for _, job := range jobs {
+				labels := func(tasks string) []prom.Label {
+					return []prom.Label{
+						{Name: "job_id", Value: strconv.Itoa(job.JobID)},
+						{Name: "job_status", Value: job.Status},
+						{Name: "tasks", Value: tasks},
+					}
+				}
+				samples = append(samples,
+					prom.Sample{Labels: labels("total"), Value: float64(job.TotalTasks)},
+					prom.Sample{Labels: labels("completed"), Value: float64(job.CompletedTasks)},
+					prom.Sample{Labels: labels("failed"), Value: float64(job.FailedTasks)},
+				)
+			}
+			return samples, nil
+		}))
+}
+
+// RegisterSSEMetrics exposes the number of open notification streams
+func (s *MetricsService) RegisterSSEMetrics(manager *SSEManager) {
+	if s == nil || s.registry == nil || manager == nil {
+		return
+	}
+	s.registry.GaugeFunc("sse_connections", "Open server-sent event notification streams.", func() float64 {
+		return float64(manager.GetClientCount())
+	})
+}
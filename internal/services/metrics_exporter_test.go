@@ -0,0 +1,32 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePrometheusWithoutRedis(t *testing.T) {
+	s := NewMetricsService(nil, 5)
+	s.Record("GET", "/api/tasks/:id", 200, 30*time.Millisecond)
+	s.ObserveHTTP("get", "/api/tasks/:id", 200, 30*time.Millisecond)
+	s.ObserveHTTP("GET", "", 404, time.Millisecond)
+
+	var out strings.Builder
+	if err := s.WritePrometheus(&out); err != nil {
+		t.Fatalf("WritePrometheus: %v", err)
+	}
+	for _, line := range []string{
+		`http_requests_total{method="GET",route="/api/tasks/:id",status="200"} 1`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`http_request_duration_seconds_bucket{method="GET",route="/api/tasks/:id",le="0.05"} 1`,
+		`# TYPE go_goroutines gauge`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("missing %q", line)
+		}
+	}
+	if strings.Contains(out.String(), "redis_pool") {
+		t.Error("Redis pool metrics should not be registered without a client")
+	}
+}
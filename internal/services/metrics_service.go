@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"comment-review-platform/internal/models"
+	"comment-review-platform/pkg/prom"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -19,16 +20,30 @@ const metricsEndpointsKey = "metrics:api:endpoints"
 type MetricsService struct {
 	rdb    *redis.Client
 	window time.Duration
+
+	// registry backs the Prometheus endpoint; the Redis counters above back
+	// the monitoring dashboard
+	registry      *prom.Registry
+	httpRequests  *prom.CounterVec
+	httpDurations *prom.HistogramVec
+	memStats      *memStatsSnapshot
 }
 
 func NewMetricsService(rdb *redis.Client, windowMinutes int) *MetricsService {
 	if windowMinutes <= 0 {
 		windowMinutes = 5
 	}
-	return &MetricsService{
-		rdb:    rdb,
-		window: time.Duration(windowMinutes) * time.Minute,
+	s := &MetricsService{
+		rdb:      rdb,
+		window:   time.Duration(windowMinutes) * time.Minute,
+		registry: prom.NewRegistry(),
+	}
+	s.registerHTTPMetrics()
+	s.registerRuntimeMetrics()
+	if rdb != nil {
+		s.registerRedisPoolMetrics(rdb)
 	}
+	return s
 }
 
 func (s *MetricsService) Record(method, path string, status int, latency time.Duration) {
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"fmt"
+)
+
+// ModerationStatusService describes the status graphs that every comment and
+// video status change is validated against
+type ModerationStatusService struct {
+	pools *VideoPoolService
+}
+
+func NewModerationStatusService() *ModerationStatusService {
+	return &ModerationStatusService{pools: NewVideoPoolService()}
+}
+
+// StatusGraphs returns the allowed status graph of each content type, with
+// the video pool placeholder resolved to the configured terminal statuses
+func (s *ModerationStatusService) StatusGraphs() ([]models.ModerationStatusGraph, error) {
+	pools, err := s.pools.ListPools(true)
+	if err != nil {
+		return nil, fmt.Errorf("load video pools: %w", err)
+	}
+	terminal := []string{}
+	for i := range pools {
+		terminal = append(terminal, videoPoolTerminalStatus(&pools[i]))
+	}
+
+	return []models.ModerationStatusGraph{
+		{Graph: models.CommentStatusMachine.Graph()},
+		{
+			Graph:   models.VideoStatusMachine.Graph(),
+			Aliases: map[string][]string{models.VideoStatusPoolConfirmed: terminal},
+		},
+	}, nil
+}
+
+// reviewStatusChange describes a status change made by a reviewer's submit
+func reviewStatusChange(source string, taskID, reviewerID int, reason *string) models.StatusChange {
+	change := models.StatusChange{Source: source, TaskID: &taskID, ActorID: &reviewerID}
+	if reason != nil {
+		change.Reason = *reason
+	}
+	return change
+}
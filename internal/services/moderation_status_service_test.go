@@ -0,0 +1,56 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/pkg/statemachine"
+	"errors"
+	"testing"
+)
+
+func TestModerationStatusGraphs(t *testing.T) {
+	tests := []struct {
+		machine  *statemachine.Machine
+		from, to string
+		ok       bool
+	}{
+		{models.CommentStatusMachine, models.CommentStatusPending, models.CommentStatusApproved, true},
+		{models.CommentStatusMachine, models.CommentStatusPending, models.CommentStatusPendingSecondReview, true},
+		{models.CommentStatusMachine, models.CommentStatusPendingSecondReview, models.CommentStatusRejected, true},
+		{models.CommentStatusMachine, models.CommentStatusApproved, models.CommentStatusPending, false},
+		{models.CommentStatusMachine, models.CommentStatusRejected, models.CommentStatusApproved, false},
+		{models.CommentStatusMachine, models.CommentStatusApproved, models.CommentStatusPendingSecondReview, false},
+		{models.VideoStatusMachine, models.VideoStatusPending, models.VideoStatusFirstReviewCompleted, true},
+		{models.VideoStatusMachine, models.VideoStatusFirstReviewCompleted, models.VideoStatusPoolConfirmed, true},
+		{models.VideoStatusMachine, models.VideoStatusPoolConfirmed, models.VideoStatusRemovedViolation, true},
+		{models.VideoStatusMachine, models.VideoStatusFirstReviewCompleted, models.VideoStatusPending, false},
+		{models.VideoStatusMachine, models.VideoStatusRemovedViolation, models.VideoStatusNaturalPool, false},
+		{models.VideoStatusMachine, models.VideoStatusNaturalPool, models.VideoStatusPoolConfirmed, false},
+	}
+	for _, tt := range tests {
+		err := tt.machine.Validate(tt.from, tt.to)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: Validate(%q, %q) = %v, want ok=%v", tt.machine.Name(), tt.from, tt.to, err, tt.ok)
+		}
+		if err != nil && !errors.Is(err, statemachine.ErrInvalidTransition) {
+			t.Errorf("%s: error %v does not match ErrInvalidTransition", tt.machine.Name(), err)
+		}
+	}
+}
+
+func TestModerationStatusFinalStates(t *testing.T) {
+	if final := models.VideoStatusMachine.Final(); len(final) != 1 || final[0] != models.VideoStatusRemovedViolation {
+		t.Errorf("video final states = %v, want only %s", final, models.VideoStatusRemovedViolation)
+	}
+	if final := models.CommentStatusMachine.Final(); len(final) != 2 {
+		t.Errorf("comment final states = %v, want approved and rejected", final)
+	}
+}
+
+func TestVideoDecidedStatusesInheritableFromPending(t *testing.T) {
+	// A duplicate inherits its original's decided status straight from pending
+	for _, status := range models.VideoDecidedStatuses {
+		if err := models.VideoStatusMachine.Validate(models.VideoStatusPending, status); err != nil {
+			t.Errorf("decided status %q is not reachable from pending: %v", status, err)
+		}
+	}
+}
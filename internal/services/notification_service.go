@@ -60,6 +60,39 @@ func (s *NotificationService) CreateNotification(req models.CreateNotificationRe
 	return notification, nil
 }
 
+// NotifyUser creates a notification only recipientID sees and pushes it to
+// their open SSE connections
+func (s *NotificationService) NotifyUser(recipientID int, title, content, notificationType string, createdBy int) (*models.Notification, error) {
+	notification := &models.Notification{
+		Title:       title,
+		Content:     content,
+		Type:        notificationType,
+		CreatedBy:   createdBy,
+		IsGlobal:    false,
+		RecipientID: &recipientID,
+	}
+
+	if err := s.notificationRepo.Create(notification); err != nil {
+		return nil, err
+	}
+
+	s.sseManager.SendToUser(recipientID, models.SSEMessage{
+		Type: "notification",
+		Data: models.NotificationResponse{
+			ID:        notification.ID,
+			Title:     notification.Title,
+			Content:   notification.Content,
+			Type:      notification.Type,
+			CreatedBy: notification.CreatedBy,
+			CreatedAt: notification.CreatedAt,
+			IsGlobal:  false,
+			IsRead:    false,
+		},
+	})
+
+	return notification, nil
+}
+
 // GetUnreadNotifications retrieves unread notifications for a user
 func (s *NotificationService) GetUnreadNotifications(userID int, limit int) ([]models.NotificationResponse, error) {
 	if limit <= 0 {
@@ -82,8 +115,8 @@ func (s *NotificationService) MarkAsRead(userID, notificationID int) error {
 		return err
 	}
 
-	if !notification.IsGlobal {
-		return errors.New("cannot mark non-global notification as read")
+	if !notification.IsGlobal && (notification.RecipientID == nil || *notification.RecipientID != userID) {
+		return errors.New("cannot mark another user's notification as read")
 	}
 
 	return s.notificationRepo.MarkAsRead(userID, notificationID)
@@ -101,9 +134,9 @@ func (s *NotificationService) GetRecentNotifications(userID int, limit, offset i
 	return s.notificationRepo.GetRecent(userID, limit, offset)
 }
 
-// GetTotalNotificationCount returns total count of notifications for pagination
-func (s *NotificationService) GetTotalNotificationCount() (int, error) {
-	return s.notificationRepo.GetTotalCount()
+// GetTotalNotificationCount returns the count of notifications a user can see, for pagination
+func (s *NotificationService) GetTotalNotificationCount(userID int) (int, error) {
+	return s.notificationRepo.GetTotalCount(userID)
 }
 
 // GetNotificationByID retrieves a notification by ID
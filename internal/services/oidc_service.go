@@ -0,0 +1,311 @@
+package services
+
+import (
+	"comment-review-platform/internal/config"
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"comment-review-platform/pkg/oidc"
+	redispkg "comment-review-platform/pkg/redis"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrOIDCDisabled     = errors.New("single sign-on is not configured")
+	ErrOIDCInvalidState = errors.New("login request expired or is invalid, please try again")
+)
+
+const (
+	oidcStatePrefix = "oidc:state:"
+	oidcStateTTL    = 10 * time.Minute
+)
+
+// oidcLoginFlow is the per-login state kept server-side between redirect and callback.
+type oidcLoginFlow struct {
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+}
+
+// OIDCService implements SSO login through an OpenID Connect provider and
+// maps the provider's identities onto platform users.
+type OIDCService struct {
+	provider          *oidc.Provider
+	userRepo          *repository.UserRepository
+	identityRepo      *repository.IdentityRepository
+	permissionService *PermissionService
+	rdb               *redis.Client
+	providerName      string
+	groupsClaim       string
+	groupPermissions  map[string][]string
+}
+
+func NewOIDCService() *OIDCService {
+	cfg := config.AppConfig
+	service := &OIDCService{
+		userRepo:          repository.NewUserRepository(),
+		identityRepo:      repository.NewIdentityRepository(),
+		permissionService: NewPermissionService(),
+		rdb:               redispkg.Client,
+		providerName:      cfg.OIDCProviderName,
+		groupsClaim:       cfg.OIDCGroupsClaim,
+		groupPermissions:  parseGroupPermissions(cfg.OIDCGroupPermissions),
+	}
+	if cfg.OIDCIssuerURL != "" && cfg.OIDCClientID != "" && cfg.OIDCRedirectURL != "" {
+		service.provider = oidc.NewProvider(oidc.Config{
+			IssuerURL:    cfg.OIDCIssuerURL,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       strings.Fields(cfg.OIDCScopes),
+		})
+	}
+	return service
+}
+
+// Enabled reports whether an OIDC provider is configured
+func (s *OIDCService) Enabled() bool {
+	return s.provider != nil
+}
+
+// ProviderName is the display name of the identity provider
+func (s *OIDCService) ProviderName() string {
+	return s.providerName
+}
+
+// BeginLogin creates the state, nonce and PKCE verifier for a login and
+// returns the provider authorization URL
+func (s *OIDCService) BeginLogin(ctx context.Context) (string, error) {
+	if !s.Enabled() {
+		return "", ErrOIDCDisabled
+	}
+
+	state, err := oidc.RandomString(24)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.RandomString(24)
+	if err != nil {
+		return "", err
+	}
+	verifier, challenge, err := oidc.GeneratePKCE()
+	if err != nil {
+		return "", err
+	}
+
+	flow, err := json.Marshal(oidcLoginFlow{CodeVerifier: verifier, Nonce: nonce})
+	if err != nil {
+		return "", err
+	}
+	if err := s.rdb.Set(ctx, oidcStatePrefix+state, flow, oidcStateTTL).Err(); err != nil {
+		return "", err
+	}
+
+	return s.provider.AuthCodeURL(ctx, state, nonce, challenge)
+}
+
+// CompleteLogin redeems the authorization code and returns the platform user
+// for the identity, linking or provisioning one as needed
+func (s *OIDCService) CompleteLogin(ctx context.Context, code, state string) (*models.User, error) {
+	if !s.Enabled() {
+		return nil, ErrOIDCDisabled
+	}
+
+	raw, err := s.rdb.GetDel(ctx, oidcStatePrefix+state).Bytes()
+	if err == redis.Nil {
+		return nil, ErrOIDCInvalidState
+	}
+	if err != nil {
+		return nil, err
+	}
+	var flow oidcLoginFlow
+	if err := json.Unmarshal(raw, &flow); err != nil {
+		return nil, ErrOIDCInvalidState
+	}
+
+	token, err := s.provider.Exchange(ctx, code, flow.CodeVerifier, flow.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.resolveUser(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if keys := permissionsForGroups(token.StringsClaim(s.groupsClaim), s.groupPermissions); len(keys) > 0 {
+		if err := s.syncGroupPermissions(ctx, user.ID, keys); err != nil {
+			log.Printf("⚠️  Failed to sync SSO group permissions for user %d: %v", user.ID, err)
+		}
+	}
+	return user, nil
+}
+
+// resolveUser finds the user linked to the identity, links an existing account
+// with the same verified email, or provisions a new account
+func (s *OIDCService) resolveUser(ctx context.Context, token *oidc.IDToken) (*models.User, error) {
+	issuer := s.provider.Issuer()
+	email := strings.TrimSpace(token.Email)
+
+	userID, err := s.identityRepo.FindUserID(issuer, token.Subject)
+	if err == nil {
+		if err := s.identityRepo.TouchLogin(issuer, token.Subject, email); err != nil {
+			log.Printf("⚠️  Failed to record SSO login for user %d: %v", userID, err)
+		}
+		return s.userRepo.FindByID(ctx, userID)
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// Only link by email when both sides have verified it; otherwise an IdP
+	// account with an unverified address could take over a platform account.
+	if email != "" && token.EmailVerified {
+		if existing, _ := s.userRepo.FindByEmail(email); existing != nil && existing.EmailVerified {
+			if err := s.identityRepo.Link(existing.ID, issuer, token.Subject, email); err != nil {
+				return nil, err
+			}
+			log.Printf("🔗 Linked SSO identity %s to user %d by verified email", token.Subject, existing.ID)
+			return existing, nil
+		}
+	}
+
+	user, err := s.provisionUser(token)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.identityRepo.Link(user.ID, issuer, token.Subject, email); err != nil {
+		return nil, err
+	}
+	log.Printf("✅ Provisioned SSO user %s (id=%d, status=%s)", user.Username, user.ID, user.Status)
+	return user, nil
+}
+
+// provisionUser creates an account for a first-time SSO user. Users whose IdP
+// groups map to a permission set are approved; everyone else waits for review.
+func (s *OIDCService) provisionUser(token *oidc.IDToken) (*models.User, error) {
+	username, err := s.availableUsername(token)
+	if err != nil {
+		return nil, err
+	}
+
+	status := "pending"
+	if len(permissionsForGroups(token.StringsClaim(s.groupsClaim), s.groupPermissions)) > 0 {
+		status = "approved"
+	}
+
+	user := &models.User{
+		Username: username,
+		Role:     "reviewer",
+		Status:   status,
+	}
+	if email := strings.TrimSpace(token.Email); email != "" && token.EmailVerified {
+		if existing, _ := s.userRepo.FindByEmail(email); existing == nil {
+			user.Email = &email
+			user.EmailVerified = true
+		}
+	}
+
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *OIDCService) availableUsername(token *oidc.IDToken) (string, error) {
+	base := sanitizeUsername(token.PreferredUsername)
+	if base == "" {
+		base = sanitizeUsername(strings.SplitN(token.Email, "@", 2)[0])
+	}
+	if len([]rune(base)) < 3 {
+		base = "sso_" + sanitizeUsername(token.Subject)
+	}
+	if runes := []rune(base); len(runes) > 40 {
+		base = string(runes[:40])
+	}
+
+	candidate := base
+	for i := 2; i <= 100; i++ {
+		if existing, _ := s.userRepo.FindByUsername(candidate); existing == nil {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s_%d", base, i)
+	}
+	return "", errors.New("could not allocate a username for SSO user")
+}
+
+// syncGroupPermissions grants the group-mapped permissions the user does not
+// hold yet. Existing grants are left alone so admin-set expiry or scopes survive.
+func (s *OIDCService) syncGroupPermissions(ctx context.Context, userID int, keys []string) error {
+	grants, err := s.permissionService.GetUserPermissionGrants(userID)
+	if err != nil {
+		return err
+	}
+	held := make(map[string]struct{}, len(grants))
+	for _, grant := range grants {
+		held[grant.PermissionKey] = struct{}{}
+	}
+
+	missing := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := held[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return s.permissionService.GrantPermissions(ctx, userID, missing, SystemGrantActor, nil, nil)
+}
+
+// parseGroupPermissions parses "group=perm1|perm2;other=perm3".
+func parseGroupPermissions(value string) map[string][]string {
+	mapping := make(map[string][]string)
+	for _, entry := range strings.Split(value, ";") {
+		group, perms, ok := strings.Cut(entry, "=")
+		group = strings.TrimSpace(group)
+		if !ok || group == "" {
+			continue
+		}
+		for _, perm := range strings.Split(perms, "|") {
+			if perm = strings.TrimSpace(perm); perm != "" {
+				mapping[group] = append(mapping[group], perm)
+			}
+		}
+	}
+	return mapping
+}
+
+// permissionsForGroups returns the de-duplicated union of permission sets of the given groups.
+func permissionsForGroups(groups []string, mapping map[string][]string) []string {
+	seen := make(map[string]struct{})
+	var keys []string
+	for _, group := range groups {
+		for _, key := range mapping[group] {
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func sanitizeUsername(value string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(value) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
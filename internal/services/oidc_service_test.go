@@ -0,0 +1,39 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGroupPermissions(t *testing.T) {
+	mapping := parseGroupPermissions(" moderators = queue.video.claim | queue.video.submit ;admins=users:list;;broken")
+	want := map[string][]string{
+		"moderators": {"queue.video.claim", "queue.video.submit"},
+		"admins":     {"users:list"},
+	}
+	if !reflect.DeepEqual(mapping, want) {
+		t.Fatalf("parseGroupPermissions = %v, want %v", mapping, want)
+	}
+}
+
+func TestPermissionsForGroups(t *testing.T) {
+	mapping := map[string][]string{
+		"moderators": {"queue.video.claim", "stats:overview"},
+		"qa":         {"stats:overview", "quality-check:claim"},
+	}
+
+	got := permissionsForGroups([]string{"qa", "unknown", "moderators"}, mapping)
+	want := []string{"stats:overview", "quality-check:claim", "queue.video.claim"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("permissionsForGroups = %v, want %v", got, want)
+	}
+	if keys := permissionsForGroups([]string{"unknown"}, mapping); len(keys) != 0 {
+		t.Fatalf("expected no permissions for unmapped groups, got %v", keys)
+	}
+}
+
+func TestSanitizeUsername(t *testing.T) {
+	if got := sanitizeUsername(" alice.w@corp "); got != "alice.wcorp" {
+		t.Fatalf("sanitizeUsername = %q", got)
+	}
+}
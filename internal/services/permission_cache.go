@@ -0,0 +1,321 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	permissionCacheKeyPrefix    = "perm:user:"
+	permissionGenerationSuffix  = ":gen"
+	permissionInvalidateChannel = "perm:invalidate"
+	defaultPermissionCacheTTL   = 5 * time.Minute
+	maxPermissionLocalCacheTTL  = 30 * time.Second
+	permissionCacheRedisTimeout = 500 * time.Millisecond
+)
+
+// PermissionCache caches the effective permission grants of each user in process
+// and in Redis. Invalidations are broadcast over Redis pub/sub so every replica
+// drops its local copy as soon as a grant, revoke or user deletion happens.
+type PermissionCache struct {
+	rdb      *redis.Client
+	shared   sharedPermissionStore
+	ttl      time.Duration
+	localTTL time.Duration
+
+	mu          sync.RWMutex
+	local       map[int]permissionCacheEntry
+	generations map[int]uint64
+
+	localHits     atomic.Int64
+	redisHits     atomic.Int64
+	misses        atomic.Int64
+	invalidations atomic.Int64
+}
+
+// sharedPermissionStore is the cross-replica layer behind the local cache.
+// Entries are versioned by a per-user generation that every invalidation bumps,
+// so grants loaded before a revoke cannot be written back after it.
+type sharedPermissionStore interface {
+	Generation(userID int) (int64, error)
+	Get(userID int) ([]models.PermissionGrant, bool)
+	SetIfGeneration(userID int, generation int64, grants []models.PermissionGrant, ttl time.Duration) error
+	Invalidate(userID int) error
+}
+
+type permissionCacheEntry struct {
+	grants    map[string]models.PermissionGrant
+	expiresAt time.Time
+}
+
+var (
+	permissionCache     *PermissionCache
+	permissionCacheOnce sync.Once
+)
+
+// InitPermissionCache initializes the shared permission cache and starts the
+// cross-replica invalidation subscriber. A nil Redis client keeps the cache
+// process-local.
+func InitPermissionCache(rdb *redis.Client, ttl time.Duration) *PermissionCache {
+	permissionCacheOnce.Do(func() {
+		permissionCache = NewPermissionCache(rdb, ttl)
+		if rdb != nil {
+			go permissionCache.subscribeInvalidations(context.Background())
+		}
+	})
+	return permissionCache
+}
+
+// getPermissionCache returns the shared permission cache, falling back to a
+// process-local cache when InitPermissionCache was never called.
+func getPermissionCache() *PermissionCache {
+	return InitPermissionCache(nil, defaultPermissionCacheTTL)
+}
+
+// NewPermissionCache creates a permission cache. Use InitPermissionCache for the
+// shared instance; this constructor exists for isolated use and tests.
+func NewPermissionCache(rdb *redis.Client, ttl time.Duration) *PermissionCache {
+	if ttl <= 0 {
+		ttl = defaultPermissionCacheTTL
+	}
+	localTTL := ttl
+	if localTTL > maxPermissionLocalCacheTTL {
+		// Keep the in-process copy short-lived so a missed pub/sub message
+		// (e.g. during a Redis reconnect) cannot keep stale permissions around.
+		localTTL = maxPermissionLocalCacheTTL
+	}
+	cache := &PermissionCache{
+		rdb:         rdb,
+		ttl:         ttl,
+		localTTL:    localTTL,
+		local:       make(map[int]permissionCacheEntry),
+		generations: make(map[int]uint64),
+	}
+	if rdb != nil {
+		cache.shared = &redisPermissionStore{rdb: rdb}
+	}
+	return cache
+}
+
+// Get returns the cached grants of a user keyed by permission key, loading them
+// with loader on a miss. Grant expiry is evaluated by the caller at check time.
+func (c *PermissionCache) Get(userID int, loader func() ([]models.PermissionGrant, error)) (map[string]models.PermissionGrant, error) {
+	now := time.Now()
+
+	c.mu.RLock()
+	entry, ok := c.local[userID]
+	generation := c.generations[userID]
+	c.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		c.localHits.Add(1)
+		return entry.grants, nil
+	}
+
+	if c.shared != nil {
+		if grants, ok := c.shared.Get(userID); ok {
+			c.redisHits.Add(1)
+			byKey := indexPermissionGrants(grants)
+			c.storeLocal(userID, generation, byKey)
+			return byKey, nil
+		}
+	}
+
+	// Read the shared generation before loading so a revoke on another replica
+	// between the load and the write-back turns the write into a no-op.
+	var sharedGeneration int64
+	writeBack := c.shared != nil
+	if writeBack {
+		var err error
+		sharedGeneration, err = c.shared.Generation(userID)
+		writeBack = err == nil
+	}
+
+	c.misses.Add(1)
+	grants, err := loader()
+	if err != nil {
+		return nil, err
+	}
+	byKey := indexPermissionGrants(grants)
+	if c.storeLocal(userID, generation, byKey) && writeBack {
+		if err := c.shared.SetIfGeneration(userID, sharedGeneration, grants, c.ttl); err != nil {
+			log.Printf("⚠️ Failed to write permission cache for user %d: %v", userID, err)
+		}
+	}
+	return byKey, nil
+}
+
+// Invalidate drops the cached permissions of a user on this and every other replica.
+func (c *PermissionCache) Invalidate(userID int) {
+	c.invalidateLocal(userID)
+
+	if c.shared == nil {
+		return
+	}
+	if err := c.shared.Invalidate(userID); err != nil {
+		log.Printf("⚠️ Failed to invalidate permission cache for user %d: %v", userID, err)
+	}
+}
+
+// Stats returns hit/miss counters for the permission cache.
+func (c *PermissionCache) Stats() models.PermissionCacheStats {
+	c.mu.RLock()
+	entries := len(c.local)
+	c.mu.RUnlock()
+
+	localHits := c.localHits.Load()
+	redisHits := c.redisHits.Load()
+	misses := c.misses.Load()
+	lookups := localHits + redisHits + misses
+
+	hitRate := 0.0
+	if lookups > 0 {
+		hitRate = float64(localHits+redisHits) / float64(lookups)
+	}
+
+	return models.PermissionCacheStats{
+		LocalHits:     localHits,
+		RedisHits:     redisHits,
+		Misses:        misses,
+		Invalidations: c.invalidations.Load(),
+		Lookups:       lookups,
+		HitRate:       hitRate,
+		LocalEntries:  entries,
+		TTLSeconds:    int(c.ttl.Seconds()),
+	}
+}
+
+func (c *PermissionCache) storeLocal(userID int, generation uint64, grants map[string]models.PermissionGrant) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// An invalidation raced with the load; do not cache a possibly stale set.
+	if c.generations[userID] != generation {
+		return false
+	}
+	c.local[userID] = permissionCacheEntry{grants: grants, expiresAt: time.Now().Add(c.localTTL)}
+	return true
+}
+
+func (c *PermissionCache) invalidateLocal(userID int) {
+	c.invalidations.Add(1)
+	c.mu.Lock()
+	delete(c.local, userID)
+	c.generations[userID]++
+	c.mu.Unlock()
+}
+
+// redisPermissionStore keeps the grants of each user next to a generation
+// counter. Both keys share a hash tag so the compare-and-set script stays on one
+// slot.
+type redisPermissionStore struct {
+	rdb *redis.Client
+}
+
+// setPermissionsIfGenerationScript writes the grants only while the generation
+// still matches the value read before the database load. A missing counter
+// counts as generation 0.
+var setPermissionsIfGenerationScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if (current or '0') ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[2], ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
+func (s *redisPermissionStore) Generation(userID int) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), permissionCacheRedisTimeout)
+	defer cancel()
+
+	generation, err := s.rdb.Get(ctx, permissionGenerationKey(userID)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return generation, err
+}
+
+func (s *redisPermissionStore) Get(userID int) ([]models.PermissionGrant, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), permissionCacheRedisTimeout)
+	defer cancel()
+
+	payload, err := s.rdb.Get(ctx, permissionCacheKey(userID)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("⚠️ Failed to read permission cache for user %d: %v", userID, err)
+		}
+		return nil, false
+	}
+	var grants []models.PermissionGrant
+	if err := json.Unmarshal(payload, &grants); err != nil {
+		return nil, false
+	}
+	return grants, true
+}
+
+func (s *redisPermissionStore) SetIfGeneration(userID int, generation int64, grants []models.PermissionGrant, ttl time.Duration) error {
+	if grants == nil {
+		grants = []models.PermissionGrant{}
+	}
+	payload, err := json.Marshal(grants)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), permissionCacheRedisTimeout)
+	defer cancel()
+
+	keys := []string{permissionGenerationKey(userID), permissionCacheKey(userID)}
+	return setPermissionsIfGenerationScript.Run(ctx, s.rdb, keys, strconv.FormatInt(generation, 10), payload, ttl.Milliseconds()).Err()
+}
+
+func (s *redisPermissionStore) Invalidate(userID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), permissionCacheRedisTimeout)
+	defer cancel()
+
+	pipe := s.rdb.TxPipeline()
+	pipe.Incr(ctx, permissionGenerationKey(userID))
+	pipe.Del(ctx, permissionCacheKey(userID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	return s.rdb.Publish(ctx, permissionInvalidateChannel, strconv.Itoa(userID)).Err()
+}
+
+// subscribeInvalidations listens for invalidations published by other replicas.
+func (c *PermissionCache) subscribeInvalidations(ctx context.Context) {
+	sub := c.rdb.Subscribe(ctx, permissionInvalidateChannel)
+	defer sub.Close()
+
+	log.Println("✅ Permission cache invalidation subscriber started")
+	for msg := range sub.Channel() {
+		userID, err := strconv.Atoi(msg.Payload)
+		if err != nil {
+			log.Printf("⚠️ Ignoring malformed permission invalidation: %q", msg.Payload)
+			continue
+		}
+		c.invalidateLocal(userID)
+	}
+}
+
+func permissionCacheKey(userID int) string {
+	return fmt.Sprintf("%s{%d}", permissionCacheKeyPrefix, userID)
+}
+
+func permissionGenerationKey(userID int) string {
+	return permissionCacheKey(userID) + permissionGenerationSuffix
+}
+
+func indexPermissionGrants(grants []models.PermissionGrant) map[string]models.PermissionGrant {
+	byKey := make(map[string]models.PermissionGrant, len(grants))
+	for _, grant := range grants {
+		byKey[grant.PermissionKey] = grant
+	}
+	return byKey
+}
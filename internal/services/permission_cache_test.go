@@ -0,0 +1,232 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"errors"
+	"testing"
+	"time"
+)
+
+func grantsFor(keys ...string) []models.PermissionGrant {
+	grants := make([]models.PermissionGrant, 0, len(keys))
+	for _, key := range keys {
+		grants = append(grants, models.PermissionGrant{PermissionKey: key})
+	}
+	return grants
+}
+
+func TestPermissionCacheGetUsesLocalCopy(t *testing.T) {
+	cache := NewPermissionCache(nil, 0)
+	loads := 0
+	loader := func() ([]models.PermissionGrant, error) {
+		loads++
+		return grantsFor("tasks:first-review:claim"), nil
+	}
+
+	for i := 0; i < 3; i++ {
+		set, err := cache.Get(7, loader)
+		if err != nil {
+			t.Fatalf("Get returned error: %v", err)
+		}
+		if _, ok := set["tasks:first-review:claim"]; !ok {
+			t.Fatalf("expected permission in cached set, got %v", set)
+		}
+	}
+
+	if loads != 1 {
+		t.Fatalf("loader called %d times, want 1", loads)
+	}
+	stats := cache.Stats()
+	if stats.Misses != 1 || stats.LocalHits != 2 {
+		t.Fatalf("stats = %+v, want 1 miss and 2 local hits", stats)
+	}
+}
+
+func TestPermissionCacheInvalidateForcesReload(t *testing.T) {
+	cache := NewPermissionCache(nil, 0)
+	granted := grantsFor("stats:overview")
+	loader := func() ([]models.PermissionGrant, error) { return granted, nil }
+
+	if _, err := cache.Get(1, loader); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	granted = nil
+	cache.Invalidate(1)
+
+	set, err := cache.Get(1, loader)
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if len(set) != 0 {
+		t.Fatalf("expected revoked permissions to be gone, got %v", set)
+	}
+}
+
+func TestPermissionCacheSkipsStoreWhenInvalidatedDuringLoad(t *testing.T) {
+	cache := NewPermissionCache(nil, 0)
+	loader := func() ([]models.PermissionGrant, error) {
+		// Simulate a revoke landing while the database read is in flight.
+		cache.Invalidate(3)
+		return grantsFor("users:approve"), nil
+	}
+
+	if _, err := cache.Get(3, loader); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if entries := cache.Stats().LocalEntries; entries != 0 {
+		t.Fatalf("expected stale load not to be cached, got %d entries", entries)
+	}
+}
+
+// fakeSharedPermissionStore mimics the Redis store: a generation counter per
+// user that Invalidate bumps and a compare-and-set write.
+type fakeSharedPermissionStore struct {
+	generations map[int]int64
+	entries     map[int][]models.PermissionGrant
+}
+
+func newFakeSharedPermissionStore() *fakeSharedPermissionStore {
+	return &fakeSharedPermissionStore{
+		generations: make(map[int]int64),
+		entries:     make(map[int][]models.PermissionGrant),
+	}
+}
+
+func (s *fakeSharedPermissionStore) Generation(userID int) (int64, error) {
+	return s.generations[userID], nil
+}
+
+func (s *fakeSharedPermissionStore) Get(userID int) ([]models.PermissionGrant, bool) {
+	grants, ok := s.entries[userID]
+	return grants, ok
+}
+
+func (s *fakeSharedPermissionStore) SetIfGeneration(userID int, generation int64, grants []models.PermissionGrant, _ time.Duration) error {
+	if s.generations[userID] == generation {
+		s.entries[userID] = grants
+	}
+	return nil
+}
+
+func (s *fakeSharedPermissionStore) Invalidate(userID int) error {
+	s.generations[userID]++
+	delete(s.entries, userID)
+	return nil
+}
+
+func TestPermissionCacheWritesSharedCopyAfterLoad(t *testing.T) {
+	shared := newFakeSharedPermissionStore()
+	cache := NewPermissionCache(nil, 0)
+	cache.shared = shared
+
+	if _, err := cache.Get(4, func() ([]models.PermissionGrant, error) { return grantsFor("stats:overview"), nil }); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if _, ok := shared.entries[4]; !ok {
+		t.Fatal("expected loaded grants to be written to the shared cache")
+	}
+}
+
+func TestPermissionCacheSkipsSharedWriteWhenInvalidatedDuringLoad(t *testing.T) {
+	shared := newFakeSharedPermissionStore()
+	reader := NewPermissionCache(nil, 0)
+	reader.shared = shared
+	revoker := NewPermissionCache(nil, 0)
+	revoker.shared = shared
+
+	loader := func() ([]models.PermissionGrant, error) {
+		// Another replica revokes and invalidates after the database read but
+		// before the reader writes the result back.
+		grants := grantsFor("users:approve")
+		revoker.Invalidate(6)
+		return grants, nil
+	}
+
+	if _, err := reader.Get(6, loader); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if grants, ok := shared.entries[6]; ok {
+		t.Fatalf("expected stale grants not to reach the shared cache, got %v", grants)
+	}
+
+	revoked, err := revoker.Get(6, func() ([]models.PermissionGrant, error) { return nil, nil })
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if len(revoked) != 0 {
+		t.Fatalf("expected revoked permissions to be gone, got %v", revoked)
+	}
+}
+
+func TestPermissionCacheDoesNotCacheLoaderErrors(t *testing.T) {
+	cache := NewPermissionCache(nil, 0)
+	loads := 0
+	loader := func() ([]models.PermissionGrant, error) {
+		loads++
+		return nil, errors.New("db down")
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Get(5, loader); err == nil {
+			t.Fatal("expected loader error to propagate")
+		}
+	}
+	if loads != 2 {
+		t.Fatalf("loader called %d times, want 2", loads)
+	}
+}
+
+func TestPermissionServiceScopedAndExpiringGrants(t *testing.T) {
+	cache := NewPermissionCache(nil, 0)
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	grants := []models.PermissionGrant{
+		{PermissionKey: "stats:overview"},
+		{PermissionKey: "queue.video.claim", Scopes: []string{"pool:1m"}, ExpiresAt: &future},
+		{PermissionKey: "audit.logs.read", ExpiresAt: &past},
+	}
+	if _, err := cache.Get(9, func() ([]models.PermissionGrant, error) { return grants, nil }); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	svc := &PermissionService{cache: cache}
+
+	tests := []struct {
+		name  string
+		check func() (bool, error)
+		want  bool
+	}{
+		{"global grant", func() (bool, error) { return svc.HasPermission(9, "stats:overview") }, true},
+		{"scoped grant does not satisfy unscoped check", func() (bool, error) { return svc.HasPermission(9, "queue.video.claim") }, false},
+		{"scoped grant matches scope", func() (bool, error) { return svc.HasScopedPermission(9, "queue.video.claim", "pool:1m") }, true},
+		{"scoped grant rejects other scope", func() (bool, error) { return svc.HasScopedPermission(9, "queue.video.claim", "pool:10m") }, false},
+		{"global grant satisfies any scope", func() (bool, error) { return svc.HasScopedPermission(9, "stats:overview", "pool:10m") }, true},
+		{"expired grant is ignored", func() (bool, error) { return svc.HasPermission(9, "audit.logs.read") }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.check()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePermissionScopes(t *testing.T) {
+	scopes, err := normalizePermissionScopes([]string{" pool:1m ", "pool:1m", "", "module:user_management"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(scopes) != 2 || scopes[0] != "pool:1m" || scopes[1] != "module:user_management" {
+		t.Fatalf("normalizePermissionScopes = %v", scopes)
+	}
+
+	if _, err := normalizePermissionScopes([]string{"1m"}); err == nil {
+		t.Fatal("expected error for scope without dimension")
+	}
+}
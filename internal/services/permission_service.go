@@ -1,18 +1,39 @@
 package services
 
 import (
+	"comment-review-platform/internal/auditevent"
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/repository"
+	"context"
 	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Permission scope dimensions understood by scoped route checks.
+const (
+	PermissionScopePool   = "pool"
+	PermissionScopeModule = "module"
+)
+
+// SystemGrantActor is passed as grantedBy for grants the platform makes on its
+// own, such as SSO group mappings. They are stored without a granting user.
+const SystemGrantActor = 0
+
+var permissionScopePattern = regexp.MustCompile(`^[a-z_]+:[A-Za-z0-9_.\-]+$`)
+
 type PermissionService struct {
 	permissionRepo *repository.PermissionRepository
+	cache          *PermissionCache
 }
 
 func NewPermissionService() *PermissionService {
 	return &PermissionService{
 		permissionRepo: repository.NewPermissionRepository(),
+		cache:          getPermissionCache(),
 	}
 }
 
@@ -21,16 +42,94 @@ func (s *PermissionService) GetUserPermissions(userID int) ([]string, error) {
 	return s.permissionRepo.GetUserPermissions(userID)
 }
 
-// HasPermission checks if a user has a specific permission
+// GetUserPermissionGrants retrieves unexpired grants (with scopes and expiry) for a user
+func (s *PermissionService) GetUserPermissionGrants(userID int) ([]models.PermissionGrant, error) {
+	return s.permissionRepo.GetUserPermissionGrants(userID)
+}
+
+// HasPermission checks if a user has a specific permission granted globally.
+// Scoped grants never satisfy an unscoped check.
 func (s *PermissionService) HasPermission(userID int, permissionKey string) (bool, error) {
-	return s.permissionRepo.HasPermission(userID, permissionKey)
+	return s.HasAnyPermission(userID, permissionKey)
+}
+
+// HasAnyPermission checks if a user has at least one of the given permissions
+// granted globally, using a single (cached) lookup of the user's grants.
+func (s *PermissionService) HasAnyPermission(userID int, permissionKeys ...string) (bool, error) {
+	grants, err := s.effectiveGrants(userID)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	for _, key := range permissionKeys {
+		if grant, ok := grants[key]; ok && grantActive(grant, now) && len(grant.Scopes) == 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HasScopedPermission checks if a user holds permissionKey either globally or
+// scoped to the given scope (e.g. "pool:1m").
+func (s *PermissionService) HasScopedPermission(userID int, permissionKey, scope string) (bool, error) {
+	global, scopes, err := s.PermissionScopes(userID, permissionKey)
+	if err != nil {
+		return false, err
+	}
+	if global {
+		return true, nil
+	}
+	for _, granted := range scopes {
+		if granted == scope {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PermissionScopes reports whether a user holds permissionKey globally and, if
+// not, which scopes the grant is limited to. Both are empty when not granted.
+func (s *PermissionService) PermissionScopes(userID int, permissionKey string) (bool, []string, error) {
+	grants, err := s.effectiveGrants(userID)
+	if err != nil {
+		return false, nil, err
+	}
+	grant, ok := grants[permissionKey]
+	if !ok || !grantActive(grant, time.Now()) {
+		return false, nil, nil
+	}
+	if len(grant.Scopes) == 0 {
+		return true, nil, nil
+	}
+	return false, grant.Scopes, nil
+}
+
+// InvalidateUser drops cached permissions for a user on every replica.
+func (s *PermissionService) InvalidateUser(userID int) {
+	s.cache.Invalidate(userID)
 }
 
-// GrantPermissions grants multiple permissions to a user
-func (s *PermissionService) GrantPermissions(userID int, permissionKeys []string, grantedBy int) error {
+func (s *PermissionService) effectiveGrants(userID int) (map[string]models.PermissionGrant, error) {
+	return s.cache.Get(userID, func() ([]models.PermissionGrant, error) {
+		return s.permissionRepo.GetUserPermissionGrants(userID)
+	})
+}
+
+// GrantPermissions grants multiple permissions to a user, optionally limited in
+// time (expiresAt) and to a set of scopes. Use SystemGrantActor as grantedBy for
+// grants no administrator made.
+func (s *PermissionService) GrantPermissions(ctx context.Context, userID int, permissionKeys []string, grantedBy int, expiresAt *time.Time, scopes []string) error {
 	if len(permissionKeys) == 0 {
 		return fmt.Errorf("no permissions to grant")
 	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return fmt.Errorf("expires_at must be in the future")
+	}
+
+	normalizedScopes, err := normalizePermissionScopes(scopes)
+	if err != nil {
+		return err
+	}
 
 	// Validate that all permission keys exist
 	for _, key := range permissionKeys {
@@ -40,17 +139,88 @@ func (s *PermissionService) GrantPermissions(userID int, permissionKeys []string
 		}
 	}
 
+	before, err := s.grantSnapshot(userID, permissionKeys)
+	if err != nil {
+		return err
+	}
+
 	// Grant permissions
-	return s.permissionRepo.GrantPermissions(userID, permissionKeys, &grantedBy)
+	var grantedByID *int
+	if grantedBy != SystemGrantActor {
+		grantedByID = &grantedBy
+	}
+	if err := s.permissionRepo.GrantPermissions(userID, permissionKeys, grantedByID, expiresAt, normalizedScopes); err != nil {
+		return err
+	}
+	s.cache.Invalidate(userID)
+
+	after := make(map[string]*models.PermissionGrant, len(permissionKeys))
+	for _, key := range permissionKeys {
+		after[key] = &models.PermissionGrant{PermissionKey: key, Scopes: normalizedScopes, ExpiresAt: expiresAt}
+	}
+	s.recordGrantChange(ctx, auditevent.PermissionGrant, userID, grantedBy, before, after)
+	return nil
 }
 
 // RevokePermissions revokes multiple permissions from a user
-func (s *PermissionService) RevokePermissions(userID int, permissionKeys []string) error {
+func (s *PermissionService) RevokePermissions(ctx context.Context, userID int, permissionKeys []string, revokedBy int) error {
 	if len(permissionKeys) == 0 {
 		return fmt.Errorf("no permissions to revoke")
 	}
 
-	return s.permissionRepo.RevokePermissions(userID, permissionKeys)
+	before, err := s.grantSnapshot(userID, permissionKeys)
+	if err != nil {
+		return err
+	}
+
+	if err := s.permissionRepo.RevokePermissions(userID, permissionKeys); err != nil {
+		return err
+	}
+	s.cache.Invalidate(userID)
+
+	after := make(map[string]*models.PermissionGrant, len(permissionKeys))
+	for _, key := range permissionKeys {
+		after[key] = nil
+	}
+	s.recordGrantChange(ctx, auditevent.PermissionRevoke, userID, revokedBy, before, after)
+	return nil
+}
+
+// grantSnapshot returns the user's current grants of the given keys, with
+// nil for keys they do not hold
+func (s *PermissionService) grantSnapshot(userID int, permissionKeys []string) (map[string]*models.PermissionGrant, error) {
+	grants, err := s.permissionRepo.GetUserPermissionGrants(userID)
+	if err != nil {
+		return nil, err
+	}
+	held := make(map[string]models.PermissionGrant, len(grants))
+	for _, grant := range grants {
+		held[grant.PermissionKey] = grant
+	}
+	snapshot := make(map[string]*models.PermissionGrant, len(permissionKeys))
+	for _, key := range permissionKeys {
+		if grant, ok := held[key]; ok {
+			snapshot[key] = &grant
+		} else {
+			snapshot[key] = nil
+		}
+	}
+	return snapshot, nil
+}
+
+// recordGrantChange records a change of a user's grants, keyed by permission
+func (s *PermissionService) recordGrantChange(ctx context.Context, action auditevent.Action, userID, actorID int, before, after map[string]*models.PermissionGrant) {
+	event := auditevent.Event{
+		Action:       action,
+		ResourceType: "user_permissions",
+		ResourceID:   strconv.Itoa(userID),
+		Before:       before,
+		After:        after,
+	}
+	if actorID > 0 {
+		event.ActorID = &actorID
+	}
+	auditevent.Record(ctx, event)
 }
 
 // GetAllPermissions retrieves all active permissions
@@ -82,3 +252,72 @@ func (s *PermissionService) ListPermissions(resource, category, search string, p
 		TotalPages: totalPages,
 	}, nil
 }
+
+// ExpireGrants removes grants past their expires_at, records the expirations
+// and invalidates the cached permissions of affected users.
+func (s *PermissionService) ExpireGrants() (int, error) {
+	userIDs, err := s.permissionRepo.ExpireGrants()
+	if err != nil {
+		return 0, err
+	}
+	for _, userID := range userIDs {
+		s.cache.Invalidate(userID)
+	}
+	return len(userIDs), nil
+}
+
+// ListGrantExpirations retrieves recorded grant expirations
+func (s *PermissionService) ListGrantExpirations(userID *int, limit int) ([]models.PermissionGrantExpiration, error) {
+	return s.permissionRepo.ListGrantExpirations(userID, limit)
+}
+
+// StartGrantExpirySweeper periodically removes expired time-bounded grants.
+func (s *PermissionService) StartGrantExpirySweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("✅ Permission grant expiry sweeper started (interval=%v)", interval)
+
+	for range ticker.C {
+		affected, err := s.ExpireGrants()
+		if err != nil {
+			log.Printf("⚠️ Error expiring permission grants: %v", err)
+			continue
+		}
+		if affected > 0 {
+			log.Printf("⏰ Expired permission grants for %d user(s)", affected)
+		}
+	}
+}
+
+// PermissionScope builds a scope string such as "pool:1m".
+func PermissionScope(dimension, value string) string {
+	return dimension + ":" + value
+}
+
+func grantActive(grant models.PermissionGrant, now time.Time) bool {
+	return grant.ExpiresAt == nil || grant.ExpiresAt.After(now)
+}
+
+func normalizePermissionScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, nil
+	}
+	seen := make(map[string]struct{}, len(scopes))
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		if !permissionScopePattern.MatchString(scope) {
+			return nil, fmt.Errorf("invalid scope %q: expected <dimension>:<value>", scope)
+		}
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		normalized = append(normalized, scope)
+	}
+	return normalized, nil
+}
@@ -5,6 +5,7 @@ import (
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/repository"
 	"comment-review-platform/pkg/r2"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -52,7 +53,7 @@ func NewProfileService() *ProfileService {
 }
 
 func (s *ProfileService) GetProfile(userID int) (*models.User, []string, error) {
-	user, err := s.userRepo.FindByID(userID)
+	user, err := s.userRepo.FindByID(context.Background(), userID)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -80,7 +81,7 @@ func (s *ProfileService) UpdateSystemProfile(userID int, req models.UpdateSystem
 	return s.userRepo.UpdateSystemProfile(userID, officeLocation, department, school, company, directManager)
 }
 
-func (s *ProfileService) UpdateAvatar(userID int, fileHeader *multipart.FileHeader) error {
+func (s *ProfileService) UpdateAvatar(ctx context.Context, userID int, fileHeader *multipart.FileHeader) error {
 	if fileHeader == nil {
 		return ErrAvatarFileInvalid
 	}
@@ -119,7 +120,7 @@ func (s *ProfileService) UpdateAvatar(userID int, fileHeader *multipart.FileHead
 	}
 
 	key := fmt.Sprintf("%s%d/%s%s", s.avatarPrefix, userID, uuid.NewString(), ext)
-	if err := s.r2.UploadObject(key, data, contentType); err != nil {
+	if err := s.r2.UploadObject(ctx, key, data, contentType); err != nil {
 		return err
 	}
 
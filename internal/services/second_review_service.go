@@ -105,11 +105,12 @@ func (s *SecondReviewService) SubmitSecondReview(reviewerID int, req models.Subm
 	if err != nil {
 		return err
 	}
-	status := "rejected"
+	status := models.CommentStatusRejected
 	if result.IsApproved {
-		status = "approved"
+		status = models.CommentStatusApproved
 	}
-	if err := s.commentRepo.UpdateModerationStatus(commentID, status); err != nil {
+	change := reviewStatusChange(models.StatusSourceSecondReview, req.TaskID, reviewerID, &req.Reason)
+	if err := s.commentRepo.UpdateModerationStatus(commentID, status, change); err != nil {
 		return err
 	}
 
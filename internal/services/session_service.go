@@ -0,0 +1,279 @@
+package services
+
+import (
+	"comment-review-platform/internal/config"
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	jwtpkg "comment-review-platform/pkg/jwt"
+	redispkg "comment-review-platform/pkg/redis"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Session revocation reasons recorded on user_sessions.revoked_reason
+const (
+	SessionRevokeLogout        = "logout"
+	SessionRevokeUser          = "user_revoked"
+	SessionRevokeAdmin         = "admin_force_logout"
+	SessionRevokeUserDeleted   = "user_deleted"
+	SessionRevokeStatusChanged = "status_changed"
+	SessionRevokeTokenReuse    = "token_reuse"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used; session revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrTokenRevoked        = errors.New("token has been revoked")
+)
+
+const accessTokenDenylistPrefix = "jwt:denylist:"
+
+// SessionService issues short-lived access tokens paired with rotating,
+// server-side refresh tokens, and revokes them through a Redis jti denylist.
+type SessionService struct {
+	sessionRepo *repository.SessionRepository
+	userRepo    *repository.UserRepository
+	rdb         *redis.Client
+	keys        *jwtpkg.KeySet
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+}
+
+func NewSessionService() *SessionService {
+	accessTTL := time.Duration(config.AppConfig.AccessTokenTTLMinutes) * time.Minute
+	if accessTTL <= 0 {
+		accessTTL = 15 * time.Minute
+	}
+	refreshTTL := time.Duration(config.AppConfig.RefreshTokenTTLHours) * time.Hour
+	if refreshTTL <= 0 {
+		refreshTTL = 30 * 24 * time.Hour
+	}
+	return &SessionService{
+		sessionRepo: repository.NewSessionRepository(),
+		userRepo:    repository.NewUserRepository(),
+		rdb:         redispkg.Client,
+		keys:        TokenKeys(),
+		accessTTL:   accessTTL,
+		refreshTTL:  refreshTTL,
+	}
+}
+
+// CreateSession starts a new login session for an authenticated user
+func (s *SessionService) CreateSession(user *models.User, userAgent, ipAddress string) (*models.LoginResponse, error) {
+	sessionID := uuid.NewString()
+	accessToken, claims, err := jwtpkg.GenerateAccessToken(user.ID, user.Username, user.Role, sessionID, s.keys, s.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.UserSession{
+		ID:               sessionID,
+		UserID:           user.ID,
+		RefreshTokenHash: hashRefreshToken(refreshToken),
+		AccessJTI:        claims.ID,
+		AccessExpiresAt:  claims.ExpiresAt.Time,
+		UserAgent:        optionalString(userAgent),
+		IPAddress:        optionalString(ipAddress),
+		ExpiresAt:        time.Now().Add(s.refreshTTL),
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	return &models.LoginResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    claims.ExpiresAt.Time,
+		SessionID:    sessionID,
+		User:         *user,
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new access/refresh pair. Presenting a
+// refresh token that was already rotated away revokes the whole session.
+func (s *SessionService) Refresh(refreshToken, userAgent, ipAddress string) (*models.LoginResponse, error) {
+	hash := hashRefreshToken(refreshToken)
+	session, err := s.sessionRepo.FindByRefreshHash(hash)
+	if err == sql.ErrNoRows {
+		if reused, findErr := s.sessionRepo.FindByPreviousHash(hash); findErr == nil {
+			if reused.RevokedAt == nil {
+				log.Printf("⚠️  Refresh token reuse detected for session %s (user %d), revoking", reused.ID, reused.UserID)
+				if revokeErr := s.revoke(reused.ID, SessionRevokeTokenReuse); revokeErr != nil {
+					return nil, revokeErr
+				}
+			}
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.FindByID(context.Background(), session.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if user.Status != "approved" {
+		if revokeErr := s.revoke(session.ID, SessionRevokeStatusChanged); revokeErr != nil {
+			return nil, revokeErr
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	accessToken, claims, err := jwtpkg.GenerateAccessToken(user.ID, user.Username, user.Role, session.ID, s.keys, s.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+	newRefreshToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	rotated, err := s.sessionRepo.Rotate(session.ID, hash, hashRefreshToken(newRefreshToken), claims.ID, claims.ExpiresAt.Time, optionalString(userAgent), optionalString(ipAddress))
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// The access token issued before this refresh is superseded.
+	s.denyAccessToken(session.AccessJTI, session.AccessExpiresAt)
+
+	return &models.LoginResponse{
+		Token:        accessToken,
+		RefreshToken: newRefreshToken,
+		ExpiresAt:    claims.ExpiresAt.Time,
+		SessionID:    session.ID,
+		User:         *user,
+	}, nil
+}
+
+// ValidateAccessToken verifies the token signature and expiry and rejects
+// tokens whose jti is on the revocation denylist.
+func (s *SessionService) ValidateAccessToken(ctx context.Context, token string) (*jwtpkg.Claims, error) {
+	claims, err := jwtpkg.ValidateToken(token, s.keys)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ID == "" || s.rdb == nil {
+		return claims, nil
+	}
+
+	denied, err := s.rdb.Exists(ctx, accessTokenDenylistPrefix+claims.ID).Result()
+	if err != nil {
+		return nil, fmt.Errorf("check token denylist: %w", err)
+	}
+	if denied > 0 {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// ListSessions lists the active sessions of a user, flagging the caller's own session
+func (s *SessionService) ListSessions(userID int, currentSessionID string) ([]models.UserSession, error) {
+	sessions, err := s.sessionRepo.ListActiveByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession revokes one of the user's own sessions (per-device logout)
+func (s *SessionService) RevokeSession(userID int, sessionID, reason string) error {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err == sql.ErrNoRows || (err == nil && session.UserID != userID) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if session.RevokedAt != nil {
+		return nil
+	}
+	return s.revoke(sessionID, reason)
+}
+
+// RevokeUserSessions revokes every active session of a user and returns how many were revoked
+func (s *SessionService) RevokeUserSessions(userID int, reason string) (int, error) {
+	sessions, err := s.sessionRepo.RevokeAllByUser(userID, reason)
+	if err != nil {
+		return 0, err
+	}
+	for _, session := range sessions {
+		s.denyAccessToken(session.AccessJTI, session.AccessExpiresAt)
+	}
+	if len(sessions) > 0 {
+		log.Printf("🔒 Revoked %d session(s) for user %d (%s)", len(sessions), userID, reason)
+	}
+	return len(sessions), nil
+}
+
+func (s *SessionService) revoke(sessionID, reason string) error {
+	session, err := s.sessionRepo.Revoke(sessionID, reason)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.denyAccessToken(session.AccessJTI, session.AccessExpiresAt)
+	return nil
+}
+
+// denyAccessToken puts a jti on the denylist until the token would have expired anyway
+func (s *SessionService) denyAccessToken(jti string, expiresAt time.Time) {
+	if s.rdb == nil || jti == "" {
+		return
+	}
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return
+	}
+	if err := s.rdb.Set(context.Background(), accessTokenDenylistPrefix+jti, 1, ttl).Err(); err != nil {
+		log.Printf("⚠️  Failed to denylist access token %s: %v", jti, err)
+	}
+}
+
+func generateRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"comment-review-platform/internal/auditevent"
 	"comment-review-platform/internal/config"
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/repository"
@@ -11,6 +12,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
@@ -40,14 +42,14 @@ func NewTaskService() *TaskService {
 }
 
 // ClaimTasks allows a reviewer to claim tasks with custom count (1-50)
-func (s *TaskService) ClaimTasks(reviewerID int, count int) ([]models.ReviewTask, error) {
+func (s *TaskService) ClaimTasks(ctx context.Context, reviewerID int, count int) ([]models.ReviewTask, error) {
 	// Validate count (1-50)
 	if count < 1 || count > 50 {
 		return nil, errors.New("claim count must be between 1 and 50")
 	}
 
 	// Check if user already has uncompleted tasks
-	existingTasks, err := s.taskRepo.GetMyTasks(reviewerID)
+	existingTasks, err := s.taskRepo.GetMyTasks(ctx, reviewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -57,7 +59,7 @@ func (s *TaskService) ClaimTasks(reviewerID int, count int) ([]models.ReviewTask
 	}
 
 	// Claim tasks from database
-	tasks, err := s.taskRepo.ClaimTasks(reviewerID, count)
+	tasks, err := s.taskRepo.ClaimTasks(ctx, reviewerID, count)
 	if err != nil {
 		return nil, err
 	}
@@ -73,22 +75,22 @@ func (s *TaskService) ClaimTasks(reviewerID int, count int) ([]models.ReviewTask
 	pipe := s.rdb.Pipeline()
 	for _, task := range tasks {
 		// Add to user's claimed set
-		pipe.SAdd(s.ctx, userClaimedKey, task.ID)
+		pipe.SAdd(ctx, userClaimedKey, task.ID)
 
 		// Set lock for each task
 		lockKey := fmt.Sprintf("task:lock:%d", task.ID)
-		pipe.Set(s.ctx, lockKey, reviewerID, timeout)
+		pipe.Set(ctx, lockKey, reviewerID, timeout)
 	}
-	pipe.Expire(s.ctx, userClaimedKey, timeout)
+	pipe.Expire(ctx, userClaimedKey, timeout)
 
-	_, err = pipe.Exec(s.ctx)
+	_, err = pipe.Exec(ctx)
 	if err != nil {
 		log.Printf("Redis error when claiming tasks: %v", err)
 		taskIDs := make([]int, len(tasks))
 		for i, task := range tasks {
 			taskIDs[i] = task.ID
 		}
-		if _, resetErr := s.taskRepo.ReturnTasks(taskIDs, reviewerID); resetErr != nil {
+		if _, resetErr := s.taskRepo.ReturnTasks(ctx, taskIDs, reviewerID); resetErr != nil {
 			log.Printf("Failed to rollback claimed tasks after Redis error: %v", resetErr)
 		}
 		return nil, errors.New("failed to claim tasks, please retry")
@@ -98,31 +100,47 @@ func (s *TaskService) ClaimTasks(reviewerID int, count int) ([]models.ReviewTask
 }
 
 // GetMyTasks retrieves the current user's in-progress tasks
-func (s *TaskService) GetMyTasks(reviewerID int) ([]models.ReviewTask, error) {
-	return s.taskRepo.GetMyTasks(reviewerID)
+func (s *TaskService) GetMyTasks(ctx context.Context, reviewerID int) ([]models.ReviewTask, error) {
+	return s.taskRepo.GetMyTasks(ctx, reviewerID)
+}
+
+// reviewTaskAuditState is the audited state of a first-review task and the
+// comment it decides
+type reviewTaskAuditState struct {
+	TaskStatus    string   `json:"task_status"`
+	CommentID     int64    `json:"comment_id"`
+	CommentStatus string   `json:"comment_status"`
+	IsApproved    *bool    `json:"is_approved,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	Reason        string   `json:"reason,omitempty"`
 }
 
 // SubmitReview submits a review result
-func (s *TaskService) SubmitReview(reviewerID int, req models.SubmitReviewRequest) error {
+func (s *TaskService) SubmitReview(ctx context.Context, reviewerID int, req models.SubmitReviewRequest) error {
 	if err := validateTags(s.tagRepo, "comment", req.Tags); err != nil {
 		return err
 	}
 
-	tx, err := database.DB.Begin()
+	tx, err := database.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	commentID, err := s.taskRepo.GetCommentIDTx(tx, req.TaskID)
+	commentID, err := s.taskRepo.GetCommentIDTx(ctx, tx, req.TaskID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return errors.New("task not found")
 		}
 		return err
 	}
+	commentStatus, err := s.commentRepo.GetModerationStatusTx(ctx, tx, commentID)
+	if err != nil {
+		return err
+	}
+	before := reviewTaskAuditState{TaskStatus: "in_progress", CommentID: commentID, CommentStatus: commentStatus}
 
-	if err := s.taskRepo.CompleteTaskTx(tx, req.TaskID, reviewerID); err != nil {
+	if err := s.taskRepo.CompleteTaskTx(ctx, tx, req.TaskID, reviewerID); err != nil {
 		if err == sql.ErrNoRows {
 			return errors.New("task not found or already completed")
 		}
@@ -137,21 +155,22 @@ func (s *TaskService) SubmitReview(reviewerID int, req models.SubmitReviewReques
 		Reason:     req.Reason,
 	}
 
-	createdResult, err := s.taskRepo.CreateReviewResultTx(tx, result)
+	createdResult, err := s.taskRepo.CreateReviewResultTx(ctx, tx, result)
 	if err != nil {
 		return err
 	}
 
 	var createdSecondReviewTask bool
-	if req.IsApproved {
-		if err := s.commentRepo.UpdateModerationStatusTx(tx, commentID, "approved"); err != nil {
-			return err
-		}
-	} else {
-		if err := s.commentRepo.UpdateModerationStatusTx(tx, commentID, "pending_second_review"); err != nil {
-			return err
-		}
-		createdSecondReviewTask, err = s.secondReviewRepo.CreateSecondReviewTaskTx(tx, result.ID, commentID)
+	change := reviewStatusChange(models.StatusSourceFirstReview, req.TaskID, reviewerID, &req.Reason)
+	newStatus := models.CommentStatusApproved
+	if !req.IsApproved {
+		newStatus = models.CommentStatusPendingSecondReview
+	}
+	if err := s.commentRepo.UpdateModerationStatusTx(ctx, tx, commentID, newStatus, change); err != nil {
+		return err
+	}
+	if !req.IsApproved {
+		createdSecondReviewTask, err = s.secondReviewRepo.CreateSecondReviewTaskTx(ctx, tx, result.ID, commentID)
 		if err != nil {
 			return err
 		}
@@ -161,13 +180,29 @@ func (s *TaskService) SubmitReview(reviewerID int, req models.SubmitReviewReques
 		return err
 	}
 
+	auditevent.Record(ctx, auditevent.Event{
+		Action:       auditevent.ReviewSubmit,
+		ResourceType: "review_task",
+		ResourceID:   strconv.Itoa(req.TaskID),
+		ActorID:      &reviewerID,
+		Before:       before,
+		After: reviewTaskAuditState{
+			TaskStatus:    "completed",
+			CommentID:     commentID,
+			CommentStatus: newStatus,
+			IsApproved:    &req.IsApproved,
+			Tags:          req.Tags,
+			Reason:        req.Reason,
+		},
+	})
+
 	if err := s.diffRepo.CreateTaskIfMismatchWithHumanResult(req.TaskID, result.ID, result.IsApproved); err != nil {
 		log.Printf("Error creating AI diff task for review task %d: %v", req.TaskID, err)
 	}
 
 	if createdSecondReviewTask {
 		queueKey := "review:queue:second"
-		if err := s.rdb.LPush(s.ctx, queueKey, commentID).Err(); err != nil {
+		if err := s.rdb.LPush(ctx, queueKey, commentID).Err(); err != nil {
 			log.Printf("Redis error pushing to second review queue: %v", err)
 		}
 	}
@@ -176,26 +211,26 @@ func (s *TaskService) SubmitReview(reviewerID int, req models.SubmitReviewReques
 	lockKey := fmt.Sprintf("task:lock:%d", req.TaskID)
 
 	pipe := s.rdb.Pipeline()
-	pipe.SRem(s.ctx, userClaimedKey, req.TaskID)
-	pipe.Del(s.ctx, lockKey)
-	_, err = pipe.Exec(s.ctx)
+	pipe.SRem(ctx, userClaimedKey, req.TaskID)
+	pipe.Del(ctx, lockKey)
+	_, err = pipe.Exec(ctx)
 
 	if err != nil {
 		log.Printf("Redis error when submitting review: %v", err)
 	}
 
 	if createdResult {
-		s.updateStats(result)
+		s.updateStats(ctx, result)
 	}
 
 	return nil
 }
 
 // SubmitBatchReviews submits multiple reviews at once
-func (s *TaskService) SubmitBatchReviews(reviewerID int, reviews []models.SubmitReviewRequest) error {
+func (s *TaskService) SubmitBatchReviews(ctx context.Context, reviewerID int, reviews []models.SubmitReviewRequest) error {
 	var failed []string
 	for _, review := range reviews {
-		if err := s.SubmitReview(reviewerID, review); err != nil {
+		if err := s.SubmitReview(ctx, reviewerID, review); err != nil {
 			failed = append(failed, fmt.Sprintf("task %d: %v", review.TaskID, err))
 		}
 	}
@@ -206,7 +241,7 @@ func (s *TaskService) SubmitBatchReviews(reviewerID int, reviews []models.Submit
 }
 
 // updateStats updates statistics in Redis
-func (s *TaskService) updateStats(result *models.ReviewResult) {
+func (s *TaskService) updateStats(ctx context.Context, result *models.ReviewResult) {
 	now := time.Now()
 	date := now.Format("2006-01-02")
 	hour := now.Hour()
@@ -215,23 +250,23 @@ func (s *TaskService) updateStats(result *models.ReviewResult) {
 	dailyKey := fmt.Sprintf("stats:daily:%s", date)
 
 	pipe := s.rdb.Pipeline()
-	pipe.HIncrBy(s.ctx, hourlyKey, "count", 1)
-	pipe.Expire(s.ctx, hourlyKey, 7*24*time.Hour) // 7 days TTL
+	pipe.HIncrBy(ctx, hourlyKey, "count", 1)
+	pipe.Expire(ctx, hourlyKey, 7*24*time.Hour) // 7 days TTL
 
-	pipe.HIncrBy(s.ctx, dailyKey, "count", 1)
+	pipe.HIncrBy(ctx, dailyKey, "count", 1)
 	if result.IsApproved {
-		pipe.HIncrBy(s.ctx, dailyKey, "approved", 1)
+		pipe.HIncrBy(ctx, dailyKey, "approved", 1)
 	} else {
-		pipe.HIncrBy(s.ctx, dailyKey, "rejected", 1)
+		pipe.HIncrBy(ctx, dailyKey, "rejected", 1)
 
 		// Track tag statistics
 		for _, tag := range result.Tags {
-			pipe.HIncrBy(s.ctx, dailyKey, fmt.Sprintf("tag:%s", tag), 1)
+			pipe.HIncrBy(ctx, dailyKey, fmt.Sprintf("tag:%s", tag), 1)
 		}
 	}
-	pipe.Expire(s.ctx, dailyKey, 30*24*time.Hour) // 30 days TTL
+	pipe.Expire(ctx, dailyKey, 30*24*time.Hour) // 30 days TTL
 
-	_, err := pipe.Exec(s.ctx)
+	_, err := pipe.Exec(ctx)
 	if err != nil {
 		log.Printf("Redis error when updating stats: %v", err)
 	}
@@ -243,14 +278,14 @@ func (s *TaskService) GetActiveTags() ([]models.TagConfig, error) {
 }
 
 // ReturnTasks allows a reviewer to return tasks back to the pool
-func (s *TaskService) ReturnTasks(reviewerID int, taskIDs []int) (int, error) {
+func (s *TaskService) ReturnTasks(ctx context.Context, reviewerID int, taskIDs []int) (int, error) {
 	// Validate task count (1-50)
 	if len(taskIDs) < 1 || len(taskIDs) > 50 {
 		return 0, errors.New("return count must be between 1 and 50")
 	}
 
 	// Return tasks in database
-	returnedCount, err := s.taskRepo.ReturnTasks(taskIDs, reviewerID)
+	returnedCount, err := s.taskRepo.ReturnTasks(ctx, taskIDs, reviewerID)
 	if err != nil {
 		return 0, err
 	}
@@ -265,14 +300,14 @@ func (s *TaskService) ReturnTasks(reviewerID int, taskIDs []int) (int, error) {
 
 	for _, taskID := range taskIDs {
 		// Remove from user's claimed set
-		pipe.SRem(s.ctx, userClaimedKey, taskID)
+		pipe.SRem(ctx, userClaimedKey, taskID)
 
 		// Remove task lock
 		lockKey := fmt.Sprintf("task:lock:%d", taskID)
-		pipe.Del(s.ctx, lockKey)
+		pipe.Del(ctx, lockKey)
 	}
 
-	_, err = pipe.Exec(s.ctx)
+	_, err = pipe.Exec(ctx)
 	if err != nil {
 		log.Printf("Redis error when returning tasks: %v", err)
 		return returnedCount, errors.New("tasks returned but failed to update cache")
@@ -335,6 +370,9 @@ func (s *TaskService) SearchTasks(req models.SearchTasksRequest) (*models.Search
 	if err != nil {
 		return nil, err
 	}
+	if err := NewVideoAnnotationService().AttachToSearchResults(results); err != nil {
+		log.Printf("Error loading video annotations for search results: %v", err)
+	}
 
 	// Calculate total pages
 	totalPages := total / req.PageSize
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"comment-review-platform/internal/config"
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"comment-review-platform/pkg/r2"
+	"comment-review-platform/pkg/thumbnail"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	thumbnailExtractTimeout = 2 * time.Minute
+	thumbnailURLExpiration  = 1 * time.Hour
+	posterMaxSize           = 480
+	keyframeStripHeight     = 120
+)
+
+// ThumbnailService renders a poster frame and a keyframe strip for each video,
+// stores them in R2 and presigns them for reviewers.
+type ThumbnailService struct {
+	videoRepo   *repository.VideoRepository
+	r2Service   *r2.R2Service
+	extractor   thumbnail.Extractor
+	prefix      string
+	stripFrames int
+}
+
+func NewThumbnailService(r2Service *r2.R2Service) *ThumbnailService {
+	cfg := config.AppConfig
+	stripFrames := cfg.KeyframeStripFrames
+	if stripFrames < 1 {
+		stripFrames = 8
+	}
+	return &ThumbnailService{
+		videoRepo:   repository.NewVideoRepository(),
+		r2Service:   r2Service,
+		extractor:   newThumbnailExtractor(cfg.ThumbnailExtractor, cfg.FFmpegPath),
+		prefix:      normalizeThumbnailPrefix(cfg.R2ThumbnailPathPrefix),
+		stripFrames: stripFrames,
+	}
+}
+
+// newOptionalThumbnailService returns nil when R2 is not configured, so
+// callers can skip thumbnails instead of failing
+func newOptionalThumbnailService() *ThumbnailService {
+	r2Service, err := r2.NewR2Service()
+	if err != nil {
+		return nil
+	}
+	return NewThumbnailService(r2Service)
+}
+
+// newThumbnailExtractor builds the extractor chain. "auto" prefers ffmpeg when
+// it is installed and always falls back to the pure-Go embedded extractor.
+func newThumbnailExtractor(mode, ffmpegPath string) thumbnail.Extractor {
+	ffmpeg := thumbnail.FFmpeg{Path: ffmpegPath}
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "embedded":
+		return thumbnail.Embedded{}
+	case "ffmpeg":
+		return thumbnail.Chain{ffmpeg, thumbnail.Embedded{}}
+	default:
+		if ffmpeg.Available() {
+			return thumbnail.Chain{ffmpeg, thumbnail.Embedded{}}
+		}
+		return thumbnail.Embedded{}
+	}
+}
+
+// Generate extracts the poster and keyframe strip of a video, uploads them and
+// records their keys on the video
+func (s *ThumbnailService) Generate(video *models.TikTokVideo) error {
+	url, err := s.r2Service.GeneratePresignedURL(video.VideoKey, thumbnailExtractTimeout+time.Minute)
+	if err != nil {
+		return err
+	}
+	src := thumbnail.Source{
+		URL:    url,
+		Reader: s.r2Service.ObjectReaderAt(video.VideoKey),
+		Size:   video.FileSize,
+	}
+	if video.Duration != nil {
+		src.DurationSeconds = float64(*video.Duration)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), thumbnailExtractTimeout)
+	defer cancel()
+	frames, err := s.extractor.Extract(ctx, src, s.stripFrames)
+	if err != nil {
+		return err
+	}
+
+	poster, err := thumbnail.EncodeJPEG(thumbnail.Fit(frames.Poster, posterMaxSize, posterMaxSize), 82)
+	if err != nil {
+		return fmt.Errorf("encode poster: %w", err)
+	}
+	posterKey := fmt.Sprintf("%s%d/poster.jpg", s.prefix, video.ID)
+	if err := s.r2Service.UploadObject(context.Background(), posterKey, poster, "image/jpeg"); err != nil {
+		return err
+	}
+
+	// A strip of a single frame adds nothing over the poster
+	var stripKey *string
+	if len(frames.Keyframes) > 1 {
+		strip, err := thumbnail.EncodeJPEG(thumbnail.Strip(frames.Keyframes, keyframeStripHeight, 2), 75)
+		if err != nil {
+			return fmt.Errorf("encode keyframe strip: %w", err)
+		}
+		key := fmt.Sprintf("%s%d/keyframes.jpg", s.prefix, video.ID)
+		if err := s.r2Service.UploadObject(context.Background(), key, strip, "image/jpeg"); err != nil {
+			return err
+		}
+		stripKey = &key
+	}
+
+	if err := s.videoRepo.UpdateVideoThumbnails(video.ID, posterKey, stripKey); err != nil {
+		return err
+	}
+	video.ThumbnailKey = &posterKey
+	video.KeyframeStripKey = stripKey
+	return nil
+}
+
+// GenerateMissing generates thumbnails for up to limit videos after afterID
+// that have none yet
+func (s *ThumbnailService) GenerateMissing(afterID, limit int) (*models.GenerateThumbnailsResponse, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	videos, err := s.videoRepo.ListVideosWithoutThumbnails(afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list videos without thumbnails: %w", err)
+	}
+
+	response := &models.GenerateThumbnailsResponse{LastID: afterID, Errors: []string{}}
+	for i := range videos {
+		video := &videos[i]
+		response.LastID = video.ID
+		if err := s.Generate(video); err != nil {
+			response.FailedCount++
+			response.Errors = append(response.Errors, fmt.Sprintf("Error generating thumbnails for video %s: %v", video.Filename, err))
+			continue
+		}
+		response.GeneratedCount++
+	}
+
+	log.Printf("Thumbnail generation completed (%s): %d generated, %d failed",
+		s.extractor.Name(), response.GeneratedCount, response.FailedCount)
+	return response, nil
+}
+
+// DecorateVideo fills in presigned thumbnail URLs for a video
+func (s *ThumbnailService) DecorateVideo(video *models.TikTokVideo) {
+	if s == nil || video == nil {
+		return
+	}
+	if video.ThumbnailKey != nil && *video.ThumbnailKey != "" {
+		if url, err := s.r2Service.GeneratePresignedURL(*video.ThumbnailKey, thumbnailURLExpiration); err == nil {
+			video.ThumbnailURL = &url
+		}
+	}
+	if video.KeyframeStripKey != nil && *video.KeyframeStripKey != "" {
+		if url, err := s.r2Service.GeneratePresignedURL(*video.KeyframeStripKey, thumbnailURLExpiration); err == nil {
+			video.KeyframeStripURL = &url
+		}
+	}
+}
+
+func normalizeThumbnailPrefix(prefix string) string {
+	cleaned := strings.TrimPrefix(strings.TrimSpace(prefix), "/")
+	if cleaned == "" {
+		cleaned = "video-thumbnails/"
+	}
+	if !strings.HasSuffix(cleaned, "/") {
+		cleaned += "/"
+	}
+	return cleaned
+}
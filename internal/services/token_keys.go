@@ -0,0 +1,44 @@
+package services
+
+import (
+	"comment-review-platform/internal/config"
+	jwtpkg "comment-review-platform/pkg/jwt"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// insecureDefaultJWTSecret is the placeholder secret older deployments shipped with.
+const insecureDefaultJWTSecret = "your-secret-key-change-this"
+
+var tokenKeys *jwtpkg.KeySet
+
+// InitTokenKeys loads the JWT signing and verification keys. It refuses to
+// start with the placeholder secret or with no usable signing key at all.
+func InitTokenKeys(cfg *config.Config) (*jwtpkg.KeySet, error) {
+	if cfg.JWTSecret == insecureDefaultJWTSecret {
+		return nil, errors.New("JWT_SECRET is set to the insecure default value; configure a real secret or remove it and use JWT_KEYS_DIR")
+	}
+
+	keys, err := jwtpkg.LoadKeySet(cfg.JWTKeysDir, cfg.JWTActiveKeyID, cfg.JWTSecret)
+	if err != nil {
+		return nil, fmt.Errorf("load JWT keys: %w", err)
+	}
+	if !keys.CanSign() {
+		return nil, errors.New("no JWT signing key configured; set JWT_KEYS_DIR (RS256/EdDSA) or JWT_SECRET (HS256)")
+	}
+
+	if kid := keys.ActiveKeyID(); kid != "" {
+		log.Printf("✅ JWT signing key loaded (kid=%s, %d verification key(s))", kid, len(keys.JWKS().Keys))
+	} else {
+		log.Println("⚠️  JWT tokens are signed with HS256; configure JWT_KEYS_DIR to use asymmetric keys")
+	}
+
+	tokenKeys = keys
+	return keys, nil
+}
+
+// TokenKeys returns the key set loaded by InitTokenKeys.
+func TokenKeys() *jwtpkg.KeySet {
+	return tokenKeys
+}
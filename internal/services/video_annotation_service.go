@@ -0,0 +1,138 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+const maxVideoAnnotationsPerReview = 50
+
+// VideoAnnotationService validates the time-ranged annotations reviewers
+// attach to a video review and joins them onto tasks for later reviewers.
+type VideoAnnotationService struct {
+	repo      *repository.VideoAnnotationRepository
+	videoRepo *repository.VideoRepository
+}
+
+func NewVideoAnnotationService() *VideoAnnotationService {
+	return &VideoAnnotationService{
+		repo:      repository.NewVideoAnnotationRepository(),
+		videoRepo: repository.NewVideoRepository(),
+	}
+}
+
+// Validate checks annotations against the video's duration. It is a no-op
+// without annotations, so reviews without any skip the video lookup.
+func (s *VideoAnnotationService) Validate(videoID int, annotations []models.VideoAnnotationInput) error {
+	if len(annotations) == 0 {
+		return nil
+	}
+	video, err := s.videoRepo.GetVideoByID(videoID)
+	if err != nil {
+		return fmt.Errorf("load video for annotations: %w", err)
+	}
+	return validateVideoAnnotations(annotations, video.Duration)
+}
+
+// CreateTx stores a review's annotations within the submit transaction
+func (s *VideoAnnotationService) CreateTx(ctx context.Context, tx *sql.Tx, videoID int, stage string, taskID, reviewerID int, annotations []models.VideoAnnotationInput) error {
+	return s.repo.CreateAnnotationsTx(ctx, tx, videoID, stage, taskID, reviewerID, annotations)
+}
+
+// Create stores a review's annotations
+func (s *VideoAnnotationService) Create(videoID int, stage string, taskID, reviewerID int, annotations []models.VideoAnnotationInput) error {
+	return s.repo.CreateAnnotations(videoID, stage, taskID, reviewerID, annotations)
+}
+
+// AttachToSecondReviewTasks adds the first reviewer's annotations to each
+// task's first review result
+func (s *VideoAnnotationService) AttachToSecondReviewTasks(tasks []models.VideoSecondReviewTask) error {
+	taskIDs := []int{}
+	for _, task := range tasks {
+		if task.FirstReviewResult != nil {
+			taskIDs = append(taskIDs, task.FirstReviewResult.TaskID)
+		}
+	}
+	byTask, err := s.repo.ListByTasks(models.VideoAnnotationStageFirstReview, taskIDs)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		if result := tasks[i].FirstReviewResult; result != nil {
+			result.Annotations = byTask[result.TaskID]
+		}
+	}
+	return nil
+}
+
+// AttachToQueueTasks adds every earlier review's annotations of the video to
+// each queue task, so later pools see what lower pools flagged
+func (s *VideoAnnotationService) AttachToQueueTasks(tasks []models.VideoQueueTask) error {
+	videoIDs := make([]int, 0, len(tasks))
+	for _, task := range tasks {
+		videoIDs = append(videoIDs, task.VideoID)
+	}
+	byVideo, err := s.repo.ListByVideos(videoIDs)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		tasks[i].Annotations = byVideo[tasks[i].VideoID]
+	}
+	return nil
+}
+
+// AttachToSearchResults adds each video review task's own annotations
+func (s *VideoAnnotationService) AttachToSearchResults(results []models.TaskSearchResult) error {
+	stages := map[string]string{
+		"video_first_review":  models.VideoAnnotationStageFirstReview,
+		"video_second_review": models.VideoAnnotationStageSecondReview,
+		"video_queue":         models.VideoAnnotationStageQueue,
+	}
+	taskIDs := map[string][]int{}
+	for _, result := range results {
+		if stage, ok := stages[result.QueueName]; ok {
+			taskIDs[stage] = append(taskIDs[stage], result.ID)
+		}
+	}
+	for stage, ids := range taskIDs {
+		byTask, err := s.repo.ListByTasks(stage, ids)
+		if err != nil {
+			return err
+		}
+		for i := range results {
+			if stages[results[i].QueueName] == stage {
+				results[i].Annotations = byTask[results[i].ID]
+			}
+		}
+	}
+	return nil
+}
+
+// validateVideoAnnotations checks each range is well-formed and, when the
+// duration is known, lies within the video
+func validateVideoAnnotations(annotations []models.VideoAnnotationInput, durationSeconds *int) error {
+	if len(annotations) > maxVideoAnnotationsPerReview {
+		return fmt.Errorf("maximum %d annotations allowed", maxVideoAnnotationsPerReview)
+	}
+	for i, a := range annotations {
+		if strings.TrimSpace(a.Tag) == "" {
+			return fmt.Errorf("annotation %d: tag is required", i+1)
+		}
+		if a.StartSeconds < 0 {
+			return fmt.Errorf("annotation %d: start_seconds must not be negative", i+1)
+		}
+		if a.EndSeconds < a.StartSeconds {
+			return fmt.Errorf("annotation %d: end_seconds must not be before start_seconds", i+1)
+		}
+		// Durations are stored in whole seconds, so allow the fractional remainder
+		if durationSeconds != nil && *durationSeconds > 0 && a.EndSeconds > float64(*durationSeconds+1) {
+			return fmt.Errorf("annotation %d: ends at %.1fs, after the end of the %ds video", i+1, a.EndSeconds, *durationSeconds)
+		}
+	}
+	return nil
+}
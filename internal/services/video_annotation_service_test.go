@@ -0,0 +1,51 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestValidateVideoAnnotations(t *testing.T) {
+	duration := 30
+	unknown := 0
+
+	tooMany := make([]models.VideoAnnotationInput, maxVideoAnnotationsPerReview+1)
+	for i := range tooMany {
+		tooMany[i] = models.VideoAnnotationInput{StartSeconds: 1, EndSeconds: 2, Tag: "violence"}
+	}
+
+	tests := []struct {
+		name        string
+		annotations []models.VideoAnnotationInput
+		duration    *int
+		wantErr     string
+	}{
+		{"empty", nil, &duration, ""},
+		{"range", []models.VideoAnnotationInput{{StartSeconds: 12.5, EndSeconds: 14, Tag: "violence"}}, &duration, ""},
+		{"point", []models.VideoAnnotationInput{{StartSeconds: 3, EndSeconds: 3, Tag: "logo"}}, &duration, ""},
+		{"fractional last second", []models.VideoAnnotationInput{{StartSeconds: 29, EndSeconds: 30.6, Tag: "logo"}}, &duration, ""},
+		{"unknown duration", []models.VideoAnnotationInput{{StartSeconds: 100, EndSeconds: 120, Tag: "logo"}}, nil, ""},
+		{"zero duration", []models.VideoAnnotationInput{{StartSeconds: 100, EndSeconds: 120, Tag: "logo"}}, &unknown, ""},
+		{"blank tag", []models.VideoAnnotationInput{{StartSeconds: 1, EndSeconds: 2, Tag: "  "}}, &duration, "tag is required"},
+		{"negative start", []models.VideoAnnotationInput{{StartSeconds: -1, EndSeconds: 2, Tag: "logo"}}, &duration, "must not be negative"},
+		{"reversed", []models.VideoAnnotationInput{{StartSeconds: 5, EndSeconds: 4, Tag: "logo"}}, &duration, "before start_seconds"},
+		{"past end", []models.VideoAnnotationInput{{StartSeconds: 10, EndSeconds: 45, Tag: "logo"}}, &duration, "after the end"},
+		{"too many", tooMany, &duration, "maximum"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateVideoAnnotations(tt.annotations, tt.duration)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
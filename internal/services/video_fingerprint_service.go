@@ -0,0 +1,309 @@
+package services
+
+import (
+	"comment-review-platform/internal/config"
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"comment-review-platform/pkg/mp4"
+	"comment-review-platform/pkg/phash"
+	"comment-review-platform/pkg/r2"
+	"comment-review-platform/pkg/thumbnail"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"math/bits"
+	"strings"
+	"time"
+)
+
+const (
+	videoFingerprintFrames    = 8
+	videoFingerprintMinFrames = 3
+	// videoFrameMaxDistance is how many of a frame hash's 64 bits may differ
+	// for two frames to count as the same picture; it must stay below
+	// phash.Bands for the band index to find every such pair
+	videoFrameMaxDistance    = 10
+	videoDuplicateCandidates = 200
+	videoFingerprintTimeout  = 2 * time.Minute
+)
+
+// Duplicate policies (VIDEO_DUPLICATE_POLICY)
+const (
+	videoDuplicatePolicyFlag    = "flag"    // link duplicates and show reviewers the earlier result
+	videoDuplicatePolicyInherit = "inherit" // also apply the earlier decision once it exists
+	videoDuplicatePolicyOff     = "off"
+)
+
+// VideoFingerprintService fingerprints imported videos, links re-uploads to
+// the earliest import of the same clip and, under the inherit policy, applies
+// the earlier decision to them instead of reviewing them again.
+type VideoFingerprintService struct {
+	repo              *repository.VideoFingerprintRepository
+	r2Service         *r2.R2Service
+	extractor         thumbnail.Extractor
+	policy            string
+	minSimilarity     float64
+	inheritSimilarity float64
+}
+
+// NewVideoFingerprintService builds the service. r2Service may be nil for
+// callers that only read duplicates.
+func NewVideoFingerprintService(r2Service *r2.R2Service) *VideoFingerprintService {
+	cfg := config.AppConfig
+	minSimilarity := cfg.VideoDuplicateMinSimilarity
+	if minSimilarity <= 0 || minSimilarity > 1 {
+		minSimilarity = 0.8
+	}
+	inheritSimilarity := cfg.VideoDuplicateInheritSimilarity
+	if inheritSimilarity < minSimilarity || inheritSimilarity > 1 {
+		inheritSimilarity = max(minSimilarity, 0.95)
+	}
+	return &VideoFingerprintService{
+		repo:              repository.NewVideoFingerprintRepository(),
+		r2Service:         r2Service,
+		extractor:         newThumbnailExtractor(cfg.ThumbnailExtractor, cfg.FFmpegPath),
+		policy:            normalizeVideoDuplicatePolicy(cfg.VideoDuplicatePolicy),
+		minSimilarity:     minSimilarity,
+		inheritSimilarity: inheritSimilarity,
+	}
+}
+
+// Enabled reports whether duplicate detection is switched on
+func (s *VideoFingerprintService) Enabled() bool {
+	return s.policy != videoDuplicatePolicyOff
+}
+
+// Process fingerprints a video and links it to, or from, the matching earlier
+// import. It returns the duplicate link of the later video of the pair, nil
+// when no duplicate was found.
+func (s *VideoFingerprintService) Process(video *models.TikTokVideo) (*models.VideoDuplicate, error) {
+	fp, err := s.fingerprint(video)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveFingerprint(fp); err != nil {
+		return nil, fmt.Errorf("save fingerprint: %w", err)
+	}
+
+	candidates, err := s.repo.FindCandidates(fp, videoDuplicateCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("find duplicate candidates: %w", err)
+	}
+	var best *models.VideoFingerprint
+	bestMethod, bestSimilarity := "", 0.0
+	for i := range candidates {
+		method, similarity := compareVideoFingerprints(fp, &candidates[i])
+		if similarity > bestSimilarity {
+			best, bestMethod, bestSimilarity = &candidates[i], method, similarity
+		}
+	}
+	if best == nil || bestSimilarity < s.minSimilarity {
+		return nil, nil
+	}
+
+	// The earlier import is the original, whichever of the pair was just
+	// fingerprinted; backfills can meet the newer video first.
+	originalID, duplicateID := best.VideoID, video.ID
+	if originalID > duplicateID {
+		originalID, duplicateID = duplicateID, originalID
+	}
+	if rootID, ok, err := s.repo.GetOriginalVideoID(originalID); err != nil {
+		return nil, err
+	} else if ok {
+		originalID = rootID
+	}
+
+	duplicate := &models.VideoDuplicate{
+		VideoID:         duplicateID,
+		OriginalVideoID: originalID,
+		Method:          bestMethod,
+		Similarity:      bestSimilarity,
+	}
+	created, err := s.repo.CreateDuplicate(duplicate)
+	if err != nil {
+		return nil, fmt.Errorf("record duplicate: %w", err)
+	}
+	if !created {
+		return nil, nil
+	}
+	log.Printf("Video %d duplicates video %d (%s, similarity %.2f)", duplicateID, originalID, bestMethod, bestSimilarity)
+	return duplicate, nil
+}
+
+// InheritDecision applies the original's decided status to one duplicate
+// under the inherit policy; otherwise it does nothing
+func (s *VideoFingerprintService) InheritDecision(videoID int) (bool, error) {
+	if s.policy != videoDuplicatePolicyInherit {
+		return false, nil
+	}
+	inherited, err := s.repo.InheritDecision(videoID, s.inheritSimilarity)
+	if err != nil {
+		return false, err
+	}
+	if inherited {
+		log.Printf("Video duplicate %d inherited its original's decision", videoID)
+	}
+	return inherited, nil
+}
+
+// InheritDecisions applies decided originals to all their unclaimed
+// duplicates under the inherit policy; otherwise it does nothing
+func (s *VideoFingerprintService) InheritDecisions() (int, error) {
+	if s.policy != videoDuplicatePolicyInherit {
+		return 0, nil
+	}
+	videoIDs, err := s.repo.ListInheritableDuplicates(s.inheritSimilarity)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, videoID := range videoIDs {
+		inherited, err := s.InheritDecision(videoID)
+		if err != nil {
+			// One invalid transition must not hold up the other duplicates
+			log.Printf("Error inheriting decision for video duplicate %d: %v", videoID, err)
+			continue
+		}
+		if inherited {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// AttachToVideos sets the duplicate link on videos that re-upload an earlier one
+func (s *VideoFingerprintService) AttachToVideos(videos []*models.TikTokVideo) error {
+	videoIDs := make([]int, 0, len(videos))
+	for _, video := range videos {
+		if video != nil {
+			videoIDs = append(videoIDs, video.ID)
+		}
+	}
+	byVideo, err := s.repo.ListByVideos(videoIDs)
+	if err != nil {
+		return err
+	}
+	for _, video := range videos {
+		if video != nil {
+			video.Duplicate = byVideo[video.ID]
+		}
+	}
+	return nil
+}
+
+// FingerprintMissing fingerprints up to limit videos after afterID that have
+// no fingerprint yet, e.g. videos imported before detection existed
+func (s *VideoFingerprintService) FingerprintMissing(afterID, limit int) (*models.FingerprintVideosResponse, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	videos, err := s.repo.ListUnfingerprintedVideos(afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list videos without fingerprints: %w", err)
+	}
+
+	response := &models.FingerprintVideosResponse{LastID: afterID, Errors: []string{}}
+	for i := range videos {
+		video := &videos[i]
+		response.LastID = video.ID
+		duplicate, err := s.Process(video)
+		if err != nil {
+			response.FailedCount++
+			response.Errors = append(response.Errors, fmt.Sprintf("Error fingerprinting video %s: %v", video.Filename, err))
+			continue
+		}
+		response.FingerprintedCount++
+		if duplicate != nil {
+			response.DuplicateCount++
+		}
+	}
+
+	if response.InheritedCount, err = s.InheritDecisions(); err != nil {
+		log.Printf("Error inheriting video duplicate decisions: %v", err)
+	}
+
+	log.Printf("Video fingerprinting completed: %d fingerprinted, %d duplicates, %d failed",
+		response.FingerprintedCount, response.DuplicateCount, response.FailedCount)
+	return response, nil
+}
+
+// fingerprint hashes frames sampled over the video and its container. Frames
+// are preferred; the container hash only catches byte-identical streams.
+func (s *VideoFingerprintService) fingerprint(video *models.TikTokVideo) (*models.VideoFingerprint, error) {
+	if s.r2Service == nil {
+		return nil, errors.New("R2 is not configured")
+	}
+	fp := &models.VideoFingerprint{VideoID: video.ID}
+	reader := s.r2Service.ObjectReaderAt(video.VideoKey)
+
+	var containerErr error
+	if hash, err := mp4.ContentHash(reader, video.FileSize); err == nil {
+		fp.ContainerHash = &hash
+	} else {
+		containerErr = err
+	}
+
+	url, err := s.r2Service.GeneratePresignedURL(video.VideoKey, videoFingerprintTimeout+time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	src := thumbnail.Source{URL: url, Reader: reader, Size: video.FileSize}
+	if video.Duration != nil {
+		src.DurationSeconds = float64(*video.Duration)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), videoFingerprintTimeout)
+	defer cancel()
+	frames, framesErr := s.extractor.Extract(ctx, src, videoFingerprintFrames)
+	if framesErr == nil {
+		fp.FrameHashes = informativeFrameHashes(frames.Keyframes)
+	}
+
+	switch {
+	case len(fp.FrameHashes) >= videoFingerprintMinFrames:
+		fp.Method = models.VideoFingerprintFrames
+	case fp.ContainerHash != nil:
+		fp.Method = models.VideoFingerprintContainer
+	default:
+		return nil, fmt.Errorf("no usable frames (%v) or container hash (%v)", framesErr, containerErr)
+	}
+	return fp, nil
+}
+
+// informativeFrameHashes hashes frames, dropping near-uniform ones such as
+// black or white fades, whose hashes match every other fade
+func informativeFrameHashes(frames []image.Image) []uint64 {
+	hashes := make([]uint64, 0, len(frames))
+	for _, frame := range frames {
+		hash := phash.Hash(frame)
+		if ones := bits.OnesCount64(hash); ones <= 4 || ones >= 60 {
+			continue
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+// compareVideoFingerprints returns the strongest evidence that two videos are
+// the same clip: an identical container hash, else their frame similarity
+func compareVideoFingerprints(a, b *models.VideoFingerprint) (string, float64) {
+	if a.ContainerHash != nil && b.ContainerHash != nil && *a.ContainerHash == *b.ContainerHash {
+		return models.VideoFingerprintContainer, 1
+	}
+	if len(a.FrameHashes) < videoFingerprintMinFrames || len(b.FrameHashes) < videoFingerprintMinFrames {
+		return "", 0
+	}
+	return models.VideoFingerprintFrames, phash.Similarity(a.FrameHashes, b.FrameHashes, videoFrameMaxDistance)
+}
+
+func normalizeVideoDuplicatePolicy(policy string) string {
+	switch strings.ToLower(strings.TrimSpace(policy)) {
+	case videoDuplicatePolicyOff, "disabled", "none":
+		return videoDuplicatePolicyOff
+	case videoDuplicatePolicyInherit:
+		return videoDuplicatePolicyInherit
+	default:
+		return videoDuplicatePolicyFlag
+	}
+}
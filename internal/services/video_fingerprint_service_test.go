@@ -0,0 +1,90 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/pkg/phash"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCompareVideoFingerprints(t *testing.T) {
+	hash := "abc123"
+	other := "def456"
+	frames := []uint64{0x0f0f00ff00ff0f0f, 0x00ffff0000ffff00, 0x0123456789abcdef, 0x5555aaaa5555aaaa}
+
+	tests := []struct {
+		name           string
+		a, b           models.VideoFingerprint
+		wantMethod     string
+		wantSimilarity float64
+	}{
+		{
+			name:           "same container",
+			a:              models.VideoFingerprint{ContainerHash: &hash},
+			b:              models.VideoFingerprint{ContainerHash: &hash, FrameHashes: frames},
+			wantMethod:     models.VideoFingerprintContainer,
+			wantSimilarity: 1,
+		},
+		{
+			name:           "re-encoded frames",
+			a:              models.VideoFingerprint{ContainerHash: &hash, FrameHashes: frames},
+			b:              models.VideoFingerprint{ContainerHash: &other, FrameHashes: []uint64{frames[0] ^ 0x3, frames[1], frames[2] ^ 0x10, frames[3]}},
+			wantMethod:     models.VideoFingerprintFrames,
+			wantSimilarity: 1,
+		},
+		{
+			name: "too few frames",
+			a:    models.VideoFingerprint{FrameHashes: frames[:2]},
+			b:    models.VideoFingerprint{FrameHashes: frames[:2]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, similarity := compareVideoFingerprints(&tt.a, &tt.b)
+			if method != tt.wantMethod || similarity != tt.wantSimilarity {
+				t.Fatalf("got %q %.2f, want %q %.2f", method, similarity, tt.wantMethod, tt.wantSimilarity)
+			}
+		})
+	}
+}
+
+func TestBandIndexCoversFrameMaxDistance(t *testing.T) {
+	if videoFrameMaxDistance >= phash.Bands {
+		t.Fatalf("frames %d bits apart may share no band with %d bands", videoFrameMaxDistance, phash.Bands)
+	}
+}
+
+func TestInformativeFrameHashesSkipsUniformFrames(t *testing.T) {
+	black := image.NewGray(image.Rect(0, 0, 32, 32))
+	ramp := image.NewGray(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			v := uint8(x * 8)
+			if (x/4+y/4)%2 == 0 {
+				v = 255 - v
+			}
+			ramp.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+
+	hashes := informativeFrameHashes([]image.Image{black, ramp})
+	if len(hashes) != 1 {
+		t.Fatalf("got %d hashes, want only the textured frame", len(hashes))
+	}
+}
+
+func TestNormalizeVideoDuplicatePolicy(t *testing.T) {
+	for input, want := range map[string]string{
+		"":          videoDuplicatePolicyFlag,
+		"flag":      videoDuplicatePolicyFlag,
+		" Inherit ": videoDuplicatePolicyInherit,
+		"off":       videoDuplicatePolicyOff,
+		"bogus":     videoDuplicatePolicyFlag,
+	} {
+		if got := normalizeVideoDuplicatePolicy(input); got != want {
+			t.Errorf("normalizeVideoDuplicatePolicy(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"comment-review-platform/pkg/r2"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	videoImportPageSize = 200
+	// videoImportStaleAfter is how long a running job may go without a
+	// heartbeat before another worker takes it over.
+	videoImportStaleAfter = 5 * time.Minute
+	// videoImportHeartbeatInterval keeps a job alive while a single item
+	// (probe, thumbnails, fingerprint) runs for minutes
+	videoImportHeartbeatInterval = time.Minute
+)
+
+var ErrVideoImportJobNotFound = errors.New("import job not found")
+
+// VideoImportService imports videos from an R2 prefix as persisted background
+// jobs. Progress is saved after every item, so a cancelled, failed or
+// interrupted job resumes from the last handled key instead of starting over.
+type VideoImportService struct {
+	repo         videoImportJobStore
+	videoRepo    *repository.VideoRepository
+	r2Service    *r2.R2Service
+	lister       videoLister
+	thumbnails   *ThumbnailService
+	fingerprints *VideoFingerprintService
+	// importItem handles one listed key; importVideo outside tests
+	importItem func(video r2.VideoMetadata) (bool, error)
+}
+
+// videoImportJobStore persists import jobs and their leases; implemented by
+// *repository.VideoImportRepository
+type videoImportJobStore interface {
+	CreateJob(job *models.VideoImportJob) error
+	GetJobByID(id int) (*models.VideoImportJob, error)
+	ListJobs(page, pageSize int) ([]models.VideoImportJob, int, error)
+	UpdateJobStatus(jobID int, status string, allowed []string, lastError *string, completedAt *time.Time) (bool, error)
+	ClaimJob(jobID int) (lease int64, claimed bool, err error)
+	RecordProgress(jobID int, lease int64, cursor string, listed, imported, skipped, failed int) (string, error)
+	Heartbeat(jobID int, lease int64) error
+	FinishJob(jobID int, lease int64, status string, lastError *string, completedAt time.Time) (bool, error)
+	ResetStaleJobs(staleAfter time.Duration) ([]int, error)
+	CreateJobError(jobID int, videoKey, message string) error
+	ListJobErrors(jobID, page, pageSize int) ([]models.VideoImportJobError, int, error)
+}
+
+// videoLister lists the objects of an import prefix page by page
+type videoLister interface {
+	ListVideosPage(prefix, startAfter string, maxKeys int32) (*r2.VideoPage, error)
+}
+
+func NewVideoImportService() (*VideoImportService, error) {
+	r2Service, err := r2.NewR2Service()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize R2 service: %w", err)
+	}
+
+	s := &VideoImportService{
+		repo:         repository.NewVideoImportRepository(),
+		videoRepo:    repository.NewVideoRepository(),
+		r2Service:    r2Service,
+		lister:       r2Service,
+		thumbnails:   NewThumbnailService(r2Service),
+		fingerprints: NewVideoFingerprintService(r2Service),
+	}
+	s.importItem = s.importVideo
+	return s, nil
+}
+
+// CreateJob records an import job for the prefix and starts it in the background
+func (s *VideoImportService) CreateJob(r2PathPrefix string, createdBy int) (*models.VideoImportJob, error) {
+	prefix := strings.TrimSpace(r2PathPrefix)
+	if prefix == "" {
+		return nil, errors.New("r2_path_prefix is required")
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	// Fail fast on bad credentials instead of creating a job that fails at once
+	if err := s.r2Service.CheckConnection(); err != nil {
+		return nil, fmt.Errorf("R2 connection failed: %w", err)
+	}
+
+	job := &models.VideoImportJob{
+		R2PathPrefix: prefix,
+		Status:       "pending",
+		CreatedBy:    &createdBy,
+	}
+	if err := s.repo.CreateJob(job); err != nil {
+		return nil, err
+	}
+
+	go s.runJob(job.ID)
+	return job, nil
+}
+
+func (s *VideoImportService) GetJob(jobID int) (*models.VideoImportJob, error) {
+	job, err := s.repo.GetJobByID(jobID)
+	if err == sql.ErrNoRows {
+		return nil, ErrVideoImportJobNotFound
+	}
+	return job, err
+}
+
+func (s *VideoImportService) ListJobs(req models.ListVideoImportJobsRequest) (*models.ListVideoImportJobsResponse, error) {
+	page, pageSize := normalizeVideoImportPage(req.Page, req.PageSize)
+	jobs, total, err := s.repo.ListJobs(page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ListVideoImportJobsResponse{
+		Data:       jobs,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}, nil
+}
+
+func (s *VideoImportService) ListJobErrors(jobID, page, pageSize int) (*models.ListVideoImportJobErrorsResponse, error) {
+	if _, err := s.GetJob(jobID); err != nil {
+		return nil, err
+	}
+	page, pageSize = normalizeVideoImportPage(page, pageSize)
+	items, total, err := s.repo.ListJobErrors(jobID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ListVideoImportJobErrorsResponse{
+		Data:       items,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}, nil
+}
+
+// CancelJob stops a pending or running job. A running worker notices at its
+// next progress update, so at most one more item is handled.
+func (s *VideoImportService) CancelJob(jobID int) (*models.VideoImportJob, error) {
+	if _, err := s.GetJob(jobID); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	updated, err := s.repo.UpdateJobStatus(jobID, "canceled", []string{"pending", "running"}, nil, &now)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, errors.New("only pending or running jobs can be canceled")
+	}
+	return s.GetJob(jobID)
+}
+
+// ResumeJob restarts a canceled or failed job from its cursor
+func (s *VideoImportService) ResumeJob(jobID int) (*models.VideoImportJob, error) {
+	if _, err := s.GetJob(jobID); err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.UpdateJobStatus(jobID, "pending", []string{"canceled", "failed"}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, errors.New("only canceled or failed jobs can be resumed")
+	}
+
+	go s.runJob(jobID)
+	return s.GetJob(jobID)
+}
+
+// ResumeStaleJobs restarts jobs no worker is handling: running jobs whose
+// worker stopped sending heartbeats and pending jobs that were never started,
+// e.g. because the process was restarted
+func (s *VideoImportService) ResumeStaleJobs() error {
+	jobIDs, err := s.repo.ResetStaleJobs(videoImportStaleAfter)
+	if err != nil {
+		return err
+	}
+	for _, jobID := range jobIDs {
+		log.Printf("Resuming interrupted video import job %d", jobID)
+		go s.runJob(jobID)
+	}
+	return nil
+}
+
+func (s *VideoImportService) runJob(jobID int) {
+	lease, claimed, err := s.repo.ClaimJob(jobID)
+	if err != nil {
+		log.Printf("Video import job %d start failed: %v", jobID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	job, err := s.repo.GetJobByID(jobID)
+	if err != nil {
+		log.Printf("Video import job %d load failed: %v", jobID, err)
+		return
+	}
+
+	cursor := ""
+	if job.Cursor != nil {
+		cursor = *job.Cursor
+	}
+
+	stopHeartbeat := s.startHeartbeat(jobID, lease)
+	defer stopHeartbeat()
+
+	for {
+		page, err := s.lister.ListVideosPage(job.R2PathPrefix, cursor, videoImportPageSize)
+		if err != nil {
+			s.finishJob(jobID, lease, fmt.Errorf("failed to list videos from R2: %w", err))
+			return
+		}
+
+		for _, video := range page.Videos {
+			imported, skipped, failed := 0, 0, 0
+			switch created, err := s.importItem(video); {
+			case err != nil:
+				failed = 1
+				if err := s.repo.CreateJobError(jobID, video.Key, err.Error()); err != nil {
+					log.Printf("Video import job %d record error for %s failed: %v", jobID, video.Key, err)
+				}
+			case created:
+				imported = 1
+			default:
+				skipped = 1
+			}
+
+			cursor = video.Key
+			status, err := s.repo.RecordProgress(jobID, lease, cursor, 1, imported, skipped, failed)
+			if errors.Is(err, repository.ErrVideoImportLeaseLost) {
+				log.Printf("Video import job %d was taken over by another worker at %s", jobID, cursor)
+				return
+			}
+			if err != nil {
+				log.Printf("Video import job %d save progress failed: %v", jobID, err)
+				return
+			}
+			if status != "running" {
+				log.Printf("Video import job %d stopped (%s) at %s", jobID, status, cursor)
+				return
+			}
+		}
+
+		// Advance past trailing non-video keys so a resume does not list them again
+		if page.LastKey != cursor {
+			cursor = page.LastKey
+			status, err := s.repo.RecordProgress(jobID, lease, cursor, 0, 0, 0, 0)
+			if errors.Is(err, repository.ErrVideoImportLeaseLost) {
+				log.Printf("Video import job %d was taken over by another worker at %s", jobID, cursor)
+				return
+			}
+			if err != nil {
+				log.Printf("Video import job %d save progress failed: %v", jobID, err)
+				return
+			}
+			if status != "running" {
+				return
+			}
+		}
+
+		if !page.HasMore {
+			break
+		}
+	}
+
+	s.finishJob(jobID, lease, nil)
+}
+
+// startHeartbeat refreshes the job's heartbeat until the returned function is
+// called. It stops by itself once the lease is lost; the worker then notices
+// at its next progress update.
+func (s *VideoImportService) startHeartbeat(jobID int, lease int64) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(videoImportHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := s.repo.Heartbeat(jobID, lease)
+				if errors.Is(err, repository.ErrVideoImportLeaseLost) {
+					return
+				}
+				if err != nil {
+					log.Printf("Video import job %d heartbeat failed: %v", jobID, err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// importVideo creates the video and its first review task. created is false
+// when the key was already imported.
+func (s *VideoImportService) importVideo(video r2.VideoMetadata) (bool, error) {
+	// Cheap check first so re-runs over a large prefix skip the probe
+	exists, err := s.videoRepo.CheckVideoExists(video.Key)
+	if err != nil {
+		return false, fmt.Errorf("check existing video: %w", err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	tiktokVideo := &models.TikTokVideo{
+		VideoKey:   video.Key,
+		Filename:   video.Filename,
+		FileSize:   video.Size,
+		UploadTime: &video.Modified,
+		Status:     "pending",
+	}
+
+	// Probe container metadata; unsupported or damaged files are imported
+	// without it rather than with made-up numbers.
+	if metadata, err := s.r2Service.ProbeVideo(video.Key, video.Size); err != nil {
+		log.Printf("Warning: Could not probe metadata for video %s: %v", video.Filename, err)
+	} else {
+		applyVideoProbe(tiktokVideo, metadata)
+	}
+
+	created, err := s.videoRepo.CreateVideo(tiktokVideo)
+	if err != nil {
+		return false, fmt.Errorf("create video: %w", err)
+	}
+
+	// Thumbnails are a convenience for reviewers; the thumbnail backfill
+	// retries videos that have none.
+	if created {
+		if err := s.thumbnails.Generate(tiktokVideo); err != nil {
+			log.Printf("Warning: Could not generate thumbnails for video %s: %v", video.Filename, err)
+		}
+	}
+
+	// Duplicate detection is best effort as well; the fingerprint backfill
+	// picks up videos it failed on. A re-upload of an already decided video
+	// inherits the decision right away under the inherit policy.
+	if created && s.fingerprints.Enabled() {
+		if duplicate, err := s.fingerprints.Process(tiktokVideo); err != nil {
+			log.Printf("Warning: Could not fingerprint video %s: %v", video.Filename, err)
+		} else if duplicate != nil {
+			if _, err := s.fingerprints.InheritDecision(duplicate.VideoID); err != nil {
+				log.Printf("Warning: Could not inherit decision for duplicate video %s: %v", video.Filename, err)
+			}
+		}
+	}
+	return created, nil
+}
+
+func (s *VideoImportService) finishJob(jobID int, lease int64, jobErr error) {
+	status := "completed"
+	var lastError *string
+	if jobErr != nil {
+		status = "failed"
+		message := jobErr.Error()
+		lastError = &message
+	}
+	finished, err := s.repo.FinishJob(jobID, lease, status, lastError, time.Now())
+	if err != nil {
+		log.Printf("Video import job %d complete failed: %v", jobID, err)
+		return
+	}
+	if !finished {
+		return
+	}
+
+	if job, err := s.repo.GetJobByID(jobID); err == nil {
+		log.Printf("Video import job %d %s: %d listed, %d imported, %d skipped, %d failed",
+			jobID, status, job.ListedCount, job.ImportedCount, job.SkippedCount, job.FailedCount)
+	}
+}
+
+func normalizeVideoImportPage(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+	return page, pageSize
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"comment-review-platform/pkg/r2"
+	"database/sql"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeVideoImportStore keeps jobs in memory with the same lease rules as the
+// video_import_jobs queries: every claim bumps the lease and writes carrying
+// an older lease are rejected.
+type fakeVideoImportStore struct {
+	mu       sync.Mutex
+	jobs     map[int]*models.VideoImportJob
+	leases   map[int]int64
+	errors   []models.VideoImportJobError
+	finished chan int
+}
+
+func newFakeVideoImportStore(jobs ...*models.VideoImportJob) *fakeVideoImportStore {
+	store := &fakeVideoImportStore{
+		jobs:     make(map[int]*models.VideoImportJob),
+		leases:   make(map[int]int64),
+		finished: make(chan int, 10),
+	}
+	for _, job := range jobs {
+		store.jobs[job.ID] = job
+	}
+	return store
+}
+
+func (s *fakeVideoImportStore) job(id int) models.VideoImportJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.jobs[id]
+}
+
+// expire makes a running job look abandoned, as after a worker crash
+func (s *fakeVideoImportStore) expire(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stale := time.Now().Add(-2 * videoImportStaleAfter)
+	s.jobs[id].HeartbeatAt = &stale
+	s.jobs[id].UpdatedAt = stale
+}
+
+func (s *fakeVideoImportStore) CreateJob(job *models.VideoImportJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.ID = len(s.jobs) + 1
+	job.CreatedAt, job.UpdatedAt = time.Now(), time.Now()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *fakeVideoImportStore) GetJobByID(id int) (*models.VideoImportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (s *fakeVideoImportStore) ListJobs(page, pageSize int) ([]models.VideoImportJob, int, error) {
+	return nil, 0, nil
+}
+
+func (s *fakeVideoImportStore) UpdateJobStatus(jobID int, status string, allowed []string, lastError *string, completedAt *time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.jobs[jobID]
+	for _, from := range allowed {
+		if job.Status == from {
+			job.Status, job.LastError, job.CompletedAt, job.HeartbeatAt = status, lastError, completedAt, nil
+			job.UpdatedAt = time.Now()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *fakeVideoImportStore) ClaimJob(jobID int) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.jobs[jobID]
+	if job.Status != "pending" {
+		return 0, false, nil
+	}
+	now := time.Now()
+	s.leases[jobID]++
+	job.Status, job.HeartbeatAt, job.UpdatedAt = "running", &now, now
+	return s.leases[jobID], true, nil
+}
+
+func (s *fakeVideoImportStore) RecordProgress(jobID int, lease int64, cursor string, listed, imported, skipped, failed int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.leases[jobID] != lease {
+		return "", repository.ErrVideoImportLeaseLost
+	}
+	job := s.jobs[jobID]
+	job.Cursor = &cursor
+	job.ListedCount += listed
+	job.ImportedCount += imported
+	job.SkippedCount += skipped
+	job.FailedCount += failed
+	job.UpdatedAt = time.Now()
+	if job.Status == "running" {
+		now := time.Now()
+		job.HeartbeatAt = &now
+	}
+	return job.Status, nil
+}
+
+func (s *fakeVideoImportStore) Heartbeat(jobID int, lease int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.leases[jobID] != lease || s.jobs[jobID].Status != "running" {
+		return repository.ErrVideoImportLeaseLost
+	}
+	now := time.Now()
+	s.jobs[jobID].HeartbeatAt = &now
+	return nil
+}
+
+func (s *fakeVideoImportStore) FinishJob(jobID int, lease int64, status string, lastError *string, completedAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.jobs[jobID]
+	if s.leases[jobID] != lease || job.Status != "running" {
+		return false, nil
+	}
+	job.Status, job.LastError, job.CompletedAt, job.HeartbeatAt = status, lastError, &completedAt, nil
+	s.finished <- jobID
+	return true, nil
+}
+
+func (s *fakeVideoImportStore) ResetStaleJobs(staleAfter time.Duration) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cutoff := time.Now().Add(-staleAfter)
+	var ids []int
+	for id, job := range s.jobs {
+		stale := job.Status == "running" && (job.HeartbeatAt == nil || job.HeartbeatAt.Before(cutoff))
+		unclaimed := job.Status == "pending" && job.UpdatedAt.Before(cutoff)
+		if stale || unclaimed {
+			job.Status, job.HeartbeatAt, job.UpdatedAt = "pending", nil, time.Now()
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func (s *fakeVideoImportStore) CreateJobError(jobID int, videoKey, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = append(s.errors, models.VideoImportJobError{JobID: jobID, VideoKey: videoKey, ErrorMessage: message})
+	return nil
+}
+
+func (s *fakeVideoImportStore) ListJobErrors(jobID, page, pageSize int) ([]models.VideoImportJobError, int, error) {
+	return nil, 0, nil
+}
+
+// fakeVideoLister lists a fixed, sorted set of keys
+type fakeVideoLister struct {
+	mu         sync.Mutex
+	keys       []string
+	startAfter []string
+}
+
+func (l *fakeVideoLister) ListVideosPage(prefix, startAfter string, maxKeys int32) (*r2.VideoPage, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.startAfter = append(l.startAfter, startAfter)
+	page := &r2.VideoPage{LastKey: startAfter}
+	for _, key := range l.keys {
+		if key <= startAfter {
+			continue
+		}
+		if len(page.Videos) == int(maxKeys) {
+			page.HasMore = true
+			break
+		}
+		page.Videos = append(page.Videos, r2.VideoMetadata{Key: key, Filename: key})
+		page.LastKey = key
+	}
+	return page, nil
+}
+
+// recordingImporter remembers the keys it imported, in order
+type recordingImporter struct {
+	mu       sync.Mutex
+	imported []string
+}
+
+func (r *recordingImporter) keys() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.imported...)
+}
+
+func (r *recordingImporter) record(video r2.VideoMetadata) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.imported = append(r.imported, video.Key)
+}
+
+func waitForFinishedJob(t *testing.T, store *fakeVideoImportStore, jobID int) {
+	t.Helper()
+	select {
+	case id := <-store.finished:
+		if id != jobID {
+			t.Fatalf("job %d finished, want %d", id, jobID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("job %d did not finish", jobID)
+	}
+}
+
+func equalKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestVideoImportStaleLeaseIsTakenOver(t *testing.T) {
+	store := newFakeVideoImportStore(&models.VideoImportJob{ID: 1, R2PathPrefix: "videos/", Status: "pending", UpdatedAt: time.Now()})
+	importer := &recordingImporter{}
+	blocked := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+
+	svc := &VideoImportService{repo: store, lister: &fakeVideoLister{keys: []string{"videos/a", "videos/b", "videos/c"}}}
+	svc.importItem = func(video r2.VideoMetadata) (bool, error) {
+		first := false
+		once.Do(func() { first = true })
+		if first {
+			// The first worker hangs on its first item long enough to miss
+			// its heartbeats
+			close(blocked)
+			<-release
+		}
+		importer.record(video)
+		return true, nil
+	}
+
+	firstWorker := make(chan struct{})
+	go func() {
+		svc.runJob(1)
+		close(firstWorker)
+	}()
+	<-blocked
+
+	store.expire(1)
+	if err := svc.ResumeStaleJobs(); err != nil {
+		t.Fatalf("ResumeStaleJobs returned error: %v", err)
+	}
+	waitForFinishedJob(t, store, 1)
+
+	close(release)
+	<-firstWorker
+
+	job := store.job(1)
+	if job.Status != "completed" {
+		t.Fatalf("status = %q, want completed", job.Status)
+	}
+	// The stale worker's late item must not be counted or move the cursor
+	if job.ImportedCount != 3 || job.ListedCount != 3 {
+		t.Fatalf("counts = %d imported, %d listed, want 3 and 3", job.ImportedCount, job.ListedCount)
+	}
+	if job.Cursor == nil || *job.Cursor != "videos/c" {
+		t.Fatalf("cursor = %v, want videos/c", job.Cursor)
+	}
+}
+
+func TestVideoImportResumesFromCursorAfterCrash(t *testing.T) {
+	cursor := "videos/b"
+	store := newFakeVideoImportStore(&models.VideoImportJob{
+		ID: 1, R2PathPrefix: "videos/", Status: "running", Cursor: &cursor,
+		ListedCount: 2, ImportedCount: 2,
+	})
+	store.leases[1] = 1
+	store.expire(1)
+
+	importer := &recordingImporter{}
+	lister := &fakeVideoLister{keys: []string{"videos/a", "videos/b", "videos/c", "videos/d"}}
+	svc := &VideoImportService{repo: store, lister: lister}
+	svc.importItem = func(video r2.VideoMetadata) (bool, error) {
+		importer.record(video)
+		return true, nil
+	}
+
+	if err := svc.ResumeStaleJobs(); err != nil {
+		t.Fatalf("ResumeStaleJobs returned error: %v", err)
+	}
+	waitForFinishedJob(t, store, 1)
+
+	if got := importer.keys(); !equalKeys(got, []string{"videos/c", "videos/d"}) {
+		t.Fatalf("imported %v, want only the keys after the cursor", got)
+	}
+	if lister.startAfter[0] != cursor {
+		t.Fatalf("listing started after %q, want %q", lister.startAfter[0], cursor)
+	}
+	job := store.job(1)
+	if job.Status != "completed" || job.ImportedCount != 4 || job.ListedCount != 4 {
+		t.Fatalf("job = %s with %d imported, %d listed; want completed with 4 and 4", job.Status, job.ImportedCount, job.ListedCount)
+	}
+}
+
+func TestVideoImportCancelDuringItemStopsAndResumes(t *testing.T) {
+	store := newFakeVideoImportStore(&models.VideoImportJob{ID: 1, R2PathPrefix: "videos/", Status: "pending", UpdatedAt: time.Now()})
+	importer := &recordingImporter{}
+	svc := &VideoImportService{repo: store, lister: &fakeVideoLister{keys: []string{"videos/a", "videos/b", "videos/c"}}}
+	svc.importItem = func(video r2.VideoMetadata) (bool, error) {
+		if video.Key == "videos/b" && store.job(1).Status == "running" {
+			if _, err := svc.CancelJob(1); err != nil {
+				t.Errorf("CancelJob returned error: %v", err)
+			}
+		}
+		importer.record(video)
+		return true, nil
+	}
+
+	svc.runJob(1)
+
+	job := store.job(1)
+	if job.Status != "canceled" || job.CompletedAt == nil {
+		t.Fatalf("job = %s (completed at %v), want canceled", job.Status, job.CompletedAt)
+	}
+	// The item in flight is still recorded so a resume does not repeat it
+	if job.Cursor == nil || *job.Cursor != "videos/b" || job.ImportedCount != 2 {
+		t.Fatalf("cursor = %v with %d imported, want videos/b with 2", job.Cursor, job.ImportedCount)
+	}
+	if got := importer.keys(); !equalKeys(got, []string{"videos/a", "videos/b"}) {
+		t.Fatalf("imported %v before stopping, want a and b", got)
+	}
+
+	if _, err := svc.ResumeJob(1); err != nil {
+		t.Fatalf("ResumeJob returned error: %v", err)
+	}
+	waitForFinishedJob(t, store, 1)
+
+	if got := importer.keys(); !equalKeys(got, []string{"videos/a", "videos/b", "videos/c"}) {
+		t.Fatalf("imported %v, want each key once", got)
+	}
+	if job := store.job(1); job.Status != "completed" || job.ImportedCount != 3 {
+		t.Fatalf("job = %s with %d imported, want completed with 3", job.Status, job.ImportedCount)
+	}
+}
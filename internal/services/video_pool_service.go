@@ -0,0 +1,305 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// videoPoolCacheTTL bounds how long another replica keeps serving a ladder
+// after an admin changed it; local changes invalidate the cache at once.
+const videoPoolCacheTTL = 30 * time.Second
+
+var (
+	ErrVideoPoolNotFound = errors.New("video pool not found")
+	ErrVideoPoolInactive = errors.New("video pool is not active")
+
+	// Pool names appear in URLs, Redis keys and permission scopes
+	videoPoolNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,9}$`)
+)
+
+// VideoPoolService serves the configured traffic-pool ladder from a short-lived
+// in-process cache so every queue request does not hit the database.
+type VideoPoolService struct {
+	repo *repository.VideoPoolRepository
+
+	mu       sync.RWMutex
+	pools    []models.VideoPool
+	byName   map[string]*models.VideoPool
+	loadedAt time.Time
+}
+
+var (
+	videoPoolService     *VideoPoolService
+	videoPoolServiceOnce sync.Once
+)
+
+// NewVideoPoolService returns the shared pool service so admin edits are seen
+// by the queue service of the same process immediately
+func NewVideoPoolService() *VideoPoolService {
+	videoPoolServiceOnce.Do(func() {
+		videoPoolService = &VideoPoolService{repo: repository.NewVideoPoolRepository()}
+	})
+	return videoPoolService
+}
+
+// ListPools returns the pools in ladder order
+func (s *VideoPoolService) ListPools(includeInactive bool) ([]models.VideoPool, error) {
+	pools, _, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]models.VideoPool, 0, len(pools))
+	for _, pool := range pools {
+		if includeInactive || pool.IsActive {
+			result = append(result, pool)
+		}
+	}
+	return result, nil
+}
+
+// GetPool returns a configured pool whether or not it is active
+func (s *VideoPoolService) GetPool(name string) (*models.VideoPool, error) {
+	_, byName, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	pool, ok := byName[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrVideoPoolNotFound, name)
+	}
+	copied := *pool
+	return &copied, nil
+}
+
+// GetActivePool returns a pool that accepts new claims
+func (s *VideoPoolService) GetActivePool(name string) (*models.VideoPool, error) {
+	pool, err := s.GetPool(name)
+	if err != nil {
+		return nil, err
+	}
+	if !pool.IsActive {
+		return nil, fmt.Errorf("%w: %s", ErrVideoPoolInactive, name)
+	}
+	return pool, nil
+}
+
+func (s *VideoPoolService) CreatePool(req models.CreateVideoPoolRequest) (*models.VideoPool, error) {
+	pools, _, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	pool := models.VideoPool{
+		Name:           strings.TrimSpace(req.Name),
+		DisplayName:    strings.TrimSpace(req.DisplayName),
+		Description:    req.Description,
+		SortOrder:      req.SortOrder,
+		NextPool:       optionalPoolField(req.NextPool),
+		TerminalStatus: optionalPoolField(req.TerminalStatus),
+		ClaimMaxCount:  req.ClaimMaxCount,
+		MaxTags:        3,
+		TagSet:         optionalPoolField(req.TagSet),
+		IsActive:       true,
+	}
+	if pool.ClaimMaxCount == 0 {
+		pool.ClaimMaxCount = 50
+	}
+	if req.MaxTags != nil {
+		pool.MaxTags = *req.MaxTags
+	}
+	if req.IsActive != nil {
+		pool.IsActive = *req.IsActive
+	}
+
+	for _, existing := range pools {
+		if existing.Name == pool.Name {
+			return nil, fmt.Errorf("video pool %s already exists", pool.Name)
+		}
+	}
+	if err := validateVideoPoolLadder(append(append([]models.VideoPool{}, pools...), pool)); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreatePool(&pool); err != nil {
+		return nil, err
+	}
+	s.Invalidate()
+	return &pool, nil
+}
+
+func (s *VideoPoolService) UpdatePool(name string, req models.UpdateVideoPoolRequest) (*models.VideoPool, error) {
+	pools, _, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	ladder := append([]models.VideoPool{}, pools...)
+	var pool *models.VideoPool
+	for i := range ladder {
+		if ladder[i].Name == name {
+			pool = &ladder[i]
+			break
+		}
+	}
+	if pool == nil {
+		return nil, fmt.Errorf("%w: %s", ErrVideoPoolNotFound, name)
+	}
+
+	if req.DisplayName != nil {
+		pool.DisplayName = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.Description != nil {
+		pool.Description = *req.Description
+	}
+	if req.SortOrder != nil {
+		pool.SortOrder = *req.SortOrder
+	}
+	if req.NextPool != nil {
+		pool.NextPool = optionalPoolField(req.NextPool)
+	}
+	if req.TerminalStatus != nil {
+		pool.TerminalStatus = optionalPoolField(req.TerminalStatus)
+	}
+	if req.ClaimMaxCount != nil {
+		pool.ClaimMaxCount = *req.ClaimMaxCount
+	}
+	if req.MaxTags != nil {
+		pool.MaxTags = *req.MaxTags
+	}
+	if req.TagSet != nil {
+		pool.TagSet = optionalPoolField(req.TagSet)
+	}
+	if req.IsActive != nil {
+		pool.IsActive = *req.IsActive
+	}
+
+	if err := validateVideoPoolLadder(ladder); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdatePool(pool); err != nil {
+		return nil, err
+	}
+	s.Invalidate()
+	updated := *pool
+	return &updated, nil
+}
+
+// Invalidate drops the cached ladder so the next read reloads it
+func (s *VideoPoolService) Invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func (s *VideoPoolService) load() ([]models.VideoPool, map[string]*models.VideoPool, error) {
+	s.mu.RLock()
+	if !s.loadedAt.IsZero() && time.Since(s.loadedAt) < videoPoolCacheTTL {
+		pools, byName := s.pools, s.byName
+		s.mu.RUnlock()
+		return pools, byName, nil
+	}
+	s.mu.RUnlock()
+
+	pools, err := s.repo.ListPools()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load video pools: %w", err)
+	}
+	byName := make(map[string]*models.VideoPool, len(pools))
+	for i := range pools {
+		byName[pools[i].Name] = &pools[i]
+	}
+
+	s.mu.Lock()
+	s.pools, s.byName, s.loadedAt = pools, byName, time.Now()
+	s.mu.Unlock()
+	return pools, byName, nil
+}
+
+// videoPoolTerminalStatus is the video status set when a video is pushed past
+// the top of its ladder
+func videoPoolTerminalStatus(pool *models.VideoPool) string {
+	if pool.TerminalStatus != nil && *pool.TerminalStatus != "" {
+		return *pool.TerminalStatus
+	}
+	return pool.Name + "_confirmed"
+}
+
+// videoPoolTagSet is the video_quality_tags.queue_id the pool reviews with
+func videoPoolTagSet(pool *models.VideoPool) string {
+	if pool.TagSet != nil && *pool.TagSet != "" {
+		return *pool.TagSet
+	}
+	return pool.Name
+}
+
+// validateVideoPoolLadder checks a whole ladder: valid names, next pools and
+// tag sets that exist, no promotion loops, and no active pool promoting into
+// an inactive one
+func validateVideoPoolLadder(pools []models.VideoPool) error {
+	byName := make(map[string]*models.VideoPool, len(pools))
+	for i := range pools {
+		pool := &pools[i]
+		if !videoPoolNamePattern.MatchString(pool.Name) {
+			return fmt.Errorf("invalid pool name %q: use up to 10 lowercase letters, digits, '-' or '_'", pool.Name)
+		}
+		if pool.DisplayName == "" {
+			return fmt.Errorf("pool %s: display_name is required", pool.Name)
+		}
+		if pool.ClaimMaxCount < 1 {
+			return fmt.Errorf("pool %s: claim_max_count must be at least 1", pool.Name)
+		}
+		if pool.MaxTags < 0 {
+			return fmt.Errorf("pool %s: max_tags must not be negative", pool.Name)
+		}
+		byName[pool.Name] = pool
+	}
+
+	for _, pool := range byName {
+		if pool.TagSet != nil {
+			if _, ok := byName[*pool.TagSet]; !ok {
+				return fmt.Errorf("pool %s: tag_set %s does not exist", pool.Name, *pool.TagSet)
+			}
+		}
+		if pool.NextPool == nil {
+			continue
+		}
+		next, ok := byName[*pool.NextPool]
+		if !ok {
+			return fmt.Errorf("pool %s: next_pool %s does not exist", pool.Name, *pool.NextPool)
+		}
+		if pool.IsActive && !next.IsActive {
+			return fmt.Errorf("pool %s promotes into inactive pool %s", pool.Name, next.Name)
+		}
+
+		seen := map[string]bool{pool.Name: true}
+		for current := next; current != nil; {
+			if seen[current.Name] {
+				return fmt.Errorf("pool %s: next_pool chain loops back to %s", pool.Name, current.Name)
+			}
+			seen[current.Name] = true
+			if current.NextPool == nil {
+				break
+			}
+			current = byName[*current.NextPool]
+		}
+	}
+	return nil
+}
+
+func optionalPoolField(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"strings"
+	"testing"
+)
+
+func testPool(name, next string, active bool) models.VideoPool {
+	pool := models.VideoPool{Name: name, DisplayName: name, ClaimMaxCount: 50, MaxTags: 3, IsActive: active}
+	if next != "" {
+		pool.NextPool = &next
+	}
+	return pool
+}
+
+func TestValidateVideoPoolLadder(t *testing.T) {
+	seeded := []models.VideoPool{testPool("100k", "1m", true), testPool("1m", "10m", true), testPool("10m", "", true)}
+	if err := validateVideoPoolLadder(seeded); err != nil {
+		t.Fatalf("seeded ladder rejected: %v", err)
+	}
+
+	withTier := append(append([]models.VideoPool{}, seeded...), testPool("50k", "100k", true))
+	if err := validateVideoPoolLadder(withTier); err != nil {
+		t.Fatalf("50k entry tier rejected: %v", err)
+	}
+
+	sea := "1m"
+	regional := testPool("1m-sea", "10m", true)
+	regional.TagSet = &sea
+	if err := validateVideoPoolLadder(append(append([]models.VideoPool{}, seeded...), regional)); err != nil {
+		t.Fatalf("regional pool sharing tags rejected: %v", err)
+	}
+
+	missingTags := "apac"
+	badTags := testPool("1m-apac", "", true)
+	badTags.TagSet = &missingTags
+
+	tests := []struct {
+		name  string
+		pools []models.VideoPool
+		want  string
+	}{
+		{"bad name", []models.VideoPool{testPool("Pool 1", "", true)}, "invalid pool name"},
+		{"name too long", []models.VideoPool{testPool("abcdefghijk", "", true)}, "invalid pool name"},
+		{"missing next pool", []models.VideoPool{testPool("100k", "1m", true)}, "does not exist"},
+		{"missing tag set", []models.VideoPool{badTags}, "tag_set"},
+		{"loop", []models.VideoPool{testPool("a", "b", true), testPool("b", "c", true), testPool("c", "a", true)}, "loops back"},
+		{"inactive next", []models.VideoPool{testPool("a", "b", true), testPool("b", "", false)}, "inactive pool b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateVideoPoolLadder(tt.pools)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+
+	// An inactive pool may still point at an active one
+	if err := validateVideoPoolLadder([]models.VideoPool{testPool("old", "b", false), testPool("b", "", true)}); err != nil {
+		t.Fatalf("inactive pool into active pool rejected: %v", err)
+	}
+}
+
+func TestVideoPoolTerminalStatusAndTagSet(t *testing.T) {
+	top := testPool("10m", "", true)
+	if got := videoPoolTerminalStatus(&top); got != "10m_confirmed" {
+		t.Fatalf("default terminal status = %q", got)
+	}
+	status := "sea_confirmed"
+	top.TerminalStatus = &status
+	if got := videoPoolTerminalStatus(&top); got != status {
+		t.Fatalf("terminal status = %q, want %q", got, status)
+	}
+
+	if got := videoPoolTagSet(&top); got != "10m" {
+		t.Fatalf("default tag set = %q", got)
+	}
+	shared := "1m"
+	top.TagSet = &shared
+	if got := videoPoolTagSet(&top); got != "1m" {
+		t.Fatalf("tag set = %q, want 1m", got)
+	}
+}
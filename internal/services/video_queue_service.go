@@ -4,8 +4,10 @@ import (
 	"comment-review-platform/internal/config"
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/repository"
+	"comment-review-platform/pkg/database"
 	redispkg "comment-review-platform/pkg/redis"
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log"
@@ -16,38 +18,47 @@ import (
 )
 
 type VideoQueueService struct {
-	queueRepo *repository.VideoQueueRepository
-	rdb       *redis.Client
-	ctx       context.Context
+	queueRepo    *repository.VideoQueueRepository
+	pools        *VideoPoolService
+	annotations  *VideoAnnotationService
+	thumbnails   *ThumbnailService
+	fingerprints *VideoFingerprintService
+	rdb          *redis.Client
+	ctx          context.Context
 }
 
 func NewVideoQueueService() *VideoQueueService {
 	return &VideoQueueService{
-		queueRepo: repository.NewVideoQueueRepository(),
-		rdb:       redispkg.Client,
-		ctx:       context.Background(),
+		queueRepo:    repository.NewVideoQueueRepository(),
+		pools:        NewVideoPoolService(),
+		annotations:  NewVideoAnnotationService(),
+		thumbnails:   newOptionalThumbnailService(),
+		fingerprints: NewVideoFingerprintService(nil),
+		rdb:          redispkg.Client,
+		ctx:          context.Background(),
 	}
 }
 
 // ClaimTasks allows a reviewer to claim tasks from a specific pool
-func (s *VideoQueueService) ClaimTasks(pool string, reviewerID int, count int) ([]models.VideoQueueTask, error) {
+func (s *VideoQueueService) ClaimTasks(ctx context.Context, pool string, reviewerID int, count int) ([]models.VideoQueueTask, error) {
 	log.Printf("📋 [DEBUG] ClaimTasks START: pool=%s, reviewerID=%d, count=%d", pool, reviewerID, count)
 
 	// Validate pool
 	log.Printf("📋 [DEBUG] ClaimTasks Step 1: Validate pool")
-	if !isValidPool(pool) {
-		return nil, errors.New("invalid pool: must be 100k, 1m, or 10m")
+	poolConfig, err := s.pools.GetActivePool(pool)
+	if err != nil {
+		return nil, err
 	}
 
-	// Validate count (1-50)
+	// Validate count against the pool's claim limit
 	log.Printf("📋 [DEBUG] ClaimTasks Step 2: Validate count")
-	if count < 1 || count > 50 {
-		return nil, errors.New("claim count must be between 1 and 50")
+	if count < 1 || count > poolConfig.ClaimMaxCount {
+		return nil, fmt.Errorf("claim count must be between 1 and %d", poolConfig.ClaimMaxCount)
 	}
 
 	// Check if user already has uncompleted tasks in this pool
 	log.Printf("📋 [DEBUG] ClaimTasks Step 3: Check existing tasks (DB count)")
-	existingCount, err := s.queueRepo.CountMyQueueTasks(pool, reviewerID)
+	existingCount, err := s.queueRepo.CountMyQueueTasks(ctx, pool, reviewerID)
 	if err != nil {
 		log.Printf("📋 [ERROR] CountMyQueueTasks failed: %v", err)
 		return nil, err
@@ -60,7 +71,7 @@ func (s *VideoQueueService) ClaimTasks(pool string, reviewerID int, count int) (
 
 	// Claim tasks from database
 	log.Printf("📋 [DEBUG] ClaimTasks Step 4: Claim tasks from DB (transaction with lock)")
-	tasks, err := s.queueRepo.ClaimQueueTasks(pool, reviewerID, count)
+	tasks, err := s.queueRepo.ClaimQueueTasks(ctx, pool, reviewerID, count)
 	if err != nil {
 		log.Printf("📋 [ERROR] ClaimQueueTasks failed: %v", err)
 		return nil, err
@@ -79,50 +90,106 @@ func (s *VideoQueueService) ClaimTasks(pool string, reviewerID int, count int) (
 	pipe := s.rdb.Pipeline()
 	for _, task := range tasks {
 		// Add to user's claimed set
-		pipe.SAdd(s.ctx, userClaimedKey, task.ID)
+		pipe.SAdd(ctx, userClaimedKey, task.ID)
 
 		// Set lock for each task
 		lockKey := fmt.Sprintf("video:lock:%d", task.ID)
-		pipe.Set(s.ctx, lockKey, reviewerID, timeout)
+		pipe.Set(ctx, lockKey, reviewerID, timeout)
 	}
-	pipe.Expire(s.ctx, userClaimedKey, timeout)
+	pipe.Expire(ctx, userClaimedKey, timeout)
 
 	log.Printf("📋 [DEBUG] ClaimTasks Step 6: Execute Redis pipeline")
 	startTime := time.Now()
-	_, err = pipe.Exec(s.ctx)
+	_, err = pipe.Exec(ctx)
 	redisDuration := time.Since(startTime)
 	log.Printf("📋 [DEBUG] Redis pipeline executed in %v", redisDuration)
 	if err != nil {
 		log.Printf("📋 [ERROR] Redis error when claiming video queue tasks: %v", err)
 	}
 
+	for i := range tasks {
+		s.thumbnails.DecorateVideo(tasks[i].Video)
+	}
+	if err := s.annotations.AttachToQueueTasks(tasks); err != nil {
+		log.Printf("Error loading video annotations: %v", err)
+	}
+	s.attachDuplicates(tasks)
+
 	log.Printf("📋 [DEBUG] ClaimTasks END: claimed %d tasks", len(tasks))
 	return tasks, nil
 }
 
 // GetMyTasks retrieves the current user's in-progress tasks in a pool
-func (s *VideoQueueService) GetMyTasks(pool string, reviewerID int) ([]models.VideoQueueTask, error) {
-	if !isValidPool(pool) {
-		return nil, errors.New("invalid pool: must be 100k, 1m, or 10m")
+func (s *VideoQueueService) GetMyTasks(ctx context.Context, pool string, reviewerID int) ([]models.VideoQueueTask, error) {
+	if _, err := s.pools.GetPool(pool); err != nil {
+		return nil, err
 	}
 
-	return s.queueRepo.GetMyQueueTasks(pool, reviewerID)
+	tasks, err := s.queueRepo.GetMyQueueTasks(ctx, pool, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tasks {
+		s.thumbnails.DecorateVideo(tasks[i].Video)
+	}
+	if err := s.annotations.AttachToQueueTasks(tasks); err != nil {
+		log.Printf("Error loading video annotations: %v", err)
+	}
+	s.attachDuplicates(tasks)
+	return tasks, nil
 }
 
-// SubmitReview submits a review result and handles queue flow
-func (s *VideoQueueService) SubmitReview(pool string, reviewerID int, req models.SubmitVideoQueueReviewRequest) error {
-	if !isValidPool(pool) {
-		return errors.New("invalid pool: must be 100k, 1m, or 10m")
+// attachDuplicates flags tasks whose video re-uploads an earlier one
+func (s *VideoQueueService) attachDuplicates(tasks []models.VideoQueueTask) {
+	videos := make([]*models.TikTokVideo, len(tasks))
+	for i := range tasks {
+		videos[i] = tasks[i].Video
 	}
+	if err := s.fingerprints.AttachToVideos(videos); err != nil {
+		log.Printf("Error loading video duplicate links: %v", err)
+	}
+}
 
-	// Complete the task
-	if err := s.queueRepo.CompleteQueueTask(req.TaskID, reviewerID); err != nil {
-		return errors.New("task not found or already completed")
+// SubmitReview submits a review result and handles queue flow. Task
+// completion, the result, its annotations and the routing to the next pool or
+// final status are written in one transaction, as in first and second review.
+func (s *VideoQueueService) SubmitReview(ctx context.Context, pool string, reviewerID int, req models.SubmitVideoQueueReviewRequest) error {
+	// Inactive pools still accept results for tasks claimed before deactivation
+	poolConfig, err := s.pools.GetPool(pool)
+	if err != nil {
+		return err
 	}
 
-	// Validate tags (max 3)
-	if len(req.Tags) > 3 {
-		return errors.New("maximum 3 tags allowed")
+	// Validate tags before the task is completed so a rejected submit can be
+	// retried
+	if len(req.Tags) > poolConfig.MaxTags {
+		return fmt.Errorf("maximum %d tags allowed", poolConfig.MaxTags)
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	videoID, err := s.queueRepo.GetVideoIDTx(ctx, tx, req.TaskID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("task not found or already completed")
+		}
+		return err
+	}
+
+	if err := s.annotations.Validate(videoID, req.Annotations); err != nil {
+		return err
+	}
+
+	// Complete the task
+	if err := s.queueRepo.CompleteQueueTaskTx(ctx, tx, req.TaskID, reviewerID); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("task not found or already completed")
+		}
+		return err
 	}
 
 	// Create review result
@@ -134,20 +201,34 @@ func (s *VideoQueueService) SubmitReview(pool string, reviewerID int, req models
 		Tags:           req.Tags,
 	}
 
-	createdResult, err := s.queueRepo.CreateQueueResult(result)
+	createdResult, err := s.queueRepo.CreateQueueResultTx(ctx, tx, result)
 	if err != nil {
 		return err
 	}
+	// A retried submit keeps the original result and its annotations
+	if createdResult {
+		if err := s.annotations.CreateTx(ctx, tx, videoID, models.VideoAnnotationStageQueue, req.TaskID, reviewerID, req.Annotations); err != nil {
+			return err
+		}
+	}
 
-	// Get task to retrieve video ID
-	task, err := s.queueRepo.GetTaskByID(req.TaskID)
+	// Route by the stored result, which on a retried submit is the original one
+	change := reviewStatusChange(models.StatusSourceQueue, req.TaskID, reviewerID, &result.Reason)
+	nextPool, err := s.handleQueueFlowTx(ctx, tx, poolConfig, videoID, result.ReviewDecision, change)
 	if err != nil {
-		log.Printf("Error getting task for queue flow: %v", err)
-	} else {
-		// Handle queue flow based on review decision
-	if err := s.handleQueueFlow(pool, task.VideoID, req.ReviewDecision); err != nil {
-		log.Printf("Error handling queue flow: %v", err)
+		return err
 	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if nextPool != "" {
+		// Push to Redis queue for next pool
+		queueKey := fmt.Sprintf("video:queue:%s", nextPool)
+		if err := s.rdb.LPush(ctx, queueKey, videoID).Err(); err != nil {
+			log.Printf("Redis error pushing to %s queue: %v", nextPool, err)
+		}
 	}
 
 	// Remove from Redis
@@ -155,9 +236,9 @@ func (s *VideoQueueService) SubmitReview(pool string, reviewerID int, req models
 	lockKey := fmt.Sprintf("video:lock:%d", req.TaskID)
 
 	pipe := s.rdb.Pipeline()
-	pipe.SRem(s.ctx, userClaimedKey, req.TaskID)
-	pipe.Del(s.ctx, lockKey)
-	_, err = pipe.Exec(s.ctx)
+	pipe.SRem(ctx, userClaimedKey, req.TaskID)
+	pipe.Del(ctx, lockKey)
+	_, err = pipe.Exec(ctx)
 
 	if err != nil {
 		log.Printf("Redis error when submitting video queue review: %v", err)
@@ -165,17 +246,17 @@ func (s *VideoQueueService) SubmitReview(pool string, reviewerID int, req models
 
 	// Update statistics in Redis
 	if createdResult {
-		s.updateQueueStats(pool, result)
+		s.updateQueueStats(ctx, pool, result)
 	}
 
 	return nil
 }
 
 // SubmitBatchReviews submits multiple reviews at once
-func (s *VideoQueueService) SubmitBatchReviews(pool string, reviewerID int, reviews []models.SubmitVideoQueueReviewRequest) error {
+func (s *VideoQueueService) SubmitBatchReviews(ctx context.Context, pool string, reviewerID int, reviews []models.SubmitVideoQueueReviewRequest) error {
 	var failed []string
 	for _, review := range reviews {
-		if err := s.SubmitReview(pool, reviewerID, review); err != nil {
+		if err := s.SubmitReview(ctx, pool, reviewerID, review); err != nil {
 			failed = append(failed, fmt.Sprintf("task %d: %v", review.TaskID, err))
 		}
 	}
@@ -186,9 +267,9 @@ func (s *VideoQueueService) SubmitBatchReviews(pool string, reviewerID int, revi
 }
 
 // ReturnTasks allows a reviewer to return tasks back to the pool
-func (s *VideoQueueService) ReturnTasks(pool string, reviewerID int, taskIDs []int) (int, error) {
-	if !isValidPool(pool) {
-		return 0, errors.New("invalid pool: must be 100k, 1m, or 10m")
+func (s *VideoQueueService) ReturnTasks(ctx context.Context, pool string, reviewerID int, taskIDs []int) (int, error) {
+	if _, err := s.pools.GetPool(pool); err != nil {
+		return 0, err
 	}
 
 	// Validate task count (1-50)
@@ -197,7 +278,7 @@ func (s *VideoQueueService) ReturnTasks(pool string, reviewerID int, taskIDs []i
 	}
 
 	// Return tasks in database
-	returnedCount, err := s.queueRepo.ReturnQueueTasks(taskIDs, reviewerID)
+	returnedCount, err := s.queueRepo.ReturnQueueTasks(ctx, taskIDs, reviewerID)
 	if err != nil {
 		return 0, err
 	}
@@ -212,14 +293,14 @@ func (s *VideoQueueService) ReturnTasks(pool string, reviewerID int, taskIDs []i
 
 	for _, taskID := range taskIDs {
 		// Remove from user's claimed set
-		pipe.SRem(s.ctx, userClaimedKey, taskID)
+		pipe.SRem(ctx, userClaimedKey, taskID)
 
 		// Remove task lock
 		lockKey := fmt.Sprintf("video:lock:%d", taskID)
-		pipe.Del(s.ctx, lockKey)
+		pipe.Del(ctx, lockKey)
 	}
 
-	_, err = pipe.Exec(s.ctx)
+	_, err = pipe.Exec(ctx)
 	if err != nil {
 		log.Printf("Redis error when returning video queue tasks: %v", err)
 	}
@@ -229,8 +310,8 @@ func (s *VideoQueueService) ReturnTasks(pool string, reviewerID int, taskIDs []i
 
 // ReleaseExpiredTasks releases tasks that have exceeded the timeout for a specific pool
 func (s *VideoQueueService) ReleaseExpiredTasks(pool string) error {
-	if !isValidPool(pool) {
-		return errors.New("invalid pool: must be 100k, 1m, or 10m")
+	if _, err := s.pools.GetPool(pool); err != nil {
+		return err
 	}
 
 	timeoutMinutes := config.AppConfig.TaskTimeoutMinutes
@@ -261,81 +342,102 @@ func (s *VideoQueueService) ReleaseExpiredTasks(pool string) error {
 	return nil
 }
 
-// ReleaseAllExpiredTasks releases expired tasks from all pools
+// ReleaseAllExpiredTasks releases expired tasks from all configured pools,
+// including inactive ones that may still hold claimed tasks
 func (s *VideoQueueService) ReleaseAllExpiredTasks() error {
-	pools := []string{"100k", "1m", "10m"}
+	pools, err := s.pools.ListPools(true)
+	if err != nil {
+		return err
+	}
 	for _, pool := range pools {
-		if err := s.ReleaseExpiredTasks(pool); err != nil {
-			log.Printf("Error releasing expired tasks for pool %s: %v", pool, err)
+		if err := s.ReleaseExpiredTasks(pool.Name); err != nil {
+			log.Printf("Error releasing expired tasks for pool %s: %v", pool.Name, err)
 		}
 	}
 	return nil
 }
 
-// handleQueueFlow handles the queue flow based on review decision
-func (s *VideoQueueService) handleQueueFlow(currentPool string, videoID int, decision string) error {
+// handleQueueFlowTx handles the queue flow based on review decision within
+// the submit transaction. It returns the next pool when a task was created
+// there, so the caller can push it to that pool's Redis queue after commit.
+func (s *VideoQueueService) handleQueueFlowTx(ctx context.Context, tx *sql.Tx, currentPool *models.VideoPool, videoID int, decision string, change models.StatusChange) (string, error) {
 	switch decision {
 	case "push_next_pool":
 		// Push to next pool
-		nextPool := getNextPool(currentPool)
-		if nextPool == "" {
-			// Already at top pool (10m), mark as confirmed
-			log.Printf("Video %d confirmed for 10m pool (top tier)", videoID)
-			return s.queueRepo.UpdateVideoStatus(videoID, "10m_confirmed")
+		if currentPool.NextPool == nil {
+			// Top of the ladder, mark with the pool's terminal status
+			status := videoPoolTerminalStatus(currentPool)
+			log.Printf("Video %d confirmed for %s pool (top tier): %s", videoID, currentPool.Name, status)
+			return "", s.queueRepo.UpdateVideoStatusTx(ctx, tx, videoID, status, change)
 		}
+		nextPool := *currentPool.NextPool
 
 		// Create task in next pool
-		createdTask, err := s.queueRepo.CreateQueueTask(videoID, nextPool)
+		createdTask, err := s.queueRepo.CreateQueueTaskTx(ctx, tx, videoID, nextPool)
 		if err != nil {
-			return fmt.Errorf("failed to create task in %s pool: %w", nextPool, err)
+			return "", fmt.Errorf("failed to create task in %s pool: %w", nextPool, err)
 		}
 
-		if createdTask {
-			// Push to Redis queue for next pool
-			queueKey := fmt.Sprintf("video:queue:%s", nextPool)
-			if err := s.rdb.LPush(s.ctx, queueKey, videoID).Err(); err != nil {
-				log.Printf("Redis error pushing to %s queue: %v", nextPool, err)
-			}
+		log.Printf("Video %d promoted from %s to %s pool", videoID, currentPool.Name, nextPool)
+		if !createdTask {
+			return "", nil
 		}
-
-		log.Printf("Video %d promoted from %s to %s pool", videoID, currentPool, nextPool)
-		return nil
+		return nextPool, nil
 
 	case "natural_pool":
 		// Stop queue flow, keep in natural pool
 		log.Printf("Video %d assigned to natural pool (no further promotion)", videoID)
-		return s.queueRepo.UpdateVideoStatus(videoID, "natural_pool")
+		return "", s.queueRepo.UpdateVideoStatusTx(ctx, tx, videoID, models.VideoStatusNaturalPool, change)
 
 	case "remove_violation":
 		// Mark as removed due to violation
 		log.Printf("Video %d removed due to violation", videoID)
-		return s.queueRepo.UpdateVideoStatus(videoID, "removed_violation")
+		return "", s.queueRepo.UpdateVideoStatusTx(ctx, tx, videoID, models.VideoStatusRemovedViolation, change)
 
 	default:
-		return fmt.Errorf("invalid review decision: %s", decision)
+		return "", fmt.Errorf("invalid review decision: %s", decision)
 	}
 }
 
 // GetTags retrieves retrieves available tags for a specific pool
 func (s *VideoQueueService) GetTags(pool string) ([]models.VideoQueueTag, error) {
-	if !isValidPool(pool) {
-		return nil, errors.New("invalid pool: must be 100k, 1m, or 10m")
+	poolConfig, err := s.pools.GetPool(pool)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.queueRepo.GetVideoQueueTags(pool)
+	return s.queueRepo.GetVideoQueueTags(videoPoolTagSet(poolConfig))
 }
 
 // GetPoolStats retrieves statistics for a specific pool
 func (s *VideoQueueService) GetPoolStats(pool string) (*models.VideoQueuePoolStats, error) {
-	if !isValidPool(pool) {
-		return nil, errors.New("invalid pool: must be 100k, 1m, or 10m")
+	if _, err := s.pools.GetPool(pool); err != nil {
+		return nil, err
 	}
 
 	return s.queueRepo.GetQueuePoolStats(pool)
 }
 
+// GetAllPoolStats retrieves statistics for every configured pool in ladder order
+func (s *VideoQueueService) GetAllPoolStats() ([]models.VideoQueuePoolStats, error) {
+	pools, err := s.pools.ListPools(true)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]models.VideoQueuePoolStats, 0, len(pools))
+	for _, pool := range pools {
+		poolStats, err := s.queueRepo.GetQueuePoolStats(pool.Name)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, *poolStats)
+	}
+	return stats, nil
+}
+
 // updateQueueStats updates statistics in Redis
-func (s *VideoQueueService) updateQueueStats(pool string, result *models.VideoQueueResult) {
+func (s *VideoQueueService) updateQueueStats(ctx context.Context, pool string, result *models.VideoQueueResult) {
 	now := time.Now()
 	date := now.Format("2006-01-02")
 	hour := now.Hour()
@@ -344,35 +446,16 @@ func (s *VideoQueueService) updateQueueStats(pool string, result *models.VideoQu
 	dailyKey := fmt.Sprintf("video:stats:queue:%s:%s", pool, date)
 
 	pipe := s.rdb.Pipeline()
-	pipe.HIncrBy(s.ctx, hourlyKey, "count", 1)
-	pipe.Expire(s.ctx, hourlyKey, 7*24*time.Hour) // 7 days TTL
+	pipe.HIncrBy(ctx, hourlyKey, "count", 1)
+	pipe.Expire(ctx, hourlyKey, 7*24*time.Hour) // 7 days TTL
 
-	pipe.HIncrBy(s.ctx, dailyKey, "count", 1)
-	pipe.HIncrBy(s.ctx, dailyKey, fmt.Sprintf("decision:%s", result.ReviewDecision), 1)
+	pipe.HIncrBy(ctx, dailyKey, "count", 1)
+	pipe.HIncrBy(ctx, dailyKey, fmt.Sprintf("decision:%s", result.ReviewDecision), 1)
 
-	pipe.Expire(s.ctx, dailyKey, 30*24*time.Hour) // 30 days TTL
+	pipe.Expire(ctx, dailyKey, 30*24*time.Hour) // 30 days TTL
 
-	_, err := pipe.Exec(s.ctx)
+	_, err := pipe.Exec(ctx)
 	if err != nil {
 		log.Printf("Redis error when updating queue stats: %v", err)
 	}
 }
-
-// Helper functions
-
-func isValidPool(pool string) bool {
-	return pool == "100k" || pool == "1m" || pool == "10m"
-}
-
-func getNextPool(currentPool string) string {
-	switch currentPool {
-	case "100k":
-		return "1m"
-	case "1m":
-		return "10m"
-	case "10m":
-		return "" // Top pool, no next pool
-	default:
-		return ""
-	}
-}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"comment-review-platform/pkg/database"
+	redispkg "comment-review-platform/pkg/redis"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// videoReviewReconcileGrace skips tasks completed very recently, so a submit
+// still running on an older replica is not mistaken for an orphan
+const videoReviewReconcileGrace = 10 * time.Minute
+
+// VideoReviewReconciliationService finds and repairs video review rows left
+// inconsistent by the non-transactional submit path: completed tasks without
+// a result, rejected first reviews without a second review task, and videos
+// whose status was never updated.
+type VideoReviewReconciliationService struct {
+	firstReviewRepo  *repository.VideoFirstReviewRepository
+	secondReviewRepo *repository.VideoSecondReviewRepository
+	rdb              *redis.Client
+	ctx              context.Context
+}
+
+func NewVideoReviewReconciliationService() *VideoReviewReconciliationService {
+	return &VideoReviewReconciliationService{
+		firstReviewRepo:  repository.NewVideoFirstReviewRepository(),
+		secondReviewRepo: repository.NewVideoSecondReviewRepository(),
+		rdb:              redispkg.Client,
+		ctx:              context.Background(),
+	}
+}
+
+// Reconcile runs every repair in one transaction. A dry run reports the same
+// counts and rolls the transaction back.
+func (s *VideoReviewReconciliationService) Reconcile(dryRun bool) (*models.VideoReviewReconciliationReport, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report := &models.VideoReviewReconciliationReport{DryRun: dryRun}
+
+	// Reset unresulted tasks first; a reset task is reviewed again and routed
+	// by the normal submit path.
+	if report.ResetFirstReviewTasks, err = s.firstReviewRepo.ResetCompletedTasksWithoutResultTx(tx, videoReviewReconcileGrace); err != nil {
+		return nil, fmt.Errorf("reset first review tasks: %w", err)
+	}
+	videoIDs, err := s.secondReviewRepo.CreateMissingSecondReviewTasksTx(tx)
+	if err != nil {
+		return nil, fmt.Errorf("create missing second review tasks: %w", err)
+	}
+	report.CreatedSecondReviewTasks = int64(len(videoIDs))
+	if report.RepairedFirstReviewStatuses, err = s.firstReviewRepo.RepairApprovedVideoStatusesTx(tx); err != nil {
+		return nil, fmt.Errorf("repair first review statuses: %w", err)
+	}
+	if report.ResetSecondReviewTasks, err = s.secondReviewRepo.ResetCompletedTasksWithoutResultTx(tx, videoReviewReconcileGrace); err != nil {
+		return nil, fmt.Errorf("reset second review tasks: %w", err)
+	}
+	if report.RepairedSecondReviewStatuses, err = s.secondReviewRepo.RepairReviewedVideoStatusesTx(tx); err != nil {
+		return nil, fmt.Errorf("repair second review statuses: %w", err)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if len(videoIDs) > 0 && s.rdb != nil {
+		queueKey := "video:review:queue:second"
+		if err := s.rdb.LPush(s.ctx, queueKey, intsToInterfaces(videoIDs)...).Err(); err != nil {
+			log.Printf("Redis error pushing reconciled videos to second review queue: %v", err)
+		}
+	}
+
+	if report.ResetFirstReviewTasks+report.CreatedSecondReviewTasks+report.RepairedFirstReviewStatuses+
+		report.ResetSecondReviewTasks+report.RepairedSecondReviewStatuses > 0 {
+		log.Printf("Video review reconciliation repaired: %d first review tasks reset, %d second review tasks created, %d first review statuses, %d second review tasks reset, %d second review statuses",
+			report.ResetFirstReviewTasks, report.CreatedSecondReviewTasks, report.RepairedFirstReviewStatuses,
+			report.ResetSecondReviewTasks, report.RepairedSecondReviewStatuses)
+	}
+	return report, nil
+}
+
+func intsToInterfaces(values []int) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"comment-review-platform/internal/repository"
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+func newTestReconciliationService() *VideoReviewReconciliationService {
+	return &VideoReviewReconciliationService{
+		firstReviewRepo:  repository.NewVideoFirstReviewRepository(),
+		secondReviewRepo: repository.NewVideoSecondReviewRepository(),
+		ctx:              context.Background(),
+	}
+}
+
+// reconciliationResponses describe a database with two unresulted first
+// review tasks, two rejected results without a second review task, one
+// approved video left pending and one unresulted second review task
+func reconciliationResponses() []fakeSQLResponse {
+	return []fakeSQLResponse{
+		{match: "UPDATE video_first_review_tasks t", affected: 2},
+		{match: "INSERT INTO video_second_review_tasks", columns: []string{"video_id"}, rows: [][]driver.Value{{int64(7)}, {int64(8)}}},
+		{match: "r.is_approved = TRUE", columns: []string{"id", "id"}, rows: [][]driver.Value{{int64(5), int64(50)}}},
+		{match: "WITH pool_statuses", columns: []string{"status", "from_pool", "to_pool"}, rows: [][]driver.Value{{"pending", false, false}}},
+		{match: "INSERT INTO video_status_transitions", affected: 1},
+		{match: "UPDATE video_second_review_tasks t", affected: 1},
+		{match: "JOIN video_second_review_results r"},
+	}
+}
+
+func TestReconcileDryRunRollsBack(t *testing.T) {
+	for _, dryRun := range []bool{true, false} {
+		t.Run(map[bool]string{true: "dry run", false: "repair"}[dryRun], func(t *testing.T) {
+			db := openFakeSQL(t, reconciliationResponses()...)
+
+			report, err := newTestReconciliationService().Reconcile(dryRun)
+			if err != nil {
+				t.Fatalf("Reconcile returned error: %v", err)
+			}
+			if report.DryRun != dryRun || report.ResetFirstReviewTasks != 2 || report.CreatedSecondReviewTasks != 2 ||
+				report.RepairedFirstReviewStatuses != 1 || report.ResetSecondReviewTasks != 1 || report.RepairedSecondReviewStatuses != 0 {
+				t.Fatalf("report = %+v", report)
+			}
+			// A dry run reports what it would repair but keeps none of it
+			if db.committed() == dryRun || db.rolledBack() != dryRun {
+				t.Fatalf("committed = %v, rolled back = %v for dry run %v", db.committed(), db.rolledBack(), dryRun)
+			}
+		})
+	}
+}
+
+func TestReconcileSkipsTasksWithinGrace(t *testing.T) {
+	db := openFakeSQL(t, reconciliationResponses()...)
+
+	if _, err := newTestReconciliationService().Reconcile(true); err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+
+	graceSeconds := int64(videoReviewReconcileGrace.Seconds())
+	for _, match := range []string{"UPDATE video_first_review_tasks t", "UPDATE video_second_review_tasks t"} {
+		resets := db.executed(match)
+		if len(resets) != 1 {
+			t.Fatalf("%q ran %d times, want 1", match, len(resets))
+		}
+		if !strings.Contains(resets[0].query, "completed_at < NOW() - INTERVAL '1 second' * $1") {
+			t.Errorf("%q does not skip recently completed tasks:\n%s", match, resets[0].query)
+		}
+		if len(resets[0].args) != 1 || resets[0].args[0] != graceSeconds {
+			t.Errorf("%q args = %v, want grace of %d seconds", match, resets[0].args, graceSeconds)
+		}
+	}
+}
+
+func TestReconcileCreatesSecondReviewTasksOnlyForRejectedResults(t *testing.T) {
+	db := openFakeSQL(t, reconciliationResponses()...)
+
+	if _, err := newTestReconciliationService().Reconcile(true); err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+
+	inserts := db.executed("INSERT INTO video_second_review_tasks")
+	if len(inserts) != 1 {
+		t.Fatalf("second review task insert ran %d times, want 1", len(inserts))
+	}
+	for _, condition := range []string{
+		"r.is_approved = FALSE",
+		"NOT EXISTS (SELECT 1 FROM video_second_review_tasks s WHERE s.first_review_result_id = r.id)",
+	} {
+		if !strings.Contains(inserts[0].query, condition) {
+			t.Errorf("second review task insert lacks %q:\n%s", condition, inserts[0].query)
+		}
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"comment-review-platform/internal/config"
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/repository"
+	"comment-review-platform/pkg/database"
 	redispkg "comment-review-platform/pkg/redis"
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log"
@@ -18,6 +20,9 @@ import (
 type VideoSecondReviewService struct {
 	secondReviewRepo *repository.VideoSecondReviewRepository
 	videoRepo        *repository.VideoRepository
+	annotations      *VideoAnnotationService
+	thumbnails       *ThumbnailService
+	fingerprints     *VideoFingerprintService
 	rdb              *redis.Client
 	ctx              context.Context
 }
@@ -26,6 +31,9 @@ func NewVideoSecondReviewService() *VideoSecondReviewService {
 	return &VideoSecondReviewService{
 		secondReviewRepo: repository.NewVideoSecondReviewRepository(),
 		videoRepo:        repository.NewVideoRepository(),
+		annotations:      NewVideoAnnotationService(),
+		thumbnails:       newOptionalThumbnailService(),
+		fingerprints:     NewVideoFingerprintService(nil),
 		rdb:              redispkg.Client,
 		ctx:              context.Background(),
 	}
@@ -78,19 +86,68 @@ func (s *VideoSecondReviewService) ClaimSecondReviewTasks(reviewerID int, count
 		log.Printf("Redis error when claiming video second review tasks: %v", err)
 	}
 
+	if err := s.annotations.AttachToSecondReviewTasks(tasks); err != nil {
+		log.Printf("Error loading first review annotations: %v", err)
+	}
+	s.attachDuplicates(tasks)
+
 	return tasks, nil
 }
 
 // GetMySecondReviewTasks retrieves the current user's in-progress second review tasks
 func (s *VideoSecondReviewService) GetMySecondReviewTasks(reviewerID int) ([]models.VideoSecondReviewTask, error) {
-	return s.secondReviewRepo.GetMySecondReviewTasks(reviewerID)
+	tasks, err := s.secondReviewRepo.GetMySecondReviewTasks(reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tasks {
+		s.thumbnails.DecorateVideo(tasks[i].Video)
+	}
+	if err := s.annotations.AttachToSecondReviewTasks(tasks); err != nil {
+		log.Printf("Error loading first review annotations: %v", err)
+	}
+	s.attachDuplicates(tasks)
+	return tasks, nil
+}
+
+// attachDuplicates flags tasks whose video re-uploads an earlier one
+func (s *VideoSecondReviewService) attachDuplicates(tasks []models.VideoSecondReviewTask) {
+	videos := make([]*models.TikTokVideo, len(tasks))
+	for i := range tasks {
+		videos[i] = tasks[i].Video
+	}
+	if err := s.fingerprints.AttachToVideos(videos); err != nil {
+		log.Printf("Error loading video duplicate links: %v", err)
+	}
 }
 
-// SubmitSecondReview submits a second review result
-func (s *VideoSecondReviewService) SubmitSecondReview(reviewerID int, req models.SubmitVideoSecondReviewRequest) error {
+// SubmitSecondReview submits a second review result. Task completion, the
+// result and the video status are written in one transaction.
+func (s *VideoSecondReviewService) SubmitSecondReview(ctx context.Context, reviewerID int, req models.SubmitVideoSecondReviewRequest) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	videoID, err := s.secondReviewRepo.GetVideoIDTx(ctx, tx, req.TaskID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("task not found")
+		}
+		return err
+	}
+
+	if err := s.annotations.Validate(videoID, req.Annotations); err != nil {
+		return err
+	}
+
 	// Complete the task
-	if err := s.secondReviewRepo.CompleteSecondReviewTask(req.TaskID, reviewerID); err != nil {
-		return errors.New("task not found or already completed")
+	if err := s.secondReviewRepo.CompleteSecondReviewTaskTx(ctx, tx, req.TaskID, reviewerID); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("task not found or already completed")
+		}
+		return err
 	}
 
 	// Calculate overall score
@@ -110,29 +167,34 @@ func (s *VideoSecondReviewService) SubmitSecondReview(reviewerID int, req models
 		Reason:            req.Reason,
 	}
 
-	createdResult, err := s.secondReviewRepo.CreateSecondReviewResult(result)
+	createdResult, err := s.secondReviewRepo.CreateSecondReviewResultTx(ctx, tx, result)
 	if err != nil {
 		return err
 	}
-
-	// Update video status based on second review result
-	tasks, err := s.secondReviewRepo.FindSecondReviewTasksWithDetails([]int{req.TaskID})
-	if err == nil && len(tasks) > 0 {
-		videoID := tasks[0].VideoID
-		status := "second_review_completed"
-		if err := s.videoRepo.UpdateVideoStatus(videoID, status); err != nil {
-			log.Printf("Error updating video status: %v", err)
+	// A retried submit keeps the original result and its annotations
+	if createdResult {
+		if err := s.annotations.CreateTx(ctx, tx, videoID, models.VideoAnnotationStageSecondReview, req.TaskID, reviewerID, req.Annotations); err != nil {
+			return err
 		}
 	}
 
+	change := reviewStatusChange(models.StatusSourceSecondReview, req.TaskID, reviewerID, result.Reason)
+	if err := s.videoRepo.UpdateVideoStatusTx(ctx, tx, videoID, models.VideoStatusSecondReviewCompleted, change); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
 	// Remove from Redis
 	userClaimedKey := fmt.Sprintf("video:second:claimed:%d", reviewerID)
 	lockKey := fmt.Sprintf("video:second:lock:%d", req.TaskID)
 
 	pipe := s.rdb.Pipeline()
-	pipe.SRem(s.ctx, userClaimedKey, req.TaskID)
-	pipe.Del(s.ctx, lockKey)
-	_, err = pipe.Exec(s.ctx)
+	pipe.SRem(ctx, userClaimedKey, req.TaskID)
+	pipe.Del(ctx, lockKey)
+	_, err = pipe.Exec(ctx)
 
 	if err != nil {
 		log.Printf("Redis error when submitting video second review: %v", err)
@@ -147,10 +209,10 @@ func (s *VideoSecondReviewService) SubmitSecondReview(reviewerID int, req models
 }
 
 // SubmitBatchSecondReviews submits multiple second reviews at once
-func (s *VideoSecondReviewService) SubmitBatchSecondReviews(reviewerID int, reviews []models.SubmitVideoSecondReviewRequest) error {
+func (s *VideoSecondReviewService) SubmitBatchSecondReviews(ctx context.Context, reviewerID int, reviews []models.SubmitVideoSecondReviewRequest) error {
 	var failed []string
 	for _, review := range reviews {
-		if err := s.SubmitSecondReview(reviewerID, review); err != nil {
+		if err := s.SubmitSecondReview(ctx, reviewerID, review); err != nil {
 			failed = append(failed, fmt.Sprintf("task %d: %v", review.TaskID, err))
 		}
 	}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSubmitSecondReviewRollsBackWhenStatusUpdateFails(t *testing.T) {
+	db := openFakeSQL(t,
+		fakeSQLResponse{match: "SELECT video_id FROM video_second_review_tasks", columns: []string{"video_id"}, rows: [][]driver.Value{{int64(11)}}},
+		fakeSQLResponse{match: "UPDATE video_second_review_tasks", affected: 1},
+		fakeSQLResponse{match: "INSERT INTO video_second_review_results", columns: []string{"id", "created_at"}, rows: [][]driver.Value{{int64(31), time.Now()}}},
+		fakeSQLResponse{match: "WITH pool_statuses", columns: []string{"status", "from_pool", "to_pool"}, rows: [][]driver.Value{{models.VideoStatusPending, false, false}}},
+		fakeSQLResponse{match: "INSERT INTO video_status_transitions", err: errors.New("connection reset")},
+	)
+	svc := &VideoSecondReviewService{
+		secondReviewRepo: repository.NewVideoSecondReviewRepository(),
+		videoRepo:        repository.NewVideoRepository(),
+		annotations:      NewVideoAnnotationService(),
+		ctx:              context.Background(),
+	}
+
+	err := svc.SubmitSecondReview(context.Background(), 9, models.SubmitVideoSecondReviewRequest{TaskID: 4, IsApproved: true})
+	if err == nil {
+		t.Fatal("expected the failed status update to fail the submit")
+	}
+	if len(db.executed("INSERT INTO video_second_review_results")) != 1 {
+		t.Fatal("expected the result to be written before the status update")
+	}
+	if db.committed() || !db.rolledBack() {
+		t.Fatalf("committed = %v, rolled back = %v; want only a rollback", db.committed(), db.rolledBack())
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"comment-review-platform/internal/models"
 	"comment-review-platform/internal/repository"
 	"comment-review-platform/internal/services/base"
+	"comment-review-platform/pkg/database"
+	"comment-review-platform/pkg/mp4"
 	"comment-review-platform/pkg/r2"
 	redispkg "comment-review-platform/pkg/redis"
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"strings"
 	"time"
 
@@ -17,10 +21,13 @@ import (
 )
 
 type VideoService struct {
-	videoRepo *repository.VideoRepository
-	r2Service *r2.R2Service
-	rdb       *redis.Client
-	ctx       context.Context
+	videoRepo    *repository.VideoRepository
+	r2Service    *r2.R2Service
+	thumbnails   *ThumbnailService
+	fingerprints *VideoFingerprintService
+	timeline     *VideoTimelineService
+	rdb          *redis.Client
+	ctx          context.Context
 }
 
 func NewVideoService() (*VideoService, error) {
@@ -30,85 +37,73 @@ func NewVideoService() (*VideoService, error) {
 	}
 
 	return &VideoService{
-		videoRepo: repository.NewVideoRepository(),
-		r2Service: r2Service,
-		rdb:       redispkg.Client,
-		ctx:       context.Background(),
+		videoRepo:    repository.NewVideoRepository(),
+		r2Service:    r2Service,
+		thumbnails:   NewThumbnailService(r2Service),
+		fingerprints: NewVideoFingerprintService(r2Service),
+		timeline:     NewVideoTimelineService(),
+		rdb:          redispkg.Client,
+		ctx:          context.Background(),
 	}, nil
 }
 
-// ImportVideosFromR2 imports videos from R2 bucket path
-func (s *VideoService) ImportVideosFromR2(r2PathPrefix string) (*models.ImportVideosResponse, error) {
-	// Test R2 connection first
-	if err := s.r2Service.CheckConnection(); err != nil {
-		return nil, fmt.Errorf("R2 connection failed: %w", err)
+// ProbeMissingMetadata probes container metadata for videos imported before
+// probing existed (or whose probe failed), up to limit videos after afterID
+func (s *VideoService) ProbeMissingMetadata(afterID, limit int) (*models.ProbeVideosResponse, error) {
+	if limit <= 0 {
+		limit = 100
 	}
-
-	// List videos in the specified path
-	videos, err := s.r2Service.ListVideosInPath(r2PathPrefix)
+	videos, err := s.videoRepo.ListUnprobedVideos(afterID, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list videos from R2: %w", err)
-	}
-
-	response := &models.ImportVideosResponse{
-		ImportedCount: 0,
-		SkippedCount:  0,
-		Errors:        []string{},
+		return nil, fmt.Errorf("failed to list unprobed videos: %w", err)
 	}
 
-	for _, video := range videos {
-		// Check if video already exists
-		exists, err := s.videoRepo.CheckVideoExists(video.Key)
+	response := &models.ProbeVideosResponse{LastID: afterID, Errors: []string{}}
+	for i := range videos {
+		video := &videos[i]
+		response.LastID = video.ID
+		metadata, err := s.r2Service.ProbeVideo(video.VideoKey, video.FileSize)
 		if err != nil {
-			response.Errors = append(response.Errors, fmt.Sprintf("Error checking video %s: %v", video.Filename, err))
+			response.FailedCount++
+			response.Errors = append(response.Errors, fmt.Sprintf("Error probing video %s: %v", video.Filename, err))
 			continue
 		}
-
-		if exists {
-			response.SkippedCount++
+		applyVideoProbe(video, metadata)
+		if err := s.videoRepo.UpdateVideoProbe(video); err != nil {
+			response.FailedCount++
+			response.Errors = append(response.Errors, fmt.Sprintf("Error saving metadata for video %s: %v", video.Filename, err))
 			continue
 		}
-
-		// Get estimated duration
-		duration, err := s.r2Service.GetVideoDuration(video.Key)
-		if err != nil {
-			log.Printf("Warning: Could not get duration for video %s: %v", video.Filename, err)
-			duration = 30 // default duration
-		}
-
-		// Create video record
-		tiktokVideo := &models.TikTokVideo{
-			VideoKey:   video.Key,
-			Filename:   video.Filename,
-			FileSize:   video.Size,
-			Duration:   &duration,
-			UploadTime: &video.Modified,
-			Status:     "pending",
-		}
-
-		if err := s.videoRepo.CreateVideo(tiktokVideo); err != nil {
-			response.Errors = append(response.Errors, fmt.Sprintf("Error creating video %s: %v", video.Filename, err))
-			continue
-		}
-
-		// Create first review task
-		if err := s.CreateFirstReviewTask(tiktokVideo.ID); err != nil {
-			log.Printf("Warning: Could not create first review task for video %d: %v", tiktokVideo.ID, err)
-		}
-
-		response.ImportedCount++
+		response.ProbedCount++
 	}
 
-	log.Printf("Video import completed: %d imported, %d skipped, %d errors",
-		response.ImportedCount, response.SkippedCount, len(response.Errors))
-
+	log.Printf("Video probe completed: %d probed, %d failed", response.ProbedCount, response.FailedCount)
 	return response, nil
 }
 
-// CreateFirstReviewTask creates a first review task for a video
-func (s *VideoService) CreateFirstReviewTask(videoID int) error {
-	firstReviewRepo := repository.NewVideoFirstReviewRepository()
-	return firstReviewRepo.CreateFirstReviewTask(videoID)
+// applyVideoProbe copies probed container metadata onto a video record
+func applyVideoProbe(video *models.TikTokVideo, metadata *mp4.Metadata) {
+	duration := int(math.Round(metadata.DurationSeconds))
+	video.Duration = &duration
+	if metadata.Width > 0 && metadata.Height > 0 {
+		video.Width = &metadata.Width
+		video.Height = &metadata.Height
+	}
+	if metadata.VideoCodec != "" {
+		video.VideoCodec = &metadata.VideoCodec
+	}
+	if metadata.FrameRate > 0 {
+		video.FrameRate = &metadata.FrameRate
+	}
+	if metadata.Bitrate > 0 {
+		video.Bitrate = &metadata.Bitrate
+	}
+	video.HasAudio = &metadata.HasAudio
+	if metadata.AudioCodec != "" {
+		video.AudioCodec = &metadata.AudioCodec
+	}
+	now := time.Now()
+	video.ProbedAt = &now
 }
 
 // GenerateVideoURL generates a pre-signed URL for video access with Redis caching
@@ -188,12 +183,47 @@ func (s *VideoService) cacheVideoURL(videoID int, url string, expiresAt time.Tim
 
 // GetVideoByID retrieves a video by ID
 func (s *VideoService) GetVideoByID(id int) (*models.TikTokVideo, error) {
-	return s.videoRepo.GetVideoByID(id)
+	video, err := s.videoRepo.GetVideoByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.thumbnails.DecorateVideo(video)
+	if err := s.fingerprints.AttachToVideos([]*models.TikTokVideo{video}); err != nil {
+		log.Printf("Error loading duplicate link for video %d: %v", id, err)
+	}
+	return video, nil
+}
+
+// GetVideoTimeline returns every task, result and status change of a
+// video's review across all stages, oldest first
+func (s *VideoService) GetVideoTimeline(video *models.TikTokVideo) (*models.VideoTimeline, error) {
+	return s.timeline.Build(video)
 }
 
 // ListVideos returns paginated videos with filters
 func (s *VideoService) ListVideos(req models.ListVideosRequest) ([]models.TikTokVideo, int, error) {
-	return s.videoRepo.ListVideos(req)
+	videos, total, err := s.videoRepo.ListVideos(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range videos {
+		s.thumbnails.DecorateVideo(&videos[i])
+	}
+	return videos, total, nil
+}
+
+// GenerateMissingThumbnails backfills thumbnails for videos that have none
+func (s *VideoService) GenerateMissingThumbnails(afterID, limit int) (*models.GenerateThumbnailsResponse, error) {
+	return s.thumbnails.GenerateMissing(afterID, limit)
+}
+
+// FingerprintMissingVideos backfills fingerprints and duplicate links for
+// videos imported before duplicate detection
+func (s *VideoService) FingerprintMissingVideos(afterID, limit int) (*models.FingerprintVideosResponse, error) {
+	if !s.fingerprints.Enabled() {
+		return nil, errors.New("video duplicate detection is disabled")
+	}
+	return s.fingerprints.FingerprintMissing(afterID, limit)
 }
 
 // GetVideoQualityTags retrieves quality tags by category
@@ -205,6 +235,9 @@ type VideoFirstReviewService struct {
 	firstReviewRepo  *repository.VideoFirstReviewRepository
 	secondReviewRepo *repository.VideoSecondReviewRepository
 	videoRepo        *repository.VideoRepository
+	annotations      *VideoAnnotationService
+	thumbnails       *ThumbnailService
+	fingerprints     *VideoFingerprintService
 	base             *base.BaseTaskService
 }
 
@@ -213,6 +246,9 @@ func NewVideoFirstReviewService() *VideoFirstReviewService {
 		firstReviewRepo:  repository.NewVideoFirstReviewRepository(),
 		secondReviewRepo: repository.NewVideoSecondReviewRepository(),
 		videoRepo:        repository.NewVideoRepository(),
+		annotations:      NewVideoAnnotationService(),
+		thumbnails:       newOptionalThumbnailService(),
+		fingerprints:     NewVideoFingerprintService(nil),
 		base:             base.NewBaseTaskService(base.VideoFirstReviewTaskServiceConfig(), redispkg.Client),
 	}
 }
@@ -257,19 +293,62 @@ func (s *VideoFirstReviewService) ClaimFirstReviewTasks(reviewerID int, count in
 		return nil, errors.New("failed to claim tasks, please retry")
 	}
 
+	s.attachDuplicates(tasks)
 	return tasks, nil
 }
 
 // GetMyFirstReviewTasks retrieves the current user's in-progress first review tasks
 func (s *VideoFirstReviewService) GetMyFirstReviewTasks(reviewerID int) ([]models.VideoFirstReviewTask, error) {
-	return s.firstReviewRepo.GetMyFirstReviewTasks(reviewerID)
+	tasks, err := s.firstReviewRepo.GetMyFirstReviewTasks(reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tasks {
+		s.thumbnails.DecorateVideo(tasks[i].Video)
+	}
+	s.attachDuplicates(tasks)
+	return tasks, nil
 }
 
-// SubmitFirstReview submits a first review result
-func (s *VideoFirstReviewService) SubmitFirstReview(reviewerID int, req models.SubmitVideoFirstReviewRequest) error {
+// attachDuplicates flags tasks whose video re-uploads an earlier one
+func (s *VideoFirstReviewService) attachDuplicates(tasks []models.VideoFirstReviewTask) {
+	videos := make([]*models.TikTokVideo, len(tasks))
+	for i := range tasks {
+		videos[i] = tasks[i].Video
+	}
+	if err := s.fingerprints.AttachToVideos(videos); err != nil {
+		log.Printf("Error loading video duplicate links: %v", err)
+	}
+}
+
+// SubmitFirstReview submits a first review result. Task completion, the
+// result, the video status and the second review task are written in one
+// transaction so a failure cannot leave a completed task unrouted.
+func (s *VideoFirstReviewService) SubmitFirstReview(ctx context.Context, reviewerID int, req models.SubmitVideoFirstReviewRequest) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	videoID, err := s.firstReviewRepo.GetVideoIDTx(ctx, tx, req.TaskID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("task not found")
+		}
+		return err
+	}
+
+	if err := s.annotations.Validate(videoID, req.Annotations); err != nil {
+		return err
+	}
+
 	// Complete the task
-	if err := s.firstReviewRepo.CompleteFirstReviewTask(req.TaskID, reviewerID); err != nil {
-		return errors.New("task not found or already completed")
+	if err := s.firstReviewRepo.CompleteFirstReviewTaskTx(ctx, tx, req.TaskID, reviewerID); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("task not found or already completed")
+		}
+		return err
 	}
 
 	// Calculate overall score
@@ -289,42 +368,39 @@ func (s *VideoFirstReviewService) SubmitFirstReview(reviewerID int, req models.S
 		Reason:            req.Reason,
 	}
 
-	createdResult, err := s.firstReviewRepo.CreateFirstReviewResult(result)
+	createdResult, err := s.firstReviewRepo.CreateFirstReviewResultTx(ctx, tx, result)
 	if err != nil {
 		return err
 	}
+	// A retried submit keeps the original result and its annotations
+	if createdResult {
+		if err := s.annotations.CreateTx(ctx, tx, videoID, models.VideoAnnotationStageFirstReview, req.TaskID, reviewerID, req.Annotations); err != nil {
+			return err
+		}
+	}
 
-	// If first review is not approved, create second review task
-	if !req.IsApproved {
-		// Get the video ID from the task
-		tasks, err := s.firstReviewRepo.FindFirstReviewTasksWithVideos([]int{req.TaskID})
-		if err != nil {
-			log.Printf("Error getting video ID for second review task: %v", err)
-		} else if len(tasks) > 0 {
-			videoID := tasks[0].VideoID
-
-			// Create second review task
-			createdTask, err := s.secondReviewRepo.CreateSecondReviewTask(result.ID, videoID)
-			if err != nil {
-				log.Printf("Error creating second review task: %v", err)
-			} else if createdTask {
-				// Push to Redis second review queue
-				queueKey := "video:review:queue:second"
-				if s.base.Rdb != nil {
-					if err := s.base.Rdb.LPush(s.base.Ctx, queueKey, videoID).Err(); err != nil {
-						log.Printf("Redis error pushing to second review queue: %v", err)
-					}
-				}
-			}
+	// Route by the stored result, which on a retried submit is the original one
+	var createdSecondReviewTask bool
+	if result.IsApproved {
+		change := reviewStatusChange(models.StatusSourceFirstReview, req.TaskID, reviewerID, result.Reason)
+		if err := s.videoRepo.UpdateVideoStatusTx(ctx, tx, videoID, models.VideoStatusFirstReviewCompleted, change); err != nil {
+			return err
 		}
 	} else {
-		// If approved, update video status
-		tasks, err := s.firstReviewRepo.FindFirstReviewTasksWithVideos([]int{req.TaskID})
-		if err == nil && len(tasks) > 0 {
-			videoID := tasks[0].VideoID
-			if err := s.videoRepo.UpdateVideoStatus(videoID, "first_review_completed"); err != nil {
-				log.Printf("Error updating video status: %v", err)
-			}
+		createdSecondReviewTask, err = s.secondReviewRepo.CreateSecondReviewTaskTx(ctx, tx, result.ID, videoID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if createdSecondReviewTask && s.base.Rdb != nil {
+		queueKey := "video:review:queue:second"
+		if err := s.base.Rdb.LPush(ctx, queueKey, videoID).Err(); err != nil {
+			log.Printf("Redis error pushing to second review queue: %v", err)
 		}
 	}
 
@@ -340,10 +416,10 @@ func (s *VideoFirstReviewService) SubmitFirstReview(reviewerID int, req models.S
 }
 
 // SubmitBatchFirstReviews submits multiple first reviews at once
-func (s *VideoFirstReviewService) SubmitBatchFirstReviews(reviewerID int, reviews []models.SubmitVideoFirstReviewRequest) error {
+func (s *VideoFirstReviewService) SubmitBatchFirstReviews(ctx context.Context, reviewerID int, reviews []models.SubmitVideoFirstReviewRequest) error {
 	var failed []string
 	for _, review := range reviews {
-		if err := s.SubmitFirstReview(reviewerID, review); err != nil {
+		if err := s.SubmitFirstReview(ctx, reviewerID, review); err != nil {
 			failed = append(failed, fmt.Sprintf("task %d: %v", review.TaskID, err))
 		}
 	}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"comment-review-platform/internal/services/base"
+	"comment-review-platform/pkg/statemachine"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestFirstReviewService() *VideoFirstReviewService {
+	return &VideoFirstReviewService{
+		firstReviewRepo:  repository.NewVideoFirstReviewRepository(),
+		secondReviewRepo: repository.NewVideoSecondReviewRepository(),
+		videoRepo:        repository.NewVideoRepository(),
+		annotations:      NewVideoAnnotationService(),
+		base:             base.NewBaseTaskService(base.VideoFirstReviewTaskServiceConfig(), nil),
+	}
+}
+
+// firstReviewSubmitResponses let a submit of task 3 on video 11 get as far as
+// routing the stored result
+func firstReviewSubmitResponses(routing ...fakeSQLResponse) []fakeSQLResponse {
+	return append(routing,
+		fakeSQLResponse{match: "SELECT video_id FROM video_first_review_tasks", columns: []string{"video_id"}, rows: [][]driver.Value{{int64(11)}}},
+		fakeSQLResponse{match: "UPDATE video_first_review_tasks", affected: 1},
+		fakeSQLResponse{match: "INSERT INTO video_first_review_results", columns: []string{"id", "created_at"}, rows: [][]driver.Value{{int64(21), time.Now()}}},
+	)
+}
+
+func TestSubmitFirstReviewRollsBackWhenSecondReviewTaskFails(t *testing.T) {
+	db := openFakeSQL(t, firstReviewSubmitResponses(
+		fakeSQLResponse{match: "INSERT INTO video_second_review_tasks", err: errors.New("connection reset")},
+	)...)
+
+	err := newTestFirstReviewService().SubmitFirstReview(context.Background(), 9, models.SubmitVideoFirstReviewRequest{TaskID: 3, IsApproved: false})
+	if err == nil {
+		t.Fatal("expected the failed second review task insert to fail the submit")
+	}
+	if len(db.executed("INSERT INTO video_first_review_results")) != 1 {
+		t.Fatal("expected the result to be written before routing")
+	}
+	// The completed task and its result must not outlive the failed routing
+	if db.committed() || !db.rolledBack() {
+		t.Fatalf("committed = %v, rolled back = %v; want only a rollback", db.committed(), db.rolledBack())
+	}
+}
+
+func TestSubmitFirstReviewRollsBackOnInvalidStatusChange(t *testing.T) {
+	db := openFakeSQL(t, firstReviewSubmitResponses(
+		fakeSQLResponse{match: "WITH pool_statuses", columns: []string{"status", "from_pool", "to_pool"}, rows: [][]driver.Value{{models.VideoStatusRemovedViolation, false, false}}},
+	)...)
+
+	err := newTestFirstReviewService().SubmitFirstReview(context.Background(), 9, models.SubmitVideoFirstReviewRequest{TaskID: 3, IsApproved: true})
+	if !errors.Is(err, statemachine.ErrInvalidTransition) {
+		t.Fatalf("err = %v, want an invalid transition", err)
+	}
+	if len(db.executed("INSERT INTO video_status_transitions")) != 0 {
+		t.Fatal("status must not be written after a rejected transition")
+	}
+	if db.committed() || !db.rolledBack() {
+		t.Fatalf("committed = %v, rolled back = %v; want only a rollback", db.committed(), db.rolledBack())
+	}
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"fmt"
+	"sort"
+)
+
+// VideoTimelineService assembles a video's way through first review, second
+// review and the traffic pools into one chronological timeline
+type VideoTimelineService struct {
+	repo *repository.VideoTimelineRepository
+}
+
+func NewVideoTimelineService() *VideoTimelineService {
+	return &VideoTimelineService{repo: repository.NewVideoTimelineRepository()}
+}
+
+// Build returns the timeline of the given video
+func (s *VideoTimelineService) Build(video *models.TikTokVideo) (*models.VideoTimeline, error) {
+	var records []repository.VideoReviewTaskRecord
+	for _, list := range []func(int) ([]repository.VideoReviewTaskRecord, error){
+		s.repo.ListFirstReviewTasks,
+		s.repo.ListSecondReviewTasks,
+		s.repo.ListQueueTasks,
+	} {
+		stageRecords, err := list(video.ID)
+		if err != nil {
+			return nil, fmt.Errorf("load review tasks: %w", err)
+		}
+		records = append(records, stageRecords...)
+	}
+
+	transitions, err := s.repo.ListStatusTransitions(video.ID)
+	if err != nil {
+		return nil, fmt.Errorf("load status transitions: %w", err)
+	}
+	actorIDs := []int{}
+	for _, t := range transitions {
+		if t.ActorID != nil {
+			actorIDs = append(actorIDs, *t.ActorID)
+		}
+	}
+	actors, err := s.repo.GetUsernames(actorIDs)
+	if err != nil {
+		return nil, fmt.Errorf("load reviewers: %w", err)
+	}
+
+	return &models.VideoTimeline{
+		Video:  video,
+		Events: buildVideoTimeline(video, records, transitions, actors),
+	}, nil
+}
+
+// videoTimelineOrder breaks ties between events at the same instant, e.g. a
+// result and the status change written in the same transaction
+var videoTimelineOrder = map[string]int{
+	models.VideoTimelineImported:        0,
+	models.VideoTimelineDuplicateLinked: 1,
+	models.VideoTimelineTaskCreated:     2,
+	models.VideoTimelineTaskClaimed:     3,
+	models.VideoTimelineReviewSubmitted: 4,
+	models.VideoTimelineStatusChanged:   5,
+}
+
+// buildVideoTimeline turns tasks, results and status transitions into events
+// sorted by time
+func buildVideoTimeline(video *models.TikTokVideo, records []repository.VideoReviewTaskRecord, transitions []models.VideoStatusTransition, actors map[int]string) []models.VideoTimelineEvent {
+	events := []models.VideoTimelineEvent{{OccurredAt: video.CreatedAt, Type: models.VideoTimelineImported}}
+
+	if d := video.Duplicate; d != nil {
+		originalID := d.OriginalVideoID
+		events = append(events, models.VideoTimelineEvent{
+			OccurredAt: d.CreatedAt,
+			Type:       models.VideoTimelineDuplicateLinked,
+			Source:     &d.Method,
+			RelatedID:  &originalID,
+		})
+	}
+
+	for _, r := range records {
+		taskID := r.TaskID
+		events = append(events, models.VideoTimelineEvent{
+			OccurredAt: r.CreatedAt,
+			Type:       models.VideoTimelineTaskCreated,
+			Stage:      r.Stage,
+			Pool:       r.Pool,
+			TaskID:     &taskID,
+		})
+		// Only the latest claim is kept on the task; earlier claims that were
+		// returned or expired leave no trace
+		if r.ClaimedAt != nil {
+			events = append(events, models.VideoTimelineEvent{
+				OccurredAt: *r.ClaimedAt,
+				Type:       models.VideoTimelineTaskClaimed,
+				Stage:      r.Stage,
+				Pool:       r.Pool,
+				TaskID:     &taskID,
+				ReviewerID: r.ReviewerID,
+			})
+		}
+		if r.ResultAt != nil {
+			events = append(events, models.VideoTimelineEvent{
+				OccurredAt:   *r.ResultAt,
+				Type:         models.VideoTimelineReviewSubmitted,
+				Stage:        r.Stage,
+				Pool:         r.Pool,
+				TaskID:       &taskID,
+				ReviewerID:   r.ResultBy,
+				ReviewerName: r.ResultByName,
+				IsApproved:   r.IsApproved,
+				Decision:     r.Decision,
+				OverallScore: r.OverallScore,
+				Reason:       r.Reason,
+				Tags:         r.Tags,
+			})
+		}
+	}
+
+	for _, t := range transitions {
+		event := models.VideoTimelineEvent{
+			OccurredAt: t.CreatedAt,
+			Type:       models.VideoTimelineStatusChanged,
+			Stage:      videoStatusSourceStage(t.Source),
+			TaskID:     t.TaskID,
+			ReviewerID: t.ActorID,
+			Reason:     t.Reason,
+			FromStatus: t.FromStatus,
+			ToStatus:   &t.ToStatus,
+			Source:     &t.Source,
+		}
+		if t.ActorID != nil {
+			if name, ok := actors[*t.ActorID]; ok {
+				event.ReviewerName = &name
+			}
+		}
+		events = append(events, event)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].OccurredAt.Equal(events[j].OccurredAt) {
+			return events[i].OccurredAt.Before(events[j].OccurredAt)
+		}
+		return videoTimelineOrder[events[i].Type] < videoTimelineOrder[events[j].Type]
+	})
+	return events
+}
+
+// videoStatusSourceStage maps the review stages among status change sources
+// to their timeline stage; system sources have none
+func videoStatusSourceStage(source string) string {
+	switch source {
+	case models.StatusSourceFirstReview:
+		return models.VideoAnnotationStageFirstReview
+	case models.StatusSourceSecondReview:
+		return models.VideoAnnotationStageSecondReview
+	case models.StatusSourceQueue:
+		return models.VideoAnnotationStageQueue
+	}
+	return ""
+}
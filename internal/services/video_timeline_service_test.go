@@ -0,0 +1,55 @@
+package services
+
+import (
+	"comment-review-platform/internal/models"
+	"comment-review-platform/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestBuildVideoTimelineOrdersEventsAcrossStages(t *testing.T) {
+	base := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) *time.Time {
+		tm := base.Add(time.Duration(minutes) * time.Minute)
+		return &tm
+	}
+	approved, reviewer, pool := true, 7, "100k"
+	decision := "push_next_pool"
+
+	video := &models.TikTokVideo{ID: 1, CreatedAt: base}
+	records := []repository.VideoReviewTaskRecord{
+		{Stage: models.VideoAnnotationStageQueue, Pool: &pool, TaskID: 30, CreatedAt: *at(20), ClaimedAt: at(25), ResultAt: at(30), ResultBy: &reviewer, Decision: &decision},
+		{Stage: models.VideoAnnotationStageFirstReview, TaskID: 10, CreatedAt: *at(1), ClaimedAt: at(5), ResultAt: at(10), ResultBy: &reviewer, IsApproved: &approved},
+	}
+	from := "pending"
+	transitions := []models.VideoStatusTransition{
+		{FromStatus: &from, ToStatus: "first_review_completed", Source: models.StatusSourceFirstReview, ActorID: &reviewer, CreatedAt: *at(10)},
+	}
+
+	events := buildVideoTimeline(video, records, transitions, map[int]string{7: "alice"})
+
+	want := []struct {
+		kind  string
+		stage string
+	}{
+		{models.VideoTimelineImported, ""},
+		{models.VideoTimelineTaskCreated, models.VideoAnnotationStageFirstReview},
+		{models.VideoTimelineTaskClaimed, models.VideoAnnotationStageFirstReview},
+		{models.VideoTimelineReviewSubmitted, models.VideoAnnotationStageFirstReview},
+		{models.VideoTimelineStatusChanged, models.VideoAnnotationStageFirstReview},
+		{models.VideoTimelineTaskCreated, models.VideoAnnotationStageQueue},
+		{models.VideoTimelineTaskClaimed, models.VideoAnnotationStageQueue},
+		{models.VideoTimelineReviewSubmitted, models.VideoAnnotationStageQueue},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, w := range want {
+		if events[i].Type != w.kind || events[i].Stage != w.stage {
+			t.Errorf("event %d = %s/%s, want %s/%s", i, events[i].Type, events[i].Stage, w.kind, w.stage)
+		}
+	}
+	if name := events[4].ReviewerName; name == nil || *name != "alice" {
+		t.Errorf("status change reviewer name = %v, want alice", name)
+	}
+}
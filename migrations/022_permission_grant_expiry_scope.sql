@@ -0,0 +1,41 @@
+-- ============================================================
+-- Migration: 022_permission_grant_expiry_scope
+-- Description: Time-bounded and scoped permission grants, plus
+--              pool-agnostic video queue permissions usable with pool scopes.
+-- Created: 2026-10-19
+-- ============================================================
+
+-- 1. Optional expiry and scopes on user grants (NULL = permanent / global)
+ALTER TABLE user_permissions ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP NULL;
+ALTER TABLE user_permissions ADD COLUMN IF NOT EXISTS scopes TEXT[] NULL;
+
+CREATE INDEX IF NOT EXISTS idx_user_permissions_expires_at
+ON user_permissions(expires_at)
+WHERE expires_at IS NOT NULL;
+
+COMMENT ON COLUMN user_permissions.expires_at IS '授权过期时间，NULL 表示永久有效';
+COMMENT ON COLUMN user_permissions.scopes IS '授权范围，如 {pool:1m} 或 {module:user_management}，NULL 表示全局';
+
+-- 2. History of grants removed by the expiry sweeper
+CREATE TABLE IF NOT EXISTS user_permission_expirations (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    permission_key VARCHAR(100) NOT NULL,
+    scopes TEXT[] NULL,
+    granted_by INTEGER NULL,
+    expires_at TIMESTAMP NOT NULL,
+    expired_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_permission_expirations_user
+ON user_permission_expirations(user_id, expired_at DESC);
+
+COMMENT ON TABLE user_permission_expirations IS '限时授权到期记录（由后台清理任务写入）';
+
+-- 3. Pool-agnostic video queue permissions (grant globally or with scope pool:<name>)
+INSERT INTO permissions (permission_key, name, description, resource, action, category, is_active) VALUES
+    ('queue.video.claim', '领取视频流量池任务', '允许领取视频流量池任务，可通过 pool:<name> 范围限制到指定流量池', 'video_queue', 'claim', 'video_review', true),
+    ('queue.video.submit', '提交视频流量池审核结果', '允许提交视频流量池审核结果，可通过 pool:<name> 范围限制', 'video_queue', 'submit', 'video_review', true),
+    ('queue.video.return', '归还视频流量池任务', '允许归还视频流量池任务，可通过 pool:<name> 范围限制', 'video_queue', 'return', 'video_review', true),
+    ('queue.video.my', '查看视频流量池我的任务', '允许查看视频流量池我的任务，可通过 pool:<name> 范围限制', 'video_queue', 'my', 'video_review', true)
+ON CONFLICT (permission_key) DO NOTHING;
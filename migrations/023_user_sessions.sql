@@ -0,0 +1,56 @@
+-- ============================================================
+-- Migration: 023_user_sessions
+-- Description: Server-side login sessions backing rotating refresh tokens,
+--              per-device logout and admin force-logout.
+-- Created: 2026-10-19
+-- ============================================================
+
+-- 1. One row per login (device). The refresh token is stored as a SHA-256 hash
+--    and rotated on every refresh; the previous hash is kept for reuse detection.
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id UUID PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    refresh_token_hash VARCHAR(64) NOT NULL,
+    previous_token_hash VARCHAR(64) NULL,
+    access_jti VARCHAR(64) NOT NULL,
+    access_expires_at TIMESTAMP NOT NULL,
+    user_agent TEXT NULL,
+    ip_address VARCHAR(64) NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NULL,
+    revoked_reason VARCHAR(50) NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_sessions_refresh_hash
+ON user_sessions(refresh_token_hash);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_previous_hash
+ON user_sessions(previous_token_hash)
+WHERE previous_token_hash IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_active
+ON user_sessions(user_id, last_used_at DESC)
+WHERE revoked_at IS NULL;
+
+COMMENT ON TABLE user_sessions IS '用户登录会话（刷新令牌、设备登出、强制下线）';
+COMMENT ON COLUMN user_sessions.refresh_token_hash IS '当前刷新令牌的 SHA-256 哈希';
+COMMENT ON COLUMN user_sessions.previous_token_hash IS '上一个刷新令牌哈希，用于检测令牌重放';
+COMMENT ON COLUMN user_sessions.access_jti IS '最近签发的访问令牌 jti，撤销时写入 Redis 黑名单';
+COMMENT ON COLUMN user_sessions.revoked_reason IS '撤销原因：logout / user_revoked / admin_force_logout / user_deleted / status_changed / token_reuse';
+
+-- 2. Permission for admin force-logout
+INSERT INTO permissions (permission_key, name, description, resource, action, category, is_active) VALUES
+    ('users:sessions:revoke', '强制用户下线', '允许撤销指定用户的全部登录会话', 'users', 'sessions_revoke', 'users', true)
+ON CONFLICT (permission_key) DO NOTHING;
+
+INSERT INTO user_permissions (user_id, permission_key, granted_by)
+SELECT u.id, p.permission_key, u.id
+FROM users u
+CROSS JOIN (
+    SELECT permission_key FROM permissions
+    WHERE permission_key IN ('users:sessions:revoke')
+) p
+WHERE u.role = 'admin'
+ON CONFLICT (user_id, permission_key) DO NOTHING;
@@ -0,0 +1,23 @@
+-- ============================================================
+-- Migration: 024_user_identities
+-- Description: Link platform users to external OIDC identities (issuer + subject)
+--              for single sign-on login.
+-- Created: 2026-10-19
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
+
+COMMENT ON TABLE user_identities IS '用户与外部 OIDC 身份的绑定关系';
+COMMENT ON COLUMN user_identities.issuer IS 'OIDC 身份提供方 issuer';
+COMMENT ON COLUMN user_identities.subject IS 'OIDC sub 声明（身份提供方内的用户唯一标识）';
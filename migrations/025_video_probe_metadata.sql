@@ -0,0 +1,33 @@
+-- ============================================================
+-- Migration: 025_video_probe_metadata
+-- Description: Store real container metadata (from the MP4/MOV moov box)
+--              on tiktok_videos. Existing rows keep NULL until re-probed;
+--              their size-based duration estimate is cleared.
+-- Created: 2026-10-19
+-- ============================================================
+
+ALTER TABLE tiktok_videos ADD COLUMN IF NOT EXISTS width INTEGER NULL;
+ALTER TABLE tiktok_videos ADD COLUMN IF NOT EXISTS height INTEGER NULL;
+ALTER TABLE tiktok_videos ADD COLUMN IF NOT EXISTS video_codec VARCHAR(20) NULL;
+ALTER TABLE tiktok_videos ADD COLUMN IF NOT EXISTS frame_rate NUMERIC(7, 2) NULL;
+ALTER TABLE tiktok_videos ADD COLUMN IF NOT EXISTS bitrate BIGINT NULL;
+ALTER TABLE tiktok_videos ADD COLUMN IF NOT EXISTS has_audio BOOLEAN NULL;
+ALTER TABLE tiktok_videos ADD COLUMN IF NOT EXISTS audio_codec VARCHAR(20) NULL;
+ALTER TABLE tiktok_videos ADD COLUMN IF NOT EXISTS probed_at TIMESTAMP NULL;
+
+-- Durations written before probing were estimated from file size; drop them so
+-- stats do not treat them as real. The probe backfill fills them in again.
+UPDATE tiktok_videos SET duration = NULL WHERE probed_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tiktok_videos_unprobed
+ON tiktok_videos(id)
+WHERE probed_at IS NULL;
+
+COMMENT ON COLUMN tiktok_videos.width IS '视频宽度（像素，来自 moov 元数据）';
+COMMENT ON COLUMN tiktok_videos.height IS '视频高度（像素）';
+COMMENT ON COLUMN tiktok_videos.video_codec IS '视频编码，如 h264 / hevc / av1';
+COMMENT ON COLUMN tiktok_videos.frame_rate IS '平均帧率（fps）';
+COMMENT ON COLUMN tiktok_videos.bitrate IS '整体码率（bit/s）';
+COMMENT ON COLUMN tiktok_videos.has_audio IS '是否包含音轨';
+COMMENT ON COLUMN tiktok_videos.audio_codec IS '音频编码，如 aac / opus';
+COMMENT ON COLUMN tiktok_videos.probed_at IS '元数据解析时间，NULL 表示尚未解析';
@@ -0,0 +1,49 @@
+-- ============================================================
+-- Migration: 026_video_import_jobs
+-- Description: Run R2 video imports as persisted background jobs with a
+--              listing cursor, progress counters and per-item errors so
+--              large prefixes can be cancelled and resumed.
+-- Created: 2026-10-19
+-- ============================================================
+
+-- 1. One row per import run. cursor is the last R2 key fully handled; a
+--    resumed job continues listing after it. heartbeat_at lets another
+--    instance take over a job whose worker died.
+CREATE TABLE IF NOT EXISTS video_import_jobs (
+    id SERIAL PRIMARY KEY,
+    r2_path_prefix VARCHAR(500) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'running', 'completed', 'failed', 'canceled')),
+    cursor VARCHAR(1024) NULL,
+    listed_count INTEGER NOT NULL DEFAULT 0,
+    imported_count INTEGER NOT NULL DEFAULT 0,
+    skipped_count INTEGER NOT NULL DEFAULT 0,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NULL,
+    created_by INTEGER NULL REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP NULL,
+    heartbeat_at TIMESTAMP NULL,
+    completed_at TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_video_import_jobs_status ON video_import_jobs(status);
+CREATE INDEX IF NOT EXISTS idx_video_import_jobs_created_at ON video_import_jobs(created_at DESC);
+
+-- 2. Items that could not be imported
+CREATE TABLE IF NOT EXISTS video_import_job_errors (
+    id SERIAL PRIMARY KEY,
+    job_id INTEGER NOT NULL REFERENCES video_import_jobs(id) ON DELETE CASCADE,
+    video_key VARCHAR(1024) NOT NULL,
+    error_message TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_video_import_job_errors_job_id ON video_import_job_errors(job_id, id);
+
+COMMENT ON TABLE video_import_jobs IS 'R2 视频导入后台任务';
+COMMENT ON COLUMN video_import_jobs.cursor IS '最后一个已处理的 R2 对象 key，恢复时从其之后继续列举';
+COMMENT ON COLUMN video_import_jobs.listed_count IS '已列举并处理的视频文件数';
+COMMENT ON COLUMN video_import_jobs.heartbeat_at IS '执行进程最近一次上报进度的时间，超时视为中断';
+COMMENT ON TABLE video_import_job_errors IS '视频导入失败明细';
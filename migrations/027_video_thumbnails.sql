@@ -0,0 +1,18 @@
+-- ============================================================
+-- Migration: 027_video_thumbnails
+-- Description: Poster frame and keyframe strip images stored in R2 so
+--              reviewers can triage a video without streaming it.
+-- Created: 2026-10-19
+-- ============================================================
+
+ALTER TABLE tiktok_videos ADD COLUMN IF NOT EXISTS thumbnail_key VARCHAR(500) NULL;
+ALTER TABLE tiktok_videos ADD COLUMN IF NOT EXISTS keyframe_strip_key VARCHAR(500) NULL;
+ALTER TABLE tiktok_videos ADD COLUMN IF NOT EXISTS thumbnails_generated_at TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tiktok_videos_without_thumbnails
+ON tiktok_videos(id)
+WHERE thumbnails_generated_at IS NULL;
+
+COMMENT ON COLUMN tiktok_videos.thumbnail_key IS '封面帧在 R2 中的 key';
+COMMENT ON COLUMN tiktok_videos.keyframe_strip_key IS '关键帧拼图在 R2 中的 key，无法提取多帧时为 NULL';
+COMMENT ON COLUMN tiktok_videos.thumbnails_generated_at IS '缩略图生成时间，NULL 表示尚未生成';
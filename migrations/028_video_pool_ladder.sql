@@ -0,0 +1,70 @@
+-- ============================================================
+-- Migration: 028_video_pool_ladder
+-- Description: Traffic pools as data instead of a hard-coded 100k/1m/10m
+--              ladder. Each pool names the pool a video is promoted to, its
+--              claim and tag limits, the tag set it reviews with and the video
+--              status applied when it is the top of its ladder.
+-- Created: 2026-10-19
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS video_pools (
+    name VARCHAR(10) PRIMARY KEY,
+    display_name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    next_pool VARCHAR(10) NULL REFERENCES video_pools(name),
+    terminal_status VARCHAR(30) NULL,
+    claim_max_count INTEGER NOT NULL DEFAULT 50 CHECK (claim_max_count BETWEEN 1 AND 200),
+    max_tags INTEGER NOT NULL DEFAULT 3 CHECK (max_tags BETWEEN 0 AND 20),
+    tag_set VARCHAR(10) NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT video_pools_next_not_self CHECK (next_pool IS NULL OR next_pool <> name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_video_pools_sort_order ON video_pools(sort_order, name);
+
+-- Seed the existing ladder top-down so each next_pool already exists
+INSERT INTO video_pools (name, display_name, description, sort_order, next_pool, terminal_status) VALUES
+    ('10m', '1000万流量池', '质检流量池，通过后视频确认为顶级流量', 30, NULL, '10m_confirmed'),
+    ('1m', '100万流量池', '', 20, '10m', NULL),
+    ('100k', '10万流量池', '入口流量池', 10, '1m', NULL)
+ON CONFLICT (name) DO NOTHING;
+
+-- Queue decisions set natural_pool, removed_violation and each pool's
+-- terminal status, none of which the original status list allowed
+ALTER TABLE tiktok_videos DROP CONSTRAINT IF EXISTS tiktok_videos_status_check;
+
+-- Pool membership is now enforced by the pool table instead of a fixed list
+ALTER TABLE video_queue_tasks DROP CONSTRAINT IF EXISTS video_queue_tasks_pool_check;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_video_queue_tasks_pool') THEN
+        ALTER TABLE video_queue_tasks
+            ADD CONSTRAINT fk_video_queue_tasks_pool FOREIGN KEY (pool) REFERENCES video_pools(name);
+    END IF;
+END $$;
+
+INSERT INTO permissions (permission_key, name, description, resource, action, category, is_active) VALUES
+    ('video-pools:manage', '管理视频流量池', '允许新增、调整和停用视频流量池', 'video_pools', 'manage', 'video_review', true)
+ON CONFLICT (permission_key) DO NOTHING;
+
+INSERT INTO user_permissions (user_id, permission_key, granted_by)
+SELECT u.id, p.permission_key, u.id
+FROM users u
+CROSS JOIN (
+    SELECT permission_key FROM permissions
+    WHERE permission_key IN ('video-pools:manage')
+) p
+WHERE u.role = 'admin'
+ON CONFLICT (user_id, permission_key) DO NOTHING;
+
+COMMENT ON TABLE video_pools IS '视频流量池配置，按 next_pool 组成晋级阶梯';
+COMMENT ON COLUMN video_pools.next_pool IS '推送下一流量池时进入的池，NULL 表示阶梯顶端';
+COMMENT ON COLUMN video_pools.terminal_status IS '阶梯顶端池推送时写入视频的状态，默认 <name>_confirmed';
+COMMENT ON COLUMN video_pools.claim_max_count IS '单次最多领取的任务数';
+COMMENT ON COLUMN video_pools.max_tags IS '单次审核最多选择的标签数';
+COMMENT ON COLUMN video_pools.tag_set IS '使用哪个池的标签（video_quality_tags.queue_id），NULL 表示使用本池';
+COMMENT ON TABLE video_queue_tasks IS 'Video review tasks organized by traffic pool (see video_pools)';
@@ -0,0 +1,29 @@
+-- ============================================================
+-- Migration: 029_video_review_annotations
+-- Description: Time-ranged annotations reviewers attach to a video review,
+--              so later reviewers can jump to the flagged part of the clip.
+-- Created: 2026-10-19
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS video_review_annotations (
+    id SERIAL PRIMARY KEY,
+    video_id INTEGER NOT NULL REFERENCES tiktok_videos(id) ON DELETE CASCADE,
+    review_stage VARCHAR(20) NOT NULL CHECK (review_stage IN ('first_review', 'second_review', 'queue')),
+    task_id INTEGER NOT NULL,
+    reviewer_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    start_seconds NUMERIC(10, 3) NOT NULL CHECK (start_seconds >= 0),
+    end_seconds NUMERIC(10, 3) NOT NULL,
+    tag VARCHAR(50) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT video_review_annotations_range CHECK (end_seconds >= start_seconds)
+);
+
+CREATE INDEX IF NOT EXISTS idx_video_review_annotations_task ON video_review_annotations(review_stage, task_id);
+CREATE INDEX IF NOT EXISTS idx_video_review_annotations_video ON video_review_annotations(video_id, start_seconds);
+
+COMMENT ON TABLE video_review_annotations IS '视频审核时间段标注';
+COMMENT ON COLUMN video_review_annotations.review_stage IS '标注所属审核环节: first_review, second_review, queue';
+COMMENT ON COLUMN video_review_annotations.task_id IS '对应环节的任务 ID（video_first_review_tasks / video_second_review_tasks / video_queue_tasks）';
+COMMENT ON COLUMN video_review_annotations.start_seconds IS '起始时间（秒）';
+COMMENT ON COLUMN video_review_annotations.end_seconds IS '结束时间（秒），与起始相同表示单个时间点';
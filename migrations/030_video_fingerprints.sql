@@ -0,0 +1,61 @@
+-- ============================================================
+-- Migration: 030_video_fingerprints
+-- Description: Perceptual fingerprints of imported videos and the duplicates
+--              found with them, so re-uploads of a clip under another key
+--              inherit the earlier decision or are flagged to the reviewer.
+-- Created: 2026-10-19
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS video_fingerprints (
+    video_id INTEGER PRIMARY KEY REFERENCES tiktok_videos(id) ON DELETE CASCADE,
+    method VARCHAR(20) NOT NULL CHECK (method IN ('frames', 'container')),
+    frame_hashes BIGINT[] NOT NULL DEFAULT '{}',
+    container_hash VARCHAR(64) NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_video_fingerprints_container_hash
+ON video_fingerprints(container_hash)
+WHERE container_hash IS NOT NULL;
+
+-- Near-duplicate index: every frame hash split into 11 bands of 5 or 6 bits.
+-- Frames up to 10 bits apart share at least one band, so candidates are found
+-- by exact lookup and ranked by how many bands they share.
+CREATE TABLE IF NOT EXISTS video_fingerprint_bands (
+    band SMALLINT NOT NULL,
+    value INTEGER NOT NULL,
+    video_id INTEGER NOT NULL REFERENCES tiktok_videos(id) ON DELETE CASCADE,
+    PRIMARY KEY (band, value, video_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_video_fingerprint_bands_video ON video_fingerprint_bands(video_id);
+
+CREATE TABLE IF NOT EXISTS video_duplicates (
+    video_id INTEGER PRIMARY KEY REFERENCES tiktok_videos(id) ON DELETE CASCADE,
+    original_video_id INTEGER NOT NULL REFERENCES tiktok_videos(id) ON DELETE CASCADE,
+    method VARCHAR(20) NOT NULL CHECK (method IN ('frames', 'container')),
+    similarity NUMERIC(5, 4) NOT NULL,
+    action VARCHAR(20) NOT NULL DEFAULT 'flagged' CHECK (action IN ('flagged', 'inherited')),
+    inherited_status VARCHAR(30) NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP NULL,
+    CONSTRAINT video_duplicates_not_self CHECK (original_video_id <> video_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_video_duplicates_original ON video_duplicates(original_video_id);
+CREATE INDEX IF NOT EXISTS idx_video_duplicates_flagged ON video_duplicates(video_id) WHERE action = 'flagged';
+
+ALTER TABLE tiktok_videos ADD COLUMN IF NOT EXISTS fingerprinted_at TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tiktok_videos_unfingerprinted
+ON tiktok_videos(id)
+WHERE fingerprinted_at IS NULL;
+
+COMMENT ON TABLE video_fingerprints IS '视频感知指纹';
+COMMENT ON COLUMN video_fingerprints.method IS 'frames: 抽帧 dHash；container: 无法抽帧时按视频轨样本表计算的容器哈希';
+COMMENT ON COLUMN video_fingerprints.frame_hashes IS '按相对位置均匀抽取的帧的 64 位 dHash';
+COMMENT ON TABLE video_fingerprint_bands IS '帧哈希按 5~6 位分段的近重复索引';
+COMMENT ON TABLE video_duplicates IS '检测到的重复视频，original_video_id 指向最早导入的同一视频';
+COMMENT ON COLUMN video_duplicates.action IS 'flagged: 提示审核员；inherited: 已继承原视频的审核结果';
+COMMENT ON COLUMN video_duplicates.inherited_status IS '继承时写入的视频状态';
+COMMENT ON COLUMN tiktok_videos.fingerprinted_at IS '指纹计算时间，NULL 表示尚未计算';
@@ -0,0 +1,58 @@
+-- ============================================================
+-- Migration: 031_comment_clusters
+-- Description: Near-duplicate clustering of pending first review comments.
+--              Each pending task gets a MinHash signature of its normalized
+--              text; signatures that match are grouped into a cluster that a
+--              reviewer can decide in one action.
+-- Created: 2026-10-19
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS comment_clusters (
+    id SERIAL PRIMARY KEY,
+    representative_task_id INTEGER NOT NULL REFERENCES review_tasks(id) ON DELETE CASCADE,
+    representative_text TEXT NOT NULL,
+    minhash INTEGER[] NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'decided', 'closed')),
+    decided_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    decided_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_clusters_status ON comment_clusters(status, updated_at DESC);
+
+CREATE TABLE IF NOT EXISTS comment_signatures (
+    task_id INTEGER PRIMARY KEY REFERENCES review_tasks(id) ON DELETE CASCADE,
+    comment_id BIGINT NOT NULL,
+    minhash INTEGER[] NOT NULL,
+    cluster_id INTEGER REFERENCES comment_clusters(id) ON DELETE SET NULL,
+    similarity NUMERIC(5, 4),
+    excluded_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_signatures_cluster ON comment_signatures(cluster_id) WHERE cluster_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_comment_signatures_unclustered ON comment_signatures(created_at) WHERE cluster_id IS NULL;
+
+INSERT INTO permissions (permission_key, name, description, resource, action, category, is_active) VALUES
+    ('tasks:comment-clusters:view', '查看相似评论聚类', '允许查看待审评论的近似重复聚类', 'tasks', 'read', '审核任务-一审', true),
+    ('tasks:comment-clusters:decide', '整簇审核相似评论', '允许对一个聚类内的全部评论一次性提交审核结果', 'tasks', 'update', '审核任务-一审', true)
+ON CONFLICT (permission_key) DO NOTHING;
+
+INSERT INTO user_permissions (user_id, permission_key, granted_by)
+SELECT u.id, p.permission_key, u.id
+FROM users u
+CROSS JOIN (
+    SELECT permission_key FROM permissions
+    WHERE permission_key IN ('tasks:comment-clusters:view', 'tasks:comment-clusters:decide')
+) p
+WHERE u.role = 'admin'
+ON CONFLICT (user_id, permission_key) DO NOTHING;
+
+COMMENT ON TABLE comment_clusters IS '近似重复的待审评论聚类（如刷屏广告）';
+COMMENT ON COLUMN comment_clusters.representative_task_id IS '聚类代表评论的一审任务，新评论与其签名比较';
+COMMENT ON COLUMN comment_clusters.minhash IS '代表评论的 MinHash 签名';
+COMMENT ON COLUMN comment_clusters.status IS 'open: 仍有待审成员; decided: 已整簇审核; closed: 成员已全部单独审核';
+COMMENT ON TABLE comment_signatures IS '待审评论的文本签名及所属聚类';
+COMMENT ON COLUMN comment_signatures.similarity IS '与聚类代表评论的估计相似度';
+COMMENT ON COLUMN comment_signatures.excluded_at IS '整簇审核时被审核员剔除的时间，剔除后按普通任务单独审核';
@@ -0,0 +1,27 @@
+-- ============================================================
+-- Migration: 032_video_status_transitions
+-- Description: Log of every tiktok_videos.status change with the stage that
+--              made it, the task and reviewer behind it, for the per-video
+--              review timeline.
+-- Created: 2026-10-19
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS video_status_transitions (
+    id BIGSERIAL PRIMARY KEY,
+    video_id INTEGER NOT NULL REFERENCES tiktok_videos(id) ON DELETE CASCADE,
+    from_status VARCHAR(30),
+    to_status VARCHAR(30) NOT NULL,
+    source VARCHAR(30) NOT NULL,
+    task_id INTEGER,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_video_status_transitions_video ON video_status_transitions(video_id, created_at);
+
+COMMENT ON TABLE video_status_transitions IS '视频状态变更记录';
+COMMENT ON COLUMN video_status_transitions.from_status IS '变更前状态';
+COMMENT ON COLUMN video_status_transitions.source IS '变更来源: first_review, second_review, queue, reconciliation, duplicate';
+COMMENT ON COLUMN video_status_transitions.task_id IS '来源环节的任务 ID（对应 source 的任务表）';
+COMMENT ON COLUMN video_status_transitions.actor_id IS '操作审核员，系统变更为 NULL';
@@ -0,0 +1,42 @@
+-- ============================================================
+-- Migration: 033_moderation_status_machine
+-- Description: Log of every comment.moderation_status change, parallel to
+--              video_status_transitions, now that comment and video status
+--              changes are validated against a central transition graph;
+--              permission to view that graph.
+-- Created: 2026-10-19
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS comment_status_transitions (
+    id BIGSERIAL PRIMARY KEY,
+    comment_id BIGINT NOT NULL REFERENCES comment(id) ON DELETE CASCADE,
+    from_status VARCHAR(30),
+    to_status VARCHAR(30) NOT NULL,
+    source VARCHAR(30) NOT NULL,
+    task_id INTEGER,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_status_transitions_comment ON comment_status_transitions(comment_id, created_at);
+
+INSERT INTO permissions (permission_key, name, description, resource, action, category, is_active) VALUES
+    ('moderation:status-graph:read', '查看审核状态流转图', '允许查看评论与视频审核状态的合法流转关系', 'moderation_status', 'read', 'system', true)
+ON CONFLICT (permission_key) DO NOTHING;
+
+INSERT INTO user_permissions (user_id, permission_key, granted_by)
+SELECT u.id, p.permission_key, u.id
+FROM users u
+CROSS JOIN (
+    SELECT permission_key FROM permissions
+    WHERE permission_key = 'moderation:status-graph:read'
+) p
+WHERE u.role = 'admin'
+ON CONFLICT (user_id, permission_key) DO NOTHING;
+
+COMMENT ON TABLE comment_status_transitions IS '评论审核状态变更记录';
+COMMENT ON COLUMN comment_status_transitions.from_status IS '变更前状态';
+COMMENT ON COLUMN comment_status_transitions.source IS '变更来源: first_review, second_review';
+COMMENT ON COLUMN comment_status_transitions.task_id IS '来源环节的任务 ID（一审为 review_tasks，二审为 second_review_tasks）';
+COMMENT ON COLUMN comment_status_transitions.actor_id IS '操作审核员，系统变更为 NULL';
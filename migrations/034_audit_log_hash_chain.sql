@@ -0,0 +1,69 @@
+-- ============================================================
+-- Migration: 034_audit_log_hash_chain
+-- Description: Tamper-evident audit log. Every entry is chained to the
+--              previous entry of the same day by hash; chain heads are
+--              checkpointed with an HMAC signature, and days deleted by
+--              retention leave a signed tombstone. Rows written before this
+--              migration stay unchained.
+-- Created: 2026-10-19
+-- ============================================================
+
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS chain_day DATE;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS chain_seq BIGINT;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS prev_hash CHAR(64);
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS entry_hash CHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_logs_chain ON audit_logs(chain_day, chain_seq) WHERE chain_seq IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS audit_log_chain_heads (
+    chain_day DATE PRIMARY KEY,
+    last_seq BIGINT NOT NULL DEFAULT 0,
+    last_hash CHAR(64) NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS audit_log_checkpoints (
+    id BIGSERIAL PRIMARY KEY,
+    chain_day DATE NOT NULL,
+    seq BIGINT NOT NULL,
+    entry_hash CHAR(64) NOT NULL,
+    signature VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (chain_day, seq)
+);
+
+CREATE TABLE IF NOT EXISTS audit_log_tombstones (
+    id BIGSERIAL PRIMARY KEY,
+    chain_day DATE NOT NULL UNIQUE,
+    first_seq BIGINT NOT NULL,
+    last_seq BIGINT NOT NULL,
+    entry_count BIGINT NOT NULL,
+    first_prev_hash CHAR(64) NOT NULL,
+    last_hash CHAR(64) NOT NULL,
+    oldest_at TIMESTAMP NOT NULL,
+    newest_at TIMESTAMP NOT NULL,
+    signature VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO permissions (permission_key, name, description, resource, action, category, is_active) VALUES
+    ('audit.logs.verify', '校验审计日志', '允许校验审计日志哈希链，发现被篡改或删除的记录', 'audit_logs', 'verify', 'system', true)
+ON CONFLICT (permission_key) DO NOTHING;
+
+INSERT INTO user_permissions (user_id, permission_key, granted_by)
+SELECT u.id, p.permission_key, u.id
+FROM users u
+CROSS JOIN (
+    SELECT permission_key FROM permissions
+    WHERE permission_key = 'audit.logs.verify'
+) p
+WHERE u.role = 'admin'
+ON CONFLICT (user_id, permission_key) DO NOTHING;
+
+COMMENT ON COLUMN audit_logs.chain_day IS '所属哈希链（按 created_at 日期分链）';
+COMMENT ON COLUMN audit_logs.chain_seq IS '链内序号，从 1 开始连续递增；迁移前的记录为 NULL';
+COMMENT ON COLUMN audit_logs.prev_hash IS '上一条记录的哈希，首条为该日的创世哈希';
+COMMENT ON COLUMN audit_logs.entry_hash IS 'SHA-256(prev_hash || 记录规范化内容)';
+COMMENT ON TABLE audit_log_chain_heads IS '各日哈希链的链头，写入时行锁保证串行追加';
+COMMENT ON TABLE audit_log_checkpoints IS '链头检查点，签名密钥保存在数据库之外';
+COMMENT ON TABLE audit_log_tombstones IS '按保留策略删除的日期的签名摘要';
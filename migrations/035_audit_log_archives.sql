@@ -0,0 +1,33 @@
+-- ============================================================
+-- Migration: 035_audit_log_archives
+-- Description: Manifest of audit log archives. Retention no longer deletes
+--              audit logs: rows past the hot window are written to R2 as
+--              gzip-compressed NDJSON, one or more parts per day, and only
+--              then removed from audit_logs. Queries reaching into archived
+--              ranges read the parts listed here back from R2.
+-- Created: 2026-10-19
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS audit_log_archives (
+    id BIGSERIAL PRIMARY KEY,
+    archive_day DATE NOT NULL,
+    part INTEGER NOT NULL,
+    object_key TEXT NOT NULL UNIQUE,
+    entry_count BIGINT NOT NULL,
+    first_seq BIGINT,
+    last_seq BIGINT,
+    oldest_at TIMESTAMP NOT NULL,
+    newest_at TIMESTAMP NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (archive_day, part)
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_archives_range ON audit_log_archives(oldest_at, newest_at);
+
+COMMENT ON TABLE audit_log_archives IS '审计日志归档清单，每行对应 R2 中的一个 gzip NDJSON 文件';
+COMMENT ON COLUMN audit_log_archives.part IS '同一天内的分片序号，从 1 开始';
+COMMENT ON COLUMN audit_log_archives.first_seq IS '分片覆盖的哈希链起始序号；迁移前未入链的记录为 NULL';
+COMMENT ON COLUMN audit_log_archives.last_seq IS '分片覆盖的哈希链结束序号，与该日墓碑记录衔接';
+COMMENT ON COLUMN audit_log_archives.sha256 IS '压缩文件的 SHA-256，读取归档时校验';
@@ -0,0 +1,25 @@
+-- ============================================================
+-- Migration: 036_audit_log_streaming_exports
+-- Description: Audit log exports run as background jobs that stream rows
+--              from a server-side cursor into a multipart upload to R2.
+--              Adds the compression option and the progress the job
+--              reports while it runs; updated_at doubles as the job's
+--              heartbeat so exports interrupted by a restart can be failed.
+-- Created: 2026-10-19
+-- ============================================================
+
+ALTER TABLE audit_log_exports ALTER COLUMN row_count TYPE BIGINT;
+ALTER TABLE audit_log_exports ADD COLUMN IF NOT EXISTS compression VARCHAR(10) NOT NULL DEFAULT 'none';
+ALTER TABLE audit_log_exports ADD COLUMN IF NOT EXISTS total_rows BIGINT;
+ALTER TABLE audit_log_exports ADD COLUMN IF NOT EXISTS processed_rows BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE audit_log_exports ADD COLUMN IF NOT EXISTS size_bytes BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE audit_log_exports ADD COLUMN IF NOT EXISTS started_at TIMESTAMP;
+ALTER TABLE audit_log_exports ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP;
+ALTER TABLE audit_log_exports ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+COMMENT ON COLUMN audit_log_exports.status IS '导出状态: queued / processing / completed / failed';
+COMMENT ON COLUMN audit_log_exports.compression IS '压缩方式: none / gzip';
+COMMENT ON COLUMN audit_log_exports.total_rows IS '导出开始时统计的总行数';
+COMMENT ON COLUMN audit_log_exports.processed_rows IS '已写入的行数';
+COMMENT ON COLUMN audit_log_exports.size_bytes IS '已上传的字节数';
+COMMENT ON COLUMN audit_log_exports.updated_at IS '最近一次进度更新时间，用作任务心跳';
@@ -0,0 +1,48 @@
+-- ============================================================
+-- Migration: 037_bug_report_triage
+-- Description: Bug reports become trackable issues: a status, an assigned
+--              admin, a resolution note and a link to the report they
+--              duplicate, plus threaded comments. Notifications gain a
+--              recipient so a reporter can be told when their report
+--              changes status without broadcasting to everyone.
+-- Created: 2026-10-19
+-- ============================================================
+
+ALTER TABLE bug_reports ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'new'
+    CHECK (status IN ('new', 'triaged', 'in_progress', 'resolved', 'wontfix'));
+ALTER TABLE bug_reports ADD COLUMN IF NOT EXISTS assignee_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE bug_reports ADD COLUMN IF NOT EXISTS duplicate_of INTEGER REFERENCES bug_reports(id) ON DELETE SET NULL;
+ALTER TABLE bug_reports ADD COLUMN IF NOT EXISTS resolution TEXT;
+ALTER TABLE bug_reports ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP;
+ALTER TABLE bug_reports ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_bug_reports_status ON bug_reports(status);
+CREATE INDEX IF NOT EXISTS idx_bug_reports_assignee_id ON bug_reports(assignee_id);
+CREATE INDEX IF NOT EXISTS idx_bug_reports_duplicate_of ON bug_reports(duplicate_of);
+
+COMMENT ON COLUMN bug_reports.status IS '处理状态: new / triaged / in_progress / resolved / wontfix';
+COMMENT ON COLUMN bug_reports.assignee_id IS '负责处理的管理员';
+COMMENT ON COLUMN bug_reports.duplicate_of IS '重复反馈所指向的原始反馈';
+COMMENT ON COLUMN bug_reports.resolution IS '处理结论，反馈关闭时填写';
+COMMENT ON COLUMN bug_reports.resolved_at IS '反馈关闭（resolved / wontfix）的时间';
+
+CREATE TABLE IF NOT EXISTS bug_report_comments (
+    id SERIAL PRIMARY KEY,
+    report_id INTEGER NOT NULL REFERENCES bug_reports(id) ON DELETE CASCADE,
+    parent_id INTEGER REFERENCES bug_report_comments(id) ON DELETE CASCADE,
+    author_id INTEGER NOT NULL REFERENCES users(id),
+    body TEXT NOT NULL,
+    internal BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bug_report_comments_report_id ON bug_report_comments(report_id, created_at);
+
+COMMENT ON TABLE bug_report_comments IS '错误反馈的讨论评论，parent_id 构成回复线程';
+COMMENT ON COLUMN bug_report_comments.internal IS '内部备注，仅管理员可见';
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS recipient_id INTEGER REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_recipient_id ON notifications(recipient_id) WHERE recipient_id IS NOT NULL;
+
+COMMENT ON COLUMN notifications.recipient_id IS '定向通知的接收用户，全局通知为空';
@@ -0,0 +1,40 @@
+-- ============================================================
+-- Migration: 038_bug_report_error_links
+-- Description: Bug reports carry the trace IDs of the requests that failed
+--              in the reporter's browser. The matching failed audit log
+--              entries are copied onto the report when it is created, so
+--              the server-side error stays visible after the audit log
+--              itself is archived. Reports are grouped by the error codes
+--              they are linked to.
+-- Created: 2026-10-19
+-- ============================================================
+
+ALTER TABLE bug_reports ADD COLUMN IF NOT EXISTS trace_ids TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE bug_reports ADD COLUMN IF NOT EXISTS error_signature VARCHAR(500);
+
+CREATE INDEX IF NOT EXISTS idx_bug_reports_error_signature ON bug_reports(error_signature) WHERE error_signature IS NOT NULL;
+
+COMMENT ON COLUMN bug_reports.trace_ids IS '前端提交的最近失败请求的 TraceID';
+COMMENT ON COLUMN bug_reports.error_signature IS '关联服务端错误的错误码签名（去重排序后以逗号连接），用于归并同类反馈';
+
+CREATE TABLE IF NOT EXISTS bug_report_errors (
+    id SERIAL PRIMARY KEY,
+    report_id INTEGER NOT NULL REFERENCES bug_reports(id) ON DELETE CASCADE,
+    trace_id VARCHAR(100) NOT NULL,
+    audit_log_id UUID NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    endpoint VARCHAR(500),
+    http_method VARCHAR(10),
+    status_code INTEGER,
+    error_code VARCHAR(100),
+    error_type VARCHAR(50),
+    error_message TEXT,
+    error_stack TEXT,
+    UNIQUE (report_id, trace_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bug_report_errors_report_id ON bug_report_errors(report_id);
+CREATE INDEX IF NOT EXISTS idx_bug_report_errors_error_code ON bug_report_errors(error_code);
+
+COMMENT ON TABLE bug_report_errors IS '错误反馈关联的服务端错误，复制自 audit_logs，不随审计日志归档而丢失';
+COMMENT ON COLUMN bug_report_errors.audit_log_id IS '来源审计日志 ID（日志归档后可能已不在 audit_logs 中）';
@@ -0,0 +1,72 @@
+-- ============================================================
+-- Migration: 039_error_issues
+-- Description: Failed requests (4xx/5xx audit log entries) are grouped into
+--              error issues by a fingerprint of the normalized endpoint,
+--              error code and top stack frames. Each issue tracks when it
+--              was first and last seen, how often it happened and which
+--              users it affected. Admins resolve, ignore or reopen issues;
+--              a resolved issue that happens again is reopened as a
+--              regression.
+-- Created: 2026-10-19
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS error_issues (
+    id SERIAL PRIMARY KEY,
+    fingerprint VARCHAR(64) NOT NULL UNIQUE,
+    http_method VARCHAR(10) NOT NULL,
+    endpoint VARCHAR(500) NOT NULL,
+    error_code VARCHAR(100) NOT NULL,
+    error_type VARCHAR(50),
+    stack_frames TEXT[] NOT NULL DEFAULT '{}',
+    status_code INTEGER NOT NULL,
+    title TEXT,
+    sample_stack TEXT,
+    last_trace_id VARCHAR(100),
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved', 'ignored')),
+    occurrence_count BIGINT NOT NULL DEFAULT 0,
+    affected_users INTEGER NOT NULL DEFAULT 0,
+    first_seen_at TIMESTAMP NOT NULL,
+    last_seen_at TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP,
+    resolved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    regressed_at TIMESTAMP,
+    regression_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_error_issues_status_last_seen ON error_issues(status, last_seen_at DESC);
+CREATE INDEX IF NOT EXISTS idx_error_issues_error_code ON error_issues(error_code);
+
+COMMENT ON TABLE error_issues IS '错误问题：按指纹归并的 4xx/5xx 失败请求';
+COMMENT ON COLUMN error_issues.fingerprint IS '请求方法、归一化接口路径、错误码与栈顶帧的 SHA-256';
+COMMENT ON COLUMN error_issues.stack_frames IS '参与指纹计算的栈顶函数（不含文件行号，避免发版后指纹变化）';
+COMMENT ON COLUMN error_issues.title IS '最近一次发生时的错误信息';
+COMMENT ON COLUMN error_issues.status IS '状态：open-未解决, resolved-已解决, ignored-已忽略';
+COMMENT ON COLUMN error_issues.affected_users IS '受影响的去重用户数（匿名请求不计）';
+COMMENT ON COLUMN error_issues.regressed_at IS '最近一次已解决后再次发生而自动重新打开的时间';
+
+CREATE TABLE IF NOT EXISTS error_issue_users (
+    issue_id INTEGER NOT NULL REFERENCES error_issues(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL,
+    first_seen_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (issue_id, user_id)
+);
+
+COMMENT ON TABLE error_issue_users IS '错误问题影响的用户，用于统计去重用户数';
+
+CREATE TABLE IF NOT EXISTS error_issue_occurrences (
+    id BIGSERIAL PRIMARY KEY,
+    issue_id INTEGER NOT NULL REFERENCES error_issues(id) ON DELETE CASCADE,
+    audit_log_id UUID NOT NULL,
+    trace_id VARCHAR(100),
+    user_id INTEGER,
+    username VARCHAR(100),
+    status_code INTEGER NOT NULL,
+    error_message TEXT,
+    occurred_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_error_issue_occurrences_issue ON error_issue_occurrences(issue_id, occurred_at DESC);
+
+COMMENT ON TABLE error_issue_occurrences IS '错误问题最近的发生记录（每个问题仅保留最近 100 条），可按 trace_id 关联审计日志';
@@ -0,0 +1,12 @@
+-- ============================================================
+-- Migration: 040_video_import_job_lease
+-- Description: Give each claim of a video import job a lease generation.
+--              A worker only records progress while its lease is current,
+--              so a job taken over after a stale heartbeat is never
+--              advanced by two workers at once.
+-- Created: 2026-10-19
+-- ============================================================
+
+ALTER TABLE video_import_jobs ADD COLUMN IF NOT EXISTS lease BIGINT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN video_import_jobs.lease IS '任务认领代数：每次认领加一，只有持有当前代数的工作进程可以记录进度';
@@ -10,6 +10,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"comment-review-platform/pkg/tracing"
 )
 
 type Config struct {
@@ -56,7 +58,7 @@ func NewClient(cfg Config) *Client {
 	}
 }
 
-func (c *Client) ReviewComment(ctx context.Context, commentText string, allowedTags []string) (ReviewOutput, string, error) {
+func (c *Client) ReviewComment(ctx context.Context, commentText string, allowedTags []string) (output ReviewOutput, rawContent string, err error) {
 	missing := make([]string, 0, 3)
 	if c.baseURL == "" {
 		missing = append(missing, "base_url")
@@ -103,18 +105,34 @@ func (c *Client) ReviewComment(ctx context.Context, commentText string, allowedT
 		return ReviewOutput{}, "", err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.baseURL, "/")+"/chat/completions", bytes.NewReader(body))
+	endpoint := strings.TrimRight(c.baseURL, "/") + "/chat/completions"
+	ctx, span := tracing.Start(ctx, "chat "+c.model,
+		tracing.WithKind(tracing.KindClient),
+		tracing.WithAttributes(
+			tracing.String("gen_ai.operation.name", "chat"),
+			tracing.String("gen_ai.request.model", c.model),
+			tracing.String("url.full", endpoint),
+		),
+	)
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
 	if err != nil {
 		return ReviewOutput{}, "", err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	tracing.Inject(ctx, req.Header)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return ReviewOutput{}, "", err
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(tracing.Int("http.response.status_code", resp.StatusCode))
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
@@ -133,7 +151,7 @@ func (c *Client) ReviewComment(ctx context.Context, commentText string, allowedT
 		return ReviewOutput{}, "", errors.New("ai response missing choices")
 	}
 
-	rawContent := strings.TrimSpace(parsedResponse.Choices[0].Message.Content)
+	rawContent = strings.TrimSpace(parsedResponse.Choices[0].Message.Content)
 	result, err := parseReviewOutput(rawContent)
 	if err != nil {
 		return ReviewOutput{}, rawContent, fmt.Errorf("ai response parse failed: %w", err)
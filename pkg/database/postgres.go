@@ -11,7 +11,8 @@ var DB *sql.DB
 
 // InitPostgres initializes PostgreSQL connection
 func InitPostgres(databaseURL string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", databaseURL)
+	// The pq driver is wrapped so every query is recorded in the request's trace
+	db, err := sql.Open(tracedDriverName, databaseURL)
 	if err != nil {
 		return nil, err
 	}
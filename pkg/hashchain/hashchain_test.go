@@ -0,0 +1,126 @@
+package hashchain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCanonicalSurvivesDatabaseRoundTrip(t *testing.T) {
+	written := time.Date(2026, 10, 19, 8, 30, 0, 123456789, time.FixedZone("CST", 8*3600))
+	// A TIMESTAMP column keeps the wall clock at microsecond precision and
+	// jsonb reorders keys and drops whitespace
+	read := time.Date(2026, 10, 19, 8, 30, 0, 123457000, time.UTC)
+
+	a, err := Canonical("alice", 200, written, json.RawMessage(`{"b": 1, "a": [1, 2]}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Canonical("alice", 200, read, json.RawMessage(`{"a":[1,2],"b":1}`), json.RawMessage(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(a) != string(b) {
+		t.Errorf("canonical forms differ:\n%s\n%s", a, b)
+	}
+}
+
+func TestCanonicalRejectsInvalidJSON(t *testing.T) {
+	if _, err := Canonical(json.RawMessage(`{"a":`)); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestSignatures(t *testing.T) {
+	key := []byte("secret")
+	sig := Sign(key, "checkpoint", "2026-10-19", "42")
+
+	if !VerifySignature(key, sig, "checkpoint", "2026-10-19", "42") {
+		t.Error("valid signature rejected")
+	}
+	if VerifySignature(key, sig, "checkpoint", "2026-10-19", "43") {
+		t.Error("signature accepted for different parts")
+	}
+	if VerifySignature([]byte("other"), sig, "checkpoint", "2026-10-19", "42") {
+		t.Error("signature accepted with a different key")
+	}
+	if VerifySignature(key, "not-hex", "checkpoint") {
+		t.Error("malformed signature accepted")
+	}
+}
+
+type record struct {
+	seq        int64
+	prev, hash string
+	content    []byte
+}
+
+func buildChain(partition string, contents ...string) []record {
+	prev := Genesis(partition)
+	records := make([]record, len(contents))
+	for i, c := range contents {
+		hash := Link(prev, []byte(c))
+		records[i] = record{seq: int64(i + 1), prev: prev, hash: hash, content: []byte(c)}
+		prev = hash
+	}
+	return records
+}
+
+func walk(partition string, records []record) []Break {
+	w := NewWalker(0, Genesis(partition))
+	var breaks []Break
+	for _, r := range records {
+		breaks = append(breaks, w.Next(r.seq, r.prev, r.hash, r.content)...)
+	}
+	return breaks
+}
+
+func TestWalkerAcceptsIntactChain(t *testing.T) {
+	if breaks := walk("day", buildChain("day", "a", "b", "c")); len(breaks) != 0 {
+		t.Errorf("intact chain reported breaks: %+v", breaks)
+	}
+}
+
+func TestWalkerDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func([]record) []record
+		want   Break
+	}{
+		{
+			name:   "edited content",
+			tamper: func(r []record) []record { r[1].content = []byte("B"); return r },
+			want:   Break{Seq: 2, Kind: BreakContent},
+		},
+		{
+			name:   "deleted entry",
+			tamper: func(r []record) []record { return append(r[:1], r[2:]...) },
+			want:   Break{Seq: 3, Kind: BreakMissing},
+		},
+		{
+			name: "rewritten entry with recomputed hash",
+			tamper: func(r []record) []record {
+				r[1].content = []byte("B")
+				r[1].hash = Link(r[1].prev, r[1].content)
+				return r
+			},
+			want: Break{Seq: 3, Kind: BreakLink},
+		},
+		{
+			name:   "chain moved from another partition",
+			tamper: func(r []record) []record { return buildChain("other day", "a", "b", "c") },
+			want:   Break{Seq: 1, Kind: BreakLink},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaks := walk("day", tt.tamper(buildChain("day", "a", "b", "c")))
+			if len(breaks) != 1 {
+				t.Fatalf("got %d breaks, want 1: %+v", len(breaks), breaks)
+			}
+			if breaks[0].Seq != tt.want.Seq || breaks[0].Kind != tt.want.Kind {
+				t.Errorf("got %+v, want seq %d kind %s", breaks[0], tt.want.Seq, tt.want.Kind)
+			}
+		})
+	}
+}
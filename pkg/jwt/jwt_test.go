@@ -0,0 +1,129 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+)
+
+func TestGenerateAccessTokenRoundTrip(t *testing.T) {
+	keys := NewKeySet("secret")
+	token, claims, err := GenerateAccessToken(42, "alice", "reviewer", "session-1", keys, time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken returned error: %v", err)
+	}
+	if claims.ID == "" {
+		t.Fatal("expected a jti on the issued token")
+	}
+
+	parsed, err := ValidateToken(token, keys)
+	if err != nil {
+		t.Fatalf("ValidateToken returned error: %v", err)
+	}
+	if parsed.UserID != 42 || parsed.SessionID != "session-1" || parsed.ID != claims.ID {
+		t.Fatalf("parsed claims = %+v, want user 42, session-1, jti %s", parsed, claims.ID)
+	}
+
+	if _, err := ValidateToken(token, NewKeySet("other-secret")); err == nil {
+		t.Fatal("expected validation to fail with the wrong secret")
+	}
+}
+
+func TestGenerateAccessTokenUniqueJTI(t *testing.T) {
+	keys := NewKeySet("secret")
+	_, first, err := GenerateAccessToken(1, "bob", "admin", "s", keys, time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken returned error: %v", err)
+	}
+	_, second, err := GenerateAccessToken(1, "bob", "admin", "s", keys, time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken returned error: %v", err)
+	}
+	if first.ID == second.ID {
+		t.Fatal("expected each access token to get its own jti")
+	}
+}
+
+func TestValidateTokenRejectsExpired(t *testing.T) {
+	keys := NewKeySet("secret")
+	token, _, err := GenerateAccessToken(1, "bob", "admin", "s", keys, -time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken returned error: %v", err)
+	}
+	if _, err := ValidateToken(token, keys); err == nil {
+		t.Fatal("expected expired token to be rejected")
+	}
+}
+
+func TestKeyRotationKeepsOldTokensValid(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate Ed25519 key: %v", err)
+	}
+
+	keys := NewKeySet("")
+	if err := keys.AddKey("2026-01", oldKey); err != nil {
+		t.Fatalf("AddKey: %v", err)
+	}
+	if err := keys.SetActive("2026-01"); err != nil {
+		t.Fatalf("SetActive: %v", err)
+	}
+	oldToken, _, err := GenerateAccessToken(1, "bob", "admin", "s", keys, time.Minute)
+	if err != nil {
+		t.Fatalf("sign with RS256: %v", err)
+	}
+
+	// Rotate: new EdDSA key signs, the old key only verifies.
+	rotated := NewKeySet("")
+	if err := rotated.AddKey("2026-01", &oldKey.PublicKey); err != nil {
+		t.Fatalf("AddKey: %v", err)
+	}
+	if err := rotated.AddKey("2026-10", newKey); err != nil {
+		t.Fatalf("AddKey: %v", err)
+	}
+	if err := rotated.SetActive("2026-10"); err != nil {
+		t.Fatalf("SetActive: %v", err)
+	}
+	newToken, _, err := GenerateAccessToken(1, "bob", "admin", "s", rotated, time.Minute)
+	if err != nil {
+		t.Fatalf("sign with EdDSA: %v", err)
+	}
+
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		if _, err := ValidateToken(token, rotated); err != nil {
+			t.Fatalf("%s token rejected after rotation: %v", name, err)
+		}
+	}
+
+	if err := rotated.SetActive("2026-01"); err == nil {
+		t.Fatal("expected a public-only key to be refused as the signing key")
+	}
+	if jwks := rotated.JWKS(); len(jwks.Keys) != 2 || jwks.Keys[0].KTY != "RSA" || jwks.Keys[1].Crv != "Ed25519" {
+		t.Fatalf("unexpected JWKS: %+v", jwks)
+	}
+}
+
+func TestValidateTokenRejectsHMACWithoutSecret(t *testing.T) {
+	token, _, err := GenerateAccessToken(1, "bob", "admin", "s", NewKeySet("secret"), time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken returned error: %v", err)
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate Ed25519 key: %v", err)
+	}
+	keys := NewKeySet("")
+	if err := keys.AddKey("k1", edKey); err != nil {
+		t.Fatalf("AddKey: %v", err)
+	}
+	if _, err := ValidateToken(token, keys); err == nil {
+		t.Fatal("expected HS256 token to be rejected when no HMAC secret is configured")
+	}
+}
@@ -0,0 +1,236 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySet holds the key used to sign new tokens and every key that is still
+// accepted for verification. Rotating keys means adding a new key, switching
+// the active kid, and removing the old key once its tokens have expired.
+type KeySet struct {
+	activeKID string
+	signing   map[string]signingKey
+	verifying map[string]verificationKey
+	// hmacSecret keeps legacy HS256 tokens (no kid) verifiable. Empty disables HS256.
+	hmacSecret []byte
+}
+
+type signingKey struct {
+	method jwt.SigningMethod
+	key    interface{}
+}
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    interface{}
+}
+
+// JWK is a single public key in JSON Web Key format.
+type JWK struct {
+	KTY string `json:"kty"`
+	KID string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewKeySet creates an empty key set. A non-empty hmacSecret enables HS256 signing
+// (when no asymmetric key is active) and verification of tokens without a kid.
+func NewKeySet(hmacSecret string) *KeySet {
+	return &KeySet{
+		signing:    make(map[string]signingKey),
+		verifying:  make(map[string]verificationKey),
+		hmacSecret: []byte(hmacSecret),
+	}
+}
+
+// LoadKeySet loads every "<kid>.pem" file in dir. Files holding a private key
+// (RSA or Ed25519, PKCS#1/PKCS#8) can sign; files holding only a public key
+// (PKIX) are kept for verification of tokens signed by retired keys.
+func LoadKeySet(dir, activeKID, hmacSecret string) (*KeySet, error) {
+	keys := NewKeySet(hmacSecret)
+	if dir == "" {
+		return keys, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		if err := keys.AddPEM(kid, data); err != nil {
+			return nil, fmt.Errorf("load key %s: %w", path, err)
+		}
+	}
+
+	if activeKID == "" && len(keys.signing) == 1 {
+		for kid := range keys.signing {
+			activeKID = kid
+		}
+	}
+	if activeKID != "" {
+		if err := keys.SetActive(activeKID); err != nil {
+			return nil, err
+		}
+	} else if len(keys.signing) > 1 {
+		return nil, errors.New("multiple signing keys found; set the active key ID")
+	}
+	return keys, nil
+}
+
+// AddPEM registers a PEM-encoded private or public key under kid.
+func (k *KeySet) AddPEM(kid string, data []byte) error {
+	if kid == "" {
+		return errors.New("key ID is required")
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return err
+	}
+	return k.AddKey(kid, parsed)
+}
+
+// AddKey registers an *rsa.PrivateKey, ed25519.PrivateKey or their public
+// counterparts under kid.
+func (k *KeySet) AddKey(kid string, key interface{}) error {
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		k.signing[kid] = signingKey{method: jwt.SigningMethodRS256, key: key}
+		k.verifying[kid] = verificationKey{method: jwt.SigningMethodRS256, key: &key.PublicKey}
+	case ed25519.PrivateKey:
+		k.signing[kid] = signingKey{method: jwt.SigningMethodEdDSA, key: key}
+		k.verifying[kid] = verificationKey{method: jwt.SigningMethodEdDSA, key: key.Public()}
+	case *rsa.PublicKey:
+		k.verifying[kid] = verificationKey{method: jwt.SigningMethodRS256, key: key}
+	case ed25519.PublicKey:
+		k.verifying[kid] = verificationKey{method: jwt.SigningMethodEdDSA, key: key}
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+	return nil
+}
+
+// SetActive selects the key used to sign new tokens.
+func (k *KeySet) SetActive(kid string) error {
+	if _, ok := k.signing[kid]; !ok {
+		return fmt.Errorf("no private key with ID %q", kid)
+	}
+	k.activeKID = kid
+	return nil
+}
+
+// ActiveKeyID returns the kid of the signing key, or "" when signing with HS256.
+func (k *KeySet) ActiveKeyID() string {
+	return k.activeKID
+}
+
+// CanSign reports whether the key set has any way to sign tokens.
+func (k *KeySet) CanSign() bool {
+	return k.activeKID != "" || len(k.hmacSecret) > 0
+}
+
+// Sign signs claims with the active key, falling back to HS256 when no
+// asymmetric key is configured.
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if k.activeKID == "" {
+		if len(k.hmacSecret) == 0 {
+			return "", errors.New("no signing key configured")
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(k.hmacSecret)
+	}
+	key := k.signing[k.activeKID]
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = k.activeKID
+	return token.SignedString(key.key)
+}
+
+// keyFunc resolves the verification key from the token's kid and refuses any
+// algorithm other than the one bound to that key.
+func (k *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || len(k.hmacSecret) == 0 {
+			return nil, errors.New("invalid signing method")
+		}
+		return k.hmacSecret, nil
+	}
+
+	key, ok := k.verifying[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("invalid signing method")
+	}
+	return key.key, nil
+}
+
+// JWKS returns the public verification keys. HS256 secrets are never published.
+func (k *KeySet) JWKS() JWKS {
+	kids := make([]string, 0, len(k.verifying))
+	for kid := range k.verifying {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := JWKS{Keys: make([]JWK, 0, len(kids))}
+	for _, kid := range kids {
+		key := k.verifying[kid]
+		jwk := JWK{KID: kid, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.key.(type) {
+		case *rsa.PublicKey:
+			jwk.KTY = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KTY = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
@@ -0,0 +1,194 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxStillSize bounds a single cover image or JPEG sample we read.
+const maxStillSize = 16 << 20
+
+var ErrNoStills = errors.New("mp4: no embedded cover art or JPEG frames")
+
+// Stills are images that can be taken from a file without decoding video.
+type Stills struct {
+	// CoverArt is the iTunes-style cover (moov/udta/meta/ilst/covr), JPEG or PNG.
+	CoverArt []byte
+	// Frames are JPEG samples of a Motion-JPEG video track, spread evenly
+	// over the track in presentation order.
+	Frames [][]byte
+}
+
+// ExtractStills returns embedded cover art and up to maxFrames evenly spaced
+// frames from a Motion-JPEG video track. Only the moov box and the chosen
+// samples are read.
+func ExtractStills(r io.ReaderAt, size int64, maxFrames int) (*Stills, error) {
+	moov, err := loadMoov(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	stills := &Stills{CoverArt: coverArt(moov)}
+
+	if maxFrames > 0 {
+		offsets, sizes := jpegSamples(moov)
+		for _, i := range spread(len(offsets), maxFrames) {
+			if sizes[i] == 0 || sizes[i] > maxStillSize || int64(offsets[i])+int64(sizes[i]) > size {
+				continue
+			}
+			frame := make([]byte, sizes[i])
+			if _, err := r.ReadAt(frame, int64(offsets[i])); err != nil && err != io.EOF {
+				return nil, fmt.Errorf("mp4: read sample: %w", err)
+			}
+			stills.Frames = append(stills.Frames, frame)
+		}
+	}
+
+	if stills.CoverArt == nil && len(stills.Frames) == 0 {
+		return nil, ErrNoStills
+	}
+	return stills, nil
+}
+
+// coverArt returns the first covr image in moov/udta/meta/ilst, if any.
+func coverArt(moov []byte) []byte {
+	meta := findPath(moov, "udta", "meta")
+	if meta == nil {
+		return nil
+	}
+	// ISO meta is a full box (4 bytes version/flags); QuickTime meta is not.
+	ilst := findPath(meta, "ilst")
+	if ilst == nil && len(meta) >= 4 {
+		ilst = findPath(meta[4:], "ilst")
+	}
+	// data: type indicator(4) locale(4) then the image bytes.
+	data := findPath(ilst, "covr", "data")
+	if len(data) <= 8 || len(data)-8 > maxStillSize {
+		return nil
+	}
+	return data[8:]
+}
+
+// jpegSamples returns the file offsets and sizes of every sample of the first
+// Motion-JPEG video track.
+func jpegSamples(moov []byte) (offsets []uint64, sizes []uint32) {
+	boxes, err := children(moov)
+	if err != nil {
+		return nil, nil
+	}
+	for _, b := range boxes {
+		if b.typ != "trak" {
+			continue
+		}
+		t := parseTrack(b.data)
+		if t.handler != "vide" || !isJPEGFormat(t.format) {
+			continue
+		}
+		stbl := findPath(b.data, "mdia", "minf", "stbl")
+		if stbl == nil {
+			return nil, nil
+		}
+		return sampleTable(stbl)
+	}
+	return nil, nil
+}
+
+func isJPEGFormat(format string) bool {
+	switch format {
+	case "jpeg", "mjpa", "mjpb", "mjp2":
+		return true
+	}
+	return false
+}
+
+// sampleTable resolves sample offsets from stsz, stsc and stco/co64.
+func sampleTable(stbl []byte) (offsets []uint64, sizes []uint32) {
+	boxes, err := children(stbl)
+	if err != nil {
+		return nil, nil
+	}
+
+	// stsz: version/flags(4) sample_size(4) sample_count(4) [entry_size(4)...]
+	stsz := find(boxes, "stsz")
+	if len(stsz) < 12 {
+		return nil, nil
+	}
+	uniform := binary.BigEndian.Uint32(stsz[4:8])
+	count := int(binary.BigEndian.Uint32(stsz[8:12]))
+	if uniform == 0 && 12+count*4 > len(stsz) {
+		count = (len(stsz) - 12) / 4
+	}
+	sizes = make([]uint32, 0, count)
+	for i := 0; i < count; i++ {
+		if uniform != 0 {
+			sizes = append(sizes, uniform)
+		} else {
+			sizes = append(sizes, binary.BigEndian.Uint32(stsz[12+i*4:]))
+		}
+	}
+
+	var chunks []uint64
+	if stco := find(boxes, "stco"); len(stco) >= 8 {
+		n := int(binary.BigEndian.Uint32(stco[4:8]))
+		for i := 0; i < n && 8+i*4+4 <= len(stco); i++ {
+			chunks = append(chunks, uint64(binary.BigEndian.Uint32(stco[8+i*4:])))
+		}
+	} else if co64 := find(boxes, "co64"); len(co64) >= 8 {
+		n := int(binary.BigEndian.Uint32(co64[4:8]))
+		for i := 0; i < n && 8+i*8+8 <= len(co64); i++ {
+			chunks = append(chunks, binary.BigEndian.Uint64(co64[8+i*8:]))
+		}
+	}
+
+	// stsc: version/flags(4) entry_count(4) then (first_chunk, samples_per_chunk, sdi) runs.
+	type run struct{ firstChunk, perChunk int }
+	var runs []run
+	if stsc := find(boxes, "stsc"); len(stsc) >= 8 {
+		n := int(binary.BigEndian.Uint32(stsc[4:8]))
+		for i := 0; i < n && 8+i*12+12 <= len(stsc); i++ {
+			entry := stsc[8+i*12:]
+			runs = append(runs, run{
+				firstChunk: int(binary.BigEndian.Uint32(entry[0:4])),
+				perChunk:   int(binary.BigEndian.Uint32(entry[4:8])),
+			})
+		}
+	}
+	if len(chunks) == 0 || len(runs) == 0 {
+		return nil, nil
+	}
+
+	offsets = make([]uint64, 0, len(sizes))
+	sample := 0
+	for chunk := 1; chunk <= len(chunks) && sample < len(sizes); chunk++ {
+		perChunk := 0
+		for _, r := range runs {
+			if r.firstChunk <= chunk {
+				perChunk = r.perChunk
+			}
+		}
+		offset := chunks[chunk-1]
+		for i := 0; i < perChunk && sample < len(sizes); i++ {
+			offsets = append(offsets, offset)
+			offset += uint64(sizes[sample])
+			sample++
+		}
+	}
+	return offsets, sizes[:len(offsets)]
+}
+
+// spread picks up to n indexes evenly distributed over [0, total).
+func spread(total, n int) []int {
+	if total <= 0 || n <= 0 {
+		return nil
+	}
+	if n > total {
+		n = total
+	}
+	indexes := make([]int, n)
+	for i := range indexes {
+		indexes[i] = (2*i + 1) * total / (2 * n)
+	}
+	return indexes
+}
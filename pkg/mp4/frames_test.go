@@ -0,0 +1,74 @@
+package mp4
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// mjpegFile lays out an ftyp, an mdat holding the given JPEG samples (two per
+// chunk) and a moov describing them, optionally with cover art.
+func mjpegFile(samples [][]byte, cover []byte) []byte {
+	ftyp := mkbox("ftyp", []byte("qt  "), u32(0))
+	mdatStart := len(ftyp) + 8
+
+	var media []byte
+	var sizes, chunkOffsets [][]byte
+	for i, sample := range samples {
+		if i%2 == 0 {
+			chunkOffsets = append(chunkOffsets, u32(uint32(mdatStart+len(media))))
+		}
+		sizes = append(sizes, u32(uint32(len(sample))))
+		media = append(media, sample...)
+	}
+
+	stsz := mkbox("stsz", u32(0), u32(0), u32(uint32(len(samples))), bytes.Join(sizes, nil))
+	stsc := mkbox("stsc", u32(0), u32(1), u32(1), u32(2), u32(1))
+	stco := mkbox("stco", u32(0), u32(uint32(len(chunkOffsets))), bytes.Join(chunkOffsets, nil))
+	stsd := mkbox("stsd", u32(0), u32(1), mkbox("jpeg", visualEntry(320, 240)))
+	stts := mkbox("stts", u32(0), u32(1), u32(uint32(len(samples))), u32(100))
+	hdlr := mkbox("hdlr", u32(0), u32(0), []byte("vide"), make([]byte, 12))
+	mdhd := mkbox("mdhd", timeHeader(1000, uint32(len(samples)*100), 4))
+	trak := mkbox("trak", tkhd(320, 240), mkbox("mdia", mdhd, hdlr, mkbox("minf", mkbox("stbl", stsd, stts, stsz, stsc, stco))))
+
+	parts := [][]byte{mkbox("mvhd", timeHeader(1000, uint32(len(samples)*100), 80)), trak}
+	if cover != nil {
+		data := mkbox("data", u32(13), u32(0), cover)
+		ilst := mkbox("ilst", mkbox("covr", data))
+		parts = append(parts, mkbox("udta", mkbox("meta", u32(0), mkbox("hdlr", make([]byte, 25)), ilst)))
+	}
+
+	return bytes.Join([][]byte{ftyp, mkbox("mdat", media), mkbox("moov", parts...)}, nil)
+}
+
+func TestExtractStillsReadsCoverAndSpreadFrames(t *testing.T) {
+	var samples [][]byte
+	for i := 0; i < 10; i++ {
+		samples = append(samples, []byte{0xFF, 0xD8, byte(i), byte(i), 0xFF, 0xD9})
+	}
+	file := mjpegFile(samples, []byte("cover-image"))
+
+	stills, err := ExtractStills(bytes.NewReader(file), int64(len(file)), 4)
+	if err != nil {
+		t.Fatalf("ExtractStills returned error: %v", err)
+	}
+	if string(stills.CoverArt) != "cover-image" {
+		t.Fatalf("cover = %q", stills.CoverArt)
+	}
+	if len(stills.Frames) != 4 {
+		t.Fatalf("got %d frames, want 4", len(stills.Frames))
+	}
+	// Samples 1, 3, 6 and 8 are the centres of four equal slices of ten.
+	for i, want := range []byte{1, 3, 6, 8} {
+		if !bytes.Equal(stills.Frames[i], samples[want]) {
+			t.Fatalf("frame %d = %v, want sample %d", i, stills.Frames[i], want)
+		}
+	}
+}
+
+func TestExtractStillsWithoutImages(t *testing.T) {
+	file := sampleFile(1024) // H.264 track, no cover art
+	if _, err := ExtractStills(bytes.NewReader(file), int64(len(file)), 4); !errors.Is(err, ErrNoStills) {
+		t.Fatalf("err = %v, want ErrNoStills", err)
+	}
+}
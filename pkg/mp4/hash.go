@@ -0,0 +1,55 @@
+package mp4
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+)
+
+var ErrNoVideoTrack = errors.New("mp4: no video track")
+
+// ContentHash fingerprints the first video track by its codec, timing and
+// sample sizes. It ignores the file name, user data and where the moov box
+// sits, so a byte-identical stream re-muxed or re-tagged under another key
+// hashes the same, while any re-encode does not.
+func ContentHash(r io.ReaderAt, size int64) (string, error) {
+	moov, err := loadMoov(r, size)
+	if err != nil {
+		return "", err
+	}
+	boxes, err := children(moov)
+	if err != nil {
+		return "", err
+	}
+
+	for _, b := range boxes {
+		if b.typ != "trak" {
+			continue
+		}
+		t := parseTrack(b.data)
+		if t.handler != "vide" {
+			continue
+		}
+		stbl := findPath(b.data, "mdia", "minf", "stbl")
+		stblBoxes, err := children(stbl)
+		if err != nil {
+			return "", err
+		}
+		stsz := find(stblBoxes, "stsz")
+		if len(stsz) < 12 {
+			return "", ErrInvalidFile
+		}
+
+		h := sha256.New()
+		h.Write([]byte(t.format))
+		binary.Write(h, binary.BigEndian, t.timescale)
+		binary.Write(h, binary.BigEndian, t.duration)
+		// stts carries the frame timing, stsz the size of every frame
+		h.Write(find(stblBoxes, "stts"))
+		h.Write(stsz)
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+	return "", ErrNoVideoTrack
+}
@@ -0,0 +1,45 @@
+package mp4
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestContentHashIgnoresMetadata(t *testing.T) {
+	samples := [][]byte{{0xFF, 0xD8, 1, 0xFF, 0xD9}, {0xFF, 0xD8, 2, 2, 0xFF, 0xD9}, {0xFF, 0xD8, 3, 0xFF, 0xD9}}
+	plain := mjpegFile(samples, nil)
+	tagged := mjpegFile(samples, []byte("cover-image"))
+
+	a, err := ContentHash(bytes.NewReader(plain), int64(len(plain)))
+	if err != nil {
+		t.Fatalf("ContentHash returned error: %v", err)
+	}
+	b, err := ContentHash(bytes.NewReader(tagged), int64(len(tagged)))
+	if err != nil {
+		t.Fatalf("ContentHash returned error: %v", err)
+	}
+	if a != b {
+		t.Fatalf("cover art changed the hash: %s != %s", a, b)
+	}
+
+	reencoded := mjpegFile([][]byte{{0xFF, 0xD8, 1, 0xFF, 0xD9}, {0xFF, 0xD8, 2, 0xFF, 0xD9}, {0xFF, 0xD8, 3, 0xFF, 0xD9}}, nil)
+	c, err := ContentHash(bytes.NewReader(reencoded), int64(len(reencoded)))
+	if err != nil {
+		t.Fatalf("ContentHash returned error: %v", err)
+	}
+	if a == c {
+		t.Fatal("different sample sizes produced the same hash")
+	}
+}
+
+func TestContentHashWithoutVideoTrack(t *testing.T) {
+	audio := mkbox("moov",
+		mkbox("mvhd", timeHeader(1000, 5000, 80)),
+		trak("soun", "mp4a", 44100, 44100*5, make([]byte, 28), 215),
+	)
+	file := append(mkbox("ftyp", []byte("isom"), u32(0)), audio...)
+	if _, err := ContentHash(bytes.NewReader(file), int64(len(file))); !errors.Is(err, ErrNoVideoTrack) {
+		t.Fatalf("err = %v, want ErrNoVideoTrack", err)
+	}
+}
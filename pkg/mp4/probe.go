@@ -0,0 +1,332 @@
+// Package mp4 extracts technical metadata from MP4/MOV (ISO BMFF / QuickTime)
+// files by reading only box headers and the moov box, so it works over ranged
+// reads against object storage without downloading the media data.
+package mp4
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// maxMoovSize bounds the moov box we are willing to load into memory.
+const maxMoovSize = 64 << 20
+
+var (
+	ErrNoMoov      = errors.New("mp4: moov box not found")
+	ErrMoovTooBig  = errors.New("mp4: moov box too large")
+	ErrInvalidFile = errors.New("mp4: invalid box structure")
+)
+
+// Metadata is the technical description of a video file.
+type Metadata struct {
+	DurationSeconds float64
+	Width           int
+	Height          int
+	VideoCodec      string
+	FrameRate       float64
+	Bitrate         int64 // bits per second, over the whole file
+	HasAudio        bool
+	AudioCodec      string
+}
+
+// Probe locates the moov box among the top-level boxes of r and parses it.
+func Probe(r io.ReaderAt, size int64) (*Metadata, error) {
+	moov, err := loadMoov(r, size)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := parseMoov(moov)
+	if err != nil {
+		return nil, err
+	}
+	if meta.DurationSeconds > 0 {
+		meta.Bitrate = int64(math.Round(float64(size) * 8 / meta.DurationSeconds))
+	}
+	return meta, nil
+}
+
+// loadMoov walks the top-level box headers of r and returns the moov payload.
+func loadMoov(r io.ReaderAt, size int64) ([]byte, error) {
+	var offset int64
+	header := make([]byte, 16)
+	for offset+8 <= size {
+		n, err := r.ReadAt(header, offset)
+		if n < 8 {
+			if err == nil {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, fmt.Errorf("mp4: read box header at %d: %w", offset, err)
+		}
+
+		boxSize := int64(binary.BigEndian.Uint32(header[0:4]))
+		boxType := string(header[4:8])
+		headerSize := int64(8)
+		switch boxSize {
+		case 0:
+			boxSize = size - offset
+		case 1:
+			if n < 16 {
+				return nil, ErrInvalidFile
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if boxSize < headerSize || offset+boxSize > size {
+			return nil, ErrInvalidFile
+		}
+
+		if boxType == "moov" {
+			if boxSize > maxMoovSize {
+				return nil, ErrMoovTooBig
+			}
+			moov := make([]byte, boxSize-headerSize)
+			if _, err := r.ReadAt(moov, offset+headerSize); err != nil && err != io.EOF {
+				return nil, fmt.Errorf("mp4: read moov: %w", err)
+			}
+			return moov, nil
+		}
+		offset += boxSize
+	}
+	return nil, ErrNoMoov
+}
+
+type box struct {
+	typ  string
+	data []byte
+}
+
+// children splits a container payload into its child boxes.
+func children(data []byte) ([]box, error) {
+	var boxes []box
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[0:4]))
+		typ := string(data[4:8])
+		headerSize := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, ErrInvalidFile
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerSize = 16
+		}
+		if size < headerSize || size > uint64(len(data)) {
+			return nil, ErrInvalidFile
+		}
+		boxes = append(boxes, box{typ: typ, data: data[headerSize:size]})
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+func find(boxes []box, typ string) []byte {
+	for _, b := range boxes {
+		if b.typ == typ {
+			return b.data
+		}
+	}
+	return nil
+}
+
+// findPath walks nested containers, e.g. findPath(data, "mdia", "minf", "stbl").
+func findPath(data []byte, path ...string) []byte {
+	for _, typ := range path {
+		boxes, err := children(data)
+		if err != nil {
+			return nil
+		}
+		if data = find(boxes, typ); data == nil {
+			return nil
+		}
+	}
+	return data
+}
+
+type track struct {
+	handler   string
+	timescale uint32
+	duration  uint64
+	format    string
+	width     int
+	height    int
+	samples   uint64
+}
+
+func parseMoov(moov []byte) (*Metadata, error) {
+	boxes, err := children(moov)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &Metadata{}
+	if mvhd := find(boxes, "mvhd"); mvhd != nil {
+		timescale, duration, ok := parseTimeHeader(mvhd)
+		if ok && timescale > 0 {
+			meta.DurationSeconds = float64(duration) / float64(timescale)
+		}
+	}
+	// Fragmented files carry the real duration in mvex/mehd.
+	if meta.DurationSeconds == 0 {
+		if mehd := findPath(moov, "mvex", "mehd"); len(mehd) >= 8 {
+			var fragmentDuration uint64
+			if mehd[0] == 1 && len(mehd) >= 12 {
+				fragmentDuration = binary.BigEndian.Uint64(mehd[4:12])
+			} else {
+				fragmentDuration = uint64(binary.BigEndian.Uint32(mehd[4:8]))
+			}
+			if mvhd := find(boxes, "mvhd"); mvhd != nil {
+				if timescale, _, ok := parseTimeHeader(mvhd); ok && timescale > 0 {
+					meta.DurationSeconds = float64(fragmentDuration) / float64(timescale)
+				}
+			}
+		}
+	}
+
+	var videoFound bool
+	for _, b := range boxes {
+		if b.typ != "trak" {
+			continue
+		}
+		t := parseTrack(b.data)
+		trackSeconds := 0.0
+		if t.timescale > 0 {
+			trackSeconds = float64(t.duration) / float64(t.timescale)
+		}
+		if meta.DurationSeconds == 0 && trackSeconds > 0 {
+			meta.DurationSeconds = trackSeconds
+		}
+
+		switch t.handler {
+		case "vide":
+			if videoFound {
+				continue
+			}
+			videoFound = true
+			meta.VideoCodec = videoCodecName(t.format)
+			meta.Width, meta.Height = t.width, t.height
+			if trackSeconds > 0 && t.samples > 0 {
+				meta.FrameRate = math.Round(float64(t.samples)/trackSeconds*100) / 100
+			}
+		case "soun":
+			if !meta.HasAudio {
+				meta.HasAudio = true
+				meta.AudioCodec = audioCodecName(t.format)
+			}
+		}
+	}
+
+	if !videoFound && !meta.HasAudio && meta.DurationSeconds == 0 {
+		return nil, ErrInvalidFile
+	}
+	return meta, nil
+}
+
+func parseTrack(trak []byte) track {
+	var t track
+	if tkhd := findPath(trak, "tkhd"); tkhd != nil {
+		// Presentation size, 16.16 fixed point, stored in the last 8 bytes.
+		if len(tkhd) >= 84 {
+			t.width = int(binary.BigEndian.Uint32(tkhd[len(tkhd)-8:]) >> 16)
+			t.height = int(binary.BigEndian.Uint32(tkhd[len(tkhd)-4:]) >> 16)
+		}
+	}
+	if hdlr := findPath(trak, "mdia", "hdlr"); len(hdlr) >= 12 {
+		t.handler = string(hdlr[8:12])
+	}
+	if mdhd := findPath(trak, "mdia", "mdhd"); mdhd != nil {
+		t.timescale, t.duration, _ = parseTimeHeader(mdhd)
+	}
+
+	stbl := findPath(trak, "mdia", "minf", "stbl")
+	if stbl == nil {
+		return t
+	}
+	stblBoxes, err := children(stbl)
+	if err != nil {
+		return t
+	}
+
+	// stsd: version/flags(4) entry_count(4), then the first sample entry box.
+	if stsd := find(stblBoxes, "stsd"); len(stsd) >= 16 {
+		entry := stsd[8:]
+		t.format = strings.TrimRight(string(entry[4:8]), "\x00 ")
+		// Visual sample entry: 8 header + 6 reserved + 2 dref + 16 pre-defined, then width/height.
+		if t.handler == "vide" && len(entry) >= 36 {
+			if w, h := int(binary.BigEndian.Uint16(entry[32:34])), int(binary.BigEndian.Uint16(entry[34:36])); w > 0 && h > 0 {
+				t.width, t.height = w, h
+			}
+		}
+	}
+
+	// stts: version/flags(4) entry_count(4) then (sample_count, sample_delta) pairs.
+	if stts := find(stblBoxes, "stts"); len(stts) >= 8 {
+		count := int(binary.BigEndian.Uint32(stts[4:8]))
+		for i := 0; i < count && 8+i*8+8 <= len(stts); i++ {
+			t.samples += uint64(binary.BigEndian.Uint32(stts[8+i*8:]))
+		}
+	}
+	return t
+}
+
+// parseTimeHeader reads timescale and duration from an mvhd or mdhd payload.
+func parseTimeHeader(data []byte) (timescale uint32, duration uint64, ok bool) {
+	if len(data) < 4 {
+		return 0, 0, false
+	}
+	if data[0] == 1 {
+		if len(data) < 32 {
+			return 0, 0, false
+		}
+		return binary.BigEndian.Uint32(data[20:24]), binary.BigEndian.Uint64(data[24:32]), true
+	}
+	if len(data) < 20 {
+		return 0, 0, false
+	}
+	return binary.BigEndian.Uint32(data[12:16]), uint64(binary.BigEndian.Uint32(data[16:20])), true
+}
+
+func videoCodecName(format string) string {
+	switch format {
+	case "avc1", "avc3":
+		return "h264"
+	case "hvc1", "hev1":
+		return "hevc"
+	case "av01":
+		return "av1"
+	case "vp09":
+		return "vp9"
+	case "vp08":
+		return "vp8"
+	case "mp4v":
+		return "mpeg4"
+	case "apcn", "apch", "apcs", "apco", "ap4h":
+		return "prores"
+	}
+	return format
+}
+
+func audioCodecName(format string) string {
+	switch format {
+	case "mp4a":
+		return "aac"
+	case "ac-3":
+		return "ac3"
+	case "ec-3":
+		return "eac3"
+	case "Opus":
+		return "opus"
+	case ".mp3":
+		return "mp3"
+	case "alac":
+		return "alac"
+	case "sowt", "twos", "lpcm":
+		return "pcm"
+	}
+	return format
+}
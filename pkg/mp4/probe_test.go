@@ -0,0 +1,121 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func mkbox(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	out := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(out[0:4], uint32(8+len(body)))
+	copy(out[4:8], typ)
+	return append(out, body...)
+}
+
+func u32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func u16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return b
+}
+
+// timeHeader builds a version-0 mvhd/mdhd payload prefix.
+func timeHeader(timescale, duration uint32, extra int) []byte {
+	return bytes.Join([][]byte{u32(0), u32(0), u32(0), u32(timescale), u32(duration), make([]byte, extra)}, nil)
+}
+
+func tkhd(width, height uint32) []byte {
+	payload := make([]byte, 84)
+	binary.BigEndian.PutUint32(payload[76:80], width<<16)
+	binary.BigEndian.PutUint32(payload[80:84], height<<16)
+	return mkbox("tkhd", payload)
+}
+
+func trak(handler, format string, timescale, duration uint32, entry []byte, samples uint32) []byte {
+	sampleEntry := mkbox(format, entry)
+	stsd := mkbox("stsd", u32(0), u32(1), sampleEntry)
+	stts := mkbox("stts", u32(0), u32(1), u32(samples), u32(duration/samples))
+	hdlr := mkbox("hdlr", u32(0), u32(0), []byte(handler), make([]byte, 12))
+	mdhd := mkbox("mdhd", timeHeader(timescale, duration, 4))
+	mdia := mkbox("mdia", mdhd, hdlr, mkbox("minf", mkbox("stbl", stsd, stts)))
+	return mkbox("trak", tkhd(0, 0), mdia)
+}
+
+func visualEntry(width, height uint16) []byte {
+	return bytes.Join([][]byte{make([]byte, 24), u16(width), u16(height), make([]byte, 50)}, nil)
+}
+
+type countingReader struct {
+	r    *bytes.Reader
+	read int64
+}
+
+func (c *countingReader) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	c.read += int64(n)
+	return n, err
+}
+
+func sampleFile(mdatSize int) []byte {
+	video := trak("vide", "avc1", 15360, 15360*12, visualEntry(720, 1280), 360)
+	audio := trak("soun", "mp4a", 44100, 44100*12, make([]byte, 28), 517)
+	moov := mkbox("moov", mkbox("mvhd", timeHeader(1000, 12000, 80)), video, audio)
+	// moov after mdat: the common "not fast-start" layout.
+	return bytes.Join([][]byte{
+		mkbox("ftyp", []byte("isom"), u32(512), []byte("isomiso2avc1mp41")),
+		mkbox("mdat", make([]byte, mdatSize)),
+		moov,
+	}, nil)
+}
+
+func TestProbeReadsOnlyHeadersAndMoov(t *testing.T) {
+	file := sampleFile(4 << 20)
+	reader := &countingReader{r: bytes.NewReader(file)}
+
+	meta, err := Probe(reader, int64(len(file)))
+	if err != nil {
+		t.Fatalf("Probe returned error: %v", err)
+	}
+
+	if meta.DurationSeconds != 12 {
+		t.Fatalf("duration = %v, want 12", meta.DurationSeconds)
+	}
+	if meta.Width != 720 || meta.Height != 1280 {
+		t.Fatalf("resolution = %dx%d, want 720x1280", meta.Width, meta.Height)
+	}
+	if meta.VideoCodec != "h264" || meta.FrameRate != 30 {
+		t.Fatalf("video = %s @ %v fps, want h264 @ 30", meta.VideoCodec, meta.FrameRate)
+	}
+	if !meta.HasAudio || meta.AudioCodec != "aac" {
+		t.Fatalf("audio = %v %q, want aac", meta.HasAudio, meta.AudioCodec)
+	}
+	if want := int64(len(file)) * 8 / 12; meta.Bitrate != want {
+		t.Fatalf("bitrate = %d, want %d", meta.Bitrate, want)
+	}
+	if reader.read > 64<<10 {
+		t.Fatalf("read %d bytes; expected to skip the media data", reader.read)
+	}
+}
+
+func TestProbeWithoutMoov(t *testing.T) {
+	file := mkbox("ftyp", []byte("isom"), u32(0))
+	if _, err := Probe(bytes.NewReader(file), int64(len(file))); !errors.Is(err, ErrNoMoov) {
+		t.Fatalf("err = %v, want ErrNoMoov", err)
+	}
+}
+
+func TestProbeRejectsTruncatedBox(t *testing.T) {
+	file := sampleFile(1024)
+	truncated := file[:len(file)-10]
+	if _, err := Probe(bytes.NewReader(truncated), int64(len(truncated))); err == nil {
+		t.Fatal("expected an error for a truncated moov box")
+	}
+}
@@ -0,0 +1,158 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIdP is a minimal OpenID provider: discovery, JWKS and a token endpoint
+// that enforces PKCE for one pre-registered authorization code.
+type mockIdP struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	code      string
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	idp := &mockIdP{key: key, code: "auth-code"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "mock-1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != idp.code || base64.RawURLEncoding.EncodeToString(sum[:]) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		claims := jwt.MapClaims{
+			"iss":   idp.server.URL,
+			"aud":   r.PostForm.Get("client_id"),
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"iat":   time.Now().Unix(),
+			"nonce": idp.nonce,
+		}
+		for k, v := range idp.claims {
+			claims[k] = v
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "mock-1"
+		signed, _ := token.SignedString(key)
+		json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func TestAuthorizationCodeFlowWithPKCE(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.claims = jwt.MapClaims{
+		"sub":            "user-123",
+		"email":          "alice@example.com",
+		"email_verified": true,
+		"groups":         []string{"moderators", "qa"},
+	}
+	provider := NewProvider(Config{
+		IssuerURL:   idp.server.URL,
+		ClientID:    "platform",
+		RedirectURL: "http://localhost/callback",
+	})
+	ctx := context.Background()
+
+	verifier, challenge, err := GeneratePKCE()
+	if err != nil {
+		t.Fatalf("GeneratePKCE: %v", err)
+	}
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", challenge)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	parsed, _ := url.Parse(authURL)
+	query := parsed.Query()
+	if query.Get("code_challenge") != challenge || query.Get("code_challenge_method") != "S256" || query.Get("state") != "state-1" {
+		t.Fatalf("unexpected authorization URL: %s", authURL)
+	}
+
+	// The IdP remembers what the browser sent to /authorize.
+	idp.challenge = challenge
+	idp.nonce = "nonce-1"
+
+	token, err := provider.Exchange(ctx, idp.code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if token.Subject != "user-123" || token.Email != "alice@example.com" || !token.EmailVerified {
+		t.Fatalf("unexpected token: %+v", token)
+	}
+	if groups := token.StringsClaim("groups"); strings.Join(groups, ",") != "moderators,qa" {
+		t.Fatalf("groups = %v", groups)
+	}
+
+	if _, err := provider.Exchange(ctx, idp.code, "wrong-verifier", "nonce-1"); err == nil {
+		t.Fatal("expected exchange with the wrong PKCE verifier to fail")
+	}
+	if _, err := provider.Exchange(ctx, idp.code, verifier, "other-nonce"); err == nil {
+		t.Fatal("expected nonce mismatch to fail")
+	}
+}
+
+func TestVerifyIDTokenRejectsWrongAudience(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := NewProvider(Config{IssuerURL: idp.server.URL, ClientID: "platform"})
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": idp.server.URL,
+		"aud": "someone-else",
+		"sub": "user-123",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	})
+	token.Header["kid"] = "mock-1"
+	signed, err := token.SignedString(idp.key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if _, err := provider.VerifyIDToken(context.Background(), signed, ""); err == nil {
+		t.Fatal("expected token for another client to be rejected")
+	}
+}
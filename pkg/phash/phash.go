@@ -0,0 +1,111 @@
+// Package phash computes perceptual hashes of video frames and compares the
+// frame sequences of two videos. Hashes survive re-encoding, rescaling and
+// small crops, so re-uploads of a clip hash close to the original even when
+// their bytes differ.
+package phash
+
+import (
+	"image"
+	"math/bits"
+)
+
+// Bands is the number of bands a hash is split into for indexing. Two hashes
+// within Bands-1 bits of each other share at least one band exactly, so Bands
+// must exceed the largest distance a caller treats as the same frame. Eleven
+// bands of 5 or 6 bits cover distances up to 10.
+const Bands = 11
+
+// Hash returns the 64-bit difference hash (dHash) of img: the image is reduced
+// to 9x8 grayscale and each bit records whether a pixel is brighter than its
+// right-hand neighbour.
+func Hash(img image.Image) uint64 {
+	gray := shrink(img, 9, 8)
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if gray[y*9+x] > gray[y*9+x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// Distance is the number of differing bits between two hashes.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Band returns the i-th band of hash, most significant first.
+func Band(hash uint64, i int) int {
+	end := bandOffset(i + 1)
+	return int(hash >> (64 - end) & (1<<BandWidth(i) - 1))
+}
+
+// BandWidth is the number of bits in the i-th band.
+func BandWidth(i int) int {
+	return bandOffset(i+1) - bandOffset(i)
+}
+
+// bandOffset is the first bit of the i-th band, counted from the most
+// significant bit; the 64 bits are spread as evenly as possible.
+func bandOffset(i int) int {
+	return i * 64 / Bands
+}
+
+// Similarity compares two frame sequences sampled at the same relative
+// positions. A frame matches when the frame at the same index, or one of its
+// neighbours, is within maxDistance bits; neighbours absorb small trims at the
+// start or end. The result is the matched fraction of the longer sequence, so
+// frames one video has and the other lacks count against it.
+func Similarity(a, b []uint64, maxDistance int) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	matched := 0
+	for i, hash := range a {
+		// Map the index onto b so sequences of different lengths line up
+		j := i * len(b) / len(a)
+		for _, k := range []int{j, j - 1, j + 1} {
+			if k >= 0 && k < len(b) && Distance(hash, b[k]) <= maxDistance {
+				matched++
+				break
+			}
+		}
+	}
+	return float64(matched) / float64(len(b))
+}
+
+// shrink averages img down to width x height luminance values in row order.
+func shrink(img image.Image, width, height int) []float64 {
+	src := img.Bounds()
+	out := make([]float64, width*height)
+	if src.Dx() == 0 || src.Dy() == 0 {
+		return out
+	}
+	for y := 0; y < height; y++ {
+		y0 := src.Min.Y + y*src.Dy()/height
+		y1 := max(y0+1, src.Min.Y+(y+1)*src.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := src.Min.X + x*src.Dx()/width
+			x1 := max(x0+1, src.Min.X+(x+1)*src.Dx()/width)
+
+			var sum float64
+			var n int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					r, g, b, _ := img.At(sx, sy).RGBA()
+					// ITU-R BT.601 luma
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+					n++
+				}
+			}
+			out[y*width+x] = sum / float64(n)
+		}
+	}
+	return out
+}
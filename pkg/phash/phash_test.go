@@ -0,0 +1,83 @@
+package phash
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// gradient draws a diagonal brightness ramp with a bright block whose
+// position depends on seed, so different seeds give different pictures.
+func gradient(w, h, seed int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := (x*255/w + y*128/h) % 256
+			if (x*8/w+seed)%3 == 0 && (y*8/h+seed)%2 == 0 {
+				v = 255 - v
+			}
+			img.SetGray(x, y, color.Gray{Y: uint8(v)})
+		}
+	}
+	return img
+}
+
+func TestHashSurvivesRescaling(t *testing.T) {
+	original := Hash(gradient(640, 360, 1))
+	if d := Distance(original, Hash(gradient(320, 180, 1))); d > 4 {
+		t.Fatalf("rescaled frame is %d bits away", d)
+	}
+	if d := Distance(original, Hash(gradient(640, 360, 2))); d < 10 {
+		t.Fatalf("different frame is only %d bits away", d)
+	}
+}
+
+func TestBandsCoverHash(t *testing.T) {
+	hash := uint64(0x0123456789abcdef)
+	var rebuilt uint64
+	for i := 0; i < Bands; i++ {
+		rebuilt = rebuilt<<BandWidth(i) | uint64(Band(hash, i))
+	}
+	if rebuilt != hash {
+		t.Fatalf("bands rebuild %x, want %x", rebuilt, hash)
+	}
+}
+
+func TestBandsShareWithinDistance(t *testing.T) {
+	hash := uint64(0x0123456789abcdef)
+	// Flip one bit in every band but the last: Bands-1 bits apart
+	other := hash
+	for i := 0; i < Bands-1; i++ {
+		other ^= 1 << (63 - bandOffset(i))
+	}
+	if d := Distance(hash, other); d != Bands-1 {
+		t.Fatalf("distance = %d, want %d", d, Bands-1)
+	}
+	shared := 0
+	for i := 0; i < Bands; i++ {
+		if Band(hash, i) == Band(other, i) {
+			shared++
+		}
+	}
+	if shared != 1 {
+		t.Fatalf("hashes share %d bands, want 1", shared)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	a := []uint64{0x0, 0xff, 0xff00, 0xff0000}
+	if got := Similarity(a, a, 0); got != 1 {
+		t.Fatalf("identical sequences = %v, want 1", got)
+	}
+	// One frame dropped at the start: neighbours still line up
+	if got := Similarity(a, a[1:], 0); got < 0.7 {
+		t.Fatalf("trimmed sequence = %v, want at least 0.7", got)
+	}
+	unrelated := []uint64{^uint64(0), 0xf0f0f0f0f0f0f0f0, 0x0f0f0f0f0f0f0f0f, 0xaaaaaaaaaaaaaaaa}
+	if got := Similarity(a, unrelated, 10); got != 0 {
+		t.Fatalf("unrelated sequences = %v, want 0", got)
+	}
+	if got := Similarity(nil, a, 10); got != 0 {
+		t.Fatalf("empty sequence = %v, want 0", got)
+	}
+}
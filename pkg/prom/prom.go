@@ -0,0 +1,325 @@
+// Package prom is a small registry of metrics that renders the Prometheus
+// text exposition format (version 0.0.4).
+//
+// Counters and histograms are updated in process; values that already live
+// elsewhere, such as connection pool stats or queue depths, are read by
+// collect functions when the registry is written.
+package prom
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the Content-Type of the text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Type is the type of a metric family
+type Type string
+
+const (
+	CounterType   Type = "counter"
+	GaugeType     Type = "gauge"
+	HistogramType Type = "histogram"
+)
+
+// DefaultBuckets are latency buckets in seconds, from 5ms to 10s
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Label is a label name and value pair
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is one value of a family; Suffix is appended to the family name,
+// as in the _bucket, _sum and _count series of a histogram
+type Sample struct {
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+type family struct {
+	name    string
+	help    string
+	typ     Type
+	collect func() []Sample
+}
+
+// Registry holds metric families and writes them in name order
+type Registry struct {
+	mu       sync.RWMutex
+	families map[string]*family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// RegisterFunc adds a family whose samples are produced by collect each time
+// the registry is written. It panics if name is already registered.
+func (r *Registry) RegisterFunc(name, help string, typ Type, collect func() []Sample) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.families[name]; exists {
+		panic(fmt.Sprintf("prom: metric %q registered twice", name))
+	}
+	r.families[name] = &family{name: name, help: help, typ: typ, collect: collect}
+}
+
+// GaugeFunc adds an unlabelled gauge read from value
+func (r *Registry) GaugeFunc(name, help string, value func() float64) {
+	r.RegisterFunc(name, help, GaugeType, func() []Sample {
+		return []Sample{{Value: value()}}
+	})
+}
+
+// CounterFunc adds an unlabelled counter read from value
+func (r *Registry) CounterFunc(name, help string, value func() float64) {
+	r.RegisterFunc(name, help, CounterType, func() []Sample {
+		return []Sample{{Value: value()}}
+	})
+}
+
+// Write renders every family in the text exposition format
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.RLock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.RUnlock()
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	var b strings.Builder
+	for _, f := range families {
+		samples := f.collect()
+		if len(samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", f.name, f.typ)
+		for _, sample := range samples {
+			b.WriteString(f.name)
+			b.WriteString(sample.Suffix)
+			writeLabels(&b, sample.Labels)
+			b.WriteByte(' ')
+			b.WriteString(formatValue(sample.Value))
+			b.WriteByte('\n')
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeLabels(b *strings.Builder, labels []Label) {
+	if len(labels) == 0 {
+		return
+	}
+	b.WriteByte('{')
+	for i, label := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(label.Name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(label.Value))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// labelKey joins label values into a map key; \xff cannot occur in UTF-8
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+func pairLabels(names, values []string) []Label {
+	labels := make([]Label, len(names))
+	for i, name := range names {
+		labels[i] = Label{Name: name, Value: values[i]}
+	}
+	return labels
+}
+
+// CounterVec is a counter partitioned by label values
+type CounterVec struct {
+	labels []string
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounterVec registers a counter with the given label names
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{labels: labels, values: make(map[string]*counterValue)}
+	r.RegisterFunc(name, help, CounterType, c.collect)
+	return c
+}
+
+// Inc adds one to the counter of the given label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds delta, which must not be negative, to the counter of the given
+// label values
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if len(labelValues) != len(c.labels) || delta < 0 {
+		return
+	}
+	key := labelKey(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	if !ok {
+		value = &counterValue{labelValues: append([]string(nil), labelValues...)}
+		c.values[key] = value
+	}
+	value.value += delta
+}
+
+func (c *CounterVec) collect() []Sample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	samples := make([]Sample, 0, len(c.values))
+	for _, value := range c.values {
+		samples = append(samples, Sample{Labels: pairLabels(c.labels, value.labelValues), Value: value.value})
+	}
+	sortSamples(samples)
+	return samples
+}
+
+// HistogramVec is a histogram partitioned by label values
+type HistogramVec struct {
+	labels  []string
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labelValues []string
+	counts      []uint64
+	sum         float64
+	count       uint64
+}
+
+// NewHistogramVec registers a histogram with the given upper bucket bounds,
+// which are sorted; the +Inf bucket is implied
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+	h := &HistogramVec{labels: labels, buckets: bounds, values: make(map[string]*histogramValue)}
+	r.RegisterFunc(name, help, HistogramType, h.collect)
+	return h
+}
+
+// Observe records one value for the given label values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	if len(labelValues) != len(h.labels) {
+		return
+	}
+	key := labelKey(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hv, ok := h.values[key]
+	if !ok {
+		hv = &histogramValue{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.values[key] = hv
+	}
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		hv.counts[i]++
+	}
+	hv.sum += value
+	hv.count++
+}
+
+func (h *HistogramVec) collect() []Sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys := make([]string, 0, len(h.values))
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	samples := make([]Sample, 0, len(keys)*(len(h.buckets)+3))
+	for _, key := range keys {
+		hv := h.values[key]
+		labels := pairLabels(h.labels, hv.labelValues)
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += hv.counts[i]
+			samples = append(samples, Sample{
+				Suffix: "_bucket",
+				Labels: append(append([]Label(nil), labels...), Label{Name: "le", Value: formatValue(bound)}),
+				Value:  float64(cumulative),
+			})
+		}
+		samples = append(samples,
+			Sample{
+				Suffix: "_bucket",
+				Labels: append(append([]Label(nil), labels...), Label{Name: "le", Value: "+Inf"}),
+				Value:  float64(hv.count),
+			},
+			Sample{Suffix: "_sum", Labels: labels, Value: hv.sum},
+			Sample{Suffix: "_count", Labels: labels, Value: float64(hv.count)},
+		)
+	}
+	return samples
+}
+
+// sortSamples orders samples by their label values, so output is stable
+func sortSamples(samples []Sample) {
+	sort.Slice(samples, func(i, j int) bool {
+		return sampleKey(samples[i]) < sampleKey(samples[j])
+	})
+}
+
+func sampleKey(sample Sample) string {
+	values := make([]string, len(sample.Labels))
+	for i, label := range sample.Labels {
+		values[i] = label.Value
+	}
+	return labelKey(values)
+}
@@ -0,0 +1,94 @@
+package prom
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWriteTextFormat(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("http_requests_total", "Requests served.", "method", "status")
+	requests.Inc("GET", "200")
+	requests.Add(2, "GET", "200")
+	requests.Inc("POST", "500")
+	requests.Add(-1, "POST", "500")
+	requests.Inc("GET")
+	r.GaugeFunc("sse_connections", "Open SSE streams.\nPer instance.", func() float64 { return 3 })
+	r.RegisterFunc("queue_tasks", "Tasks by queue.", GaugeType, func() []Sample {
+		return []Sample{{Labels: []Label{{Name: "queue", Value: `say "hi"\`}}, Value: 1.5}}
+	})
+	r.RegisterFunc("empty_family", "Nothing yet.", GaugeType, func() []Sample { return nil })
+
+	var out strings.Builder
+	if err := r.Write(&out); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	want := `# HELP http_requests_total Requests served.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",status="200"} 3
+http_requests_total{method="POST",status="500"} 1
+# HELP queue_tasks Tasks by queue.
+# TYPE queue_tasks gauge
+queue_tasks{queue="say \"hi\"\\"} 1.5
+# HELP sse_connections Open SSE streams.\nPer instance.
+# TYPE sse_connections gauge
+sse_connections 3
+`
+	if out.String() != want {
+		t.Fatalf("output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	r := NewRegistry()
+	latency := r.NewHistogramVec("latency_seconds", "Latency.", []float64{1, 0.1}, "route")
+	for _, v := range []float64{0.05, 0.1, 0.5, 3} {
+		latency.Observe(v, "/api/tasks")
+	}
+
+	var out strings.Builder
+	if err := r.Write(&out); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	for _, line := range []string{
+		`latency_seconds_bucket{route="/api/tasks",le="0.1"} 2`,
+		`latency_seconds_bucket{route="/api/tasks",le="1"} 3`,
+		`latency_seconds_bucket{route="/api/tasks",le="+Inf"} 4`,
+		`latency_seconds_sum{route="/api/tasks"} 3.65`,
+		`latency_seconds_count{route="/api/tasks"} 4`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	cases := map[float64]string{
+		0:            "0",
+		42:           "42",
+		0.25:         "0.25",
+		1e21:         "1e+21",
+		math.Inf(1):  "+Inf",
+		math.Inf(-1): "-Inf",
+		math.NaN():   "NaN",
+	}
+	for value, want := range cases {
+		if got := formatValue(value); got != want {
+			t.Errorf("formatValue(%v) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestRegisterTwicePanics(t *testing.T) {
+	r := NewRegistry()
+	r.GaugeFunc("up", "Up.", func() float64 { return 1 })
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering a name twice should panic")
+		}
+	}()
+	r.GaugeFunc("up", "Up.", func() float64 { return 1 })
+}
@@ -4,17 +4,21 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"comment-review-platform/internal/config"
+	"comment-review-platform/pkg/mp4"
+	"comment-review-platform/pkg/tracing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 type R2Service struct {
@@ -22,6 +26,19 @@ type R2Service struct {
 	bucket string
 }
 
+// Common video file extensions
+var videoExtensions = map[string]bool{
+	".mp4":  true,
+	".avi":  true,
+	".mov":  true,
+	".mkv":  true,
+	".webm": true,
+	".flv":  true,
+	".wmv":  true,
+	".m4v":  true,
+	".3gp":  true,
+}
+
 type VideoMetadata struct {
 	Key      string
 	Filename string
@@ -40,6 +57,7 @@ func NewR2Service() (*R2Service, error) {
 	// Create AWS config for R2
 	awsConfig, err := awsconfig.LoadDefaultConfig(context.TODO(),
 		awsconfig.WithRegion("auto"), // R2 uses "auto" region
+		awsconfig.WithHTTPClient(newTracedHTTPClient()),
 		awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
 			cfg.R2AccessKeyID,
 			cfg.R2SecretAccessKey,
@@ -89,18 +107,9 @@ func (r *R2Service) ListVideosInPath(prefix string) ([]VideoMetadata, error) {
 		prefix += "/"
 	}
 
-	// Common video file extensions
-	videoExtensions := map[string]bool{
-		".mp4":  true,
-		".avi":  true,
-		".mov":  true,
-		".mkv":  true,
-		".webm": true,
-		".flv":  true,
-		".wmv":  true,
-		".m4v":  true,
-		".3gp":  true,
-	}
+	ctx, span := r.startSpan(context.Background(), "ListObjectsV2", "")
+	defer span.End()
+	span.SetAttributes(tracing.String("aws.s3.prefix", prefix))
 
 	paginator := s3.NewListObjectsV2Paginator(r.client, &s3.ListObjectsV2Input{
 		Bucket: aws.String(r.bucket),
@@ -108,47 +117,99 @@ func (r *R2Service) ListVideosInPath(prefix string) ([]VideoMetadata, error) {
 	})
 
 	for paginator.HasMorePages() {
-		page, err := paginator.NextPage(context.TODO())
+		page, err := paginator.NextPage(ctx)
 		if err != nil {
+			span.RecordError(err)
 			return nil, fmt.Errorf("failed to list objects: %w", err)
 		}
 
 		for _, obj := range page.Contents {
-			// Skip directories (objects ending with /)
-			if strings.HasSuffix(*obj.Key, "/") {
-				continue
+			if video, ok := videoFromObject(obj); ok {
+				videos = append(videos, video)
 			}
+		}
+	}
 
-			// Check if it's a video file
-			ext := strings.ToLower(filepath.Ext(*obj.Key))
-			if !videoExtensions[ext] {
-				continue
-			}
+	log.Printf("Found %d videos in path: %s", len(videos), prefix)
+	return videos, nil
+}
+
+// VideoPage is one page of a prefix listing
+type VideoPage struct {
+	Videos []VideoMetadata
+	// LastKey is the last key listed, video or not. Pass it as startAfter to
+	// fetch the next page.
+	LastKey string
+	HasMore bool
+}
+
+// ListVideosPage lists up to maxKeys objects under prefix whose keys sort after
+// startAfter and returns the video files among them
+func (r *R2Service) ListVideosPage(prefix, startAfter string, maxKeys int32) (*VideoPage, error) {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(r.bucket),
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int32(maxKeys),
+	}
+	if startAfter != "" {
+		input.StartAfter = aws.String(startAfter)
+	}
 
-			// Extract filename from key
-			filename := filepath.Base(*obj.Key)
+	ctx, span := r.startSpan(context.Background(), "ListObjectsV2", "")
+	defer span.End()
+	span.SetAttributes(tracing.String("aws.s3.prefix", prefix))
+	result, err := r.client.ListObjectsV2(ctx, input)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
 
-			videos = append(videos, VideoMetadata{
-				Key:      *obj.Key,
-				Filename: filename,
-				Size:     *obj.Size,
-				Modified: *obj.LastModified,
-			})
+	page := &VideoPage{
+		LastKey: startAfter,
+		HasMore: aws.ToBool(result.IsTruncated),
+	}
+	for _, obj := range result.Contents {
+		page.LastKey = aws.ToString(obj.Key)
+		if video, ok := videoFromObject(obj); ok {
+			page.Videos = append(page.Videos, video)
 		}
 	}
+	return page, nil
+}
 
-	log.Printf("Found %d videos in path: %s", len(videos), prefix)
-	return videos, nil
+// videoFromObject converts a listed object to VideoMetadata, skipping
+// directories and non-video files
+func videoFromObject(obj types.Object) (VideoMetadata, bool) {
+	key := aws.ToString(obj.Key)
+	if strings.HasSuffix(key, "/") {
+		return VideoMetadata{}, false
+	}
+	if !videoExtensions[strings.ToLower(filepath.Ext(key))] {
+		return VideoMetadata{}, false
+	}
+	return VideoMetadata{
+		Key:      key,
+		Filename: filepath.Base(key),
+		Size:     aws.ToInt64(obj.Size),
+		Modified: aws.ToTime(obj.LastModified),
+	}, true
 }
 
 // GetVideoMetadata gets metadata for a specific video
 func (r *R2Service) GetVideoMetadata(videoKey string) (*VideoMetadata, error) {
-	result, err := r.client.HeadObject(context.TODO(), &s3.HeadObjectInput{
+	ctx, span := r.startSpan(context.Background(), "HeadObject", videoKey)
+	defer span.End()
+	result, err := r.client.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(r.bucket),
 		Key:    aws.String(videoKey),
 	})
 
 	if err != nil {
+		span.RecordError(err)
 		return nil, fmt.Errorf("failed to get video metadata: %w", err)
 	}
 
@@ -164,11 +225,14 @@ func (r *R2Service) GetVideoMetadata(videoKey string) (*VideoMetadata, error) {
 
 // CheckConnection tests the R2 connection
 func (r *R2Service) CheckConnection() error {
-	_, err := r.client.HeadBucket(context.TODO(), &s3.HeadBucketInput{
+	ctx, span := r.startSpan(context.Background(), "HeadBucket", "")
+	defer span.End()
+	_, err := r.client.HeadBucket(ctx, &s3.HeadBucketInput{
 		Bucket: aws.String(r.bucket),
 	})
 
 	if err != nil {
+		span.RecordError(err)
 		return fmt.Errorf("failed to connect to R2 bucket: %w", err)
 	}
 
@@ -176,8 +240,8 @@ func (r *R2Service) CheckConnection() error {
 	return nil
 }
 
-// UploadObject uploads data to R2 at the given key
-func (r *R2Service) UploadObject(key string, data []byte, contentType string) error {
+// UploadObject uploads data to R2 at the given key, traced under the span of ctx
+func (r *R2Service) UploadObject(ctx context.Context, key string, data []byte, contentType string) error {
 	if r == nil {
 		return fmt.Errorf("R2 service not initialized")
 	}
@@ -191,34 +255,237 @@ func (r *R2Service) UploadObject(key string, data []byte, contentType string) er
 		input.ContentType = aws.String(contentType)
 	}
 
-	_, err := r.client.PutObject(context.TODO(), input)
+	ctx, span := r.startSpan(ctx, "PutObject", key)
+	defer span.End()
+	_, err := r.client.PutObject(ctx, input)
 	if err != nil {
+		span.RecordError(err)
 		return fmt.Errorf("failed to upload object: %w", err)
 	}
 	return nil
 }
 
-// GetVideoDuration attempts to get video duration (placeholder - would need ffmpeg integration)
-func (r *R2Service) GetVideoDuration(videoKey string) (int, error) {
-	// This is a placeholder. In a real implementation, you would:
-	// 1. Download a small portion of the video
-	// 2. Use ffmpeg or similar to extract duration
-	// 3. Return the duration in seconds
+// DownloadObject opens an object for reading; the caller closes the body
+func (r *R2Service) DownloadObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	if r == nil {
+		return nil, fmt.Errorf("R2 service not initialized")
+	}
+
+	ctx, span := r.startSpan(ctx, "GetObject", key)
+	defer span.End()
+	result, err := r.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to download object: %w", err)
+	}
+	return result.Body, nil
+}
+
+// ProbeVideo reads the container metadata of an MP4/MOV object using ranged
+// reads, fetching only box headers and the moov box.
+func (r *R2Service) ProbeVideo(videoKey string, size int64) (*mp4.Metadata, error) {
+	if size <= 0 {
+		metadata, err := r.GetVideoMetadata(videoKey)
+		if err != nil {
+			return nil, err
+		}
+		size = metadata.Size
+	}
+	ctx, span := tracing.Start(context.Background(), "R2.ProbeVideo", r.spanOptions("ProbeVideo", videoKey)...)
+	defer span.End()
+	metadata, err := mp4.Probe(&objectReaderAt{ctx: ctx, service: r, key: videoKey}, size)
+	span.RecordError(err)
+	return metadata, err
+}
+
+// ObjectReaderAt gives random access to an object; every ReadAt is one ranged GET.
+func (r *R2Service) ObjectReaderAt(key string) io.ReaderAt {
+	return &objectReaderAt{ctx: context.Background(), service: r, key: key}
+}
+
+// objectReaderAt serves ReadAt calls with HTTP Range requests against one object.
+// Reads are only traced as part of a larger operation, such as ProbeVideo.
+type objectReaderAt struct {
+	ctx     context.Context
+	service *R2Service
+	key     string
+}
+
+func (o *objectReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	ctx, span := tracing.StartChild(o.ctx, "R2.GetObject", o.service.spanOptions("GetObject", o.key)...)
+	defer span.End()
+	result, err := o.service.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(o.service.bucket),
+		Key:    aws.String(o.key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", off, off+int64(len(p))-1)),
+	})
+	if err != nil {
+		span.RecordError(err)
+		return 0, fmt.Errorf("failed to read object range: %w", err)
+	}
+	defer result.Body.Close()
+
+	n, err := io.ReadFull(result.Body, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+const (
+	// multipartPartSize is the size of every part but the last. R2 requires
+	// those parts to be the same size, so it is fixed for the whole upload.
+	multipartPartSize = 8 << 20
+	// multipartMaxParts is the most parts one upload may have, which caps an
+	// object at multipartPartSize*multipartMaxParts bytes (about 78 GiB)
+	multipartMaxParts = 10000
+)
+
+// MultipartWriter uploads an object of unknown size as a stream of parts.
+// Only the part being filled is held in memory. Close completes the upload;
+// Abort discards it.
+type MultipartWriter struct {
+	service  *R2Service
+	key      string
+	uploadID *string
+	buffer   []byte
+	partSize int
+	parts    []types.CompletedPart
+	size     int64
+	done     bool
+}
+
+// NewMultipartWriter starts a multipart upload to key
+func (r *R2Service) NewMultipartWriter(key, contentType string) (*MultipartWriter, error) {
+	if r == nil {
+		return nil, fmt.Errorf("R2 service not initialized")
+	}
 
-	// For now, return a default duration based on file size
-	metadata, err := r.GetVideoMetadata(videoKey)
+	input := &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(key),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	ctx, span := r.startSpan(context.Background(), "CreateMultipartUpload", key)
+	defer span.End()
+	result, err := r.client.CreateMultipartUpload(ctx, input)
 	if err != nil {
-		return 0, err
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to start multipart upload: %w", err)
 	}
+	return &MultipartWriter{
+		service:  r,
+		key:      key,
+		uploadID: result.UploadId,
+		partSize: multipartPartSize,
+	}, nil
+}
 
-	// Rough estimation: assume 1MB = 10 seconds for TikTok videos
-	estimatedDuration := int(metadata.Size / (1024 * 1024) * 10)
-	if estimatedDuration < 10 {
-		estimatedDuration = 10 // minimum 10 seconds
+// Write buffers p and uploads every part that fills up
+func (m *MultipartWriter) Write(p []byte) (int, error) {
+	if m.done {
+		return 0, fmt.Errorf("multipart upload of %s is finished", m.key)
 	}
-	if estimatedDuration > 60 {
-		estimatedDuration = 60 // maximum 60 seconds for TikTok
+	written := 0
+	for len(p) > 0 {
+		if m.buffer == nil {
+			m.buffer = make([]byte, 0, m.partSize)
+		}
+		n := copy(m.buffer[len(m.buffer):m.partSize], p)
+		m.buffer = m.buffer[:len(m.buffer)+n]
+		p = p[n:]
+		written += n
+		if len(m.buffer) == m.partSize {
+			if err := m.uploadPart(); err != nil {
+				return written, err
+			}
+		}
 	}
+	return written, nil
+}
 
-	return estimatedDuration, nil
+// Size returns the number of bytes written so far
+func (m *MultipartWriter) Size() int64 {
+	return m.size + int64(len(m.buffer))
+}
+
+func (m *MultipartWriter) uploadPart() error {
+	if len(m.parts) >= multipartMaxParts {
+		return fmt.Errorf("multipart upload of %s exceeds the %d-part limit (%d bytes)",
+			m.key, multipartMaxParts, int64(multipartMaxParts)*int64(m.partSize))
+	}
+	number := int32(len(m.parts) + 1)
+	ctx, span := m.service.startSpan(context.Background(), "UploadPart", m.key)
+	defer span.End()
+	result, err := m.service.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:     aws.String(m.service.bucket),
+		Key:        aws.String(m.key),
+		UploadId:   m.uploadID,
+		PartNumber: aws.Int32(number),
+		Body:       bytes.NewReader(m.buffer),
+	})
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to upload part %d: %w", number, err)
+	}
+	m.parts = append(m.parts, types.CompletedPart{ETag: result.ETag, PartNumber: aws.Int32(number)})
+	m.size += int64(len(m.buffer))
+	m.buffer = m.buffer[:0]
+	return nil
+}
+
+// Close uploads the last part and completes the upload. The upload is only
+// finished once completing it succeeds; after a failed Close, Abort discards
+// it.
+func (m *MultipartWriter) Close() error {
+	if m.done {
+		return nil
+	}
+	if len(m.buffer) > 0 || len(m.parts) == 0 {
+		if err := m.uploadPart(); err != nil {
+			return err
+		}
+	}
+	ctx, span := m.service.startSpan(context.Background(), "CompleteMultipartUpload", m.key)
+	defer span.End()
+	_, err := m.service.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(m.service.bucket),
+		Key:             aws.String(m.key),
+		UploadId:        m.uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: m.parts},
+	})
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	m.done = true
+	return nil
+}
+
+// Abort discards the upload and the parts uploaded so far
+func (m *MultipartWriter) Abort() error {
+	if m.done {
+		return nil
+	}
+	m.done = true
+	ctx, span := m.service.startSpan(context.Background(), "AbortMultipartUpload", m.key)
+	defer span.End()
+	_, err := m.service.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(m.service.bucket),
+		Key:      aws.String(m.key),
+		UploadId: m.uploadID,
+	})
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+	return nil
 }
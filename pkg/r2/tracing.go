@@ -0,0 +1,36 @@
+package r2
+
+import (
+	"context"
+	"net/http"
+
+	"comment-review-platform/pkg/tracing"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+)
+
+// newTracedHTTPClient returns the SDK's default HTTP client with the trace
+// context of each operation's span propagated on its requests
+func newTracedHTTPClient() *http.Client {
+	return &http.Client{Transport: &tracing.Transport{Base: awshttp.NewBuildableClient().GetTransport()}}
+}
+
+// startSpan starts a client span for one R2 operation, a child of the span of
+// ctx. Operations run only by background jobs pass context.Background and
+// start traces of their own.
+func (r *R2Service) startSpan(ctx context.Context, operation, key string) (context.Context, *tracing.Span) {
+	return tracing.Start(ctx, "R2."+operation, r.spanOptions(operation, key)...)
+}
+
+func (r *R2Service) spanOptions(operation, key string) []tracing.StartOption {
+	attributes := []tracing.Attribute{
+		tracing.String("rpc.system", "aws-api"),
+		tracing.String("rpc.service", "S3"),
+		tracing.String("rpc.method", operation),
+		tracing.String("aws.s3.bucket", r.bucket),
+	}
+	if key != "" {
+		attributes = append(attributes, tracing.String("aws.s3.key", key))
+	}
+	return []tracing.StartOption{tracing.WithKind(tracing.KindClient), tracing.WithAttributes(attributes...)}
+}
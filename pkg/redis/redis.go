@@ -46,6 +46,7 @@ func InitRedis(addr, password string, db int, useTLS bool, tlsSkipVerify bool) (
     }
 
 	client := redis.NewClient(options)
+	client.AddHook(tracingHook{})
 
 	// Test connection
 	ctx := context.Background()
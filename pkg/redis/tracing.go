@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"context"
+	"errors"
+
+	"comment-review-platform/pkg/tracing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tracingHook records commands and pipelines as client spans. Like database
+// queries they are only recorded inside a sampled trace. Arguments are left
+// out, as they can hold tokens and other secrets.
+type tracingHook struct{}
+
+func (tracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (tracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := tracing.StartChild(ctx, cmd.Name(),
+			tracing.WithKind(tracing.KindClient),
+			tracing.WithAttributes(
+				tracing.String("db.system", "redis"),
+				tracing.String("db.operation", cmd.Name()),
+			),
+		)
+		err := next(ctx, cmd)
+		endRedisSpan(span, err)
+		return err
+	}
+}
+
+func (tracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := tracing.StartChild(ctx, "pipeline",
+			tracing.WithKind(tracing.KindClient),
+			tracing.WithAttributes(
+				tracing.String("db.system", "redis"),
+				tracing.String("db.operation", "pipeline"),
+				tracing.Int("db.operation.batch.size", len(cmds)),
+			),
+		)
+		err := next(ctx, cmds)
+		endRedisSpan(span, err)
+		return err
+	}
+}
+
+// endRedisSpan ends span, treating a missing key as a result rather than
+// a failure
+func endRedisSpan(span *tracing.Span, err error) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+var _ redis.Hook = tracingHook{}
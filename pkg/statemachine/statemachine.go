@@ -0,0 +1,129 @@
+// Package statemachine validates status changes against a fixed graph of
+// allowed transitions. A machine is declared once per kind of entity and is
+// consulted wherever that entity's status is written, so an illegal change is
+// rejected no matter which code path attempts it.
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidTransition matches every *TransitionError with errors.Is.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// Transition is one allowed edge of a machine.
+type Transition struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Graph is the serializable form of a machine.
+type Graph struct {
+	Name        string       `json:"name"`
+	Initial     string       `json:"initial"`
+	States      []string     `json:"states"`
+	Final       []string     `json:"final"`
+	Transitions []Transition `json:"transitions"`
+}
+
+// TransitionError reports a status change that a machine does not allow.
+type TransitionError struct {
+	Machine string
+	From    string
+	To      string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s status cannot change from %q to %q", e.Machine, e.From, e.To)
+}
+
+// Is makes every TransitionError match ErrInvalidTransition.
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// Machine is the transition graph of one kind of entity. It is immutable once
+// built and safe for concurrent use.
+type Machine struct {
+	name        string
+	states      []string
+	transitions []Transition
+	next        map[string]map[string]bool
+}
+
+// New builds a machine. states lists every status in display order, the
+// first one being the status entities start in. Graphs are declared at
+// compile time, so New panics on a transition between undeclared states.
+func New(name string, states []string, transitions []Transition) *Machine {
+	if len(states) == 0 {
+		panic("statemachine: " + name + " has no states")
+	}
+	m := &Machine{
+		name:        name,
+		states:      append([]string(nil), states...),
+		transitions: append([]Transition(nil), transitions...),
+		next:        make(map[string]map[string]bool, len(states)),
+	}
+	for _, s := range states {
+		m.next[s] = map[string]bool{}
+	}
+	for _, t := range transitions {
+		if !m.Has(t.From) || !m.Has(t.To) {
+			panic(fmt.Sprintf("statemachine: %s transition %s -> %s uses an undeclared state", name, t.From, t.To))
+		}
+		m.next[t.From][t.To] = true
+	}
+	return m
+}
+
+// Name returns the name the machine reports in errors.
+func (m *Machine) Name() string {
+	return m.name
+}
+
+// Has reports whether state is declared.
+func (m *Machine) Has(state string) bool {
+	_, ok := m.next[state]
+	return ok
+}
+
+// Can reports whether an entity in from may move to to.
+func (m *Machine) Can(from, to string) bool {
+	return m.next[from][to]
+}
+
+// Validate returns a *TransitionError unless from may move to to. Writing a
+// declared status over itself is always valid, so retried writes stay
+// idempotent.
+func (m *Machine) Validate(from, to string) error {
+	if from == to && m.Has(to) {
+		return nil
+	}
+	if m.Can(from, to) {
+		return nil
+	}
+	return &TransitionError{Machine: m.name, From: from, To: to}
+}
+
+// Final returns the states without outgoing transitions, in display order.
+func (m *Machine) Final() []string {
+	final := []string{}
+	for _, s := range m.states {
+		if len(m.next[s]) == 0 {
+			final = append(final, s)
+		}
+	}
+	return final
+}
+
+// Graph returns a copy of the machine's graph.
+func (m *Machine) Graph() Graph {
+	return Graph{
+		Name:        m.name,
+		Initial:     m.states[0],
+		States:      append([]string(nil), m.states...),
+		Final:       m.Final(),
+		Transitions: append([]Transition(nil), m.transitions...),
+	}
+}
@@ -0,0 +1,75 @@
+package statemachine
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func testMachine() *Machine {
+	return New("ticket", []string{"open", "in_progress", "done", "dropped"}, []Transition{
+		{From: "open", To: "in_progress"},
+		{From: "open", To: "dropped"},
+		{From: "in_progress", To: "done"},
+	})
+}
+
+func TestValidate(t *testing.T) {
+	m := testMachine()
+	tests := []struct {
+		from, to string
+		ok       bool
+	}{
+		{"open", "in_progress", true},
+		{"in_progress", "done", true},
+		{"done", "done", true},
+		{"done", "open", false},
+		{"open", "done", false},
+		{"open", "archived", false},
+		{"archived", "archived", false},
+	}
+	for _, tt := range tests {
+		err := m.Validate(tt.from, tt.to)
+		if (err == nil) != tt.ok {
+			t.Errorf("Validate(%q, %q) = %v, want ok=%v", tt.from, tt.to, err, tt.ok)
+		}
+	}
+}
+
+func TestTransitionErrorIsTyped(t *testing.T) {
+	err := testMachine().Validate("done", "open")
+
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("errors.Is(%v, ErrInvalidTransition) = false", err)
+	}
+	var te *TransitionError
+	if !errors.As(err, &te) {
+		t.Fatalf("errors.As(%v, *TransitionError) = false", err)
+	}
+	if te.Machine != "ticket" || te.From != "done" || te.To != "open" {
+		t.Errorf("unexpected error fields: %+v", te)
+	}
+}
+
+func TestGraph(t *testing.T) {
+	g := testMachine().Graph()
+
+	if g.Initial != "open" {
+		t.Errorf("Initial = %q, want open", g.Initial)
+	}
+	if want := []string{"done", "dropped"}; !reflect.DeepEqual(g.Final, want) {
+		t.Errorf("Final = %v, want %v", g.Final, want)
+	}
+	if len(g.Transitions) != 3 {
+		t.Errorf("got %d transitions, want 3", len(g.Transitions))
+	}
+}
+
+func TestNewRejectsUndeclaredState(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("New did not panic on an undeclared state")
+		}
+	}()
+	New("ticket", []string{"open"}, []Transition{{From: "open", To: "closed"}})
+}
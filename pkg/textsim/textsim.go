@@ -0,0 +1,141 @@
+// Package textsim finds near-identical short texts such as spam comment
+// waves. Texts are normalized so trivial variations (case, full-width forms,
+// punctuation, emoji padding, changed numbers or links) disappear, then
+// compared by MinHash signatures over character shingles, which works for
+// Chinese text without word segmentation.
+package textsim
+
+import (
+	"hash/fnv"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	// SignatureSize is the number of MinHash values per signature.
+	SignatureSize = 64
+	// BandRows is the number of signature values per LSH band. With 16 bands
+	// of 4 rows, pairs above ~0.5 similarity share a band with high probability.
+	BandRows = 4
+	// Bands is the number of LSH bands in a signature.
+	Bands = SignatureSize / BandRows
+
+	shingleSize = 3
+)
+
+var (
+	urlPattern    = regexp.MustCompile(`(?i)(https?://|www\.)\S+`)
+	digitsPattern = regexp.MustCompile(`\d+`)
+)
+
+// Normalize folds a text to the form that spam variants share: lower case,
+// half-width, links and numbers replaced by placeholders, punctuation, symbols
+// and spaces removed, and runs of a repeated character cut to two.
+func Normalize(text string) string {
+	text = strings.Map(foldWidth, text)
+	text = strings.ToLower(text)
+	text = urlPattern.ReplaceAllString(text, "↗")
+	text = digitsPattern.ReplaceAllString(text, "#")
+
+	var b strings.Builder
+	var last rune
+	run := 0
+	for _, r := range text {
+		if !(unicode.IsLetter(r) || unicode.IsNumber(r) || r == '↗' || r == '#') {
+			continue
+		}
+		if r == last {
+			run++
+			if run > 2 {
+				continue
+			}
+		} else {
+			last, run = r, 1
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// foldWidth maps full-width ASCII variants and the ideographic space to
+// their half-width forms.
+func foldWidth(r rune) rune {
+	switch {
+	case r >= 0xFF01 && r <= 0xFF5E:
+		return r - 0xFEE0
+	case r == 0x3000:
+		return ' '
+	}
+	return r
+}
+
+// Signature is the MinHash signature of a normalized text.
+type Signature [SignatureSize]uint32
+
+// Sign computes the MinHash signature of a normalized text's character
+// shingles. Texts shorter than a shingle are hashed whole.
+func Sign(normalized string) Signature {
+	var sig Signature
+	for i := range sig {
+		sig[i] = math.MaxUint32
+	}
+	for _, shingle := range shingles(normalized) {
+		h := fnv.New64a()
+		h.Write([]byte(shingle))
+		sum := h.Sum64()
+		// Derive the k hash functions from two halves (Kirsch-Mitzenmacher)
+		h1, h2 := uint32(sum), uint32(sum>>32)
+		for i := range sig {
+			if v := h1 + uint32(i)*h2; v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// Similarity estimates the Jaccard similarity of the shingle sets behind two
+// signatures.
+func Similarity(a, b Signature) float64 {
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / SignatureSize
+}
+
+// BandKeys returns one bucket key per LSH band. Signatures that share any key
+// are candidates for a full comparison.
+func BandKeys(sig Signature) [Bands]uint64 {
+	var keys [Bands]uint64
+	for band := range keys {
+		h := fnv.New64a()
+		var buf [4]byte
+		for _, v := range sig[band*BandRows : (band+1)*BandRows] {
+			buf[0], buf[1], buf[2], buf[3] = byte(v), byte(v>>8), byte(v>>16), byte(v>>24)
+			h.Write(buf[:])
+		}
+		// Keep the band number in the key so bands never collide with each other
+		keys[band] = h.Sum64()&^0xff | uint64(band)
+	}
+	return keys
+}
+
+func shingles(text string) []string {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return nil
+	}
+	if len(runes) <= shingleSize {
+		return []string{text}
+	}
+	result := make([]string, 0, len(runes)-shingleSize+1)
+	for i := 0; i+shingleSize <= len(runes); i++ {
+		result = append(result, string(runes[i:i+shingleSize]))
+	}
+	return result
+}
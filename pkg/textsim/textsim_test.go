@@ -0,0 +1,62 @@
+package textsim
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Buy NOW!!! at http://spam.example/x?1", "buynowat↗"},
+		{"ＨＥＬＬＯ　ｗｏｒｌｄ", "helloworld"},
+		{"加微信 123456 领红包～～", "加微信#领红包"},
+		{"好好好好好看", "好好看"},
+		{"   ", ""},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarityOfVariants(t *testing.T) {
+	base := Sign(Normalize("关注我的主页，每天免费领取会员，名额有限先到先得"))
+	variant := Sign(Normalize("关注我的主页!!每天免费领取会员~名额有限 先到先得 😀"))
+	other := Sign(Normalize("这个视频拍得真好，背景音乐是什么歌？"))
+
+	if got := Similarity(base, variant); got != 1 {
+		t.Errorf("punctuation variant similarity = %.2f, want 1", got)
+	}
+	if got := Similarity(base, other); got > 0.2 {
+		t.Errorf("unrelated text similarity = %.2f, want <= 0.2", got)
+	}
+
+	edited := Sign(Normalize("关注我的主页，每天免费领取超级会员，名额有限先到先得"))
+	if got := Similarity(base, edited); got < 0.6 {
+		t.Errorf("one-word edit similarity = %.2f, want >= 0.6", got)
+	}
+}
+
+func TestBandKeysShareBucketForSimilarTexts(t *testing.T) {
+	a := BandKeys(Sign(Normalize("限时抢购，点击链接领取优惠券 http://a.example")))
+	b := BandKeys(Sign(Normalize("限时抢购!点击链接领取优惠券 http://b.example")))
+	shared := 0
+	for i := range a {
+		if a[i] == b[i] {
+			shared++
+		}
+	}
+	if shared != Bands {
+		t.Errorf("identical normalized texts share %d of %d bands", shared, Bands)
+	}
+}
+
+func TestSignShortText(t *testing.T) {
+	if Similarity(Sign("ok"), Sign("ok")) != 1 {
+		t.Error("short identical texts should match")
+	}
+	if Similarity(Sign("ok"), Sign("no")) != 0 {
+		t.Error("short different texts should not match")
+	}
+}
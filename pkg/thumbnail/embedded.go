@@ -0,0 +1,50 @@
+package thumbnail
+
+import (
+	"bytes"
+	"context"
+	"image"
+	_ "image/jpeg" // register decoders for embedded stills
+	_ "image/png"
+
+	"comment-review-platform/pkg/mp4"
+)
+
+// Embedded reads cover art and Motion-JPEG frames stored in MP4/MOV files.
+// It needs no external tools but yields nothing for H.264/HEVC-only files
+// without cover art.
+type Embedded struct{}
+
+func (Embedded) Name() string { return "embedded" }
+
+func (Embedded) Extract(ctx context.Context, src Source, count int) (*Frames, error) {
+	if src.Reader == nil {
+		return nil, ErrNoFrames
+	}
+	stills, err := mp4.ExtractStills(src.Reader, src.Size, count)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := &Frames{}
+	for _, data := range stills.Frames {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if img, _, err := image.Decode(bytes.NewReader(data)); err == nil {
+			frames.Keyframes = append(frames.Keyframes, img)
+		}
+	}
+	if stills.CoverArt != nil {
+		if img, _, err := image.Decode(bytes.NewReader(stills.CoverArt)); err == nil {
+			frames.Poster = img
+		}
+	}
+	if frames.Poster == nil && len(frames.Keyframes) > 0 {
+		frames.Poster = frames.Keyframes[0]
+	}
+	if frames.Poster == nil {
+		return nil, ErrNoFrames
+	}
+	return frames, nil
+}
@@ -0,0 +1,88 @@
+package thumbnail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// FFmpeg decodes frames with an external ffmpeg binary reading Source.URL.
+// Each frame is a separate input seek, so only the needed GOPs are fetched.
+type FFmpeg struct {
+	Path string // defaults to "ffmpeg" on PATH
+}
+
+func (f FFmpeg) Name() string { return "ffmpeg" }
+
+// Available reports whether the ffmpeg binary can be found.
+func (f FFmpeg) Available() bool {
+	_, err := exec.LookPath(f.binary())
+	return err == nil
+}
+
+func (f FFmpeg) binary() string {
+	if f.Path != "" {
+		return f.Path
+	}
+	return "ffmpeg"
+}
+
+func (f FFmpeg) Extract(ctx context.Context, src Source, count int) (*Frames, error) {
+	if src.URL == "" {
+		return nil, errors.New("ffmpeg: source URL is required")
+	}
+
+	frames := &Frames{}
+	// Skip the first 10% for the poster: openings are often black or a logo.
+	poster, err := f.frameAt(ctx, src.URL, src.DurationSeconds*0.1)
+	if err != nil {
+		return nil, err
+	}
+	frames.Poster = poster
+
+	// Without a duration there is nothing to spread keyframes over.
+	if src.DurationSeconds <= 0 {
+		return frames, nil
+	}
+	for i := 0; i < count; i++ {
+		at := src.DurationSeconds * (float64(2*i+1) / float64(2*count))
+		img, err := f.frameAt(ctx, src.URL, at)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		frames.Keyframes = append(frames.Keyframes, img)
+	}
+	return frames, nil
+}
+
+func (f FFmpeg) frameAt(ctx context.Context, url string, seconds float64) (image.Image, error) {
+	cmd := exec.CommandContext(ctx, f.binary(),
+		"-v", "error",
+		"-ss", strconv.FormatFloat(seconds, 'f', 3, 64),
+		"-i", url,
+		"-frames:v", "1",
+		"-f", "image2pipe",
+		"-vcodec", "png",
+		"-",
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	img, err := png.Decode(&stdout)
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg: decode frame at %.3fs: %w", seconds, err)
+	}
+	return img, nil
+}
@@ -0,0 +1,97 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+)
+
+// Fit scales img down (never up) so that it fits within maxWidth x maxHeight,
+// keeping the aspect ratio. A zero bound is unconstrained.
+func Fit(img image.Image, maxWidth, maxHeight int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return img
+	}
+	scale := 1.0
+	if maxWidth > 0 && w > maxWidth {
+		scale = float64(maxWidth) / float64(w)
+	}
+	if maxHeight > 0 && float64(h)*scale > float64(maxHeight) {
+		scale = float64(maxHeight) / float64(h)
+	}
+	if scale >= 1 {
+		return img
+	}
+	return Resize(img, max(1, int(float64(w)*scale+0.5)), max(1, int(float64(h)*scale+0.5)))
+}
+
+// Resize scales img to width x height, averaging the source pixels that fall
+// into each destination pixel (box filter).
+func Resize(img image.Image, width, height int) *image.RGBA {
+	src := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := src.Min.Y + y*src.Dy()/height
+		y1 := max(y0+1, src.Min.Y+(y+1)*src.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := src.Min.X + x*src.Dx()/width
+			x1 := max(x0+1, src.Min.X+(x+1)*src.Dx()/width)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+// Strip lays frames side by side, each scaled to height, separated by gap
+// pixels of black. It returns nil when there are no frames.
+func Strip(frames []image.Image, height, gap int) *image.RGBA {
+	if len(frames) == 0 || height <= 0 {
+		return nil
+	}
+
+	scaled := make([]image.Image, len(frames))
+	width := gap * (len(frames) - 1)
+	for i, frame := range frames {
+		b := frame.Bounds()
+		w := max(1, b.Dx()*height/max(1, b.Dy()))
+		scaled[i] = Resize(frame, w, height)
+		width += w
+	}
+
+	strip := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(strip, strip.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	x := 0
+	for _, frame := range scaled {
+		w := frame.Bounds().Dx()
+		draw.Draw(strip, image.Rect(x, 0, x+w, height), frame, frame.Bounds().Min, draw.Src)
+		x += w + gap
+	}
+	return strip
+}
+
+// EncodeJPEG encodes img as a JPEG at the given quality (1-100).
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
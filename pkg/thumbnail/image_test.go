@@ -0,0 +1,105 @@
+package thumbnail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+func solid(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestFitKeepsAspectRatioAndNeverUpscales(t *testing.T) {
+	portrait := solid(720, 1280, color.White)
+	if got := Fit(portrait, 480, 480).Bounds(); got.Dx() != 270 || got.Dy() != 480 {
+		t.Fatalf("portrait fit = %v, want 270x480", got)
+	}
+	small := solid(100, 50, color.White)
+	if got := Fit(small, 480, 480); got != small {
+		t.Fatal("expected a small image to be returned unchanged")
+	}
+}
+
+func TestResizeAveragesPixels(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.RGBA{A: 255})
+	img.Set(1, 0, color.RGBA{R: 200, G: 200, B: 200, A: 255})
+
+	got := Resize(img, 1, 1).RGBAAt(0, 0)
+	if got.R != 100 || got.A != 255 {
+		t.Fatalf("averaged pixel = %+v, want R=100", got)
+	}
+}
+
+func TestStripPlacesFramesSideBySide(t *testing.T) {
+	red := solid(160, 90, color.RGBA{R: 255, A: 255})
+	blue := solid(90, 160, color.RGBA{B: 255, A: 255})
+
+	strip := Strip([]image.Image{red, blue}, 90, 4)
+	// 160x90 stays 160 wide; 90x160 scaled to height 90 is 50 wide.
+	if strip.Bounds().Dx() != 160+4+50 || strip.Bounds().Dy() != 90 {
+		t.Fatalf("strip size = %v", strip.Bounds())
+	}
+	if c := strip.RGBAAt(10, 10); c.R != 255 {
+		t.Fatalf("first frame pixel = %+v, want red", c)
+	}
+	if c := strip.RGBAAt(162, 10); c.R != 0 || c.B != 0 {
+		t.Fatalf("gap pixel = %+v, want black", c)
+	}
+	if c := strip.RGBAAt(170, 10); c.B != 255 {
+		t.Fatalf("second frame pixel = %+v, want blue", c)
+	}
+	if Strip(nil, 90, 4) != nil {
+		t.Fatal("expected nil strip for no frames")
+	}
+}
+
+type stubExtractor struct {
+	name   string
+	frames *Frames
+	err    error
+}
+
+func (s stubExtractor) Name() string { return s.name }
+func (s stubExtractor) Extract(context.Context, Source, int) (*Frames, error) {
+	return s.frames, s.err
+}
+
+func TestChainFallsBackToNextExtractor(t *testing.T) {
+	want := &Frames{Poster: solid(1, 1, color.White)}
+	chain := Chain{
+		stubExtractor{name: "ffmpeg", err: errors.New("not installed")},
+		stubExtractor{name: "embedded", frames: want},
+	}
+	got, err := chain.Extract(context.Background(), Source{}, 4)
+	if err != nil || got != want {
+		t.Fatalf("Extract = %v, %v", got, err)
+	}
+
+	failing := Chain{stubExtractor{name: "embedded", err: errors.New("no cover")}}
+	if _, err := failing.Extract(context.Background(), Source{}, 4); !errors.Is(err, ErrNoFrames) {
+		t.Fatalf("err = %v, want ErrNoFrames", err)
+	}
+}
+
+func TestEncodeJPEGRoundTrip(t *testing.T) {
+	data, err := EncodeJPEG(solid(8, 8, color.White), 80)
+	if err != nil {
+		t.Fatalf("EncodeJPEG: %v", err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil || img.Bounds().Dx() != 8 {
+		t.Fatalf("decode = %v, %v", img, err)
+	}
+}
@@ -0,0 +1,65 @@
+// Package thumbnail produces poster frames and keyframe strips for videos.
+// Frame decoding is delegated to a pluggable Extractor; Embedded works in pure
+// Go for files that carry cover art or Motion-JPEG frames, FFmpeg handles
+// everything else when an ffmpeg binary is available.
+package thumbnail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+)
+
+var ErrNoFrames = errors.New("thumbnail: no frames could be extracted")
+
+// Source is a video to take frames from.
+type Source struct {
+	// URL is a readable (e.g. presigned) URL for decoders that stream the file.
+	URL string
+	// Reader gives random access to the file for in-process parsing.
+	Reader io.ReaderAt
+	Size   int64
+	// DurationSeconds is 0 when unknown.
+	DurationSeconds float64
+}
+
+// Frames are the stills extracted from one video.
+type Frames struct {
+	Poster    image.Image
+	Keyframes []image.Image // presentation order, may be empty
+}
+
+// Extractor pulls a poster and up to count keyframes out of a video.
+type Extractor interface {
+	Name() string
+	Extract(ctx context.Context, src Source, count int) (*Frames, error)
+}
+
+// Chain tries each extractor in order and returns the first result.
+type Chain []Extractor
+
+func (c Chain) Name() string {
+	names := make([]string, len(c))
+	for i, e := range c {
+		names[i] = e.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (c Chain) Extract(ctx context.Context, src Source, count int) (*Frames, error) {
+	var errs []string
+	for _, e := range c {
+		frames, err := e.Extract(ctx, src, count)
+		if err == nil {
+			return frames, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", e.Name(), err))
+	}
+	if len(errs) == 0 {
+		return nil, ErrNoFrames
+	}
+	return nil, fmt.Errorf("%w (%s)", ErrNoFrames, strings.Join(errs, "; "))
+}
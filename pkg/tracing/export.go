@@ -0,0 +1,348 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// instrumentationScope names this package in exported spans
+const instrumentationScope = "comment-review-platform/pkg/tracing"
+
+// exportTimeout bounds one export call
+const exportTimeout = 10 * time.Second
+
+type exporter interface {
+	exportSpans(ctx context.Context, spans []SpanData) error
+	shutdown(ctx context.Context) error
+}
+
+// OTLP/JSON request body: IDs are hex, 64-bit integers are strings and enums
+// are numbers
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	TraceState        string         `json:"traceState,omitempty"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              Kind           `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func otlpAttributes(attributes []Attribute) []otlpKeyValue {
+	if len(attributes) == 0 {
+		return nil
+	}
+	values := make([]otlpKeyValue, 0, len(attributes))
+	for _, attribute := range attributes {
+		var value otlpAnyValue
+		switch v := attribute.Value.(type) {
+		case string:
+			value.StringValue = &v
+		case bool:
+			value.BoolValue = &v
+		case int64:
+			s := strconv.FormatInt(v, 10)
+			value.IntValue = &s
+		case float64:
+			value.DoubleValue = &v
+		default:
+			s := fmt.Sprint(v)
+			value.StringValue = &s
+		}
+		values = append(values, otlpKeyValue{Key: attribute.Key, Value: value})
+	}
+	return values
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// encodeOTLP renders spans as an OTLP/JSON ExportTraceServiceRequest
+func encodeOTLP(resource []Attribute, spans []SpanData) ([]byte, error) {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		s := otlpSpan{
+			TraceID:           span.SpanContext.TraceID.String(),
+			SpanID:            span.SpanContext.SpanID.String(),
+			TraceState:        span.SpanContext.TraceState,
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: unixNano(span.Start),
+			EndTimeUnixNano:   unixNano(span.End),
+			Attributes:        otlpAttributes(span.Attributes),
+			Status:            otlpStatus{Code: span.StatusCode, Message: span.StatusText},
+		}
+		if span.ParentSpanID.IsValid() {
+			s.ParentSpanID = span.ParentSpanID.String()
+		}
+		for _, event := range span.Events {
+			s.Events = append(s.Events, otlpEvent{
+				TimeUnixNano: unixNano(event.Time),
+				Name:         event.Name,
+				Attributes:   otlpAttributes(event.Attributes),
+			})
+		}
+		encoded = append(encoded, s)
+	}
+	return json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: otlpAttributes(resource)},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: instrumentationScope}, Spans: encoded}},
+	}}})
+}
+
+// otlpExporter posts spans to a collector's OTLP/HTTP traces endpoint
+type otlpExporter struct {
+	url      string
+	headers  map[string]string
+	resource []Attribute
+	client   *http.Client
+}
+
+func newOTLPExporter(endpoint string, headers map[string]string, resource []Attribute) *otlpExporter {
+	url := strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+	// The exporter's own requests are deliberately not traced
+	return &otlpExporter{url: url, headers: headers, resource: resource, client: &http.Client{Timeout: exportTimeout}}
+}
+
+func (e *otlpExporter) exportSpans(ctx context.Context, spans []SpanData) error {
+	body, err := encodeOTLP(e.resource, spans)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("otlp export failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (e *otlpExporter) shutdown(context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// fileExporter appends each batch to a file as one line of OTLP/JSON, the
+// format the collector's file exporter writes
+type fileExporter struct {
+	mu       sync.Mutex
+	file     *os.File
+	resource []Attribute
+}
+
+func newFileExporter(path string, resource []Attribute) (*fileExporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %w", err)
+	}
+	return &fileExporter{file: file, resource: resource}, nil
+}
+
+func (e *fileExporter) exportSpans(_ context.Context, spans []SpanData) error {
+	line, err := encodeOTLP(e.resource, spans)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.file.Write(append(line, '\n'))
+	return err
+}
+
+func (e *fileExporter) shutdown(context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.file.Close()
+}
+
+type batchConfig struct {
+	queueSize     int
+	batchSize     int
+	flushInterval time.Duration
+}
+
+var defaultBatchConfig = batchConfig{queueSize: 4096, batchSize: 512, flushInterval: 5 * time.Second}
+
+// batchProcessor queues ended spans and exports them in batches from one
+// goroutine. Spans are dropped, never blocked on, when the queue is full.
+type batchProcessor struct {
+	exporters []exporter
+	config    batchConfig
+	queue     chan SpanData
+	done      chan struct{}
+	stopped   chan struct{}
+	stopOnce  sync.Once
+	dropped   atomic.Int64
+}
+
+func newBatchProcessor(exporters []exporter, config batchConfig) *batchProcessor {
+	p := &batchProcessor{
+		exporters: exporters,
+		config:    config,
+		queue:     make(chan SpanData, config.queueSize),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *batchProcessor) enqueue(span SpanData) {
+	select {
+	case <-p.done:
+		p.dropped.Add(1)
+		return
+	default:
+	}
+	select {
+	case p.queue <- span:
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+func (p *batchProcessor) run() {
+	defer close(p.stopped)
+	ticker := time.NewTicker(p.config.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, p.config.batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			p.export(batch)
+			batch = make([]SpanData, 0, p.config.batchSize)
+		}
+	}
+	for {
+		select {
+		case span := <-p.queue:
+			batch = append(batch, span)
+			if len(batch) >= p.config.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-p.done:
+			for {
+				select {
+				case span := <-p.queue:
+					batch = append(batch, span)
+					if len(batch) >= p.config.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *batchProcessor) export(batch []SpanData) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	for _, e := range p.exporters {
+		if err := e.exportSpans(ctx, batch); err != nil {
+			log.Printf("⚠️ Error exporting %d spans: %v", len(batch), err)
+		}
+	}
+	if dropped := p.dropped.Swap(0); dropped > 0 {
+		log.Printf("⚠️ Dropped %d spans: trace export queue is full", dropped)
+	}
+}
+
+// shutdown exports the queued spans and closes the exporters
+func (p *batchProcessor) shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() { close(p.done) })
+	select {
+	case <-p.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	var firstErr error
+	for _, e := range p.exporters {
+		if err := e.shutdown(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+
+	// maxTraceStateLength is the longest tracestate W3C requires to be
+	// propagated; longer values are dropped rather than truncated
+	maxTraceStateLength = 512
+)
+
+// ParseTraceparent parses a W3C traceparent header value. Versions after 00
+// are read by their version 00 prefix, as the spec asks.
+func ParseTraceparent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	value = strings.TrimSpace(value)
+	if len(value) < 55 || (len(value) > 55 && value[55] != '-') {
+		return sc, false
+	}
+	version := value[0:2]
+	if !isLowerHex(version) || version == "ff" || (version == "00" && len(value) != 55) {
+		return sc, false
+	}
+	if value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return sc, false
+	}
+
+	traceID, spanID, flags := value[3:35], value[36:52], value[53:55]
+	if !isLowerHex(traceID) || !isLowerHex(spanID) || !isLowerHex(flags) {
+		return sc, false
+	}
+	hex.Decode(sc.TraceID[:], []byte(traceID))
+	hex.Decode(sc.SpanID[:], []byte(spanID))
+	var flagBits [1]byte
+	hex.Decode(flagBits[:], []byte(flags))
+	sc.Sampled = flagBits[0]&0x01 == 0x01
+	sc.Remote = true
+	return sc, sc.IsValid()
+}
+
+// FormatTraceparent renders sc as a version 00 traceparent header value
+func FormatTraceparent(sc SpanContext) string {
+	flags := 0
+	if sc.Sampled {
+		flags = 1
+	}
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, flags)
+}
+
+// Extract reads the trace context of an incoming request
+func Extract(header http.Header) (SpanContext, bool) {
+	sc, ok := ParseTraceparent(header.Get(TraceparentHeader))
+	if !ok {
+		return SpanContext{}, false
+	}
+	if state := strings.Join(header.Values(TracestateHeader), ","); len(state) <= maxTraceStateLength {
+		sc.TraceState = state
+	}
+	return sc, true
+}
+
+// Inject writes the trace context of ctx to the headers of an outgoing request
+func Inject(ctx context.Context, header http.Header) {
+	sc, ok := parentSpanContext(ctx)
+	if !ok {
+		return
+	}
+	header.Set(TraceparentHeader, FormatTraceparent(sc))
+	if sc.TraceState != "" {
+		header.Set(TracestateHeader, sc.TraceState)
+	} else {
+		header.Del(TracestateHeader)
+	}
+}
+
+// Transport propagates the trace context of each request's context to the
+// server it calls
+type Transport struct {
+	// Base makes the requests; nil means http.DefaultTransport
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if _, ok := parentSpanContext(req.Context()); !ok {
+		return base.RoundTrip(req)
+	}
+	// A RoundTripper must not modify the caller's request
+	req = req.Clone(req.Context())
+	Inject(req.Context(), req.Header)
+	return base.RoundTrip(req)
+}
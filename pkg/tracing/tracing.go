@@ -0,0 +1,508 @@
+// Package tracing records spans and exports them to an OpenTelemetry
+// collector over OTLP/HTTP with the JSON encoding, or to a local file.
+//
+// Trace context travels in and out of the process in the W3C traceparent
+// header. IDs are generated and propagated even when no exporter is
+// configured, so a request keeps a single trace ID across services either
+// way; only sampled spans of an initialized tracer are recorded.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceID identifies a trace; the zero value is invalid
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace; the zero value is invalid
+type SpanID [8]byte
+
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// String returns the ID as 32 lowercase hex digits
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// String returns the ID as 16 lowercase hex digits
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// NewTraceID returns a random trace ID
+func NewTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+// NewSpanID returns a random span ID
+func NewSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+// ParseTraceID accepts a trace ID as 32 hex digits or in the dashed UUID
+// form, which trace IDs issued before OpenTelemetry used
+func ParseTraceID(s string) (TraceID, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) == 36 && s[8] == '-' && s[13] == '-' && s[18] == '-' && s[23] == '-' {
+		s = strings.ReplaceAll(s, "-", "")
+	}
+	var id TraceID
+	if len(s) != 32 || !isLowerHex(s) {
+		return id, false
+	}
+	hex.Decode(id[:], []byte(s))
+	return id, id.IsValid()
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// SpanContext is the part of a span that is propagated to other services
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string
+	// Remote is set on span contexts extracted from an incoming request
+	Remote bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Kind is the role of a span in a request, as in the OTLP enum
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+// StatusCode is the outcome of a span, as in the OTLP enum
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Attribute is a span attribute; Value is a string, bool, int64 or float64
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+func String(key, value string) Attribute { return Attribute{Key: key, Value: value} }
+
+func Int(key string, value int) Attribute { return Attribute{Key: key, Value: int64(value)} }
+
+func Int64(key string, value int64) Attribute { return Attribute{Key: key, Value: value} }
+
+func Bool(key string, value bool) Attribute { return Attribute{Key: key, Value: value} }
+
+func Float64(key string, value float64) Attribute { return Attribute{Key: key, Value: value} }
+
+// Event is a timestamped annotation of a span, such as a recorded error
+type Event struct {
+	Name       string
+	Time       time.Time
+	Attributes []Attribute
+}
+
+// SpanData is the immutable record of an ended span handed to exporters
+type SpanData struct {
+	Name         string
+	Kind         Kind
+	SpanContext  SpanContext
+	ParentSpanID SpanID
+	Start        time.Time
+	End          time.Time
+	Attributes   []Attribute
+	Events       []Event
+	StatusCode   StatusCode
+	StatusText   string
+}
+
+// Span is one timed operation. A nil *Span is valid and does nothing, so
+// callers can end spans that were never started.
+type Span struct {
+	tracer       *tracer // nil when the span is not recorded
+	spanContext  SpanContext
+	parentSpanID SpanID
+	kind         Kind
+	start        time.Time
+
+	mu         sync.Mutex
+	name       string
+	attributes []Attribute
+	events     []Event
+	statusCode StatusCode
+	statusText string
+	ended      bool
+}
+
+// SpanContext returns the IDs to propagate for this span
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.spanContext
+}
+
+// IsRecording reports whether the span will be exported when it ends
+func (s *Span) IsRecording() bool {
+	return s != nil && s.tracer != nil
+}
+
+// SetName replaces the name given when the span started, e.g. once the
+// route of a request is known
+func (s *Span) SetName(name string) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.name = name
+	}
+}
+
+// SetAttributes adds attributes, replacing earlier values of the same keys.
+// Changes after End are ignored, here and in the other setters.
+func (s *Span) SetAttributes(attributes ...Attribute) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	for _, attribute := range attributes {
+		replaced := false
+		for i := range s.attributes {
+			if s.attributes[i].Key == attribute.Key {
+				s.attributes[i] = attribute
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			s.attributes = append(s.attributes, attribute)
+		}
+	}
+}
+
+// SetStatus sets the outcome of the span; an error status is not
+// downgraded by a later OK
+func (s *Span) SetStatus(code StatusCode, description string) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended || (s.statusCode == StatusError && code != StatusError) {
+		return
+	}
+	s.statusCode = code
+	if code == StatusError {
+		s.statusText = description
+	}
+}
+
+// RecordError adds an exception event and marks the span failed; a nil
+// error is ignored
+func (s *Span) RecordError(err error) {
+	if err == nil || !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.events = append(s.events, Event{
+		Name: "exception",
+		Time: time.Now(),
+		Attributes: []Attribute{
+			String("exception.type", fmt.Sprintf("%T", err)),
+			String("exception.message", err.Error()),
+		},
+	})
+	s.statusCode = StatusError
+	s.statusText = err.Error()
+}
+
+// End records the span's end time and queues it for export. Only the first
+// call has an effect.
+func (s *Span) End() {
+	if !s.IsRecording() {
+		return
+	}
+	end := time.Now()
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	data := SpanData{
+		Name:         s.name,
+		Kind:         s.kind,
+		SpanContext:  s.spanContext,
+		ParentSpanID: s.parentSpanID,
+		Start:        s.start,
+		End:          end,
+		Attributes:   s.attributes,
+		Events:       s.events,
+		StatusCode:   s.statusCode,
+		StatusText:   s.statusText,
+	}
+	s.mu.Unlock()
+	s.tracer.processor.enqueue(data)
+}
+
+// StartOption configures a span being started
+type StartOption func(*startConfig)
+
+type startConfig struct {
+	kind       Kind
+	attributes []Attribute
+	traceID    TraceID
+}
+
+// WithKind sets the span kind; spans are internal by default
+func WithKind(kind Kind) StartOption {
+	return func(c *startConfig) { c.kind = kind }
+}
+
+// WithAttributes sets attributes known when the span starts
+func WithAttributes(attributes ...Attribute) StartOption {
+	return func(c *startConfig) { c.attributes = append(c.attributes, attributes...) }
+}
+
+// WithTraceID makes a span that has no parent start a trace with the given
+// ID instead of a random one
+func WithTraceID(traceID TraceID) StartOption {
+	return func(c *startConfig) { c.traceID = traceID }
+}
+
+type spanContextKey struct{}
+
+type remoteSpanContextKey struct{}
+
+// ContextWithSpan returns a context in which span is the current span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the current span of ctx, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext returns a context whose spans continue the
+// trace of an incoming request
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteSpanContextKey{}, sc)
+}
+
+// parentSpanContext finds the parent of a new span: the current span of ctx
+// or the remote parent of ctx
+func parentSpanContext(ctx context.Context) (SpanContext, bool) {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.spanContext, true
+	}
+	if ctx != nil {
+		if sc, ok := ctx.Value(remoteSpanContextKey{}).(SpanContext); ok && sc.IsValid() {
+			return sc, true
+		}
+	}
+	return SpanContext{}, false
+}
+
+// Start starts a span as a child of the span found in ctx, or as the root of
+// a new trace. The span is always returned, so its IDs can be propagated
+// even when it is not recorded.
+func Start(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parent, hasParent := parentSpanContext(ctx)
+	return start(ctx, name, parent, hasParent, opts)
+}
+
+// StartChild starts a span only when it would be recorded as part of an
+// existing trace, and otherwise returns ctx and a nil span. It suits
+// operations that are noise on their own, such as single queries issued by
+// background workers.
+func StartChild(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	if active.Load() == nil {
+		return ctx, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parent, hasParent := parentSpanContext(ctx)
+	if !hasParent || !parent.Sampled {
+		return ctx, nil
+	}
+	return start(ctx, name, parent, true, opts)
+}
+
+func start(ctx context.Context, name string, parent SpanContext, hasParent bool, opts []StartOption) (context.Context, *Span) {
+	cfg := startConfig{kind: KindInternal}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	t := active.Load()
+	span := &Span{kind: cfg.kind, start: time.Now(), name: name, attributes: cfg.attributes}
+	span.spanContext.SpanID = NewSpanID()
+	if hasParent {
+		span.spanContext.TraceID = parent.TraceID
+		span.spanContext.Sampled = parent.Sampled
+		span.spanContext.TraceState = parent.TraceState
+		span.parentSpanID = parent.SpanID
+	} else {
+		span.spanContext.TraceID = cfg.traceID
+		if !span.spanContext.TraceID.IsValid() {
+			span.spanContext.TraceID = NewTraceID()
+		}
+		span.spanContext.Sampled = t != nil && t.sample(span.spanContext.TraceID)
+	}
+	if t != nil && span.spanContext.Sampled {
+		span.tracer = t
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+// Config selects where spans are exported
+type Config struct {
+	ServiceName string
+	// OTLPEndpoint is the collector's OTLP/HTTP base URL, such as
+	// http://localhost:4318; /v1/traces is appended unless present
+	OTLPEndpoint string
+	OTLPHeaders  map[string]string
+	// FilePath appends every exported batch to a file as one line of OTLP
+	// JSON, for tests and local runs without a collector
+	FilePath string
+	// SampleRatio is the share of new traces recorded; traces started by a
+	// caller follow the caller's sampled flag
+	SampleRatio float64
+}
+
+type tracer struct {
+	sampleRatio float64
+	processor   *batchProcessor
+}
+
+// sample decides on new traces from the trace ID alone, so every service
+// using the same ratio keeps the same traces
+func (t *tracer) sample(traceID TraceID) bool {
+	switch {
+	case t.sampleRatio >= 1:
+		return true
+	case t.sampleRatio <= 0:
+		return false
+	}
+	return binary.BigEndian.Uint64(traceID[8:])>>1 < uint64(t.sampleRatio*(1<<63))
+}
+
+var active atomic.Pointer[tracer]
+
+// Init starts exporting spans as configured. Without an OTLP endpoint or a
+// file path nothing is recorded, and Init only returns nil.
+func Init(cfg Config) error {
+	var exporters []exporter
+	resource := []Attribute{
+		String("service.name", cfg.ServiceName),
+		String("telemetry.sdk.language", "go"),
+	}
+	if cfg.OTLPEndpoint != "" {
+		exporters = append(exporters, newOTLPExporter(cfg.OTLPEndpoint, cfg.OTLPHeaders, resource))
+	}
+	if cfg.FilePath != "" {
+		fileExporter, err := newFileExporter(cfg.FilePath, resource)
+		if err != nil {
+			return err
+		}
+		exporters = append(exporters, fileExporter)
+	}
+	if len(exporters) == 0 {
+		return nil
+	}
+
+	t := &tracer{sampleRatio: cfg.SampleRatio, processor: newBatchProcessor(exporters, defaultBatchConfig)}
+	if previous := active.Swap(t); previous != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := previous.processor.shutdown(ctx); err != nil {
+			log.Printf("⚠️ Error shutting down previous tracer: %v", err)
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether spans are being recorded
+func Enabled() bool {
+	return active.Load() != nil
+}
+
+// Shutdown exports the queued spans and stops recording
+func Shutdown(ctx context.Context) error {
+	t := active.Swap(nil)
+	if t == nil {
+		return nil
+	}
+	return t.processor.shutdown(ctx)
+}
+
+// ParseHeaders parses OTLP headers given as comma-separated key=value pairs
+// with URL-encoded values, as in OTEL_EXPORTER_OTLP_HEADERS
+func ParseHeaders(s string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		if decoded, err := url.PathUnescape(strings.TrimSpace(value)); err == nil {
+			value = decoded
+		}
+		headers[key] = strings.TrimSpace(value)
+	}
+	return headers
+}
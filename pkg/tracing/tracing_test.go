@@ -0,0 +1,180 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok {
+		t.Fatal("valid traceparent rejected")
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" {
+		t.Fatalf("parsed IDs %s/%s", sc.TraceID, sc.SpanID)
+	}
+	if !sc.Sampled || !sc.Remote {
+		t.Fatalf("sampled=%v remote=%v, want both set", sc.Sampled, sc.Remote)
+	}
+	if got := FormatTraceparent(sc); got != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Fatalf("FormatTraceparent = %q", got)
+	}
+
+	if sc, ok := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future"); !ok || sc.Sampled {
+		t.Fatalf("future version: ok=%v sampled=%v, want ok and unsampled", ok, sc.Sampled)
+	}
+
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		if _, ok := ParseTraceparent(value); ok {
+			t.Errorf("ParseTraceparent(%q) accepted an invalid value", value)
+		}
+	}
+}
+
+func TestParseTraceID(t *testing.T) {
+	cases := map[string]string{
+		"4bf92f3577b34da6a3ce929d0e0e4736":     "4bf92f3577b34da6a3ce929d0e0e4736",
+		"4BF92F3577B34DA6A3CE929D0E0E4736":     "4bf92f3577b34da6a3ce929d0e0e4736",
+		"4bf92f35-77b3-4da6-a3ce-929d0e0e4736": "4bf92f3577b34da6a3ce929d0e0e4736",
+	}
+	for input, want := range cases {
+		id, ok := ParseTraceID(input)
+		if !ok || id.String() != want {
+			t.Errorf("ParseTraceID(%q) = %s, %v; want %s", input, id, ok, want)
+		}
+	}
+	for _, input := range []string{"", "lq3k-abc123", "00000000-0000-0000-0000-000000000000", "4bf92f3577b34da6a3ce929d0e0e47"} {
+		if _, ok := ParseTraceID(input); ok {
+			t.Errorf("ParseTraceID(%q) accepted an invalid ID", input)
+		}
+	}
+}
+
+func TestInjectAndExtract(t *testing.T) {
+	parent := SpanContext{TraceID: NewTraceID(), SpanID: NewSpanID(), Sampled: true, TraceState: "vendor=1"}
+	ctx, span := Start(ContextWithRemoteSpanContext(context.Background(), parent), "child")
+
+	header := http.Header{}
+	Inject(ctx, header)
+	extracted, ok := Extract(header)
+	if !ok {
+		t.Fatalf("Extract failed on %q", header.Get(TraceparentHeader))
+	}
+	if extracted.TraceID != parent.TraceID || extracted.SpanID != span.SpanContext().SpanID {
+		t.Fatalf("extracted %s/%s, want trace %s and the child's span", extracted.TraceID, extracted.SpanID, parent.TraceID)
+	}
+	if !extracted.Sampled || extracted.TraceState != "vendor=1" {
+		t.Fatalf("sampled=%v tracestate=%q", extracted.Sampled, extracted.TraceState)
+	}
+}
+
+func TestSample(t *testing.T) {
+	low := TraceID{8: 0x00, 15: 0x01}
+	high := TraceID{8: 0xff, 9: 0xff, 10: 0xff, 11: 0xff, 12: 0xff, 13: 0xff, 14: 0xff, 15: 0xff}
+	half := &tracer{sampleRatio: 0.5}
+	if !half.sample(low) || half.sample(high) {
+		t.Fatal("ratio 0.5 should keep low trace IDs and drop high ones")
+	}
+	if !(&tracer{sampleRatio: 1}).sample(high) || (&tracer{sampleRatio: 0}).sample(low) {
+		t.Fatal("ratios 1 and 0 should keep and drop every trace")
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	if err := Init(Config{ServiceName: "test-service", FilePath: path, SampleRatio: 1}); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	traceID := NewTraceID()
+	ctx, root := Start(context.Background(), "GET /api/tasks", WithKind(KindServer), WithTraceID(traceID))
+	_, query := StartChild(ctx, "SELECT", WithKind(KindClient), WithAttributes(Int("rows", 3)))
+	query.RecordError(errors.New("boom"))
+	query.End()
+	if _, orphan := StartChild(context.Background(), "SELECT"); orphan != nil {
+		t.Fatal("StartChild without a parent should not start a span")
+	}
+	_, ai := Start(ctx, "chat.completions", WithKind(KindClient))
+	ai.End()
+	root.SetAttributes(String("http.route", "/api/tasks"), Int("http.response.status_code", 200))
+	root.End()
+
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	spans := make(map[string]otlpSpan)
+	var service string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var request otlpRequest
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			t.Fatalf("line is not OTLP JSON: %v", err)
+		}
+		for _, resourceSpans := range request.ResourceSpans {
+			service = *resourceSpans.Resource.Attributes[0].Value.StringValue
+			for _, span := range resourceSpans.ScopeSpans[0].Spans {
+				spans[span.Name] = span
+			}
+		}
+	}
+
+	if service != "test-service" {
+		t.Fatalf("service.name = %q", service)
+	}
+	if len(spans) != 3 {
+		t.Fatalf("exported %d spans, want 3: %v", len(spans), spans)
+	}
+	server, selectSpan, chat := spans["GET /api/tasks"], spans["SELECT"], spans["chat.completions"]
+	if server.TraceID != traceID.String() || server.Kind != KindServer || server.ParentSpanID != "" {
+		t.Fatalf("root span %+v", server)
+	}
+	if selectSpan.TraceID != traceID.String() || selectSpan.ParentSpanID != server.SpanID {
+		t.Fatalf("bound child span %+v is not under the root", selectSpan)
+	}
+	if selectSpan.Status.Code != StatusError || selectSpan.Status.Message != "boom" || len(selectSpan.Events) != 1 {
+		t.Fatalf("child status %+v events %v", selectSpan.Status, selectSpan.Events)
+	}
+	if *selectSpan.Attributes[0].Value.IntValue != "3" {
+		t.Fatalf("int attribute encoded as %+v", selectSpan.Attributes[0].Value)
+	}
+	if chat.ParentSpanID != server.SpanID {
+		t.Fatalf("context child span %+v is not under the root", chat)
+	}
+}
+
+func TestUnrecordedSpansPropagate(t *testing.T) {
+	ctx, span := Start(context.Background(), "GET /health")
+	if span.IsRecording() || !span.SpanContext().IsValid() {
+		t.Fatalf("without a tracer the span should carry IDs but not record: %+v", span.SpanContext())
+	}
+	header := http.Header{}
+	Inject(ctx, header)
+	if sc, ok := Extract(header); !ok || sc.Sampled {
+		t.Fatalf("injected %q, want an unsampled traceparent", header.Get(TraceparentHeader))
+	}
+
+	var nilSpan *Span
+	nilSpan.SetAttributes(String("k", "v"))
+	nilSpan.RecordError(errors.New("ignored"))
+	nilSpan.End()
+}
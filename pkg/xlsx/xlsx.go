@@ -0,0 +1,279 @@
+// Package xlsx writes Office Open XML spreadsheets as a stream.
+//
+// Rows are encoded straight into the zip entry of the current worksheet, so
+// memory use does not grow with the number of rows. Cells are written as
+// inline strings or numbers, without shared strings or styles. A worksheet
+// that reaches Excel's row limit is continued on a new sheet that repeats the
+// header.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxSheetRows is the number of rows Excel allows in one worksheet
+	MaxSheetRows = 1048576
+	// MaxCellChars is the number of characters Excel allows in one cell
+	MaxCellChars = 32767
+)
+
+var ErrClosed = errors.New("xlsx: writer is closed")
+
+// Writer streams rows into a workbook
+type Writer struct {
+	zip        *zip.Writer
+	sheet      *bufio.Writer
+	sheetName  string
+	sheets     int
+	rows       int
+	maxRows    int
+	header     []interface{}
+	closed     bool
+	cellBuffer []byte
+}
+
+// NewWriter starts a workbook on w; sheets are named sheetName, sheetName 2,
+// and so on
+func NewWriter(w io.Writer, sheetName string) *Writer {
+	if sheetName == "" {
+		sheetName = "Sheet"
+	}
+	return &Writer{zip: zip.NewWriter(w), sheetName: sheetName, maxRows: MaxSheetRows}
+}
+
+// SetHeader writes the header row and repeats it at the top of every later sheet
+func (w *Writer) SetHeader(values ...interface{}) error {
+	w.header = values
+	return w.WriteRow(values...)
+}
+
+// WriteRow appends a row. Integers, floats and bools become numeric cells;
+// everything else is written as text.
+func (w *Writer) WriteRow(values ...interface{}) error {
+	if w.closed {
+		return ErrClosed
+	}
+	if w.sheet == nil || w.rows >= w.maxRows {
+		if err := w.nextSheet(); err != nil {
+			return err
+		}
+	}
+	return w.writeRow(values)
+}
+
+func (w *Writer) writeRow(values []interface{}) error {
+	w.rows++
+	row := strconv.Itoa(w.rows)
+	buf := w.cellBuffer[:0]
+	buf = append(buf, `<row r="`...)
+	buf = append(buf, row...)
+	buf = append(buf, `">`...)
+	for i, value := range values {
+		buf = appendCell(buf, ColumnName(i)+row, value)
+	}
+	buf = append(buf, "</row>"...)
+	w.cellBuffer = buf
+	_, err := w.sheet.Write(buf)
+	return err
+}
+
+func (w *Writer) nextSheet() error {
+	if err := w.finishSheet(); err != nil {
+		return err
+	}
+	w.sheets++
+	entry, err := w.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", w.sheets))
+	if err != nil {
+		return err
+	}
+	w.sheet = bufio.NewWriterSize(entry, 64*1024)
+	w.rows = 0
+	if _, err := w.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return err
+	}
+	if w.sheets > 1 && len(w.header) > 0 {
+		return w.writeRow(w.header)
+	}
+	return nil
+}
+
+func (w *Writer) finishSheet() error {
+	if w.sheet == nil {
+		return nil
+	}
+	if _, err := w.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return w.sheet.Flush()
+}
+
+// Close finishes the workbook. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if w.sheet == nil {
+		if err := w.nextSheet(); err != nil {
+			return err
+		}
+	}
+	if err := w.finishSheet(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	parts := map[string]string{
+		"[Content_Types].xml":        w.contentTypes(),
+		"_rels/.rels":                rootRels,
+		"xl/workbook.xml":            w.workbook(),
+		"xl/_rels/workbook.xml.rels": w.workbookRels(),
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		entry, err := w.zip.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(entry, parts[name]); err != nil {
+			return err
+		}
+	}
+	return w.zip.Close()
+}
+
+// Sheets returns the number of worksheets started so far
+func (w *Writer) Sheets() int {
+	return w.sheets
+}
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+func (w *Writer) contentTypes() string {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	for i := 1; i <= w.sheets; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func (w *Writer) workbook() string {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i := 1; i <= w.sheets; i++ {
+		name := w.sheetName
+		if i > 1 {
+			name = fmt.Sprintf("%s %d", w.sheetName, i)
+		}
+		b.WriteString(`<sheet name="`)
+		xml.EscapeText(&b, []byte(name))
+		fmt.Fprintf(&b, `" sheetId="%d" r:id="rId%d"/>`, i, i)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	return b.String()
+}
+
+func (w *Writer) workbookRels() string {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= w.sheets; i++ {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+// ColumnName returns the column letters of a zero-based column index
+func ColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+func appendCell(buf []byte, ref string, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return buf
+	case int:
+		return appendNumber(buf, ref, strconv.Itoa(v))
+	case int64:
+		return appendNumber(buf, ref, strconv.FormatInt(v, 10))
+	case float64:
+		return appendNumber(buf, ref, strconv.FormatFloat(v, 'f', -1, 64))
+	case bool:
+		if v {
+			return appendNumber(buf, ref, "1")
+		}
+		return appendNumber(buf, ref, "0")
+	case string:
+		return appendString(buf, ref, v)
+	case time.Time:
+		return appendString(buf, ref, v.Format(time.RFC3339))
+	default:
+		return appendString(buf, ref, fmt.Sprint(v))
+	}
+}
+
+func appendNumber(buf []byte, ref, number string) []byte {
+	buf = append(buf, `<c r="`...)
+	buf = append(buf, ref...)
+	buf = append(buf, `"><v>`...)
+	buf = append(buf, number...)
+	return append(buf, `</v></c>`...)
+}
+
+func appendString(buf []byte, ref, text string) []byte {
+	if text == "" {
+		return buf
+	}
+	if utf8.RuneCountInString(text) > MaxCellChars {
+		text = string([]rune(text)[:MaxCellChars])
+	}
+	buf = append(buf, `<c r="`...)
+	buf = append(buf, ref...)
+	buf = append(buf, `" t="inlineStr"><is><t xml:space="preserve">`...)
+	buf = appendEscaped(buf, text)
+	return append(buf, `</t></is></c>`...)
+}
+
+// appendEscaped escapes text for XML, replacing characters XML 1.0 cannot
+// represent
+func appendEscaped(buf []byte, text string) []byte {
+	for _, r := range text {
+		switch {
+		case r == '<':
+			buf = append(buf, "&lt;"...)
+		case r == '>':
+			buf = append(buf, "&gt;"...)
+		case r == '&':
+			buf = append(buf, "&amp;"...)
+		case r == '"':
+			buf = append(buf, "&quot;"...)
+		case r == '\t' || r == '\n' || r == '\r':
+			buf = utf8.AppendRune(buf, r)
+		case r < 0x20 || r == 0xFFFE || r == 0xFFFF || (r >= 0xD800 && r <= 0xDFFF) || r == utf8.RuneError:
+			buf = utf8.AppendRune(buf, '�')
+		default:
+			buf = utf8.AppendRune(buf, r)
+		}
+	}
+	return buf
+}
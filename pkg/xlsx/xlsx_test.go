@@ -0,0 +1,102 @@
+package xlsx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestColumnName(t *testing.T) {
+	cases := map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"}
+	for index, want := range cases {
+		if got := ColumnName(index); got != want {
+			t.Fatalf("ColumnName(%d) = %q, want %q", index, got, want)
+		}
+	}
+}
+
+func TestWriterOutputOpensInExcelize(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, "Audit")
+	if err := w.SetHeader("id", "name", "count"); err != nil {
+		t.Fatalf("SetHeader: %v", err)
+	}
+	if err := w.WriteRow("1", `<a & "b">`, 42); err != nil {
+		t.Fatalf("WriteRow: %v", err)
+	}
+	if err := w.WriteRow("2", "bad\x01char", 1.5); err != nil {
+		t.Fatalf("WriteRow: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	file, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatalf("OpenReader: %v", err)
+	}
+	defer file.Close()
+	rows, err := file.GetRows("Audit")
+	if err != nil {
+		t.Fatalf("GetRows: %v", err)
+	}
+	want := [][]string{{"id", "name", "count"}, {"1", `<a & "b">`, "42"}, {"2", "bad�char", "1.5"}}
+	if len(rows) != len(want) {
+		t.Fatalf("rows = %v, want %v", rows, want)
+	}
+	for i := range want {
+		if strings.Join(rows[i], "|") != strings.Join(want[i], "|") {
+			t.Fatalf("row %d = %v, want %v", i, rows[i], want[i])
+		}
+	}
+}
+
+func TestWriterRollsOverToNewSheet(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, "Audit")
+	w.maxRows = 3
+	if err := w.SetHeader("n"); err != nil {
+		t.Fatalf("SetHeader: %v", err)
+	}
+	for i := 1; i <= 4; i++ {
+		if err := w.WriteRow(i); err != nil {
+			t.Fatalf("WriteRow: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if w.Sheets() != 2 {
+		t.Fatalf("Sheets = %d, want 2", w.Sheets())
+	}
+
+	file, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatalf("OpenReader: %v", err)
+	}
+	defer file.Close()
+	second, err := file.GetRows("Audit 2")
+	if err != nil {
+		t.Fatalf("GetRows: %v", err)
+	}
+	if len(second) != 3 || second[0][0] != "n" || second[1][0] != "3" || second[2][0] != "4" {
+		t.Fatalf("second sheet = %v, want header then 3 and 4", second)
+	}
+}
+
+func TestEmptyWorkbookIsValid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewWriter(&buf, "Empty").Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	file, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatalf("OpenReader: %v", err)
+	}
+	defer file.Close()
+	if sheets := file.GetSheetList(); len(sheets) != 1 || sheets[0] != "Empty" {
+		t.Fatalf("sheets = %v", sheets)
+	}
+}